@@ -17,13 +17,21 @@ package v1
 
 import (
 	"fmt"
+	"net"
+	"regexp"
+	"strings"
 
+	core_v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // PgclusterResourcePlural ..
 const PgclusterResourcePlural = "pgclusters"
 
+// PgclusterFinalizer is the finalizer that keeps a pgcluster from being
+// deleted until all of the resources of the cluster have been removed
+const PgclusterFinalizer = "crunchydata.com/pgcluster-cleanup"
+
 // Pgcluster is the CRD that defines a Crunchy PG Cluster
 //
 // swagger:ignore Pgcluster
@@ -38,45 +46,95 @@ type Pgcluster struct {
 // PgclusterSpec is the CRD that defines a Crunchy PG Cluster Spec
 // swagger:ignore
 type PgclusterSpec struct {
-	Namespace          string                   `json:"namespace"`
-	Name               string                   `json:"name"`
-	ClusterName        string                   `json:"clustername"`
-	Policies           string                   `json:"policies"`
-	CCPImage           string                   `json:"ccpimage"`
-	CCPImageTag        string                   `json:"ccpimagetag"`
-	Port               string                   `json:"port"`
-	PGBadgerPort       string                   `json:"pgbadgerport"`
-	ExporterPort       string                   `json:"exporterport"`
-	NodeName           string                   `json:"nodename"`
-	PrimaryStorage     PgStorageSpec            `json:primarystorage`
-	ArchiveStorage     PgStorageSpec            `json:archivestorage`
-	ReplicaStorage     PgStorageSpec            `json:replicastorage`
-	BackrestStorage    PgStorageSpec            `json:backreststorage`
-	ContainerResources PgContainerResources     `json:containerresources`
-	PrimaryHost        string                   `json:"primaryhost"`
-	User               string                   `json:"user"`
-	Database           string                   `json:"database"`
-	Replicas           string                   `json:"replicas"`
-	SecretFrom         string                   `json:"secretfrom"`
-	UserSecretName     string                   `json:"usersecretname"`
-	RootSecretName     string                   `json:"rootsecretname"`
-	PrimarySecretName  string                   `json:"primarysecretname"`
-	CollectSecretName  string                   `json:"collectSecretName"`
-	Status             string                   `json:"status"`
-	PswLastUpdate      string                   `json:"pswlastupdate"`
-	CustomConfig       string                   `json:"customconfig"`
-	UserLabels         map[string]string        `json:"userlabels"`
-	PodAntiAffinity    PodAntiAffinitySpec      `json:"podPodAntiAffinity"`
-	SyncReplication    *bool                    `json:"syncReplication"`
-	BackrestS3Bucket   string                   `json:"backrestS3Bucket"`
-	BackrestS3Region   string                   `json:"backrestS3Region"`
-	BackrestS3Endpoint string                   `json:"backrestS3Endpoint"`
-	BackrestRepoPath   string                   `json:"backrestRepoPath"`
-	TablespaceMounts   map[string]PgStorageSpec `json:"tablespaceMounts"`
-	TLS                TLSSpec                  `json:"tls"`
-	TLSOnly            bool                     `json:"tlsOnly"`
-	Standby            bool                     `json:"standby"`
-	Shutdown           bool                     `json:"shutdown"`
+	Namespace                string                   `json:"namespace"`
+	Name                     string                   `json:"name"`
+	ClusterName              string                   `json:"clustername"`
+	Policies                 string                   `json:"policies"`
+	CCPImage                 string                   `json:"ccpimage"`
+	CCPImageTag              string                   `json:"ccpimagetag"`
+	Port                     string                   `json:"port"`
+	PGBadgerPort             string                   `json:"pgbadgerport"`
+	ExporterPort             string                   `json:"exporterport"`
+	NodeName                 string                   `json:"nodename"`
+	PrimaryStorage           PgStorageSpec            `json:primarystorage`
+	ArchiveStorage           PgStorageSpec            `json:archivestorage`
+	ReplicaStorage           PgStorageSpec            `json:replicastorage`
+	BackrestStorage          PgStorageSpec            `json:backreststorage`
+	ContainerResources       PgContainerResources     `json:containerresources`
+	PrimaryHost              string                   `json:"primaryhost"`
+	User                     string                   `json:"user"`
+	Database                 string                   `json:"database"`
+	Replicas                 string                   `json:"replicas"`
+	SecretFrom               string                   `json:"secretfrom"`
+	UserSecretName           string                   `json:"usersecretname"`
+	RootSecretName           string                   `json:"rootsecretname"`
+	PrimarySecretName        string                   `json:"primarysecretname"`
+	CollectSecretName        string                   `json:"collectSecretName"`
+	Status                   string                   `json:"status"`
+	PswLastUpdate            string                   `json:"pswlastupdate"`
+	CustomConfig             string                   `json:"customconfig"`
+	UserLabels               map[string]string        `json:"userlabels"`
+	PodAntiAffinity          PodAntiAffinitySpec      `json:"podPodAntiAffinity"`
+	SyncReplication          *bool                    `json:"syncReplication"`
+	BackrestS3Bucket         string                   `json:"backrestS3Bucket"`
+	BackrestS3Region         string                   `json:"backrestS3Region"`
+	BackrestS3Endpoint       string                   `json:"backrestS3Endpoint"`
+	BackrestGCSBucket        string                   `json:"backrestGCSBucket"`
+	BackrestGCSEndpoint      string                   `json:"backrestGCSEndpoint"`
+	BackrestGCSKeyType       string                   `json:"backrestGCSKeyType"`
+	BackrestAzureContainer   string                   `json:"backrestAzureContainer"`
+	BackrestAzureEndpoint    string                   `json:"backrestAzureEndpoint"`
+	BackrestAzureURIStyle    string                   `json:"backrestAzureURIStyle"`
+	BackrestStorageVerifyTLS string                   `json:"backrestStorageVerifyTLS"`
+	BackrestCipherType       string                   `json:"backrestCipherType"`
+	BackrestRepoPath         string                   `json:"backrestRepoPath"`
+	TablespaceMounts         map[string]PgStorageSpec `json:"tablespaceMounts"`
+	TLS                      TLSSpec                  `json:"tls"`
+	TLSOnly                  bool                     `json:"tlsOnly"`
+	Standby                  bool                     `json:"standby"`
+	Shutdown                 bool                     `json:"shutdown"`
+	// DeletionProtection, if set, prevents the cluster from being deleted by
+	// "pgo delete cluster", "pgo delete namespace" or a pgo-rmdata task until it
+	// is disabled
+	DeletionProtection bool `json:"deletionProtection"`
+	// ExpirationTime, if set, is the time after which the Operator deletes the
+	// cluster, honoring the "keep-data" and "keep-backups" annotations
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+	// Tolerations are added to the pods of the PostgreSQL instances, pgBouncer
+	// and the pgBackRest repository, e.g. to schedule them onto tainted nodes.
+	// If not set, the tolerations in pgo.yaml are used
+	Tolerations []core_v1.Toleration `json:"tolerations,omitempty"`
+	// PriorityClassName is the priority class of the pods of the cluster. If
+	// not set, the priority class in pgo.yaml is used
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// ZoneSpread determines how the PostgreSQL instances of the cluster are
+	// spread across zones. If not set, the value in pgo.yaml is used
+	ZoneSpread ZoneSpreadType `json:"zoneSpread,omitempty"`
+	// Metadata holds custom annotations and labels for the objects of the
+	// cluster, e.g. to configure a cloud load balancer
+	Metadata ClusterMetadataSpec `json:"metadata,omitempty"`
+	// ReplicaServiceType is the type of the replica Service. If not set, the
+	// replica Service has the same type as the primary Service
+	ReplicaServiceType string `json:"replicaServiceType,omitempty"`
+	// LoadBalancerSourceRanges restricts the clients of the primary and
+	// replica Services of type LoadBalancer to these CIDRs
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+	// HBA are the pg_hba.conf rules of the cluster, in order. They follow the
+	// rules that the Operator needs for replication, monitoring and pgBouncer.
+	// If not set, the rules in pgo.yaml are used
+	HBA []HBARule `json:"hba,omitempty"`
+	// NetworkPolicy, if enabled, restricts the clients of the PostgreSQL
+	// instances, pgBouncer and the pgBackRest repository with NetworkPolicies
+	NetworkPolicy NetworkPolicySpec `json:"networkPolicy,omitempty"`
+	// PgBouncer holds the settings of the pgBouncer connection poolers of the
+	// cluster, which is enabled by the "crunchy-pgbouncer" label
+	PgBouncer PgBouncerSpec `json:"pgBouncer,omitempty"`
+	// Publications are the publications of the databases of the cluster that
+	// other clusters subscribe to with logical replication
+	Publications []PublicationSpec `json:"publications,omitempty"`
+	// Subscriptions are the subscriptions of the cluster to the publications of
+	// other clusters
+	Subscriptions []SubscriptionSpec `json:"subscriptions,omitempty"`
 }
 
 // PgclusterList is the CRD that defines a Crunchy PG Cluster List
@@ -94,6 +152,140 @@ type PgclusterList struct {
 type PgclusterStatus struct {
 	State   PgclusterState `json:"state,omitempty"`
 	Message string         `json:"message,omitempty"`
+	// Snapshots is the catalog of the snapshot backups that have been taken of
+	// the cluster, from oldest to newest
+	Snapshots []PgclusterSnapshot `json:"snapshots,omitempty"`
+	// ObservedGeneration is the generation of the pgcluster that the observed
+	// state below was recorded for. As the pgcluster does not have a status
+	// subresource, writing the status also increments the generation, which
+	// is accounted for when this is set
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions are the latest observations of the state of the cluster
+	Conditions []PgclusterCondition `json:"conditions,omitempty"`
+	// Primary is the name of the instance that is currently the primary
+	Primary string `json:"primary,omitempty"`
+	// Replicas is the number of replica instances that are running, and
+	// ReadyReplicas is how many of them are ready
+	Replicas      int `json:"replicas,omitempty"`
+	ReadyReplicas int `json:"readyReplicas,omitempty"`
+	// LastBackupTime is when the last successful pgBackRest backup of the
+	// cluster completed
+	LastBackupTime *metav1.Time `json:"lastBackupTime,omitempty"`
+	// PgBouncerReady is true when the cluster has a pgBouncer Deployment with
+	// all of its Pods ready, as well as its read-only pgBouncer if it has one
+	PgBouncerReady bool `json:"pgBouncerReady,omitempty"`
+	// PostgresVersion is the PostgreSQL version that the primary is running
+	PostgresVersion string `json:"postgresVersion,omitempty"`
+	// Subscriptions are the last observed state of the subscriptions of the
+	// cluster
+	Subscriptions []SubscriptionStatus `json:"subscriptions,omitempty"`
+	// Hibernate is the state of the hibernate schedule of the cluster, if it
+	// has one. It is kept up-to-date by pgo-scheduler
+	Hibernate *PgclusterHibernateStatus `json:"hibernate,omitempty"`
+}
+
+// PgclusterHibernateStatus is the state of the hibernate schedule of a cluster
+// swagger:ignore
+type PgclusterHibernateStatus struct {
+	// NextStop and NextStart are when the cluster is next shut down and
+	// started again
+	NextStop  *metav1.Time `json:"nextStop,omitempty"`
+	NextStart *metav1.Time `json:"nextStart,omitempty"`
+	// Message explains why the last shutdown was skipped, if it was
+	Message string `json:"message,omitempty"`
+}
+
+// PgclusterConditionType is the type of a condition of a pgcluster
+// swagger:ignore
+type PgclusterConditionType string
+
+// PgclusterCondition is an observation of one aspect of the state of a
+// pgcluster, modeled after the conditions of the built-in resources
+// swagger:ignore
+type PgclusterCondition struct {
+	Type   PgclusterConditionType `json:"type"`
+	Status metav1.ConditionStatus `json:"status"`
+	// LastTransitionTime is when the status of the condition last changed
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+	// Reason is a single CamelCase word explaining the status, and Message a
+	// human readable description of it
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	// PgclusterConditionReady is true when the cluster is initialized, its
+	// primary and all of its replicas are ready and it is neither being
+	// upgraded nor restored
+	PgclusterConditionReady PgclusterConditionType = "Ready"
+	// PgclusterConditionPrimaryAvailable is true when the primary is ready
+	PgclusterConditionPrimaryAvailable PgclusterConditionType = "PrimaryAvailable"
+	// PgclusterConditionReplicasReady is true when all of the replicas are ready
+	PgclusterConditionReplicasReady PgclusterConditionType = "ReplicasReady"
+	// PgclusterConditionBackupsHealthy is true when the last backup succeeded
+	// and completed within the recovery point objective
+	PgclusterConditionBackupsHealthy PgclusterConditionType = "BackupsHealthy"
+	// PgclusterConditionUpgrading is true while a minor or major upgrade of
+	// the cluster is in progress
+	PgclusterConditionUpgrading PgclusterConditionType = "Upgrading"
+	// PgclusterConditionRestoring is true while the cluster is being restored
+	PgclusterConditionRestoring PgclusterConditionType = "Restoring"
+)
+
+// GetCondition returns the condition of the type provided, or nil if the
+// status does not have one
+func (s *PgclusterStatus) GetCondition(conditionType PgclusterConditionType) *PgclusterCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == conditionType {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition adds or replaces the condition of the same type. The
+// transition time of an existing condition is kept if its status does not
+// change
+func (s *PgclusterStatus) SetCondition(condition PgclusterCondition) {
+	existing := s.GetCondition(condition.Type)
+	if existing == nil {
+		s.Conditions = append(s.Conditions, condition)
+		return
+	}
+
+	if existing.Status == condition.Status {
+		condition.LastTransitionTime = existing.LastTransitionTime
+	}
+	*existing = condition
+}
+
+// PgclusterSnapshot describes a snapshot backup of a cluster, i.e. a set of
+// VolumeSnapshots of the PGDATA and tablespace PVCs of the primary that were
+// taken between a pg_start_backup and a pg_stop_backup
+// swagger:ignore
+type PgclusterSnapshot struct {
+	// Name is the name of the snapshot backup, which is also used as the prefix
+	// of the names of its VolumeSnapshots
+	Name string `json:"name"`
+	// CreationTime is when the snapshot backup completed
+	CreationTime metav1.Time `json:"creationTime"`
+	// PGDataDirectory is the name of the directory in the PGDATA volume that
+	// holds the data directory, i.e. the name of the instance that the
+	// snapshot was taken from
+	PGDataDirectory string `json:"pgDataDirectory"`
+	// PGDataSnapshot is the name of the VolumeSnapshot of the PGDATA PVC
+	PGDataSnapshot string `json:"pgDataSnapshot"`
+	// TablespaceSnapshots maps the name of each tablespace to the name of the
+	// VolumeSnapshot of its PVC
+	TablespaceSnapshots map[string]string `json:"tablespaceSnapshots,omitempty"`
+	// StartLSN and StopLSN are the WAL locations returned by pg_start_backup
+	// and pg_stop_backup. All of the WAL up to StopLSN needs to be replayed
+	// from the pgBackRest archive to make the snapshot consistent
+	StartLSN string `json:"startLSN"`
+	StopLSN  string `json:"stopLSN"`
+	// VolumeSnapshotClass is the class that the VolumeSnapshots were taken
+	// with. An empty value means the default class was used
+	VolumeSnapshotClass string `json:"volumeSnapshotClass,omitempty"`
 }
 
 // PgclusterState is the crd that defines PG Cluster Stage
@@ -126,6 +318,56 @@ type PodAntiAffinitySpec struct {
 	PgBouncer  PodAntiAffinityType `json:"pgBouncer"`
 }
 
+// MetadataSpec holds custom annotations and labels that are added to an object
+type MetadataSpec struct {
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+// ClusterMetadataSpec holds the custom annotations and labels of the objects of
+// a cluster. They are added to the objects in addition to the ones the Operator
+// sets, and cannot replace those.
+// - "Pod" applies to the Pods of the PostgreSQL instances
+// - "PrimaryService" and "ReplicaService" apply to the respective Services
+// - "PgBouncer" applies to the pgBouncer Pods and Service
+// - "PgBackRest" applies to the pgBackRest repository Pod and Service
+// swagger:ignore
+type ClusterMetadataSpec struct {
+	Pod            MetadataSpec `json:"pod,omitempty"`
+	PrimaryService MetadataSpec `json:"primaryService,omitempty"`
+	ReplicaService MetadataSpec `json:"replicaService,omitempty"`
+	PgBouncer      MetadataSpec `json:"pgBouncer,omitempty"`
+	PgBackRest     MetadataSpec `json:"pgBackRest,omitempty"`
+}
+
+// ZoneSpreadType defines how the PostgreSQL instances of a cluster are spread
+// across the zones of the Kubernetes cluster, using a topology spread
+// constraint on "topology.kubernetes.io/zone". Valid values are "required",
+// which does not schedule an instance into a zone that would make the spread
+// uneven, "preferred", which spreads the instances if possible, and "disabled"
+type ZoneSpreadType string
+
+const (
+	// ZoneSpreadRequired results in a topology spread constraint that is
+	// "DoNotSchedule" when unsatisfiable
+	ZoneSpreadRequired ZoneSpreadType = "required"
+	// ZoneSpreadPreferred results in a topology spread constraint that is
+	// "ScheduleAnyway" when unsatisfiable
+	ZoneSpreadPreferred ZoneSpreadType = "preferred"
+	// ZoneSpreadDisabled does not spread the instances across zones
+	ZoneSpreadDisabled ZoneSpreadType = "disabled"
+)
+
+// Validate returns an error if the type of zone spread is not valid
+func (z ZoneSpreadType) Validate() error {
+	switch z {
+	case ZoneSpreadRequired, ZoneSpreadPreferred, ZoneSpreadDisabled, "":
+		return nil
+	}
+	return fmt.Errorf("invalid zone spread %q, valid values are %q, %q or %q",
+		z, ZoneSpreadRequired, ZoneSpreadPreferred, ZoneSpreadDisabled)
+}
+
 // TLSSpec contains the information to set up a TLS-enabled PostgreSQL cluster
 type TLSSpec struct {
 	// CASecret contains the name of the secret to use as the trusted CA for the
@@ -138,6 +380,279 @@ type TLSSpec struct {
 	// This follows the Kubernetes secret format ("kubernetes.io/tls") which has
 	// two keys: tls.crt and tls.key
 	TLSSecret string `json:"tlsSecret"`
+	// Auto, if set, has the Operator issue the certificate of the cluster from
+	// a CA that it maintains in the namespace, and renew it ahead of its
+	// expiration. The certificate and the CA are stored in the Secrets named
+	// by TLSSecret and CASecret
+	Auto bool `json:"auto,omitempty"`
+	// CertAuth, if set, has the PostgreSQL users of the cluster, including the
+	// replication user, authenticate with a client certificate instead of a
+	// password. The pgBouncer and monitoring users still use passwords. The
+	// client certificates are issued by the Operator, so this requires Auto
+	CertAuth bool `json:"certAuth,omitempty"`
+	// CertAuthUsers are PostgreSQL users that authenticate with a client
+	// certificate instead of a password. Their certificates are issued by the
+	// Operator into Secrets named "<clusterName>-<userName>-client-tls", so
+	// this requires Auto
+	CertAuthUsers []string `json:"certAuthUsers,omitempty"`
+}
+
+// IsCertAuthUser returns true if a PostgreSQL user authenticates with a client
+// certificate
+func (t TLSSpec) IsCertAuthUser(username string) bool {
+	switch username {
+	case PGUserPgBouncer, PGUserMonitor:
+		return false
+	case PGUserReplication:
+		return t.CertAuth
+	}
+
+	if t.CertAuth {
+		return true
+	}
+
+	for _, user := range t.CertAuthUsers {
+		if user == username {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HBARule is a rule of the pg_hba.conf of a cluster, i.e. which clients may
+// connect to which databases as which users, and how they authenticate
+type HBARule struct {
+	// Type is "local", "host", "hostssl" or "hostnossl"
+	Type string `json:"type"`
+	// Database and User are comma separated lists of names, or of keywords
+	// such as "all". A user name that starts with "+" matches the members of
+	// that role
+	Database string `json:"database"`
+	User     string `json:"user"`
+	// Address is a CIDR, "all", "samehost" or "samenet". Rules of type
+	// "local" have no address
+	Address string `json:"address,omitempty"`
+	// Method is how the clients authenticate, e.g. "scram-sha-256", "md5",
+	// "cert" or "reject"
+	Method string `json:"method"`
+}
+
+var (
+	// hbaTypes and hbaMethods are the types and authentication methods of the
+	// pg_hba.conf rules that can be set on a cluster
+	hbaTypes   = map[string]bool{"local": true, "host": true, "hostssl": true, "hostnossl": true}
+	hbaMethods = map[string]bool{"trust": true, "reject": true, "scram-sha-256": true, "md5": true,
+		"password": true, "cert": true, "peer": true, "ident": true}
+	// hbaNamePattern matches a database or user name in a pg_hba.conf rule,
+	// which is not quoted
+	hbaNamePattern = regexp.MustCompile(`^\+?[A-Za-z0-9_][A-Za-z0-9_.$-]*$`)
+	// hbaReservedUsers are the users whose pg_hba.conf rules are managed by
+	// the Operator
+	hbaReservedUsers = map[string]bool{PGUserAdmin: true, PGUserLogicalReplication: true,
+		PGUserMonitor: true, PGUserPgBouncer: true, PGUserReplication: true}
+)
+
+// String returns the rule as a line of pg_hba.conf
+func (r HBARule) String() string {
+	fields := []string{r.Type, r.Database, r.User}
+	if r.Address != "" {
+		fields = append(fields, r.Address)
+	}
+
+	return strings.Join(append(fields, r.Method), " ")
+}
+
+// Validate returns an error if a pg_hba.conf rule is not valid, or if it is
+// about replication or the users of the Operator, whose rules are managed by
+// the Operator
+func (r HBARule) Validate() error {
+	if !hbaTypes[r.Type] {
+		return fmt.Errorf("invalid type %q in pg_hba rule %q, valid values are \"local\", \"host\", "+
+			"\"hostssl\" or \"hostnossl\"", r.Type, r)
+	}
+
+	for _, database := range strings.Split(r.Database, ",") {
+		if strings.HasPrefix(database, "+") || !hbaNamePattern.MatchString(database) {
+			return fmt.Errorf("invalid database %q in pg_hba rule %q", database, r)
+		}
+
+		if database == "replication" {
+			return fmt.Errorf("pg_hba rule %q cannot be about replication, which is managed by the Operator", r)
+		}
+	}
+
+	for _, user := range strings.Split(r.User, ",") {
+		if !hbaNamePattern.MatchString(user) {
+			return fmt.Errorf("invalid user %q in pg_hba rule %q", user, r)
+		}
+
+		if hbaReservedUsers[strings.TrimPrefix(user, "+")] {
+			return fmt.Errorf("pg_hba rule %q cannot be about user %q, which is managed by the Operator", r, user)
+		}
+	}
+
+	if r.Type == "local" {
+		if r.Address != "" {
+			return fmt.Errorf("pg_hba rule %q of type \"local\" cannot have an address", r)
+		}
+	} else if _, _, err := net.ParseCIDR(r.Address); err != nil {
+		switch r.Address {
+		case "all", "samehost", "samenet":
+		default:
+			return fmt.Errorf("invalid address %q in pg_hba rule %q, valid values are a CIDR, \"all\", "+
+				"\"samehost\" or \"samenet\"", r.Address, r)
+		}
+	}
+
+	switch {
+	case !hbaMethods[r.Method]:
+		return fmt.Errorf("invalid method %q in pg_hba rule %q", r.Method, r)
+	case r.Method == "peer" && r.Type != "local":
+		return fmt.Errorf("pg_hba rule %q can only use method \"peer\" if it is of type \"local\"", r)
+	case r.Method == "cert" && r.Type != "hostssl":
+		return fmt.Errorf("pg_hba rule %q can only use method \"cert\" if it is of type \"hostssl\"", r)
+	}
+
+	return nil
+}
+
+// NetworkPolicySpec determines the NetworkPolicies of a cluster. When they are
+// enabled, PostgreSQL only accepts connections from the other pods of the
+// cluster, such as pgBouncer, from the PostgreSQL Operator, and from the
+// namespaces and CIDRs listed here, which may also connect to pgBouncer
+// swagger:ignore
+type NetworkPolicySpec struct {
+	Enabled bool `json:"enabled"`
+	// NamespaceSelectors are label selectors, e.g. "team=payments", of the
+	// namespaces whose pods may connect to the cluster
+	NamespaceSelectors []string `json:"namespaceSelectors,omitempty"`
+	// SourceRanges are the CIDRs that may connect to the cluster
+	SourceRanges []string `json:"sourceRanges,omitempty"`
+}
+
+// Validate returns an error if a namespace selector or a source range of the
+// NetworkPolicies is not valid
+func (n NetworkPolicySpec) Validate() error {
+	for _, selector := range n.NamespaceSelectors {
+		// an empty selector would select every namespace
+		if strings.TrimSpace(selector) == "" {
+			return fmt.Errorf("a namespace selector cannot be empty")
+		}
+
+		if _, err := metav1.ParseToLabelSelector(selector); err != nil {
+			return fmt.Errorf("invalid namespace selector %q: %v", selector, err)
+		}
+	}
+
+	for _, sourceRange := range n.SourceRanges {
+		if _, _, err := net.ParseCIDR(sourceRange); err != nil {
+			return fmt.Errorf("invalid source range %q, must be a CIDR", sourceRange)
+		}
+	}
+
+	return nil
+}
+
+// PublicationSpec is a publication of a database of a cluster for logical
+// replication
+// swagger:ignore
+type PublicationSpec struct {
+	// Name is the name of the publication in the database
+	Name string `json:"name"`
+	// Database is the database the publication is created in
+	Database string `json:"database"`
+	// Tables are the tables that are published. If not set, every table of the
+	// database is
+	Tables []string `json:"tables,omitempty"`
+	// Subscribers are the clusters that subscribe to the publication, which are
+	// allowed to connect by the NetworkPolicies of the cluster
+	Subscribers []string `json:"subscribers,omitempty"`
+}
+
+// SubscriptionSpec is a subscription of a database of a cluster to a
+// publication of another cluster in the same namespace
+// swagger:ignore
+type SubscriptionSpec struct {
+	// Name is the name of the subscription in the database
+	Name string `json:"name"`
+	// Database is the database the subscription is created in
+	Database string `json:"database"`
+	// SourceCluster is the cluster that has the publication
+	SourceCluster string `json:"sourceCluster"`
+	// Publication is the name of the publication of the source cluster
+	Publication string `json:"publication"`
+}
+
+// the states of a subscription of a cluster
+const (
+	// SubscriptionStateInitializing is a subscription whose tables are still
+	// being copied from the source cluster
+	SubscriptionStateInitializing = "initializing"
+	// SubscriptionStateStreaming is a subscription that receives the changes
+	// of the source cluster
+	SubscriptionStateStreaming = "streaming"
+	// SubscriptionStateStopped is a subscription that is enabled but does not
+	// receive changes, e.g. because the source cluster cannot be reached
+	SubscriptionStateStopped = "stopped"
+	// SubscriptionStateDisabled is a subscription that has been disabled
+	SubscriptionStateDisabled = "disabled"
+	// SubscriptionStateMissing is a subscription that is in the spec of the
+	// cluster but not in its database
+	SubscriptionStateMissing = "missing"
+)
+
+// SubscriptionStatus is the observed state of a subscription of a cluster
+// swagger:ignore
+type SubscriptionStatus struct {
+	// Name is the name of the subscription
+	Name string `json:"name"`
+	// Database is the database the subscription is in
+	Database string `json:"database"`
+	// State is one of the SubscriptionState constants
+	State string `json:"state"`
+	// LagBytes is the amount of WAL that the source cluster has written since
+	// the last change that the subscription confirmed, if it is known
+	LagBytes *int64 `json:"lagBytes,omitempty"`
+	// LastMessageTime is when the subscription last heard from the source
+	// cluster
+	LastMessageTime *metav1.Time `json:"lastMessageTime,omitempty"`
+}
+
+// GetPublication returns the publication of the cluster with the given name,
+// or nil if there is none
+func (s *PgclusterSpec) GetPublication(name string) *PublicationSpec {
+	for i := range s.Publications {
+		if s.Publications[i].Name == name {
+			return &s.Publications[i]
+		}
+	}
+
+	return nil
+}
+
+// GetSubscription returns the subscription of the cluster with the given
+// name, or nil if there is none
+func (s *PgclusterSpec) GetSubscription(name string) *SubscriptionSpec {
+	for i := range s.Subscriptions {
+		if s.Subscriptions[i].Name == name {
+			return &s.Subscriptions[i]
+		}
+	}
+
+	return nil
+}
+
+// PgBouncerSpec holds the settings of the pgBouncer connection poolers of a
+// cluster
+// swagger:ignore
+type PgBouncerSpec struct {
+	// ReadOnly, if set, adds a second pgBouncer Deployment and Service, named
+	// "<clusterName>-pgbouncer-ro", whose pool connects to the replica Service
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// Replicas is the number of Pods of each pgBouncer Deployment. If not set,
+	// there is one
+	Replicas int32 `json:"replicas,omitempty"`
 }
 
 // IsTLSEnabled returns true if the cluster is TLS enabled, i.e. both the TLS
@@ -158,6 +673,9 @@ const (
 	// PgclusterStateShutdown indicates that the cluster has been shut down (i.e. the primary)
 	// deployment has been scaled to 0
 	PgclusterStateShutdown PgclusterState = "pgcluster Shutdown"
+	// PgclusterStateMajorUpgrade indicates that the data directory of the cluster has been
+	// upgraded to a new PostgreSQL major version and the primary is starting on it
+	PgclusterStateMajorUpgrade PgclusterState = "pgcluster Major Upgrade"
 
 	// PodAntiAffinityRequired results in requiredDuringSchedulingIgnoredDuringExecution for any
 	// default pod anti-affinity rules applied to pg custers
@@ -34,6 +34,19 @@ const PrimarySecretSuffix = "-primaryuser-secret"
 // CollectSecretSuffix ...
 const CollectSecretSuffix = "-collect-secret"
 
+// TLSSecretSuffix and TLSCASecretSuffix are the suffixes of the Secrets that
+// hold the certificate of a cluster and the CA that issued it, when the
+// Operator manages the certificates of the cluster
+const (
+	TLSSecretSuffix   = "-tls"
+	TLSCASecretSuffix = "-tls-ca"
+)
+
+// UserTLSSecretFormat is the format of the name of the Secret that holds the
+// client certificate of a PostgreSQL user, which is
+// "<clusterName>-<userName>-client-tls"
+const UserTLSSecretFormat = "%s-%s-client" + TLSSecretSuffix
+
 // StorageExisting ...
 const StorageExisting = "existing"
 
@@ -52,6 +65,9 @@ const (
 	// PGUserAdmin is a special user that can perform administrative actions
 	// without being a superuser itself
 	PGUserAdmin = "crunchyadm"
+	// PGUserLogicalReplication is the user that the subscriptions of other
+	// clusters connect to the publications of a cluster as
+	PGUserLogicalReplication = "logicalreplicator"
 	// PGUserMonitor is the monitoring user that can access metric data
 	PGUserMonitor = "ccp_monitoring"
 	// PGUserPgBouncer is the user that's used for managing pgBouncer, which a
@@ -74,11 +90,12 @@ var PGFSGroup int64 = 26
 // PGUserSystemAccounts maintains an easy-to-access list of what the systems
 // accounts are, which may affect how information is returned, etc.
 var PGUserSystemAccounts = map[string]struct{}{
-	PGUserAdmin:       struct{}{},
-	PGUserMonitor:     struct{}{},
-	PGUserPgBouncer:   struct{}{},
-	PGUserReplication: struct{}{},
-	PGUserSuperuser:   struct{}{},
+	PGUserAdmin:              struct{}{},
+	PGUserLogicalReplication: struct{}{},
+	PGUserMonitor:            struct{}{},
+	PGUserPgBouncer:          struct{}{},
+	PGUserReplication:        struct{}{},
+	PGUserSuperuser:          struct{}{},
 }
 
 // PgStorageSpec ...
@@ -137,6 +154,9 @@ const CompletedStatus = "completed"
 // InProgressStatus -
 const InProgressStatus = "in progress"
 
+// FailedStatus -
+const FailedStatus = "failed"
+
 // SubmittedStatus -
 const SubmittedStatus = "submitted"
 
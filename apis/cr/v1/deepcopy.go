@@ -15,7 +15,10 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-import "k8s.io/apimachinery/pkg/runtime"
+import (
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
 
 // DeepCopyInto copies all properties of this object into another object of the
 // same type that is provided as a pointer.
@@ -31,6 +34,14 @@ func (in *Pgreplica) DeepCopyInto(out *Pgreplica) {
 		ContainerResources: in.Spec.ContainerResources,
 		Status:             in.Spec.Status,
 		UserLabels:         in.Spec.UserLabels,
+		Snapshot:           in.Spec.Snapshot,
+		PriorityClassName:  in.Spec.PriorityClassName,
+	}
+	if in.Spec.Tolerations != nil {
+		out.Spec.Tolerations = make([]core_v1.Toleration, len(in.Spec.Tolerations))
+		for i := range in.Spec.Tolerations {
+			in.Spec.Tolerations[i].DeepCopyInto(&out.Spec.Tolerations[i])
+		}
 	}
 }
 
@@ -64,6 +75,11 @@ func (in *Pgcluster) DeepCopyInto(out *Pgcluster) {
 	out.TypeMeta = in.TypeMeta
 	out.ObjectMeta = in.ObjectMeta
 	out.Status = in.Status
+	// conditions are modified in place, so they must not be shared
+	if in.Status.Conditions != nil {
+		out.Status.Conditions = make([]PgclusterCondition, len(in.Status.Conditions))
+		copy(out.Status.Conditions, in.Status.Conditions)
+	}
 	out.Spec = PgclusterSpec{
 		Namespace:          in.Spec.Namespace,
 		Name:               in.Spec.Name,
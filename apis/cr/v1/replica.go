@@ -16,6 +16,7 @@ package v1
 */
 
 import (
+	core_v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -42,6 +43,15 @@ type PgreplicaSpec struct {
 	ContainerResources PgContainerResources `json:"containerresources"`
 	Status             string               `json:"status"`
 	UserLabels         map[string]string    `json:"userlabels"`
+	// Snapshot, if set, is the name of the snapshot backup of the cluster that
+	// the PVCs of the replica are provisioned from
+	Snapshot string `json:"snapshot"`
+	// Tolerations, if set, are added to the pod of the replica instead of the
+	// tolerations of the cluster
+	Tolerations []core_v1.Toleration `json:"tolerations,omitempty"`
+	// PriorityClassName, if set, is the priority class of the pod of the
+	// replica instead of the priority class of the cluster
+	PriorityClassName string `json:"priorityClassName,omitempty"`
 }
 
 // PgreplicaList ...
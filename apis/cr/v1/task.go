@@ -31,12 +31,14 @@ const PgtaskFailover = "failover"
 const PgtaskAutoFailover = "autofailover"
 const PgtaskAddPolicies = "addpolicies"
 const PgtaskMinorUpgrade = "minorupgradecluster"
+const PgtaskMajorUpgrade = "majorupgradecluster"
 
 const PgtaskWorkflow = "workflow"
 const PgtaskWorkflowCloneType = "cloneworkflow"
 const PgtaskWorkflowCreateClusterType = "createcluster"
 const PgtaskWorkflowBackrestRestoreType = "pgbackrestrestore"
 const PgtaskWorkflowBackupType = "backupworkflow"
+const PgtaskWorkflowMajorUpgradeType = "majorupgradeworkflow"
 const PgtaskWorkflowSubmittedStatus = "task submitted"
 const PgtaskWorkflowCompletedStatus = "task completed"
 const PgtaskWorkflowID = "workflowid"
@@ -50,11 +52,21 @@ const PgtaskWorkflowCloneSyncRepo = "clone 1.2: sync pgbackrest repo"
 const PgtaskWorkflowCloneRestoreBackup = "clone 2: restoring backup"
 const PgtaskWorkflowCloneClusterCreate = "clone 3: cluster creating"
 
+const PgtaskWorkflowMajorUpgradeBackup = "major upgrade 1: pre-upgrade backup"
+const PgtaskWorkflowMajorUpgradeShutdown = "major upgrade 2: cluster shutdown"
+const PgtaskWorkflowMajorUpgradePGUpgrade = "major upgrade 3: pg_upgrade"
+const PgtaskWorkflowMajorUpgradeStanzaUpgrade = "major upgrade 4: stanza upgrade"
+const PgtaskWorkflowMajorUpgradeReplicas = "major upgrade 5: replicas reinitializing"
+const PgtaskWorkflowMajorUpgradeFailed = "major upgrade failed"
+
 const PgtaskBackrest = "backrest"
 const PgtaskBackrestBackup = "backup"
 const PgtaskBackrestInfo = "info"
 const PgtaskBackrestRestore = "restore"
 const PgtaskBackrestStanzaCreate = "stanza-create"
+const PgtaskBackrestStanzaUpgrade = "stanza-upgrade"
+
+const PgtaskSnapshotBackup = "snapshot-backup"
 
 const PgtaskpgDump = "pgdump"
 const PgtaskpgDumpBackup = "pgdumpbackup"
@@ -75,11 +87,22 @@ const (
 	BackupTypeFailover string = "failover"
 	// this type of backup is taken when a new cluster is being bootstrapped
 	BackupTypeBootstrap string = "bootstrap"
+	// this type of backup is taken before a major upgrade of a cluster
+	BackupTypeMajorUpgrade string = "majorupgrade"
 )
 
 // BackrestStorageTypes defines the valid types of storage that can be utilized
 // with pgBackRest
-var BackrestStorageTypes = []string{"local", "s3"}
+var BackrestStorageTypes = []string{"local", "s3", "gcs", "azure"}
+
+// BackrestCloudStorageTypes defines the types of storage that are backed by an
+// object store. At most one of these can be enabled for a cluster, as they are
+// all used for the same pgBackRest repository (repo1)
+var BackrestCloudStorageTypes = []string{"s3", "gcs", "azure"}
+
+// BackrestCipherTypes defines the types of encryption that can be used for a
+// pgBackRest repository, i.e. for the "repo1-cipher-type" setting
+var BackrestCipherTypes = []string{"none", "aes-256-cbc"}
 
 // PgtaskSpec ...
 // swagger:ignore
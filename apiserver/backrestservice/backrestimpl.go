@@ -42,9 +42,9 @@ const containername = "database"
 // pgBackRest info
 var pgBackRestInfoCommand = []string{"pgbackrest", "info", "--output", "json"}
 
-// repoTypeFlagS3 is used for getting the pgBackRest info for a repository that
-// is stored in S3
-var repoTypeFlagS3 = []string{"--repo-type", "s3"}
+// repoTypeFlag is used for getting the pgBackRest info for a repository that
+// is stored in an object store, i.e. S3, GCS or Azure
+const repoTypeFlag = "--repo-type"
 
 //  CreateBackup ...
 // pgo backup mycluster
@@ -373,8 +373,8 @@ func ShowBackrest(name, selector, ns string) msgs.ShowBackrestResponse {
 			//
 			// 1. If storage type is "local" and the string either contains "local" or
 			// is empty, we can add the pgBackRest info
-			// 2. if the storage type is "s3" and the string contains "s3", we can
-			// add the pgBackRest info
+			// 2. if the storage type is "s3", "gcs" or "azure" and the string
+			// contains it, we can add the pgBackRest info
 			// 3. Otherwise, continue
 			if (storageTypes == "" && storageType != "local") || (storageTypes != "" && !strings.Contains(storageTypes, storageType)) {
 				continue
@@ -420,8 +420,8 @@ func getInfo(clusterName, storageType, podname, ns string) (string, error) {
 
 	cmd := pgBackRestInfoCommand
 
-	if storageType == "s3" {
-		cmd = append(cmd, repoTypeFlagS3...)
+	if util.IsStringOneOf(storageType, crv1.BackrestCloudStorageTypes...) {
+		cmd = append(cmd, repoTypeFlag, storageType)
 	}
 
 	output, stderr, err := kubeapi.ExecToPodThroughAPI(apiserver.RESTConfig, apiserver.Clientset, cmd, containername, podname, ns, nil)
@@ -202,3 +202,192 @@ func RestoreHandler(w http.ResponseWriter, r *http.Request) {
 	resp = Restore(&request, ns, username)
 	json.NewEncoder(w).Encode(resp)
 }
+
+// ShowRecoverabilityHandler ...
+// pgo show recoverability mycluster
+// pgo show recoverability --selector=name=mycluster
+// pgo show recoverability --all
+func ShowRecoverabilityHandler(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /recoverability backrestservice recoverability
+	/*```
+	Displays the recoverability window of the pgBackRest repositories of PostgreSQL clusters
+	*/
+	// ---
+	//  produces:
+	//  - application/json
+	//  parameters:
+	//  - name: "Show Recoverability Request"
+	//    in: "body"
+	//    schema:
+	//      "$ref": "#/definitions/ShowRecoverabilityRequest"
+	//  responses:
+	//    '200':
+	//      description: Output
+	//      schema:
+	//        "$ref": "#/definitions/ShowRecoverabilityResponse"
+	log.Debug("backrestservice.ShowRecoverabilityHandler called")
+
+	username, err := apiserver.Authn(apiserver.SHOW_BACKUP_PERM, w, r)
+	if err != nil {
+		return
+	}
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := msgs.ShowRecoverabilityResponse{}
+
+	var request msgs.ShowRecoverabilityRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	log.Debugf("ShowRecoverabilityHandler parameters [%+v]", request)
+
+	if request.ClientVersion != msgs.PGO_VERSION {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: apiserver.VERSION_MISMATCH_ERROR}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	ns, err := apiserver.GetNamespace(apiserver.Clientset, username, request.Namespace)
+	if err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	request.Namespace = ns
+
+	resp = ShowRecoverability(request)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// CreateSnapshotBackupHandler ...
+// pgo backup mycluster --backup-type=snapshot
+// pgo backup --selector=name=mycluster --backup-type=snapshot
+func CreateSnapshotBackupHandler(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /snapshotbackup backrestservice snapshotbackup
+	/*```
+	Takes snapshot backups of PostgreSQL clusters
+	*/
+	// ---
+	//  produces:
+	//  - application/json
+	//  parameters:
+	//  - name: "Create Snapshot Backup Request"
+	//    in: "body"
+	//    schema:
+	//      "$ref": "#/definitions/CreateSnapshotBackupRequest"
+	//  responses:
+	//    '200':
+	//      description: Output
+	//      schema:
+	//        "$ref": "#/definitions/CreateSnapshotBackupResponse"
+	log.Debug("backrestservice.CreateSnapshotBackupHandler called")
+
+	username, err := apiserver.Authn(apiserver.CREATE_BACKUP_PERM, w, r)
+	if err != nil {
+		return
+	}
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := msgs.CreateSnapshotBackupResponse{}
+
+	var request msgs.CreateSnapshotBackupRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	log.Debugf("CreateSnapshotBackupHandler parameters [%+v]", request)
+
+	if request.ClientVersion != msgs.PGO_VERSION {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: apiserver.VERSION_MISMATCH_ERROR}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	ns, err := apiserver.GetNamespace(apiserver.Clientset, username, request.Namespace)
+	if err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	request.Namespace = ns
+
+	resp = CreateSnapshotBackup(request, username)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ShowSnapshotBackupHandler ...
+// pgo show backup mycluster --backup-type=snapshot
+func ShowSnapshotBackupHandler(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /showsnapshotbackup backrestservice showsnapshotbackup
+	/*```
+	Lists the snapshot backups of PostgreSQL clusters
+	*/
+	// ---
+	//  produces:
+	//  - application/json
+	//  parameters:
+	//  - name: "Show Snapshot Backup Request"
+	//    in: "body"
+	//    schema:
+	//      "$ref": "#/definitions/ShowSnapshotBackupRequest"
+	//  responses:
+	//    '200':
+	//      description: Output
+	//      schema:
+	//        "$ref": "#/definitions/ShowSnapshotBackupResponse"
+	log.Debug("backrestservice.ShowSnapshotBackupHandler called")
+
+	username, err := apiserver.Authn(apiserver.SHOW_BACKUP_PERM, w, r)
+	if err != nil {
+		return
+	}
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := msgs.ShowSnapshotBackupResponse{}
+
+	var request msgs.ShowSnapshotBackupRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	log.Debugf("ShowSnapshotBackupHandler parameters [%+v]", request)
+
+	if request.ClientVersion != msgs.PGO_VERSION {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: apiserver.VERSION_MISMATCH_ERROR}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	ns, err := apiserver.GetNamespace(apiserver.Clientset, username, request.Namespace)
+	if err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	request.Namespace = ns
+
+	resp = ShowSnapshotBackups(request)
+	json.NewEncoder(w).Encode(resp)
+}
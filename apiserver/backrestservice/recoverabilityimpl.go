@@ -0,0 +1,619 @@
+package backrestservice
+
+/*
+Copyright 2020 Crunchy Data Solutions, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/apiserver"
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	"github.com/crunchydata/postgres-operator/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// the types of pgBackRest backups that are reported on
+const (
+	backupTypeDiff = "diff"
+	backupTypeFull = "full"
+	backupTypeIncr = "incr"
+)
+
+// walSegmentNameLength is the length of a WAL segment name, e.g.
+// "000000010000000000000003", which is made up of the timeline, the log and the
+// segment, 8 characters each
+const walSegmentNameLength = 24
+
+// sqlLastArchivedWAL returns the WAL segment that PostgreSQL archived last, and
+// when, as a Unix timestamp
+const sqlLastArchivedWAL = `SELECT last_archived_wal, extract(epoch FROM last_archived_time)::bigint ` +
+	`FROM pg_stat_archiver WHERE last_archived_wal IS NOT NULL;`
+
+// lastArchivedWAL is the WAL segment that PostgreSQL archived last, and when
+type lastArchivedWAL struct {
+	Segment string
+	Time    time.Time
+}
+
+// walArchive is what is in the archive of a stanza for one of its databases,
+// as listed in the repository
+type walArchive struct {
+	// Segments are the names of the WAL segments in the archive
+	Segments []string
+	// Size is the total size of the files in the archive, in bytes
+	Size int64
+}
+
+// walHole is a range of WAL segments that is missing from an archive
+type walHole struct {
+	// Start and Stop are the first and the last segment that are missing
+	Start string
+	Stop  string
+	// Previous is the segment in the archive that precedes the hole
+	Previous string
+}
+
+// repoListEntry is an entry of the JSON output of "pgbackrest repo-ls"
+type repoListEntry struct {
+	Size int64  `json:"size"`
+	Type string `json:"type"`
+}
+
+// ShowRecoverability returns the recoverability window of the pgBackRest
+// repositories of all of the clusters that match the selector in the request,
+// and checks them against the recovery point objective (RPO)
+func ShowRecoverability(request msgs.ShowRecoverabilityRequest) msgs.ShowRecoverabilityResponse {
+	response := msgs.ShowRecoverabilityResponse{
+		Results: []msgs.RecoverabilityDetail{},
+		Status:  msgs.Status{Code: msgs.Ok},
+	}
+
+	// if the RPO is not set in the request, use the one from the Operator
+	// configuration
+	rpoValue := request.RPO
+
+	if rpoValue == "" {
+		rpoValue = apiserver.Pgo.Cluster.BackrestRPO
+	}
+
+	rpo, err := time.ParseDuration(rpoValue)
+
+	if err != nil {
+		response.Status = msgs.Status{Code: msgs.Error, Msg: fmt.Sprintf("invalid RPO: %s", err)}
+		return response
+	}
+
+	// if the selector is not set to "*", then use the value from the request
+	selector := ""
+
+	if request.Selector != msgs.RecoverabilityShowAllSelector {
+		selector = request.Selector
+	}
+
+	clusterList := crv1.PgclusterList{}
+
+	if err := kubeapi.GetpgclustersBySelector(apiserver.RESTClient, &clusterList, selector,
+		request.Namespace); err != nil {
+		response.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		return response
+	}
+
+	log.Debugf("recoverability clusters found len is %d", len(clusterList.Items))
+
+	now := time.Now()
+
+	for _, cluster := range clusterList.Items {
+		response.Results = append(response.Results,
+			getClusterRecoverability(&cluster, request.Namespace, now, rpo)...)
+	}
+
+	return response
+}
+
+// getClusterRecoverability returns the recoverability of each of the
+// pgBackRest repositories of a cluster. If the information cannot be retrieved
+// from pgBackRest, the error is reported as part of the result, so that one
+// unavailable cluster does not hide the others
+func getClusterRecoverability(cluster *crv1.Pgcluster, ns string, now time.Time,
+	rpo time.Duration) []msgs.RecoverabilityDetail {
+	results := []msgs.RecoverabilityDetail{}
+
+	storageTypes := cluster.Spec.UserLabels[config.LABEL_BACKREST_STORAGE_TYPE]
+
+	// an empty storage type means that only local storage is used
+	if storageTypes == "" {
+		storageTypes = "local"
+	}
+
+	podname, podErr := getPrimaryPodName(cluster, ns)
+
+	// the time the archive ends is only known to PostgreSQL. If it cannot be
+	// retrieved, the end of the latest backup is used instead
+	var archived *lastArchivedWAL
+	if podErr == nil {
+		var err error
+		if archived, err = getLastArchivedWAL(cluster); err != nil {
+			log.Error(err)
+		}
+	}
+
+	for _, storageType := range strings.Split(storageTypes, ",") {
+		detail := msgs.RecoverabilityDetail{
+			ClusterName: cluster.Name,
+			RPO:         rpo.String(),
+			StorageType: storageType,
+		}
+
+		if podErr != nil {
+			detail.Error = podErr.Error()
+			results = append(results, detail)
+			continue
+		}
+
+		info, err := getInfo(cluster.Name, storageType, podname, ns)
+
+		if err != nil {
+			log.Error(err)
+			detail.Error = err.Error()
+			results = append(results, detail)
+			continue
+		}
+
+		stanzas := []msgs.PgBackRestInfo{}
+
+		if err := json.Unmarshal([]byte(info), &stanzas); err != nil {
+			log.Error(err)
+			detail.Error = err.Error()
+			results = append(results, detail)
+			continue
+		}
+
+		for _, stanza := range stanzas {
+			// if an archive cannot be listed, its size and any holes in it are
+			// not reported
+			walArchives := map[string]*walArchive{}
+			for _, archive := range stanza.Archives {
+				wal, err := getWALArchive(storageType, podname, ns, stanza.Name, archive.ID)
+				if err != nil {
+					log.Error(err)
+					continue
+				}
+				walArchives[archive.ID] = wal
+			}
+
+			stanzaDetail := detail
+			setRecoverability(&stanzaDetail, stanza, walArchives, archived, now, rpo)
+			results = append(results, stanzaDetail)
+		}
+	}
+
+	return results
+}
+
+// getWALArchive lists the archive of a stanza for one of its databases in a
+// pgBackRest repository
+func getWALArchive(storageType, podname, ns, stanza, archiveID string) (*walArchive, error) {
+	cmd := []string{"pgbackrest", "repo-ls", "--output=json", "--recurse",
+		fmt.Sprintf("archive/%s/%s", stanza, archiveID)}
+
+	if util.IsStringOneOf(storageType, crv1.BackrestCloudStorageTypes...) {
+		cmd = append(cmd, repoTypeFlag, storageType)
+	}
+
+	stdout, stderr, err := kubeapi.ExecToPodThroughAPI(apiserver.RESTConfig, apiserver.Clientset, cmd,
+		containername, podname, ns, nil)
+	if err != nil {
+		log.Error(stderr)
+		return nil, err
+	}
+
+	return parseWALArchive(stdout)
+}
+
+// parseWALArchive parses the output of "pgbackrest repo-ls" for an archive.
+// Any file counts towards the size of the archive, but only those of complete
+// WAL segments, e.g. "000000010000000000000003-<checksum>.gz", are segments:
+// history, backup and partial files are not
+func parseWALArchive(output string) (*walArchive, error) {
+	entries := map[string]repoListEntry{}
+	if err := json.Unmarshal([]byte(output), &entries); err != nil {
+		return nil, err
+	}
+
+	wal := &walArchive{Segments: []string{}}
+
+	for path, entry := range entries {
+		if entry.Type != "file" {
+			continue
+		}
+
+		wal.Size += entry.Size
+
+		name := path[strings.LastIndex(path, "/")+1:]
+		if len(name) > walSegmentNameLength && name[walSegmentNameLength] == '-' {
+			if _, _, _, ok := parseWALSegment(name[:walSegmentNameLength]); ok {
+				wal.Segments = append(wal.Segments, name[:walSegmentNameLength])
+			}
+		}
+	}
+
+	sort.Strings(wal.Segments)
+
+	return wal, nil
+}
+
+// getLastArchivedWAL returns the WAL segment that the primary of a cluster
+// archived last, and when, or nil if it has not archived any yet
+func getLastArchivedWAL(cluster *crv1.Pgcluster) (*lastArchivedWAL, error) {
+	pod, err := util.GetPrimaryPod(apiserver.Clientset, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, stderr, err := kubeapi.ExecToPodThroughAPI(apiserver.RESTConfig, apiserver.Clientset,
+		[]string{"psql", "-X", "-A", "-t", "-q", "-p", cluster.Spec.Port}, "database",
+		pod.Name, pod.Namespace, strings.NewReader(sqlLastArchivedWAL))
+	if err != nil {
+		return nil, err
+	} else if stderr != "" {
+		return nil, fmt.Errorf(stderr)
+	}
+
+	return parseLastArchivedWAL(stdout)
+}
+
+// parseLastArchivedWAL parses the output of sqlLastArchivedWAL
+func parseLastArchivedWAL(output string) (*lastArchivedWAL, error) {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return nil, nil
+	}
+
+	fields := strings.Split(output, "|")
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("unexpected output of pg_stat_archiver: %q", output)
+	}
+
+	seconds, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected output of pg_stat_archiver: %q", output)
+	}
+
+	return &lastArchivedWAL{Segment: fields[0], Time: time.Unix(seconds, 0)}, nil
+}
+
+// setRecoverability computes the recoverability window of a pgBackRest stanza
+// from the timestamps and WAL ranges of its backups and the WAL that is
+// available in the archive, and checks it against the RPO. The latest
+// recoverable point is the end of the archive, which is when PostgreSQL
+// archived the last WAL segment if the archive of the stanza has it, and is
+// otherwise no earlier than the end of the latest backup. If the archives of
+// the stanza were listed, their size counts towards the size of the
+// repository, and any holes between their first and last segment are reported:
+// a backup cannot be restored across a hole, nor can the WAL after one be
+// replayed
+func setRecoverability(detail *msgs.RecoverabilityDetail, stanza msgs.PgBackRestInfo,
+	walArchives map[string]*walArchive, archived *lastArchivedWAL, now time.Time, rpo time.Duration) {
+	detail.Stanza = stanza.Name
+	detail.WALGaps = []string{}
+	detail.RPOBreaches = []string{}
+
+	holes := map[int][]walHole{}
+	for _, archive := range stanza.Archives {
+		wal := walArchives[archive.ID]
+		if wal == nil {
+			continue
+		}
+
+		detail.RepoSize += wal.Size
+
+		archiveHoles := getWALHoles(wal.Segments)
+		holes[archive.DB.ID] = append(holes[archive.DB.ID], archiveHoles...)
+
+		for _, hole := range archiveHoles {
+			detail.WALGaps = append(detail.WALGaps, fmt.Sprintf("%s-%s (missing from archive %s)",
+				hole.Start, hole.Stop, archive.ID))
+		}
+	}
+
+	// the newest backup that can be restored, whose WAL is replayed up to the
+	// latest recoverable point
+	var restorable *msgs.PgBackRestInfoBackup
+
+	for i, backup := range stanza.Backups {
+		detail.RepoSize += backup.Info.Repository.Delta
+
+		stop := time.Unix(backup.Timestamp.Stop, 0)
+		latest := &msgs.RecoverabilityBackup{
+			Age:       int64(now.Sub(stop).Seconds()),
+			Label:     backup.Label,
+			Timestamp: stop,
+		}
+
+		// the backups are listed from oldest to newest, so the last one of each
+		// type wins
+		switch backup.Type {
+		case backupTypeFull:
+			detail.LastFullBackup = latest
+		case backupTypeDiff:
+			detail.LastDiffBackup = latest
+		case backupTypeIncr:
+			detail.LastIncrBackup = latest
+		}
+
+		if detail.LatestBackupTime == nil || stop.After(*detail.LatestBackupTime) {
+			detail.LatestBackupTime = &stop
+		}
+
+		// a backup can only be restored if all of the WAL that was written while
+		// it was running is in the archive
+		if !isWALArchived(stanza.Archives, backup.Database.ID, backup.Archive.Start, backup.Archive.Stop) {
+			detail.WALGaps = append(detail.WALGaps, fmt.Sprintf("%s-%s (needed by backup %s)",
+				backup.Archive.Start, backup.Archive.Stop, backup.Label))
+			continue
+		}
+
+		// a hole in the WAL of the backup is already reported as such
+		if hasWALHole(holes[backup.Database.ID], backup.Archive.Start, backup.Archive.Stop) {
+			continue
+		}
+
+		restorable = &stanza.Backups[i]
+
+		// the earliest recoverable point is the end of the oldest backup that can
+		// be restored
+		if detail.EarliestRecoverableTime == nil {
+			detail.EarliestRecoverableTime = &stop
+			detail.EarliestRecoverableWAL = backup.Archive.Stop
+		}
+	}
+
+	detail.LatestRecoverableTime = detail.LatestBackupTime
+
+	// the latest recoverable point is the newest WAL in the current archive,
+	// which is the archive of the most recent database, unless there is a hole
+	// in the archive after the latest backup that can be restored. In that
+	// case, WAL can only be replayed up to the hole, the time of which is not
+	// known, so the end of that backup is the latest recoverable point
+	if len(stanza.Archives) > 0 {
+		archive := stanza.Archives[len(stanza.Archives)-1]
+		detail.LatestRecoverableWAL = archive.Max
+
+		var hole *walHole
+		if restorable != nil && restorable.Database.ID == archive.DB.ID {
+			hole = getWALHoleAfter(holes[archive.DB.ID], restorable.Archive.Stop)
+		}
+
+		switch {
+		case hole != nil:
+			stop := time.Unix(restorable.Timestamp.Stop, 0)
+			detail.LatestRecoverableTime = &stop
+			detail.LatestRecoverableWAL = hole.Previous
+		case archived != nil && archive.Max != "" && compareWALSegments(archive.Max, archived.Segment) >= 0 &&
+			(detail.LatestRecoverableTime == nil || archived.Time.After(*detail.LatestRecoverableTime)):
+			detail.LatestRecoverableTime = &archived.Time
+		}
+
+		// if WAL stopped being archived before the latest backup completed, none
+		// of the changes since then can be recovered
+		if len(stanza.Backups) > 0 {
+			backup := stanza.Backups[len(stanza.Backups)-1]
+
+			if backup.Database.ID == archive.DB.ID && archive.Max != "" &&
+				compareWALSegments(archive.Max, backup.Archive.Stop) < 0 {
+				detail.WALGaps = append(detail.WALGaps, fmt.Sprintf("%s-%s (archive ends before latest backup %s)",
+					archive.Max, backup.Archive.Stop, backup.Label))
+			}
+		}
+	}
+
+	// finally, check the repository against the RPO, i.e. how much would be
+	// lost if the cluster were recovered now
+	switch {
+	case detail.LatestBackupTime == nil:
+		detail.RPOBreaches = append(detail.RPOBreaches, "no backups")
+	case now.Sub(*detail.LatestRecoverableTime) > rpo:
+		detail.RPOBreaches = append(detail.RPOBreaches, fmt.Sprintf("latest recoverable point is %s old",
+			now.Sub(*detail.LatestRecoverableTime).Truncate(time.Second)))
+	}
+
+	if detail.EarliestRecoverableTime == nil && detail.LatestBackupTime != nil {
+		detail.RPOBreaches = append(detail.RPOBreaches, "no backup can be restored")
+	}
+
+	if len(detail.WALGaps) > 0 {
+		detail.RPOBreaches = append(detail.RPOBreaches, fmt.Sprintf("%d WAL gap(s)", len(detail.WALGaps)))
+	}
+
+	detail.RPOBreached = len(detail.RPOBreaches) > 0
+}
+
+// isWALArchived returns true if the range of WAL segments from start to stop
+// is available in the archive for the database with the given ID
+func isWALArchived(archives []msgs.PgBackRestInfoArchive, dbID int, start, stop string) bool {
+	for _, archive := range archives {
+		if archive.DB.ID != dbID || archive.Min == "" || archive.Max == "" {
+			continue
+		}
+
+		if compareWALSegments(archive.Min, start) <= 0 && compareWALSegments(stop, archive.Max) <= 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getWALHoles returns the holes between the first and the last segment of an
+// archive, in order. Segments of any timeline fill the positions, i.e. logs
+// and segments, they are at, as recovery switches to a new timeline at the
+// segment that it branched off at. Which segment is the last one of a log
+// depends on the WAL segment size of the cluster, and is taken to be the
+// highest segment in the archive
+func getWALHoles(segments []string) []walHole {
+	type position struct {
+		timeline, log, segment uint64
+	}
+
+	positions := []position{}
+	var lastSegment uint64
+
+	for _, name := range segments {
+		timeline, logNumber, segment, ok := parseWALSegment(name)
+		if !ok {
+			continue
+		}
+
+		positions = append(positions, position{timeline, logNumber, segment})
+		if segment > lastSegment {
+			lastSegment = segment
+		}
+	}
+
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].log != positions[j].log {
+			return positions[i].log < positions[j].log
+		}
+		return positions[i].segment < positions[j].segment
+	})
+
+	holes := []walHole{}
+
+	for i := 1; i < len(positions); i++ {
+		previous, current := positions[i-1], positions[i]
+
+		// the position that follows the previous segment
+		nextLog, nextSegment := previous.log, previous.segment+1
+		if previous.segment >= lastSegment {
+			nextLog, nextSegment = previous.log+1, 0
+		}
+
+		if current.log < nextLog || (current.log == nextLog && current.segment <= nextSegment) {
+			continue
+		}
+
+		// the position that precedes the current segment
+		stopLog, stopSegment := current.log, current.segment-1
+		if current.segment == 0 {
+			stopLog, stopSegment = current.log-1, lastSegment
+		}
+
+		holes = append(holes, walHole{
+			Start:    formatWALSegment(current.timeline, nextLog, nextSegment),
+			Stop:     formatWALSegment(current.timeline, stopLog, stopSegment),
+			Previous: formatWALSegment(previous.timeline, previous.log, previous.segment),
+		})
+	}
+
+	return holes
+}
+
+// getWALHoleAfter returns the first hole that starts after a WAL segment, if
+// any
+func getWALHoleAfter(holes []walHole, segment string) *walHole {
+	for i := range holes {
+		if compareWALPositions(holes[i].Start, segment) > 0 {
+			return &holes[i]
+		}
+	}
+
+	return nil
+}
+
+// hasWALHole returns true if any of the holes is in the range of WAL segments
+// from start to stop
+func hasWALHole(holes []walHole, start, stop string) bool {
+	for _, hole := range holes {
+		if compareWALPositions(hole.Start, stop) <= 0 && compareWALPositions(start, hole.Stop) <= 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compareWALSegments compares two WAL segments by their timeline, then their
+// log, then their segment, and returns -1, 0 or 1 like strings.Compare.
+// Segment names that are not of the expected length are compared as strings
+func compareWALSegments(a, b string) int {
+	timelineA, logA, segmentA, okA := parseWALSegment(a)
+	timelineB, logB, segmentB, okB := parseWALSegment(b)
+
+	if !okA || !okB {
+		return strings.Compare(a, b)
+	}
+
+	return compareUint64s([]uint64{timelineA, logA, segmentA}, []uint64{timelineB, logB, segmentB})
+}
+
+// compareWALPositions compares two WAL segments by their log, then their
+// segment, regardless of their timeline, like compareWALSegments
+func compareWALPositions(a, b string) int {
+	_, logA, segmentA, okA := parseWALSegment(a)
+	_, logB, segmentB, okB := parseWALSegment(b)
+
+	if !okA || !okB {
+		return strings.Compare(a, b)
+	}
+
+	return compareUint64s([]uint64{logA, segmentA}, []uint64{logB, segmentB})
+}
+
+// compareUint64s compares two lists of numbers of the same length in order,
+// and returns -1, 0 or 1 like strings.Compare
+func compareUint64s(a, b []uint64) int {
+	for i := range a {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// parseWALSegment returns the timeline, the log and the segment of a WAL
+// segment name, which are 8 hexadecimal characters each
+func parseWALSegment(name string) (timeline, logNumber, segment uint64, ok bool) {
+	if len(name) != walSegmentNameLength {
+		return 0, 0, 0, false
+	}
+
+	values := [3]uint64{}
+	for i := range values {
+		value, err := strconv.ParseUint(name[i*8:i*8+8], 16, 32)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		values[i] = value
+	}
+
+	return values[0], values[1], values[2], true
+}
+
+// formatWALSegment returns the name of a WAL segment
+func formatWALSegment(timeline, logNumber, segment uint64) string {
+	return fmt.Sprintf("%08X%08X%08X", timeline, logNumber, segment)
+}
@@ -0,0 +1,392 @@
+package backrestservice
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+)
+
+func TestCompareWALSegments(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"000000010000000000000003", "000000010000000000000003", 0},
+		{"000000010000000000000003", "00000001000000000000000A", -1},
+		{"00000001000000000000000a", "00000001000000000000000A", 0},
+		{"000000010000000100000000", "0000000100000000000000FF", 1},
+		{"000000020000000000000001", "000000010000000000000009", 1},
+		{"000000010000000200000000", "000000020000000100000000", -1},
+		{"0000000A0000000000000001", "000000090000000000000001", 1},
+		{"short", "000000010000000000000003", 1},
+	}
+
+	for i, test := range tests {
+		if actual := compareWALSegments(test.a, test.b); actual != test.expected {
+			t.Fatalf("tests[%d] - expected %d, got %d", i, test.expected, actual)
+		}
+	}
+}
+
+func TestParseLastArchivedWAL(t *testing.T) {
+	tests := []struct {
+		output    string
+		expected  *lastArchivedWAL
+		expectErr bool
+	}{
+		{"", nil, false},
+		{"\n", nil, false},
+		{"000000010000000000000009|1600000000\n",
+			&lastArchivedWAL{Segment: "000000010000000000000009", Time: time.Unix(1600000000, 0)}, false},
+		{"000000010000000000000009", nil, true},
+		{"000000010000000000000009|now", nil, true},
+	}
+
+	for i, test := range tests {
+		archived, err := parseLastArchivedWAL(test.output)
+
+		if (err != nil) != test.expectErr {
+			t.Fatalf("tests[%d] - expected error %t, got %v", i, test.expectErr, err)
+		}
+
+		if !reflect.DeepEqual(archived, test.expected) {
+			t.Fatalf("tests[%d] - expected %v, got %v", i, test.expected, archived)
+		}
+	}
+}
+
+func TestSetRecoverability(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	rpo := 24 * time.Hour
+
+	tests := []struct {
+		stanza       msgs.PgBackRestInfo
+		walArchives  map[string]*walArchive
+		archived     *lastArchivedWAL
+		earliestWAL  string
+		latestTime   time.Time
+		walGaps      int
+		breached     bool
+		expectedSize int64
+	}{
+		// no backups at all
+		{msgs.PgBackRestInfo{}, nil, nil, "", time.Time{}, 0, true, 0},
+		// a full and an incremental backup, all WAL is available
+		{msgs.PgBackRestInfo{
+			Archives: []msgs.PgBackRestInfoArchive{
+				{DB: msgs.PgBackRestInfoDB{ID: 1}, Min: "000000010000000000000002", Max: "000000010000000000000009"},
+			},
+			Backups: []msgs.PgBackRestInfoBackup{
+				{
+					Label:     "full",
+					Type:      backupTypeFull,
+					Archive:   msgs.PgBackRestInfoBackupArchive{Start: "000000010000000000000002", Stop: "000000010000000000000003"},
+					Database:  msgs.PgBackRestInfoDB{ID: 1},
+					Info:      msgs.PgBackRestInfoBackupInfo{Repository: msgs.PgBackRestInfoBackupInfoRepository{Delta: 1024}},
+					Timestamp: msgs.PgBackRestInfoBackupTimestamp{Stop: now.Add(-30 * time.Hour).Unix()},
+				},
+				{
+					Label:     "incr",
+					Type:      backupTypeIncr,
+					Archive:   msgs.PgBackRestInfoBackupArchive{Start: "000000010000000000000006", Stop: "000000010000000000000007"},
+					Database:  msgs.PgBackRestInfoDB{ID: 1},
+					Info:      msgs.PgBackRestInfoBackupInfo{Repository: msgs.PgBackRestInfoBackupInfoRepository{Delta: 1024}},
+					Timestamp: msgs.PgBackRestInfoBackupTimestamp{Stop: now.Add(-time.Hour).Unix()},
+				},
+			},
+		}, nil, nil, "000000010000000000000003", now.Add(-time.Hour), 0, false, 2048},
+		// the oldest backup is missing its WAL, so recovery starts from the next
+		{msgs.PgBackRestInfo{
+			Archives: []msgs.PgBackRestInfoArchive{
+				{DB: msgs.PgBackRestInfoDB{ID: 1}, Min: "000000010000000000000005", Max: "000000010000000000000009"},
+			},
+			Backups: []msgs.PgBackRestInfoBackup{
+				{
+					Label:     "full1",
+					Type:      backupTypeFull,
+					Archive:   msgs.PgBackRestInfoBackupArchive{Start: "000000010000000000000002", Stop: "000000010000000000000003"},
+					Database:  msgs.PgBackRestInfoDB{ID: 1},
+					Info:      msgs.PgBackRestInfoBackupInfo{Repository: msgs.PgBackRestInfoBackupInfoRepository{Delta: 1024}},
+					Timestamp: msgs.PgBackRestInfoBackupTimestamp{Stop: now.Add(-30 * time.Hour).Unix()},
+				},
+				{
+					Label:     "full2",
+					Type:      backupTypeFull,
+					Archive:   msgs.PgBackRestInfoBackupArchive{Start: "000000010000000000000006", Stop: "000000010000000000000007"},
+					Database:  msgs.PgBackRestInfoDB{ID: 1},
+					Info:      msgs.PgBackRestInfoBackupInfo{Repository: msgs.PgBackRestInfoBackupInfoRepository{Delta: 1024}},
+					Timestamp: msgs.PgBackRestInfoBackupTimestamp{Stop: now.Add(-time.Hour).Unix()},
+				},
+			},
+		}, nil, nil, "000000010000000000000007", now.Add(-time.Hour), 1, true, 2048},
+		// the latest backup is older than the RPO, and the end of the archive
+		// is unknown
+		{msgs.PgBackRestInfo{
+			Archives: []msgs.PgBackRestInfoArchive{
+				{DB: msgs.PgBackRestInfoDB{ID: 1}, Min: "000000010000000000000002", Max: "000000010000000000000009"},
+			},
+			Backups: []msgs.PgBackRestInfoBackup{
+				{
+					Label:     "full",
+					Type:      backupTypeFull,
+					Archive:   msgs.PgBackRestInfoBackupArchive{Start: "000000010000000000000002", Stop: "000000010000000000000003"},
+					Database:  msgs.PgBackRestInfoDB{ID: 1},
+					Info:      msgs.PgBackRestInfoBackupInfo{Repository: msgs.PgBackRestInfoBackupInfoRepository{Delta: 1024}},
+					Timestamp: msgs.PgBackRestInfoBackupTimestamp{Stop: now.Add(-48 * time.Hour).Unix()},
+				},
+			},
+		}, nil, nil, "000000010000000000000003", now.Add(-48 * time.Hour), 0, true, 1024},
+		// the latest backup is older than the RPO, but the archive is current
+		{msgs.PgBackRestInfo{
+			Archives: []msgs.PgBackRestInfoArchive{
+				{DB: msgs.PgBackRestInfoDB{ID: 1}, Min: "000000010000000000000002", Max: "000000010000000000000009"},
+			},
+			Backups: []msgs.PgBackRestInfoBackup{
+				{
+					Label:     "full",
+					Type:      backupTypeFull,
+					Archive:   msgs.PgBackRestInfoBackupArchive{Start: "000000010000000000000002", Stop: "000000010000000000000003"},
+					Database:  msgs.PgBackRestInfoDB{ID: 1},
+					Info:      msgs.PgBackRestInfoBackupInfo{Repository: msgs.PgBackRestInfoBackupInfoRepository{Delta: 1024}},
+					Timestamp: msgs.PgBackRestInfoBackupTimestamp{Stop: now.Add(-48 * time.Hour).Unix()},
+				},
+			},
+		}, nil, &lastArchivedWAL{Segment: "000000010000000000000009", Time: now.Add(-time.Minute)},
+			"000000010000000000000003", now.Add(-time.Minute), 0, false, 1024},
+		// the archive of the repository is behind PostgreSQL, so its end is
+		// unknown
+		{msgs.PgBackRestInfo{
+			Archives: []msgs.PgBackRestInfoArchive{
+				{DB: msgs.PgBackRestInfoDB{ID: 1}, Min: "000000010000000000000002", Max: "000000010000000000000009"},
+			},
+			Backups: []msgs.PgBackRestInfoBackup{
+				{
+					Label:     "full",
+					Type:      backupTypeFull,
+					Archive:   msgs.PgBackRestInfoBackupArchive{Start: "000000010000000000000002", Stop: "000000010000000000000003"},
+					Database:  msgs.PgBackRestInfoDB{ID: 1},
+					Info:      msgs.PgBackRestInfoBackupInfo{Repository: msgs.PgBackRestInfoBackupInfoRepository{Delta: 1024}},
+					Timestamp: msgs.PgBackRestInfoBackupTimestamp{Stop: now.Add(-48 * time.Hour).Unix()},
+				},
+			},
+		}, nil, &lastArchivedWAL{Segment: "000000020000000000000001", Time: now.Add(-time.Minute)},
+			"000000010000000000000003", now.Add(-48 * time.Hour), 0, true, 1024},
+	}
+
+	for i, test := range tests {
+		detail := msgs.RecoverabilityDetail{}
+		setRecoverability(&detail, test.stanza, test.walArchives, test.archived, now, rpo)
+
+		if detail.EarliestRecoverableWAL != test.earliestWAL {
+			t.Fatalf("tests[%d] - expected earliest WAL %q, got %q", i, test.earliestWAL, detail.EarliestRecoverableWAL)
+		}
+
+		if (detail.LatestRecoverableTime == nil) != test.latestTime.IsZero() ||
+			(detail.LatestRecoverableTime != nil && !detail.LatestRecoverableTime.Equal(test.latestTime)) {
+			t.Fatalf("tests[%d] - expected latest recoverable time %v, got %v", i, test.latestTime, detail.LatestRecoverableTime)
+		}
+
+		if len(detail.WALGaps) != test.walGaps {
+			t.Fatalf("tests[%d] - expected %d WAL gaps, got %v", i, test.walGaps, detail.WALGaps)
+		}
+
+		if detail.RPOBreached != test.breached {
+			t.Fatalf("tests[%d] - expected RPO breached %t, got %t: %v", i, test.breached, detail.RPOBreached, detail.RPOBreaches)
+		}
+
+		if detail.RepoSize != test.expectedSize {
+			t.Fatalf("tests[%d] - expected repo size %d, got %d", i, test.expectedSize, detail.RepoSize)
+		}
+	}
+}
+
+func TestSetRecoverabilityWALHoles(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	rpo := 24 * time.Hour
+
+	stanza := msgs.PgBackRestInfo{
+		Archives: []msgs.PgBackRestInfoArchive{
+			{DB: msgs.PgBackRestInfoDB{ID: 1}, ID: "12-1", Min: "000000010000000000000002", Max: "00000001000000000000000C"},
+		},
+		Backups: []msgs.PgBackRestInfoBackup{
+			{
+				Label:     "full",
+				Type:      backupTypeFull,
+				Archive:   msgs.PgBackRestInfoBackupArchive{Start: "000000010000000000000002", Stop: "000000010000000000000003"},
+				Database:  msgs.PgBackRestInfoDB{ID: 1},
+				Info:      msgs.PgBackRestInfoBackupInfo{Repository: msgs.PgBackRestInfoBackupInfoRepository{Delta: 1024}},
+				Timestamp: msgs.PgBackRestInfoBackupTimestamp{Stop: now.Add(-30 * time.Hour).Unix()},
+			},
+			{
+				Label:     "incr",
+				Type:      backupTypeIncr,
+				Archive:   msgs.PgBackRestInfoBackupArchive{Start: "000000010000000000000006", Stop: "000000010000000000000007"},
+				Database:  msgs.PgBackRestInfoDB{ID: 1},
+				Info:      msgs.PgBackRestInfoBackupInfo{Repository: msgs.PgBackRestInfoBackupInfoRepository{Delta: 1024}},
+				Timestamp: msgs.PgBackRestInfoBackupTimestamp{Stop: now.Add(-2 * time.Hour).Unix()},
+			},
+		},
+	}
+	archived := &lastArchivedWAL{Segment: "00000001000000000000000C", Time: now.Add(-time.Minute)}
+
+	tests := []struct {
+		segments    []string
+		earliestWAL string
+		latestWAL   string
+		latestTime  time.Time
+		walGaps     []string
+		breached    bool
+	}{
+		// the archive is complete, so the latest recoverable point is when the
+		// primary archived its last segment
+		{
+			segments: []string{
+				"000000010000000000000002", "000000010000000000000003", "000000010000000000000004",
+				"000000010000000000000005", "000000010000000000000006", "000000010000000000000007",
+				"000000010000000000000008", "000000010000000000000009", "00000001000000000000000A",
+				"00000001000000000000000B", "00000001000000000000000C",
+			},
+			earliestWAL: "000000010000000000000003",
+			latestWAL:   "00000001000000000000000C",
+			latestTime:  now.Add(-time.Minute),
+			walGaps:     []string{},
+		},
+		// a hole after the latest backup stops the recovery at the hole
+		{
+			segments: []string{
+				"000000010000000000000002", "000000010000000000000003", "000000010000000000000004",
+				"000000010000000000000005", "000000010000000000000006", "000000010000000000000007",
+				"000000010000000000000008", "000000010000000000000009", "00000001000000000000000C",
+			},
+			earliestWAL: "000000010000000000000003",
+			latestWAL:   "000000010000000000000009",
+			latestTime:  now.Add(-2 * time.Hour),
+			walGaps:     []string{"00000001000000000000000A-00000001000000000000000B (missing from archive 12-1)"},
+			breached:    true,
+		},
+		// a hole in the WAL of the latest backup means that it cannot be
+		// restored, so the recovery starts from, and stops after, the full backup
+		{
+			segments: []string{
+				"000000010000000000000002", "000000010000000000000003", "000000010000000000000004",
+				"000000010000000000000005", "000000010000000000000007", "000000010000000000000008",
+				"000000010000000000000009", "00000001000000000000000A", "00000001000000000000000B",
+				"00000001000000000000000C",
+			},
+			earliestWAL: "000000010000000000000003",
+			latestWAL:   "000000010000000000000005",
+			latestTime:  now.Add(-30 * time.Hour),
+			walGaps:     []string{"000000010000000000000006-000000010000000000000006 (missing from archive 12-1)"},
+			breached:    true,
+		},
+	}
+
+	for i, test := range tests {
+		detail := msgs.RecoverabilityDetail{}
+		setRecoverability(&detail, stanza, map[string]*walArchive{
+			"12-1": {Segments: test.segments, Size: 4096},
+		}, archived, now, rpo)
+
+		if detail.EarliestRecoverableWAL != test.earliestWAL {
+			t.Fatalf("tests[%d] - expected earliest WAL %q, got %q", i, test.earliestWAL, detail.EarliestRecoverableWAL)
+		}
+
+		if detail.LatestRecoverableWAL != test.latestWAL {
+			t.Fatalf("tests[%d] - expected latest WAL %q, got %q", i, test.latestWAL, detail.LatestRecoverableWAL)
+		}
+
+		if detail.LatestRecoverableTime == nil || !detail.LatestRecoverableTime.Equal(test.latestTime) {
+			t.Fatalf("tests[%d] - expected latest recoverable time %v, got %v", i, test.latestTime, detail.LatestRecoverableTime)
+		}
+
+		if !reflect.DeepEqual(detail.WALGaps, test.walGaps) {
+			t.Fatalf("tests[%d] - expected WAL gaps %v, got %v", i, test.walGaps, detail.WALGaps)
+		}
+
+		if detail.RPOBreached != test.breached {
+			t.Fatalf("tests[%d] - expected RPO breached %t, got %t: %v", i, test.breached, detail.RPOBreached, detail.RPOBreaches)
+		}
+
+		// the archive counts towards the size of the repository
+		if detail.RepoSize != 2048+4096 {
+			t.Fatalf("tests[%d] - expected repo size %d, got %d", i, 2048+4096, detail.RepoSize)
+		}
+	}
+}
+
+func TestGetWALHoles(t *testing.T) {
+	tests := []struct {
+		segments []string
+		expected []walHole
+	}{
+		{nil, []walHole{}},
+		{[]string{"000000010000000000000002", "000000010000000000000003", "000000010000000000000005",
+			"000000010000000000000008"}, []walHole{
+			{Start: "000000010000000000000004", Stop: "000000010000000000000004", Previous: "000000010000000000000003"},
+			{Start: "000000010000000000000006", Stop: "000000010000000000000007", Previous: "000000010000000000000005"},
+		}},
+		// a new timeline continues at the segment it branched off at
+		{[]string{"000000020000000000000005", "000000010000000000000004", "000000010000000000000005",
+			"000000020000000000000006"}, []walHole{}},
+		// the segments continue in the next log
+		{[]string{"0000000100000000000000FE", "0000000100000000000000FF", "000000010000000100000000"},
+			[]walHole{}},
+		{[]string{"0000000100000000000000FF", "000000010000000100000002"}, []walHole{
+			{Start: "000000010000000100000000", Stop: "000000010000000100000001", Previous: "0000000100000000000000FF"},
+		}},
+		{[]string{"0000000100000000000000FE", "000000010000000100000000", "0000000100000001000000FF"}, []walHole{
+			{Start: "0000000100000000000000FF", Stop: "0000000100000000000000FF", Previous: "0000000100000000000000FE"},
+			{Start: "000000010000000100000001", Stop: "0000000100000001000000FE", Previous: "000000010000000100000000"},
+		}},
+	}
+
+	for i, test := range tests {
+		if holes := getWALHoles(test.segments); !reflect.DeepEqual(holes, test.expected) {
+			t.Fatalf("tests[%d] - expected %v, got %v", i, test.expected, holes)
+		}
+	}
+}
+
+func TestParseWALArchive(t *testing.T) {
+	output := `{".":{"type":"path"},"0000000100000000":{"type":"path"},` +
+		`"0000000100000000/000000010000000000000003-b2c1a9f3.gz":{"type":"file","size":100,"time":1600000000},` +
+		`"0000000100000000/000000010000000000000002-a1b0f8e2.gz":{"type":"file","size":100,"time":1600000000},` +
+		`"0000000100000000/000000010000000000000002.00000028.backup":{"type":"file","size":10,"time":1600000000},` +
+		`"0000000100000000/000000010000000000000004.partial-c3d2b0a4.gz":{"type":"file","size":50,"time":1600000000},` +
+		`"00000002.history":{"type":"file","size":5,"time":1600000000}}`
+
+	wal, err := parseWALArchive(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"000000010000000000000002", "000000010000000000000003"}
+	if !reflect.DeepEqual(wal.Segments, expected) {
+		t.Fatalf("expected segments %v, got %v", expected, wal.Segments)
+	}
+
+	if wal.Size != 265 {
+		t.Fatalf("expected size 265, got %d", wal.Size)
+	}
+
+	if _, err := parseWALArchive("not json"); err == nil {
+		t.Fatal("expected an error for output that is not JSON")
+	}
+}
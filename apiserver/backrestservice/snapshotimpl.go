@@ -0,0 +1,204 @@
+package backrestservice
+
+/*
+Copyright 2020 Crunchy Data Solutions, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/apiserver"
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	log "github.com/sirupsen/logrus"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// snapshotNameTimeFormat is the format of the timestamp that makes up the name
+// of a snapshot backup, which is prefixed with the name of the cluster
+const snapshotNameTimeFormat = "20060102-150405"
+
+// CreateSnapshotBackup starts a snapshot backup of each of the clusters in the
+// request
+func CreateSnapshotBackup(request msgs.CreateSnapshotBackupRequest, pgouser string) msgs.CreateSnapshotBackupResponse {
+	response := msgs.CreateSnapshotBackupResponse{
+		Results: []string{},
+		Status:  msgs.Status{Code: msgs.Ok},
+	}
+
+	clusters, err := getSnapshotClusters(request.Namespace, request.Selector, request.Args)
+	if err != nil {
+		response.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		return response
+	}
+
+	// backups from standby clusters are not allowed, as the cluster is following
+	// a remote primary, which is responsible for its own backups
+	if hasStandby, standbyClusters := apiserver.PGClusterListHasStandby(crv1.PgclusterList{Items: clusters}); hasStandby {
+		response.Status = msgs.Status{Code: msgs.Error, Msg: fmt.Sprintf("Request rejected, unable to "+
+			"create backups for clusters %s: %s.", strings.Join(standbyClusters, ","),
+			apiserver.ErrStandbyNotAllowed.Error())}
+		return response
+	}
+
+	// validate all of the clusters before starting any of the backups
+	for _, cluster := range clusters {
+		if err := validateSnapshotBackup(&cluster); err != nil {
+			response.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+			return response
+		}
+	}
+
+	snapshotClass := request.SnapshotClass
+
+	if snapshotClass == "" {
+		snapshotClass = apiserver.Pgo.Cluster.SnapshotClass
+	}
+
+	now := time.Now()
+
+	for _, cluster := range clusters {
+		snapshotName := fmt.Sprintf("%s-%s", cluster.Name, now.Format(snapshotNameTimeFormat))
+
+		task := &crv1.Pgtask{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name: snapshotName,
+				Labels: map[string]string{
+					config.LABEL_PG_CLUSTER: cluster.Name,
+					config.LABEL_PGOUSER:    pgouser,
+				},
+			},
+			Spec: crv1.PgtaskSpec{
+				Name:     snapshotName,
+				TaskType: crv1.PgtaskSnapshotBackup,
+				Parameters: map[string]string{
+					config.LABEL_PG_CLUSTER:     cluster.Name,
+					config.LABEL_PGO_SNAPSHOT:   snapshotName,
+					config.LABEL_SNAPSHOT_CLASS: snapshotClass,
+				},
+			},
+		}
+
+		if err := kubeapi.Createpgtask(apiserver.RESTClient, task, request.Namespace); err != nil {
+			log.Error(err)
+			response.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+			return response
+		}
+
+		response.Results = append(response.Results,
+			fmt.Sprintf("started snapshot backup %s of cluster %s", snapshotName, cluster.Name))
+	}
+
+	return response
+}
+
+// ShowSnapshotBackups returns the catalog of snapshot backups of each of the
+// clusters in the request
+func ShowSnapshotBackups(request msgs.ShowSnapshotBackupRequest) msgs.ShowSnapshotBackupResponse {
+	response := msgs.ShowSnapshotBackupResponse{
+		Results: []msgs.SnapshotBackupDetail{},
+		Status:  msgs.Status{Code: msgs.Ok},
+	}
+
+	clusters, err := getSnapshotClusters(request.Namespace, request.Selector, request.Args)
+	if err != nil {
+		response.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		return response
+	}
+
+	for _, cluster := range clusters {
+		for _, snapshot := range cluster.Status.Snapshots {
+			response.Results = append(response.Results, msgs.SnapshotBackupDetail{
+				ClusterName:         cluster.Name,
+				CreationTime:        snapshot.CreationTime.Time,
+				Name:                snapshot.Name,
+				PGDataSnapshot:      snapshot.PGDataSnapshot,
+				StartLSN:            snapshot.StartLSN,
+				StopLSN:             snapshot.StopLSN,
+				TablespaceSnapshots: snapshot.TablespaceSnapshots,
+				VolumeSnapshotClass: snapshot.VolumeSnapshotClass,
+			})
+		}
+	}
+
+	return response
+}
+
+// getSnapshotClusters returns the clusters that match either the selector or
+// the list of cluster names. It is an error if any of the named clusters does
+// not exist
+func getSnapshotClusters(namespace, selector string, clusterNames []string) ([]crv1.Pgcluster, error) {
+	if selector != "" {
+		clusterList := crv1.PgclusterList{}
+
+		if err := kubeapi.GetpgclustersBySelector(apiserver.RESTClient, &clusterList, selector,
+			namespace); err != nil {
+			return nil, err
+		}
+
+		if len(clusterList.Items) == 0 {
+			return nil, fmt.Errorf("no clusters found with selector %s", selector)
+		}
+
+		return clusterList.Items, nil
+	}
+
+	clusters := []crv1.Pgcluster{}
+
+	for _, clusterName := range clusterNames {
+		cluster := crv1.Pgcluster{}
+
+		if found, err := kubeapi.Getpgcluster(apiserver.RESTClient, &cluster, clusterName, namespace); !found {
+			return nil, fmt.Errorf("%s was not found, verify cluster name", clusterName)
+		} else if err != nil {
+			return nil, err
+		}
+
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters, nil
+}
+
+// validateSnapshotBackup checks that a snapshot backup can be taken of a
+// cluster. The cluster has to be running, and pgBackRest needs to be enabled,
+// as the WAL that makes the snapshot consistent is replayed from its archive.
+// The VolumeSnapshots can only be taken of dynamically provisioned volumes
+func validateSnapshotBackup(cluster *crv1.Pgcluster) error {
+	if cluster.Labels[config.LABEL_BACKREST] != "true" {
+		return fmt.Errorf("%s does not have pgbackrest enabled", cluster.Name)
+	}
+
+	if cluster.Status.State == crv1.PgclusterStateShutdown {
+		return fmt.Errorf("%s is shut down", cluster.Name)
+	}
+
+	if cluster.Spec.PrimaryStorage.StorageType != "dynamic" {
+		return fmt.Errorf("%s does not use dynamic storage, which is required for snapshot backups",
+			cluster.Name)
+	}
+
+	for tablespaceName, storage := range cluster.Spec.TablespaceMounts {
+		if storage.StorageType != "dynamic" {
+			return fmt.Errorf("tablespace %s of %s does not use dynamic storage, which is required "+
+				"for snapshot backups", tablespaceName, cluster.Name)
+		}
+	}
+
+	return nil
+}
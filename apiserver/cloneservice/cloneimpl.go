@@ -26,14 +26,17 @@ import (
 	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
 	"github.com/crunchydata/postgres-operator/config"
 	"github.com/crunchydata/postgres-operator/kubeapi"
+	snapshotoperator "github.com/crunchydata/postgres-operator/operator/snapshot"
 	"github.com/crunchydata/postgres-operator/util"
 
 	log "github.com/sirupsen/logrus"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-//  Clone allows a user to clone a cluster into a new deployment
-func Clone(request *msgs.CloneRequest, namespace, pgouser string) msgs.CloneResponse {
+//  Clone allows a user to clone a cluster into a new deployment. The new
+// cluster is created in "targetNamespace", which may be the same as the
+// namespace of the source cluster
+func Clone(request *msgs.CloneRequest, namespace, targetNamespace, pgouser string) msgs.CloneResponse {
 	log.Debugf("clone called with ")
 
 	// set up the response here
@@ -70,7 +73,7 @@ func Clone(request *msgs.CloneRequest, namespace, pgouser string) msgs.CloneResp
 	// now, let's ensure the target pgCluster does *not* exist
 	targetPgcluster := crv1.Pgcluster{}
 	targetPgclusterExists, _ := kubeapi.Getpgcluster(apiserver.RESTClient,
-		&targetPgcluster, request.TargetClusterName, namespace)
+		&targetPgcluster, request.TargetClusterName, targetNamespace)
 
 	if targetPgclusterExists {
 		response.Status.Code = msgs.Error
@@ -80,11 +83,12 @@ func Clone(request *msgs.CloneRequest, namespace, pgouser string) msgs.CloneResp
 	}
 
 	// finally, let's make sure there is not already a task in progress for
-	// making the clone
+	// making the clone. The clone tasks are always created in the namespace of
+	// the target cluster
 	selector := fmt.Sprintf("%s=true,pg-cluster=%s", config.LABEL_PGO_CLONE, request.TargetClusterName)
 	taskList := crv1.PgtaskList{}
 
-	if err := kubeapi.GetpgtasksBySelector(apiserver.RESTClient, &taskList, selector, namespace); err != nil {
+	if err := kubeapi.GetpgtasksBySelector(apiserver.RESTClient, &taskList, selector, targetNamespace); err != nil {
 		log.Error(err)
 		response.Status.Code = msgs.Error
 		response.Status.Msg = fmt.Sprintf("Could not clone cluster: could not validate %s", err.Error())
@@ -102,7 +106,7 @@ func Clone(request *msgs.CloneRequest, namespace, pgouser string) msgs.CloneResp
 
 	// create the workflow task to track how this is progressing
 	uid := util.RandStringBytesRmndr(4)
-	workflowID, err := createWorkflowTask(request.TargetClusterName, uid, namespace)
+	workflowID, err := createWorkflowTask(request.TargetClusterName, uid, targetNamespace)
 
 	if err != nil {
 		response.Status.Code = msgs.Error
@@ -114,21 +118,28 @@ func Clone(request *msgs.CloneRequest, namespace, pgouser string) msgs.CloneResp
 	cloneTask := util.CloneTask{
 		BackrestPVCSize:       request.BackrestPVCSize,
 		BackrestStorageSource: request.BackrestStorageSource,
+		BackupSet:             request.BackupSet,
 		EnableMetrics:         request.EnableMetrics,
 		PGOUser:               pgouser,
 		PVCSize:               request.PVCSize,
+		RecoveryTarget:        request.RecoveryTarget,
+		RecoveryTargetType:    request.RecoveryTargetType,
+		RotateCipherPass:      request.RotateCipherPass,
+		Snapshot:              request.Snapshot,
 		SourceClusterName:     request.SourceClusterName,
+		SourceNamespace:       namespace,
 		TargetClusterName:     request.TargetClusterName,
 		TaskStepLabel:         config.LABEL_PGO_CLONE_STEP_1,
 		TaskType:              crv1.PgtaskCloneStep1,
 		Timestamp:             time.Now(),
+		TTL:                   request.TTL,
 		WorkflowID:            workflowID,
 	}
 
 	task := cloneTask.Create()
 
 	// create the Pgtask CRD for the clone task
-	err = kubeapi.Createpgtask(apiserver.RESTClient, task, namespace)
+	err = kubeapi.Createpgtask(apiserver.RESTClient, task, targetNamespace)
 
 	if err != nil {
 		response.Status.Code = msgs.Error
@@ -137,6 +148,7 @@ func Clone(request *msgs.CloneRequest, namespace, pgouser string) msgs.CloneResp
 	}
 
 	response.TargetClusterName = request.TargetClusterName
+	response.TargetNamespace = targetNamespace
 	response.WorkflowID = workflowID
 
 	return response
@@ -217,11 +229,60 @@ func validateCloneRequest(request *msgs.CloneRequest, cluster crv1.Pgcluster) er
 		}
 	}
 
+	// ensure the TTL of the target cluster, if any, is a valid duration
+	if request.TTL != "" {
+		if _, err := apiserver.ValidateTTL(request.TTL); err != nil {
+			return fmt.Errorf(apiserver.ErrMessageTTL, request.TTL, err.Error())
+		}
+	}
+
 	// clone is a form of restore, so validate using ValidateBackrestStorageTypeOnBackupRestore
 	if err := util.ValidateBackrestStorageTypeOnBackupRestore(request.BackrestStorageSource,
 		cluster.Spec.UserLabels[config.LABEL_BACKREST_STORAGE_TYPE], true); err != nil {
 		return err
 	}
 
+	// if a specific pgBackRest backup is being restored from, ensure it looks
+	// like a pgBackRest backup label
+	if err := util.ValidateCloneBackupSet(request.BackupSet); err != nil {
+		return err
+	}
+
+	// if restoring to a specific point-in-time, ensure the target is valid
+	if err := util.ValidateCloneRecoveryTarget(request.RecoveryTargetType,
+		request.RecoveryTarget); err != nil {
+		return err
+	}
+
+	// a new passphrase can only be used if the repository is encrypted. The
+	// repositories of the target cluster start from scratch: its "local"
+	// repository is re-created, and its S3, GCS or Azure repository is kept in
+	// a path of its own
+	if request.RotateCipherPass && !util.IsBackrestCipherEnabled(cluster) {
+		return errors.New("the pgBackRest repository of the source cluster is not encrypted")
+	}
+
+	// a clone from a snapshot backup replays the WAL from the pgBackRest
+	// repository of the source cluster, so it cannot be combined with a backup
+	// set or a new repository. As a PVC can only be provisioned from a
+	// VolumeSnapshot in its own namespace, the clone has to be created in the
+	// namespace of the source cluster
+	if request.Snapshot != "" {
+		if _, found := snapshotoperator.FindSnapshot(&cluster, request.Snapshot); !found {
+			return fmt.Errorf("snapshot backup %s of cluster %s was not found", request.Snapshot,
+				cluster.Name)
+		}
+
+		if request.BackupSet != "" || request.RotateCipherPass {
+			return errors.New("a snapshot backup cannot be combined with a pgBackRest backup set " +
+				"or a new pgBackRest cipher passphrase")
+		}
+
+		if request.TargetNamespace != "" && request.TargetNamespace != cluster.Namespace {
+			return errors.New("a clone from a snapshot backup has to be created in the namespace " +
+				"of the source cluster")
+		}
+	}
+
 	return nil
 }
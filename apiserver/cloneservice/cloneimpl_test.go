@@ -0,0 +1,71 @@
+package cloneservice
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"testing"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+	"github.com/crunchydata/postgres-operator/config"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateCloneRequestRotateCipherPass(t *testing.T) {
+	tests := []struct {
+		cipherType    string
+		storageType   string
+		storageSource string
+		rotate        bool
+		err           bool
+	}{
+		{"aes-256-cbc", "local", "", true, false},
+		{"aes-256-cbc", "local,s3", "s3", true, false},
+		{"aes-256-cbc", "s3", "s3", true, false},
+		{"aes-256-cbc", "local,gcs", "gcs", true, false},
+		{"aes-256-cbc", "azure", "azure", true, false},
+		{"", "local", "", true, true},
+		{"none", "local,s3", "s3", true, true},
+		{"", "local,s3", "s3", false, false},
+	}
+
+	for i, test := range tests {
+		cluster := crv1.Pgcluster{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo"},
+			Spec: crv1.PgclusterSpec{
+				BackrestCipherType: test.cipherType,
+				UserLabels:         map[string]string{config.LABEL_BACKREST_STORAGE_TYPE: test.storageType},
+			},
+		}
+
+		request := &msgs.CloneRequest{
+			SourceClusterName:     "hippo",
+			TargetClusterName:     "rhino",
+			BackrestStorageSource: test.storageSource,
+			RotateCipherPass:      test.rotate,
+		}
+
+		err := validateCloneRequest(request, cluster)
+
+		if test.err && err == nil {
+			t.Fatalf("tests[%d] - expected an error", i)
+		}
+
+		if !test.err && err != nil {
+			t.Fatalf("tests[%d] - expected no error, got %s", i, err)
+		}
+	}
+}
@@ -71,6 +71,26 @@ func CloneHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := Clone(&request, ns, username)
+	// if the clone is going into a different namespace, ensure that the user has
+	// access to that namespace as well. Otherwise, the clone is created in the
+	// same namespace as the source cluster
+	targetNamespace := ns
+
+	if request.TargetNamespace != "" {
+		targetNamespace, err = apiserver.GetNamespace(apiserver.Clientset, username, request.TargetNamespace)
+
+		if err != nil {
+			resp := msgs.CloneResponse{
+				Status: msgs.Status{
+					Code: msgs.Error,
+					Msg:  err.Error(),
+				},
+			}
+			json.NewEncoder(w).Encode(resp)
+			return
+		}
+	}
+
+	resp := Clone(&request, ns, targetNamespace, username)
 	json.NewEncoder(w).Encode(resp)
 }
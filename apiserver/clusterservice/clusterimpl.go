@@ -20,26 +20,39 @@ import (
 	"fmt"
 
 	"io/ioutil"
+	"net"
 	"strconv"
 	"strings"
 	"time"
 
 	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
 	"github.com/crunchydata/postgres-operator/apiserver"
+	"github.com/crunchydata/postgres-operator/apiserver/profileservice"
 	log "github.com/sirupsen/logrus"
 
 	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
 	"github.com/crunchydata/postgres-operator/config"
 	"github.com/crunchydata/postgres-operator/kubeapi"
+	"github.com/crunchydata/postgres-operator/operator"
+	clusteroperator "github.com/crunchydata/postgres-operator/operator/cluster"
+	"github.com/crunchydata/postgres-operator/tlsutil"
 	"github.com/crunchydata/postgres-operator/util"
 
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 
 	v1 "k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation"
 )
 
+var (
+	// backrestGCSKeyTypes are the types of GCS keys supported by pgBackRest
+	backrestGCSKeyTypes = []string{"service", "token"}
+	// backrestAzureURIStyles are the Azure URI styles supported by pgBackRest
+	backrestAzureURIStyles = []string{"host", "path"}
+)
+
 // DeleteCluster ...
 func DeleteCluster(name, selector string, deleteData, deleteBackups bool, ns, pgouser string) msgs.DeleteClusterResponse {
 	var err error
@@ -74,6 +87,15 @@ func DeleteCluster(name, selector string, deleteData, deleteBackups bool, ns, pg
 		return response
 	}
 
+	// refuse the whole request if any of the clusters is protected, so that a
+	// selector that matches more than intended does not delete anything
+	if protected := apiserver.FindProtectedClusters(clusterList); len(protected) > 0 {
+		response.Status.Code = msgs.Error
+		response.Status.Msg = fmt.Sprintf("deletion protection is enabled for %s, disable it with "+
+			"\"pgo update cluster --disable-deletion-protection\" first", strings.Join(protected, ", "))
+		return response
+	}
+
 	for _, cluster := range clusterList.Items {
 
 		log.Debugf("deleting cluster %s", cluster.Spec.Name)
@@ -161,6 +183,8 @@ func ShowCluster(name, selector, ccpimagetag, ns string, allflag bool) msgs.Show
 
 		// capture whether or not the cluster is currently a standby cluster
 		detail.Standby = c.Spec.Standby
+		detail.TLS = getClusterTLS(&c, ns)
+		detail.HBA = clusteroperator.GetHBARules(&c, apiserver.Pgo.Cluster.HBA)
 
 		if ccpimagetag == "" {
 			response.Results = append(response.Results, detail)
@@ -173,6 +197,40 @@ func ShowCluster(name, selector, ccpimagetag, ns string, allflag bool) msgs.Show
 
 }
 
+// getClusterTLS returns the expiration times of the certificate of a
+// TLS-enabled cluster and of its CA, or nil if the cluster is not TLS-enabled
+func getClusterTLS(cluster *crv1.Pgcluster, ns string) *msgs.ShowClusterTLS {
+	if !cluster.Spec.TLS.IsTLSEnabled() {
+		return nil
+	}
+
+	return &msgs.ShowClusterTLS{
+		Auto:                  cluster.Spec.TLS.Auto,
+		CertificateExpiration: getCertificateExpiration(cluster.Spec.TLS.TLSSecret, v1.TLSCertKey, ns),
+		CAExpiration:          getCertificateExpiration(cluster.Spec.TLS.CASecret, "ca.crt", ns),
+		CertAuth:              cluster.Spec.TLS.CertAuth,
+		CertAuthUsers:         cluster.Spec.TLS.CertAuthUsers,
+	}
+}
+
+// getCertificateExpiration returns the expiration time of the certificate in a
+// key of a secret. If the key holds a bundle of certificates, the first one is
+// used. The time is zero if there is no such certificate
+func getCertificateExpiration(secretName, key, ns string) time.Time {
+	secret, found, _ := kubeapi.GetSecret(apiserver.Clientset, secretName, ns)
+	if !found {
+		return time.Time{}
+	}
+
+	cert, err := tlsutil.ParsePEMEncodedCert(secret.Data[key])
+	if err != nil {
+		log.Errorf("could not parse %s of secret %s: %v", key, secretName, err)
+		return time.Time{}
+	}
+
+	return cert.NotAfter
+}
+
 func getDeployments(cluster *crv1.Pgcluster, ns string) ([]msgs.ShowClusterDeployment, error) {
 	output := make([]msgs.ShowClusterDeployment, 0)
 
@@ -489,6 +547,18 @@ func CreateCluster(request *msgs.CreateClusterRequest, ns, pgouser string) msgs.
 		return resp
 	}
 
+	// settings that are not set by the request are taken from the profile, if
+	// one is used
+	if request.Profile != "" {
+		profile, err := profileservice.GetProfile(apiserver.Clientset, request.Profile)
+		if err != nil {
+			resp.Status.Code = msgs.Error
+			resp.Status.Msg = err.Error()
+			return resp
+		}
+		profileservice.MergeProfile(request, profile)
+	}
+
 	if request.ReplicaCount < 0 {
 		resp.Status.Code = msgs.Error
 		resp.Status.Msg = "invalid replica-count , should be greater than or equal to 0"
@@ -571,6 +641,65 @@ func CreateCluster(request *msgs.CreateClusterRequest, ns, pgouser string) msgs.
 		}
 	}
 
+	// ensure the TTL of the cluster, if any, is a valid duration
+	if request.TTL != "" {
+		if _, err := apiserver.ValidateTTL(request.TTL); err != nil {
+			resp.Status.Code = msgs.Error
+			resp.Status.Msg = fmt.Sprintf(apiserver.ErrMessageTTL, request.TTL, err.Error())
+			return resp
+		}
+	}
+
+	// ensure the tolerations and the zone spreading, if any, are valid
+	if _, err := config.ParseTolerations(request.Tolerations); err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = err.Error()
+		return resp
+	}
+
+	if err := crv1.ZoneSpreadType(request.ZoneSpread).Validate(); err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = err.Error()
+		return resp
+	}
+
+	// ensure the pg_hba.conf rules, if any, are valid
+	if _, err := config.ParseHBARules(request.HBA); err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = err.Error()
+		return resp
+	}
+
+	// ensure the clients of the NetworkPolicies, if any, are valid
+	if err := validateNetworkPolicy(request.NetworkPolicy != nil && !*request.NetworkPolicy,
+		request.NetworkPolicyNamespaces, request.NetworkPolicySourceRanges); err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = err.Error()
+		return resp
+	}
+
+	// ensure the custom annotations and labels, the replica service type and
+	// the load balancer source ranges, if any, are valid
+	if err := applyClusterMetadata(&crv1.ClusterMetadataSpec{}, request.Metadata); err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = err.Error()
+		return resp
+	}
+
+	if request.ReplicaServiceType != "" {
+		if err := validateServiceType(request.ReplicaServiceType); err != nil {
+			resp.Status.Code = msgs.Error
+			resp.Status.Msg = err.Error()
+			return resp
+		}
+	}
+
+	if err := validateSourceRanges(request.LoadBalancerSourceRanges); err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = err.Error()
+		return resp
+	}
+
 	// validate the storage type for each specified tablespace actually exists.
 	// if a PVCSize is passed in, also validate that it follows the Kubernetes
 	// format
@@ -761,14 +890,23 @@ func CreateCluster(request *msgs.CreateClusterRequest, ns, pgouser string) msgs.
 		}
 	}
 
-	validateConfigPolicies(clusterName, request.Policies, ns)
+	// on a dry run, the objects that would be created are collected instead
+	dryRunObjects := []runtime.Object{}
+
+	if policiesTask, _ := validateConfigPolicies(clusterName, request.Policies, ns); policiesTask != nil {
+		if request.DryRun {
+			dryRunObjects = append(dryRunObjects, policiesTask)
+		} else {
+			kubeapi.Createpgtask(apiserver.RESTClient, policiesTask, ns)
+		}
+	}
 
 	t := time.Now()
 	newInstance.Spec.PswLastUpdate = t.Format(time.RFC3339)
 
 	// create the user secrets
 	// first, the superuser
-	if secretName, password, err := createUserSecret(request, newInstance, crv1.RootSecretSuffix,
+	if secretName, password, secret, err := createUserSecret(request, newInstance, crv1.RootSecretSuffix,
 		crv1.PGUserSuperuser, request.PasswordSuperuser); err != nil {
 		log.Error(err)
 		resp.Status.Code = msgs.Error
@@ -777,6 +915,10 @@ func CreateCluster(request *msgs.CreateClusterRequest, ns, pgouser string) msgs.
 	} else {
 		newInstance.Spec.RootSecretName = secretName
 
+		if secret != nil {
+			dryRunObjects = append(dryRunObjects, secret)
+		}
+
 		// if the user requests to show system accounts, append it to the list
 		if request.ShowSystemAccounts {
 			user := msgs.CreateClusterDetailUser{
@@ -789,7 +931,7 @@ func CreateCluster(request *msgs.CreateClusterRequest, ns, pgouser string) msgs.
 	}
 
 	// next, the replication user
-	if secretName, password, err := createUserSecret(request, newInstance, crv1.PrimarySecretSuffix,
+	if secretName, password, secret, err := createUserSecret(request, newInstance, crv1.PrimarySecretSuffix,
 		crv1.PGUserReplication, request.PasswordReplication); err != nil {
 		log.Error(err)
 		resp.Status.Code = msgs.Error
@@ -798,6 +940,10 @@ func CreateCluster(request *msgs.CreateClusterRequest, ns, pgouser string) msgs.
 	} else {
 		newInstance.Spec.PrimarySecretName = secretName
 
+		if secret != nil {
+			dryRunObjects = append(dryRunObjects, secret)
+		}
+
 		// if the user requests to show system accounts, append it to the list
 		if request.ShowSystemAccounts {
 			user := msgs.CreateClusterDetailUser{
@@ -811,7 +957,7 @@ func CreateCluster(request *msgs.CreateClusterRequest, ns, pgouser string) msgs.
 
 	// finally, the user from the request and/or default user
 	userSecretSuffix := fmt.Sprintf("-%s%s", newInstance.Spec.User, crv1.UserSecretSuffix)
-	if secretName, password, err := createUserSecret(request, newInstance, userSecretSuffix, newInstance.Spec.User,
+	if secretName, password, secret, err := createUserSecret(request, newInstance, userSecretSuffix, newInstance.Spec.User,
 		request.Password); err != nil {
 		log.Error(err)
 		resp.Status.Code = msgs.Error
@@ -820,6 +966,10 @@ func CreateCluster(request *msgs.CreateClusterRequest, ns, pgouser string) msgs.
 	} else {
 		newInstance.Spec.UserSecretName = secretName
 
+		if secret != nil {
+			dryRunObjects = append(dryRunObjects, secret)
+		}
+
 		user := msgs.CreateClusterDetailUser{
 			Username: newInstance.Spec.User,
 			Password: password,
@@ -831,21 +981,44 @@ func CreateCluster(request *msgs.CreateClusterRequest, ns, pgouser string) msgs.
 	// there's a secret for the monitoring user too
 	newInstance.Spec.CollectSecretName = clusterName + crv1.CollectSecretSuffix
 
-	// Create Backrest secret for S3/SSH Keys:
+	// Create Backrest secret for S3/GCS/Azure/SSH Keys:
 	// We make this regardless if backrest is enabled or not because
 	// the deployment template always tries to mount /sshd volume
 	secretName := fmt.Sprintf("%s-%s", clusterName, config.LABEL_BACKREST_REPO_SECRET)
 	_, _, err = kubeapi.GetSecret(apiserver.Clientset, secretName, request.Namespace)
 	if kerrors.IsNotFound(err) {
-		err := util.CreateBackrestRepoSecrets(apiserver.Clientset,
-			util.BackrestRepoConfig{
-				BackrestS3Key:       request.BackrestS3Key,
-				BackrestS3KeySecret: request.BackrestS3KeySecret,
-				ClusterName:         clusterName,
-				ClusterNamespace:    request.Namespace,
-				OperatorNamespace:   apiserver.PgoNamespace,
-			})
-		if err != nil {
+		// if the repository is encrypted, generate the passphrase that is used
+		// for the new stanza
+		var cipherPass string
+		if util.IsBackrestCipherEnabled(*newInstance) {
+			if cipherPass, err = util.GenerateBackrestCipherPass(); err != nil {
+				resp.Status.Code = msgs.Error
+				resp.Status.Msg = fmt.Sprintf("could not generate backrest cipher passphrase: %s", err)
+				return resp
+			}
+		}
+
+		backrestRepoConfig := util.BackrestRepoConfig{
+			BackrestS3Key:        request.BackrestS3Key,
+			BackrestS3KeySecret:  request.BackrestS3KeySecret,
+			BackrestGCSKey:       request.BackrestGCSKey,
+			BackrestAzureAccount: request.BackrestAzureAccount,
+			BackrestAzureKey:     request.BackrestAzureKey,
+			BackrestCipherPass:   cipherPass,
+			ClusterName:          clusterName,
+			ClusterNamespace:     request.Namespace,
+			OperatorNamespace:    apiserver.PgoNamespace,
+		}
+
+		if request.DryRun {
+			secret, err := util.NewBackrestRepoSecret(apiserver.Clientset, backrestRepoConfig)
+			if err != nil {
+				resp.Status.Code = msgs.Error
+				resp.Status.Msg = fmt.Sprintf("could not create backrest repo secret: %s", err)
+				return resp
+			}
+			dryRunObjects = append(dryRunObjects, secret)
+		} else if err := util.CreateBackrestRepoSecrets(apiserver.Clientset, backrestRepoConfig); err != nil {
 			resp.Status.Code = msgs.Error
 			resp.Status.Msg = fmt.Sprintf("could not create backrest repo secret: %s", err)
 			return resp
@@ -857,13 +1030,23 @@ func CreateCluster(request *msgs.CreateClusterRequest, ns, pgouser string) msgs.
 	}
 
 	//create a workflow for this new cluster
-	id, err = createWorkflowTask(clusterName, ns, pgouser)
+	workflowTask, err := newWorkflowTask(clusterName, ns, pgouser)
 	if err != nil {
 		log.Error(err)
 		resp.Status.Code = msgs.Error
 		resp.Status.Msg = err.Error()
 		return resp
 	}
+	id = workflowTask.Spec.Parameters[crv1.PgtaskWorkflowID]
+
+	if request.DryRun {
+		dryRunObjects = append(dryRunObjects, workflowTask)
+	} else if err := kubeapi.Createpgtask(apiserver.RESTClient, workflowTask, ns); err != nil {
+		log.Error(err)
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = err.Error()
+		return resp
+	}
 
 	// assign the workflow information to rhe result, as well as the use labels
 	// for the CRD
@@ -871,6 +1054,24 @@ func CreateCluster(request *msgs.CreateClusterRequest, ns, pgouser string) msgs.
 	newInstance.Spec.UserLabels[config.LABEL_WORKFLOW_ID] = id
 	resp.Result.Database = newInstance.Spec.Database
 
+	// on a dry run, return the pgcluster and every object the Operator creates
+	// for it instead of creating the pgcluster
+	if request.DryRun {
+		objects, err := getDryRunObjects(append(dryRunObjects, newInstance), newInstance, ns)
+		if err != nil {
+			log.Error(err)
+			resp.Status.Code = msgs.Error
+			resp.Status.Msg = err.Error()
+			return resp
+		}
+
+		// the passwords of the users are not kept, so they are not returned
+		resp.Result.Name = newInstance.Spec.Name
+		resp.Result.Users = nil
+		resp.Result.Objects = objects
+		return resp
+	}
+
 	//create CRD for new cluster
 	err = kubeapi.Createpgcluster(apiserver.RESTClient,
 		newInstance, ns)
@@ -887,7 +1088,10 @@ func CreateCluster(request *msgs.CreateClusterRequest, ns, pgouser string) msgs.
 	return resp
 }
 
-func validateConfigPolicies(clusterName, PoliciesFlag, ns string) error {
+// validateConfigPolicies validates the policies that are added to a new
+// cluster, and returns the pgtask that adds them once the cluster is ready. The
+// pgtask is nil if there are no policies to add
+func validateConfigPolicies(clusterName, PoliciesFlag, ns string) (*crv1.Pgtask, error) {
 	var err error
 	var configPolicies string
 
@@ -900,7 +1104,7 @@ func validateConfigPolicies(clusterName, PoliciesFlag, ns string) error {
 
 	if configPolicies == "" {
 		log.Debug("no policies are specified in either pgo.yaml or from user")
-		return err
+		return nil, err
 	}
 
 	policies := strings.Split(configPolicies, ",")
@@ -913,12 +1117,12 @@ func validateConfigPolicies(clusterName, PoliciesFlag, ns string) error {
 			&result, v, ns)
 		if !found {
 			log.Error("policy " + v + " specified in configuration was not found")
-			return err
+			return nil, err
 		}
 
 		if err != nil {
 			log.Error("error getting pgpolicy " + v + err.Error())
-			return err
+			return nil, err
 		}
 		//create a pgtask to add the policy after the db is ready
 	}
@@ -944,9 +1148,7 @@ func validateConfigPolicies(clusterName, PoliciesFlag, ns string) error {
 		Spec: spec,
 	}
 
-	kubeapi.Createpgtask(apiserver.RESTClient, newInstance, ns)
-
-	return err
+	return newInstance, err
 }
 
 func getClusterParams(request *msgs.CreateClusterRequest, name string, userLabelsMap map[string]string, ns string) *crv1.Pgcluster {
@@ -1121,6 +1323,15 @@ func getClusterParams(request *msgs.CreateClusterRequest, name string, userLabel
 	spec.TLS.CASecret = request.CASecret
 	spec.TLS.TLSSecret = request.TLSSecret
 
+	// the Operator creates the secrets of a cluster whose certificates it
+	// manages before the cluster is deployed
+	if request.TLSAuto {
+		spec.TLS.Auto = true
+		spec.TLS.CertAuth = request.TLSCertAuth
+		spec.TLS.CASecret = name + crv1.TLSCASecretSuffix
+		spec.TLS.TLSSecret = name + crv1.TLSSecretSuffix
+	}
+
 	//pass along command line flags for a restore
 	if request.SecretFrom != "" {
 		spec.SecretFrom = request.SecretFrom
@@ -1140,6 +1351,35 @@ func getClusterParams(request *msgs.CreateClusterRequest, name string, userLabel
 		spec.BackrestS3Region = request.BackrestS3Region
 	}
 
+	// set pgBackRest GCS settings in the spec if included in the request
+	if request.BackrestGCSBucket != "" {
+		spec.BackrestGCSBucket = request.BackrestGCSBucket
+	}
+	if request.BackrestGCSEndpoint != "" {
+		spec.BackrestGCSEndpoint = request.BackrestGCSEndpoint
+	}
+	if request.BackrestGCSKeyType != "" {
+		spec.BackrestGCSKeyType = request.BackrestGCSKeyType
+	}
+
+	// set pgBackRest Azure settings in the spec if included in the request
+	if request.BackrestAzureContainer != "" {
+		spec.BackrestAzureContainer = request.BackrestAzureContainer
+	}
+	if request.BackrestAzureEndpoint != "" {
+		spec.BackrestAzureEndpoint = request.BackrestAzureEndpoint
+	}
+	if request.BackrestAzureURIStyle != "" {
+		spec.BackrestAzureURIStyle = request.BackrestAzureURIStyle
+	}
+
+	if request.BackrestStorageVerifyTLS != nil {
+		spec.BackrestStorageVerifyTLS = strconv.FormatBool(*request.BackrestStorageVerifyTLS)
+	}
+
+	// set the pgBackRest cipher type in the spec if included in the request
+	spec.BackrestCipherType = request.BackrestCipherType
+
 	labels := make(map[string]string)
 	labels[config.LABEL_NAME] = name
 	if !request.AutofailFlag || apiserver.Pgo.Cluster.DisableAutofail {
@@ -1152,6 +1392,39 @@ func getClusterParams(request *msgs.CreateClusterRequest, name string, userLabel
 	spec.Standby = request.Standby
 	// set the pgBackRest repository path
 	spec.BackrestRepoPath = request.BackrestRepoPath
+	// set whether or not the cluster is protected from being deleted
+	spec.DeletionProtection = request.DeletionProtection
+	// set when the cluster expires, if it was created with a TTL. The TTL has
+	// already been validated
+	if request.TTL != "" {
+		ttl, _ := apiserver.ValidateTTL(request.TTL)
+		expirationTime := meta_v1.NewTime(time.Now().Add(ttl))
+		spec.ExpirationTime = &expirationTime
+	}
+	// set the scheduling of the pods of the cluster. The tolerations and the
+	// zone spreading have already been validated
+	spec.Tolerations, _ = config.ParseTolerations(request.Tolerations)
+	spec.PriorityClassName = request.PriorityClassName
+	spec.ZoneSpread = crv1.ZoneSpreadType(request.ZoneSpread)
+	// set the custom metadata and the Services of the cluster. They have
+	// already been validated
+	applyClusterMetadata(&spec.Metadata, request.Metadata)
+	spec.ReplicaServiceType = request.ReplicaServiceType
+	spec.LoadBalancerSourceRanges = request.LoadBalancerSourceRanges
+	spec.HBA, _ = config.ParseHBARules(request.HBA)
+	// set the NetworkPolicies of the cluster, which the namespaces and the
+	// source ranges imply. Otherwise the value in pgo.yaml is used
+	spec.NetworkPolicy = crv1.NetworkPolicySpec{
+		Enabled:            apiserver.Pgo.Cluster.NetworkPolicy,
+		NamespaceSelectors: request.NetworkPolicyNamespaces,
+		SourceRanges:       request.NetworkPolicySourceRanges,
+	}
+	if request.NetworkPolicy != nil {
+		spec.NetworkPolicy.Enabled = *request.NetworkPolicy
+	}
+	if len(request.NetworkPolicyNamespaces) > 0 || len(request.NetworkPolicySourceRanges) > 0 {
+		spec.NetworkPolicy.Enabled = true
+	}
 
 	//pgbadger - set with global flag first then check for a user flag
 	labels[config.LABEL_BADGER] = strconv.FormatBool(apiserver.BadgerFlag)
@@ -1244,7 +1517,9 @@ func createDeleteDataTasks(clusterName string, storageSpec crv1.PgStorageSpec, d
 	return err
 }
 
-func createWorkflowTask(clusterName, ns, pgouser string) (string, error) {
+// newWorkflowTask returns the workflow pgtask of a new cluster, without
+// creating it
+func newWorkflowTask(clusterName, ns, pgouser string) (*crv1.Pgtask, error) {
 
 	//create pgtask CRD
 	spec := crv1.PgtaskSpec{}
@@ -1259,7 +1534,7 @@ func createWorkflowTask(clusterName, ns, pgouser string) (string, error) {
 	u, err := ioutil.ReadFile("/proc/sys/kernel/random/uuid")
 	if err != nil {
 		log.Error(err)
-		return "", err
+		return nil, err
 	}
 	spec.Parameters[crv1.PgtaskWorkflowID] = string(u[:len(u)-1])
 
@@ -1274,12 +1549,7 @@ func createWorkflowTask(clusterName, ns, pgouser string) (string, error) {
 	newInstance.ObjectMeta.Labels[config.LABEL_PG_CLUSTER] = clusterName
 	newInstance.ObjectMeta.Labels[crv1.PgtaskWorkflowID] = spec.Parameters[crv1.PgtaskWorkflowID]
 
-	err = kubeapi.Createpgtask(apiserver.RESTClient, newInstance, ns)
-	if err != nil {
-		log.Error(err)
-		return "", err
-	}
-	return spec.Parameters[crv1.PgtaskWorkflowID], err
+	return newInstance, nil
 }
 
 func getType(pod *v1.Pod, clusterName string) string {
@@ -1355,8 +1625,10 @@ func getReplicas(cluster *crv1.Pgcluster, ns string) ([]msgs.ShowClusterReplica,
 // 5. The password is generated by the global Operator default value for
 //    password length
 //
-// returns the secertname, password as well as any errors
-func createUserSecret(request *msgs.CreateClusterRequest, cluster *crv1.Pgcluster, secretNameSuffix, username, password string) (string, string, error) {
+// returns the secertname, password, the secret that is created as well as any
+// errors. The secret is nil if it already exists, and is not created on a dry
+// run
+func createUserSecret(request *msgs.CreateClusterRequest, cluster *crv1.Pgcluster, secretNameSuffix, username, password string) (string, string, *v1.Secret, error) {
 	// the secretName is just the combination cluster name and the secretNameSuffix
 	secretName := fmt.Sprintf("%s%s", cluster.Spec.Name, secretNameSuffix)
 
@@ -1365,7 +1637,7 @@ func createUserSecret(request *msgs.CreateClusterRequest, cluster *crv1.Pgcluste
 	if secret, found, _ := kubeapi.GetSecret(apiserver.Clientset, secretName, cluster.Spec.Namespace); found {
 		log.Infof("secret exists: [%s] - skipping", secretName)
 
-		return secretName, string(secret.Data["password"][:]), nil
+		return secretName, string(secret.Data["password"][:]), nil, nil
 	}
 
 	// alright, go through the hierarchy and determine if we need to set the
@@ -1385,7 +1657,7 @@ func createUserSecret(request *msgs.CreateClusterRequest, cluster *crv1.Pgcluste
 		// if there is an error, abandon here, otherwise set the oldPassword as the
 		// current password
 		if err != nil {
-			return "", "", err
+			return "", "", nil, err
 		}
 
 		password = oldPassword
@@ -1401,14 +1673,17 @@ func createUserSecret(request *msgs.CreateClusterRequest, cluster *crv1.Pgcluste
 		password = util.GeneratePassword(passwordLength)
 	}
 
+	secret := util.NewSecret(cluster.Spec.Name, secretName, username, password)
+
 	// great, now we can create the secret! if we can't, return an error
-	if err := util.CreateSecret(apiserver.Clientset, cluster.Spec.Name, secretName,
-		username, password, cluster.Spec.Namespace); err != nil {
-		return "", "", err
+	if !request.DryRun {
+		if err := kubeapi.CreateSecret(apiserver.Clientset, secret, cluster.Spec.Namespace); err != nil {
+			return "", "", nil, err
+		}
 	}
 
 	// otherwise, return the secret name, password
-	return secretName, password, nil
+	return secretName, password, secret, nil
 }
 
 // UpdateCluster ...
@@ -1436,6 +1711,76 @@ func UpdateCluster(request *msgs.UpdateClusterRequest) msgs.UpdateClusterRespons
 		return response
 	}
 
+	// ensure the TTL extension, if any, is a valid duration
+	var extendTTL time.Duration
+	if request.ExtendTTL != "" {
+		if extendTTL, err = apiserver.ValidateTTL(request.ExtendTTL); err != nil {
+			response.Status.Code = msgs.Error
+			response.Status.Msg = fmt.Sprintf(apiserver.ErrMessageTTL, request.ExtendTTL, err.Error())
+			return response
+		}
+	}
+
+	// ensure the custom annotations and labels, the service types and the load
+	// balancer source ranges, if any, are valid
+	if err := applyClusterMetadata(&crv1.ClusterMetadataSpec{}, request.Metadata); err != nil {
+		response.Status.Code = msgs.Error
+		response.Status.Msg = err.Error()
+		return response
+	}
+
+	for _, serviceType := range []string{request.ServiceType, request.ReplicaServiceType} {
+		if serviceType == "" {
+			continue
+		}
+
+		if err := validateServiceType(serviceType); err != nil {
+			response.Status.Code = msgs.Error
+			response.Status.Msg = err.Error()
+			return response
+		}
+	}
+
+	if err := validateSourceRanges(request.LoadBalancerSourceRanges); err != nil {
+		response.Status.Code = msgs.Error
+		response.Status.Msg = err.Error()
+		return response
+	}
+
+	if request.ReplicaServiceType != "" && request.ClearReplicaServiceType {
+		response.Status.Code = msgs.Error
+		response.Status.Msg = "the replica service type cannot be set and cleared at the same time"
+		return response
+	}
+
+	if len(request.LoadBalancerSourceRanges) > 0 && request.ClearLoadBalancerSourceRanges {
+		response.Status.Code = msgs.Error
+		response.Status.Msg = "load balancer source ranges cannot be set and cleared at the same time"
+		return response
+	}
+
+	// ensure the pg_hba.conf rules, if any, are valid
+	hba, err := config.ParseHBARules(request.HBA)
+	if err != nil {
+		response.Status.Code = msgs.Error
+		response.Status.Msg = err.Error()
+		return response
+	}
+
+	if len(hba) > 0 && request.ClearHBA {
+		response.Status.Code = msgs.Error
+		response.Status.Msg = "pg_hba rules cannot be set and cleared at the same time"
+		return response
+	}
+
+	// ensure the clients of the NetworkPolicies, if any, are valid
+	if err := validateNetworkPolicy(request.NetworkPolicy == msgs.UpdateClusterNetworkPolicyDisable,
+		request.NetworkPolicyNamespaces, request.NetworkPolicySourceRanges); err != nil {
+		response.Status.Code = msgs.Error
+		response.Status.Msg = err.Error()
+		return response
+	}
+
 	// validate the storage type for each specified tablespace actually exists.
 	// if a PVCSize is passed in, also validate that it follows the Kubernetes
 	// format
@@ -1482,6 +1827,23 @@ func UpdateCluster(request *msgs.UpdateClusterRequest) msgs.UpdateClusterRespons
 		return response
 	}
 
+	// ensure the new pg_hba.conf rules still let pgBouncer connect, as it
+	// connects as the users of the application, and that every cluster has a
+	// TTL to extend, before any of the clusters is updated
+	for i := range clusterList.Items {
+		if err := clusteroperator.ValidatePgBouncerHBA(apiserver.Clientset, &clusterList.Items[i], hba); err != nil {
+			response.Status.Code = msgs.Error
+			response.Status.Msg = err.Error()
+			return response
+		}
+
+		if extendTTL > 0 && clusterList.Items[i].Spec.ExpirationTime == nil {
+			response.Status.Code = msgs.Error
+			response.Status.Msg = fmt.Sprintf("cluster %s does not have a TTL", clusterList.Items[i].Name)
+			return response
+		}
+	}
+
 	for _, cluster := range clusterList.Items {
 
 		//set autofail=true or false on each pgcluster CRD
@@ -1524,6 +1886,79 @@ func UpdateCluster(request *msgs.UpdateClusterRequest) msgs.UpdateClusterRespons
 			cluster.Spec.Shutdown = true
 		}
 
+		// enable or disable the deletion protection. The handler has already
+		// ensured the user is authorized to disable it
+		switch request.DeletionProtection {
+		case msgs.UpdateClusterDeletionProtectionEnable:
+			cluster.Spec.DeletionProtection = true
+		case msgs.UpdateClusterDeletionProtectionDisable:
+			cluster.Spec.DeletionProtection = false
+		}
+
+		// push back the expiration time of the cluster, counting from now if
+		// the cluster is already expired. A new warning is sent ahead of the new
+		// expiration time
+		if extendTTL > 0 {
+			expirationTime := cluster.Spec.ExpirationTime.Time
+			if now := time.Now(); expirationTime.Before(now) {
+				expirationTime = now
+			}
+
+			cluster.Spec.ExpirationTime = &meta_v1.Time{Time: expirationTime.Add(extendTTL)}
+			delete(cluster.ObjectMeta.Annotations, config.ANNOTATION_EXPIRATION_WARNED)
+		}
+
+		// update the custom metadata and the Services, which the Operator then
+		// applies to the objects of the cluster
+		applyClusterMetadata(&cluster.Spec.Metadata, request.Metadata)
+
+		if request.ServiceType != "" {
+			if cluster.Spec.UserLabels == nil {
+				cluster.Spec.UserLabels = map[string]string{}
+			}
+			cluster.Spec.UserLabels[config.LABEL_SERVICE_TYPE] = request.ServiceType
+		}
+
+		// without a type of its own, the replica Service has the type of the
+		// primary Service
+		if request.ReplicaServiceType != "" {
+			cluster.Spec.ReplicaServiceType = request.ReplicaServiceType
+		} else if request.ClearReplicaServiceType {
+			cluster.Spec.ReplicaServiceType = ""
+		}
+
+		// without source ranges, any client may connect to the Services of
+		// type LoadBalancer
+		if len(request.LoadBalancerSourceRanges) > 0 {
+			cluster.Spec.LoadBalancerSourceRanges = request.LoadBalancerSourceRanges
+		} else if request.ClearLoadBalancerSourceRanges {
+			cluster.Spec.LoadBalancerSourceRanges = nil
+		}
+
+		// the Operator applies the pg_hba.conf rules to the cluster without a
+		// restart
+		if len(hba) > 0 {
+			cluster.Spec.HBA = hba
+		} else if request.ClearHBA {
+			cluster.Spec.HBA = nil
+		}
+
+		// the Operator updates the NetworkPolicies of the cluster to match
+		switch request.NetworkPolicy {
+		case msgs.UpdateClusterNetworkPolicyEnable:
+			cluster.Spec.NetworkPolicy.Enabled = true
+		case msgs.UpdateClusterNetworkPolicyDisable:
+			cluster.Spec.NetworkPolicy.Enabled = false
+		}
+
+		if len(request.NetworkPolicyNamespaces) > 0 {
+			cluster.Spec.NetworkPolicy.NamespaceSelectors = request.NetworkPolicyNamespaces
+		}
+
+		if len(request.NetworkPolicySourceRanges) > 0 {
+			cluster.Spec.NetworkPolicy.SourceRanges = request.NetworkPolicySourceRanges
+		}
+
 		// extract the parameters for the TablespaceMounts and put them in the
 		// format that is required by the pgcluster CRD
 		for _, tablespace := range request.Tablespaces {
@@ -1619,6 +2054,31 @@ func validateBackrestStorageTypeOnCreate(request *msgs.CreateClusterRequest) err
 		return errors.New("A configuration setting for AWS S3 storage is missing. Values must be " +
 			"provided for the S3 bucket, S3 endpoint and S3 region in order to use the 's3' " +
 			"storage type with pgBackRest.")
+	} else if strings.Contains(requestBackRestStorageType, "gcs") && isMissingGCSConfig(request) {
+		return errors.New("A configuration setting for GCS storage is missing. A value must be " +
+			"provided for the GCS bucket in order to use the 'gcs' storage type with pgBackRest.")
+	} else if strings.Contains(requestBackRestStorageType, "azure") && isMissingAzureConfig(request) {
+		return errors.New("A configuration setting for Azure storage is missing. A value must be " +
+			"provided for the Azure container in order to use the 'azure' storage type with " +
+			"pgBackRest.")
+	}
+
+	if request.BackrestGCSKeyType != "" &&
+		!util.IsStringOneOf(request.BackrestGCSKeyType, backrestGCSKeyTypes...) {
+		return fmt.Errorf("Invalid value provided for the GCS key type. The following values are allowed: %s",
+			"\""+strings.Join(backrestGCSKeyTypes, "\", \"")+"\"")
+	}
+
+	if request.BackrestAzureURIStyle != "" &&
+		!util.IsStringOneOf(request.BackrestAzureURIStyle, backrestAzureURIStyles...) {
+		return fmt.Errorf("Invalid value provided for the Azure URI style. The following values are allowed: %s",
+			"\""+strings.Join(backrestAzureURIStyles, "\", \"")+"\"")
+	}
+
+	if request.BackrestCipherType != "" &&
+		!util.IsStringOneOf(request.BackrestCipherType, crv1.BackrestCipherTypes...) {
+		return fmt.Errorf("Invalid value provided for the pgBackRest cipher type. The following values are allowed: %s",
+			"\""+strings.Join(crv1.BackrestCipherTypes, "\", \"")+"\"")
 	}
 
 	return nil
@@ -1627,6 +2087,19 @@ func validateBackrestStorageTypeOnCreate(request *msgs.CreateClusterRequest) err
 // validateClusterTLS validates the parameters that allow a user to enable TLS
 // connections to a PostgreSQL cluster
 func validateClusterTLS(request *msgs.CreateClusterRequest) error {
+	// the client certificates are issued from the CA that the Operator maintains
+	if request.TLSCertAuth && !request.TLSAuto {
+		return fmt.Errorf("Client certificate authentication requires the Operator to manage the certificates")
+	}
+
+	// if the Operator manages the certificates, there are no secrets to check
+	if request.TLSAuto {
+		if request.TLSSecret != "" || request.CASecret != "" {
+			return fmt.Errorf("A TLS secret and CA secret cannot be set when the Operator manages the certificates")
+		}
+		return nil
+	}
+
 	// if TLSOnly is not set and  neither TLSSecret no CASecret are set, just return
 	if !request.TLSOnly && request.TLSSecret == "" && request.CASecret == "" {
 		return nil
@@ -1692,6 +2165,19 @@ func isMissingS3Config(request *msgs.CreateClusterRequest) bool {
 	return false
 }
 
+// determines if the GCS bucket is missing from both the incoming request and the
+// pgo.yaml config file. The endpoint defaults to the one provided by pgBackRest
+func isMissingGCSConfig(request *msgs.CreateClusterRequest) bool {
+	return request.BackrestGCSBucket == "" && apiserver.Pgo.Cluster.BackrestGCSBucket == ""
+}
+
+// determines if the Azure container is missing from both the incoming request
+// and the pgo.yaml config file. The endpoint defaults to the one provided by
+// pgBackRest
+func isMissingAzureConfig(request *msgs.CreateClusterRequest) bool {
+	return request.BackrestAzureContainer == "" && apiserver.Pgo.Cluster.BackrestAzureContainer == ""
+}
+
 func validateStandbyCluster(request *msgs.CreateClusterRequest) error {
 	switch {
 	case !strings.Contains(request.BackrestStorageType, "s3"):
@@ -1700,6 +2186,120 @@ func validateStandbyCluster(request *msgs.CreateClusterRequest) error {
 	case request.BackrestRepoPath == "":
 		return errors.New("A pgBackRest repository path must be specified when creating a " +
 			"standby cluster")
+	case request.BackrestCipherType != "" && request.BackrestCipherType != "none":
+		return errors.New("A standby cluster cannot be created with an encrypted pgBackRest " +
+			"repository, as a new passphrase cannot decrypt the repository it replicates from")
+	}
+	return nil
+}
+
+// applyClusterMetadata adds the custom annotations and labels of a request to
+// the metadata of a cluster, and removes the ones that the request removes
+func applyClusterMetadata(spec *crv1.ClusterMetadataSpec, request msgs.ClusterMetadata) error {
+	objects := []struct {
+		metadata    *crv1.MetadataSpec
+		annotations []string
+		labels      []string
+	}{
+		{&spec.Pod, request.AnnotationsPostgres, request.LabelsPostgres},
+		{&spec.PrimaryService, request.AnnotationsPrimaryService, request.LabelsPrimaryService},
+		{&spec.ReplicaService, request.AnnotationsReplicaService, request.LabelsReplicaService},
+		{&spec.PgBouncer, request.AnnotationsPgBouncer, request.LabelsPgBouncer},
+		{&spec.PgBackRest, request.AnnotationsPgBackRest, request.LabelsPgBackRest},
+	}
+
+	for _, object := range objects {
+		var err error
+
+		if object.metadata.Annotations, err = updateMetadata(object.metadata.Annotations,
+			object.annotations, false); err != nil {
+			return err
+		}
+
+		if object.metadata.Labels, err = updateMetadata(object.metadata.Labels,
+			object.labels, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateMetadata adds the annotations or labels in the "key=value" format to a
+// map, and removes the ones in the "key-" format. Labels that the Operator sets
+// cannot be added
+func updateMetadata(metadata map[string]string, entries []string, isLabel bool) (map[string]string, error) {
+	kind := "annotation"
+	if isLabel {
+		kind = "label"
+	}
+
+	for _, entry := range entries {
+		if !strings.Contains(entry, "=") && strings.HasSuffix(entry, "-") {
+			delete(metadata, strings.TrimSuffix(entry, "-"))
+			continue
+		}
+
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return metadata, fmt.Errorf("invalid %s %q, the format is \"key=value\"", kind, entry)
+		}
+
+		key, value := parts[0], parts[1]
+
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return metadata, fmt.Errorf("invalid %s %q: %s", kind, entry, strings.Join(errs, "; "))
+		}
+
+		if isLabel {
+			if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+				return metadata, fmt.Errorf("invalid %s %q: %s", kind, entry, strings.Join(errs, "; "))
+			}
+
+			if operator.IsReservedLabel(key) {
+				return metadata, fmt.Errorf("label %q is set by the Operator and cannot be a custom label", key)
+			}
+		} else if key == config.ANNOTATION_CUSTOM_ANNOTATIONS || key == config.ANNOTATION_CUSTOM_LABELS {
+			return metadata, fmt.Errorf("annotation %q is set by the Operator and cannot be a custom annotation", key)
+		}
+
+		if metadata == nil {
+			metadata = map[string]string{}
+		}
+		metadata[key] = value
+	}
+
+	return metadata, nil
+}
+
+// validateServiceType ensures a service type is one that the Operator supports
+func validateServiceType(serviceType string) error {
+	switch serviceType {
+	case config.DEFAULT_SERVICE_TYPE, config.NODEPORT_SERVICE_TYPE, config.LOAD_BALANCER_SERVICE_TYPE:
+		return nil
+	}
+	return fmt.Errorf("invalid service type %q, valid values are %q, %q or %q", serviceType,
+		config.DEFAULT_SERVICE_TYPE, config.NODEPORT_SERVICE_TYPE, config.LOAD_BALANCER_SERVICE_TYPE)
+}
+
+// validateNetworkPolicy ensures that the namespace selectors and the source
+// ranges of the NetworkPolicies of a cluster are valid, and that they are not
+// set along with disabling the NetworkPolicies
+func validateNetworkPolicy(disabled bool, namespaces, sourceRanges []string) error {
+	if disabled && (len(namespaces) > 0 || len(sourceRanges) > 0) {
+		return errors.New("network policy namespaces and source ranges cannot be set when the network " +
+			"policies are disabled")
+	}
+
+	return crv1.NetworkPolicySpec{NamespaceSelectors: namespaces, SourceRanges: sourceRanges}.Validate()
+}
+
+// validateSourceRanges ensures that load balancer source ranges are CIDRs
+func validateSourceRanges(sourceRanges []string) error {
+	for _, sourceRange := range sourceRanges {
+		if _, _, err := net.ParseCIDR(sourceRange); err != nil {
+			return fmt.Errorf("invalid load balancer source range %q: %v", sourceRange, err)
+		}
 	}
 	return nil
 }
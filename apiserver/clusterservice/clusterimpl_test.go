@@ -0,0 +1,56 @@
+package clusterservice
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"testing"
+
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+)
+
+func TestValidateStandbyCluster(t *testing.T) {
+	tests := []struct {
+		storageType string
+		repoPath    string
+		cipherType  string
+		err         bool
+	}{
+		{"s3", "/backrestrepo/hippo-backrest-shared-repo", "", false},
+		{"local,s3", "/backrestrepo/hippo-backrest-shared-repo", "none", false},
+		{"local", "/backrestrepo/hippo-backrest-shared-repo", "", true},
+		{"s3", "", "", true},
+		{"s3", "/backrestrepo/hippo-backrest-shared-repo", "aes-256-cbc", true},
+	}
+
+	for i, test := range tests {
+		request := &msgs.CreateClusterRequest{
+			Standby:             true,
+			BackrestStorageType: test.storageType,
+			BackrestRepoPath:    test.repoPath,
+			BackrestCipherType:  test.cipherType,
+		}
+
+		err := validateStandbyCluster(request)
+
+		if test.err && err == nil {
+			t.Fatalf("tests[%d] - expected an error", i)
+		}
+
+		if !test.err && err != nil {
+			t.Fatalf("tests[%d] - expected no error, got %s", i, err)
+		}
+	}
+}
@@ -340,6 +340,15 @@ func UpdateClusterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// a special authz check here: disabling the deletion protection of a
+	// cluster requires its own permission
+	if requiresDisableDeletionProtectionPerm(&request) &&
+		!apiserver.BasicAuthzCheck(username, apiserver.DISABLE_DELETION_PROTECTION_PERM) {
+		log.Errorf("Authorization Failed %s username=[%s]", apiserver.DISABLE_DELETION_PROTECTION_PERM, username)
+		http.Error(w, "Not authorized for this apiserver action", 403)
+		return
+	}
+
 	w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -368,3 +377,10 @@ func UpdateClusterHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 
 }
+
+// requiresDisableDeletionProtectionPerm returns true if an update of clusters
+// requires the DisableDeletionProtection permission on top of the UpdateCluster
+// permission, i.e. if it disables their deletion protection
+func requiresDisableDeletionProtectionPerm(request *msgs.UpdateClusterRequest) bool {
+	return request.DeletionProtection == msgs.UpdateClusterDeletionProtectionDisable
+}
@@ -0,0 +1,41 @@
+package clusterservice
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"testing"
+
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+)
+
+func TestRequiresDisableDeletionProtectionPerm(t *testing.T) {
+	tests := []struct {
+		deletionProtection msgs.UpdateClusterDeletionProtectionStatus
+		expected           bool
+	}{
+		{msgs.UpdateClusterDeletionProtectionDoNothing, false},
+		{msgs.UpdateClusterDeletionProtectionEnable, false},
+		{msgs.UpdateClusterDeletionProtectionDisable, true},
+	}
+
+	for i, test := range tests {
+		request := &msgs.UpdateClusterRequest{DeletionProtection: test.deletionProtection}
+
+		if required := requiresDisableDeletionProtectionPerm(request); required != test.expected {
+			t.Fatalf("tests[%d] - expected %t, got %t", i, test.expected, required)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package clusterservice
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"encoding/json"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/apiserver"
+	clusteroperator "github.com/crunchydata/postgres-operator/operator/cluster"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// redactedValue replaces the values of secrets that are returned by a dry run
+const redactedValue = "REDACTED"
+
+// getDryRunObjects returns the objects that are created for a new cluster on
+// top of the objects that are created by the apiserver itself, i.e. every
+// object the Operator creates from the templates in pgo-config, encoded as
+// JSON. The data of secrets is redacted
+func getDryRunObjects(objects []runtime.Object, cluster *crv1.Pgcluster, ns string) ([]json.RawMessage, error) {
+	rendered, err := clusteroperator.RenderCluster(apiserver.Clientset, cluster, ns)
+	if err != nil {
+		return nil, err
+	}
+	objects = append(objects, rendered...)
+
+	result := make([]json.RawMessage, 0, len(objects))
+
+	for _, object := range objects {
+		if secret, ok := object.(*v1.Secret); ok {
+			object = redactSecret(secret)
+		}
+
+		if err := setDryRunObjectMeta(object, ns); err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(object)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, data)
+	}
+
+	return result, nil
+}
+
+// redactSecret returns a copy of a secret with all of its values redacted
+func redactSecret(secret *v1.Secret) *v1.Secret {
+	redacted := secret.DeepCopy()
+	redacted.Data = nil
+	redacted.StringData = make(map[string]string)
+
+	for key := range secret.Data {
+		redacted.StringData[key] = redactedValue
+	}
+
+	for key := range secret.StringData {
+		redacted.StringData[key] = redactedValue
+	}
+
+	return redacted
+}
+
+// setDryRunObjectMeta sets the kind and the namespace of an object that is
+// returned by a dry run, as the templates and the clients do not always set
+// them
+func setDryRunObjectMeta(object runtime.Object, ns string) error {
+	kinds, _, err := scheme.Scheme.ObjectKinds(object)
+	if err != nil {
+		return err
+	}
+	object.GetObjectKind().SetGroupVersionKind(kinds[0])
+
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	accessor.SetNamespace(ns)
+
+	return nil
+}
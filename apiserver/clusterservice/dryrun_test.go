@@ -0,0 +1,54 @@
+package clusterservice
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRedactSecret(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "hippo-postgres-secret"},
+		Data: map[string][]byte{
+			"username": []byte("postgres"),
+			"password": []byte("datalake"),
+		},
+	}
+
+	redacted := redactSecret(secret)
+
+	expected := map[string]string{
+		"username": redactedValue,
+		"password": redactedValue,
+	}
+
+	if redacted.Data != nil || !reflect.DeepEqual(redacted.StringData, expected) {
+		t.Fatalf("expected the values of the secret to be redacted, got %v and %v", redacted.Data,
+			redacted.StringData)
+	}
+
+	if redacted.Name != secret.Name {
+		t.Fatalf("expected name %q, got %q", secret.Name, redacted.Name)
+	}
+
+	if string(secret.Data["password"]) != "datalake" {
+		t.Fatalf("expected the original secret to be left alone")
+	}
+}
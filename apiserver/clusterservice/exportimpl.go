@@ -0,0 +1,617 @@
+package clusterservice
+
+/*
+Copyright 2020 Crunchy Data Solutions, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/apiserver"
+	"github.com/crunchydata/postgres-operator/apiserver/scheduleservice"
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	"github.com/crunchydata/postgres-operator/util"
+	log "github.com/sirupsen/logrus"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// exportIgnoredLabels are the labels that the Operator sets on a pgcluster and
+// its pgreplicas while it runs the cluster, which are left out of an export.
+// The labels of the policies that have been applied are left out as well, as
+// the policies are applied again once the cluster is re-created
+var exportIgnoredLabels = map[string]bool{
+	config.LABEL_CURRENT_PRIMARY:       true,
+	config.LABEL_DELETE_BACKUPS:        true,
+	config.LABEL_DELETE_DATA:           true,
+	config.LABEL_DELETE_DATA_STARTED:   true,
+	config.LABEL_DEPLOYMENT_NAME:       true,
+	config.LABEL_FAILOVER_STARTED:      true,
+	config.LABEL_MAJOR_UPGRADE:         true,
+	config.LABEL_MINOR_UPGRADE:         true,
+	config.LABEL_PGBOUNCER:             true,
+	config.LABEL_PGHA_SCOPE:            true,
+	config.LABEL_PGO_CREATED_BY:        true,
+	config.LABEL_PGO_UPDATED_BY:        true,
+	config.LABEL_PGOUSER:               true,
+	config.LABEL_PG_CLUSTER_IDENTIFIER: true,
+	config.LABEL_UPGRADE_COMPLETED:     true,
+	config.LABEL_UPGRADE_DATE:          true,
+	config.LABEL_UPGRADE_FAILED:        true,
+	config.LABEL_UPGRADE_IN_PROGRESS:   true,
+	config.LABEL_WORKFLOW_ID:           true,
+}
+
+// exportedAnnotations are the annotations of a pgcluster that are part of an
+// export, as they are settings of the cluster rather than state
+var exportedAnnotations = []string{
+	config.ANNOTATION_KEEP_BACKUPS,
+	config.ANNOTATION_KEEP_DATA,
+}
+
+// ExportCluster returns a portable description of a cluster, i.e. its
+// pgcluster and pgreplicas without the state the Operator keeps in them, the
+// policies that have been applied to it, its schedules, its pgBouncer settings
+// and its users. Secrets are referenced by name, and their values are never
+// returned
+func ExportCluster(clusterName, ns string) msgs.ExportClusterResponse {
+	resp := msgs.ExportClusterResponse{}
+	resp.Status = msgs.Status{Code: msgs.Ok, Msg: ""}
+
+	cluster := crv1.Pgcluster{}
+	found, err := kubeapi.Getpgcluster(apiserver.RESTClient, &cluster, clusterName, ns)
+	if !found {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: fmt.Sprintf("cluster %s not found", clusterName)}
+		return resp
+	} else if err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		return resp
+	}
+
+	selector := config.LABEL_PG_CLUSTER + "=" + clusterName
+
+	// the policies are the ones the cluster is labeled with
+	policyNames := []string{}
+	for k, v := range cluster.ObjectMeta.Labels {
+		if v == config.LABEL_PGPOLICY {
+			policyNames = append(policyNames, k)
+		}
+	}
+	sort.Strings(policyNames)
+
+	export := msgs.ClusterExport{
+		Cluster: exportPgcluster(&cluster, policyNames),
+	}
+
+	replicaList := crv1.PgreplicaList{}
+	if err := kubeapi.GetpgreplicasBySelector(apiserver.RESTClient, &replicaList, selector, ns); err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		return resp
+	}
+	sort.Slice(replicaList.Items, func(i, j int) bool {
+		return replicaList.Items[i].Name < replicaList.Items[j].Name
+	})
+	for i := range replicaList.Items {
+		export.Replicas = append(export.Replicas, exportPgreplica(&replicaList.Items[i]))
+	}
+
+	for _, policyName := range policyNames {
+		policy := crv1.Pgpolicy{}
+		if found, _ := kubeapi.Getpgpolicy(apiserver.RESTClient, &policy, policyName, ns); !found {
+			log.Warnf("policy %s of cluster %s not found, not exporting it", policyName, clusterName)
+			continue
+		}
+		export.Policies = append(export.Policies, exportPgpolicy(&policy))
+	}
+
+	// schedules are only listed if there are any
+	if configMaps, ok := kubeapi.ListConfigMap(apiserver.Clientset,
+		"crunchy-scheduler=true,"+selector, ns); ok {
+		sort.Slice(configMaps.Items, func(i, j int) bool {
+			return configMaps.Items[i].Name < configMaps.Items[j].Name
+		})
+
+		for _, configMap := range configMaps.Items {
+			schedule, err := exportSchedule([]byte(configMap.Data[configMap.Name]))
+			if err != nil {
+				resp.Status = msgs.Status{Code: msgs.Error,
+					Msg: fmt.Sprintf("could not read schedule %s: %s", configMap.Name, err)}
+				return resp
+			}
+			export.Schedules = append(export.Schedules, schedule)
+		}
+	}
+
+	if cluster.ObjectMeta.Labels[config.LABEL_PGBOUNCER] == config.LABEL_TRUE {
+		export.PgBouncer = msgs.ClusterExportPgBouncer{
+			Enabled:    true,
+			SecretName: util.GeneratePgBouncerSecretName(clusterName),
+		}
+	}
+
+	secrets, err := kubeapi.GetSecrets(apiserver.Clientset, selector, ns)
+	if err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		return resp
+	}
+	for _, secret := range secrets.Items {
+		username := string(secret.Data["username"])
+		if _, isSystem := crv1.PGUserSystemAccounts[username]; isSystem || username == "" {
+			continue
+		}
+		export.Users = append(export.Users, msgs.ClusterExportUser{
+			Username:   username,
+			SecretName: secret.Name,
+		})
+	}
+	sort.Slice(export.Users, func(i, j int) bool {
+		return export.Users[i].Username < export.Users[j].Username
+	})
+
+	resp.Result = export
+	return resp
+}
+
+// appliedObjects are the objects that ApplyCluster has created so far, which
+// are removed again if the pgcluster cannot be created
+type appliedObjects struct {
+	namespace string
+	policies  []string
+	tasks     []string
+	secrets   []string
+	replicas  []string
+}
+
+// remove deletes the objects that have been created, in the reverse order of
+// their creation. Errors are logged, as the objects are removed on a best
+// effort basis
+func (a *appliedObjects) remove() {
+	for i := len(a.replicas) - 1; i >= 0; i-- {
+		if err := kubeapi.Deletepgreplica(apiserver.RESTClient, a.replicas[i], a.namespace); err != nil {
+			log.Error(err)
+		}
+	}
+	for i := len(a.tasks) - 1; i >= 0; i-- {
+		if err := kubeapi.Deletepgtask(apiserver.RESTClient, a.tasks[i], a.namespace); err != nil {
+			log.Error(err)
+		}
+	}
+	for i := len(a.secrets) - 1; i >= 0; i-- {
+		if err := kubeapi.DeleteSecret(apiserver.Clientset, a.secrets[i], a.namespace); err != nil {
+			log.Error(err)
+		}
+	}
+	for i := len(a.policies) - 1; i >= 0; i-- {
+		if err := kubeapi.Deletepgpolicy(apiserver.RESTClient, a.policies[i], a.namespace); err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+// ApplyCluster creates a cluster from an export in the namespace provided. The
+// policies that do not exist in the namespace are created, and the secrets of
+// the cluster that do not exist are created with new credentials. If the
+// pgcluster cannot be created, the objects created for it are removed again so
+// that the export can be applied once more
+func ApplyCluster(export *msgs.ClusterExport, ns, pgouser string) msgs.ApplyClusterResponse {
+	resp := msgs.ApplyClusterResponse{}
+	resp.Status = msgs.Status{Code: msgs.Ok, Msg: ""}
+	resp.Results = make([]string, 0)
+
+	// once the pgcluster exists, the objects of the cluster are removed along
+	// with it, i.e. by "pgo delete cluster"
+	created := &appliedObjects{namespace: ns}
+	clusterCreated := false
+	defer func() {
+		if resp.Status.Code == msgs.Error && !clusterCreated {
+			log.Infof("removing the objects created for cluster %s", export.Cluster.Name)
+			created.remove()
+		}
+	}()
+
+	cluster := export.Cluster
+	clusterName := cluster.Name
+
+	if err := validateClusterExport(export, ns); err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		return resp
+	}
+
+	cluster.ObjectMeta.Namespace = ns
+	cluster.Spec.Namespace = ns
+	cluster.ObjectMeta.Labels = copyExportLabels(cluster.ObjectMeta.Labels)
+	cluster.ObjectMeta.Labels[config.LABEL_PGOUSER] = pgouser
+	cluster.Spec.UserLabels = copyExportLabels(cluster.Spec.UserLabels)
+	if export.PgBouncer.Enabled {
+		cluster.ObjectMeta.Labels[config.LABEL_PGBOUNCER] = config.LABEL_TRUE
+	}
+
+	// the policies are created if they do not exist yet, and are applied once
+	// the cluster is ready
+	for _, exported := range export.Policies {
+		policy := exported
+		policy.ObjectMeta = meta_v1.ObjectMeta{Name: exported.Name, Labels: exported.Labels}
+		policy.Spec.Namespace = ns
+
+		if found, _ := kubeapi.Getpgpolicy(apiserver.RESTClient, &crv1.Pgpolicy{}, policy.Name, ns); found {
+			resp.Results = append(resp.Results, fmt.Sprintf("policy %s already exists", policy.Name))
+			continue
+		}
+
+		if err := kubeapi.Createpgpolicy(apiserver.RESTClient, &policy, ns); err != nil {
+			resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+			return resp
+		}
+		created.policies = append(created.policies, policy.Name)
+		resp.Results = append(resp.Results, fmt.Sprintf("created policy %s", policy.Name))
+	}
+
+	if cluster.Spec.Policies != "" {
+		policiesTask, err := validateConfigPolicies(clusterName, cluster.Spec.Policies, ns)
+		if err != nil {
+			resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+			return resp
+		}
+		if err := kubeapi.Createpgtask(apiserver.RESTClient, policiesTask, ns); err != nil {
+			resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+			return resp
+		}
+		created.tasks = append(created.tasks, policiesTask.Name)
+	}
+
+	// the secrets of the users the cluster is bootstrapped with are created
+	// with new passwords if they are not provided
+	request := &msgs.CreateClusterRequest{}
+	userSecrets := []struct {
+		suffix   string
+		username string
+	}{
+		{suffix: crv1.RootSecretSuffix, username: crv1.PGUserSuperuser},
+		{suffix: crv1.PrimarySecretSuffix, username: crv1.PGUserReplication},
+		{suffix: fmt.Sprintf("-%s%s", cluster.Spec.User, crv1.UserSecretSuffix), username: cluster.Spec.User},
+	}
+	for _, userSecret := range userSecrets {
+		secretName, _, secret, err := createUserSecret(request, &cluster, userSecret.suffix, userSecret.username, "")
+		if err != nil {
+			resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+			return resp
+		}
+		if secret != nil {
+			created.secrets = append(created.secrets, secretName)
+			resp.Results = append(resp.Results,
+				fmt.Sprintf("created secret %s for user %s with a new password", secretName, userSecret.username))
+		}
+	}
+	cluster.Spec.RootSecretName = clusterName + crv1.RootSecretSuffix
+	cluster.Spec.PrimarySecretName = clusterName + crv1.PrimarySecretSuffix
+	cluster.Spec.UserSecretName = fmt.Sprintf("%s-%s%s", clusterName, cluster.Spec.User, crv1.UserSecretSuffix)
+
+	// the other users are only known to PostgreSQL if the data of the cluster
+	// is restored, so their secrets are not created
+	for _, user := range export.Users {
+		if user.Username == cluster.Spec.User {
+			continue
+		}
+		if _, found, _ := kubeapi.GetSecret(apiserver.Clientset, user.SecretName, ns); !found {
+			resp.Results = append(resp.Results,
+				fmt.Sprintf("secret %s of user %s not found", user.SecretName, user.Username))
+		}
+	}
+
+	secretName := fmt.Sprintf("%s-%s", clusterName, config.LABEL_BACKREST_REPO_SECRET)
+	if _, _, err := kubeapi.GetSecret(apiserver.Clientset, secretName, ns); kerrors.IsNotFound(err) {
+		// an S3, GCS or Azure repository may already hold the backups of the
+		// cluster, which a new passphrase cannot decrypt
+		if err := validateApplyBackrestCipher(cluster, secretName); err != nil {
+			resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+			return resp
+		}
+
+		var cipherPass string
+		if util.IsBackrestCipherEnabled(cluster) {
+			if cipherPass, err = util.GenerateBackrestCipherPass(); err != nil {
+				resp.Status = msgs.Status{Code: msgs.Error,
+					Msg: fmt.Sprintf("could not generate backrest cipher passphrase: %s", err)}
+				return resp
+			}
+		}
+
+		backrestRepoConfig := util.BackrestRepoConfig{
+			BackrestCipherPass: cipherPass,
+			ClusterName:        clusterName,
+			ClusterNamespace:   ns,
+			OperatorNamespace:  apiserver.PgoNamespace,
+		}
+
+		if err := util.CreateBackrestRepoSecrets(apiserver.Clientset, backrestRepoConfig); err != nil {
+			resp.Status = msgs.Status{Code: msgs.Error,
+				Msg: fmt.Sprintf("could not create backrest repo secret: %s", err)}
+			return resp
+		}
+		created.secrets = append(created.secrets, secretName)
+		resp.Results = append(resp.Results, fmt.Sprintf("created secret %s without any cloud storage credentials",
+			secretName))
+	} else if err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error,
+			Msg: fmt.Sprintf("could not query if backrest repo secret exits: %s", err)}
+		return resp
+	}
+
+	workflowTask, err := newWorkflowTask(clusterName, ns, pgouser)
+	if err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		return resp
+	}
+	if err := kubeapi.Createpgtask(apiserver.RESTClient, workflowTask, ns); err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		return resp
+	}
+	created.tasks = append(created.tasks, workflowTask.Name)
+	cluster.Spec.UserLabels[config.LABEL_WORKFLOW_ID] = workflowTask.Spec.Parameters[crv1.PgtaskWorkflowID]
+
+	// the pgreplicas are created ahead of the pgcluster so that the Operator
+	// does not create replicas of its own for them
+	for _, exported := range export.Replicas {
+		replica := exported
+		replica.ObjectMeta = meta_v1.ObjectMeta{
+			Name:        exported.Name,
+			Labels:      copyExportLabels(exported.Labels),
+			Annotations: exported.Annotations,
+		}
+		replica.ObjectMeta.Labels[config.LABEL_PGOUSER] = pgouser
+		replica.Spec.Namespace = ns
+		replica.Status = crv1.PgreplicaStatus{}
+
+		if err := kubeapi.Createpgreplica(apiserver.RESTClient, &replica, ns); err != nil {
+			resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+			return resp
+		}
+		created.replicas = append(created.replicas, replica.Name)
+		resp.Results = append(resp.Results, fmt.Sprintf("created replica %s", replica.Name))
+	}
+
+	if err := kubeapi.Createpgcluster(apiserver.RESTClient, &cluster, ns); err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		return resp
+	}
+	clusterCreated = true
+	resp.Results = append(resp.Results, fmt.Sprintf("created cluster %s", clusterName))
+
+	for _, exported := range export.Schedules {
+		schedule := scheduleservice.PgScheduleSpec{}
+		if err := json.Unmarshal(exported, &schedule); err != nil {
+			resp.Status = msgs.Status{Code: msgs.Error, Msg: fmt.Sprintf("invalid schedule: %s", err)}
+			return resp
+		}
+		schedule.Namespace = ns
+		schedule.Created = time.Now().Format(time.RFC3339)
+
+		if _, exists := kubeapi.GetConfigMap(apiserver.Clientset, schedule.Name, ns); exists {
+			resp.Results = append(resp.Results, fmt.Sprintf("schedule %s already exists", schedule.Name))
+			continue
+		}
+
+		blob, err := json.Marshal(schedule)
+		if err != nil {
+			resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+			return resp
+		}
+
+		if err := kubeapi.CreateConfigMap(apiserver.Clientset,
+			scheduleservice.NewScheduleConfigMap(&schedule, blob), ns); err != nil {
+			resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+			return resp
+		}
+		resp.Results = append(resp.Results, fmt.Sprintf("created schedule %s", schedule.Name))
+	}
+
+	return resp
+}
+
+// validateClusterExport checks that an export describes a cluster that can be
+// created in the namespace provided, i.e. that the cluster does not exist yet
+// and that the objects it refers to exist
+func validateClusterExport(export *msgs.ClusterExport, ns string) error {
+	cluster := export.Cluster
+
+	if errs := validation.IsDNS1035Label(cluster.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid cluster name format %s", errs[0])
+	}
+
+	if cluster.Spec.Name != cluster.Name || cluster.Spec.ClusterName != cluster.Name {
+		return fmt.Errorf("the names in the spec of cluster %s do not match its name", cluster.Name)
+	}
+
+	if found, _ := kubeapi.Getpgcluster(apiserver.RESTClient, &crv1.Pgcluster{}, cluster.Name, ns); found {
+		return fmt.Errorf("pgcluster %s was found so we will not create it", cluster.Name)
+	}
+
+	for _, replica := range export.Replicas {
+		if replica.Spec.ClusterName != cluster.Name {
+			return fmt.Errorf("replica %s does not belong to cluster %s", replica.Name, cluster.Name)
+		}
+	}
+
+	for _, schedule := range export.Schedules {
+		spec := scheduleservice.PgScheduleSpec{}
+		if err := json.Unmarshal(schedule, &spec); err != nil {
+			return fmt.Errorf("invalid schedule: %s", err)
+		}
+		if spec.Cluster != cluster.Name {
+			return fmt.Errorf("schedule %s does not belong to cluster %s", spec.Name, cluster.Name)
+		}
+	}
+
+	if cluster.Spec.CustomConfig != "" {
+		if _, found := kubeapi.GetConfigMap(apiserver.Clientset, cluster.Spec.CustomConfig, ns); !found {
+			return fmt.Errorf("configmap %s of cluster %s not found", cluster.Spec.CustomConfig, cluster.Name)
+		}
+	}
+
+	// the Operator creates the secrets of a cluster whose certificates it
+	// manages
+	for _, secretName := range []string{cluster.Spec.TLS.CASecret, cluster.Spec.TLS.TLSSecret} {
+		if secretName == "" || cluster.Spec.TLS.Auto {
+			continue
+		}
+		if _, found, _ := kubeapi.GetSecret(apiserver.Clientset, secretName, ns); !found {
+			return fmt.Errorf("secret %s of cluster %s not found", secretName, cluster.Name)
+		}
+	}
+
+	return nil
+}
+
+// validateApplyBackrestCipher checks that the pgBackRest repo secret of a
+// cluster that is applied can be created with a new passphrase. pgBackRest
+// cannot change the passphrase of an existing repository, so the secret of an
+// encrypted cluster that uses S3, GCS or Azure storage has to be created with
+// the passphrase of its repository before the cluster is applied
+func validateApplyBackrestCipher(cluster crv1.Pgcluster, secretName string) error {
+	storageType := util.GetBackrestCloudStorageType(cluster.Spec.UserLabels[config.LABEL_BACKREST_STORAGE_TYPE])
+
+	if !util.IsBackrestCipherEnabled(cluster) || storageType == "" {
+		return nil
+	}
+
+	return fmt.Errorf("the pgBackRest repository of cluster %s is encrypted and uses %s storage: "+
+		"create secret %s with the passphrase of the repository in its %q key before applying the cluster",
+		cluster.Name, storageType, secretName, util.BackRestRepoSecretKeyCipherPass)
+}
+
+// exportPgcluster returns a copy of a pgcluster without the state the Operator
+// keeps in it, with the policies provided as the policies of the cluster
+func exportPgcluster(cluster *crv1.Pgcluster, policyNames []string) crv1.Pgcluster {
+	exported := crv1.Pgcluster{
+		TypeMeta: meta_v1.TypeMeta{
+			APIVersion: crv1.SchemeGroupVersion.String(),
+			Kind:       "Pgcluster",
+		},
+		ObjectMeta: exportObjectMeta(cluster.ObjectMeta),
+		Spec:       cluster.Spec,
+	}
+
+	exported.Spec.Namespace = ""
+	exported.Spec.Status = ""
+	exported.Spec.PswLastUpdate = ""
+	exported.Spec.ExpirationTime = nil
+	exported.Spec.Policies = strings.Join(policyNames, ",")
+	exported.Spec.UserLabels = exportLabels(cluster.Spec.UserLabels)
+
+	return exported
+}
+
+// exportPgreplica returns a copy of a pgreplica without the state the Operator
+// keeps in it
+func exportPgreplica(replica *crv1.Pgreplica) crv1.Pgreplica {
+	exported := crv1.Pgreplica{
+		TypeMeta: meta_v1.TypeMeta{
+			APIVersion: crv1.SchemeGroupVersion.String(),
+			Kind:       "Pgreplica",
+		},
+		ObjectMeta: exportObjectMeta(replica.ObjectMeta),
+		Spec:       replica.Spec,
+	}
+
+	exported.Spec.Namespace = ""
+	exported.Spec.Status = ""
+	exported.Spec.UserLabels = exportLabels(replica.Spec.UserLabels)
+
+	return exported
+}
+
+// exportPgpolicy returns a copy of a pgpolicy without its namespace and status
+func exportPgpolicy(policy *crv1.Pgpolicy) crv1.Pgpolicy {
+	exported := crv1.Pgpolicy{
+		TypeMeta: meta_v1.TypeMeta{
+			APIVersion: crv1.SchemeGroupVersion.String(),
+			Kind:       "Pgpolicy",
+		},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:   policy.Name,
+			Labels: policy.Labels,
+		},
+		Spec: policy.Spec,
+	}
+
+	exported.Spec.Namespace = ""
+	exported.Spec.Status = ""
+
+	return exported
+}
+
+// exportSchedule returns a schedule without the time it was created at and its
+// namespace
+func exportSchedule(blob []byte) (json.RawMessage, error) {
+	schedule := scheduleservice.PgScheduleSpec{}
+	if err := json.Unmarshal(blob, &schedule); err != nil {
+		return nil, err
+	}
+
+	schedule.Created = ""
+	schedule.Namespace = ""
+
+	return json.Marshal(schedule)
+}
+
+// exportObjectMeta returns the name, the labels and the annotations of an
+// object that are part of an export
+func exportObjectMeta(objectMeta meta_v1.ObjectMeta) meta_v1.ObjectMeta {
+	exported := meta_v1.ObjectMeta{
+		Name:   objectMeta.Name,
+		Labels: exportLabels(objectMeta.Labels),
+	}
+
+	for _, annotation := range exportedAnnotations {
+		if value, ok := objectMeta.Annotations[annotation]; ok {
+			if exported.Annotations == nil {
+				exported.Annotations = map[string]string{}
+			}
+			exported.Annotations[annotation] = value
+		}
+	}
+
+	return exported
+}
+
+// exportLabels returns the labels that are part of an export
+func exportLabels(labels map[string]string) map[string]string {
+	exported := map[string]string{}
+
+	for k, v := range labels {
+		if exportIgnoredLabels[k] || v == config.LABEL_PGPOLICY {
+			continue
+		}
+		exported[k] = v
+	}
+
+	return exported
+}
+
+// copyExportLabels returns a copy of the labels of an export that is never nil
+func copyExportLabels(labels map[string]string) map[string]string {
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return copied
+}
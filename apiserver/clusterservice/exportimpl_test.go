@@ -0,0 +1,203 @@
+package clusterservice
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExportPgcluster(t *testing.T) {
+	cluster := &crv1.Pgcluster{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:            "hippo",
+			Namespace:       "pgouser1",
+			ResourceVersion: "42",
+			Finalizers:      []string{crv1.PgclusterFinalizer},
+			Labels: map[string]string{
+				config.LABEL_PG_CLUSTER:      "hippo",
+				config.LABEL_CURRENT_PRIMARY: "hippo-abcd",
+				config.LABEL_PGOUSER:         "admin",
+				config.LABEL_PGBOUNCER:       "true",
+				"rhino":                      config.LABEL_PGPOLICY,
+				"team":                       "datalake",
+			},
+			Annotations: map[string]string{
+				config.ANNOTATION_KEEP_DATA:          "true",
+				config.ANNOTATION_PRIMARY_DEPLOYMENT: "hippo-abcd",
+			},
+		},
+		Spec: crv1.PgclusterSpec{
+			Namespace:     "pgouser1",
+			Name:          "hippo",
+			ClusterName:   "hippo",
+			Replicas:      "2",
+			Status:        "completed",
+			PswLastUpdate: "2020-03-01T00:00:00Z",
+			UserLabels: map[string]string{
+				config.LABEL_WORKFLOW_ID: "1234",
+				config.LABEL_PGO_VERSION: "4.3.0",
+			},
+		},
+		Status: crv1.PgclusterStatus{State: crv1.PgclusterStateInitialized},
+	}
+
+	exported := exportPgcluster(cluster, []string{"rhino"})
+
+	expectedLabels := map[string]string{
+		config.LABEL_PG_CLUSTER: "hippo",
+		"team":                  "datalake",
+	}
+	if !reflect.DeepEqual(exported.Labels, expectedLabels) {
+		t.Fatalf("expected labels %v, got %v", expectedLabels, exported.Labels)
+	}
+
+	expectedAnnotations := map[string]string{config.ANNOTATION_KEEP_DATA: "true"}
+	if !reflect.DeepEqual(exported.Annotations, expectedAnnotations) {
+		t.Fatalf("expected annotations %v, got %v", expectedAnnotations, exported.Annotations)
+	}
+
+	expectedUserLabels := map[string]string{config.LABEL_PGO_VERSION: "4.3.0"}
+	if !reflect.DeepEqual(exported.Spec.UserLabels, expectedUserLabels) {
+		t.Fatalf("expected user labels %v, got %v", expectedUserLabels, exported.Spec.UserLabels)
+	}
+
+	if exported.Namespace != "" || exported.ResourceVersion != "" || len(exported.Finalizers) != 0 {
+		t.Fatalf("expected the object metadata to be removed, got %+v", exported.ObjectMeta)
+	}
+
+	if exported.Spec.Namespace != "" || exported.Spec.Status != "" || exported.Spec.PswLastUpdate != "" {
+		t.Fatalf("expected the state in the spec to be removed, got %+v", exported.Spec)
+	}
+
+	if exported.Spec.Policies != "rhino" || exported.Spec.Replicas != "2" {
+		t.Fatalf("expected the settings of the spec to be kept, got %+v", exported.Spec)
+	}
+
+	if exported.Status.State != "" {
+		t.Fatalf("expected the status to be removed, got %+v", exported.Status)
+	}
+
+	if exported.Kind != "Pgcluster" || exported.APIVersion != "crunchydata.com/v1" {
+		t.Fatalf("expected the kind to be set, got %+v", exported.TypeMeta)
+	}
+
+	if cluster.Labels[config.LABEL_CURRENT_PRIMARY] == "" || cluster.Spec.UserLabels[config.LABEL_WORKFLOW_ID] == "" {
+		t.Fatalf("expected the original cluster to be left alone")
+	}
+}
+
+func TestExportSchedule(t *testing.T) {
+	tests := []struct {
+		blob     string
+		expected map[string]interface{}
+	}{
+		{
+			blob: `{"version":"v1","name":"hippo-pgbackrest-full","cluster":"hippo",` +
+				`"created":"2020-03-01T00:00:00Z","schedule":"0 1 * * *","namespace":"pgouser1",` +
+				`"type":"pgbackrest","pgbackrest":{"type":"full"},"policy":{}}`,
+			expected: map[string]interface{}{
+				"version":    "v1",
+				"name":       "hippo-pgbackrest-full",
+				"cluster":    "hippo",
+				"created":    "",
+				"schedule":   "0 1 * * *",
+				"namespace":  "",
+				"type":       "pgbackrest",
+				"pgbackrest": map[string]interface{}{"type": "full"},
+				"policy":     map[string]interface{}{},
+				"hibernate":  map[string]interface{}{},
+			},
+		},
+		{
+			blob: `{"version":"v1","name":"hippo-hibernate","cluster":"hippo",` +
+				`"created":"2020-03-01T00:00:00Z","schedule":"","namespace":"pgouser1",` +
+				`"type":"hibernate","pgbackrest":{},"policy":{},` +
+				`"hibernate":{"stop":"0 19 * * 1-5","start":"0 7 * * 1-5"}}`,
+			expected: map[string]interface{}{
+				"version":    "v1",
+				"name":       "hippo-hibernate",
+				"cluster":    "hippo",
+				"created":    "",
+				"schedule":   "",
+				"namespace":  "",
+				"type":       "hibernate",
+				"pgbackrest": map[string]interface{}{},
+				"policy":     map[string]interface{}{},
+				"hibernate": map[string]interface{}{
+					"stop":  "0 19 * * 1-5",
+					"start": "0 7 * * 1-5",
+				},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		exported, err := exportSchedule([]byte(test.blob))
+		if err != nil {
+			t.Fatalf("tests[%d] - %s", i, err)
+		}
+
+		actual := map[string]interface{}{}
+		if err := json.Unmarshal(exported, &actual); err != nil {
+			t.Fatalf("tests[%d] - %s", i, err)
+		}
+
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Fatalf("tests[%d] - expected %v, got %v", i, test.expected, actual)
+		}
+	}
+}
+
+func TestValidateApplyBackrestCipher(t *testing.T) {
+	tests := []struct {
+		cipherType  string
+		storageType string
+		err         bool
+	}{
+		{"", "s3", false},
+		{"none", "azure", false},
+		{"aes-256-cbc", "", false},
+		{"aes-256-cbc", "local", false},
+		{"aes-256-cbc", "local,s3", true},
+		{"aes-256-cbc", "gcs", true},
+	}
+
+	for i, test := range tests {
+		cluster := crv1.Pgcluster{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "hippo"},
+			Spec: crv1.PgclusterSpec{
+				BackrestCipherType: test.cipherType,
+				UserLabels:         map[string]string{config.LABEL_BACKREST_STORAGE_TYPE: test.storageType},
+			},
+		}
+
+		err := validateApplyBackrestCipher(cluster, "hippo-backrest-repo-config")
+
+		if test.err && err == nil {
+			t.Fatalf("tests[%d] - expected an error", i)
+		}
+
+		if !test.err && err != nil {
+			t.Fatalf("tests[%d] - expected no error, got %s", i, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package clusterservice
+
+/*
+Copyright 2020 Crunchy Data Solutions, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/crunchydata/postgres-operator/apiserver"
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+	log "github.com/sirupsen/logrus"
+)
+
+// ExportClusterHandler ...
+// pgo export cluster mycluster
+// returns an ExportClusterResponse
+func ExportClusterHandler(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /clusterexport clusterservice clusterexport
+	/*```
+	  Export the definition of a PostgreSQL cluster
+	*/
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: "Export Request"
+	//   in: "body"
+	//   schema:
+	//     "$ref": "#/definitions/ExportClusterRequest"
+	//	responses:
+	//	  '200':
+	//	    description: Output
+	//	    schema:
+	//	      "$ref": "#/definitions/ExportClusterResponse"
+	var request msgs.ExportClusterRequest
+	_ = json.NewDecoder(r.Body).Decode(&request)
+
+	log.Debugf("clusterservice.ExportClusterHandler %v", request)
+
+	username, err := apiserver.Authn(apiserver.EXPORT_CLUSTER_PERM, w, r)
+	if err != nil {
+		return
+	}
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := msgs.ExportClusterResponse{}
+	resp.Status = msgs.Status{Code: msgs.Ok, Msg: ""}
+
+	if request.ClientVersion != msgs.PGO_VERSION {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: apiserver.VERSION_MISMATCH_ERROR}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	ns, err := apiserver.GetNamespace(apiserver.Clientset, username, request.Namespace)
+	if err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	resp = ExportCluster(request.ClusterName, ns)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ApplyClusterHandler ...
+// pgo apply -f mycluster.yaml
+// returns an ApplyClusterResponse
+func ApplyClusterHandler(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /clusterapply clusterservice clusterapply
+	/*```
+	  Create a PostgreSQL cluster from an exported definition
+	*/
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: "Apply Request"
+	//   in: "body"
+	//   schema:
+	//     "$ref": "#/definitions/ApplyClusterRequest"
+	//	responses:
+	//	  '200':
+	//	    description: Output
+	//	    schema:
+	//	      "$ref": "#/definitions/ApplyClusterResponse"
+	var request msgs.ApplyClusterRequest
+	_ = json.NewDecoder(r.Body).Decode(&request)
+
+	log.Debugf("clusterservice.ApplyClusterHandler %s", request.Export.Cluster.Name)
+
+	username, err := apiserver.Authn(apiserver.APPLY_CLUSTER_PERM, w, r)
+	if err != nil {
+		return
+	}
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := msgs.ApplyClusterResponse{}
+	resp.Status = msgs.Status{Code: msgs.Ok, Msg: ""}
+	resp.Results = make([]string, 0)
+
+	if request.ClientVersion != msgs.PGO_VERSION {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: apiserver.VERSION_MISMATCH_ERROR}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	ns, err := apiserver.GetNamespace(apiserver.Clientset, username, request.Namespace)
+	if err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	resp = ApplyCluster(&request.Export, ns, username)
+	json.NewEncoder(w).Encode(resp)
+}
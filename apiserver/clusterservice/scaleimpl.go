@@ -25,16 +25,19 @@ import (
 	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
 	"github.com/crunchydata/postgres-operator/config"
 	"github.com/crunchydata/postgres-operator/kubeapi"
+	snapshotoperator "github.com/crunchydata/postgres-operator/operator/snapshot"
 	"github.com/crunchydata/postgres-operator/util"
 
 	log "github.com/sirupsen/logrus"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
 )
 
 // ScaleCluster ...
 func ScaleCluster(name, replicaCount, resourcesConfig, storageConfig, nodeLabel,
-	ccpImageTag, serviceType, ns, pgouser string) msgs.ClusterScaleResponse {
+	ccpImageTag, serviceType, snapshot string, tolerations []string, priorityClassName, ns,
+	pgouser string) msgs.ClusterScaleResponse {
 	var err error
 
 	response := msgs.ClusterScaleResponse{}
@@ -87,6 +90,34 @@ func ScaleCluster(name, replicaCount, resourcesConfig, storageConfig, nodeLabel,
 		spec.ReplicaStorage, _ = apiserver.Pgo.GetStorageSpec(storageConfig)
 	}
 
+	// if the replica is provisioned from a snapshot backup, the snapshot has to
+	// be in the catalog of the cluster, and the storage has to be dynamic so
+	// that the PVCs can be provisioned from the VolumeSnapshots
+	if snapshot != "" {
+		if _, found := snapshotoperator.FindSnapshot(&cluster, snapshot); !found {
+			response.Status.Code = msgs.Error
+			response.Status.Msg = fmt.Sprintf("snapshot backup %s of cluster %s was not found", snapshot, cluster.Name)
+			return response
+		}
+
+		if spec.ReplicaStorage.StorageType != "dynamic" {
+			response.Status.Code = msgs.Error
+			response.Status.Msg = "replicas can only be created from a snapshot backup with dynamic storage"
+			return response
+		}
+
+		spec.Snapshot = snapshot
+	}
+
+	// the replicas may be scheduled differently than the rest of the cluster,
+	// otherwise they use the tolerations and priority class of the cluster
+	if spec.Tolerations, err = config.ParseTolerations(tolerations); err != nil {
+		response.Status.Code = msgs.Error
+		response.Status.Msg = err.Error()
+		return response
+	}
+	spec.PriorityClassName = priorityClassName
+
 	spec.UserLabels = cluster.Spec.UserLabels
 
 	if ccpImageTag != "" {
@@ -150,6 +181,7 @@ func ScaleCluster(name, replicaCount, resourcesConfig, storageConfig, nodeLabel,
 	labels[config.LABEL_PGOUSER] = pgouser
 	labels[config.LABEL_PG_CLUSTER_IDENTIFIER] = cluster.ObjectMeta.Labels[config.LABEL_PG_CLUSTER_IDENTIFIER]
 
+	created := 0
 	for i := 0; i < rc; i++ {
 
 		uniqueName := util.RandStringBytesRmndr(4)
@@ -178,11 +210,20 @@ func ScaleCluster(name, replicaCount, resourcesConfig, storageConfig, nodeLabel,
 			Do().Into(&result)
 		if err != nil {
 			log.Error(" in creating Pgreplica instance" + err.Error())
+		} else {
+			created++
 		}
 
 		response.Results = append(response.Results, "created Pgreplica "+labels[config.LABEL_NAME])
 	}
 
+	// keep the replica count of the cluster in sync, otherwise the Operator
+	// removes the new replicas when it reconciles the cluster
+	if err := updateReplicaCount(&cluster, created); err != nil {
+		response.Status.Code = msgs.Error
+		response.Status.Msg = "could not update the replica count of the cluster: " + err.Error()
+	}
+
 	return response
 }
 
@@ -346,6 +387,36 @@ func ScaleDown(deleteData bool, clusterName, replicaName, ns string) msgs.ScaleD
 		return response
 	}
 
+	// keep the replica count of the cluster in sync, otherwise the Operator
+	// replaces the replica when it reconciles the cluster
+	if err := updateReplicaCount(&cluster, -1); err != nil {
+		response.Status.Code = msgs.Error
+		response.Status.Msg = "could not update the replica count of the cluster: " + err.Error()
+		return response
+	}
+
 	response.Results = append(response.Results, "deleted replica "+replicaName)
 	return response
 }
+
+// updateReplicaCount adds the change provided to the replica count in the spec
+// of the latest version of a pgcluster, and adds it again if the pgcluster was
+// changed in the meantime, e.g. by another scale request
+func updateReplicaCount(cluster *crv1.Pgcluster, change int) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := crv1.Pgcluster{}
+		if _, err := kubeapi.Getpgcluster(apiserver.RESTClient, &current, cluster.Name, cluster.Namespace); err != nil {
+			return err
+		}
+
+		replicas, _ := strconv.Atoi(current.Spec.Replicas)
+
+		replicas += change
+		if replicas < 0 {
+			replicas = 0
+		}
+		current.Spec.Replicas = strconv.Itoa(replicas)
+
+		return kubeapi.Updatepgcluster(apiserver.RESTClient, &current, current.Name, current.Namespace)
+	})
+}
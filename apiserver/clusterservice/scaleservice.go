@@ -104,11 +104,15 @@ func ScaleClusterHandler(w http.ResponseWriter, r *http.Request) {
 	serviceType := r.URL.Query().Get(config.LABEL_SERVICE_TYPE)
 	clientVersion := r.URL.Query().Get(config.LABEL_VERSION)
 	ccpImageTag := r.URL.Query().Get(config.LABEL_CCP_IMAGE_TAG_KEY)
+	snapshot := r.URL.Query().Get(config.LABEL_SNAPSHOT)
+	tolerations := r.URL.Query()[config.LABEL_TOLERATION]
+	priorityClassName := r.URL.Query().Get(config.LABEL_PRIORITY_CLASS)
 
 	log.Debugf("ScaleClusterHandler parameters name [%s] namespace [%s] replica-count [%s] "+
 		"resources-config [%s] storage-config [%s] node-label [%s] service-type [%s] version [%s]"+
-		"ccp-image-tag [%s]", clusterName, namespace, replicaCount, resourcesConfig,
-		storageConfig, nodeLabel, serviceType, clientVersion, ccpImageTag)
+		"ccp-image-tag [%s] snapshot [%s] toleration %v priority-class [%s]", clusterName, namespace,
+		replicaCount, resourcesConfig, storageConfig, nodeLabel, serviceType, clientVersion, ccpImageTag,
+		snapshot, tolerations, priorityClassName)
 
 	username, err := apiserver.Authn(apiserver.SCALE_CLUSTER_PERM, w, r)
 	if err != nil {
@@ -136,7 +140,7 @@ func ScaleClusterHandler(w http.ResponseWriter, r *http.Request) {
 
 	// TODO too many params need to create a struct for this
 	resp = ScaleCluster(clusterName, replicaCount, resourcesConfig, storageConfig, nodeLabel,
-		ccpImageTag, serviceType, ns, username)
+		ccpImageTag, serviceType, snapshot, tolerations, priorityClassName, ns, username)
 
 	json.NewEncoder(w).Encode(resp)
 }
@@ -17,7 +17,9 @@ limitations under the License.
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
+	"time"
 
 	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
 	"github.com/crunchydata/postgres-operator/config"
@@ -38,10 +40,13 @@ const (
 	// ErrMessagePVCSize provides a standard error message when a PVCSize is not
 	// specified to the Kubernetes stnadard
 	ErrMessagePVCSize = `could not parse PVC size "%s": %s (hint: try a value like "1Gi")`
+	// ErrMessageTTL provides a standard error message when a TTL is not a valid
+	// duration
+	ErrMessageTTL = `could not parse TTL "%s": %s (hint: try a value like "72h")`
 )
 
 var (
-	backrestStorageTypes = []string{"local", "s3"}
+	backrestStorageTypes = []string{"local", "s3", "gcs", "azure"}
 	// ErrDBContainerNotFound is an error that indicates that a "database" container
 	// could not be found in a specific pod
 	ErrDBContainerNotFound = errors.New("\"database\" container not found in pod")
@@ -166,6 +171,21 @@ func ValidateQuantity(quantity string) error {
 	return err
 }
 
+// ValidateTTL ensures that a TTL is a positive duration, e.g. "72h", and
+// returns it
+func ValidateTTL(ttl string) (time.Duration, error) {
+	duration, err := time.ParseDuration(ttl)
+	if err != nil {
+		return 0, err
+	}
+
+	if duration <= 0 {
+		return 0, fmt.Errorf("must be greater than zero")
+	}
+
+	return duration, nil
+}
+
 // FindStandbyClusters takes a list of pgcluster structs and returns a slice containing the names
 // of those clusters that are in standby mode as indicated by whether or not the standby prameter
 // in the pgcluster spec is true.
@@ -186,3 +206,15 @@ func PGClusterListHasStandby(clusterList crv1.PgclusterList) (bool, []string) {
 	standbyClusters := FindStandbyClusters(clusterList)
 	return len(FindStandbyClusters(clusterList)) > 0, standbyClusters
 }
+
+// FindProtectedClusters returns the names of the clusters in a list of
+// pgclusters that have deletion protection enabled
+func FindProtectedClusters(clusterList crv1.PgclusterList) []string {
+	protected := make([]string, 0)
+	for _, cluster := range clusterList.Items {
+		if cluster.Spec.DeletionProtection {
+			protected = append(protected, cluster.Name)
+		}
+	}
+	return protected
+}
@@ -0,0 +1,51 @@
+package apiserver
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"reflect"
+	"testing"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFindProtectedClusters(t *testing.T) {
+	tests := []struct {
+		protected map[string]bool
+		names     []string
+		expected  []string
+	}{
+		{map[string]bool{}, []string{}, []string{}},
+		{map[string]bool{"hippo": false, "rhino": false}, []string{"hippo", "rhino"}, []string{}},
+		{map[string]bool{"hippo": true, "rhino": false}, []string{"hippo", "rhino"}, []string{"hippo"}},
+		{map[string]bool{"hippo": true, "rhino": true}, []string{"hippo", "rhino"}, []string{"hippo", "rhino"}},
+	}
+
+	for i, test := range tests {
+		clusterList := crv1.PgclusterList{}
+		for _, name := range test.names {
+			clusterList.Items = append(clusterList.Items, crv1.Pgcluster{
+				ObjectMeta: meta_v1.ObjectMeta{Name: name},
+				Spec:       crv1.PgclusterSpec{DeletionProtection: test.protected[name]},
+			})
+		}
+
+		if protected := FindProtectedClusters(clusterList); !reflect.DeepEqual(protected, test.expected) {
+			t.Fatalf("tests[%d] - expected %v, got %v", i, test.expected, protected)
+		}
+	}
+}
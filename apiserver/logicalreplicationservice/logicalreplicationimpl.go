@@ -0,0 +1,415 @@
+package logicalreplicationservice
+
+/*
+Copyright 2020 Crunchy Data Solutions, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/apiserver"
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	clusteroperator "github.com/crunchydata/postgres-operator/operator/cluster"
+	"github.com/crunchydata/postgres-operator/util"
+	log "github.com/sirupsen/logrus"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+)
+
+// logicalReplicationNameMaxLength is the maximum length of the name of a
+// publication or a subscription, i.e. NAMEDATALEN - 1
+const logicalReplicationNameMaxLength = 63
+
+var (
+	// validLogicalReplicationName matches the names of the publications and
+	// subscriptions that the Operator manages, which need no quoting
+	validLogicalReplicationName = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+	// validTableName matches the name of a table, which may be qualified by its
+	// schema
+	validTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)?$`)
+)
+
+// CreatePublication creates a publication of the tables of a database of a
+// cluster, and the user that the subscriptions of other clusters connect as
+// pgo create publication mycluster --name=orders --database=hippo --table=orders
+func CreatePublication(request *msgs.CreatePublicationRequest, ns, pgouser string) msgs.CreatePublicationResponse {
+	resp := msgs.CreatePublicationResponse{}
+	resp.Status.Code = msgs.Ok
+	resp.Results = []string{}
+
+	publication := crv1.PublicationSpec{
+		Name:     request.Name,
+		Database: request.Database,
+		Tables:   request.Tables,
+	}
+
+	if err := validateLogicalReplicationName("publication", publication.Name); err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = err.Error()
+		return resp
+	}
+
+	if publication.Database == "" {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = "the database of the publication is required"
+		return resp
+	}
+
+	for _, table := range publication.Tables {
+		if !validTableName.MatchString(table) {
+			resp.Status.Code = msgs.Error
+			resp.Status.Msg = fmt.Sprintf("invalid table name %q", table)
+			return resp
+		}
+	}
+
+	cluster, err := getLogicalReplicationCluster(request.ClusterName, ns)
+	if err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = err.Error()
+		return resp
+	}
+
+	if cluster.Spec.GetPublication(publication.Name) != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = fmt.Sprintf("cluster %s already has publication %s", cluster.Name, publication.Name)
+		return resp
+	}
+
+	password, err := getLogicalReplicationPassword(cluster)
+	if err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = err.Error()
+		return resp
+	}
+
+	log.Debugf("creating publication %s in database %s of cluster %s", publication.Name,
+		publication.Database, cluster.Name)
+
+	if _, err := util.ExecSQL(apiserver.Clientset, apiserver.RESTConfig, ns, cluster.Name,
+		publication.Database, util.CreateLogicalReplicationUserSQL(password)+
+			util.CreatePublicationSQL(publication)); err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = fmt.Sprintf("could not create publication %s: %v", publication.Name, err)
+		return resp
+	}
+
+	// the publication is dropped again if it cannot be recorded, as it would
+	// otherwise never be cleaned up
+	if err := updateCluster(cluster.Name, ns, func(cluster *crv1.Pgcluster) {
+		if cluster.Spec.GetPublication(publication.Name) == nil {
+			cluster.Spec.Publications = append(cluster.Spec.Publications, publication)
+		}
+	}); err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = fmt.Sprintf("could not record publication %s: %v", publication.Name, err)
+
+		if _, err := util.ExecSQL(apiserver.Clientset, apiserver.RESTConfig, ns, cluster.Name,
+			publication.Database, util.DropPublicationSQL(publication.Name)); err != nil {
+			log.Error(err)
+		}
+
+		return resp
+	}
+
+	resp.Results = append(resp.Results, fmt.Sprintf("created publication %s in database %s of cluster %s",
+		publication.Name, publication.Database, cluster.Name))
+
+	return resp
+}
+
+// CreateSubscription creates a subscription of a database of a cluster to a
+// publication of another cluster in the same namespace, which is connected to
+// through its Service. The NetworkPolicies of the source cluster are updated
+// first so that the cluster can connect
+// pgo create subscription mycluster --source-cluster=hippo --publication=orders
+func CreateSubscription(request *msgs.CreateSubscriptionRequest, ns, pgouser string) msgs.CreateSubscriptionResponse {
+	resp := msgs.CreateSubscriptionResponse{}
+	resp.Status.Code = msgs.Ok
+	resp.Results = []string{}
+
+	subscription := crv1.SubscriptionSpec{
+		Name:          request.Name,
+		Database:      request.Database,
+		SourceCluster: request.SourceCluster,
+		Publication:   request.Publication,
+	}
+
+	if subscription.Name == "" {
+		subscription.Name = subscription.Publication
+	}
+
+	if err := validateLogicalReplicationName("subscription", subscription.Name); err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = err.Error()
+		return resp
+	}
+
+	if subscription.SourceCluster == "" || subscription.SourceCluster == request.ClusterName {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = "the source cluster has to be another cluster"
+		return resp
+	}
+
+	cluster, err := getLogicalReplicationCluster(request.ClusterName, ns)
+	if err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = err.Error()
+		return resp
+	}
+
+	source, err := getLogicalReplicationCluster(subscription.SourceCluster, ns)
+	if err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = err.Error()
+		return resp
+	}
+
+	publication := source.Spec.GetPublication(subscription.Publication)
+	if publication == nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = fmt.Sprintf("cluster %s has no publication %q", source.Name, subscription.Publication)
+		return resp
+	}
+
+	if subscription.Database == "" {
+		subscription.Database = publication.Database
+	}
+
+	// the subscriptions are named uniquely in a cluster, as their replication
+	// slots are named after them
+	if cluster.Spec.GetSubscription(subscription.Name) != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = fmt.Sprintf("cluster %s already has subscription %s", cluster.Name, subscription.Name)
+		return resp
+	}
+
+	secret, found, err := kubeapi.GetSecret(apiserver.Clientset,
+		util.GenerateLogicalReplicationSecretName(source.Name), ns)
+	if !found {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = fmt.Sprintf("could not get the logical replication credentials of cluster %s: %v",
+			source.Name, err)
+		return resp
+	}
+
+	// let the cluster connect to the source cluster before subscribing, and
+	// restore the NetworkPolicies if the subscription cannot be created
+	original := source.Spec.Publications
+	source.Spec.Publications = make([]crv1.PublicationSpec, len(original))
+	copy(source.Spec.Publications, original)
+
+	publication = source.Spec.GetPublication(subscription.Publication)
+	subscribed := hasSubscriber(publication.Subscribers, cluster.Name)
+	publication.Subscribers = appendSubscriber(publication.Subscribers, cluster.Name)
+
+	if err := clusteroperator.ReconcileNetworkPolicies(apiserver.Clientset, source); err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = err.Error()
+		return resp
+	}
+
+	log.Debugf("creating subscription %s of cluster %s to publication %s of cluster %s",
+		subscription.Name, cluster.Name, subscription.Publication, source.Name)
+
+	if _, err := util.ExecSQL(apiserver.Clientset, apiserver.RESTConfig, ns, cluster.Name,
+		subscription.Database, util.CreateSubscriptionSQL(cluster.Name, subscription,
+			fmt.Sprintf("%s.%s.svc", source.Name, ns), source.Spec.Port, publication.Database,
+			string(secret.Data["password"]))); err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = fmt.Sprintf("could not create subscription %s: %v", subscription.Name, err)
+
+		source.Spec.Publications = original
+		if err := clusteroperator.ReconcileNetworkPolicies(apiserver.Clientset, source); err != nil {
+			log.Error(err)
+		}
+
+		return resp
+	}
+
+	// record the subscription on both clusters, so that it is cleaned up when
+	// either of them is deleted. If it cannot be recorded, the subscription and
+	// its replication slot are dropped again
+	if err := recordSubscription(cluster.Name, source.Name, ns, subscription); err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = fmt.Sprintf("could not record subscription %s: %v", subscription.Name, err)
+
+		if err := util.DropSubscription(apiserver.Clientset, apiserver.RESTConfig, ns, cluster.Name,
+			subscription); err != nil {
+			log.Error(err)
+		}
+
+		// the cluster may subscribe to the publication already
+		if !subscribed {
+			if err := updateCluster(source.Name, ns, func(source *crv1.Pgcluster) {
+				removeSubscriber(source, subscription.Publication, cluster.Name)
+			}); err != nil {
+				log.Error(err)
+			}
+		}
+
+		source.Spec.Publications = original
+		if err := clusteroperator.ReconcileNetworkPolicies(apiserver.Clientset, source); err != nil {
+			log.Error(err)
+		}
+
+		return resp
+	}
+
+	resp.Results = append(resp.Results, fmt.Sprintf(
+		"created subscription %s in database %s of cluster %s to publication %s of cluster %s",
+		subscription.Name, subscription.Database, cluster.Name, subscription.Publication, source.Name))
+
+	return resp
+}
+
+// recordSubscription adds a subscription to the spec of the cluster that
+// subscribes, and the cluster to the subscribers of the publication of the
+// source cluster
+func recordSubscription(clusterName, sourceName, ns string, subscription crv1.SubscriptionSpec) error {
+	if err := updateCluster(sourceName, ns, func(source *crv1.Pgcluster) {
+		if publication := source.Spec.GetPublication(subscription.Publication); publication != nil {
+			publication.Subscribers = appendSubscriber(publication.Subscribers, clusterName)
+		}
+	}); err != nil {
+		return err
+	}
+
+	return updateCluster(clusterName, ns, func(cluster *crv1.Pgcluster) {
+		if cluster.Spec.GetSubscription(subscription.Name) == nil {
+			cluster.Spec.Subscriptions = append(cluster.Spec.Subscriptions, subscription)
+		}
+	})
+}
+
+// updateCluster applies a change to the latest version of a pgcluster, and
+// applies it again if the pgcluster was changed in the meantime
+func updateCluster(name, ns string, change func(*crv1.Pgcluster)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cluster := crv1.Pgcluster{}
+		if _, err := kubeapi.Getpgcluster(apiserver.RESTClient, &cluster, name, ns); err != nil {
+			return err
+		}
+
+		change(&cluster)
+
+		return kubeapi.Updatepgcluster(apiserver.RESTClient, &cluster, name, ns)
+	})
+}
+
+// getLogicalReplicationCluster returns a cluster that takes part in logical
+// replication, which has to run PostgreSQL 10 or later and cannot be a
+// standby, as it is written to
+func getLogicalReplicationCluster(name, ns string) (*crv1.Pgcluster, error) {
+	cluster := crv1.Pgcluster{}
+	if found, err := kubeapi.Getpgcluster(apiserver.RESTClient, &cluster, name, ns); !found {
+		if kerrors.IsNotFound(err) {
+			return nil, fmt.Errorf("cluster %s not found", name)
+		}
+		return nil, err
+	}
+
+	if cluster.Spec.Standby {
+		return nil, fmt.Errorf("cluster %s: %v", name, apiserver.ErrStandbyNotAllowed)
+	}
+
+	version, err := util.GetPostgresMajorVersion(cluster.Spec.CCPImageTag)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(version, "9.") {
+		return nil, fmt.Errorf("cluster %s runs PostgreSQL %s, logical replication requires 10 or later",
+			name, version)
+	}
+
+	return &cluster, nil
+}
+
+// getLogicalReplicationPassword returns the password of the logical
+// replication user of a cluster, generating it and storing it in a secret the
+// first time a publication of the cluster is created
+func getLogicalReplicationPassword(cluster *crv1.Pgcluster) (string, error) {
+	secret, found, err := kubeapi.GetSecret(apiserver.Clientset,
+		util.GenerateLogicalReplicationSecretName(cluster.Name), cluster.Namespace)
+	if found {
+		return string(secret.Data["password"]), nil
+	} else if !kerrors.IsNotFound(err) {
+		return "", err
+	}
+
+	password := util.GeneratePassword(util.GeneratedPasswordLength(apiserver.Pgo.Cluster.PasswordLength))
+
+	if err := util.CreateUserSecret(apiserver.Clientset, cluster.Name, crv1.PGUserLogicalReplication,
+		password, cluster.Namespace); err != nil {
+		return "", err
+	}
+
+	return password, nil
+}
+
+// validateLogicalReplicationName returns an error if the name of a publication
+// or a subscription is not a lowercase PostgreSQL identifier
+func validateLogicalReplicationName(kind, name string) error {
+	if !validLogicalReplicationName.MatchString(name) || len(name) > logicalReplicationNameMaxLength {
+		return fmt.Errorf("invalid %s name %q, it has to consist of lowercase letters, digits "+
+			"and underscores and be at most %d characters long", kind, name, logicalReplicationNameMaxLength)
+	}
+
+	return nil
+}
+
+// removeSubscriber removes a cluster from the subscribers of a publication of
+// a cluster
+func removeSubscriber(cluster *crv1.Pgcluster, publicationName, name string) {
+	publication := cluster.Spec.GetPublication(publicationName)
+	if publication == nil {
+		return
+	}
+
+	subscribers := []string{}
+	for _, subscriber := range publication.Subscribers {
+		if subscriber != name {
+			subscribers = append(subscribers, subscriber)
+		}
+	}
+
+	publication.Subscribers = subscribers
+}
+
+// appendSubscriber adds a cluster to the subscribers of a publication, unless
+// it subscribes to it already
+func appendSubscriber(subscribers []string, name string) []string {
+	if hasSubscriber(subscribers, name) {
+		return subscribers
+	}
+
+	return append(append([]string{}, subscribers...), name)
+}
+
+// hasSubscriber returns true if a cluster subscribes to a publication
+func hasSubscriber(subscribers []string, name string) bool {
+	for _, subscriber := range subscribers {
+		if subscriber == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,135 @@
+package logicalreplicationservice
+
+/*
+Copyright 2020 Crunchy Data Solutions, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/crunchydata/postgres-operator/apiserver"
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+	log "github.com/sirupsen/logrus"
+)
+
+// CreatePublicationHandler ...
+// pgo create publication
+func CreatePublicationHandler(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /publication logicalreplicationservice publication-post
+	/*```
+	  Create a publication of a database of a cluster
+	*/
+	// ---
+	//  produces:
+	//  - application/json
+	//  parameters:
+	//  - name: "Create Publication Request"
+	//    in: "body"
+	//    schema:
+	//      "$ref": "#/definitions/CreatePublicationRequest"
+	//  responses:
+	//    '200':
+	//      description: Output
+	//      schema:
+	//        "$ref": "#/definitions/CreatePublicationResponse"
+	log.Debug("logicalreplicationservice.CreatePublicationHandler called")
+	username, err := apiserver.Authn(apiserver.CREATE_PUBLICATION_PERM, w, r)
+	if err != nil {
+		return
+	}
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	var request msgs.CreatePublicationRequest
+	_ = json.NewDecoder(r.Body).Decode(&request)
+
+	resp := msgs.CreatePublicationResponse{}
+	resp.Status = msgs.Status{Code: msgs.Ok, Msg: ""}
+
+	if request.ClientVersion != msgs.PGO_VERSION {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = apiserver.VERSION_MISMATCH_ERROR
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	ns, err := apiserver.GetNamespace(apiserver.Clientset, username, request.Namespace)
+	if err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = err.Error()
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	resp = CreatePublication(&request, ns, username)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// CreateSubscriptionHandler ...
+// pgo create subscription
+func CreateSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /subscription logicalreplicationservice subscription-post
+	/*```
+	  Create a subscription of a cluster to a publication of another cluster
+	*/
+	// ---
+	//  produces:
+	//  - application/json
+	//  parameters:
+	//  - name: "Create Subscription Request"
+	//    in: "body"
+	//    schema:
+	//      "$ref": "#/definitions/CreateSubscriptionRequest"
+	//  responses:
+	//    '200':
+	//      description: Output
+	//      schema:
+	//        "$ref": "#/definitions/CreateSubscriptionResponse"
+	log.Debug("logicalreplicationservice.CreateSubscriptionHandler called")
+	username, err := apiserver.Authn(apiserver.CREATE_SUBSCRIPTION_PERM, w, r)
+	if err != nil {
+		return
+	}
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	var request msgs.CreateSubscriptionRequest
+	_ = json.NewDecoder(r.Body).Decode(&request)
+
+	resp := msgs.CreateSubscriptionResponse{}
+	resp.Status = msgs.Status{Code: msgs.Ok, Msg: ""}
+
+	if request.ClientVersion != msgs.PGO_VERSION {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = apiserver.VERSION_MISMATCH_ERROR
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	ns, err := apiserver.GetNamespace(apiserver.Clientset, username, request.Namespace)
+	if err != nil {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = err.Error()
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	resp = CreateSubscription(&request, ns, username)
+	json.NewEncoder(w).Encode(resp)
+}
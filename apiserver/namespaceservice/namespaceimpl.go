@@ -16,6 +16,10 @@ limitations under the License.
 */
 
 import (
+	"fmt"
+	"strings"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
 	"github.com/crunchydata/postgres-operator/apiserver"
 	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
 	"github.com/crunchydata/postgres-operator/kubeapi"
@@ -104,6 +108,16 @@ func CreateNamespace(clientset *kubernetes.Clientset, createdBy string, request
 
 }
 
+// validateNamespaceDeletion returns an error if a namespace that is to be
+// deleted holds any cluster that is protected from being deleted
+func validateNamespaceDeletion(namespace string, clusterList crv1.PgclusterList) error {
+	if protected := apiserver.FindProtectedClusters(clusterList); len(protected) > 0 {
+		return fmt.Errorf("namespace %s contains clusters with deletion protection enabled: %s",
+			namespace, strings.Join(protected, ", "))
+	}
+	return nil
+}
+
 // DeleteNamespace ...
 func DeleteNamespace(clientset *kubernetes.Clientset, deletedBy string, request *msgs.DeleteNamespaceRequest) msgs.DeleteNamespaceResponse {
 	resp := msgs.DeleteNamespaceResponse{}
@@ -111,6 +125,23 @@ func DeleteNamespace(clientset *kubernetes.Clientset, deletedBy string, request
 	resp.Status.Msg = ""
 	resp.Results = make([]string, 0)
 
+	// refuse to delete any of the namespaces if one of them holds a cluster
+	// that is protected from being deleted
+	for _, namespace := range request.Args {
+		clusterList := crv1.PgclusterList{}
+		if err := kubeapi.Getpgclusters(apiserver.RESTClient, &clusterList, namespace); err != nil {
+			resp.Status.Code = msgs.Error
+			resp.Status.Msg = err.Error()
+			return resp
+		}
+
+		if err := validateNamespaceDeletion(namespace, clusterList); err != nil {
+			resp.Status.Code = msgs.Error
+			resp.Status.Msg = err.Error()
+			return resp
+		}
+	}
+
 	for _, namespace := range request.Args {
 
 		err := ns.DeleteNamespace(clientset, apiserver.InstallationName, apiserver.PgoNamespace, deletedBy, namespace)
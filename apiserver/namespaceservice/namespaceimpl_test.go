@@ -0,0 +1,56 @@
+package namespaceservice
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"testing"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateNamespaceDeletion(t *testing.T) {
+	tests := []struct {
+		clusters  []string
+		protected []bool
+		expected  string
+	}{
+		{nil, nil, ""},
+		{[]string{"hippo"}, []bool{false}, ""},
+		{[]string{"hippo", "rhino"}, []bool{false, true},
+			"namespace pgo contains clusters with deletion protection enabled: rhino"},
+		{[]string{"hippo", "rhino"}, []bool{true, true},
+			"namespace pgo contains clusters with deletion protection enabled: hippo, rhino"},
+	}
+
+	for i, test := range tests {
+		clusterList := crv1.PgclusterList{}
+		for j, name := range test.clusters {
+			clusterList.Items = append(clusterList.Items, crv1.Pgcluster{
+				ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: "pgo"},
+				Spec:       crv1.PgclusterSpec{DeletionProtection: test.protected[j]},
+			})
+		}
+
+		err := validateNamespaceDeletion("pgo", clusterList)
+		switch {
+		case test.expected == "" && err != nil:
+			t.Fatalf("tests[%d] - expected no error, got %s", i, err)
+		case test.expected != "" && (err == nil || err.Error() != test.expected):
+			t.Fatalf("tests[%d] - expected %q, got %v", i, test.expected, err)
+		}
+	}
+}
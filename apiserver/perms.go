@@ -24,32 +24,38 @@ import (
 // the system
 const (
 	// MISC
-	APPLY_POLICY_PERM = "ApplyPolicy"
-	CAT_PERM          = "Cat"
-	CLONE_PERM        = "Clone"
-	DF_CLUSTER_PERM   = "DfCluster"
-	LABEL_PERM        = "Label"
-	LOAD_PERM         = "Load"
-	RELOAD_PERM       = "Reload"
-	RESTORE_PERM      = "Restore"
-	STATUS_PERM       = "Status"
-	TEST_CLUSTER_PERM = "TestCluster"
-	VERSION_PERM      = "Version"
+	APPLY_CLUSTER_PERM               = "ApplyCluster"
+	APPLY_POLICY_PERM                = "ApplyPolicy"
+	CAT_PERM                         = "Cat"
+	CLONE_PERM                       = "Clone"
+	DF_CLUSTER_PERM                  = "DfCluster"
+	DISABLE_DELETION_PROTECTION_PERM = "DisableDeletionProtection"
+	EXPORT_CLUSTER_PERM              = "ExportCluster"
+	LABEL_PERM                       = "Label"
+	LOAD_PERM                        = "Load"
+	RELOAD_PERM                      = "Reload"
+	RESTORE_PERM                     = "Restore"
+	STATUS_PERM                      = "Status"
+	TEST_CLUSTER_PERM                = "TestCluster"
+	VERSION_PERM                     = "Version"
 
 	// CREATE
-	CREATE_BACKUP_PERM    = "CreateBackup"
-	CREATE_CLUSTER_PERM   = "CreateCluster"
-	CREATE_DUMP_PERM      = "CreateDump"
-	CREATE_FAILOVER_PERM  = "CreateFailover"
-	CREATE_INGEST_PERM    = "CreateIngest"
-	CREATE_NAMESPACE_PERM = "CreateNamespace"
-	CREATE_PGBOUNCER_PERM = "CreatePgbouncer"
-	CREATE_PGOUSER_PERM   = "CreatePgouser"
-	CREATE_PGOROLE_PERM   = "CreatePgorole"
-	CREATE_POLICY_PERM    = "CreatePolicy"
-	CREATE_SCHEDULE_PERM  = "CreateSchedule"
-	CREATE_UPGRADE_PERM   = "CreateUpgrade"
-	CREATE_USER_PERM      = "CreateUser"
+	CREATE_BACKUP_PERM       = "CreateBackup"
+	CREATE_CLUSTER_PERM      = "CreateCluster"
+	CREATE_DUMP_PERM         = "CreateDump"
+	CREATE_FAILOVER_PERM     = "CreateFailover"
+	CREATE_INGEST_PERM       = "CreateIngest"
+	CREATE_NAMESPACE_PERM    = "CreateNamespace"
+	CREATE_PGBOUNCER_PERM    = "CreatePgbouncer"
+	CREATE_PGOUSER_PERM      = "CreatePgouser"
+	CREATE_PGOROLE_PERM      = "CreatePgorole"
+	CREATE_POLICY_PERM       = "CreatePolicy"
+	CREATE_PROFILE_PERM      = "CreateProfile"
+	CREATE_PUBLICATION_PERM  = "CreatePublication"
+	CREATE_SCHEDULE_PERM     = "CreateSchedule"
+	CREATE_SUBSCRIPTION_PERM = "CreateSubscription"
+	CREATE_UPGRADE_PERM      = "CreateUpgrade"
+	CREATE_USER_PERM         = "CreateUser"
 
 	// RESTORE
 	RESTORE_DUMP_PERM = "RestoreDump"
@@ -63,6 +69,7 @@ const (
 	DELETE_PGOROLE_PERM   = "DeletePgorole"
 	DELETE_PGOUSER_PERM   = "DeletePgouser"
 	DELETE_POLICY_PERM    = "DeletePolicy"
+	DELETE_PROFILE_PERM   = "DeleteProfile"
 	DELETE_SCHEDULE_PERM  = "DeleteSchedule"
 	DELETE_USER_PERM      = "DeleteUser"
 
@@ -76,6 +83,7 @@ const (
 	SHOW_PGOROLE_PERM         = "ShowPgorole"
 	SHOW_PGOUSER_PERM         = "ShowPgouser"
 	SHOW_POLICY_PERM          = "ShowPolicy"
+	SHOW_PROFILE_PERM         = "ShowProfile"
 	SHOW_PVC_PERM             = "ShowPVC"
 	SHOW_SCHEDULE_PERM        = "ShowSchedule"
 	SHOW_SECRETS_PERM         = "ShowSecrets"
@@ -108,32 +116,38 @@ func InitializePerms() {
 	// it slightly more organized
 	PermMap = map[string]string{
 		// MISC
-		APPLY_POLICY_PERM: "yes",
-		CAT_PERM:          "yes",
-		CLONE_PERM:        "yes",
-		DF_CLUSTER_PERM:   "yes",
-		LABEL_PERM:        "yes",
-		LOAD_PERM:         "yes",
-		RELOAD_PERM:       "yes",
-		RESTORE_PERM:      "yes",
-		STATUS_PERM:       "yes",
-		TEST_CLUSTER_PERM: "yes",
-		VERSION_PERM:      "yes",
+		APPLY_CLUSTER_PERM:               "yes",
+		APPLY_POLICY_PERM:                "yes",
+		CAT_PERM:                         "yes",
+		CLONE_PERM:                       "yes",
+		DF_CLUSTER_PERM:                  "yes",
+		DISABLE_DELETION_PROTECTION_PERM: "yes",
+		EXPORT_CLUSTER_PERM:              "yes",
+		LABEL_PERM:                       "yes",
+		LOAD_PERM:                        "yes",
+		RELOAD_PERM:                      "yes",
+		RESTORE_PERM:                     "yes",
+		STATUS_PERM:                      "yes",
+		TEST_CLUSTER_PERM:                "yes",
+		VERSION_PERM:                     "yes",
 
 		// CREATE
-		CREATE_BACKUP_PERM:    "yes",
-		CREATE_DUMP_PERM:      "yes",
-		CREATE_CLUSTER_PERM:   "yes",
-		CREATE_FAILOVER_PERM:  "yes",
-		CREATE_INGEST_PERM:    "yes",
-		CREATE_NAMESPACE_PERM: "yes",
-		CREATE_PGBOUNCER_PERM: "yes",
-		CREATE_PGOROLE_PERM:   "yes",
-		CREATE_PGOUSER_PERM:   "yes",
-		CREATE_POLICY_PERM:    "yes",
-		CREATE_SCHEDULE_PERM:  "yes",
-		CREATE_UPGRADE_PERM:   "yes",
-		CREATE_USER_PERM:      "yes",
+		CREATE_BACKUP_PERM:       "yes",
+		CREATE_DUMP_PERM:         "yes",
+		CREATE_CLUSTER_PERM:      "yes",
+		CREATE_FAILOVER_PERM:     "yes",
+		CREATE_INGEST_PERM:       "yes",
+		CREATE_NAMESPACE_PERM:    "yes",
+		CREATE_PGBOUNCER_PERM:    "yes",
+		CREATE_PGOROLE_PERM:      "yes",
+		CREATE_PGOUSER_PERM:      "yes",
+		CREATE_POLICY_PERM:       "yes",
+		CREATE_PROFILE_PERM:      "yes",
+		CREATE_PUBLICATION_PERM:  "yes",
+		CREATE_SCHEDULE_PERM:     "yes",
+		CREATE_SUBSCRIPTION_PERM: "yes",
+		CREATE_UPGRADE_PERM:      "yes",
+		CREATE_USER_PERM:         "yes",
 
 		// RESTORE
 		RESTORE_DUMP_PERM: "yes",
@@ -147,6 +161,7 @@ func InitializePerms() {
 		DELETE_PGOROLE_PERM:   "yes",
 		DELETE_PGOUSER_PERM:   "yes",
 		DELETE_POLICY_PERM:    "yes",
+		DELETE_PROFILE_PERM:   "yes",
 		DELETE_SCHEDULE_PERM:  "yes",
 		DELETE_USER_PERM:      "yes",
 
@@ -160,6 +175,7 @@ func InitializePerms() {
 		SHOW_PGOROLE_PERM:         "yes",
 		SHOW_PGOUSER_PERM:         "yes",
 		SHOW_POLICY_PERM:          "yes",
+		SHOW_PROFILE_PERM:         "yes",
 		SHOW_PVC_PERM:             "yes",
 		SHOW_SCHEDULE_PERM:        "yes",
 		SHOW_SECRETS_PERM:         "yes",
@@ -17,6 +17,7 @@ limitations under the License.
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
@@ -32,9 +33,15 @@ import (
 
 const pgBouncerServiceSuffix = "-pgbouncer"
 
+// pgBouncerReadOnlyServiceSuffix is the suffix of the Service of the read-only
+// pgBouncer
+const pgBouncerReadOnlyServiceSuffix = "-pgbouncer-ro"
+
 // CreatePgbouncer ...
 // pgo create pgbouncer mycluster
 // pgo create pgbouncer --selector=name=mycluster
+// pgo create pgbouncer mycluster --read-only
+// pgo create pgbouncer mycluster --replicas=2
 func CreatePgbouncer(request *msgs.CreatePgbouncerRequest, ns, pgouser string) msgs.CreatePgbouncerResponse {
 	var err error
 	resp := msgs.CreatePgbouncerResponse{}
@@ -44,6 +51,12 @@ func CreatePgbouncer(request *msgs.CreatePgbouncerRequest, ns, pgouser string) m
 
 	log.Debugf("createPgbouncer selector is [%s]", request.Selector)
 
+	if request.Replicas < 0 {
+		resp.Status.Code = msgs.Error
+		resp.Status.Msg = fmt.Sprintf("invalid number of pgbouncer replicas: %d", request.Replicas)
+		return resp
+	}
+
 	// try to get the list of clusters. if there is an error, put it into the
 	// status and return
 	clusterList, err := getClusterList(request.Namespace, request.Args, request.Selector)
@@ -57,12 +70,27 @@ func CreatePgbouncer(request *msgs.CreatePgbouncerRequest, ns, pgouser string) m
 	for _, cluster := range clusterList.Items {
 		log.Debugf("adding pgbouncer to cluster [%s]", cluster.Name)
 
-		if err := clusteroperator.CreatePgTaskforAddpgBouncer(apiserver.RESTClient, &cluster, pgouser); err != nil {
+		parameters := map[string]string{}
+
+		if request.ReadOnly {
+			parameters[config.LABEL_PGBOUNCER_READ_ONLY] = "true"
+		}
+
+		if request.Replicas > 0 {
+			parameters[config.LABEL_PGBOUNCER_REPLICAS] = strconv.Itoa(int(request.Replicas))
+		}
+
+		if err := clusteroperator.CreatePgTaskforAddpgBouncer(apiserver.RESTClient, &cluster, pgouser, parameters); err != nil {
 			log.Error(err)
 			resp.Results = append(resp.Results, err.Error())
 			continue
 		}
 
+		if request.ReadOnly {
+			resp.Results = append(resp.Results, fmt.Sprintf("%s read-only pgbouncer added", cluster.Name))
+			continue
+		}
+
 		resp.Results = append(resp.Results, fmt.Sprintf("%s pgbouncer added", cluster.Name))
 	}
 
@@ -72,6 +100,7 @@ func CreatePgbouncer(request *msgs.CreatePgbouncerRequest, ns, pgouser string) m
 // DeletePgbouncer ...
 // pgo delete pgbouncer mycluster
 // pgo delete pgbouncer --selector=name=mycluster
+// pgo delete pgbouncer mycluster --read-only
 func DeletePgbouncer(request *msgs.DeletePgbouncerRequest, ns string) msgs.DeletePgbouncerResponse {
 	var err error
 	resp := msgs.DeletePgbouncerResponse{}
@@ -116,6 +145,7 @@ func DeletePgbouncer(request *msgs.DeletePgbouncerRequest, ns string) msgs.Delet
 		spec.Parameters = map[string]string{
 			config.LABEL_PGBOUNCER_TASK_CLUSTER: cluster.Name,
 			config.LABEL_PGBOUNCER_UNINSTALL:    fmt.Sprintf("%t", request.Uninstall),
+			config.LABEL_PGBOUNCER_READ_ONLY:    fmt.Sprintf("%t", request.ReadOnly),
 		}
 
 		newInstance := &crv1.Pgtask{
@@ -136,6 +166,8 @@ func DeletePgbouncer(request *msgs.DeletePgbouncerRequest, ns string) msgs.Delet
 			resp.Status.Code = msgs.Error
 			resp.Results = append(resp.Results, err.Error())
 			return resp
+		} else if request.ReadOnly {
+			resp.Results = append(resp.Results, cluster.Name+" read-only pgbouncer deleted")
 		} else {
 			resp.Results = append(resp.Results, cluster.Name+" pgbouncer deleted")
 		}
@@ -151,6 +183,7 @@ func DeletePgbouncer(request *msgs.DeletePgbouncerRequest, ns string) msgs.Delet
 //
 // pgo show pgbouncer
 // pgo show pgbouncer --selector
+// pgo show pgbouncer --stats
 func ShowPgBouncer(request *msgs.ShowPgBouncerRequest, namespace string) msgs.ShowPgBouncerResponse {
 	// set up a dummy response
 	response := msgs.ShowPgBouncerResponse{
@@ -193,6 +226,13 @@ func ShowPgBouncer(request *msgs.ShowPgBouncerRequest, namespace string) msgs.Sh
 		// only set the pgBouncer user if we know this is a pgBouncer enabled
 		// cluster...even though, yes, this is a constant
 		result.Username = crv1.PGUserPgBouncer
+		result.HasReadOnly = cluster.Spec.PgBouncer.ReadOnly
+		result.Replicas = cluster.Spec.PgBouncer.Replicas
+
+		// clusters that do not set the number of replicas have one
+		if result.Replicas < 1 {
+			result.Replicas = 1
+		}
 
 		// set the pgBouncer service information on this record
 		setPgBouncerServiceDetail(cluster, &result)
@@ -200,6 +240,17 @@ func ShowPgBouncer(request *msgs.ShowPgBouncerRequest, namespace string) msgs.Sh
 		// get the user information about the pgBouncer deployment
 		setPgBouncerPasswordDetail(cluster, &result)
 
+		// if requested, gather the statistics of the pgBouncer deployments
+		if request.Stats {
+			result.Stats = append(result.Stats,
+				getPgBouncerStats(cluster, cluster.Spec.Name+pgBouncerServiceSuffix))
+
+			if result.HasReadOnly {
+				result.Stats = append(result.Stats,
+					getPgBouncerStats(cluster, cluster.Spec.Name+pgBouncerReadOnlyServiceSuffix))
+			}
+		}
+
 		// append the result to the list
 		response.Results = append(response.Results, result)
 	}
@@ -208,10 +259,11 @@ func ShowPgBouncer(request *msgs.ShowPgBouncerRequest, namespace string) msgs.Sh
 }
 
 // UpdatePgBouncer updates a cluster's pgBouncer deployment based on the
-// parameters passed in. Right now, that is only rotating the service account
-// password
+// parameters passed in, i.e. rotating the service account password and setting
+// the number of replicas
 //
 // pgo update pgbouncer --rotate-password
+// pgo update pgbouncer --replicas=2
 func UpdatePgBouncer(request *msgs.UpdatePgBouncerRequest, namespace, pgouser string) msgs.UpdatePgBouncerResponse {
 	// set up a dummy response
 	response := msgs.UpdatePgBouncerResponse{
@@ -224,6 +276,12 @@ func UpdatePgBouncer(request *msgs.UpdatePgBouncerRequest, namespace, pgouser st
 
 	log.Debugf("update pgbouncer called, cluster [%v], selector [%s]", request.ClusterNames, request.Selector)
 
+	if request.Replicas < 0 {
+		response.Status.Code = msgs.Error
+		response.Status.Msg = fmt.Sprintf("invalid number of pgbouncer replicas: %d", request.Replicas)
+		return response
+	}
+
 	// try to get the list of clusters. if there is an error, put it into the
 	// status and return
 	clusterList, err := getClusterList(request.Namespace, request.ClusterNames, request.Selector)
@@ -234,10 +292,10 @@ func UpdatePgBouncer(request *msgs.UpdatePgBouncerRequest, namespace, pgouser st
 		return response
 	}
 
-	// Return an error if any clusters selected to have pgbouncer updated have standby mode enabled.
-	// This is because while in standby mode the cluster is read-only, preventing the execution of the
-	// SQL required to update pgbouncer.
-	if hasStandby, standbyClusters := apiserver.PGClusterListHasStandby(clusterList); hasStandby {
+	// Return an error if any clusters selected to have the pgbouncer password rotated have standby
+	// mode enabled. This is because while in standby mode the cluster is read-only, preventing the
+	// execution of the SQL required to update pgbouncer.
+	if hasStandby, standbyClusters := apiserver.PGClusterListHasStandby(clusterList); hasStandby && request.RotatePassword {
 
 		response.Status.Code = msgs.Error
 		response.Status.Msg = fmt.Sprintf("Request rejected, unable to update pgbouncer for "+
@@ -270,6 +328,10 @@ func UpdatePgBouncer(request *msgs.UpdatePgBouncerRequest, namespace, pgouser st
 			parameters[config.LABEL_PGBOUNCER_ROTATE_PASSWORD] = "true"
 		}
 
+		if request.Replicas > 0 {
+			parameters[config.LABEL_PGBOUNCER_REPLICAS] = strconv.Itoa(int(request.Replicas))
+		}
+
 		if err := clusteroperator.CreatePgTaskforUpdatepgBouncer(apiserver.RESTClient, &cluster, pgouser, parameters); err != nil {
 			log.Error(err)
 			result.Error = true
@@ -374,22 +436,28 @@ func setPgBouncerServiceDetail(cluster crv1.Pgcluster, result *msgs.ShowPgBounce
 	// adding the service information was borrowed from the ShowCluster
 	// resource
 	for _, service := range services.Items {
-		// if this service is not for pgBouncer, then skip
-		if !strings.HasSuffix(service.Name, pgBouncerServiceSuffix) {
-			continue
-		}
-
-		// this is the pgBouncer service!
-		result.ServiceClusterIP = service.Spec.ClusterIP
-		result.ServiceName = service.Name
-
 		// try to get the exterinal IP based on the formula used in show cluster
+		externalIP := ""
+
 		if len(service.Spec.ExternalIPs) > 0 {
-			result.ServiceExternalIP = service.Spec.ExternalIPs[0]
+			externalIP = service.Spec.ExternalIPs[0]
 		}
 
 		if len(service.Status.LoadBalancer.Ingress) > 0 {
-			result.ServiceExternalIP = service.Status.LoadBalancer.Ingress[0].IP
+			externalIP = service.Status.LoadBalancer.Ingress[0].IP
+		}
+
+		switch {
+		// this is the pgBouncer service!
+		case strings.HasSuffix(service.Name, pgBouncerServiceSuffix):
+			result.ServiceClusterIP = service.Spec.ClusterIP
+			result.ServiceName = service.Name
+			result.ServiceExternalIP = externalIP
+		// ...and this is the one of the read-only pgBouncer
+		case strings.HasSuffix(service.Name, pgBouncerReadOnlyServiceSuffix):
+			result.ReadOnlyServiceClusterIP = service.Spec.ClusterIP
+			result.ReadOnlyServiceName = service.Name
+			result.ReadOnlyServiceExternalIP = externalIP
 		}
 	}
 }
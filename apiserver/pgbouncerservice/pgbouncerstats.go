@@ -0,0 +1,289 @@
+package pgbouncerservice
+
+/*
+Copyright 2020 Crunchy Data Solutions, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/apiserver"
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+)
+
+// pgBouncerAdminDatabase is the administrative console of pgBouncer, whose own
+// pool is left out of the statistics
+const pgBouncerAdminDatabase = "pgbouncer"
+
+// pgBouncerStatsCommand connects to the administrative console of the
+// pgBouncer in the Pod as the "pgbouncer" user, whose password is in the
+// environment of the container. pgBouncer listens on the port in pgbouncer.ini
+var pgBouncerStatsCommand = []string{"bash", "-c",
+	fmt.Sprintf(`PGPASSWORD="${PG_PASSWORD}" psql -X -A -h localhost -p 5432 -U %s %s`,
+		crv1.PGUserPgBouncer, pgBouncerAdminDatabase)}
+
+// pgBouncerStatsQueries are the commands of the administrative console whose
+// output the statistics are gathered from
+var pgBouncerStatsQueries = []string{"SHOW POOLS;", "SHOW STATS;", "SHOW CLIENTS;", "SHOW SERVERS;", "SHOW DATABASES;"}
+
+// psqlFooter matches the row count that psql prints after a result
+var psqlFooter = regexp.MustCompile(`^\(\d+ rows?\)$`)
+
+// pgBouncerPodStats contains the output of the administrative console of a
+// pgBouncer Pod, i.e. the rows of each of pgBouncerStatsQueries by column name
+type pgBouncerPodStats struct {
+	pools, stats, clients, servers, databases []map[string]string
+}
+
+// getPgBouncerStats gathers the statistics of a pgBouncer deployment of a
+// cluster from each of its running Pods. A Pod whose statistics cannot be
+// gathered is listed in the errors of the statistics, but does not fail the
+// request
+func getPgBouncerStats(cluster crv1.Pgcluster, name string) msgs.PgBouncerStats {
+	selector := fmt.Sprintf("%s=%s,%s=%s", config.LABEL_PG_CLUSTER, cluster.Spec.Name,
+		config.LABEL_SERVICE_NAME, name)
+
+	pods, err := kubeapi.GetPods(apiserver.Clientset, selector, cluster.Spec.Namespace)
+
+	if err != nil {
+		return msgs.PgBouncerStats{Name: name, Errors: []string{err.Error()}}
+	}
+
+	podStats := []pgBouncerPodStats{}
+	errors := []string{}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+
+		stats, err := getPgBouncerPodStats(pod)
+
+		if err != nil {
+			log.Warn(err)
+			errors = append(errors, fmt.Sprintf("%s: %s", pod.Name, err.Error()))
+			continue
+		}
+
+		podStats = append(podStats, stats)
+	}
+
+	result := aggregatePgBouncerStats(name, podStats)
+	result.Errors = errors
+
+	return result
+}
+
+// getPgBouncerPodStats runs the commands of the administrative console on a
+// pgBouncer Pod
+func getPgBouncerPodStats(pod v1.Pod) (pgBouncerPodStats, error) {
+	results := make([][]map[string]string, len(pgBouncerStatsQueries))
+
+	for i, query := range pgBouncerStatsQueries {
+		stdout, stderr, err := kubeapi.ExecToPodThroughAPI(apiserver.RESTConfig,
+			apiserver.Clientset, pgBouncerStatsCommand, "pgbouncer", pod.Name, pod.Namespace,
+			strings.NewReader(query))
+
+		if err != nil {
+			return pgBouncerPodStats{}, fmt.Errorf("%s: %v %s", query, err, stderr)
+		}
+
+		results[i] = parsePsqlTable(stdout)
+	}
+
+	return pgBouncerPodStats{
+		pools:     results[0],
+		stats:     results[1],
+		clients:   results[2],
+		servers:   results[3],
+		databases: results[4],
+	}, nil
+}
+
+// aggregatePgBouncerStats sums the statistics of the Pods of a pgBouncer
+// deployment. The pools are identified by their database and user, and the
+// average wait time is weighted by the transactions of each database
+func aggregatePgBouncerStats(name string, podStats []pgBouncerPodStats) msgs.PgBouncerStats {
+	result := msgs.PgBouncerStats{
+		Name:             name,
+		Pods:             len(podStats),
+		ClientsByAddress: map[string]int{},
+		ServersByState:   map[string]int{},
+		Pools:            []msgs.PgBouncerPoolStats{},
+	}
+
+	pools := map[string]*msgs.PgBouncerPoolStats{}
+	serversActive := 0
+	waitTime, transactions, waitTimes := int64(0), int64(0), []int64{}
+
+	for _, pod := range podStats {
+		// the pool size is set per database
+		poolSizes := map[string]int{}
+		for _, database := range pod.databases {
+			poolSizes[database["name"]] = parseInt(database["pool_size"])
+		}
+
+		for _, row := range pod.pools {
+			if row["database"] == pgBouncerAdminDatabase {
+				continue
+			}
+
+			key := row["database"] + "/" + row["user"]
+			pool, ok := pools[key]
+
+			if !ok {
+				pool = &msgs.PgBouncerPoolStats{Database: row["database"], User: row["user"]}
+				pools[key] = pool
+			}
+
+			pool.ClientsActive += parseInt(row["cl_active"])
+			pool.ClientsWaiting += parseInt(row["cl_waiting"])
+			pool.ServersActive += parseInt(row["sv_active"])
+			pool.ServersIdle += parseInt(row["sv_idle"])
+			pool.PoolSize += poolSizes[row["database"]]
+
+			maxWait := parseFloat(row["maxwait"]) + parseFloat(row["maxwait_us"])/1000000
+
+			if maxWait > pool.MaxWait {
+				pool.MaxWait = maxWait
+			}
+		}
+
+		for _, row := range pod.stats {
+			if row["database"] == pgBouncerAdminDatabase {
+				continue
+			}
+
+			avgWaitTime := int64(parseInt(row["avg_wait_time"]))
+			avgTransactions := int64(parseInt(row["avg_xact_count"]))
+
+			waitTime += avgWaitTime * avgTransactions
+			transactions += avgTransactions
+			waitTimes = append(waitTimes, avgWaitTime)
+		}
+
+		for _, row := range pod.clients {
+			if row["database"] == pgBouncerAdminDatabase {
+				continue
+			}
+
+			result.Clients++
+			result.ClientsByAddress[row["addr"]]++
+		}
+
+		for _, row := range pod.servers {
+			result.Servers++
+			result.ServersByState[row["state"]]++
+		}
+	}
+
+	for _, pool := range pools {
+		if pool.PoolSize > 0 {
+			pool.Saturation = float64(pool.ServersActive) / float64(pool.PoolSize)
+		}
+
+		result.ClientsActive += pool.ClientsActive
+		result.ClientsWaiting += pool.ClientsWaiting
+		result.PoolSize += pool.PoolSize
+		serversActive += pool.ServersActive
+
+		if pool.MaxWait > result.MaxWait {
+			result.MaxWait = pool.MaxWait
+		}
+
+		result.Pools = append(result.Pools, *pool)
+	}
+
+	sort.Slice(result.Pools, func(i, j int) bool {
+		if result.Pools[i].Database != result.Pools[j].Database {
+			return result.Pools[i].Database < result.Pools[j].Database
+		}
+		return result.Pools[i].User < result.Pools[j].User
+	})
+
+	if result.PoolSize > 0 {
+		result.Saturation = float64(serversActive) / float64(result.PoolSize)
+	}
+
+	// if there were no transactions in the last period, every database counts
+	// the same
+	if transactions > 0 {
+		result.AverageWaitTime = waitTime / transactions
+	} else if len(waitTimes) > 0 {
+		for _, t := range waitTimes {
+			result.AverageWaitTime += t
+		}
+		result.AverageWaitTime /= int64(len(waitTimes))
+	}
+
+	return result
+}
+
+// parsePsqlTable parses the unaligned output of psql, i.e. a header of column
+// names, the rows and the row count, each separated by "|", into a map of
+// column name to value for each row
+func parsePsqlTable(output string) []map[string]string {
+	rows := []map[string]string{}
+	columns := []string{}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if psqlFooter.MatchString(line) {
+			break
+		}
+
+		values := strings.Split(line, "|")
+
+		if len(columns) == 0 {
+			columns = values
+			continue
+		}
+
+		row := map[string]string{}
+		for i, column := range columns {
+			if i < len(values) {
+				row[column] = values[i]
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// parseFloat returns the number in a column, or 0 if it is not a number
+func parseFloat(value string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	return f
+}
+
+// parseInt returns the integer in a column, or 0 if it is not an integer
+func parseInt(value string) int {
+	i, _ := strconv.Atoi(strings.TrimSpace(value))
+	return i
+}
@@ -0,0 +1,107 @@
+package pgbouncerservice
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"testing"
+)
+
+func TestParsePsqlTable(t *testing.T) {
+	rows := parsePsqlTable("database|user|cl_active\nhippo|app|3\npgbouncer|pgbouncer|1\n(2 rows)\n")
+
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %+v", rows)
+	}
+
+	if rows[0]["database"] != "hippo" || rows[0]["user"] != "app" || rows[0]["cl_active"] != "3" {
+		t.Fatalf("unexpected row %+v", rows[0])
+	}
+
+	if rows := parsePsqlTable("database|user\n(0 rows)\n"); len(rows) != 0 {
+		t.Fatalf("expected no rows, got %+v", rows)
+	}
+}
+
+func TestAggregatePgBouncerStats(t *testing.T) {
+	pod := func(active, waiting, servers string, maxWait, avgWait, transactions string) pgBouncerPodStats {
+		return pgBouncerPodStats{
+			pools: parsePsqlTable("database|user|cl_active|cl_waiting|sv_active|sv_idle|maxwait|maxwait_us\n" +
+				"hippo|app|" + active + "|" + waiting + "|" + servers + "|0|" + maxWait + "|500000\n" +
+				"pgbouncer|pgbouncer|1|0|0|0|0|0\n"),
+			stats: parsePsqlTable("database|avg_xact_count|avg_wait_time\n" +
+				"hippo|" + transactions + "|" + avgWait + "\n" +
+				"pgbouncer|0|0\n"),
+			clients: parsePsqlTable("type|user|database|state|addr\n" +
+				"C|app|hippo|active|10.0.0.1\n" +
+				"C|app|hippo|waiting|10.0.0.2\n" +
+				"C|pgbouncer|pgbouncer|active|127.0.0.1\n"),
+			servers: parsePsqlTable("type|user|database|state\nS|app|hippo|active\n"),
+			databases: parsePsqlTable("name|host|pool_size\n" +
+				"hippo|hippo|20\npgbouncer||2\n"),
+		}
+	}
+
+	t.Run("pods", func(t *testing.T) {
+		stats := aggregatePgBouncerStats("hippo-pgbouncer", []pgBouncerPodStats{
+			pod("10", "5", "10", "2", "100", "1"),
+			pod("20", "0", "20", "0", "400", "3"),
+		})
+
+		if stats.Pods != 2 || stats.ClientsActive != 30 || stats.ClientsWaiting != 5 {
+			t.Fatalf("unexpected clients %+v", stats)
+		}
+
+		if len(stats.Pools) != 1 || stats.Pools[0].PoolSize != 40 || stats.PoolSize != 40 {
+			t.Fatalf("expected one pool of 40 connections, got %+v", stats.Pools)
+		}
+
+		if stats.Saturation != 0.75 {
+			t.Fatalf("expected a saturation of 0.75, got %f", stats.Saturation)
+		}
+
+		if stats.MaxWait != 2.5 {
+			t.Fatalf("expected a max wait of 2.5s, got %f", stats.MaxWait)
+		}
+
+		// weighted by the transactions of each pod
+		if stats.AverageWaitTime != 325 {
+			t.Fatalf("expected an average wait time of 325us, got %d", stats.AverageWaitTime)
+		}
+
+		if stats.Clients != 4 || stats.ClientsByAddress["10.0.0.1"] != 2 || stats.ServersByState["active"] != 2 {
+			t.Fatalf("unexpected connections %+v", stats)
+		}
+	})
+
+	t.Run("no transactions", func(t *testing.T) {
+		stats := aggregatePgBouncerStats("hippo-pgbouncer", []pgBouncerPodStats{
+			pod("0", "0", "0", "0", "100", "0"),
+			pod("0", "0", "0", "0", "300", "0"),
+		})
+
+		if stats.AverageWaitTime != 200 {
+			t.Fatalf("expected an average wait time of 200us, got %d", stats.AverageWaitTime)
+		}
+	})
+
+	t.Run("no pods", func(t *testing.T) {
+		stats := aggregatePgBouncerStats("hippo-pgbouncer", nil)
+
+		if stats.Pods != 0 || stats.PoolSize != 0 || stats.Saturation != 0 || len(stats.Pools) != 0 {
+			t.Fatalf("expected empty statistics, got %+v", stats)
+		}
+	})
+}
@@ -0,0 +1,323 @@
+package profileservice
+
+/*
+Copyright 2020 Crunchy Data Solutions, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/crunchydata/postgres-operator/apiserver"
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// profileSecretPrefix is the prefix of the names of the secrets that store
+	// the cluster profiles in the namespace of the Operator
+	profileSecretPrefix = "pgo-profile-"
+
+	// profileSecretKey is the key of the secret that holds the settings of a
+	// profile, encoded as JSON
+	profileSecretKey = "profile"
+
+	// setFieldsName is the name of the field of a request for a cluster that
+	// holds the names of the settings that were set explicitly
+	setFieldsName = "SetFields"
+)
+
+var (
+	// profileIgnoredFields are the fields of a request for a cluster that only
+	// apply to a single request, and are therefore never part of a profile
+	profileIgnoredFields = []string{"Name", "Namespace", "ClientVersion", "Profile", "DryRun"}
+
+	// profileCredentialFields are the fields of a request for a cluster that
+	// hold credentials. A profile can be viewed by anyone who may view
+	// profiles, so it cannot hold them
+	profileCredentialFields = []string{"Password", "PasswordSuperuser", "PasswordReplication",
+		"BackrestS3Key", "BackrestS3KeySecret", "BackrestGCSKey", "BackrestAzureKey"}
+)
+
+// CreateProfile creates a cluster profile
+func CreateProfile(clientset *kubernetes.Clientset, createdBy string,
+	request *msgs.CreateProfileRequest) msgs.CreateProfileResponse {
+	resp := msgs.CreateProfileResponse{}
+	resp.Status = msgs.Status{Code: msgs.Ok, Msg: ""}
+
+	if errs := validation.IsDNS1035Label(request.Name); len(errs) > 0 {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: "invalid profile name format " + errs[0]}
+		return resp
+	}
+
+	profile := request.Profile
+	clearIgnoredFields(&profile)
+
+	if credentials := getCredentialFields(&profile); len(credentials) > 0 {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: fmt.Sprintf(
+			"a profile cannot hold credentials, remove %s", strings.Join(credentials, ", "))}
+		return resp
+	}
+
+	data, err := json.Marshal(profile)
+	if err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		return resp
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: profileSecretPrefix + request.Name,
+			Labels: map[string]string{
+				config.LABEL_VENDOR:         config.LABEL_CRUNCHY,
+				config.LABEL_PGO_PROFILE:    config.LABEL_TRUE,
+				config.LABEL_PROFILE_NAME:   request.Name,
+				config.LABEL_PGO_CREATED_BY: createdBy,
+			},
+		},
+		Data: map[string][]byte{
+			profileSecretKey: data,
+		},
+	}
+
+	if err := kubeapi.CreateSecret(clientset, secret, apiserver.PgoNamespace); kerrors.IsAlreadyExists(err) {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: fmt.Sprintf("profile %s already exists", request.Name)}
+		return resp
+	} else if err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		return resp
+	}
+
+	log.Debugf("profile %s created by %s", request.Name, createdBy)
+
+	return resp
+}
+
+// ShowProfile returns the settings of cluster profiles
+func ShowProfile(clientset *kubernetes.Clientset, request *msgs.ShowProfileRequest) msgs.ShowProfileResponse {
+	resp := msgs.ShowProfileResponse{}
+	resp.Status = msgs.Status{Code: msgs.Ok, Msg: ""}
+	resp.Results = make([]msgs.ProfileDetail, 0)
+
+	names, err := getProfileNames(clientset, request.Names, request.AllFlag)
+	if err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		return resp
+	}
+
+	for _, name := range names {
+		profile, err := GetProfile(clientset, name)
+		if err != nil {
+			resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+			return resp
+		}
+
+		// profiles that were created before credentials were rejected may still
+		// hold them
+		redactCredentialFields(profile)
+
+		resp.Results = append(resp.Results, msgs.ProfileDetail{Name: name, Profile: *profile})
+	}
+
+	return resp
+}
+
+// DeleteProfile deletes cluster profiles. Clusters that were created with a
+// profile are not affected
+func DeleteProfile(clientset *kubernetes.Clientset, deletedBy string,
+	request *msgs.DeleteProfileRequest) msgs.DeleteProfileResponse {
+	resp := msgs.DeleteProfileResponse{}
+	resp.Status = msgs.Status{Code: msgs.Ok, Msg: ""}
+	resp.Results = make([]string, 0)
+
+	names, err := getProfileNames(clientset, request.Names, request.AllFlag)
+	if err != nil {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+		return resp
+	}
+
+	for _, name := range names {
+		err := kubeapi.DeleteSecret(clientset, profileSecretPrefix+name, apiserver.PgoNamespace)
+		if kerrors.IsNotFound(err) {
+			resp.Results = append(resp.Results, fmt.Sprintf("profile %s not found", name))
+			continue
+		} else if err != nil {
+			resp.Status = msgs.Status{Code: msgs.Error, Msg: err.Error()}
+			return resp
+		}
+
+		log.Debugf("profile %s deleted by %s", name, deletedBy)
+		resp.Results = append(resp.Results, fmt.Sprintf("deleted profile %s", name))
+	}
+
+	return resp
+}
+
+// GetProfile returns the settings of a cluster profile
+func GetProfile(clientset *kubernetes.Clientset, name string) (*msgs.CreateClusterRequest, error) {
+	secret, found, err := kubeapi.GetSecret(clientset, profileSecretPrefix+name, apiserver.PgoNamespace)
+	if !found {
+		return nil, fmt.Errorf("profile %s not found", name)
+	} else if err != nil {
+		return nil, err
+	}
+
+	profile := &msgs.CreateClusterRequest{}
+	if err := json.Unmarshal(secret.Data[profileSecretKey], profile); err != nil {
+		return nil, fmt.Errorf("could not read profile %s: %s", name, err)
+	}
+
+	return profile, nil
+}
+
+// MergeProfile sets every setting of a request for a cluster that is not set
+// to the setting of the profile, if the profile sets it. Settings of the request
+// take precedence over the profile, which in turn takes precedence over the
+// defaults of the Operator, as those are only used for settings that are not
+// set by either
+func MergeProfile(request, profile *msgs.CreateClusterRequest) {
+	requestValue := reflect.ValueOf(request).Elem()
+	profileValue := reflect.ValueOf(profile).Elem()
+
+	for i := 0; i < requestValue.NumField(); i++ {
+		name := requestValue.Type().Field(i).Name
+		if isIgnoredField(name) || name == setFieldsName {
+			continue
+		}
+
+		field, profileField := requestValue.Field(i), profileValue.Field(i)
+
+		// the settings in a group of settings are merged one by one
+		if field.Kind() == reflect.Struct {
+			for j := 0; j < field.NumField(); j++ {
+				if isZero(field.Field(j)) {
+					field.Field(j).Set(profileField.Field(j))
+				}
+			}
+			continue
+		}
+
+		if !isSet(field, name, request.SetFields) && isSet(profileField, name, profile.SetFields) {
+			field.Set(profileField)
+		}
+	}
+}
+
+// clearIgnoredFields clears the fields of a profile that only apply to a single
+// request
+func clearIgnoredFields(profile *msgs.CreateClusterRequest) {
+	value := reflect.ValueOf(profile).Elem()
+
+	for _, name := range profileIgnoredFields {
+		field := value.FieldByName(name)
+		field.Set(reflect.Zero(field.Type()))
+	}
+}
+
+// getCredentialFields returns the names of the fields of a profile that hold
+// credentials
+func getCredentialFields(profile *msgs.CreateClusterRequest) []string {
+	value := reflect.ValueOf(profile).Elem()
+	names := []string{}
+
+	for _, name := range profileCredentialFields {
+		if !isZero(value.FieldByName(name)) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// redactCredentialFields clears the fields of a profile that hold credentials
+func redactCredentialFields(profile *msgs.CreateClusterRequest) {
+	value := reflect.ValueOf(profile).Elem()
+
+	for _, name := range profileCredentialFields {
+		field := value.FieldByName(name)
+		field.Set(reflect.Zero(field.Type()))
+	}
+}
+
+// getProfileNames returns the names of the profiles a request applies to, i.e.
+// either the names in the request, or every profile
+func getProfileNames(clientset *kubernetes.Clientset, names []string, all bool) ([]string, error) {
+	if !all {
+		return names, nil
+	}
+
+	selector := config.LABEL_PGO_PROFILE + "=" + config.LABEL_TRUE
+	secrets, err := kubeapi.GetSecrets(clientset, selector, apiserver.PgoNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	names = make([]string, 0, len(secrets.Items))
+	for _, secret := range secrets.Items {
+		names = append(names, secret.Labels[config.LABEL_PROFILE_NAME])
+	}
+
+	return names, nil
+}
+
+// isIgnoredField returns true if a field of a request for a cluster is never
+// part of a profile
+func isIgnoredField(name string) bool {
+	for _, ignored := range profileIgnoredFields {
+		if name == ignored {
+			return true
+		}
+	}
+	return false
+}
+
+// isSet returns true if a setting of a request or a profile is set. A setting
+// that can be turned off or set to zero is set if it was set explicitly, unless
+// it is not known which settings were, e.g. for a profile that was created
+// before they were recorded. Any other setting is set if it is not empty
+func isSet(value reflect.Value, name string, setFields []string) bool {
+	switch value.Kind() {
+	case reflect.Bool, reflect.Int:
+		if setFields != nil {
+			for _, setField := range setFields {
+				if setField == name {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	return !isZero(value)
+}
+
+// isZero returns true if a setting is empty. Empty lists are treated like
+// missing ones, as the client sends them when they are not provided
+func isZero(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+
+	return reflect.DeepEqual(value.Interface(), reflect.Zero(value.Type()).Interface())
+}
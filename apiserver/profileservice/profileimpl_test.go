@@ -0,0 +1,128 @@
+package profileservice
+
+/*
+Copyright 2020 Crunchy Data Solutions, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"reflect"
+	"testing"
+
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+)
+
+func TestMergeProfile(t *testing.T) {
+	disabled := false
+	enabled := true
+
+	tests := []struct {
+		request  msgs.CreateClusterRequest
+		profile  msgs.CreateClusterRequest
+		expected msgs.CreateClusterRequest
+	}{
+		// settings that are not set are taken from the profile
+		{
+			request:  msgs.CreateClusterRequest{Name: "hippo", ReplicaCount: 0, ServiceType: ""},
+			profile:  msgs.CreateClusterRequest{ReplicaCount: 2, ServiceType: "LoadBalancer", MetricsFlag: true},
+			expected: msgs.CreateClusterRequest{Name: "hippo", ReplicaCount: 2, ServiceType: "LoadBalancer", MetricsFlag: true},
+		},
+		// settings of the request take precedence
+		{
+			request:  msgs.CreateClusterRequest{Name: "hippo", ReplicaCount: 1, SyncReplication: &disabled},
+			profile:  msgs.CreateClusterRequest{ReplicaCount: 2, SyncReplication: &enabled},
+			expected: msgs.CreateClusterRequest{Name: "hippo", ReplicaCount: 1, SyncReplication: &disabled},
+		},
+		// empty lists are not set
+		{
+			request: msgs.CreateClusterRequest{Name: "hippo", Tablespaces: []msgs.ClusterTablespaceDetail{}},
+			profile: msgs.CreateClusterRequest{Tablespaces: []msgs.ClusterTablespaceDetail{{Name: "lake"}}},
+			expected: msgs.CreateClusterRequest{Name: "hippo",
+				Tablespaces: []msgs.ClusterTablespaceDetail{{Name: "lake"}}},
+		},
+		// groups of settings are merged setting by setting
+		{
+			request: msgs.CreateClusterRequest{Name: "hippo",
+				Metadata: msgs.ClusterMetadata{LabelsPostgres: []string{"team=hippo"}}},
+			profile: msgs.CreateClusterRequest{Metadata: msgs.ClusterMetadata{
+				LabelsPostgres:            []string{"team=rhino"},
+				AnnotationsPrimaryService: []string{"example.com/internal=true"}}},
+			expected: msgs.CreateClusterRequest{Name: "hippo", Metadata: msgs.ClusterMetadata{
+				LabelsPostgres:            []string{"team=hippo"},
+				AnnotationsPrimaryService: []string{"example.com/internal=true"}}},
+		},
+		// settings that only apply to a single request are never taken
+		{
+			request:  msgs.CreateClusterRequest{Name: "hippo", Profile: "small"},
+			profile:  msgs.CreateClusterRequest{Name: "rhino", Namespace: "pgo", DryRun: true, Profile: "large"},
+			expected: msgs.CreateClusterRequest{Name: "hippo", Profile: "small"},
+		},
+		// settings that are turned off or set to zero explicitly take precedence
+		{
+			request: msgs.CreateClusterRequest{Name: "hippo", ReplicaCount: 0, MetricsFlag: false,
+				SetFields: []string{"ReplicaCount", "MetricsFlag"}},
+			profile: msgs.CreateClusterRequest{ReplicaCount: 2, MetricsFlag: true},
+			expected: msgs.CreateClusterRequest{Name: "hippo", ReplicaCount: 0, MetricsFlag: false,
+				SetFields: []string{"ReplicaCount", "MetricsFlag"}},
+		},
+		// defaults of the client that are not set explicitly are not set
+		{
+			request: msgs.CreateClusterRequest{Name: "hippo", AutofailFlag: true, ServiceType: "NodePort",
+				SetFields: []string{}},
+			profile: msgs.CreateClusterRequest{AutofailFlag: false, ServiceType: "LoadBalancer",
+				SetFields: []string{"AutofailFlag", "ServiceType"}},
+			expected: msgs.CreateClusterRequest{Name: "hippo", AutofailFlag: false, ServiceType: "NodePort",
+				SetFields: []string{}},
+		},
+		// settings that a profile does not set explicitly are not taken
+		{
+			request:  msgs.CreateClusterRequest{Name: "hippo", SetFields: []string{}},
+			profile:  msgs.CreateClusterRequest{ReplicaCount: 2, SetFields: []string{}},
+			expected: msgs.CreateClusterRequest{Name: "hippo", SetFields: []string{}},
+		},
+	}
+
+	for i, test := range tests {
+		request := test.request
+		MergeProfile(&request, &test.profile)
+
+		if !reflect.DeepEqual(request, test.expected) {
+			t.Fatalf("tests[%d] - expected %+v, got %+v", i, test.expected, request)
+		}
+	}
+}
+
+func TestCredentialFields(t *testing.T) {
+	tests := []struct {
+		profile  msgs.CreateClusterRequest
+		expected []string
+	}{
+		{msgs.CreateClusterRequest{ReplicaCount: 2, BackrestS3Bucket: "hippo"}, []string{}},
+		{msgs.CreateClusterRequest{Password: "datalake", BackrestS3Key: "key", BackrestS3KeySecret: "secret"},
+			[]string{"Password", "BackrestS3Key", "BackrestS3KeySecret"}},
+		{msgs.CreateClusterRequest{BackrestGCSKey: "key", BackrestAzureKey: "key"},
+			[]string{"BackrestGCSKey", "BackrestAzureKey"}},
+	}
+
+	for i, test := range tests {
+		if credentials := getCredentialFields(&test.profile); !reflect.DeepEqual(credentials, test.expected) {
+			t.Fatalf("tests[%d] - expected credentials %v, got %v", i, test.expected, credentials)
+		}
+
+		redactCredentialFields(&test.profile)
+
+		if credentials := getCredentialFields(&test.profile); len(credentials) != 0 {
+			t.Fatalf("tests[%d] - expected the credentials to be redacted, got %v", i, credentials)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package profileservice
+
+/*
+Copyright 2020 Crunchy Data Solutions, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/crunchydata/postgres-operator/apiserver"
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+	log "github.com/sirupsen/logrus"
+)
+
+// CreateProfileHandler creates a cluster profile
+func CreateProfileHandler(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /profilecreate profileservice profilecreate
+	/*```
+	Create a cluster profile
+	*/
+	// ---
+	//  produces:
+	//  - application/json
+	//  parameters:
+	//  - name: "Create Profile Request"
+	//    in: "body"
+	//    schema:
+	//      "$ref": "#/definitions/CreateProfileRequest"
+	//  responses:
+	//    '200':
+	//      description: Output
+	//      schema:
+	//        "$ref": "#/definitions/CreateProfileResponse"
+	log.Debug("profileservice.CreateProfileHandler called")
+
+	var request msgs.CreateProfileRequest
+	_ = json.NewDecoder(r.Body).Decode(&request)
+
+	username, err := apiserver.Authn(apiserver.CREATE_PROFILE_PERM, w, r)
+	if err != nil {
+		return
+	}
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := msgs.CreateProfileResponse{}
+	resp.Status = msgs.Status{Code: msgs.Ok, Msg: ""}
+
+	if request.ClientVersion != msgs.PGO_VERSION {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: apiserver.VERSION_MISMATCH_ERROR}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	resp = CreateProfile(apiserver.Clientset, username, &request)
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ShowProfileHandler shows cluster profiles
+func ShowProfileHandler(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /profileshow profileservice profileshow
+	/*```
+	Show cluster profiles
+	*/
+	// ---
+	//  produces:
+	//  - application/json
+	//  parameters:
+	//  - name: "Show Profile Request"
+	//    in: "body"
+	//    schema:
+	//      "$ref": "#/definitions/ShowProfileRequest"
+	//  responses:
+	//    '200':
+	//      description: Output
+	//      schema:
+	//        "$ref": "#/definitions/ShowProfileResponse"
+	log.Debug("profileservice.ShowProfileHandler called")
+
+	var request msgs.ShowProfileRequest
+	_ = json.NewDecoder(r.Body).Decode(&request)
+
+	_, err := apiserver.Authn(apiserver.SHOW_PROFILE_PERM, w, r)
+	if err != nil {
+		return
+	}
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := msgs.ShowProfileResponse{}
+	resp.Status = msgs.Status{Code: msgs.Ok, Msg: ""}
+
+	if request.ClientVersion != msgs.PGO_VERSION {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: apiserver.VERSION_MISMATCH_ERROR}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	resp = ShowProfile(apiserver.Clientset, &request)
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// DeleteProfileHandler deletes cluster profiles
+func DeleteProfileHandler(w http.ResponseWriter, r *http.Request) {
+	// swagger:operation POST /profiledelete profileservice profiledelete
+	/*```
+	Delete cluster profiles
+	*/
+	// ---
+	//  produces:
+	//  - application/json
+	//  parameters:
+	//  - name: "Delete Profile Request"
+	//    in: "body"
+	//    schema:
+	//      "$ref": "#/definitions/DeleteProfileRequest"
+	//  responses:
+	//    '200':
+	//      description: Output
+	//      schema:
+	//        "$ref": "#/definitions/DeleteProfileResponse"
+	log.Debug("profileservice.DeleteProfileHandler called")
+
+	var request msgs.DeleteProfileRequest
+	_ = json.NewDecoder(r.Body).Decode(&request)
+
+	username, err := apiserver.Authn(apiserver.DELETE_PROFILE_PERM, w, r)
+	if err != nil {
+		return
+	}
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	resp := msgs.DeleteProfileResponse{}
+	resp.Status = msgs.Status{Code: msgs.Ok, Msg: ""}
+
+	if request.ClientVersion != msgs.PGO_VERSION {
+		resp.Status = msgs.Status{Code: msgs.Error, Msg: apiserver.VERSION_MISMATCH_ERROR}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	resp = DeleteProfile(apiserver.Clientset, username, &request)
+
+	json.NewEncoder(w).Encode(resp)
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/crunchydata/postgres-operator/config"
 	"github.com/crunchydata/postgres-operator/kubeapi"
 	"github.com/crunchydata/postgres-operator/ns"
+	"github.com/crunchydata/postgres-operator/operator"
 	"github.com/crunchydata/postgres-operator/tlsutil"
 	"github.com/crunchydata/postgres-operator/util"
 	log "github.com/sirupsen/logrus"
@@ -136,6 +137,10 @@ func Initialize() {
 
 	initConfig()
 
+	// the Operator functions that render the objects of a cluster, e.g. for a
+	// dry run of creating a cluster, read the configuration of the Operator
+	operator.Pgo = Pgo
+
 	validateWithKube()
 
 	//validateUserCredentials()
@@ -25,12 +25,14 @@ import (
 	"github.com/crunchydata/postgres-operator/apiserver/failoverservice"
 	"github.com/crunchydata/postgres-operator/apiserver/labelservice"
 	"github.com/crunchydata/postgres-operator/apiserver/loadservice"
+	"github.com/crunchydata/postgres-operator/apiserver/logicalreplicationservice"
 	"github.com/crunchydata/postgres-operator/apiserver/namespaceservice"
 	"github.com/crunchydata/postgres-operator/apiserver/pgbouncerservice"
 	"github.com/crunchydata/postgres-operator/apiserver/pgdumpservice"
 	"github.com/crunchydata/postgres-operator/apiserver/pgoroleservice"
 	"github.com/crunchydata/postgres-operator/apiserver/pgouserservice"
 	"github.com/crunchydata/postgres-operator/apiserver/policyservice"
+	"github.com/crunchydata/postgres-operator/apiserver/profileservice"
 	"github.com/crunchydata/postgres-operator/apiserver/pvcservice"
 	"github.com/crunchydata/postgres-operator/apiserver/reloadservice"
 	"github.com/crunchydata/postgres-operator/apiserver/scheduleservice"
@@ -55,12 +57,14 @@ func RegisterAllRoutes(r *mux.Router) {
 	RegisterFailoverSvcRoutes(r)
 	RegisterLabelSvcRoutes(r)
 	RegisterLoadSvcRoutes(r)
+	RegisterLogicalReplicationSvcRoutes(r)
 	RegisterNamespaceSvcRoutes(r)
 	RegisterPGBouncerSvcRoutes(r)
 	RegisterPGDumpSvcRoutes(r)
 	RegisterPGORoleSvcRoutes(r)
 	RegisterPGOUserSvcRoutes(r)
 	RegisterPolicySvcRoutes(r)
+	RegisterProfileSvcRoutes(r)
 	RegisterPVCSvcRoutes(r)
 	RegisterReloadSvcRoutes(r)
 	RegisterScheduleSvcRoutes(r)
@@ -76,6 +80,9 @@ func RegisterBackrestSvcRoutes(r *mux.Router) {
 	r.HandleFunc("/backrestbackup", backrestservice.CreateBackupHandler).Methods("POST")
 	r.HandleFunc("/backrest/{name}", backrestservice.ShowBackrestHandler).Methods("GET")
 	r.HandleFunc("/restore", backrestservice.RestoreHandler).Methods("POST")
+	r.HandleFunc("/recoverability", backrestservice.ShowRecoverabilityHandler).Methods("POST")
+	r.HandleFunc("/snapshotbackup", backrestservice.CreateSnapshotBackupHandler).Methods("POST")
+	r.HandleFunc("/showsnapshotbackup", backrestservice.ShowSnapshotBackupHandler).Methods("POST")
 }
 
 // RegisterCatSvcRoutes registers all routes from the Cat Service
@@ -95,6 +102,8 @@ func RegisterClusterSvcRoutes(r *mux.Router) {
 	r.HandleFunc("/clustersdelete", clusterservice.DeleteClusterHandler).Methods("POST")
 	r.HandleFunc("/clustersupdate", clusterservice.UpdateClusterHandler).Methods("POST")
 	r.HandleFunc("/testclusters", clusterservice.TestClusterHandler).Methods("POST")
+	r.HandleFunc("/clusterexport", clusterservice.ExportClusterHandler).Methods("POST")
+	r.HandleFunc("/clusterapply", clusterservice.ApplyClusterHandler).Methods("POST")
 	r.HandleFunc("/clusters/scale/{name}", clusterservice.ScaleClusterHandler)
 	r.HandleFunc("/scale/{name}", clusterservice.ScaleQueryHandler).Methods("GET")
 	r.HandleFunc("/scaledown/{name}", clusterservice.ScaleDownHandler).Methods("GET")
@@ -127,6 +136,13 @@ func RegisterLoadSvcRoutes(r *mux.Router) {
 	r.HandleFunc("/load", loadservice.LoadHandler).Methods("POST")
 }
 
+// RegisterLogicalReplicationSvcRoutes registers all routes from the Logical
+// Replication Service
+func RegisterLogicalReplicationSvcRoutes(r *mux.Router) {
+	r.HandleFunc("/publication", logicalreplicationservice.CreatePublicationHandler).Methods("POST")
+	r.HandleFunc("/subscription", logicalreplicationservice.CreateSubscriptionHandler).Methods("POST")
+}
+
 // RegisterNamespaceSvcRoutes registers all routes from the Namespace Service
 func RegisterNamespaceSvcRoutes(r *mux.Router) {
 	r.HandleFunc("/namespace", namespaceservice.ShowNamespaceHandler).Methods("POST")
@@ -167,6 +183,13 @@ func RegisterPGOUserSvcRoutes(r *mux.Router) {
 	r.HandleFunc("/pgousershow", pgouserservice.ShowPgouserHandler).Methods("POST")
 }
 
+// RegisterProfileSvcRoutes registers all routes from the Profile Service
+func RegisterProfileSvcRoutes(r *mux.Router) {
+	r.HandleFunc("/profilecreate", profileservice.CreateProfileHandler).Methods("POST")
+	r.HandleFunc("/profileshow", profileservice.ShowProfileHandler).Methods("POST")
+	r.HandleFunc("/profiledelete", profileservice.DeleteProfileHandler).Methods("POST")
+}
+
 // RegisterPolicySvcRoutes registers all routes from the Policy Service
 func RegisterPolicySvcRoutes(r *mux.Router) {
 	r.HandleFunc("/policies", policyservice.CreatePolicyHandler)
@@ -100,6 +100,30 @@ func (s scheduleRequest) createPolicySchedule(cluster *crv1.Pgcluster, ns string
 	return schedule
 }
 
+func (s scheduleRequest) createHibernateSchedule(cluster *crv1.Pgcluster, ns string) *PgScheduleSpec {
+	name := fmt.Sprintf("%s-%s", cluster.Name, s.Request.ScheduleType)
+
+	if err := util.ValidateHibernateSchedule(s.Request.StopSchedule, s.Request.StartSchedule); err != nil {
+		s.Response.Status.Code = msgs.Error
+		s.Response.Status.Msg = err.Error()
+		return &PgScheduleSpec{}
+	}
+
+	schedule := &PgScheduleSpec{
+		Name:      name,
+		Cluster:   cluster.Name,
+		Version:   "v1",
+		Created:   time.Now().Format(time.RFC3339),
+		Type:      s.Request.ScheduleType,
+		Namespace: ns,
+		Hibernate: Hibernate{
+			Stop:  s.Request.StopSchedule,
+			Start: s.Request.StartSchedule,
+		},
+	}
+	return schedule
+}
+
 //  CreateSchedule
 func CreateSchedule(request *msgs.CreateScheduleRequest, ns string) msgs.CreateScheduleResponse {
 	log.Debugf("Create schedule called: %s", request.ClusterName)
@@ -150,6 +174,9 @@ func CreateSchedule(request *msgs.CreateScheduleRequest, ns string) msgs.CreateS
 		case "policy":
 			schedule := sr.createPolicySchedule(&cluster, ns)
 			schedules = append(schedules, schedule)
+		case "hibernate":
+			schedule := sr.createHibernateSchedule(&cluster, ns)
+			schedules = append(schedules, schedule)
 		default:
 			sr.Response.Status.Code = msgs.Error
 			sr.Response.Status.Msg = fmt.Sprintf("Schedule type unknown: %s", sr.Request.ScheduleType)
@@ -178,20 +205,7 @@ func CreateSchedule(request *msgs.CreateScheduleRequest, ns string) msgs.CreateS
 			return *sr.Response
 		}
 
-		labels := make(map[string]string)
-		labels["pg-cluster"] = schedule.Cluster
-		labels["crunchy-scheduler"] = "true"
-
-		data := make(map[string]string)
-		data[schedule.Name] = string(blob)
-
-		configmap := &v1.ConfigMap{
-			ObjectMeta: meta_v1.ObjectMeta{
-				Name:   schedule.Name,
-				Labels: labels,
-			},
-			Data: data,
-		}
+		configmap := NewScheduleConfigMap(schedule, blob)
 
 		log.Debug("Creating configmap..")
 		err = kubeapi.CreateConfigMap(apiserver.Clientset, configmap, schedule.Namespace)
@@ -207,6 +221,25 @@ func CreateSchedule(request *msgs.CreateScheduleRequest, ns string) msgs.CreateS
 	return *sr.Response
 }
 
+// NewScheduleConfigMap returns the configMap that stores a schedule for the
+// scheduler, given the schedule encoded as JSON
+func NewScheduleConfigMap(schedule *PgScheduleSpec, blob []byte) *v1.ConfigMap {
+	labels := make(map[string]string)
+	labels["pg-cluster"] = schedule.Cluster
+	labels["crunchy-scheduler"] = "true"
+
+	data := make(map[string]string)
+	data[schedule.Name] = string(blob)
+
+	return &v1.ConfigMap{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:   schedule.Name,
+			Labels: labels,
+		},
+		Data: data,
+	}
+}
+
 //  DeleteSchedule ...
 func DeleteSchedule(request *msgs.DeleteScheduleRequest, ns string) msgs.DeleteScheduleResponse {
 	log.Debug("Deleted schedule called")
@@ -305,6 +338,10 @@ func ShowSchedule(request *msgs.ShowScheduleRequest, ns string) msgs.ShowSchedul
 		if blob.Type == "pgbackrest" {
 			results += fmt.Sprintf("\n\tbackup-type: %s", blob.PGBackRest.Type)
 		}
+		if blob.Type == "hibernate" {
+			results = fmt.Sprintf("%s:\n\tschedule-type: %s", blob.Name, blob.Type)
+			results += showHibernateSchedule(&blob.Hibernate, time.Now())
+		}
 		sr.Results = append(sr.Results, results)
 	}
 	return *sr
@@ -334,3 +371,20 @@ func getSchedules(clusterName, selector, ns string) ([]string, error) {
 
 	return schedules, nil
 }
+
+// showHibernateSchedule returns the stop and start schedules of a hibernate
+// schedule along with the next time each of them runs
+func showHibernateSchedule(hibernate *Hibernate, now time.Time) string {
+	results := ""
+	for _, s := range []struct{ name, schedule string }{
+		{"stop", hibernate.Stop},
+		{"start", hibernate.Start},
+	} {
+		next := "unknown"
+		if t, err := util.NextScheduleTime(s.schedule, now); err == nil {
+			next = t.Format(time.RFC3339)
+		}
+		results += fmt.Sprintf("\n\t%s: %s\n\tnext-%s: %s", s.name, s.schedule, s.name, next)
+	}
+	return results
+}
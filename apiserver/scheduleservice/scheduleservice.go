@@ -34,6 +34,7 @@ type PgScheduleSpec struct {
 	Type       string `json:"type"`
 	PGBackRest `json:"pgbackrest,omitempty"`
 	Policy     `json:"policy,omitempty"`
+	Hibernate  `json:"hibernate,omitempty"`
 }
 
 type Policy struct {
@@ -44,6 +45,13 @@ type Policy struct {
 	ImageTag    string `json:"imageTag,omitempty"`
 }
 
+// Hibernate holds the cron expressions of when a cluster is shut down and when
+// it is started again
+type Hibernate struct {
+	Stop  string `json:"stop,omitempty"`
+	Start string `json:"start,omitempty"`
+}
+
 type PGBackRest struct {
 	Deployment  string `json:"deployment,omitempty"`
 	Label       string `json:"label,omitempty"`
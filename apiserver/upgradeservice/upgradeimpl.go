@@ -16,18 +16,23 @@ limitations under the License.
 */
 
 import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
 	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
 	"github.com/crunchydata/postgres-operator/apiserver"
 	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
 	"github.com/crunchydata/postgres-operator/config"
 	"github.com/crunchydata/postgres-operator/kubeapi"
+	"github.com/crunchydata/postgres-operator/util"
 	log "github.com/sirupsen/logrus"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 )
 
 // CreateUpgrade ...
-func CreateUpgrade(request *msgs.CreateUpgradeRequest, ns string) msgs.CreateUpgradeResponse {
+func CreateUpgrade(request *msgs.CreateUpgradeRequest, ns, pgouser string) msgs.CreateUpgradeResponse {
 	response := msgs.CreateUpgradeResponse{}
 	response.Status = msgs.Status{Code: msgs.Ok, Msg: ""}
 	response.Results = make([]string, 1)
@@ -72,6 +77,19 @@ func CreateUpgrade(request *msgs.CreateUpgradeRequest, ns string) msgs.CreateUpg
 	for _, clusterName := range request.Args {
 		log.Debugf("create upgrade called for %s", clusterName)
 
+		// a major upgrade is a workflow of its own, which is handled separately
+		if request.MajorVersion != "" {
+			msg, err := createMajorUpgrade(request, clusterName, ns, pgouser)
+			if err != nil {
+				response.Status.Code = msgs.Error
+				response.Status.Msg = err.Error()
+				return response
+			}
+
+			response.Results = append(response.Results, msg)
+			continue
+		}
+
 		//build the pgtask for the minor upgrade
 		spec := crv1.PgtaskSpec{}
 		spec.TaskType = crv1.PgtaskMinorUpgrade
@@ -142,3 +160,181 @@ func CreateUpgrade(request *msgs.CreateUpgradeRequest, ns string) msgs.CreateUpg
 
 	return response
 }
+
+// createMajorUpgrade validates that a cluster can be upgraded to a new
+// PostgreSQL major version and creates the major upgrade task, along with the
+// workflow that tracks the progress of the upgrade
+func createMajorUpgrade(request *msgs.CreateUpgradeRequest, clusterName, ns, pgouser string) (string, error) {
+	cluster := crv1.Pgcluster{}
+	found, err := kubeapi.Getpgcluster(apiserver.RESTClient, &cluster, clusterName, ns)
+	if !found {
+		return "", fmt.Errorf("%s is not a valid pgcluster", clusterName)
+	} else if err != nil {
+		return "", err
+	}
+
+	if err := validateMajorUpgrade(&cluster); err != nil {
+		return "", err
+	}
+
+	// figure out what image tag we are upgrading to, which has to contain the
+	// PostgreSQL major version that was requested
+	ccpImageTag := apiserver.Pgo.Cluster.CCPImageTag
+	if request.CCPImageTag != "" {
+		ccpImageTag = request.CCPImageTag
+	}
+
+	targetVersion, err := util.GetPostgresMajorVersion(ccpImageTag)
+	if err != nil {
+		return "", err
+	}
+
+	if targetVersion != request.MajorVersion {
+		return "", fmt.Errorf("image tag %s is for PostgreSQL %s, not %s. Use --ccp-image-tag "+
+			"to specify an image tag for PostgreSQL %s", ccpImageTag, targetVersion,
+			request.MajorVersion, request.MajorVersion)
+	}
+
+	currentVersion, err := util.GetPostgresMajorVersion(cluster.Spec.CCPImageTag)
+	if err != nil {
+		return "", err
+	}
+
+	if err := util.ValidateMajorUpgrade(currentVersion, targetVersion); err != nil {
+		return "", err
+	}
+
+	taskName := clusterName + "-" + config.LABEL_MAJOR_UPGRADE
+
+	// a major upgrade that is still running cannot be replaced, but the task of
+	// a previous upgrade is removed
+	task := crv1.Pgtask{}
+	found, _ = kubeapi.Getpgtask(apiserver.RESTClient, &task, taskName, ns)
+	if found {
+		if task.Spec.Status == crv1.InProgressStatus {
+			return "", fmt.Errorf("a major upgrade of %s is already in progress", clusterName)
+		}
+
+		if err := kubeapi.Deletepgtask(apiserver.RESTClient, taskName, ns); err != nil {
+			return "", err
+		}
+	}
+
+	workflowID, err := createMajorUpgradeWorkflowTask(clusterName, ns, pgouser)
+	if err != nil {
+		return "", err
+	}
+
+	task = crv1.Pgtask{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: taskName,
+			Labels: map[string]string{
+				config.LABEL_PG_CLUSTER: clusterName,
+				config.LABEL_PGOUSER:    pgouser,
+			},
+		},
+		Spec: crv1.PgtaskSpec{
+			Namespace: ns,
+			Name:      taskName,
+			TaskType:  crv1.PgtaskMajorUpgrade,
+			Status:    "requested",
+			Parameters: map[string]string{
+				config.LABEL_PG_CLUSTER:                   clusterName,
+				"CCPImageTag":                             ccpImageTag,
+				config.LABEL_MAJOR_UPGRADE_FROM_IMAGE_TAG: cluster.Spec.CCPImageTag,
+				config.LABEL_MAJOR_UPGRADE_FROM_VERSION:   currentVersion,
+				config.LABEL_MAJOR_UPGRADE_TO_VERSION:     targetVersion,
+				crv1.PgtaskWorkflowID:                     workflowID,
+			},
+		},
+	}
+
+	if err := kubeapi.Createpgtask(apiserver.RESTClient, &task, ns); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("created major upgrade task for %s from PostgreSQL %s to %s, workflow id %s",
+		clusterName, currentVersion, targetVersion, workflowID), nil
+}
+
+// createMajorUpgradeWorkflowTask creates the workflow task that tracks the
+// steps of a major upgrade and returns the ID of the workflow
+func createMajorUpgradeWorkflowTask(clusterName, ns, pgouser string) (string, error) {
+	// set a random ID for this workflow task
+	u, err := ioutil.ReadFile("/proc/sys/kernel/random/uuid")
+	if err != nil {
+		return "", err
+	}
+
+	id := string(u[:len(u)-1])
+
+	taskName := clusterName + "-" + crv1.PgtaskWorkflowMajorUpgradeType
+	task := &crv1.Pgtask{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: taskName,
+			Labels: map[string]string{
+				config.LABEL_PG_CLUSTER: clusterName,
+				config.LABEL_PGOUSER:    pgouser,
+				crv1.PgtaskWorkflowID:   id,
+			},
+		},
+		Spec: crv1.PgtaskSpec{
+			Namespace: ns,
+			Name:      taskName,
+			TaskType:  crv1.PgtaskWorkflow,
+			Parameters: map[string]string{
+				crv1.PgtaskWorkflowSubmittedStatus: time.Now().Format(time.RFC3339),
+				config.LABEL_PG_CLUSTER:            clusterName,
+				crv1.PgtaskWorkflowID:              id,
+			},
+		},
+	}
+
+	// remove the workflow of any previous major upgrade of the cluster
+	found, _ := kubeapi.Getpgtask(apiserver.RESTClient, &crv1.Pgtask{}, taskName, ns)
+	if found {
+		if err := kubeapi.Deletepgtask(apiserver.RESTClient, taskName, ns); err != nil {
+			return "", err
+		}
+	}
+
+	if err := kubeapi.Createpgtask(apiserver.RESTClient, task, ns); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// validateMajorUpgrade checks that a cluster is in a state that allows for a
+// major upgrade. The upgrade takes a pgBackRest backup before it starts and
+// re-creates the replicas from a pgBackRest backup once it is done, so
+// pgBackRest has to be enabled
+func validateMajorUpgrade(cluster *crv1.Pgcluster) error {
+	if cluster.Labels[config.LABEL_BACKREST] != "true" {
+		return fmt.Errorf("%s does not have pgbackrest enabled, which is required for a major upgrade",
+			cluster.Name)
+	}
+
+	if cluster.Spec.Standby {
+		return fmt.Errorf("%s is a standby cluster and cannot be upgraded. Upgrade the primary "+
+			"cluster and re-create the standby cluster instead", cluster.Name)
+	}
+
+	switch cluster.Status.State {
+	case crv1.PgclusterStateShutdown:
+		return fmt.Errorf("%s is shut down", cluster.Name)
+	case crv1.PgclusterStateInitialized:
+	default:
+		return fmt.Errorf("%s is not initialized", cluster.Name)
+	}
+
+	if cluster.Spec.UserLabels[config.LABEL_MINOR_UPGRADE] == config.LABEL_UPGRADE_IN_PROGRESS {
+		return fmt.Errorf("a minor upgrade of %s is in progress", cluster.Name)
+	}
+
+	if cluster.Spec.UserLabels[config.LABEL_MAJOR_UPGRADE] == config.LABEL_UPGRADE_IN_PROGRESS {
+		return fmt.Errorf("a major upgrade of %s is already in progress", cluster.Name)
+	}
+
+	return nil
+}
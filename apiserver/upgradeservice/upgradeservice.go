@@ -81,6 +81,6 @@ func CreateUpgradeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp = CreateUpgrade(&request, ns)
+	resp = CreateUpgrade(&request, ns, username)
 	json.NewEncoder(w).Encode(resp)
 }
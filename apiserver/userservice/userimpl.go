@@ -26,6 +26,7 @@ import (
 	"github.com/crunchydata/postgres-operator/apiserver"
 	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
 	"github.com/crunchydata/postgres-operator/kubeapi"
+	clusteroperator "github.com/crunchydata/postgres-operator/operator/cluster"
 	"github.com/crunchydata/postgres-operator/util"
 
 	log "github.com/sirupsen/logrus"
@@ -43,6 +44,10 @@ const (
 	// additional options being supplied to it, but allows for the user to be
 	// supplied in. Note that the user must be escape to avoid SQL injections
 	sqlAlterRole = `ALTER ROLE %s`
+	// sqlCreateCertAuthRole is SQL that creates a new PostgreSQL user that
+	// authenticates with a client certificate, and therefore has no password.
+	// The role name must be escaped with SQLQuoteIdentifier
+	sqlCreateCertAuthRole = `CREATE ROLE %s LOGIN`
 	// sqlCreateRole is SQL that allows a new PostgreSQL user to be created. To
 	// safely use this function, the role name and passsword must be escaped to
 	// avoid SQL injections, which is handled in the SetPostgreSQLPassword
@@ -167,6 +172,22 @@ func CreateUser(request *msgs.CreateUserRequest, pgouser string) msgs.CreateUser
 		return response
 	}
 
+	// a user that authenticates with a client certificate has no password
+	switch request.AuthType {
+	case "", msgs.UserAuthTypePassword:
+	case msgs.UserAuthTypeCert:
+		if request.Password != "" {
+			response.Status.Code = msgs.Error
+			response.Status.Msg = "A password cannot be set for a user that authenticates with a client certificate."
+			return response
+		}
+	default:
+		response.Status.Code = msgs.Error
+		response.Status.Msg = fmt.Sprintf("Invalid authentication type %q. Supported types are: %q, %q",
+			request.AuthType, msgs.UserAuthTypePassword, msgs.UserAuthTypeCert)
+		return response
+	}
+
 	// as the password age is uniform throughout the request, we can check for the
 	// user supplied value and the defaults here
 	validUntil := generateValidUntilDateString(request.PasswordAgeDays)
@@ -207,6 +228,24 @@ func CreateUser(request *msgs.CreateUserRequest, pgouser string) msgs.CreateUser
 			continue
 		}
 
+		// users of a cluster where every user authenticates with a client
+		// certificate do so as well
+		if request.AuthType == msgs.UserAuthTypeCert || cluster.Spec.TLS.CertAuth {
+			result.ValidUntil = ""
+
+			secretName, err := createCertAuthUser(&cluster, pod, result.Username)
+			if err != nil {
+				log.Error(err)
+
+				result.Error = true
+				result.ErrorMessage = err.Error()
+			}
+
+			result.TLSSecret = secretName
+			response.Results = append(response.Results, result)
+			continue
+		}
+
 		// build up the SQL clause that will be executed.
 		sql := sqlCreateRole
 
@@ -370,6 +409,9 @@ loop:
 		// or it fails to delete, we don't care
 		deleteUserSecret(cluster, result.Username)
 
+		// the same goes for the client certificate of the user, if it has one
+		deleteCertAuthUser(cluster, result.Username)
+
 		response.Results = append(response.Results, result)
 	}
 
@@ -610,6 +652,76 @@ func deleteUserSecret(cluster crv1.Pgcluster, username string) {
 	}
 }
 
+// createCertAuthUser creates a PostgreSQL user that authenticates with a client
+// certificate. The certificate is issued by the Operator into a Secret, whose
+// name is returned, and the user is added to the users of the cluster that
+// authenticate with one, which has the Operator update the pg_hba.conf of the
+// cluster as well as renew the certificate
+func createCertAuthUser(cluster *crv1.Pgcluster, pod *v1.Pod, username string) (string, error) {
+	if !cluster.Spec.TLS.Auto {
+		return "", fmt.Errorf("cluster %s does not have its certificates managed by the Operator",
+			cluster.Spec.ClusterName)
+	}
+
+	sql := fmt.Sprintf(sqlCreateCertAuthRole, util.SQLQuoteIdentifier(username))
+
+	if _, err := executeSQL(pod, sql, []string{}); err != nil {
+		return "", err
+	}
+
+	if err := clusteroperator.EnsureUserTLS(apiserver.Clientset, cluster, username); err != nil {
+		return "", err
+	}
+
+	// the user is added even if every user of the cluster authenticates with a
+	// client certificate, as the Operator would not renew it otherwise
+	for _, user := range cluster.Spec.TLS.CertAuthUsers {
+		if user == username {
+			return fmt.Sprintf(crv1.UserTLSSecretFormat, cluster.Spec.ClusterName, username), nil
+		}
+	}
+
+	cluster.Spec.TLS.CertAuthUsers = append(cluster.Spec.TLS.CertAuthUsers, username)
+
+	if err := kubeapi.Updatepgcluster(apiserver.RESTClient, cluster, cluster.Name,
+		cluster.Namespace); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(crv1.UserTLSSecretFormat, cluster.Spec.ClusterName, username), nil
+}
+
+// deleteCertAuthUser removes a PostgreSQL user from the users of a cluster that
+// authenticate with a client certificate, and deletes its certificate. As with
+// deleteUserSecret, we don't care if this fails, but log the error
+func deleteCertAuthUser(cluster crv1.Pgcluster, username string) {
+	users := []string{}
+	for _, user := range cluster.Spec.TLS.CertAuthUsers {
+		if user != username {
+			users = append(users, user)
+		}
+	}
+
+	if len(users) != len(cluster.Spec.TLS.CertAuthUsers) {
+		cluster.Spec.TLS.CertAuthUsers = users
+
+		if err := kubeapi.Updatepgcluster(apiserver.RESTClient, &cluster, cluster.Name,
+			cluster.Namespace); err != nil {
+			log.Error(err)
+		}
+	}
+
+	secretName := fmt.Sprintf(crv1.UserTLSSecretFormat, cluster.Spec.ClusterName, username)
+
+	if _, found, _ := kubeapi.GetSecret(apiserver.Clientset, secretName, cluster.Spec.Namespace); !found {
+		return
+	}
+
+	if err := kubeapi.DeleteSecret(apiserver.Clientset, secretName, cluster.Spec.Namespace); err != nil {
+		log.Error(err)
+	}
+}
+
 // executeSQL executes SQL on the primary PostgreSQL Pod. This occurs using the
 // Kubernets exec function, which allows us to perform the request over
 // a PostgreSQL connection that's authenticated with peer authentication
@@ -15,6 +15,10 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
+import (
+	"time"
+)
+
 // CreateBackrestBackupResponse ...
 // swagger:model
 type CreateBackrestBackupResponse struct {
@@ -132,3 +136,135 @@ type RestoreRequest struct {
 	NodeLabel           string
 	BackrestStorageType string
 }
+
+// RecoverabilityShowAllSelector is a value that is used to represent "all"
+const RecoverabilityShowAllSelector = "*"
+
+// ShowRecoverabilityRequest contains the parameters that can be used to get the
+// recoverability window of PostgreSQL clusters
+// swagger:model
+type ShowRecoverabilityRequest struct {
+	ClientVersion string
+	Namespace     string
+	// RPO, if set, is the recovery point objective, e.g. "24h", that the
+	// clusters are checked against. If not set, the value from the Operator
+	// configuration is used
+	RPO      string
+	Selector string
+}
+
+// RecoverabilityBackup contains information about the latest backup of a
+// specific type, i.e. "full", "diff" or "incr"
+// swagger:model
+type RecoverabilityBackup struct {
+	// Age is the number of seconds since the backup completed
+	Age       int64
+	Label     string
+	Timestamp time.Time
+}
+
+// RecoverabilityDetail contains the recoverability window of a pgBackRest
+// repository of a PostgreSQL cluster
+// swagger:model
+type RecoverabilityDetail struct {
+	ClusterName string
+	// EarliestRecoverableTime is the earliest point-in-time that the cluster
+	// can be recovered to, i.e. the end of the oldest backup that has all of
+	// the WAL it needs in the archive
+	EarliestRecoverableTime *time.Time
+	// EarliestRecoverableWAL is the WAL segment that has to be replayed to
+	// reach the earliest recoverable point
+	EarliestRecoverableWAL string
+	// Error is set if the recoverability of the repository could not be
+	// determined
+	Error string
+	// LastDiffBackup, LastFullBackup and LastIncrBackup are the latest backups
+	// of each type, if any
+	LastDiffBackup *RecoverabilityBackup
+	LastFullBackup *RecoverabilityBackup
+	LastIncrBackup *RecoverabilityBackup
+	// LatestBackupTime is the time the latest backup completed, which is the
+	// lower bound for the latest recoverable point
+	LatestBackupTime *time.Time
+	// LatestRecoverableTime is the end of the WAL archive, i.e. the latest
+	// point-in-time that the cluster can be recovered to, which the RPO is
+	// checked against
+	LatestRecoverableTime *time.Time
+	// LatestRecoverableWAL is the latest WAL segment in the archive, i.e. the
+	// latest point that the cluster can be recovered to
+	LatestRecoverableWAL string
+	// RepoSize is the total size of the backups and of the WAL archive in the
+	// repository, in bytes. The WAL archive is left out if it cannot be listed
+	RepoSize int64
+	// RPO is the recovery point objective the repository was checked against
+	RPO string
+	// RPOBreached is true if the repository does not meet the RPO, with the
+	// reasons listed in RPOBreaches
+	RPOBreached bool
+	RPOBreaches []string
+	Stanza      string
+	StorageType string
+	// WALGaps lists the ranges of WAL that are missing from the archive, i.e.
+	// the holes between its first and last segment and the WAL of backups that
+	// is not in it
+	WALGaps []string
+}
+
+// ShowRecoverabilityResponse returns the recoverability window of the
+// pgBackRest repositories of PostgreSQL clusters
+// swagger:model
+type ShowRecoverabilityResponse struct {
+	Results []RecoverabilityDetail
+	Status
+}
+
+// CreateSnapshotBackupRequest contains the parameters for taking snapshot
+// backups of PostgreSQL clusters
+// swagger:model
+type CreateSnapshotBackupRequest struct {
+	Args          []string
+	ClientVersion string
+	Namespace     string
+	Selector      string
+	// SnapshotClass, if set, is the VolumeSnapshotClass that the snapshots are
+	// taken with. Otherwise the class from the Operator configuration is used
+	SnapshotClass string
+}
+
+// CreateSnapshotBackupResponse returns the snapshot backups that were started
+// swagger:model
+type CreateSnapshotBackupResponse struct {
+	Results []string
+	Status
+}
+
+// ShowSnapshotBackupRequest contains the parameters for listing the snapshot
+// backups of PostgreSQL clusters
+// swagger:model
+type ShowSnapshotBackupRequest struct {
+	Args          []string
+	ClientVersion string
+	Namespace     string
+	Selector      string
+}
+
+// SnapshotBackupDetail describes a snapshot backup of a PostgreSQL cluster
+// swagger:model
+type SnapshotBackupDetail struct {
+	ClusterName         string
+	CreationTime        time.Time
+	Name                string
+	PGDataSnapshot      string
+	StartLSN            string
+	StopLSN             string
+	TablespaceSnapshots map[string]string
+	VolumeSnapshotClass string
+}
+
+// ShowSnapshotBackupResponse returns the snapshot backups of PostgreSQL
+// clusters
+// swagger:model
+type ShowSnapshotBackupResponse struct {
+	Results []SnapshotBackupDetail
+	Status
+}
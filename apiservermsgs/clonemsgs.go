@@ -23,8 +23,12 @@ type CloneRequest struct {
 	// BackrestPVCSize, if set, is the size of the PVC to use for the pgBackRest
 	// repository if local storage is being used
 	BackrestPVCSize string
+	// BackupSet, if set, is the label of the pgBackRest backup (e.g.
+	// "20200619-203502F") to restore the clone from. If not set, the latest
+	// backup is used
+	BackupSet string
 	// BackrestStorageSource contains the accepted values for where pgBackRest
-	// repository storage exists ("local", "s3" or both)
+	// repository storage exists ("local", "s3", "gcs" or "azure")
 	BackrestStorageSource string
 	ClientVersion         string
 	// EnableMetrics enables metrics support in the target cluster
@@ -33,12 +37,35 @@ type CloneRequest struct {
 	// PVCSize, if set, is the size of the PVC to use for the primary and any
 	// replicas
 	PVCSize string
+	// RecoveryTarget, if set, is the point that the clone is recovered to, e.g.
+	// a timestamp, a LSN, the name of a restore point or a transaction ID.
+	// RecoveryTargetType must also be set
+	RecoveryTarget string
+	// RecoveryTargetType is the type of the RecoveryTarget, and is one of
+	// "time", "lsn", "name" or "xid"
+	RecoveryTargetType string
+	// RotateCipherPass, if set, encrypts the pgBackRest repository of the target
+	// cluster with a newly generated passphrase. As the passphrase of an
+	// existing stanza cannot be changed, the target cluster starts with a new
+	// pgBackRest repository
+	RotateCipherPass bool
+	// Snapshot, if set, is the name of a snapshot backup of the source cluster
+	// to provision the PVCs of the target cluster from. The WAL since the
+	// snapshot was taken is then replayed from the pgBackRest repository
+	Snapshot string
 	// SourceClusterName is the name of the source PostgreSQL cluster being used
 	// for the clone
 	SourceClusterName string
 	// TargetClusterName is the name of the target PostgreSQL cluster that the
 	// PostgreSQL cluster will be cloned to
 	TargetClusterName string
+	// TargetNamespace, if set, is the namespace that the target PostgreSQL
+	// cluster is created in. If not set, the clone is created in the namespace
+	// of the source cluster
+	TargetNamespace string
+	// TTL, if set, is the duration after which the target cluster is deleted
+	// automatically, counting from when it is created, e.g. "72h"
+	TTL string
 }
 
 // CloneReseponse
@@ -46,5 +73,6 @@ type CloneRequest struct {
 type CloneResponse struct {
 	Status
 	TargetClusterName string
+	TargetNamespace   string
 	WorkflowID        string
 }
@@ -16,6 +16,9 @@ limitations under the License.
 */
 
 import (
+	"encoding/json"
+	"time"
+
 	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
 )
 
@@ -82,8 +85,25 @@ type CreateClusterRequest struct {
 	BackrestS3Bucket          string
 	BackrestS3Region          string
 	BackrestS3Endpoint        string
-	Standby                   bool
-	BackrestRepoPath          string
+	// BackrestGCSKey contains the GCS service account key, or the token if the
+	// GCS key type is set to "token"
+	BackrestGCSKey         string
+	BackrestGCSBucket      string
+	BackrestGCSEndpoint    string
+	BackrestGCSKeyType     string
+	BackrestAzureAccount   string
+	BackrestAzureKey       string
+	BackrestAzureContainer string
+	BackrestAzureEndpoint  string
+	BackrestAzureURIStyle  string
+	// BackrestStorageVerifyTLS determines if TLS certificates are verified when
+	// connecting to GCS or Azure. Only set if explicitly provided
+	BackrestStorageVerifyTLS *bool
+	// BackrestCipherType, if set to a value other than "none", encrypts the
+	// pgBackRest repository with a generated passphrase, e.g. "aes-256-cbc"
+	BackrestCipherType string
+	Standby            bool
+	BackrestRepoPath   string
 	// allow the user to set custom sizes for PVCs
 	// PVCSize applies to the primary/replica storage specs
 	PVCSize string
@@ -100,8 +120,27 @@ type CreateClusterRequest struct {
 	// of the initial database that is created
 	Database string
 	// TLSOnly indicates that a PostgreSQL cluster should be deployed with only
-	// TLS connections accepted. Requires that TLSSecret and CASecret are set
+	// TLS connections accepted. Requires that TLSSecret and CASecret, or
+	// TLSAuto, are set
 	TLSOnly bool
+	// TLSAuto has the Operator issue and renew the certificate of the
+	// PostgreSQL cluster, instead of using TLSSecret and CASecret
+	TLSAuto bool
+	// TLSCertAuth has the PostgreSQL users of the cluster authenticate with
+	// client certificates that the Operator issues. Requires TLSAuto
+	TLSCertAuth bool
+	// HBA are the pg_hba.conf rules of the cluster, in the format of a line of
+	// pg_hba.conf. If not set, the rules in pgo.yaml are used
+	HBA []string
+	// NetworkPolicy determines if the cluster has NetworkPolicies. Only set if
+	// explicitly provided, otherwise the value in pgo.yaml is used. Namespace
+	// selectors or source ranges imply NetworkPolicies
+	NetworkPolicy *bool
+	// NetworkPolicyNamespaces are label selectors of the namespaces whose pods
+	// may connect to the cluster, and NetworkPolicySourceRanges are the CIDRs
+	// that may do so
+	NetworkPolicyNamespaces   []string
+	NetworkPolicySourceRanges []string
 	// TLSSecret is the name of the secret that contains the keypair required to
 	// deploy a TLS-enabled PostgreSQL cluster
 	TLSSecret string
@@ -114,6 +153,41 @@ type CreateClusterRequest struct {
 	// MemoryRequest is the value of how much RAM should be requested for
 	// deploying the PostgreSQL cluster
 	MemoryRequest string
+	// DeletionProtection, if set, prevents the cluster from being deleted until
+	// the protection is disabled with "pgo update cluster"
+	DeletionProtection bool
+	// TTL, if set, is the duration after which the cluster is deleted
+	// automatically, e.g. "72h"
+	TTL string
+	// Tolerations are the tolerations of the pods of the cluster, in the
+	// "key=value:Effect" format of "kubectl taint"
+	Tolerations []string
+	// PriorityClassName is the name of the priority class of the pods of the
+	// cluster
+	PriorityClassName string
+	// ZoneSpread determines how the PostgreSQL instances are spread across
+	// zones, i.e. "required", "preferred" or "disabled"
+	ZoneSpread string
+	// Metadata holds the custom annotations and labels of the objects of the
+	// cluster
+	Metadata ClusterMetadata
+	// ReplicaServiceType is the type of the replica Service, if it should
+	// differ from the one of the primary Service
+	ReplicaServiceType string
+	// LoadBalancerSourceRanges restricts the clients of Services of type
+	// LoadBalancer to these CIDRs
+	LoadBalancerSourceRanges []string
+	// Profile is the name of a cluster profile whose settings are used for any
+	// setting that is not part of the request
+	Profile string
+	// DryRun, if set, validates the request and returns the objects that would
+	// be created for the cluster without creating any of them
+	DryRun bool
+	// SetFields are the names of the settings that were set explicitly, so
+	// that a setting that is turned off or set to zero, e.g. with
+	// "--replica-count=0", takes precedence over the setting of a profile. If
+	// not set, only settings that are not empty are considered set
+	SetFields []string
 }
 
 // CreateClusterDetail provides details about the PostgreSQL cluster that is
@@ -130,6 +204,9 @@ type CreateClusterDetail struct {
 	Users []CreateClusterDetailUser
 	// WorkflowID matches up to the WorkflowID of the cluster
 	WorkflowID string
+	// Objects contains the objects that would be created for the cluster when
+	// the request is a dry run, with the data of any secrets redacted
+	Objects []json.RawMessage
 }
 
 // CreateClusterDetailUser provides information about an individual PostgreSQL
@@ -212,6 +289,28 @@ type ShowClusterDetail struct {
 	Services    []ShowClusterService
 	Replicas    []ShowClusterReplica
 	Standby     bool
+	// TLS is only set for a TLS-enabled cluster
+	TLS *ShowClusterTLS
+	// HBA are the pg_hba.conf rules of the cluster if they are managed by the
+	// Operator, including the ones that the Operator needs
+	HBA []string
+}
+
+// ShowClusterTLS holds the expiration times of the certificate of a
+// TLS-enabled cluster and of the CA that issued it. A time that could not be
+// determined, e.g. as the secret it is in does not exist, is zero
+//
+// swagger:model
+type ShowClusterTLS struct {
+	// Auto is true if the Operator manages the certificate
+	Auto                  bool
+	CertificateExpiration time.Time
+	CAExpiration          time.Time
+	// CertAuth is true if the PostgreSQL users of the cluster authenticate
+	// with client certificates, and CertAuthUsers are the users that do so
+	// otherwise
+	CertAuth      bool
+	CertAuthUsers []string
 }
 
 // ShowClusterResponse ...
@@ -265,6 +364,46 @@ const (
 	UpdateClusterStandbyDisable
 )
 
+// UpdateClusterDeletionProtectionStatus defines the types for updating the
+// deletion protection of a cluster
+type UpdateClusterDeletionProtectionStatus int
+
+// set the different values around updating the deletion protection
+const (
+	UpdateClusterDeletionProtectionDoNothing UpdateClusterDeletionProtectionStatus = iota
+	UpdateClusterDeletionProtectionEnable
+	UpdateClusterDeletionProtectionDisable
+)
+
+// UpdateClusterNetworkPolicyStatus defines the types for updating the
+// NetworkPolicies of a cluster
+type UpdateClusterNetworkPolicyStatus int
+
+// set the different values around updating the NetworkPolicies
+const (
+	UpdateClusterNetworkPolicyDoNothing UpdateClusterNetworkPolicyStatus = iota
+	UpdateClusterNetworkPolicyEnable
+	UpdateClusterNetworkPolicyDisable
+)
+
+// ClusterMetadata holds the custom annotations and labels of the objects of a
+// cluster, in the "key=value" format. When updating a cluster, "key-" removes
+// the annotation or label. The objects are the PostgreSQL Pods, the primary and
+// the replica Services, and the Pods and Services of pgBouncer and of the
+// pgBackRest repository
+type ClusterMetadata struct {
+	AnnotationsPostgres       []string
+	AnnotationsPrimaryService []string
+	AnnotationsReplicaService []string
+	AnnotationsPgBouncer      []string
+	AnnotationsPgBackRest     []string
+	LabelsPostgres            []string
+	LabelsPrimaryService      []string
+	LabelsReplicaService      []string
+	LabelsPgBouncer           []string
+	LabelsPgBackRest          []string
+}
+
 // UpdateClusterRequest ...
 // swagger:model
 type UpdateClusterRequest struct {
@@ -280,6 +419,38 @@ type UpdateClusterRequest struct {
 	Startup       bool
 	Shutdown      bool
 	Tablespaces   []ClusterTablespaceDetail
+	// DeletionProtection enables or disables the deletion protection of the
+	// clusters. Disabling it requires the DisableDeletionProtection permission
+	DeletionProtection UpdateClusterDeletionProtectionStatus
+	// ExtendTTL, if set, is the duration that the expiration time of the
+	// clusters is pushed back by, e.g. "24h"
+	ExtendTTL string
+	// Metadata holds the custom annotations and labels to add to, or remove
+	// from, the objects of the clusters
+	Metadata ClusterMetadata
+	// ServiceType and ReplicaServiceType, if set, change the type of the
+	// primary and the replica Services. ClearReplicaServiceType removes the
+	// type of the replica Service, which then has the type of the primary
+	// Service again
+	ServiceType             string
+	ReplicaServiceType      string
+	ClearReplicaServiceType bool
+	// LoadBalancerSourceRanges, if set, replace the CIDRs that the clients of
+	// Services of type LoadBalancer are restricted to.
+	// ClearLoadBalancerSourceRanges removes them, which lets any client
+	// connect
+	LoadBalancerSourceRanges      []string
+	ClearLoadBalancerSourceRanges bool
+	// HBA, if set, replaces the pg_hba.conf rules of the clusters. ClearHBA
+	// removes them, which has the clusters use the rules in pgo.yaml
+	HBA      []string
+	ClearHBA bool
+	// NetworkPolicy enables or disables the NetworkPolicies of the clusters.
+	// NetworkPolicyNamespaces and NetworkPolicySourceRanges, if set, replace
+	// the namespaces and the CIDRs that may connect to the clusters
+	NetworkPolicy             UpdateClusterNetworkPolicyStatus
+	NetworkPolicyNamespaces   []string
+	NetworkPolicySourceRanges []string
 }
 
 // UpdateClusterResponse ...
@@ -384,3 +555,74 @@ type ClusterTablespaceDetail struct {
 	// e.g. "nfsstorage", that is specified in the pgo.yaml configuration
 	StorageConfig string
 }
+
+// ClusterExport is a portable description of a cluster that can be kept in
+// version control and applied to create the cluster again, e.g. in another
+// namespace. Secrets are only referenced by their names
+// swagger:model
+type ClusterExport struct {
+	// Cluster is the pgcluster, without the state that the Operator keeps in
+	// it while it runs the cluster
+	Cluster crv1.Pgcluster `json:"cluster"`
+	// Replicas are the pgreplicas of the cluster
+	Replicas []crv1.Pgreplica `json:"replicas,omitempty"`
+	// Policies are the SQL policies that have been applied to the cluster
+	Policies []crv1.Pgpolicy `json:"policies,omitempty"`
+	// Schedules are the schedules of the cluster, in the format they are
+	// stored in for the scheduler
+	Schedules []json.RawMessage `json:"schedules,omitempty"`
+	// PgBouncer holds the pgBouncer settings of the cluster
+	PgBouncer ClusterExportPgBouncer `json:"pgBouncer"`
+	// Users are the PostgreSQL users of the cluster that have a secret
+	Users []ClusterExportUser `json:"users,omitempty"`
+}
+
+// ClusterExportPgBouncer holds the pgBouncer settings of an exported cluster
+// swagger:model
+type ClusterExportPgBouncer struct {
+	Enabled    bool   `json:"enabled"`
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// ClusterExportUser references the secret of a PostgreSQL user of an exported
+// cluster
+// swagger:model
+type ClusterExportUser struct {
+	Username   string `json:"username"`
+	SecretName string `json:"secretName"`
+}
+
+// ExportClusterRequest ...
+// swagger:model
+type ExportClusterRequest struct {
+	ClusterName string
+	Namespace   string
+	// Version of API client
+	// required: true
+	ClientVersion string
+}
+
+// ExportClusterResponse ...
+// swagger:model
+type ExportClusterResponse struct {
+	Result ClusterExport
+	Status
+}
+
+// ApplyClusterRequest creates a cluster from an export in the namespace of the
+// request
+// swagger:model
+type ApplyClusterRequest struct {
+	Export    ClusterExport
+	Namespace string
+	// Version of API client
+	// required: true
+	ClientVersion string
+}
+
+// ApplyClusterResponse ...
+// swagger:model
+type ApplyClusterResponse struct {
+	Results []string
+	Status
+}
@@ -0,0 +1,67 @@
+package apiservermsgs
+
+/*
+Copyright 2020 Crunchy Data Solutions, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// CreatePublicationRequest creates a publication of a database of a cluster
+// that other clusters can subscribe to
+// swagger:model
+type CreatePublicationRequest struct {
+	// ClusterName is the cluster whose database is published
+	ClusterName string
+	// Name is the name of the publication
+	Name string
+	// Database is the database whose tables are published
+	Database string
+	// Tables are the tables that are published, which may be qualified by their
+	// schema. If none are given, every table of the database is published
+	Tables        []string
+	Namespace     string
+	ClientVersion string
+}
+
+// CreatePublicationResponse ...
+// swagger:model
+type CreatePublicationResponse struct {
+	Results []string
+	Status
+}
+
+// CreateSubscriptionRequest creates a subscription of a database of a cluster
+// to a publication of another cluster in the same namespace
+// swagger:model
+type CreateSubscriptionRequest struct {
+	// ClusterName is the cluster that subscribes to the publication
+	ClusterName string
+	// Name is the name of the subscription. If it is not set, the subscription
+	// is named after the publication
+	Name string
+	// SourceCluster is the cluster that has the publication
+	SourceCluster string
+	// Publication is the name of the publication
+	Publication string
+	// Database is the database the subscription is created in. If it is not
+	// set, it is the database of the publication
+	Database      string
+	Namespace     string
+	ClientVersion string
+}
+
+// CreateSubscriptionResponse ...
+// swagger:model
+type CreateSubscriptionResponse struct {
+	Results []string
+	Status
+}
@@ -24,6 +24,13 @@ type CreatePgbouncerRequest struct {
 	Selector      string
 	Namespace     string
 	ClientVersion string
+	// ReadOnly adds the read-only pgBouncer, whose pool connects to the
+	// replicas, along with pgBouncer if the cluster does not have it yet
+	ReadOnly bool
+	// Replicas is the number of Pods of each pgBouncer deployment. If it is 0,
+	// the number of Pods is not changed from the default of 1, or from that of
+	// the existing pgBouncer when only the read-only pgBouncer is added
+	Replicas int32
 }
 
 // CreatePgbouncerResponse ...
@@ -41,6 +48,8 @@ type DeletePgbouncerRequest struct {
 	Namespace     string
 	ClientVersion string
 	Uninstall     bool
+	// ReadOnly only deletes the read-only pgBouncer
+	ReadOnly bool
 }
 
 // DeletePgbouncerResponse ...
@@ -61,8 +70,22 @@ type ShowPgBouncerDetail struct {
 	// HasPgBouncer is set to true if there is a pgBouncer deployment with this
 	// cluster, otherwise its false
 	HasPgBouncer bool
+	// HasReadOnly is set to true if the cluster has a read-only pgBouncer
+	// deployment as well
+	HasReadOnly bool
 	// Password contains the password for the pgBouncer service account
 	Password string
+	// Replicas is the number of Pods of each pgBouncer deployment
+	Replicas int32
+	// ReadOnlyServiceClusterIP contains the ClusterIP address of the Service of
+	// the read-only pgBouncer
+	ReadOnlyServiceClusterIP string
+	// ReadOnlyServiceExternalIP contains the external IP address of the Service
+	// of the read-only pgBouncer, if it is assigned
+	ReadOnlyServiceExternalIP string
+	// ReadOnlyServiceName contains the name of the Kubernetes Service of the
+	// read-only pgBouncer
+	ReadOnlyServiceName string
 	// ServiceClusterIP contains the ClusterIP address of the Service
 	ServiceClusterIP string
 	// ServiceExternalIP contains the external IP address of the Service, if it
@@ -70,10 +93,74 @@ type ShowPgBouncerDetail struct {
 	ServiceExternalIP string
 	// ServiceName contains the name of the Kubernetes Service
 	ServiceName string
+	// Stats contains the statistics of the pgBouncer deployment and of the
+	// read-only pgBouncer deployment, if they are requested
+	Stats []PgBouncerStats
 	// Username is the username for the pgBouncer service account
 	Username string
 }
 
+// PgBouncerStats contains the statistics of the pools of a pgBouncer
+// deployment, which are gathered from the administrative console of each of
+// its Pods and aggregated across them
+//
+// swagger:model
+type PgBouncerStats struct {
+	// Name is the name of the pgBouncer deployment
+	Name string
+	// Pods is the number of Pods that the statistics were gathered from
+	Pods int
+	// Errors contains the errors of the Pods whose statistics could not be
+	// gathered
+	Errors []string
+	// Clients is the number of client connections
+	Clients int
+	// ClientsActive is the number of client connections that are linked to a
+	// server connection
+	ClientsActive int
+	// ClientsWaiting is the number of client connections that are waiting for
+	// a server connection
+	ClientsWaiting int
+	// ClientsByAddress is the number of client connections of each client
+	// address, which is helpful to find where a connection storm comes from
+	ClientsByAddress map[string]int
+	// MaxWait is the time in seconds that the oldest waiting client has been
+	// waiting for a server connection
+	MaxWait float64
+	// AverageWaitTime is the average time in microseconds that clients waited
+	// for a server connection in the last statistics period of pgBouncer
+	AverageWaitTime int64
+	// Servers is the number of server connections
+	Servers int
+	// ServersByState is the number of server connections in each state, e.g.
+	// "active" or "idle"
+	ServersByState map[string]int
+	// PoolSize is the number of server connections that the pools can open
+	PoolSize int
+	// Saturation is the ratio of active server connections to the pool size,
+	// where 1 means that the pools are exhausted
+	Saturation float64
+	// Pools contains the statistics of each pool, i.e. of each database and
+	// user
+	Pools []PgBouncerPoolStats
+}
+
+// PgBouncerPoolStats contains the statistics of a pool of a pgBouncer
+// deployment, aggregated across its Pods
+//
+// swagger:model
+type PgBouncerPoolStats struct {
+	Database       string
+	User           string
+	ClientsActive  int
+	ClientsWaiting int
+	ServersActive  int
+	ServersIdle    int
+	MaxWait        float64
+	PoolSize       int
+	Saturation     float64
+}
+
 // ShowPgBouncerRequest contains the attributes for requesting information about
 // a pgBouncer deployment
 //
@@ -93,6 +180,10 @@ type ShowPgBouncerRequest struct {
 	// Selector is optional and contains a selector to gather information about
 	// a PostgreSQL cluster's pgBouncer
 	Selector string
+
+	// Stats, if set, gathers the statistics of the pools of the pgBouncer
+	// deployments as well
+	Stats bool
 }
 
 // ShowPgBouncerResponse contains the attributes that are part of the response
@@ -138,6 +229,10 @@ type UpdatePgBouncerRequest struct {
 	// Namespace is the namespace to perform the query in
 	Namespace string
 
+	// Replicas, if greater than 0, sets the number of Pods of each pgBouncer
+	// deployment
+	Replicas int32
+
 	// RotatePassword is used to rotate the password for the "pgbouncer" service
 	// account
 	RotatePassword bool
@@ -0,0 +1,73 @@
+package apiservermsgs
+
+/*
+Copyright 2020 Crunchy Data Solutions, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// ProfileDetail is a cluster profile, i.e. a named set of settings for creating
+// clusters
+// swagger:model
+type ProfileDetail struct {
+	Name string
+	// Profile holds the settings of the profile. Settings that are not set are
+	// left to the request for a cluster and the defaults of the Operator
+	Profile CreateClusterRequest
+}
+
+// CreateProfileRequest ...
+// swagger:model
+type CreateProfileRequest struct {
+	Name          string
+	Profile       CreateClusterRequest
+	Namespace     string
+	ClientVersion string
+}
+
+// CreateProfileResponse ...
+// swagger:model
+type CreateProfileResponse struct {
+	Status
+}
+
+// ShowProfileRequest ...
+// swagger:model
+type ShowProfileRequest struct {
+	Names         []string
+	AllFlag       bool
+	Namespace     string
+	ClientVersion string
+}
+
+// ShowProfileResponse ...
+// swagger:model
+type ShowProfileResponse struct {
+	Results []ProfileDetail
+	Status
+}
+
+// DeleteProfileRequest ...
+// swagger:model
+type DeleteProfileRequest struct {
+	Names         []string
+	AllFlag       bool
+	Namespace     string
+	ClientVersion string
+}
+
+// DeleteProfileResponse ...
+// swagger:model
+type DeleteProfileResponse struct {
+	Results []string
+	Status
+}
@@ -32,6 +32,10 @@ type CreateScheduleRequest struct {
 	PolicyName          string
 	Database            string
 	Secret              string
+	// StopSchedule and StartSchedule are the cron expressions of when a
+	// cluster is shut down and started again by a hibernate schedule
+	StopSchedule  string
+	StartSchedule string
 }
 
 // CreateScheduleResponse ...
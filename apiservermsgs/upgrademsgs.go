@@ -25,6 +25,9 @@ type CreateUpgradeRequest struct {
 	Namespace     string
 	CCPImageTag   string
 	ClientVersion string
+	// MajorVersion, if set, is the PostgreSQL major version (e.g. "13") to
+	// upgrade the clusters to using pg_upgrade
+	MajorVersion string
 }
 
 // CreateUpgradeResponse ...
@@ -27,11 +27,23 @@ const (
 	UpdateUserLoginDisable
 )
 
+// the ways a PostgreSQL user can authenticate, which are set in the AuthType
+// of a CreateUserRequest
+const (
+	// UserAuthTypePassword has the user authenticate with a password, which is
+	// the default
+	UserAuthTypePassword = "password"
+	// UserAuthTypeCert has the user authenticate with a client certificate that
+	// the Operator issues
+	UserAuthTypeCert = "cert"
+)
+
 // CreateUserRequest contains the parameters that are passed in when an Operator
 // user requests to create a new PostgreSQL user
 // swagger:model
 type CreateUserRequest struct {
 	AllFlag         bool
+	AuthType        string
 	Clusters        []string
 	ClientVersion   string
 	ManagedUser     bool
@@ -126,6 +138,9 @@ type UserResponseDetail struct {
 	Error        bool
 	ErrorMessage string
 	Password     string
-	Username     string
-	ValidUntil   string
+	// TLSSecret is the name of the Secret with the client certificate of a user
+	// that authenticates with one
+	TLSSecret  string
+	Username   string
+	ValidUntil string
 }
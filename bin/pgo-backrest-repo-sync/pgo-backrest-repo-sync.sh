@@ -60,15 +60,74 @@ aws_sync_repo() {
 	echo "Finished aws s3 sync"
 }
 
+# Use rclone to sync files from a source location to a target location in
+# either GCS or Azure Blob storage, or to a local directory.  The pgBackRest
+# settings for the repository are translated into the equivalent rclone
+# settings, which allows for the use of emulators such as fake-gcs-server and
+# Azurite by setting a custom endpoint and disabling TLS verification.
+rclone_sync_repo() {
+	rclone_opts=()
+
+	if [[ "${PGBACKREST_REPO1_STORAGE_VERIFY_TLS}" == "n" ]]
+	then
+		rclone_opts+=("--no-check-certificate")
+	fi
+
+	if [[ "${BACKREST_CLOUD_STORAGE}" == "gcs" ]]
+	then
+		if [[ "${PGBACKREST_REPO1_GCS_ENDPOINT}" != "" ]]
+		then
+			rclone_opts+=("--gcs-endpoint=https://${PGBACKREST_REPO1_GCS_ENDPOINT}/storage/v1/")
+		fi
+
+		if [[ "${PGBACKREST_REPO1_GCS_KEY_TYPE}" == "token" ]]
+		then
+			rclone_opts+=("--gcs-access-token=${PGBACKREST_REPO1_GCS_KEY}")
+		else
+			rclone_opts+=("--gcs-service-account-file=${PGBACKREST_REPO1_GCS_KEY}")
+		fi
+	else
+		rclone_opts+=("--azureblob-account=${PGBACKREST_REPO1_AZURE_ACCOUNT}")
+		rclone_opts+=("--azureblob-key=${PGBACKREST_REPO1_AZURE_KEY}")
+
+		if [[ "${PGBACKREST_REPO1_AZURE_ENDPOINT}" != "" ]]
+		then
+			if [[ "${PGBACKREST_REPO1_AZURE_URI_STYLE}" == "path" ]]
+			then
+				azure_endpoint="https://${PGBACKREST_REPO1_AZURE_ENDPOINT}/${PGBACKREST_REPO1_AZURE_ACCOUNT}"
+			else
+				azure_endpoint="https://${PGBACKREST_REPO1_AZURE_ACCOUNT}.${PGBACKREST_REPO1_AZURE_ENDPOINT}"
+			fi
+			rclone_opts+=("--azureblob-endpoint=${azure_endpoint}")
+		fi
+	fi
+
+	echo "Executing rclone sync from source ${1} to target ${2}"
+	rclone sync "${rclone_opts[@]}" "${1}" "${2}"
+	echo "Finished rclone sync"
+}
+
+# rclone_location returns the rclone location of the repository path provided
+# in the GCS bucket or Azure container that is in use
+rclone_location() {
+	if [[ "${BACKREST_CLOUD_STORAGE}" == "gcs" ]]
+	then
+		echo ":gcs:${PGBACKREST_REPO1_GCS_BUCKET}${1}/"
+	else
+		echo ":azureblob:${PGBACKREST_REPO1_AZURE_CONTAINER}${1}/"
+	fi
+}
+
 # If s3 is identifed as the data source, then the aws cli will be utilized to
 # sync the repo to the target location in s3.  If local storage is also enabled
 # (along with s3) for the cluster, then also use the aws cli to sync the repo
-# from s3 to the target volume locally.
+# from s3 to the target volume locally.  The same applies to gcs and azure,
+# which use rclone instead of the aws cli.
 #
 # If the data source is local (the default if not specified at all), then first
-# rsync the repo to the target directory locally.  Then, if s3 storage is also
-# enabled (along with local), use the aws cli to sync the local repo to the
-# target s3 location.
+# rsync the repo to the target directory locally.  Then, if s3, gcs or azure
+# storage is also enabled (along with local), sync the local repo to the target
+# location in the cloud storage.
 if [[ "${BACKREST_STORAGE_SOURCE}" == "s3" ]]
 then
 	aws_source="s3://${PGBACKREST_REPO1_S3_BUCKET}${PGBACKREST_REPO1_PATH}/"
@@ -80,6 +139,16 @@ then
 		aws_target="${NEW_PGBACKREST_REPO}/"
 		aws_sync_repo "${aws_source}" "${aws_target}"
 	fi
+elif [[ "${BACKREST_STORAGE_SOURCE}" == "gcs" || "${BACKREST_STORAGE_SOURCE}" == "azure" ]]
+then
+	rclone_source="$(rclone_location "${PGBACKREST_REPO1_PATH}")"
+	rclone_target="$(rclone_location "${NEW_PGBACKREST_REPO}")"
+	rclone_sync_repo "${rclone_source}" "${rclone_target}"
+	if [[ "${PGHA_PGBACKREST_LOCAL_GCS_STORAGE}" == "true" || \
+		"${PGHA_PGBACKREST_LOCAL_AZURE_STORAGE}" == "true" ]]
+	then
+		rclone_sync_repo "${rclone_source}" "${NEW_PGBACKREST_REPO}/"
+	fi
 else
 	enable_sshd # enable sshd for rsync
 
@@ -91,5 +160,9 @@ else
 		aws_source="${NEW_PGBACKREST_REPO}/"
 		aws_target="s3://${PGBACKREST_REPO1_S3_BUCKET}${NEW_PGBACKREST_REPO}/"
 		aws_sync_repo "${aws_source}" "${aws_target}"
+	elif [[ "${PGHA_PGBACKREST_LOCAL_GCS_STORAGE}" == "true" || \
+		"${PGHA_PGBACKREST_LOCAL_AZURE_STORAGE}" == "true" ]]
+	then
+		rclone_sync_repo "${NEW_PGBACKREST_REPO}/" "$(rclone_location "${NEW_PGBACKREST_REPO}")"
 	fi
 fi
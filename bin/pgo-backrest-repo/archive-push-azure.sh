@@ -0,0 +1,26 @@
+#!/bin/bash
+
+# Copyright 2020 Crunchy Data Solutions, Inc.
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# pgBackRest does not pass the credentials of the Azure Blob repository to the
+# repository host, and the environment of this container is not visible to
+# commands that are run over SSH, so the credentials must be in the pgBackRest
+# configuration that pgo-backrest-repo.sh writes on startup
+if ! grep -q "^repo1-azure-key=" /etc/pgbackrest/pgbackrest.conf
+then
+	echo "repo1-azure-key is not set in /etc/pgbackrest/pgbackrest.conf" >&2
+	exit 1
+fi
+
+pgbackrest "$@"
@@ -52,6 +52,23 @@ then
 		then
 			printf "repo1-s3-key-secret=%s\n" "${PGBACKREST_REPO1_S3_KEY_SECRET}" >> /etc/pgbackrest/pgbackrest.conf
 		fi
+
+		# The same applies to the GCS and Azure Blob credentials, which
+		# pgBackRest does not pass to the repository host either
+		if [[ "${PGBACKREST_REPO1_GCS_KEY}" != "" ]]
+		then
+			printf "repo1-gcs-key=%s\n" "${PGBACKREST_REPO1_GCS_KEY}" >> /etc/pgbackrest/pgbackrest.conf
+		fi
+
+		if [[ "${PGBACKREST_REPO1_AZURE_ACCOUNT}" != "" ]]
+		then
+			printf "repo1-azure-account=%s\n" "${PGBACKREST_REPO1_AZURE_ACCOUNT}" >> /etc/pgbackrest/pgbackrest.conf
+		fi
+
+		if [[ "${PGBACKREST_REPO1_AZURE_KEY}" != "" ]]
+		then
+			printf "repo1-azure-key=%s\n" "${PGBACKREST_REPO1_AZURE_KEY}" >> /etc/pgbackrest/pgbackrest.conf
+		fi
 fi
 
 mkdir ~/.ssh/
@@ -17,11 +17,36 @@ package config
 
 // annotations used by the operator
 const (
-	ANNOTATION_PGHA_BOOTSTRAP_REPLICA    = "pgo-pgha-bootstrap-replica"
-	ANNOTATION_CLONE_BACKREST_PVC_SIZE   = "clone-backrest-pvc-size"
-	ANNOTATION_CLONE_ENABLE_METRICS      = "clone-enable-metrics"
-	ANNOTATION_CLONE_PVC_SIZE            = "clone-pvc-size"
-	ANNOTATION_CLONE_SOURCE_CLUSTER_NAME = "clone-source-cluster-name"
-	ANNOTATION_CLONE_TARGET_CLUSTER_NAME = "clone-target-cluster-name"
-	ANNOTATION_PRIMARY_DEPLOYMENT        = "primary-deployment"
+	ANNOTATION_PGHA_BOOTSTRAP_REPLICA     = "pgo-pgha-bootstrap-replica"
+	ANNOTATION_CLONE_BACKREST_PVC_SIZE    = "clone-backrest-pvc-size"
+	ANNOTATION_CLONE_BACKUP_SET           = "clone-backup-set"
+	ANNOTATION_CLONE_ENABLE_METRICS       = "clone-enable-metrics"
+	ANNOTATION_CLONE_PVC_SIZE             = "clone-pvc-size"
+	ANNOTATION_CLONE_RECOVERY_TARGET      = "clone-recovery-target"
+	ANNOTATION_CLONE_RECOVERY_TARGET_TYPE = "clone-recovery-target-type"
+	ANNOTATION_CLONE_ROTATE_CIPHER_PASS   = "clone-rotate-cipher-pass"
+	ANNOTATION_CLONE_SNAPSHOT             = "clone-snapshot"
+	ANNOTATION_CLONE_SOURCE_CLUSTER_NAME  = "clone-source-cluster-name"
+	ANNOTATION_CLONE_SOURCE_NAMESPACE     = "clone-source-namespace"
+	ANNOTATION_CLONE_TARGET_CLUSTER_NAME  = "clone-target-cluster-name"
+	ANNOTATION_CLONE_TTL                  = "clone-ttl"
+	ANNOTATION_PRIMARY_DEPLOYMENT         = "primary-deployment"
+	// annotations that control which data is kept when a pgcluster is deleted
+	ANNOTATION_KEEP_BACKUPS = "keep-backups"
+	ANNOTATION_KEEP_DATA    = "keep-data"
+	// annotation that stops the Operator from reconciling a pgcluster
+	ANNOTATION_RECONCILE_PAUSED = "reconcile-paused"
+	// annotation that records the expiration time of a pgcluster that a
+	// warning was already sent for
+	ANNOTATION_EXPIRATION_WARNED = "expiration-warned"
+	// annotations that record which annotations and labels of an object are the
+	// custom ones of its pgcluster
+	ANNOTATION_CUSTOM_ANNOTATIONS = "pgo-custom-annotations"
+	ANNOTATION_CUSTOM_LABELS      = "pgo-custom-labels"
+	// annotation that records the serial number of the TLS certificate that
+	// PostgreSQL was last reloaded with on a pod
+	ANNOTATION_TLS_CERTIFICATE = "pgo-tls-certificate"
+	// annotation of the pod template of a pgBouncer deployment that records when
+	// the Operator last restarted its pods, e.g. to rotate the password
+	ANNOTATION_PGBOUNCER_RESTARTED_AT = "pgo-pgbouncer-restarted-at"
 )
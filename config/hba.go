@@ -0,0 +1,60 @@
+package config
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"fmt"
+	"strings"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+)
+
+// ParseHBARule parses a pg_hba.conf rule in the same format as a line of
+// pg_hba.conf, i.e. "type database user address method", where rules of type
+// "local" have no address. Options of the authentication method are not
+// supported
+func ParseHBARule(rule string) (crv1.HBARule, error) {
+	fields := strings.Fields(rule)
+	result := crv1.HBARule{}
+
+	switch {
+	case len(fields) == 4 && fields[0] == "local":
+		result.Type, result.Database, result.User, result.Method = fields[0], fields[1], fields[2], fields[3]
+	case len(fields) == 5 && fields[0] != "local":
+		result.Type, result.Database, result.User, result.Address, result.Method =
+			fields[0], fields[1], fields[2], fields[3], fields[4]
+	default:
+		return result, fmt.Errorf("invalid pg_hba rule %q, the format is \"type database user address method\", "+
+			"without an address for rules of type \"local\"", rule)
+	}
+
+	return result, result.Validate()
+}
+
+// ParseHBARules parses a list of pg_hba.conf rules, see ParseHBARule
+func ParseHBARules(rules []string) ([]crv1.HBARule, error) {
+	result := make([]crv1.HBARule, 0, len(rules))
+
+	for _, rule := range rules {
+		parsed, err := ParseHBARule(rule)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, parsed)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,73 @@
+package config
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"testing"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+)
+
+func TestParseHBARule(t *testing.T) {
+	tests := []struct {
+		rule     string
+		expected crv1.HBARule
+		valid    bool
+	}{
+		{"hostssl app app,+readers 10.0.0.0/8 scram-sha-256", crv1.HBARule{Type: "hostssl", Database: "app",
+			User: "app,+readers", Address: "10.0.0.0/8", Method: "scram-sha-256"}, true},
+		{"host  all  all  samenet  md5", crv1.HBARule{Type: "host", Database: "all", User: "all",
+			Address: "samenet", Method: "md5"}, true},
+		{"host all all fd00::/8 reject", crv1.HBARule{Type: "host", Database: "all", User: "all",
+			Address: "fd00::/8", Method: "reject"}, true},
+		{"local all app peer", crv1.HBARule{Type: "local", Database: "all", User: "app", Method: "peer"}, true},
+		{"hostssl all app all cert", crv1.HBARule{Type: "hostssl", Database: "all", User: "app",
+			Address: "all", Method: "cert"}, true},
+		{"local all app 10.0.0.0/8 md5", crv1.HBARule{}, false},
+		{"host all app md5", crv1.HBARule{}, false},
+		{"hostgssenc all all all md5", crv1.HBARule{}, false},
+		{"host replication app all md5", crv1.HBARule{}, false},
+		{"host all primaryuser all md5", crv1.HBARule{}, false},
+		{"host all app,+pgbouncer all md5", crv1.HBARule{}, false},
+		{"host all @users all md5", crv1.HBARule{}, false},
+		{"host +app all all md5", crv1.HBARule{}, false},
+		{"host all app 10.0.0.1 md5", crv1.HBARule{}, false},
+		{"host all app all ldap", crv1.HBARule{}, false},
+		{"host all app all peer", crv1.HBARule{}, false},
+		{"host all app all cert", crv1.HBARule{}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.rule, func(t *testing.T) {
+			rule, err := ParseHBARule(test.rule)
+
+			if !test.valid {
+				if err == nil {
+					t.Fatalf("expected an error for %q", test.rule)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rule != test.expected {
+				t.Fatalf("expected %v, got %v", test.expected, rule)
+			}
+		})
+	}
+}
@@ -36,6 +36,7 @@ const (
 	CONTAINER_IMAGE_CRUNCHY_POSTGRES_HA      = "crunchy-postgres-ha"
 	CONTAINER_IMAGE_CRUNCHY_POSTGRES_GIS_HA  = "crunchy-postgres-gis-ha"
 	CONTAINER_IMAGE_CRUNCHY_PROMETHEUS       = "crunchy-prometheus"
+	CONTAINER_IMAGE_CRUNCHY_UPGRADE          = "crunchy-upgrade"
 )
 
 // a map of the "RELATED_IMAGE_*" environmental variables to their defined
@@ -61,4 +62,5 @@ var RelatedImageMap = map[string]string{
 	"RELATED_IMAGE_CRUNCHY_PGRESTORE":        CONTAINER_IMAGE_CRUNCHY_PGRESTORE,
 	"RELATED_IMAGE_CRUNCHY_POSTGRES_HA":      CONTAINER_IMAGE_CRUNCHY_POSTGRES_HA,
 	"RELATED_IMAGE_CRUNCHY_POSTGRES_GIS_HA":  CONTAINER_IMAGE_CRUNCHY_POSTGRES_GIS_HA,
+	"RELATED_IMAGE_CRUNCHY_UPGRADE":          CONTAINER_IMAGE_CRUNCHY_UPGRADE,
 }
@@ -65,8 +65,22 @@ const LABEL_STARTUP = "startup"
 const LABEL_SHUTDOWN = "shutdown"
 
 const LABEL_MINOR_UPGRADE = "minor-upgrade"
+const LABEL_MAJOR_UPGRADE = "major-upgrade"
+const LABEL_MAJOR_UPGRADE_FROM_VERSION = "major-upgrade-from-version"
+const LABEL_MAJOR_UPGRADE_TO_VERSION = "major-upgrade-to-version"
+const LABEL_MAJOR_UPGRADE_FROM_IMAGE_TAG = "major-upgrade-from-image-tag"
+const LABEL_MAJOR_UPGRADE_STEP = "major-upgrade-step"
+const LABEL_MAJOR_UPGRADE_ENCODING = "major-upgrade-encoding"
+const LABEL_MAJOR_UPGRADE_LC_COLLATE = "major-upgrade-lc-collate"
+const LABEL_MAJOR_UPGRADE_LC_CTYPE = "major-upgrade-lc-ctype"
+const LABEL_MAJOR_UPGRADE_DATA_CHECKSUMS = "major-upgrade-data-checksums"
+const LABEL_MAJOR_UPGRADE_EXTENSIONS = "major-upgrade-extensions"
+const LABEL_MAJOR_UPGRADE_EXTENSION_NAMES = "major-upgrade-extension-names"
+const LABEL_MAJOR_UPGRADE_ROLLBACK = "major-upgrade-rollback"
+const LABEL_PG_UPGRADE_JOB = "pgo-pg-upgrade"
 const LABEL_UPGRADE_IN_PROGRESS = "upgrade-in-progress"
 const LABEL_UPGRADE_COMPLETED = "upgrade-complete"
+const LABEL_UPGRADE_FAILED = "upgrade-failed"
 const LABEL_UPGRADE_REPLICA = "upgrade-replicas"
 const LABEL_UPGRADE_PRIMARY = "upgrade-primary"
 const LABEL_UPGRADE_BACKREST = "upgrade-backrest"
@@ -89,6 +103,18 @@ const LABEL_BADGER = "crunchy-pgbadger"
 const LABEL_BADGER_CCPIMAGE = "crunchy-pgbadger"
 const LABEL_BACKUP_TYPE_BACKREST = "pgbackrest"
 const LABEL_BACKUP_TYPE_PGDUMP = "pgdump"
+const LABEL_BACKUP_TYPE_SNAPSHOT = "snapshot"
+
+// LABEL_PGO_SNAPSHOT is set on each VolumeSnapshot of a snapshot backup to the
+// name of the snapshot backup, and LABEL_SNAPSHOT_RESTORE on the jobs that
+// prepare PVCs that were provisioned from a snapshot backup
+const LABEL_PGO_SNAPSHOT = "pgo-snapshot"
+const LABEL_SNAPSHOT_RESTORE = "pgo-snapshot-restore"
+const LABEL_SNAPSHOT_CLASS = "snapshot-class"
+const LABEL_SNAPSHOT = "snapshot"
+
+const LABEL_TOLERATION = "toleration"
+const LABEL_PRIORITY_CLASS = "priority-class"
 
 const LABEL_PGDUMP_COMMAND = "pgdump"
 const LABEL_PGDUMP_RESTORE = "pgdump-restore"
@@ -121,6 +147,8 @@ const LABEL_ROLENAME = "rolename"
 const LABEL_PASSWORD = "password"
 
 const LABEL_PGBOUNCER = "crunchy-pgbouncer"
+const LABEL_PGBOUNCER_READ_ONLY = "pgbouncer-read-only"
+const LABEL_PGBOUNCER_REPLICAS = "pgbouncer-replicas"
 const LABEL_PGBOUNCER_ROTATE_PASSWORD = "pgbouncer-rotate-password"
 const LABEL_PGBOUNCER_TASK_ADD = "pgbouncer-add"
 const LABEL_PGBOUNCER_TASK_DELETE = "pgbouncer-delete"
@@ -154,6 +182,8 @@ const LABEL_CLAIM_NAME = "claimName"
 
 const LABEL_PGO_PGOUSER = "pgo-pgouser"
 const LABEL_PGO_PGOROLE = "pgo-pgorole"
+const LABEL_PGO_PROFILE = "pgo-profile"
+const LABEL_PROFILE_NAME = "profile-name"
 const LABEL_PGOUSER = "pgouser"
 const LABEL_WORKFLOW_ID = "workflowid" // NOTE: this now matches crv1.PgtaskWorkflowID
 
@@ -23,6 +23,7 @@ import (
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
 	"github.com/crunchydata/postgres-operator/kubeapi"
@@ -121,6 +122,18 @@ var PgbackrestS3EnvVarsTemplate *template.Template
 
 const pgbackrestS3EnvVarsPath = "pgbackrest-s3-env-vars.json"
 
+var PgbackrestGCSEnvVarsTemplate *template.Template
+
+const pgbackrestGCSEnvVarsPath = "pgbackrest-gcs-env-vars.json"
+
+var PgbackrestAzureEnvVarsTemplate *template.Template
+
+const pgbackrestAzureEnvVarsPath = "pgbackrest-azure-env-vars.json"
+
+var PgbackrestCipherEnvVarsTemplate *template.Template
+
+const pgbackrestCipherEnvVarsPath = "pgbackrest-cipher-env-vars.json"
+
 var PgbouncerTemplate *template.Template
 
 const pgbouncerTemplatePath = "pgbouncer-template.json"
@@ -153,6 +166,14 @@ var BackrestRestorejobTemplate *template.Template
 
 const backrestRestorejobPath = "backrest-restore-job.json"
 
+var SnapshotRestorejobTemplate *template.Template
+
+const snapshotRestorejobPath = "snapshot-restore-job.json"
+
+var PgUpgradeJobTemplate *template.Template
+
+const pgUpgradeJobPath = "pgupgrade-job.json"
+
 var PgDumpBackupJobTemplate *template.Template
 
 const pgDumpBackupJobPath = "pgdump-job.json"
@@ -203,6 +224,15 @@ type ClusterStruct struct {
 	BackrestS3Bucket              string `yaml:"BackrestS3Bucket"`
 	BackrestS3Endpoint            string `yaml:"BackrestS3Endpoint"`
 	BackrestS3Region              string `yaml:"BackrestS3Region"`
+	BackrestGCSBucket             string `yaml:"BackrestGCSBucket"`
+	BackrestGCSEndpoint           string `yaml:"BackrestGCSEndpoint"`
+	BackrestGCSKeyType            string `yaml:"BackrestGCSKeyType"`
+	BackrestAzureContainer        string `yaml:"BackrestAzureContainer"`
+	BackrestAzureEndpoint         string `yaml:"BackrestAzureEndpoint"`
+	BackrestAzureURIStyle         string `yaml:"BackrestAzureURIStyle"`
+	BackrestStorageVerifyTLS      string `yaml:"BackrestStorageVerifyTLS"`
+	BackrestRPO                   string `yaml:"BackrestRPO"`
+	SnapshotClass                 string `yaml:"SnapshotClass"`
 	DisableAutofail               bool   `yaml:"DisableAutofail"`
 	PgmonitorPassword             string `yaml:"PgmonitorPassword"`
 	EnableCrunchyadm              bool   `yaml:"EnableCrunchyadm"`
@@ -211,6 +241,20 @@ type ClusterStruct struct {
 	PodAntiAffinityPgBackRest     string `yaml:"PodAntiAffinityPgBackRest"`
 	PodAntiAffinityPgBouncer      string `yaml:"PodAntiAffinityPgBouncer"`
 	SyncReplication               bool   `yaml:"SyncReplication"`
+	// Tolerations are the default tolerations of the pods of a cluster, in the
+	// format "key=value:Effect"
+	Tolerations []string `yaml:"Tolerations"`
+	// HBA are the default pg_hba.conf rules of a cluster, in the format of a
+	// line of pg_hba.conf, e.g. "hostssl all all 10.0.0.0/8 scram-sha-256"
+	HBA []string `yaml:"HBA"`
+	// PriorityClassName is the default priority class of the pods of a cluster
+	PriorityClassName string `yaml:"PriorityClassName"`
+	// ZoneSpread is the default spread of the PostgreSQL instances of a
+	// cluster across zones, i.e. "required", "preferred" or "disabled"
+	ZoneSpread string `yaml:"ZoneSpread"`
+	// NetworkPolicy, if true, creates NetworkPolicies for new clusters that
+	// restrict their clients
+	NetworkPolicy bool `yaml:"NetworkPolicy"`
 }
 
 type StorageStruct struct {
@@ -234,6 +278,7 @@ type PgoStruct struct {
 	Audit                 bool   `yaml:"Audit"`
 	PGOImagePrefix        string `yaml:"PGOImagePrefix"`
 	PGOImageTag           string `yaml:"PGOImageTag"`
+	ReconcileInterval     string `yaml:"ReconcileInterval"`
 }
 
 type PgoConfig struct {
@@ -266,6 +311,8 @@ const DEFAULT_PGBADGER_PORT = "10000"
 const DEFAULT_EXPORTER_PORT = "9187"
 const DEFAULT_POSTGRES_PORT = "5432"
 const DEFAULT_PATRONI_PORT = "8009"
+const DEFAULT_BACKREST_RPO = "24h"
+const DEFAULT_RECONCILE_INTERVAL = "5m"
 
 func (c *PgoConfig) Validate() error {
 	var err error
@@ -300,6 +347,24 @@ func (c *PgoConfig) Validate() error {
 		}
 	}
 
+	if c.Cluster.BackrestStorageVerifyTLS != "" {
+		if _, err := strconv.ParseBool(c.Cluster.BackrestStorageVerifyTLS); err != nil {
+			return errors.New(errPrefix + "Invalid BackrestStorageVerifyTLS: " + err.Error())
+		}
+	}
+
+	if c.Cluster.BackrestRPO == "" {
+		c.Cluster.BackrestRPO = DEFAULT_BACKREST_RPO
+		log.Infof("setting BackrestRPO to default %s", c.Cluster.BackrestRPO)
+	} else if _, err := time.ParseDuration(c.Cluster.BackrestRPO); err != nil {
+		return errors.New(errPrefix + "Invalid BackrestRPO: " + err.Error())
+	}
+
+	if c.Cluster.BackrestAzureURIStyle != "" &&
+		c.Cluster.BackrestAzureURIStyle != "host" && c.Cluster.BackrestAzureURIStyle != "path" {
+		return errors.New(errPrefix + "Invalid BackrestAzureURIStyle: must be either \"host\" or \"path\"")
+	}
+
 	if c.Cluster.PrimaryNodeLabel != "" {
 		parts := strings.Split(c.Cluster.PrimaryNodeLabel, "=")
 		if len(parts) != 2 {
@@ -345,6 +410,14 @@ func (c *PgoConfig) Validate() error {
 	if c.Pgo.PGOImageTag == "" {
 		return errors.New(errPrefix + "Pgo.PGOImageTag is required")
 	}
+	if c.Pgo.ReconcileInterval == "" {
+		c.Pgo.ReconcileInterval = DEFAULT_RECONCILE_INTERVAL
+		log.Infof("setting ReconcileInterval to default %s", c.Pgo.ReconcileInterval)
+	} else if interval, err := time.ParseDuration(c.Pgo.ReconcileInterval); err != nil {
+		return errors.New(errPrefix + "Invalid Pgo.ReconcileInterval: " + err.Error())
+	} else if interval <= 0 {
+		return errors.New(errPrefix + "Invalid Pgo.ReconcileInterval: must be greater than 0")
+	}
 
 	if c.DefaultContainerResources != "" {
 		_, ok = c.ContainerResources[c.DefaultContainerResources]
@@ -434,6 +507,18 @@ func (c *PgoConfig) Validate() error {
 		return errors.New(errPrefix + "Invalid value provided for Cluster.PodAntiAffinityPgBouncer")
 	}
 
+	if _, err := ParseTolerations(c.Cluster.Tolerations); err != nil {
+		return errors.New(errPrefix + "Invalid value provided for Cluster.Tolerations: " + err.Error())
+	}
+
+	if _, err := ParseHBARules(c.Cluster.HBA); err != nil {
+		return errors.New(errPrefix + "Invalid value provided for Cluster.HBA: " + err.Error())
+	}
+
+	if err := crv1.ZoneSpreadType(c.Cluster.ZoneSpread).Validate(); err != nil {
+		return errors.New(errPrefix + "Invalid value provided for Cluster.ZoneSpread")
+	}
+
 	return err
 }
 
@@ -701,6 +786,21 @@ func (c *PgoConfig) GetConfig(clientset *kubernetes.Clientset, namespace string)
 		return err
 	}
 
+	PgbackrestGCSEnvVarsTemplate, err = c.LoadTemplate(cMap, rootPath, pgbackrestGCSEnvVarsPath)
+	if err != nil {
+		return err
+	}
+
+	PgbackrestAzureEnvVarsTemplate, err = c.LoadTemplate(cMap, rootPath, pgbackrestAzureEnvVarsPath)
+	if err != nil {
+		return err
+	}
+
+	PgbackrestCipherEnvVarsTemplate, err = c.LoadTemplate(cMap, rootPath, pgbackrestCipherEnvVarsPath)
+	if err != nil {
+		return err
+	}
+
 	PgbouncerTemplate, err = c.LoadTemplate(cMap, rootPath, pgbouncerTemplatePath)
 	if err != nil {
 		return err
@@ -741,6 +841,16 @@ func (c *PgoConfig) GetConfig(clientset *kubernetes.Clientset, namespace string)
 		return err
 	}
 
+	SnapshotRestorejobTemplate, err = c.LoadTemplate(cMap, rootPath, snapshotRestorejobPath)
+	if err != nil {
+		return err
+	}
+
+	PgUpgradeJobTemplate, err = c.LoadTemplate(cMap, rootPath, pgUpgradeJobPath)
+	if err != nil {
+		return err
+	}
+
 	PgDumpBackupJobTemplate, err = c.LoadTemplate(cMap, rootPath, pgDumpBackupJobPath)
 	if err != nil {
 		return err
@@ -0,0 +1,79 @@
+package config
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ParseToleration parses a toleration in the same format as the taints of
+// "kubectl taint", i.e. "key=value:Effect". The value and the effect are
+// optional: without a value the toleration matches any value of the key, and
+// without an effect it matches all effects
+func ParseToleration(toleration string) (v1.Toleration, error) {
+	result := v1.Toleration{}
+
+	keyValue := toleration
+	if i := strings.LastIndex(toleration, ":"); i >= 0 {
+		keyValue = toleration[:i]
+		result.Effect = v1.TaintEffect(toleration[i+1:])
+
+		switch result.Effect {
+		case v1.TaintEffectNoSchedule, v1.TaintEffectPreferNoSchedule, v1.TaintEffectNoExecute:
+		default:
+			return result, fmt.Errorf("invalid effect in toleration %q, valid values are %q, %q or %q",
+				toleration, v1.TaintEffectNoSchedule, v1.TaintEffectPreferNoSchedule, v1.TaintEffectNoExecute)
+		}
+	}
+
+	result.Operator = v1.TolerationOpExists
+	if i := strings.Index(keyValue, "="); i >= 0 {
+		result.Key = keyValue[:i]
+		result.Value = keyValue[i+1:]
+		result.Operator = v1.TolerationOpEqual
+
+		if errs := validation.IsValidLabelValue(result.Value); len(errs) > 0 {
+			return result, fmt.Errorf("invalid value in toleration %q: %s", toleration, strings.Join(errs, "; "))
+		}
+	} else {
+		result.Key = keyValue
+	}
+
+	if errs := validation.IsQualifiedName(result.Key); len(errs) > 0 {
+		return result, fmt.Errorf("invalid key in toleration %q: %s", toleration, strings.Join(errs, "; "))
+	}
+
+	return result, nil
+}
+
+// ParseTolerations parses a list of tolerations, see ParseToleration
+func ParseTolerations(tolerations []string) ([]v1.Toleration, error) {
+	result := make([]v1.Toleration, 0, len(tolerations))
+
+	for _, toleration := range tolerations {
+		parsed, err := ParseToleration(toleration)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, parsed)
+	}
+
+	return result, nil
+}
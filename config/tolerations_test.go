@@ -0,0 +1,57 @@
+package config
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestParseToleration(t *testing.T) {
+	tests := []struct {
+		toleration string
+		expected   v1.Toleration
+		valid      bool
+	}{
+		{"dedicated=database:NoSchedule", v1.Toleration{Key: "dedicated", Operator: v1.TolerationOpEqual,
+			Value: "database", Effect: v1.TaintEffectNoSchedule}, true},
+		{"dedicated:NoExecute", v1.Toleration{Key: "dedicated", Operator: v1.TolerationOpExists,
+			Effect: v1.TaintEffectNoExecute}, true},
+		{"dedicated=database", v1.Toleration{Key: "dedicated", Operator: v1.TolerationOpEqual,
+			Value: "database"}, true},
+		{"example.com/dedicated", v1.Toleration{Key: "example.com/dedicated",
+			Operator: v1.TolerationOpExists}, true},
+		{"dedicated=database:PreferNoSchedule", v1.Toleration{Key: "dedicated", Operator: v1.TolerationOpEqual,
+			Value: "database", Effect: v1.TaintEffectPreferNoSchedule}, true},
+		{"", v1.Toleration{}, false},
+		{"dedicated=database:NoSchedul", v1.Toleration{}, false},
+		{"dedicated=data base", v1.Toleration{}, false},
+		{"=database:NoSchedule", v1.Toleration{}, false},
+	}
+
+	for i, test := range tests {
+		toleration, err := ParseToleration(test.toleration)
+
+		if test.valid && err != nil {
+			t.Errorf("tests[%d]: expected valid, got %v", i, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("tests[%d]: expected invalid, got valid", i)
+		} else if test.valid && toleration != test.expected {
+			t.Errorf("tests[%d]: expected %+v, got %+v", i, test.expected, toleration)
+		}
+	}
+}
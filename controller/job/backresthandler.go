@@ -34,6 +34,14 @@ import (
 // backrestUpdateHandler is responsible for handling updates to backrest jobs
 func (c *Controller) handleBackrestUpdate(job *apiv1.Job) error {
 
+	// a failed job that is part of a major upgrade stops the upgrade, and a failed backup is
+	// reported in the status of the pgcluster
+	if isJobFailed(job) {
+		c.handleMajorUpgradeBackrestFailure(job)
+		c.handleBackrestBackupFailure(job)
+		return nil
+	}
+
 	// return if job wasn't successful
 	if !isJobSuccessful(job) {
 		log.Debugf("jobController onUpdate job %s was unsuccessful and will be ignored",
@@ -65,6 +73,8 @@ func (c *Controller) handleBackrestUpdate(job *apiv1.Job) error {
 		c.handleBackrestRestoreUpdate(job)
 	case labels[config.LABEL_BACKREST_COMMAND] == crv1.PgtaskBackrestStanzaCreate:
 		c.handleBackrestStanzaCreateUpdate(job)
+	case labels[config.LABEL_BACKREST_COMMAND] == crv1.PgtaskBackrestStanzaUpgrade:
+		c.handleBackrestStanzaUpgradeUpdate(job)
 	}
 
 	return nil
@@ -125,11 +135,14 @@ func (c *Controller) handleCloneBackrestRestoreUpdate(job *apiv1.Job) error {
 			BackrestPVCSize:   job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_BACKREST_PVC_SIZE],
 			PGOUser:           job.ObjectMeta.Labels[config.LABEL_PGOUSER],
 			PVCSize:           job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_PVC_SIZE],
+			RotateCipherPass:  job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_ROTATE_CIPHER_PASS] == "true",
 			SourceClusterName: sourceClusterName,
+			SourceNamespace:   job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_SOURCE_NAMESPACE],
 			TargetClusterName: targetClusterName,
 			TaskStepLabel:     config.LABEL_PGO_CLONE_STEP_3,
 			TaskType:          crv1.PgtaskCloneStep3,
 			Timestamp:         time.Now(),
+			TTL:               job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_TTL],
 			WorkflowID:        workflowID,
 		}
 
@@ -161,6 +174,13 @@ func (c *Controller) handleBackrestBackupUpdate(job *apiv1.Job) error {
 	}
 	publishBackupComplete(labels[config.LABEL_PG_CLUSTER], job.ObjectMeta.Labels[config.LABEL_PG_CLUSTER_IDENTIFIER], job.ObjectMeta.Labels[config.LABEL_PGOUSER], "pgbackrest", job.ObjectMeta.Namespace, "")
 
+	if err := clusteroperator.RecordBackupCompleted(c.JobClientset, c.JobClient,
+		labels[config.LABEL_PG_CLUSTER], job.ObjectMeta.Namespace,
+		*job.Status.CompletionTime); err != nil {
+		log.Errorf("error updating the status of cluster %s: %s", labels[config.LABEL_PG_CLUSTER],
+			err.Error())
+	}
+
 	// If the completed backup was a cluster bootstrap backup, then mark the cluster as initialized
 	// and initiate the creation of any replicas.  Otherwise if the completed backup was taken as
 	// the result of a failover, then proceed with tremove the "primary_on_role_change" tag.
@@ -174,6 +194,17 @@ func (c *Controller) handleBackrestBackupUpdate(job *apiv1.Job) error {
 		controller.InitializeReplicaCreation(c.JobClient, labels[config.LABEL_PG_CLUSTER],
 			job.ObjectMeta.Namespace)
 
+		// if the cluster was initialized again following a major upgrade, then
+		// the upgrade is now complete
+		clusteroperator.CompleteMajorUpgrade(c.JobClient, labels[config.LABEL_PG_CLUSTER],
+			job.ObjectMeta.Namespace)
+
+	} else if labels[config.LABEL_PGHA_BACKUP_TYPE] == crv1.BackupTypeMajorUpgrade {
+		// the backup taken prior to a major upgrade is complete, so the cluster
+		// can now be shut down and upgraded
+		clusteroperator.MajorUpgradeShutdown(c.JobClientset, c.JobClient, c.JobConfig,
+			labels[config.LABEL_PG_CLUSTER], job.ObjectMeta.Namespace)
+
 	} else if labels[config.LABEL_PGHA_BACKUP_TYPE] == crv1.BackupTypeFailover {
 		err := clusteroperator.RemovePrimaryOnRoleChangeTag(c.JobClientset, c.JobConfig,
 			labels[config.LABEL_PG_CLUSTER], job.ObjectMeta.Namespace)
@@ -231,3 +262,59 @@ func (c *Controller) handleBackrestStanzaCreateUpdate(job *apiv1.Job) error {
 	}
 	return nil
 }
+
+// handleBackrestStanzaUpgradeUpdate is responsible for handling updates to backrest stanza
+// upgrade jobs, which are run as part of a major upgrade
+func (c *Controller) handleBackrestStanzaUpgradeUpdate(job *apiv1.Job) error {
+
+	labels := job.GetObjectMeta().GetLabels()
+	log.Debugf("jobController onUpdate backrest stanza-upgrade job case")
+
+	if job.Status.Succeeded == 1 {
+		var backrestRepoPodName string
+		for _, cont := range job.Spec.Template.Spec.Containers {
+			for _, envVar := range cont.Env {
+				if envVar.Name == "PODNAME" {
+					backrestRepoPodName = envVar.Value
+				}
+			}
+		}
+
+		clusteroperator.MajorUpgradeReinitialize(c.JobClientset, c.JobClient, labels[config.LABEL_PG_CLUSTER],
+			job.ObjectMeta.Namespace, backrestRepoPodName)
+	}
+	return nil
+}
+
+// handleMajorUpgradeBackrestFailure is responsible for handling backrest jobs that failed,
+// stopping any major upgrade the job was a part of
+func (c *Controller) handleMajorUpgradeBackrestFailure(job *apiv1.Job) {
+
+	labels := job.GetObjectMeta().GetLabels()
+
+	if labels[config.LABEL_PGHA_BACKUP_TYPE] != crv1.BackupTypeMajorUpgrade &&
+		labels[config.LABEL_BACKREST_COMMAND] != crv1.PgtaskBackrestStanzaUpgrade {
+		return
+	}
+
+	clusteroperator.FailMajorUpgrade(c.JobClientset, c.JobClient, labels[config.LABEL_PG_CLUSTER],
+		job.ObjectMeta.Namespace, fmt.Sprintf("job %s failed", job.Name))
+}
+
+// handleBackrestBackupFailure is responsible for handling backrest backup jobs that failed,
+// marking the backups of the cluster as unhealthy until the next backup succeeds
+func (c *Controller) handleBackrestBackupFailure(job *apiv1.Job) {
+
+	labels := job.GetObjectMeta().GetLabels()
+
+	if labels[config.LABEL_BACKREST_COMMAND] != "backup" {
+		return
+	}
+
+	if err := clusteroperator.RecordBackupFailed(c.JobClientset, c.JobClient,
+		labels[config.LABEL_PG_CLUSTER], job.ObjectMeta.Namespace, getJobFailedTime(job),
+		fmt.Sprintf("backup job %s failed", job.Name)); err != nil {
+		log.Errorf("error updating the status of cluster %s: %s", labels[config.LABEL_PG_CLUSTER],
+			err.Error())
+	}
+}
@@ -107,6 +107,8 @@ func (c *Controller) onUpdate(oldObj, newObj interface{}) {
 	switch {
 	case labels[config.LABEL_RMDATA] == "true":
 		err = c.handleRMDataUpdate(job)
+	case labels[config.LABEL_SNAPSHOT_RESTORE] == "true":
+		err = c.handleSnapshotRestoreUpdate(job)
 	case labels[config.LABEL_BACKREST] == "true" ||
 		labels[config.LABEL_BACKREST_RESTORE] == "true":
 		err = c.handleBackrestUpdate(job)
@@ -118,6 +120,8 @@ func (c *Controller) onUpdate(oldObj, newObj interface{}) {
 		err = c.handleLoadUpdate(job)
 	case labels[config.LABEL_PGO_CLONE_STEP_1] == "true":
 		err = c.handleRepoSyncUpdate(job)
+	case labels[config.LABEL_PG_UPGRADE_JOB] == "true":
+		err = c.handlePGUpgradeUpdate(job)
 	}
 
 	if err != nil {
@@ -28,6 +28,24 @@ func isJobSuccessful(job *apiv1.Job) bool {
 	return job.Status.CompletionTime != nil
 }
 
+// isJobFailed returns true if the job provided has failed, i.e. if any of its pods failed.
+// The jobs created by the Operator are not retried, so a single failed pod means that the
+// job has failed.
+func isJobFailed(job *apiv1.Job) bool {
+	return job.Status.Failed > 0
+}
+
+// getJobFailedTime returns when the job provided failed, as recorded in its "Failed" condition.
+// The current time is returned if the job does not have that condition yet.
+func getJobFailedTime(job *apiv1.Job) meta_v1.Time {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == apiv1.JobFailed {
+			return condition.LastTransitionTime
+		}
+	}
+	return meta_v1.Now()
+}
+
 // isJobInForegroundDeletion determines if a job is currently being deleted using foreground
 // cascading deletion, as indicated by the presence of value “foregroundDeletion” in the jobs
 // metadata.finalizers.
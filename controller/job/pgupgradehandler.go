@@ -0,0 +1,55 @@
+package job
+
+/*
+Copyright 2020 Crunchy Data Solutions, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"fmt"
+
+	"github.com/crunchydata/postgres-operator/config"
+	clusteroperator "github.com/crunchydata/postgres-operator/operator/cluster"
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/batch/v1"
+)
+
+// handlePGUpgradeUpdate is responsible for handling updates to the jobs that
+// run pg_upgrade as part of a major upgrade
+func (c *Controller) handlePGUpgradeUpdate(job *apiv1.Job) error {
+
+	// return if job is being deleted
+	if isJobInForegroundDeletion(job) {
+		log.Debugf("jobController onUpdate job %s is being deleted and will be ignored",
+			job.Name)
+		return nil
+	}
+
+	labels := job.GetObjectMeta().GetLabels()
+	clusterName := labels[config.LABEL_PG_CLUSTER]
+
+	switch {
+	case isJobFailed(job):
+		log.Debugf("jobController onUpdate pg_upgrade job for cluster %s failed", clusterName)
+
+		clusteroperator.FailMajorUpgrade(c.JobClientset, c.JobClient, clusterName, job.ObjectMeta.Namespace,
+			fmt.Sprintf("pg_upgrade failed, see the logs of job %s", job.Name))
+	case isJobSuccessful(job):
+		log.Debugf("jobController onUpdate pg_upgrade job for cluster %s complete", clusterName)
+
+		clusteroperator.MajorUpgradeStartPrimary(c.JobClientset, c.JobClient, clusterName,
+			job.ObjectMeta.Namespace)
+	}
+
+	return nil
+}
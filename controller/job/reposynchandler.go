@@ -68,15 +68,22 @@ func (c *Controller) handleRepoSyncUpdate(job *apiv1.Job) error {
 
 	// now, set up a new pgtask that will allow us to perform the restore
 	cloneTask := util.CloneTask{
-		BackrestPVCSize:   job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_BACKREST_PVC_SIZE],
-		PGOUser:           job.ObjectMeta.Labels[config.LABEL_PGOUSER],
-		PVCSize:           job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_PVC_SIZE],
-		SourceClusterName: sourceClusterName,
-		TargetClusterName: targetClusterName,
-		TaskStepLabel:     config.LABEL_PGO_CLONE_STEP_2,
-		TaskType:          crv1.PgtaskCloneStep2,
-		Timestamp:         time.Now(),
-		WorkflowID:        workflowID,
+		BackrestPVCSize:    job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_BACKREST_PVC_SIZE],
+		BackupSet:          job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_BACKUP_SET],
+		PGOUser:            job.ObjectMeta.Labels[config.LABEL_PGOUSER],
+		PVCSize:            job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_PVC_SIZE],
+		RecoveryTarget:     job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_RECOVERY_TARGET],
+		RecoveryTargetType: job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_RECOVERY_TARGET_TYPE],
+		RotateCipherPass:   job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_ROTATE_CIPHER_PASS] == "true",
+		Snapshot:           job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_SNAPSHOT],
+		SourceClusterName:  sourceClusterName,
+		SourceNamespace:    job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_SOURCE_NAMESPACE],
+		TargetClusterName:  targetClusterName,
+		TaskStepLabel:      config.LABEL_PGO_CLONE_STEP_2,
+		TaskType:           crv1.PgtaskCloneStep2,
+		Timestamp:          time.Now(),
+		TTL:                job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_TTL],
+		WorkflowID:         workflowID,
 	}
 
 	task := cloneTask.Create()
@@ -0,0 +1,70 @@
+package job
+
+/*
+Copyright 2020 Crunchy Data Solutions, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	clusteroperator "github.com/crunchydata/postgres-operator/operator/cluster"
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/batch/v1"
+)
+
+// handleSnapshotRestoreUpdate is responsible for handling updates to the jobs
+// that prepare the PVCs that were provisioned from a snapshot backup, either
+// for a clone or for a replica
+func (c *Controller) handleSnapshotRestoreUpdate(job *apiv1.Job) error {
+
+	// return if job wasn't successful
+	if !isJobSuccessful(job) {
+		log.Debugf("jobController onUpdate job %s was unsuccessful and will be ignored",
+			job.Name)
+		return nil
+	}
+
+	// return if job is being deleted
+	if isJobInForegroundDeletion(job) {
+		log.Debugf("jobController onUpdate job %s is being deleted and will be ignored",
+			job.Name)
+		return nil
+	}
+
+	labels := job.GetObjectMeta().GetLabels()
+
+	// a snapshot restore that is part of a clone continues the clone the same
+	// way as a pgBackRest restore does
+	if labels[config.LABEL_PGO_CLONE_STEP_2] == "true" {
+		return c.handleCloneBackrestRestoreUpdate(job)
+	}
+
+	replicaName := labels[config.LABEL_REPLICA_NAME]
+	if replicaName == "" {
+		return nil
+	}
+
+	log.Debugf("jobController onUpdate snapshot restore job for replica %s complete", replicaName)
+
+	// the PVCs of the replica are ready, so it can now be created
+	replica := crv1.Pgreplica{}
+	if _, err := kubeapi.Getpgreplica(c.JobClient, &replica, replicaName, job.ObjectMeta.Namespace); err != nil {
+		return err
+	}
+
+	clusteroperator.ScaleBase(c.JobClientset, c.JobClient, &replica, job.ObjectMeta.Namespace)
+
+	return nil
+}
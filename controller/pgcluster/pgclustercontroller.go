@@ -23,6 +23,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
 	"github.com/crunchydata/postgres-operator/config"
@@ -34,20 +35,42 @@ import (
 	clusteroperator "github.com/crunchydata/postgres-operator/operator/cluster"
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 )
 
+// expirationInterval is how often the controller checks for clusters whose TTL
+// has passed
+const expirationInterval = time.Minute
+
+// finalizerInterval is how often the controller checks on the removal of the
+// pgclusters that are marked for deletion, and retries the removals that failed
+const finalizerInterval = time.Minute
+
+// tlsInterval is how often the controller renews the certificates that are
+// about to expire of the clusters whose certificates the Operator manages
+const tlsInterval = 10 * time.Minute
+
+// subscriptionInterval is how often the controller records the state of the
+// logical replication subscriptions of the clusters. It is recorded apart from
+// the reconcile passes as the lag changes constantly, and every update of a
+// pgcluster causes another pass
+const subscriptionInterval = 30 * time.Second
+
 // Controller holds the connections for the controller
 type Controller struct {
+	PgclusterConfig    *rest.Config
 	PgclusterClient    *rest.RESTClient
 	PgclusterScheme    *runtime.Scheme
 	PgclusterClientset *kubernetes.Clientset
 	Queue              workqueue.RateLimitingInterface
+	ReconcileQueue     workqueue.RateLimitingInterface
 	Ctx                context.Context
 	informerNsMutex    sync.Mutex
 	InformerNamespaces map[string]struct{}
@@ -59,6 +82,7 @@ func (c *Controller) Run() error {
 
 	//shut down the work queue to cause workers to end
 	defer c.Queue.ShutDown()
+	defer c.ReconcileQueue.ShutDown()
 
 	err := c.watchPgclusters(c.Ctx)
 	if err != nil {
@@ -66,6 +90,28 @@ func (c *Controller) Run() error {
 		return err
 	}
 
+	// periodically reconcile every pgcluster, which catches drift in the
+	// objects of a cluster that does not cause the pgcluster to change
+	interval, err := time.ParseDuration(operator.Pgo.Pgo.ReconcileInterval)
+	if err != nil {
+		log.Errorf("Invalid reconcile interval: %v", err)
+		return err
+	}
+
+	go wait.Until(c.enqueueAllForReconcile, interval, c.Ctx.Done())
+
+	// delete the clusters whose TTL has passed, warning ahead of time
+	go wait.Until(c.expireClusters, expirationInterval, c.Ctx.Done())
+
+	// retry removing the resources of the clusters whose removal failed
+	go wait.Until(c.removeDeletedClusters, finalizerInterval, c.Ctx.Done())
+
+	// renew the certificates managed by the Operator ahead of their expiration
+	go wait.Until(c.rotateCertificates, tlsInterval, c.Ctx.Done())
+
+	// track the state and the lag of the subscriptions of the clusters
+	go wait.Until(c.recordSubscriptions, subscriptionInterval, c.Ctx.Done())
+
 	<-c.Ctx.Done()
 
 	return c.Ctx.Err()
@@ -87,6 +133,24 @@ func (c *Controller) onAdd(obj interface{}) {
 	cluster := obj.(*crv1.Pgcluster)
 	log.Debugf("[pgcluster Controller] ns %s onAdd %s", cluster.ObjectMeta.Namespace, cluster.ObjectMeta.SelfLink)
 
+	// a pgcluster that was marked for deletion while the operator was not
+	// running still needs to have its resources removed
+	if cluster.DeletionTimestamp != nil {
+		if err := clusteroperator.DeleteClusterFromFinalizer(c.PgclusterClientset, c.PgclusterClient, cluster); err != nil {
+			log.Error(err)
+		}
+		return
+	}
+
+	// ensure the resources of the cluster are removed when the pgcluster is
+	// deleted, including pgclusters that were created before the finalizer was
+	// introduced
+	if err := clusteroperator.AddFinalizer(c.PgclusterClient, cluster); err != nil {
+		log.Error(err)
+	}
+
+	c.enqueueForReconcile(cluster)
+
 	//handle the case when the operator restarts and don't
 	//process already processed pgclusters
 	if cluster.Status.State == crv1.PgclusterStateProcessed {
@@ -163,12 +227,215 @@ func (c *Controller) processNextItem() bool {
 	return true
 }
 
+// RunReconcileWorker reconciles the pgclusters in the reconcile work queue
+// until the queue is shut down
+func (c *Controller) RunReconcileWorker() {
+	for c.processNextReconcileItem() {
+	}
+}
+
+// processNextReconcileItem reconciles the next pgcluster in the reconcile work
+// queue. A pgcluster that failed to reconcile, or that has not converged yet,
+// is put back on the queue with a rate limited delay
+func (c *Controller) processNextReconcileItem() bool {
+	key, quit := c.ReconcileQueue.Get()
+	if quit {
+		return false
+	}
+
+	defer c.ReconcileQueue.Done(key)
+
+	keyNamespace, keyResourceName, err := cache.SplitMetaNamespaceKey(key.(string))
+	if err != nil {
+		log.Error(err)
+		c.ReconcileQueue.Forget(key)
+		return true
+	}
+
+	cluster := crv1.Pgcluster{}
+	found, err := kubeapi.Getpgcluster(c.PgclusterClient, &cluster, keyResourceName, keyNamespace)
+	if !found {
+		if kerrors.IsNotFound(err) {
+			c.ReconcileQueue.Forget(key)
+		} else {
+			c.ReconcileQueue.AddRateLimited(key)
+		}
+		return true
+	}
+
+	requeue, err := clusteroperator.Reconcile(c.PgclusterClientset, c.PgclusterClient, c.PgclusterConfig,
+		&cluster)
+	if err != nil {
+		log.Errorf("reconcile: could not reconcile cluster %s: %v", key, err)
+	}
+
+	// refresh the status after every pass, including the periodic ones, so
+	// that conditions that depend on time such as BackupsHealthy stay current
+	if statusErr := clusteroperator.UpdateStatus(c.PgclusterClientset, c.PgclusterClient,
+		keyResourceName, keyNamespace); statusErr != nil {
+		log.Errorf("reconcile: could not update status of cluster %s: %v", key, statusErr)
+		requeue = true
+	}
+
+	if err != nil || requeue {
+		c.ReconcileQueue.AddRateLimited(key)
+	} else {
+		c.ReconcileQueue.Forget(key)
+	}
+
+	return true
+}
+
+// enqueueForReconcile adds a pgcluster to the reconcile work queue
+func (c *Controller) enqueueForReconcile(cluster *crv1.Pgcluster) {
+	key, err := cache.MetaNamespaceKeyFunc(cluster)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	c.ReconcileQueue.Add(key)
+}
+
+// enqueueAllForReconcile adds every pgcluster in the namespaces watched by the
+// controller to the reconcile work queue
+func (c *Controller) enqueueAllForReconcile() {
+	for _, namespace := range c.watchedNamespaces() {
+		clusterList := crv1.PgclusterList{}
+		if err := kubeapi.Getpgclusters(c.PgclusterClient, &clusterList, namespace); err != nil {
+			continue
+		}
+
+		for i := range clusterList.Items {
+			c.enqueueForReconcile(&clusterList.Items[i])
+		}
+	}
+}
+
+// watchedNamespaces returns a copy of the namespaces watched by the controller
+func (c *Controller) watchedNamespaces() []string {
+	c.informerNsMutex.Lock()
+	defer c.informerNsMutex.Unlock()
+
+	namespaces := make([]string, 0, len(c.InformerNamespaces))
+	for namespace := range c.InformerNamespaces {
+		namespaces = append(namespaces, namespace)
+	}
+
+	return namespaces
+}
+
+// expireClusters warns about and deletes the pgclusters in the namespaces
+// watched by the controller that have a TTL, once they are about to expire or
+// have expired
+func (c *Controller) expireClusters() {
+	for _, namespace := range c.watchedNamespaces() {
+		clusterList := crv1.PgclusterList{}
+		if err := kubeapi.Getpgclusters(c.PgclusterClient, &clusterList, namespace); err != nil {
+			continue
+		}
+
+		for i := range clusterList.Items {
+			clusteroperator.ExpireCluster(c.PgclusterClientset, c.PgclusterClient, &clusterList.Items[i])
+		}
+	}
+}
+
+// removeDeletedClusters removes the resources of the pgclusters in the
+// namespaces watched by the controller that are marked for deletion, which
+// retries the removals that failed
+func (c *Controller) removeDeletedClusters() {
+	for _, namespace := range c.watchedNamespaces() {
+		clusterList := crv1.PgclusterList{}
+		if err := kubeapi.Getpgclusters(c.PgclusterClient, &clusterList, namespace); err != nil {
+			continue
+		}
+
+		for i := range clusterList.Items {
+			if clusterList.Items[i].DeletionTimestamp == nil {
+				continue
+			}
+
+			if err := clusteroperator.DeleteClusterFromFinalizer(c.PgclusterClientset, c.PgclusterClient,
+				&clusterList.Items[i]); err != nil {
+				log.Error(err)
+			}
+		}
+	}
+}
+
+// rotateCertificates renews the certificates of the pgclusters in the
+// namespaces watched by the controller whose certificates the Operator
+// manages, and reloads their PostgreSQL instances once the new certificates
+// are in place
+func (c *Controller) rotateCertificates() {
+	for _, namespace := range c.watchedNamespaces() {
+		clusterList := crv1.PgclusterList{}
+		if err := kubeapi.Getpgclusters(c.PgclusterClient, &clusterList, namespace); err != nil {
+			continue
+		}
+
+		for i := range clusterList.Items {
+			if err := clusteroperator.RotateClusterTLS(c.PgclusterClientset, c.PgclusterConfig,
+				&clusterList.Items[i]); err != nil {
+				log.Errorf("could not rotate the certificate of cluster %s: %v", clusterList.Items[i].Name, err)
+			}
+		}
+	}
+}
+
+// recordSubscriptions records the state of the subscriptions of the pgclusters
+// in the namespaces watched by the controller that have any, or that had any
+// the last time it was recorded
+func (c *Controller) recordSubscriptions() {
+	for _, namespace := range c.watchedNamespaces() {
+		clusterList := crv1.PgclusterList{}
+		if err := kubeapi.Getpgclusters(c.PgclusterClient, &clusterList, namespace); err != nil {
+			continue
+		}
+
+		for _, cluster := range clusterList.Items {
+			if len(cluster.Spec.Subscriptions) == 0 && len(cluster.Status.Subscriptions) == 0 {
+				continue
+			}
+
+			if err := clusteroperator.RecordSubscriptions(c.PgclusterClientset, c.PgclusterClient,
+				c.PgclusterConfig, cluster.Name, cluster.Namespace); err != nil {
+				log.Errorf("could not record the subscriptions of cluster %s: %v", cluster.Name, err)
+			}
+		}
+	}
+}
+
 // onUpdate is called when a pgcluster is updated
 func (c *Controller) onUpdate(oldObj, newObj interface{}) {
 	oldcluster := oldObj.(*crv1.Pgcluster)
 	newcluster := newObj.(*crv1.Pgcluster)
 	//	log.Debugf("pgcluster ns=%s %s onUpdate", newcluster.ObjectMeta.Namespace, newcluster.ObjectMeta.Name)
 
+	// if the pgcluster has been marked for deletion, remove the resources of
+	// the cluster. Nothing else is done with a pgcluster that is being deleted
+	if newcluster.DeletionTimestamp != nil {
+		if err := clusteroperator.DeleteClusterFromFinalizer(c.PgclusterClientset, c.PgclusterClient, newcluster); err != nil {
+			log.Error(err)
+		}
+		return
+	}
+
+	// add the finalizer if adding it when the pgcluster was added failed
+	if err := clusteroperator.AddFinalizer(c.PgclusterClient, newcluster); err != nil {
+		log.Error(err)
+	}
+
+	// converge the cluster to any changes to its spec
+	c.enqueueForReconcile(newcluster)
+
+	// nothing else needs to be done when only the conditions and the observed
+	// state in the status changed, which the reconcile worker writes regularly
+	if isObservedStatusUpdate(oldcluster, newcluster) {
+		return
+	}
+
 	// if the 'shutdown' parameter in the pgcluster update shows that the cluster should be either
 	// shutdown or started but its current status does not properly reflect that it is, then
 	// proceed with the logic needed to either shutdown or start the cluster
@@ -224,13 +491,21 @@ func (c *Controller) onUpdate(oldObj, newObj interface{}) {
 	}
 }
 
+// isObservedStatusUpdate returns true if an update of a pgcluster changed
+// neither its spec, labels and annotations nor the state of the cluster
+func isObservedStatusUpdate(oldcluster, newcluster *crv1.Pgcluster) bool {
+	return oldcluster.Status.State == newcluster.Status.State &&
+		reflect.DeepEqual(oldcluster.Spec, newcluster.Spec) &&
+		reflect.DeepEqual(oldcluster.Labels, newcluster.Labels) &&
+		reflect.DeepEqual(oldcluster.Annotations, newcluster.Annotations)
+}
+
 // onDelete is called when a pgcluster is deleted
+// The resources of the cluster have already been removed at this point, as
+// the finalizer of the pgcluster is only removed once pgo-rmdata succeeds
 func (c *Controller) onDelete(obj interface{}) {
-	//cluster := obj.(*crv1.Pgcluster)
-	//	log.Debugf("[Controller] ns=%s onDelete %s", cluster.ObjectMeta.Namespace, cluster.ObjectMeta.SelfLink)
-
-	//handle pgcluster cleanup
-	//	clusteroperator.DeleteClusterBase(c.PgclusterClientset, c.PgclusterClient, cluster, cluster.ObjectMeta.Namespace)
+	cluster := obj.(*crv1.Pgcluster)
+	log.Debugf("[pgcluster Controller] ns=%s onDelete %s", cluster.ObjectMeta.Namespace, cluster.ObjectMeta.SelfLink)
 }
 
 func GetPrimaryPodStatus(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster, ns string) (error, bool) {
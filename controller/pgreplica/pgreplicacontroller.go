@@ -27,6 +27,7 @@ import (
 	"github.com/crunchydata/postgres-operator/operator"
 	clusteroperator "github.com/crunchydata/postgres-operator/operator/cluster"
 	log "github.com/sirupsen/logrus"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
@@ -125,9 +126,12 @@ func (c *Controller) processNextItem() bool {
 		}
 
 		// get the pgcluster resource for the cluster the replica is a part of
+		// the pgreplicas of a cluster that is applied from an export are created
+		// before the pgcluster, and are pending like any other replica until the
+		// cluster is initialized
 		cluster := crv1.Pgcluster{}
 		_, err = kubeapi.Getpgcluster(c.PgreplicaClient, &cluster, replica.Spec.ClusterName, keyNamespace)
-		if err != nil {
+		if err != nil && !kerrors.IsNotFound(err) {
 			log.Error(err)
 			return false
 		}
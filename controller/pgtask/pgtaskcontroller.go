@@ -28,6 +28,7 @@ import (
 	backrestoperator "github.com/crunchydata/postgres-operator/operator/backrest"
 	clusteroperator "github.com/crunchydata/postgres-operator/operator/cluster"
 	pgdumpoperator "github.com/crunchydata/postgres-operator/operator/pgdump"
+	snapshotoperator "github.com/crunchydata/postgres-operator/operator/snapshot"
 	taskoperator "github.com/crunchydata/postgres-operator/operator/task"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/fields"
@@ -127,6 +128,9 @@ func (c *Controller) processNextItem() bool {
 	case crv1.PgtaskMinorUpgrade:
 		log.Debug("delete minor upgrade task added")
 		clusteroperator.AddUpgrade(c.PgtaskClientset, c.PgtaskClient, &tmpTask, keyNamespace)
+	case crv1.PgtaskMajorUpgrade:
+		log.Debug("major upgrade task added")
+		clusteroperator.AddMajorUpgrade(c.PgtaskClientset, c.PgtaskClient, c.PgtaskConfig, &tmpTask, keyNamespace)
 	case crv1.PgtaskDeletePgbouncer:
 		log.Debug("delete pgbouncer task added")
 		clusteroperator.DeletePgbouncerFromPgTask(c.PgtaskClientset, c.PgtaskClient, c.PgtaskConfig, &tmpTask)
@@ -160,6 +164,9 @@ func (c *Controller) processNextItem() bool {
 	case crv1.PgtaskBackrestRestore:
 		log.Debug("backrest restore task added")
 		backrestoperator.Restore(c.PgtaskClient, keyNamespace, c.PgtaskClientset, &tmpTask)
+	case crv1.PgtaskSnapshotBackup:
+		log.Debug("snapshot backup task added")
+		snapshotoperator.Backup(c.PgtaskClientset, c.PgtaskClient, c.PgtaskConfig, keyNamespace, &tmpTask)
 
 	case crv1.PgtaskpgDump:
 		log.Debug("pgDump task added")
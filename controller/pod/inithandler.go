@@ -59,6 +59,10 @@ func (c *Controller) handleClusterInit(newPod *apiv1.Pod, cluster *crv1.Pgcluste
 		log.Debugf("Pod Controller: restore detected during cluster %s init, calling restore "+
 			"handler", clusterName)
 		return c.handleRestoreInit(cluster)
+	case cluster.Status.State == crv1.PgclusterStateMajorUpgrade:
+		log.Debugf("Pod Controller: major upgrade detected during cluster %s init, calling "+
+			"stanza upgrade", clusterName)
+		return clusteroperator.MajorUpgradeStanzaUpgrade(c.PodClientset, c.PodClient, cluster)
 	case cluster.Spec.Standby:
 		log.Debugf("Pod Controller: standby cluster detected during cluster %s init, calling "+
 			"standby handler", clusterName)
@@ -88,6 +92,14 @@ func (c *Controller) handleCommonInit(cluster *crv1.Pgcluster) error {
 	operator.UpdatePGHAConfigInitFlag(c.PodClientset, false, cluster.Name,
 		cluster.Namespace)
 
+	// push WAL to both repositories of a cluster that uses local storage along
+	// with GCS or Azure Blob storage
+	if err := clusteroperator.ReconcileArchiveCommand(c.PodClientset, c.PodConfig,
+		cluster); err != nil {
+		log.Error(err)
+		return err
+	}
+
 	return nil
 }
 
@@ -151,7 +163,8 @@ func (c *Controller) handleBootstrapInit(newPod *apiv1.Pod, cluster *crv1.Pgclus
 		// attempt to find the pgbouncer pgtask. If one does not exist
 		// create it!
 		if found, _ := kubeapi.Getpgtask(c.PodClient, &tmptask, taskName, newPod.ObjectMeta.Namespace); !found {
-			clusteroperator.CreatePgTaskforAddpgBouncer(c.PodClient, cluster, cluster.Labels[config.LABEL_PGOUSER])
+			clusteroperator.CreatePgTaskforAddpgBouncer(c.PodClient, cluster,
+				cluster.Labels[config.LABEL_PGOUSER], map[string]string{})
 		}
 	}
 
@@ -202,7 +215,7 @@ func (c *Controller) handleStandbyInit(cluster *crv1.Pgcluster) error {
 		// create it!
 		if found, _ := kubeapi.Getpgtask(c.PodClient, &tmptask, taskName, namespace); !found {
 			clusteroperator.CreatePgTaskforAddpgBouncer(c.PodClient, cluster,
-				cluster.Labels[config.LABEL_PGOUSER])
+				cluster.Labels[config.LABEL_PGOUSER], map[string]string{})
 		}
 	}
 
@@ -25,6 +25,7 @@ import (
 	"github.com/crunchydata/postgres-operator/kubeapi"
 	"github.com/crunchydata/postgres-operator/ns"
 	"github.com/crunchydata/postgres-operator/operator"
+	clusteroperator "github.com/crunchydata/postgres-operator/operator/cluster"
 
 	log "github.com/sirupsen/logrus"
 	apiv1 "k8s.io/api/core/v1"
@@ -122,6 +123,15 @@ func (c *Controller) onUpdate(oldObj, newObj interface{}) {
 		return
 	}
 
+	// keep the status of the pgcluster current as its instances and pgBouncer
+	// become ready or unready, or as the instances change roles
+	if isObservedPodChange(oldPod, newPod) {
+		if err := clusteroperator.UpdateStatus(c.PodClientset, c.PodClient, clusterName,
+			newPod.Namespace); err != nil {
+			log.Error(err)
+		}
+	}
+
 	// Handle the "role" label change from "replica" to "master" following a failover.  This
 	// logic is only triggered when the cluster has already been initialized, which implies
 	// a failover or switchove has ocurred.
@@ -254,6 +264,31 @@ func isDBContainerBecomingReady(oldPod, newPod *apiv1.Pod) bool {
 	return false
 }
 
+// isObservedPodChange determines whether or not an update of a database or pgBouncer Pod changed
+// anything that is reported in the status of its pgcluster, i.e. the readiness of any of its
+// containers or the role of the instance
+func isObservedPodChange(oldPod, newPod *apiv1.Pod) bool {
+	if !isPostgresPod(newPod) && newPod.Labels[config.LABEL_PGBOUNCER] != "true" {
+		return false
+	}
+
+	if oldPod.Labels[config.LABEL_PGHA_ROLE] != newPod.Labels[config.LABEL_PGHA_ROLE] {
+		return true
+	}
+
+	ready := make(map[string]bool)
+	for _, status := range oldPod.Status.ContainerStatuses {
+		ready[status.Name] = status.Ready
+	}
+	for _, status := range newPod.Status.ContainerStatuses {
+		if ready[status.Name] != status.Ready {
+			return true
+		}
+	}
+
+	return false
+}
+
 // isPostgresPrimaryPod determines whether or not the specific Pod provided is the primary database
 // Pod within a PG cluster.  This is done by checking to see if the "role" label for the Pod is set
 // to either "master", as set by Patroni to identify the current primary, or "promoted", as set by
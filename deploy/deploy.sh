@@ -25,6 +25,18 @@ awsKeySecret() {
     fi
 }
 
+# azureKeySecret pulls the Azure Blob storage credentials out of the Azure
+# credentials file in the same fashion as awsKeySecret
+azureKeySecret() {
+    val=$(grep "$1" -m 1 "${PGOROOT}/conf/pgo-backrest-repo/azure-credentials.yaml" | sed "s/^.*:\s*//")
+    # remove leading and trailing whitespace
+    val=$(echo -e "${val}" | sed -e 's/^[[:space:]]*//' -e 's/[[:space:]]*$//')
+    if [[ "$val" != "" ]]
+		then
+        echo "${val}"
+    fi
+}
+
 DIR="$( cd "$( dirname "${BASH_SOURCE[0]}" )" && pwd )"
 
 $DIR/cleanup.sh
@@ -40,13 +52,18 @@ fi
 # credentials for pgbackrest sshd
 pgbackrest_aws_s3_key=$(awsKeySecret "aws-s3-key")
 pgbackrest_aws_s3_key_secret=$(awsKeySecret "aws-s3-key-secret")
+pgbackrest_azure_account=$(azureKeySecret "azure-account")
+pgbackrest_azure_key=$(azureKeySecret "azure-key")
 
 $PGO_CMD --namespace=$PGO_OPERATOR_NAMESPACE create secret generic pgo-backrest-repo-config \
 	--from-file=config=$PGOROOT/conf/pgo-backrest-repo/config \
 	--from-file=sshd_config=$PGOROOT/conf/pgo-backrest-repo/sshd_config \
 	--from-file=aws-s3-ca.crt=$PGOROOT/conf/pgo-backrest-repo/aws-s3-ca.crt \
 	--from-literal=aws-s3-key="${pgbackrest_aws_s3_key}" \
-	--from-literal=aws-s3-key-secret="${pgbackrest_aws_s3_key_secret}"
+	--from-literal=aws-s3-key-secret="${pgbackrest_aws_s3_key_secret}" \
+	--from-file=gcs-key=$PGOROOT/conf/pgo-backrest-repo/gcs-key \
+	--from-literal=azure-account="${pgbackrest_azure_account}" \
+	--from-literal=azure-key="${pgbackrest_azure_key}"
 
 #
 # credentials for pgo-apiserver TLS REST API
@@ -51,8 +51,10 @@ const (
 	EventRestoreClusterCompleted  = "RestoreClusterCompleted"
 	EventUpgradeCluster           = "UpgradeCluster"
 	EventUpgradeClusterCompleted  = "UpgradeClusterCompleted"
+	EventUpgradeClusterFailure    = "UpgradeClusterFailure"
 	EventDeleteCluster            = "DeleteCluster"
 	EventDeleteClusterCompleted   = "DeleteClusterCompleted"
+	EventClusterExpiring          = "ClusterExpiring"
 	EventCreateLabel              = "CreateLabel"
 	EventLoad                     = "Load"
 	EventLoadCompleted            = "LoadCompleted"
@@ -331,6 +333,24 @@ func (lvl EventUpgradeClusterCompletedFormat) String() string {
 	return msg
 }
 
+//----------------------------
+type EventUpgradeClusterFailureFormat struct {
+	EventHeader  `json:"eventheader"`
+	Clustername  string `json:"clustername"`
+	ErrorMessage string `json:"errormessage"`
+	WorkflowID   string `json:"workflowid"`
+}
+
+func (p EventUpgradeClusterFailureFormat) GetHeader() EventHeader {
+	return p.EventHeader
+}
+
+func (lvl EventUpgradeClusterFailureFormat) String() string {
+	msg := fmt.Sprintf("Event %s (upgrade failure) - clustername %s workflow %s error %s",
+		lvl.EventHeader, lvl.Clustername, lvl.WorkflowID, lvl.ErrorMessage)
+	return msg
+}
+
 //----------------------------
 type EventDeleteClusterFormat struct {
 	EventHeader `json:"eventheader"`
@@ -361,6 +381,22 @@ func (lvl EventDeleteClusterCompletedFormat) String() string {
 	return msg
 }
 
+//----------------------------
+type EventClusterExpiringFormat struct {
+	EventHeader    `json:"eventheader"`
+	Clustername    string    `json:"clustername"`
+	ExpirationTime time.Time `json:"expirationtime"`
+}
+
+func (p EventClusterExpiringFormat) GetHeader() EventHeader {
+	return p.EventHeader
+}
+
+func (lvl EventClusterExpiringFormat) String() string {
+	msg := fmt.Sprintf("Event %s (cluster expiring) - clustername %s - expirationtime %s", lvl.EventHeader, lvl.Clustername, lvl.ExpirationTime.Format(time.RFC3339))
+	return msg
+}
+
 //----------------------------
 type EventCreateBackupFormat struct {
 	EventHeader `json:"eventheader"`
@@ -0,0 +1,34 @@
+package kubeapi
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CreateEvent creates a Kubernetes Event, e.g. to record something that
+// happened to a custom resource of the Operator
+func CreateEvent(clientset *kubernetes.Clientset, event *v1.Event, namespace string) error {
+	_, err := clientset.CoreV1().Events(namespace).Create(event)
+	if err != nil {
+		log.Error(err)
+		log.Error("error creating event " + event.Name)
+	}
+
+	return err
+}
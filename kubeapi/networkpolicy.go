@@ -0,0 +1,87 @@
+package kubeapi
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	log "github.com/sirupsen/logrus"
+	networking_v1 "k8s.io/api/networking/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// GetNetworkPolicy gets a NetworkPolicy by name
+func GetNetworkPolicy(clientset *kubernetes.Clientset, name, namespace string) (*networking_v1.NetworkPolicy, bool, error) {
+	policy, err := clientset.NetworkingV1().NetworkPolicies(namespace).Get(name, meta_v1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return policy, false, err
+	}
+	if err != nil {
+		log.Error(err)
+		return policy, false, err
+	}
+
+	return policy, true, err
+}
+
+// GetNetworkPolicies gets a list of NetworkPolicies by selector
+func GetNetworkPolicies(clientset *kubernetes.Clientset, selector, namespace string) (*networking_v1.NetworkPolicyList, error) {
+	lo := meta_v1.ListOptions{LabelSelector: selector}
+
+	policies, err := clientset.NetworkingV1().NetworkPolicies(namespace).List(lo)
+	if err != nil {
+		log.Error(err)
+		log.Error("error getting network policies selector=[" + selector + "]")
+	}
+
+	return policies, err
+}
+
+// CreateNetworkPolicy creates a NetworkPolicy
+func CreateNetworkPolicy(clientset *kubernetes.Clientset, policy *networking_v1.NetworkPolicy, namespace string) error {
+	result, err := clientset.NetworkingV1().NetworkPolicies(namespace).Create(policy)
+	if err != nil {
+		log.Error(err)
+		log.Error("error creating network policy " + policy.Name)
+		return err
+	}
+
+	log.Info("created network policy " + result.Name)
+	return err
+}
+
+// UpdateNetworkPolicy updates a NetworkPolicy
+func UpdateNetworkPolicy(clientset *kubernetes.Clientset, policy *networking_v1.NetworkPolicy, namespace string) error {
+	_, err := clientset.NetworkingV1().NetworkPolicies(namespace).Update(policy)
+	if err != nil {
+		log.Error(err)
+		log.Error("error updating network policy " + policy.Name)
+	}
+	return err
+}
+
+// DeleteNetworkPolicy deletes a NetworkPolicy
+func DeleteNetworkPolicy(clientset *kubernetes.Clientset, name, namespace string) error {
+	err := clientset.NetworkingV1().NetworkPolicies(namespace).Delete(name, &meta_v1.DeleteOptions{})
+	if err != nil {
+		log.Error(err)
+		log.Error("error deleting network policy " + name)
+		return err
+	}
+
+	log.Info("deleted network policy " + name)
+	return err
+}
@@ -156,11 +156,9 @@ func PatchpgclusterStatus(restclient *rest.RESTClient, state crv1.PgclusterState
 		return err
 	}
 
-	//change it
-	oldCrd.Status = crv1.PgclusterStatus{
-		State:   state,
-		Message: message,
-	}
+	//change it, keeping the rest of the status, e.g. the snapshot catalog
+	oldCrd.Status.State = state
+	oldCrd.Status.Message = message
 
 	//create the patch
 	var newData, patchBytes []byte
@@ -187,3 +185,169 @@ func PatchpgclusterStatus(restclient *rest.RESTClient, state crv1.PgclusterState
 	return err6
 
 }
+
+// PatchpgclusterSnapshots replaces the catalog of snapshot backups in the
+// status of a pgcluster
+func PatchpgclusterSnapshots(restclient *rest.RESTClient, snapshots []crv1.PgclusterSnapshot, name, namespace string) error {
+	patch := map[string]interface{}{
+		"status": map[string]interface{}{
+			"snapshots": snapshots,
+		},
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	log.Debug(string(patchBytes))
+
+	err = restclient.Patch(types.MergePatchType).
+		Namespace(namespace).
+		Resource(crv1.PgclusterResourcePlural).
+		Name(name).
+		Body(patchBytes).
+		Do().
+		Error()
+	if err != nil {
+		log.Error("error patching pgcluster snapshots " + err.Error())
+	}
+
+	return err
+}
+
+// PatchpgclusterAnnotations merges the annotations provided into the
+// annotations of a pgcluster
+func PatchpgclusterAnnotations(restclient *rest.RESTClient, annotations map[string]string, name, namespace string) error {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	log.Debug(string(patchBytes))
+
+	err = restclient.Patch(types.MergePatchType).
+		Namespace(namespace).
+		Resource(crv1.PgclusterResourcePlural).
+		Name(name).
+		Body(patchBytes).
+		Do().
+		Error()
+	if err != nil {
+		log.Error("error patching pgcluster annotations " + err.Error())
+	}
+
+	return err
+}
+
+// PatchpgclusterFinalizers replaces the finalizers of a pgcluster. The
+// resource version of the pgcluster is part of the patch, so the patch fails
+// if the pgcluster was modified in the meantime
+func PatchpgclusterFinalizers(restclient *rest.RESTClient, finalizers []string, cluster *crv1.Pgcluster) error {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"finalizers":      finalizers,
+			"resourceVersion": cluster.ResourceVersion,
+		},
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	log.Debug(string(patchBytes))
+
+	err = restclient.Patch(types.MergePatchType).
+		Namespace(cluster.Namespace).
+		Resource(crv1.PgclusterResourcePlural).
+		Name(cluster.Name).
+		Body(patchBytes).
+		Do().
+		Error()
+	if err != nil {
+		log.Error("error patching pgcluster finalizers " + err.Error())
+	}
+
+	return err
+}
+
+// PatchpgclusterObservedStatus replaces the conditions and the observed state
+// in the status of a pgcluster, leaving its state, message and snapshot
+// catalog alone. The resource version of the pgcluster is part of the patch,
+// so the patch fails if the pgcluster was modified in the meantime
+func PatchpgclusterObservedStatus(restclient *rest.RESTClient, status crv1.PgclusterStatus, cluster *crv1.Pgcluster) error {
+	// every field is given explicitly, so that zero values replace the values
+	// that are currently stored
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"resourceVersion": cluster.ResourceVersion,
+		},
+		"status": map[string]interface{}{
+			"observedGeneration": status.ObservedGeneration,
+			"conditions":         status.Conditions,
+			"primary":            status.Primary,
+			"replicas":           status.Replicas,
+			"readyReplicas":      status.ReadyReplicas,
+			"lastBackupTime":     status.LastBackupTime,
+			"pgBouncerReady":     status.PgBouncerReady,
+			"postgresVersion":    status.PostgresVersion,
+		},
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	log.Debug(string(patchBytes))
+
+	err = restclient.Patch(types.MergePatchType).
+		Namespace(cluster.Namespace).
+		Resource(crv1.PgclusterResourcePlural).
+		Name(cluster.Name).
+		Body(patchBytes).
+		Do().
+		Error()
+	if err != nil {
+		log.Error("error patching pgcluster status " + err.Error())
+	}
+
+	return err
+}
+
+// PatchpgclusterHibernateStatus replaces the state of the hibernate schedule
+// in the status of a pgcluster. A nil status removes it
+func PatchpgclusterHibernateStatus(restclient *rest.RESTClient, status *crv1.PgclusterHibernateStatus, name, namespace string) error {
+	patch := map[string]interface{}{
+		"status": map[string]interface{}{
+			"hibernate": status,
+		},
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	log.Debug(string(patchBytes))
+
+	err = restclient.Patch(types.MergePatchType).
+		Namespace(namespace).
+		Resource(crv1.PgclusterResourcePlural).
+		Name(name).
+		Body(patchBytes).
+		Do().
+		Error()
+	if err != nil {
+		log.Error("error patching pgcluster hibernate status " + err.Error())
+	}
+
+	return err
+}
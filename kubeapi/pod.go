@@ -136,6 +136,28 @@ func AddLabelToPod(clientset *kubernetes.Clientset, origPod *v1.Pod, key, value,
 	return err
 }
 
+// AddAnnotationToPod sets an annotation of a pod with a merge patch, which
+// leaves the rest of the pod as it is
+func AddAnnotationToPod(clientset *kubernetes.Clientset, origPod *v1.Pod, key, value, namespace string) error {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{key: value},
+		},
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	_, err = clientset.CoreV1().Pods(namespace).Patch(origPod.Name, types.MergePatchType, patchBytes)
+	if err != nil {
+		log.Error(err)
+		log.Errorf("error add annotation to Pod %s %s=%s", origPod.Name, key, value)
+	}
+	return err
+}
+
 func GetLogs(client *kubernetes.Clientset, logOpts v1.PodLogOptions, out io.Writer, podName, ns string) error {
 	req := client.CoreV1().Pods(ns).GetLogs(podName, &logOpts)
 
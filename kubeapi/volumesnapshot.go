@@ -0,0 +1,133 @@
+package kubeapi
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	log "github.com/sirupsen/logrus"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// The CSI VolumeSnapshot API is not a part of client-go, so VolumeSnapshots
+// are managed as unstructured objects through the dynamic client
+const (
+	// VolumeSnapshotAPIGroup is the API group of the CSI VolumeSnapshot API,
+	// which is also used as the "apiGroup" of a PVC data source
+	VolumeSnapshotAPIGroup = "snapshot.storage.k8s.io"
+	// VolumeSnapshotKind is the kind of a VolumeSnapshot
+	VolumeSnapshotKind = "VolumeSnapshot"
+)
+
+// VolumeSnapshotResource identifies VolumeSnapshots for the dynamic client
+var VolumeSnapshotResource = schema.GroupVersionResource{
+	Group:    VolumeSnapshotAPIGroup,
+	Version:  "v1beta1",
+	Resource: "volumesnapshots",
+}
+
+// VolumeSnapshotStatus is the subset of the status of a VolumeSnapshot that
+// the Operator acts on
+type VolumeSnapshotStatus struct {
+	// Cut is true once the point-in-time of the snapshot has been taken, i.e.
+	// the volume can be written to again, even if the snapshot is not ready yet
+	Cut bool
+	// Error is the message of any error that occurred while taking the snapshot
+	Error string
+	// ReadyToUse is true when a PVC can be provisioned from the snapshot
+	ReadyToUse bool
+}
+
+// CreateVolumeSnapshot creates a VolumeSnapshot of a PVC. If the class name is
+// empty, the default VolumeSnapshotClass is used
+func CreateVolumeSnapshot(client dynamic.Interface, name, namespace, pvcName, className string,
+	labels map[string]string) error {
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": pvcName,
+		},
+	}
+
+	if className != "" {
+		spec["volumeSnapshotClassName"] = className
+	}
+
+	snapshot := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": VolumeSnapshotResource.GroupVersion().String(),
+			"kind":       VolumeSnapshotKind,
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"spec": spec,
+		},
+	}
+	snapshot.SetLabels(labels)
+
+	if _, err := client.Resource(VolumeSnapshotResource).Namespace(namespace).Create(snapshot,
+		meta_v1.CreateOptions{}); err != nil {
+		log.Error("error creating volume snapshot " + err.Error() + " in namespace " + namespace)
+		return err
+	}
+
+	log.Debugf("created volume snapshot %s of pvc %s", name, pvcName)
+
+	return nil
+}
+
+// GetVolumeSnapshotStatus gets the status of a VolumeSnapshot by name
+// returns status, found=bool, error
+func GetVolumeSnapshotStatus(client dynamic.Interface, name, namespace string) (VolumeSnapshotStatus, bool, error) {
+	status := VolumeSnapshotStatus{}
+
+	snapshot, err := client.Resource(VolumeSnapshotResource).Namespace(namespace).Get(name, meta_v1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return status, false, err
+	} else if err != nil {
+		log.Error(err)
+		return status, false, err
+	}
+
+	// the creation time is only set once the snapshot has been cut
+	if creationTime, found, _ := unstructured.NestedString(snapshot.Object, "status", "creationTime"); found && creationTime != "" {
+		status.Cut = true
+	}
+
+	status.ReadyToUse, _, _ = unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+	status.Error, _, _ = unstructured.NestedString(snapshot.Object, "status", "error", "message")
+
+	// a snapshot that is ready to use has certainly been cut
+	if status.ReadyToUse {
+		status.Cut = true
+	}
+
+	return status, true, nil
+}
+
+// DeleteVolumeSnapshot deletes a VolumeSnapshot by name. It is not an error
+// if the VolumeSnapshot does not exist
+func DeleteVolumeSnapshot(client dynamic.Interface, name, namespace string) error {
+	err := client.Resource(VolumeSnapshotResource).Namespace(namespace).Delete(name, &meta_v1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
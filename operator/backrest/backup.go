@@ -52,6 +52,8 @@ type backrestJobTemplateFields struct {
 	PgbackrestRepoPath            string
 	PgbackrestRepoType            string
 	BackrestLocalAndS3Storage     bool
+	BackrestLocalAndGCSStorage    bool
+	BackrestLocalAndAzureStorage  bool
 	PgbackrestRestoreVolumes      string
 	PgbackrestRestoreVolumeMounts string
 }
@@ -83,6 +85,8 @@ func Backrest(namespace string, clientset *kubernetes.Clientset, task *crv1.Pgta
 		PgbackrestRestoreVolumeMounts: "",
 		PgbackrestRepoType:            operator.GetRepoType(task.Spec.Parameters[config.LABEL_BACKREST_STORAGE_TYPE]),
 		BackrestLocalAndS3Storage:     operator.IsLocalAndS3Storage(task.Spec.Parameters[config.LABEL_BACKREST_STORAGE_TYPE]),
+		BackrestLocalAndGCSStorage:    operator.IsLocalAndGCSStorage(task.Spec.Parameters[config.LABEL_BACKREST_STORAGE_TYPE]),
+		BackrestLocalAndAzureStorage:  operator.IsLocalAndAzureStorage(task.Spec.Parameters[config.LABEL_BACKREST_STORAGE_TYPE]),
 	}
 
 	podCommandOpts, err := getCommandOptsFromPod(clientset, task, namespace)
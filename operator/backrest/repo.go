@@ -30,6 +30,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -51,32 +52,47 @@ type RepoDeploymentTemplateFields struct {
 	PgbackrestStanza          string
 	PgbackrestRepoType        string
 	PgbackrestS3EnvVars       string
+	PgbackrestGCSEnvVars      string
+	PgbackrestAzureEnvVars    string
+	PgbackrestCipherEnvVars   string
 	Name                      string
 	ClusterName               string
 	PodAntiAffinity           string
 	PodAntiAffinityLabelName  string
 	PodAntiAffinityLabelValue string
+	PriorityClassName         string
+	Tolerations               string
+	PodAnnotations            string
+	PodCustomLabels           string
 }
 
 type RepoServiceTemplateFields struct {
-	Name        string
-	ClusterName string
-	Port        string
+	Name         string
+	ClusterName  string
+	Port         string
+	Annotations  string
+	CustomLabels string
 }
 
-func CreateRepoDeployment(clientset *kubernetes.Clientset, namespace string, cluster *crv1.Pgcluster, createPVC bool) error {
+// newRepoServiceFields returns the template fields of the service of the
+// pgBackRest repository of a cluster
+func newRepoServiceFields(cluster *crv1.Pgcluster) RepoServiceTemplateFields {
+	fields := RepoServiceTemplateFields{
+		Name:        fmt.Sprintf(BackrestRepoServiceName, cluster.Name),
+		ClusterName: cluster.Name,
+		Port:        "2022",
+	}
+	fields.Annotations, fields.CustomLabels = operator.GetCustomMetadataJSON(cluster.Spec.Metadata.PgBackRest, nil)
 
-	var b bytes.Buffer
+	return fields
+}
+
+func CreateRepoDeployment(clientset *kubernetes.Clientset, namespace string, cluster *crv1.Pgcluster, createPVC bool) error {
 
 	repoName := fmt.Sprintf(BackrestRepoPVCName, cluster.Name)
-	serviceName := fmt.Sprintf(BackrestRepoServiceName, cluster.Name)
 
 	//create backrest repo service
-	serviceFields := RepoServiceTemplateFields{
-		Name:        serviceName,
-		ClusterName: cluster.Name,
-		Port:        "2022",
-	}
+	serviceFields := newRepoServiceFields(cluster)
 
 	err := createService(clientset, &serviceFields, namespace)
 	if err != nil {
@@ -99,36 +115,89 @@ func CreateRepoDeployment(clientset *kubernetes.Clientset, namespace string, clu
 		}
 	}
 
+	deployment, err := renderRepoDeployment(clientset, namespace, cluster)
+	if err != nil {
+		return err
+	}
+
+	err = kubeapi.CreateDeploymentV1(clientset, deployment, namespace)
+
+	return err
+
+}
+
+// RenderRepo returns the service, the PVC and the deployment of the
+// pgBackRest repository that CreateRepoDeployment creates for a new cluster,
+// without creating them
+func RenderRepo(clientset *kubernetes.Clientset, namespace string, cluster *crv1.Pgcluster) ([]runtime.Object, error) {
+	objects := []runtime.Object{}
+
+	serviceFields := newRepoServiceFields(cluster)
+	service, err := renderService(&serviceFields)
+	if err != nil {
+		return nil, err
+	}
+	objects = append(objects, service)
+
+	repoPVC, _, err := pvc.RenderPVC(&cluster.Spec.BackrestStorage,
+		fmt.Sprintf(BackrestRepoPVCName, cluster.Name), cluster.Name)
+	if err != nil {
+		return nil, err
+	}
+	if repoPVC != nil {
+		objects = append(objects, repoPVC)
+	}
+
+	deployment, err := renderRepoDeployment(clientset, namespace, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(objects, deployment), nil
+}
+
+// renderRepoDeployment returns the deployment of the pgBackRest repository of
+// a cluster
+func renderRepoDeployment(clientset *kubernetes.Clientset, namespace string, cluster *crv1.Pgcluster) (*v1.Deployment, error) {
+	var b bytes.Buffer
+
 	//create backrest repo deployment
 	fields := RepoDeploymentTemplateFields{
-		PGOImagePrefix:        operator.Pgo.Pgo.PGOImagePrefix,
-		PGOImageTag:           operator.Pgo.Pgo.PGOImageTag,
-		ContainerResources:    "",
-		BackrestRepoClaimName: repoName,
-		SshdSecretsName:       "pgo-backrest-repo-config",
-		PGbackrestDBHost:      cluster.Name,
-		PgbackrestRepoPath:    util.GetPGBackRestRepoPath(*cluster),
-		PgbackrestDBPath:      "/pgdata/" + cluster.Name,
-		PgbackrestPGPort:      cluster.Spec.Port,
-		SshdPort:              operator.Pgo.Cluster.BackrestPort,
-		PgbackrestStanza:      "db",
-		PgbackrestRepoType:    operator.GetRepoType(cluster.Spec.UserLabels[config.LABEL_BACKREST_STORAGE_TYPE]),
-		PgbackrestS3EnvVars:   operator.GetPgbackrestS3EnvVars(*cluster, clientset, namespace),
-		Name:                  serviceName,
-		ClusterName:           cluster.Name,
-		SecurityContext:       util.GetPodSecurityContext(cluster.Spec.PrimaryStorage.GetSupplementalGroups()),
+		PGOImagePrefix:          operator.Pgo.Pgo.PGOImagePrefix,
+		PGOImageTag:             operator.Pgo.Pgo.PGOImageTag,
+		ContainerResources:      "",
+		BackrestRepoClaimName:   fmt.Sprintf(BackrestRepoPVCName, cluster.Name),
+		SshdSecretsName:         "pgo-backrest-repo-config",
+		PGbackrestDBHost:        cluster.Name,
+		PgbackrestRepoPath:      util.GetPGBackRestRepoPath(*cluster),
+		PgbackrestDBPath:        "/pgdata/" + cluster.Name,
+		PgbackrestPGPort:        cluster.Spec.Port,
+		SshdPort:                operator.Pgo.Cluster.BackrestPort,
+		PgbackrestStanza:        "db",
+		PgbackrestRepoType:      operator.GetRepoType(cluster.Spec.UserLabels[config.LABEL_BACKREST_STORAGE_TYPE]),
+		PgbackrestS3EnvVars:     operator.GetPgbackrestS3EnvVars(*cluster, clientset, namespace),
+		PgbackrestGCSEnvVars:    operator.GetPgbackrestGCSEnvVars(*cluster),
+		PgbackrestAzureEnvVars:  operator.GetPgbackrestAzureEnvVars(*cluster),
+		PgbackrestCipherEnvVars: operator.GetPgbackrestCipherEnvVars(*cluster),
+		Name:                    fmt.Sprintf(BackrestRepoServiceName, cluster.Name),
+		ClusterName:             cluster.Name,
+		SecurityContext:         util.GetPodSecurityContext(cluster.Spec.PrimaryStorage.GetSupplementalGroups()),
 		PodAntiAffinity: operator.GetPodAntiAffinity(cluster,
 			crv1.PodAntiAffinityDeploymentPgBackRest, cluster.Spec.PodAntiAffinity.PgBackRest),
 		PodAntiAffinityLabelName: config.LABEL_POD_ANTI_AFFINITY,
 		PodAntiAffinityLabelValue: string(operator.GetPodAntiAffinityType(cluster,
 			crv1.PodAntiAffinityDeploymentPgBackRest, cluster.Spec.PodAntiAffinity.PgBackRest)),
+		PriorityClassName: operator.GetPriorityClassName(cluster),
+		Tolerations:       operator.GetTolerationsJSON(operator.GetTolerations(cluster)),
 	}
+	fields.PodAnnotations, fields.PodCustomLabels = operator.GetCustomMetadataJSON(
+		cluster.Spec.Metadata.PgBackRest, nil)
 	log.Debugf(fields.Name)
 
-	err = config.PgoBackrestRepoTemplate.Execute(&b, fields)
+	err := config.PgoBackrestRepoTemplate.Execute(&b, fields)
 	if err != nil {
 		log.Error(err.Error())
-		return err
+		return nil, err
 	}
 
 	if operator.CRUNCHY_DEBUG {
@@ -139,46 +208,54 @@ func CreateRepoDeployment(clientset *kubernetes.Clientset, namespace string, clu
 	err = json.Unmarshal(b.Bytes(), &deployment)
 	if err != nil {
 		log.Error("error unmarshalling backrest repo json into Deployment " + err.Error())
-		return err
+		return nil, err
 	}
 
 	// set the container image to an override value, if one exists
 	operator.SetContainerImageOverride(config.CONTAINER_IMAGE_PGO_BACKREST_REPO,
 		&deployment.Spec.Template.Spec.Containers[0])
 
-	err = kubeapi.CreateDeploymentV1(clientset, &deployment, namespace)
-
-	return err
-
+	return &deployment, nil
 }
 
 func createService(clientset *kubernetes.Clientset, fields *RepoServiceTemplateFields, namespace string) error {
 	var err error
 
-	var b bytes.Buffer
-
 	_, found, err := kubeapi.GetService(clientset, fields.Name, namespace)
 	if !found || err != nil {
 
-		err = config.PgoBackrestRepoServiceTemplate.Execute(&b, fields)
+		var s *corev1.Service
+		s, err = renderService(fields)
 		if err != nil {
-			log.Error(err.Error())
 			return err
 		}
 
-		if operator.CRUNCHY_DEBUG {
-			config.PgoBackrestRepoServiceTemplate.Execute(os.Stdout, fields)
-		}
+		_, err = kubeapi.CreateService(clientset, s, namespace)
+	}
 
-		s := corev1.Service{}
-		err = json.Unmarshal(b.Bytes(), &s)
-		if err != nil {
-			log.Error("error unmarshalling repo service json into repo Service " + err.Error())
-			return err
-		}
+	return err
+}
 
-		_, err = kubeapi.CreateService(clientset, &s, namespace)
+// renderService returns the service of the pgBackRest repository
+func renderService(fields *RepoServiceTemplateFields) (*corev1.Service, error) {
+	var b bytes.Buffer
+
+	err := config.PgoBackrestRepoServiceTemplate.Execute(&b, fields)
+	if err != nil {
+		log.Error(err.Error())
+		return nil, err
 	}
 
-	return err
+	if operator.CRUNCHY_DEBUG {
+		config.PgoBackrestRepoServiceTemplate.Execute(os.Stdout, fields)
+	}
+
+	s := corev1.Service{}
+	err = json.Unmarshal(b.Bytes(), &s)
+	if err != nil {
+		log.Error("error unmarshalling repo service json into repo Service " + err.Error())
+		return nil, err
+	}
+
+	return &s, nil
 }
@@ -39,25 +39,28 @@ import (
 )
 
 type BackrestRestoreJobTemplateFields struct {
-	JobName                string
-	ClusterName            string
-	WorkflowID             string
-	ToClusterPVCName       string
-	SecurityContext        string
-	PGOImagePrefix         string
-	PGOImageTag            string
-	CommandOpts            string
-	PITRTarget             string
-	PgbackrestStanza       string
-	PgbackrestDBPath       string
-	PgbackrestRepo1Path    string
-	PgbackrestRepo1Host    string
-	PgbackrestRepoType     string
-	PgbackrestS3EnvVars    string
-	NodeSelector           string
-	Tablespaces            string
-	TablespaceVolumes      string
-	TablespaceVolumeMounts string
+	JobName                 string
+	ClusterName             string
+	WorkflowID              string
+	ToClusterPVCName        string
+	SecurityContext         string
+	PGOImagePrefix          string
+	PGOImageTag             string
+	CommandOpts             string
+	PITRTarget              string
+	PgbackrestStanza        string
+	PgbackrestDBPath        string
+	PgbackrestRepo1Path     string
+	PgbackrestRepo1Host     string
+	PgbackrestRepoType      string
+	PgbackrestS3EnvVars     string
+	PgbackrestGCSEnvVars    string
+	PgbackrestAzureEnvVars  string
+	PgbackrestCipherEnvVars string
+	NodeSelector            string
+	Tablespaces             string
+	TablespaceVolumes       string
+	TablespaceVolumeMounts  string
 }
 
 // Restore ...
@@ -169,24 +172,27 @@ func Restore(restclient *rest.RESTClient, namespace string, clientset *kubernete
 
 	workflowID := task.Spec.Parameters[crv1.PgtaskWorkflowID]
 	jobFields := BackrestRestoreJobTemplateFields{
-		JobName:                "restore-" + task.Spec.Parameters[config.LABEL_BACKREST_RESTORE_FROM_CLUSTER] + "-" + util.RandStringBytesRmndr(4),
-		ClusterName:            task.Spec.Parameters[config.LABEL_BACKREST_RESTORE_FROM_CLUSTER],
-		SecurityContext:        util.GetPodSecurityContext(storage.GetSupplementalGroups()),
-		ToClusterPVCName:       pvcName,
-		WorkflowID:             workflowID,
-		CommandOpts:            task.Spec.Parameters[config.LABEL_BACKREST_RESTORE_OPTS],
-		PITRTarget:             task.Spec.Parameters[config.LABEL_BACKREST_PITR_TARGET],
-		PGOImagePrefix:         operator.Pgo.Pgo.PGOImagePrefix,
-		PGOImageTag:            operator.Pgo.Pgo.PGOImageTag,
-		PgbackrestStanza:       task.Spec.Parameters[config.LABEL_PGBACKREST_STANZA],
-		PgbackrestDBPath:       task.Spec.Parameters[config.LABEL_PGBACKREST_DB_PATH],
-		PgbackrestRepo1Path:    util.GetPGBackRestRepoPath(cluster),
-		PgbackrestRepo1Host:    task.Spec.Parameters[config.LABEL_PGBACKREST_REPO_HOST],
-		NodeSelector:           operator.GetAffinity(task.Spec.Parameters["NodeLabelKey"], task.Spec.Parameters["NodeLabelValue"], "In"),
-		PgbackrestRepoType:     operator.GetRepoType(task.Spec.Parameters[config.LABEL_BACKREST_STORAGE_TYPE]),
-		PgbackrestS3EnvVars:    operator.GetPgbackrestS3EnvVars(cluster, clientset, namespace),
-		TablespaceVolumes:      operator.GetTablespaceVolumesJSON(pvcName, tablespaceMountsMap),
-		TablespaceVolumeMounts: operator.GetTablespaceVolumeMountsJSON(tablespaceMountsMap),
+		JobName:                 "restore-" + task.Spec.Parameters[config.LABEL_BACKREST_RESTORE_FROM_CLUSTER] + "-" + util.RandStringBytesRmndr(4),
+		ClusterName:             task.Spec.Parameters[config.LABEL_BACKREST_RESTORE_FROM_CLUSTER],
+		SecurityContext:         util.GetPodSecurityContext(storage.GetSupplementalGroups()),
+		ToClusterPVCName:        pvcName,
+		WorkflowID:              workflowID,
+		CommandOpts:             task.Spec.Parameters[config.LABEL_BACKREST_RESTORE_OPTS],
+		PITRTarget:              task.Spec.Parameters[config.LABEL_BACKREST_PITR_TARGET],
+		PGOImagePrefix:          operator.Pgo.Pgo.PGOImagePrefix,
+		PGOImageTag:             operator.Pgo.Pgo.PGOImageTag,
+		PgbackrestStanza:        task.Spec.Parameters[config.LABEL_PGBACKREST_STANZA],
+		PgbackrestDBPath:        task.Spec.Parameters[config.LABEL_PGBACKREST_DB_PATH],
+		PgbackrestRepo1Path:     util.GetPGBackRestRepoPath(cluster),
+		PgbackrestRepo1Host:     task.Spec.Parameters[config.LABEL_PGBACKREST_REPO_HOST],
+		NodeSelector:            operator.GetAffinity(task.Spec.Parameters["NodeLabelKey"], task.Spec.Parameters["NodeLabelValue"], "In"),
+		PgbackrestRepoType:      operator.GetRepoType(task.Spec.Parameters[config.LABEL_BACKREST_STORAGE_TYPE]),
+		PgbackrestS3EnvVars:     operator.GetPgbackrestS3EnvVars(cluster, clientset, namespace),
+		PgbackrestGCSEnvVars:    operator.GetPgbackrestGCSEnvVars(cluster),
+		PgbackrestAzureEnvVars:  operator.GetPgbackrestAzureEnvVars(cluster),
+		PgbackrestCipherEnvVars: operator.GetPgbackrestCipherEnvVars(cluster),
+		TablespaceVolumes:       operator.GetTablespaceVolumesJSON(pvcName, tablespaceMountsMap),
+		TablespaceVolumeMounts:  operator.GetTablespaceVolumeMountsJSON(tablespaceMountsMap),
 	}
 
 	jobTemplate := bytes.Buffer{}
@@ -346,6 +352,8 @@ func CreateRestoredDeployment(restclient *rest.RESTClient, cluster *crv1.Pgclust
 		affinityStr = operator.GetAffinity(cluster.Spec.UserLabels["NodeLabelKey"], cluster.Spec.UserLabels["NodeLabelValue"], "In")
 	}
 
+	operator.CreateCollectSecret(clientset, &cluster.Spec, namespace)
+
 	// set up a map of the names of the tablespaces as well as the storage classes
 	tablespaceStorageTypeMap := operator.GetTablespaceStorageTypeMap(cluster.Spec.TablespaceMounts)
 
@@ -375,16 +383,22 @@ func CreateRestoredDeployment(restclient *rest.RESTClient, cluster *crv1.Pgclust
 		NodeSelector:      affinityStr,
 		PodAntiAffinity: operator.GetPodAntiAffinity(cluster,
 			crv1.PodAntiAffinityDeploymentDefault, cluster.Spec.PodAntiAffinity.Default),
-		ContainerResources: operator.GetContainerResourcesJSON(&cluster.Spec.ContainerResources),
-		ConfVolume:         operator.GetConfVolume(clientset, cluster, namespace),
-		CollectAddon:       operator.GetCollectAddon(clientset, namespace, &cluster.Spec),
-		CollectVolume:      operator.GetCollectVolume(clientset, cluster, namespace),
-		BadgerAddon:        operator.GetBadgerAddon(clientset, namespace, cluster, restoreToName),
-		ScopeLabel:         config.LABEL_PGHA_SCOPE,
-		Standby:            false, // always disabled since standby clusters cannot be restored
+		Tolerations:               operator.GetTolerationsJSON(operator.GetTolerations(cluster)),
+		PriorityClassName:         operator.GetPriorityClassName(cluster),
+		TopologySpreadConstraints: operator.GetTopologySpreadConstraintsJSON(cluster),
+		ContainerResources:        operator.GetContainerResourcesJSON(&cluster.Spec.ContainerResources),
+		ConfVolume:                operator.GetConfVolume(clientset, cluster, namespace),
+		CollectAddon:              operator.GetCollectAddon(clientset, namespace, &cluster.Spec),
+		CollectVolume:             operator.GetCollectVolume(clientset, cluster, namespace),
+		BadgerAddon:               operator.GetBadgerAddon(clientset, namespace, cluster, restoreToName),
+		ScopeLabel:                config.LABEL_PGHA_SCOPE,
+		Standby:                   false, // always disabled since standby clusters cannot be restored
 		PgbackrestEnvVars: operator.GetPgbackrestEnvVars(cluster, cluster.Labels[config.LABEL_BACKREST], restoreToName,
 			cluster.Spec.Port, cluster.Spec.UserLabels[config.LABEL_BACKREST_STORAGE_TYPE]),
 		PgbackrestS3EnvVars:      operator.GetPgbackrestS3EnvVars(*cluster, clientset, namespace),
+		PgbackrestGCSEnvVars:     operator.GetPgbackrestGCSEnvVars(*cluster),
+		PgbackrestAzureEnvVars:   operator.GetPgbackrestAzureEnvVars(*cluster),
+		PgbackrestCipherEnvVars:  operator.GetPgbackrestCipherEnvVars(*cluster),
 		EnableCrunchyadm:         operator.Pgo.Cluster.EnableCrunchyadm,
 		ReplicaReinitOnStartFail: !operator.Pgo.Cluster.DisableReplicaStartFailReinit,
 		SyncReplication:          operator.GetSyncReplication(cluster.Spec.SyncReplication),
@@ -395,7 +409,10 @@ func CreateRestoredDeployment(restclient *rest.RESTClient, cluster *crv1.Pgclust
 		TLSOnly:                  cluster.Spec.TLSOnly,
 		TLSSecret:                cluster.Spec.TLS.TLSSecret,
 		CASecret:                 cluster.Spec.TLS.CASecret,
+		ReplicationTLSSecret:     operator.GetReplicationTLSSecretName(cluster),
 	}
+	deploymentFields.PodAnnotations, deploymentFields.PodCustomLabels = operator.GetCustomMetadataJSON(
+		cluster.Spec.Metadata.Pod, cluster.Spec.UserLabels)
 
 	log.Debug("collectaddon value is [" + deploymentFields.CollectAddon + "]")
 	var primaryDoc bytes.Buffer
@@ -16,7 +16,9 @@ package backrest
 */
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
 	"github.com/crunchydata/postgres-operator/config"
@@ -106,3 +108,67 @@ func StanzaCreate(namespace, clusterName string, clientset *kubernetes.Clientset
 	}
 
 }
+
+// StanzaUpgrade upgrades the pgBackRest stanza of a cluster after the major
+// PostgreSQL version of the cluster has been upgraded. Any task and job of a
+// previous stanza upgrade are removed first
+func StanzaUpgrade(namespace, clusterName string, clientset *kubernetes.Clientset,
+	RESTClient *rest.RESTClient) error {
+
+	taskName := clusterName + "-" + crv1.PgtaskBackrestStanzaUpgrade
+
+	//look up the backrest-repo pod name
+	selector := config.LABEL_PG_CLUSTER + "=" + clusterName + "," + config.LABEL_PGO_BACKREST_REPO + "=true"
+	pods, err := kubeapi.GetPods(clientset, selector, namespace)
+	if err != nil {
+		return err
+	} else if len(pods.Items) != 1 {
+		return fmt.Errorf("pods len != 1 for cluster %s", clusterName)
+	}
+
+	cluster := crv1.Pgcluster{}
+	if _, err := kubeapi.Getpgcluster(RESTClient, &cluster, clusterName, namespace); err != nil {
+		return err
+	}
+
+	if found, _ := kubeapi.Getpgtask(RESTClient, &crv1.Pgtask{}, taskName, namespace); found {
+		if err := kubeapi.Deletepgtask(RESTClient, taskName, namespace); err != nil {
+			return err
+		}
+	}
+
+	if job, found := kubeapi.GetJob(clientset, taskName, namespace); found {
+		if err := kubeapi.DeleteJob(clientset, taskName, namespace); err != nil {
+			return err
+		}
+		if err := kubeapi.IsJobDeleted(clientset, namespace, job, time.Minute); err != nil {
+			return err
+		}
+	}
+
+	//create the stanza-upgrade task
+	spec := crv1.PgtaskSpec{}
+	spec.Name = taskName
+	spec.TaskType = crv1.PgtaskBackrest
+	spec.Parameters = make(map[string]string)
+	spec.Parameters[config.LABEL_JOB_NAME] = taskName
+	spec.Parameters[config.LABEL_PG_CLUSTER] = clusterName
+	spec.Parameters[config.LABEL_POD_NAME] = pods.Items[0].Name
+	spec.Parameters[config.LABEL_CONTAINER_NAME] = "pgo-backrest-repo"
+	spec.Parameters[config.LABEL_BACKREST_COMMAND] = crv1.PgtaskBackrestStanzaUpgrade
+	spec.Parameters[config.LABEL_BACKREST_STORAGE_TYPE] =
+		cluster.Spec.UserLabels[config.LABEL_BACKREST_STORAGE_TYPE]
+	spec.Parameters[config.LABEL_BACKREST_OPTS] = ""
+
+	newInstance := &crv1.Pgtask{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: taskName,
+			Labels: map[string]string{
+				config.LABEL_PG_CLUSTER: clusterName,
+			},
+		},
+		Spec: spec,
+	}
+
+	return kubeapi.Createpgtask(RESTClient, newInstance, namespace)
+}
@@ -0,0 +1,141 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	"github.com/crunchydata/postgres-operator/operator"
+	"github.com/crunchydata/postgres-operator/util"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// archivePushCommand pushes a WAL segment to the repository of the
+	// PGBACKREST_REPO_TYPE of the container, which is the local repository
+	// when local storage is used along with a cloud storage type
+	archivePushCommand = `source /opt/cpm/bin/pgbackrest/pgbackrest-set-env.sh && pgbackrest archive-push "%p"`
+
+	// archivePushCloudCommand also pushes a WAL segment to the repository of a
+	// cloud storage type
+	archivePushCloudCommand = ` && pgbackrest archive-push --repo-type=%s "%%p"`
+)
+
+// patroniArchiveConfig is the part of the dynamic configuration of Patroni
+// that holds the archive_command of PostgreSQL. A nil command removes the
+// setting from the dynamic configuration
+type patroniArchiveConfig struct {
+	PostgreSQL struct {
+		Parameters struct {
+			ArchiveCommand *string `json:"archive_command"`
+		} `json:"parameters"`
+	} `json:"postgresql"`
+}
+
+// getArchiveCommand returns the archive_command of a cluster that uses local
+// storage along with GCS or Azure Blob storage, which pushes every WAL segment
+// to both repositories. Otherwise it returns an empty string, and the command
+// of the container is used, which takes care of local and S3 storage itself
+func getArchiveCommand(backrestStorageType string) string {
+	switch {
+	case operator.IsLocalAndGCSStorage(backrestStorageType):
+		return archivePushCommand + fmt.Sprintf(archivePushCloudCommand, "gcs")
+	case operator.IsLocalAndAzureStorage(backrestStorageType):
+		return archivePushCommand + fmt.Sprintf(archivePushCloudCommand, "azure")
+	}
+
+	return ""
+}
+
+// isOperatorArchiveCommand returns true if an archive_command is one that the
+// Operator sets, see getArchiveCommand
+func isOperatorArchiveCommand(command string) bool {
+	return strings.HasPrefix(command, archivePushCommand+" && ")
+}
+
+// ReconcileArchiveCommand sets the archive_command of a cluster that uses local
+// storage along with GCS or Azure Blob storage in the dynamic configuration of
+// Patroni, and removes it once the cluster no longer does. It is called once
+// the primary of a cluster is ready
+func ReconcileArchiveCommand(clientset *kubernetes.Clientset, restconfig *rest.Config,
+	cluster *crv1.Pgcluster) error {
+	if cluster.Labels[config.LABEL_BACKREST] != "true" {
+		return nil
+	}
+
+	pod, err := util.GetPrimaryPod(clientset, cluster)
+	if err != nil {
+		return err
+	}
+
+	if !isDatabaseContainerReady(pod) {
+		return nil
+	}
+
+	stdout, stderr, err := kubeapi.ExecToPodThroughAPI(restconfig, clientset,
+		[]string{"curl", "-s", "--fail", fmt.Sprintf("http://127.0.0.1:%s/config", config.DEFAULT_PATRONI_PORT)},
+		"database", pod.Name, pod.Namespace, nil)
+	if err != nil {
+		log.Error(stderr)
+		return err
+	}
+
+	current := patroniArchiveConfig{}
+	if err := json.Unmarshal([]byte(stdout), &current); err != nil {
+		return err
+	}
+
+	currentCommand := ""
+	if current.PostgreSQL.Parameters.ArchiveCommand != nil {
+		currentCommand = *current.PostgreSQL.Parameters.ArchiveCommand
+	}
+
+	desired := patroniArchiveConfig{}
+	if command := getArchiveCommand(cluster.Spec.UserLabels[config.LABEL_BACKREST_STORAGE_TYPE]); command != "" {
+		desired.PostgreSQL.Parameters.ArchiveCommand = &command
+	} else if !isOperatorArchiveCommand(currentCommand) {
+		return nil
+	}
+
+	if desired.PostgreSQL.Parameters.ArchiveCommand != nil &&
+		*desired.PostgreSQL.Parameters.ArchiveCommand == currentCommand {
+		return nil
+	}
+
+	log.Infof("updating the archive_command of cluster %s", cluster.Name)
+
+	patch, err := json.Marshal(desired)
+	if err != nil {
+		return err
+	}
+
+	if _, stderr, err := kubeapi.ExecToPodThroughAPI(restconfig, clientset,
+		[]string{"curl", "-s", "--fail", "-X", "PATCH", "-d", "@-",
+			fmt.Sprintf("http://127.0.0.1:%s/config", config.DEFAULT_PATRONI_PORT)},
+		"database", pod.Name, pod.Namespace, strings.NewReader(string(patch))); err != nil {
+		log.Error(stderr)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import "testing"
+
+func TestGetArchiveCommand(t *testing.T) {
+	tests := []struct {
+		storageType string
+		expected    string
+	}{
+		{"", ""},
+		{"local", ""},
+		{"s3", ""},
+		{"gcs", ""},
+		{"azure", ""},
+		{"local,s3", ""},
+		{"local,gcs", `source /opt/cpm/bin/pgbackrest/pgbackrest-set-env.sh && pgbackrest archive-push "%p" && pgbackrest archive-push --repo-type=gcs "%p"`},
+		{"azure,local", `source /opt/cpm/bin/pgbackrest/pgbackrest-set-env.sh && pgbackrest archive-push "%p" && pgbackrest archive-push --repo-type=azure "%p"`},
+	}
+
+	for i, test := range tests {
+		command := getArchiveCommand(test.storageType)
+
+		if command != test.expected {
+			t.Fatalf("tests[%d] - expected %q, got %q", i, test.expected, command)
+		}
+
+		if command != "" && !isOperatorArchiveCommand(command) {
+			t.Fatalf("tests[%d] - expected %q to be set by the Operator", i, command)
+		}
+	}
+}
+
+func TestIsOperatorArchiveCommand(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected bool
+	}{
+		{"", false},
+		{archivePushCommand, false},
+		{`pgbackrest archive-push "%p"`, false},
+		{archivePushCommand + ` && pgbackrest archive-push --repo-type=gcs "%p"`, true},
+	}
+
+	for i, test := range tests {
+		if actual := isOperatorArchiveCommand(test.command); actual != test.expected {
+			t.Fatalf("tests[%d] - expected %t, got %t", i, test.expected, actual)
+		}
+	}
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/crunchydata/postgres-operator/operator"
 	"github.com/crunchydata/postgres-operator/operator/backrest"
 	"github.com/crunchydata/postgres-operator/operator/pvc"
+	snapshotoperator "github.com/crunchydata/postgres-operator/operator/snapshot"
 	"github.com/crunchydata/postgres-operator/util"
 	log "github.com/sirupsen/logrus"
 	batch_v1 "k8s.io/api/batch/v1"
@@ -44,6 +45,7 @@ import (
 const (
 	pgBackRestRepoSyncContainerImageName = "%s/pgo-backrest-repo-sync:%s"
 	pgBackRestRepoSyncJobNamePrefix      = "pgo-backrest-repo-sync-%s-%s"
+	pgBackRestRepoSyncSecretName         = "%s-backrest-repo-sync-config"
 	pgBackRestStanza                     = "db" // this is hardcoded throughout...
 	patchResource                        = "pgtasks"
 	patchURL                             = "/spec/status"
@@ -193,8 +195,10 @@ func UpdateCloneWorkflow(client *rest.RESTClient, namespace, workflowID, status
 func cloneStep1(clientset *kubernetes.Clientset, client *rest.RESTClient, namespace string, task *crv1.Pgtask) {
 	sourceClusterName, targetClusterName, workflowID := getCloneTaskIdentifiers(task)
 
-	log.Debugf("clone step 1 called: namespace:[%s] sourcecluster:[%s] targetcluster:[%s] workflowid:[%s]",
-		namespace, sourceClusterName, targetClusterName, workflowID)
+	sourceNamespace := getCloneSourceNamespace(task, namespace)
+
+	log.Debugf("clone step 1 called: namespace:[%s] sourcenamespace:[%s] sourcecluster:[%s] targetcluster:[%s] workflowid:[%s]",
+		namespace, sourceNamespace, sourceClusterName, targetClusterName, workflowID)
 
 	// before we get stared, let's ensure we publish an event that the clone
 	// workflow has begun
@@ -210,7 +214,7 @@ func cloneStep1(clientset *kubernetes.Clientset, client *rest.RESTClient, namesp
 
 	// get the information about the current pgcluster by name, to ensure it
 	// exists
-	sourcePgcluster, err := getSourcePgcluster(client, namespace, sourceClusterName)
+	sourcePgcluster, err := getSourcePgcluster(client, sourceNamespace, sourceClusterName)
 
 	// if there is an error getting the pgcluster, abort here
 	if err != nil {
@@ -244,10 +248,28 @@ func cloneStep1(clientset *kubernetes.Clientset, client *rest.RESTClient, namesp
 
 	// first, create the PVC for the pgBackRest storage, as we will be needing
 	// that sooner
-	createPVCs(clientset, client, task, namespace, sourcePgcluster, targetClusterName)
+	if err := createPVCs(clientset, client, task, namespace, sourcePgcluster, targetClusterName); err != nil {
+		log.Error(err)
+		errorMessage := fmt.Sprintf("Could not create pvcs: %s", err.Error())
+		PublishCloneEvent(events.EventCloneClusterFailure, namespace, task, errorMessage)
+		return
+	}
 
 	log.Debug("clone step 1: created pvcs")
 
+	// if the clone is going into a different namespace, the repo sync job needs
+	// to be able to access the pgBackRest repository of the source cluster,
+	// which means the SSH credentials of the source repository need to be
+	// available in the target namespace
+	if sourceNamespace != namespace {
+		if err := createRepoSyncSecret(clientset, sourceNamespace, namespace, sourceClusterName, targetClusterName); err != nil {
+			log.Error(err)
+			errorMessage := fmt.Sprintf("Could not copy pgbackrest repo secret: %s", err.Error())
+			PublishCloneEvent(events.EventCloneClusterFailure, namespace, task, errorMessage)
+			return
+		}
+	}
+
 	// awesome. now it's time to synchronize the source and targe cluster
 	// pgBackRest repositories
 
@@ -268,16 +290,19 @@ func cloneStep1(clientset *kubernetes.Clientset, client *rest.RESTClient, namesp
 
 // cloneStep2 creates a pgBackRest restore job for the new PostgreSQL cluster by
 // running a restore from the new target cluster pgBackRest repository to the
-// new target cluster PVC
+// new target cluster PVC. If the clone is made from a snapshot backup, the job
+// instead prepares the PVC that was provisioned from the snapshot backup
 func cloneStep2(clientset *kubernetes.Clientset, client *rest.RESTClient, namespace string, task *crv1.Pgtask) {
 	sourceClusterName, targetClusterName, workflowID := getCloneTaskIdentifiers(task)
 
-	log.Debugf("clone step 2 called: namespace:[%s] sourcecluster:[%s] targetcluster:[%s] workflowid:[%s]",
-		namespace, sourceClusterName, targetClusterName, workflowID)
+	sourceNamespace := getCloneSourceNamespace(task, namespace)
+
+	log.Debugf("clone step 2 called: namespace:[%s] sourcenamespace:[%s] sourcecluster:[%s] targetcluster:[%s] workflowid:[%s]",
+		namespace, sourceNamespace, sourceClusterName, targetClusterName, workflowID)
 
 	// get the information about the current pgcluster by name, to ensure it
 	// exists, as we still need information about the PrimaryStorage
-	sourcePgcluster, err := getSourcePgcluster(client, namespace, sourceClusterName)
+	sourcePgcluster, err := getSourcePgcluster(client, sourceNamespace, sourceClusterName)
 
 	// if there is an error getting the pgcluster, abort here
 	if err != nil {
@@ -288,7 +313,7 @@ func cloneStep2(clientset *kubernetes.Clientset, client *rest.RESTClient, namesp
 	}
 
 	// Retrieve current S3 key & key secret
-	s3Creds, err := util.GetS3CredsFromBackrestRepoSecret(clientset, namespace, sourcePgcluster.Name)
+	s3Creds, err := util.GetS3CredsFromBackrestRepoSecret(clientset, sourceNamespace, sourcePgcluster.Name)
 	if err != nil {
 		log.Error(err)
 		errorMessage := fmt.Sprintf("Unable to get S3 key and key secret from source cluster "+
@@ -297,15 +322,49 @@ func cloneStep2(clientset *kubernetes.Clientset, client *rest.RESTClient, namesp
 		return
 	}
 
+	// Retrieve current GCS and Azure credentials
+	gcsCreds, err := util.GetGCSCredsFromBackrestRepoSecret(clientset, sourceNamespace, sourcePgcluster.Name)
+	if err != nil {
+		log.Error(err)
+		errorMessage := fmt.Sprintf("Unable to get GCS key from source cluster "+
+			"backrest repo secret: %s", err.Error())
+		PublishCloneEvent(events.EventCloneClusterFailure, namespace, task, errorMessage)
+		return
+	}
+
+	azureCreds, err := util.GetAzureCredsFromBackrestRepoSecret(clientset, sourceNamespace, sourcePgcluster.Name)
+	if err != nil {
+		log.Error(err)
+		errorMessage := fmt.Sprintf("Unable to get Azure account and key from source cluster "+
+			"backrest repo secret: %s", err.Error())
+		PublishCloneEvent(events.EventCloneClusterFailure, namespace, task, errorMessage)
+		return
+	}
+
+	// if the repository is encrypted, the target cluster needs the passphrase of
+	// the source cluster in order to restore from the repository
+	cipherPass, err := util.GetCipherPassFromBackrestRepoSecret(clientset, sourceNamespace, sourcePgcluster.Name)
+	if err != nil {
+		log.Error(err)
+		errorMessage := fmt.Sprintf("Unable to get cipher passphrase from source cluster "+
+			"backrest repo secret: %s", err.Error())
+		PublishCloneEvent(events.EventCloneClusterFailure, namespace, task, errorMessage)
+		return
+	}
+
 	// we need to set up the secret for the pgBackRest repo. This is the place to
 	// do it
 	if err := util.CreateBackrestRepoSecrets(clientset,
 		util.BackrestRepoConfig{
-			BackrestS3Key:       s3Creds.AWSS3Key,
-			BackrestS3KeySecret: s3Creds.AWSS3KeySecret,
-			ClusterName:         targetClusterName,
-			ClusterNamespace:    namespace,
-			OperatorNamespace:   operator.PgoNamespace,
+			BackrestS3Key:        s3Creds.AWSS3Key,
+			BackrestS3KeySecret:  s3Creds.AWSS3KeySecret,
+			BackrestGCSKey:       gcsCreds.GCSKey,
+			BackrestAzureAccount: azureCreds.AzureAccount,
+			BackrestAzureKey:     azureCreds.AzureKey,
+			BackrestCipherPass:   cipherPass,
+			ClusterName:          targetClusterName,
+			ClusterNamespace:     namespace,
+			OperatorNamespace:    operator.PgoNamespace,
 		}); err != nil {
 		log.Error(err)
 		// publish a failure event
@@ -328,8 +387,9 @@ func cloneStep2(clientset *kubernetes.Clientset, client *rest.RESTClient, namesp
 			},
 		},
 		Spec: crv1.PgclusterSpec{
-			Port:           sourcePgcluster.Spec.Port,
-			PrimaryStorage: sourcePgcluster.Spec.PrimaryStorage,
+			BackrestCipherType: sourcePgcluster.Spec.BackrestCipherType,
+			Port:               sourcePgcluster.Spec.Port,
+			PrimaryStorage:     sourcePgcluster.Spec.PrimaryStorage,
 			UserLabels: map[string]string{
 				config.LABEL_BACKREST_STORAGE_TYPE: sourcePgcluster.Spec.UserLabels[config.LABEL_BACKREST_STORAGE_TYPE],
 			},
@@ -354,50 +414,24 @@ func cloneStep2(clientset *kubernetes.Clientset, client *rest.RESTClient, namesp
 		return
 	}
 
-	backrestRestoreJobFields := backrest.BackrestRestoreJobTemplateFields{
-		JobName:     fmt.Sprintf("restore-%s-%s", targetClusterName, util.RandStringBytesRmndr(4)),
-		ClusterName: targetClusterName,
-		SecurityContext: util.GetPodSecurityContext(
-			sourcePgcluster.Spec.PrimaryStorage.GetSupplementalGroups()),
-		ToClusterPVCName: targetClusterName, // the PVC name should match that of the target cluster
-		WorkflowID:       workflowID,
-		// use a delta restore in order to optimize how the restore occurs
-		CommandOpts: "--delta",
-		// PITRTarget is not supported in the first iteration of clone
-		PGOImagePrefix:      operator.Pgo.Pgo.PGOImagePrefix,
-		PGOImageTag:         operator.Pgo.Pgo.PGOImageTag,
-		PgbackrestStanza:    pgBackRestStanza,
-		PgbackrestDBPath:    fmt.Sprintf(targetClusterPGDATAPath, targetClusterName),
-		PgbackrestRepo1Path: util.GetPGBackRestRepoPath(sourcePgcluster),
-		PgbackrestRepo1Host: fmt.Sprintf(backrest.BackrestRepoServiceName, targetClusterName),
-		PgbackrestRepoType:  operator.GetRepoType(task.Spec.Parameters["backrestStorageType"]),
-		PgbackrestS3EnvVars: operator.GetPgbackrestS3EnvVars(sourcePgcluster, clientset, namespace),
-	}
-
-	// substitute the variables into the BackrestRestore job template
-	var backrestRestoreJobDoc bytes.Buffer
+	// create the restore job. If the clone is made from a snapshot backup, the
+	// data directory was already provisioned in step 1 and only needs to be
+	// prepared for the new cluster, otherwise it is restored with pgBackRest
+	var job *batch_v1.Job
 
-	if err = config.BackrestRestorejobTemplate.Execute(&backrestRestoreJobDoc, backrestRestoreJobFields); err != nil {
-		log.Error(err)
-		errorMessage := fmt.Sprintf("Could not create pgbackrest restore template: %s", err.Error())
-		PublishCloneEvent(events.EventCloneClusterFailure, namespace, task, errorMessage)
-		return
+	if task.Spec.Parameters[util.CloneParameterSnapshot] != "" {
+		job, err = newCloneSnapshotRestoreJob(task, sourcePgcluster, targetClusterName, workflowID)
+	} else {
+		job, err = newCloneBackrestRestoreJob(clientset, task, sourcePgcluster, namespace, targetClusterName, workflowID)
 	}
 
-	// create the pgBackRest restore job!
-	job := batch_v1.Job{}
-
-	if err := json.Unmarshal(backrestRestoreJobDoc.Bytes(), &job); err != nil {
+	if err != nil {
 		log.Error(err)
-		errorMessage := fmt.Sprintf("Could not turn pgbackrest restore template into JSON: %s", err.Error())
+		errorMessage := fmt.Sprintf("Could not create restore job template: %s", err.Error())
 		PublishCloneEvent(events.EventCloneClusterFailure, namespace, task, errorMessage)
 		return
 	}
 
-	// set the container image to an override value, if one exists
-	operator.SetContainerImageOverride(config.CONTAINER_IMAGE_PGO_BACKREST_RESTORE,
-		&job.Spec.Template.Spec.Containers[0])
-
 	// update the job annotations to include information about the source and
 	// target cluster
 	if job.ObjectMeta.Annotations == nil {
@@ -407,8 +441,11 @@ func cloneStep2(clientset *kubernetes.Clientset, client *rest.RESTClient, namesp
 	job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_BACKREST_PVC_SIZE] = task.Spec.Parameters[util.CloneParameterBackrestPVCSize]
 	job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_ENABLE_METRICS] = task.Spec.Parameters[util.CloneParameterEnableMetrics]
 	job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_PVC_SIZE] = task.Spec.Parameters[util.CloneParameterPVCSize]
+	job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_ROTATE_CIPHER_PASS] = task.Spec.Parameters[util.CloneParameterRotateCipherPass]
 	job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_SOURCE_CLUSTER_NAME] = sourcePgcluster.Spec.ClusterName
+	job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_SOURCE_NAMESPACE] = sourceNamespace
 	job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_TARGET_CLUSTER_NAME] = targetClusterName
+	job.ObjectMeta.Annotations[config.ANNOTATION_CLONE_TTL] = task.Spec.Parameters[util.CloneParameterTTL]
 	// also add the label to indicate this is also part of a clone job!
 	if job.ObjectMeta.Labels == nil {
 		job.ObjectMeta.Labels = map[string]string{}
@@ -417,7 +454,7 @@ func cloneStep2(clientset *kubernetes.Clientset, client *rest.RESTClient, namesp
 	job.ObjectMeta.Labels[config.LABEL_PGOUSER] = task.ObjectMeta.Labels[config.LABEL_PGOUSER]
 
 	// create the Job in Kubernetes
-	if jobName, err := kubeapi.CreateJob(clientset, &job, namespace); err != nil {
+	if jobName, err := kubeapi.CreateJob(clientset, job, namespace); err != nil {
 		log.Error(err)
 		errorMessage := fmt.Sprintf("Could not create pgbackrest restore job: %s", err.Error())
 		PublishCloneEvent(events.EventCloneClusterFailure, namespace, task, errorMessage)
@@ -429,16 +466,99 @@ func cloneStep2(clientset *kubernetes.Clientset, client *rest.RESTClient, namesp
 	patchPgtaskComplete(client, namespace, task.Spec.Name)
 }
 
+// newCloneBackrestRestoreJob returns the job that performs the pgBackRest
+// restore of the source cluster into the PGDATA PVC of the target cluster
+func newCloneBackrestRestoreJob(clientset *kubernetes.Clientset, task *crv1.Pgtask, sourcePgcluster crv1.Pgcluster,
+	namespace, targetClusterName, workflowID string) (*batch_v1.Job, error) {
+	// the S3, GCS and Azure credentials are read from the pgBackRest repo secret
+	// of the target cluster, which was created above with the credentials of the
+	// source cluster. This allows for the restore job to run in a different
+	// namespace than the source cluster
+	repoPgcluster := sourcePgcluster
+	repoPgcluster.ObjectMeta.Name = targetClusterName
+	repoPgcluster.ObjectMeta.Namespace = namespace
+
+	backrestRestoreJobFields := backrest.BackrestRestoreJobTemplateFields{
+		JobName:     fmt.Sprintf("restore-%s-%s", targetClusterName, util.RandStringBytesRmndr(4)),
+		ClusterName: targetClusterName,
+		SecurityContext: util.GetPodSecurityContext(
+			sourcePgcluster.Spec.PrimaryStorage.GetSupplementalGroups()),
+		ToClusterPVCName: targetClusterName, // the PVC name should match that of the target cluster
+		WorkflowID:       workflowID,
+		// use a delta restore in order to optimize how the restore occurs, along
+		// with any backup set or recovery target that was requested
+		CommandOpts:             getCloneRestoreCommandOpts(task),
+		PITRTarget:              task.Spec.Parameters[util.CloneParameterRecoveryTarget],
+		PGOImagePrefix:          operator.Pgo.Pgo.PGOImagePrefix,
+		PGOImageTag:             operator.Pgo.Pgo.PGOImageTag,
+		PgbackrestStanza:        pgBackRestStanza,
+		PgbackrestDBPath:        fmt.Sprintf(targetClusterPGDATAPath, targetClusterName),
+		PgbackrestRepo1Path:     util.GetPGBackRestRepoPath(sourcePgcluster),
+		PgbackrestRepo1Host:     fmt.Sprintf(backrest.BackrestRepoServiceName, targetClusterName),
+		PgbackrestRepoType:      operator.GetRepoType(task.Spec.Parameters["backrestStorageType"]),
+		PgbackrestS3EnvVars:     operator.GetPgbackrestS3EnvVars(repoPgcluster, clientset, namespace),
+		PgbackrestGCSEnvVars:    operator.GetPgbackrestGCSEnvVars(repoPgcluster),
+		PgbackrestAzureEnvVars:  operator.GetPgbackrestAzureEnvVars(repoPgcluster),
+		PgbackrestCipherEnvVars: operator.GetPgbackrestCipherEnvVars(repoPgcluster),
+	}
+
+	// substitute the variables into the BackrestRestore job template
+	var backrestRestoreJobDoc bytes.Buffer
+
+	if err := config.BackrestRestorejobTemplate.Execute(&backrestRestoreJobDoc, backrestRestoreJobFields); err != nil {
+		return nil, err
+	}
+
+	// create the pgBackRest restore job!
+	job := &batch_v1.Job{}
+
+	if err := json.Unmarshal(backrestRestoreJobDoc.Bytes(), job); err != nil {
+		return nil, err
+	}
+
+	// set the container image to an override value, if one exists
+	operator.SetContainerImageOverride(config.CONTAINER_IMAGE_PGO_BACKREST_RESTORE,
+		&job.Spec.Template.Spec.Containers[0])
+
+	return job, nil
+}
+
+// newCloneSnapshotRestoreJob returns the job that prepares the PGDATA PVC of the
+// target cluster, which was provisioned from a snapshot backup of the source
+// cluster, to replay the WAL from the pgBackRest repository up to any recovery
+// target that was requested
+func newCloneSnapshotRestoreJob(task *crv1.Pgtask, sourcePgcluster crv1.Pgcluster,
+	targetClusterName, workflowID string) (*batch_v1.Job, error) {
+	snapshot, found := snapshotoperator.FindSnapshot(&sourcePgcluster, task.Spec.Parameters[util.CloneParameterSnapshot])
+	if !found {
+		return nil, fmt.Errorf("snapshot backup %s not found for cluster %s",
+			task.Spec.Parameters[util.CloneParameterSnapshot], sourcePgcluster.Spec.ClusterName)
+	}
+
+	return snapshotoperator.RestoreJob{
+		Cluster:            &sourcePgcluster,
+		Snapshot:           snapshot,
+		ClusterName:        targetClusterName,
+		PVCName:            targetClusterName,
+		EnableRecovery:     true,
+		RecoveryTarget:     task.Spec.Parameters[util.CloneParameterRecoveryTarget],
+		RecoveryTargetType: task.Spec.Parameters[util.CloneParameterRecoveryTargetType],
+		WorkflowID:         workflowID,
+	}.Job()
+}
+
 // cloneStep3 creates the new cluster by creating a new Pgcluster
 func cloneStep3(clientset *kubernetes.Clientset, client *rest.RESTClient, namespace string, task *crv1.Pgtask) {
 	sourceClusterName, targetClusterName, workflowID := getCloneTaskIdentifiers(task)
 
-	log.Debugf("clone step 3 called: namespace:[%s] sourcecluster:[%s] targetcluster:[%s] workflowid:[%s]",
-		namespace, sourceClusterName, targetClusterName, workflowID)
+	sourceNamespace := getCloneSourceNamespace(task, namespace)
+
+	log.Debugf("clone step 3 called: namespace:[%s] sourcenamespace:[%s] sourcecluster:[%s] targetcluster:[%s] workflowid:[%s]",
+		namespace, sourceNamespace, sourceClusterName, targetClusterName, workflowID)
 
 	// get the information about the current pgcluster by name, to ensure we can
 	// copy over some of the necessary cluster attributes
-	sourcePgcluster, err := getSourcePgcluster(client, namespace, sourceClusterName)
+	sourcePgcluster, err := getSourcePgcluster(client, sourceNamespace, sourceClusterName)
 
 	// if there is an error getting the pgcluster, abort here
 	if err != nil {
@@ -464,6 +584,26 @@ func cloneStep3(clientset *kubernetes.Clientset, client *rest.RESTClient, namesp
 		return
 	}
 
+	// if a new passphrase was requested for the encrypted pgBackRest repository,
+	// the repository needs to start from scratch, as pgBackRest does not allow the
+	// passphrase of an existing stanza to be changed. The repository of the new
+	// cluster will then be initialized with a new stanza that uses the new
+	// passphrase
+	if task.Spec.Parameters[util.CloneParameterRotateCipherPass] == "true" {
+		if err := rotateCipherPass(clientset, namespace, targetClusterName); err != nil {
+			log.Error(err)
+			errorMessage := fmt.Sprintf("Could not rotate pgbackrest cipher passphrase: %s", err.Error())
+			PublishCloneEvent(events.EventCloneClusterFailure, namespace, task, errorMessage)
+			return
+		}
+	}
+
+	// if the source repository credentials were copied into the target namespace
+	// for the repo sync, they are no longer needed. Again, ignore any errors
+	if sourceNamespace != namespace {
+		_ = kubeapi.DeleteSecret(clientset, fmt.Sprintf(pgBackRestRepoSyncSecretName, targetClusterName), namespace)
+	}
+
 	// and go forth and create the cluster!
 	if err := createCluster(clientset, client, task, sourcePgcluster, namespace, targetClusterName, workflowID); err != nil {
 		log.Error(err)
@@ -478,6 +618,32 @@ func cloneStep3(clientset *kubernetes.Clientset, client *rest.RESTClient, namesp
 	patchPgtaskComplete(client, namespace, task.Spec.Name)
 }
 
+// rotateCipherPass removes the pgBackRest repository that was used to restore
+// the target cluster, and stores a newly generated passphrase in the pgBackRest
+// repo secret of the target cluster. The PVC for the repository is then
+// recreated when the target cluster is created. Any S3, GCS or Azure repository
+// of the target cluster is kept in the default path for the target cluster, and
+// not in the one of the source cluster, so its stanza is created with the new
+// passphrase as well
+func rotateCipherPass(clientset *kubernetes.Clientset, namespace, targetClusterName string) error {
+	pvcName := fmt.Sprintf(backrest.BackrestRepoPVCName, targetClusterName)
+
+	if err := kubeapi.DeletePVC(clientset, pvcName, namespace); err != nil {
+		return err
+	}
+
+	if err := kubeapi.IsPVCDeleted(clientset, 90*time.Second, pvcName, namespace); err != nil {
+		return err
+	}
+
+	cipherPass, err := util.GenerateBackrestCipherPass()
+	if err != nil {
+		return err
+	}
+
+	return util.SetCipherPassInBackrestRepoSecret(clientset, namespace, targetClusterName, cipherPass)
+}
+
 // createPgBackRestRepoSyncJob prepares and creates the job that will use
 // rsync to synchronize two pgBackRest repositories, i.e. it will copy the files
 // from the source PostgreSQL cluster to the pgBackRest repository in the target
@@ -485,6 +651,18 @@ func cloneStep3(clientset *kubernetes.Clientset, client *rest.RESTClient, namesp
 func createPgBackRestRepoSyncJob(clientset *kubernetes.Clientset, namespace string, task *crv1.Pgtask, sourcePgcluster crv1.Pgcluster) (string, error) {
 	targetClusterName := task.Spec.Parameters["targetClusterName"]
 	workflowID := task.Spec.Parameters[crv1.PgtaskWorkflowID]
+	sourceNamespace := getCloneSourceNamespace(task, namespace)
+	// the source pgBackRest repository is accessed from the target namespace. If
+	// the source cluster is in a different namespace, the repository host needs
+	// to be qualified with the namespace, and the SSH credentials are in the
+	// secret that was copied over for the repo sync
+	sourceRepoHost := fmt.Sprintf(backrest.BackrestRepoServiceName, sourcePgcluster.Spec.ClusterName)
+	sourceRepoSecretName := fmt.Sprintf("%s-%s", sourcePgcluster.Spec.ClusterName, config.LABEL_BACKREST_REPO_SECRET)
+
+	if sourceNamespace != namespace {
+		sourceRepoHost = fmt.Sprintf("%s.%s", sourceRepoHost, sourceNamespace)
+		sourceRepoSecretName = fmt.Sprintf(pgBackRestRepoSyncSecretName, targetClusterName)
+	}
 	// set the name of the job, with the "entropy" that we add
 	jobName := fmt.Sprintf(pgBackRestRepoSyncJobNamePrefix, targetClusterName, util.RandStringBytesRmndr(4))
 	// we set the PodSecurityContext if the storageclass has additional
@@ -514,11 +692,18 @@ func createPgBackRestRepoSyncJob(clientset *kubernetes.Clientset, namespace stri
 			Annotations: map[string]string{
 				// these annotations are used for the subsequent steps to be
 				// able to identify how to connect these jobs
-				config.ANNOTATION_CLONE_BACKREST_PVC_SIZE:   task.Spec.Parameters[util.CloneParameterBackrestPVCSize],
-				config.ANNOTATION_CLONE_ENABLE_METRICS:      task.Spec.Parameters[util.CloneParameterEnableMetrics],
-				config.ANNOTATION_CLONE_PVC_SIZE:            task.Spec.Parameters[util.CloneParameterPVCSize],
-				config.ANNOTATION_CLONE_SOURCE_CLUSTER_NAME: sourcePgcluster.Spec.ClusterName,
-				config.ANNOTATION_CLONE_TARGET_CLUSTER_NAME: targetClusterName,
+				config.ANNOTATION_CLONE_BACKREST_PVC_SIZE:    task.Spec.Parameters[util.CloneParameterBackrestPVCSize],
+				config.ANNOTATION_CLONE_BACKUP_SET:           task.Spec.Parameters[util.CloneParameterBackupSet],
+				config.ANNOTATION_CLONE_ENABLE_METRICS:       task.Spec.Parameters[util.CloneParameterEnableMetrics],
+				config.ANNOTATION_CLONE_PVC_SIZE:             task.Spec.Parameters[util.CloneParameterPVCSize],
+				config.ANNOTATION_CLONE_RECOVERY_TARGET:      task.Spec.Parameters[util.CloneParameterRecoveryTarget],
+				config.ANNOTATION_CLONE_RECOVERY_TARGET_TYPE: task.Spec.Parameters[util.CloneParameterRecoveryTargetType],
+				config.ANNOTATION_CLONE_ROTATE_CIPHER_PASS:   task.Spec.Parameters[util.CloneParameterRotateCipherPass],
+				config.ANNOTATION_CLONE_SNAPSHOT:             task.Spec.Parameters[util.CloneParameterSnapshot],
+				config.ANNOTATION_CLONE_SOURCE_CLUSTER_NAME:  sourcePgcluster.Spec.ClusterName,
+				config.ANNOTATION_CLONE_SOURCE_NAMESPACE:     sourceNamespace,
+				config.ANNOTATION_CLONE_TARGET_CLUSTER_NAME:  targetClusterName,
+				config.ANNOTATION_CLONE_TTL:                  task.Spec.Parameters[util.CloneParameterTTL],
 			},
 			Labels: map[string]string{
 				config.LABEL_VENDOR:           config.LABEL_CRUNCHY,
@@ -551,7 +736,7 @@ func createPgBackRestRepoSyncJob(clientset *kubernetes.Clientset, namespace stri
 							Env: []v1.EnvVar{
 								v1.EnvVar{
 									Name:  "PGBACKREST_REPO1_HOST",
-									Value: fmt.Sprintf(backrest.BackrestRepoServiceName, sourcePgcluster.Spec.ClusterName),
+									Value: sourceRepoHost,
 								},
 								v1.EnvVar{
 									Name:  "PGBACKREST_REPO1_PATH",
@@ -600,7 +785,7 @@ func createPgBackRestRepoSyncJob(clientset *kubernetes.Clientset, namespace stri
 								Secret: &v1.SecretVolumeSource{
 									// the SSHD secret is stored under the name of the *source*
 									// cluster, as we have yet to create the target cluster!
-									SecretName: fmt.Sprintf("%s-backrest-repo-config", sourcePgcluster.Spec.ClusterName),
+									SecretName: sourceRepoSecretName,
 									// DefaultMode: &pgBackRestRepoVolumeDefaultMode,
 								},
 							},
@@ -615,63 +800,16 @@ func createPgBackRestRepoSyncJob(clientset *kubernetes.Clientset, namespace stri
 	operator.SetContainerImageOverride(config.CONTAINER_IMAGE_PGO_BACKREST_REPO_SYNC,
 		&job.Spec.Template.Spec.Containers[0])
 
-	// Retrieve current S3 key & key secret
-	s3Creds, err := util.GetS3CredsFromBackrestRepoSecret(clientset, namespace, sourcePgcluster.Name)
+	// add the settings for the cloud storage that is used by the source cluster,
+	// if any, to the environment of the sync job
+	syncEnv, err := getRepoSyncCloudStorageEnv(clientset, sourceNamespace, task, sourcePgcluster)
 	if err != nil {
 		log.Error(err)
-		errorMessage := fmt.Sprintf("Unable to get S3 key and key secret from source cluster "+
-			"backrest repo secret: %s", err.Error())
-		PublishCloneEvent(events.EventCloneClusterFailure, namespace, task, errorMessage)
+		PublishCloneEvent(events.EventCloneClusterFailure, namespace, task, err.Error())
 		return "", err
 	}
-	// if using S3 for the clone, the add the S3 env vars to the env
-	if strings.Contains(sourcePgcluster.Spec.UserLabels[config.LABEL_BACKREST_STORAGE_TYPE],
-		"s3") {
-		syncEnv := job.Spec.Template.Spec.Containers[0].Env
-		syncEnv = append(syncEnv, []v1.EnvVar{
-			v1.EnvVar{
-				Name:  "BACKREST_STORAGE_SOURCE",
-				Value: task.Spec.Parameters["backrestStorageType"],
-			},
-			v1.EnvVar{
-				Name: "PGBACKREST_REPO1_S3_BUCKET",
-				Value: getS3Param(sourcePgcluster.Spec.BackrestS3Bucket,
-					operator.Pgo.Cluster.BackrestS3Bucket),
-			},
-			v1.EnvVar{
-				Name: "PGBACKREST_REPO1_S3_ENDPOINT",
-				Value: getS3Param(sourcePgcluster.Spec.BackrestS3Endpoint,
-					operator.Pgo.Cluster.BackrestS3Endpoint),
-			},
-			v1.EnvVar{
-				Name: "PGBACKREST_REPO1_S3_REGION",
-				Value: getS3Param(sourcePgcluster.Spec.BackrestS3Region,
-					operator.Pgo.Cluster.BackrestS3Region),
-			},
-			v1.EnvVar{
-				Name:  "PGBACKREST_REPO1_S3_KEY",
-				Value: s3Creds.AWSS3Key,
-			},
-			v1.EnvVar{
-				Name:  "PGBACKREST_REPO1_S3_KEY_SECRET",
-				Value: s3Creds.AWSS3KeySecret,
-			},
-			v1.EnvVar{
-				Name:  "PGBACKREST_REPO1_S3_CA_FILE",
-				Value: "/sshd/aws-s3-ca.crt",
-			},
-		}...)
-		if operator.IsLocalAndS3Storage(
-			sourcePgcluster.Spec.UserLabels[config.LABEL_BACKREST_STORAGE_TYPE]) {
-			syncEnv = append(syncEnv, []v1.EnvVar{
-				v1.EnvVar{
-					Name:  "PGHA_PGBACKREST_LOCAL_S3_STORAGE",
-					Value: "true",
-				},
-			}...)
-		}
-		job.Spec.Template.Spec.Containers[0].Env = syncEnv
-	}
+	job.Spec.Template.Spec.Containers[0].Env = append(job.Spec.Template.Spec.Containers[0].Env,
+		syncEnv...)
 
 	// create the job!
 	if jobName, err := kubeapi.CreateJob(clientset, &job, namespace); err != nil {
@@ -693,8 +831,11 @@ func createPgBackRestRepoSyncJob(clientset *kubernetes.Clientset, namespace stri
 //
 // if the user spceified a different PVCSize than what is in the storage spec,
 // then that gets passed to the "createPVC" function
+//
+// if the clone is made from a snapshot backup, the PVCs for the PGDATA and the
+// tablespaces are provisioned from the VolumeSnapshots of the snapshot backup
 func createPVCs(clientset *kubernetes.Clientset, client *rest.RESTClient,
-	task *crv1.Pgtask, namespace string, sourcePgcluster crv1.Pgcluster, targetClusterName string) {
+	task *crv1.Pgtask, namespace string, sourcePgcluster crv1.Pgcluster, targetClusterName string) error {
 	// first, create the PVC for the pgBackRest storage, as we will be needing
 	// that sooner
 	CreatePVC{
@@ -708,6 +849,20 @@ func createPVCs(clientset *kubernetes.Clientset, client *rest.RESTClient,
 		Storage:    sourcePgcluster.Spec.BackrestStorage,
 	}.createPVC()
 
+	// if the clone is made from a snapshot backup, provision the rest of the
+	// PVCs from its VolumeSnapshots
+	if snapshotName := task.Spec.Parameters[util.CloneParameterSnapshot]; snapshotName != "" {
+		snapshot, found := snapshotoperator.FindSnapshot(&sourcePgcluster, snapshotName)
+		if !found {
+			return fmt.Errorf("snapshot backup %s not found for cluster %s", snapshotName,
+				sourcePgcluster.Spec.ClusterName)
+		}
+
+		return snapshotoperator.CreatePVCs(clientset, snapshot, targetClusterName, targetClusterName,
+			task.Spec.Parameters[util.CloneParameterPVCSize], sourcePgcluster.Spec.PrimaryStorage,
+			sourcePgcluster.Spec.TablespaceMounts, namespace)
+	}
+
 	// now create the PVC for the target cluster
 	CreatePVC{
 		Clientset:   clientset,
@@ -737,6 +892,8 @@ func createPVCs(clientset *kubernetes.Clientset, client *rest.RESTClient,
 			Storage:    storageSpec,
 		}.createPVC()
 	}
+
+	return nil
 }
 
 func createCluster(clientset *kubernetes.Clientset, client *rest.RESTClient, task *crv1.Pgtask, sourcePgcluster crv1.Pgcluster, namespace string, targetClusterName string, workflowID string) error {
@@ -747,9 +904,10 @@ func createCluster(clientset *kubernetes.Clientset, client *rest.RESTClient, tas
 		// initialize a new repository
 		AdditionalSelectors: []string{"pgo-backrest-repo!=true"},
 		ClientSet:           clientset,
-		Namespace:           namespace,
+		Namespace:           getCloneSourceNamespace(task, namespace),
 		SourceClusterName:   sourcePgcluster.Spec.ClusterName,
 		TargetClusterName:   targetClusterName,
+		TargetNamespace:     namespace,
 	}
 
 	if err := cloneClusterSecrets.Clone(); err != nil {
@@ -777,14 +935,22 @@ func createCluster(clientset *kubernetes.Clientset, client *rest.RESTClient, tas
 			},
 		},
 		Spec: crv1.PgclusterSpec{
-			ArchiveStorage:     sourcePgcluster.Spec.ArchiveStorage,
-			BackrestStorage:    sourcePgcluster.Spec.BackrestStorage,
-			BackrestS3Bucket:   sourcePgcluster.Spec.BackrestS3Bucket,
-			BackrestS3Endpoint: sourcePgcluster.Spec.BackrestS3Endpoint,
-			BackrestS3Region:   sourcePgcluster.Spec.BackrestS3Region,
-			ClusterName:        targetClusterName,
-			CCPImage:           sourcePgcluster.Spec.CCPImage,
-			CCPImageTag:        sourcePgcluster.Spec.CCPImageTag,
+			ArchiveStorage:           sourcePgcluster.Spec.ArchiveStorage,
+			BackrestStorage:          sourcePgcluster.Spec.BackrestStorage,
+			BackrestS3Bucket:         sourcePgcluster.Spec.BackrestS3Bucket,
+			BackrestS3Endpoint:       sourcePgcluster.Spec.BackrestS3Endpoint,
+			BackrestS3Region:         sourcePgcluster.Spec.BackrestS3Region,
+			BackrestGCSBucket:        sourcePgcluster.Spec.BackrestGCSBucket,
+			BackrestGCSEndpoint:      sourcePgcluster.Spec.BackrestGCSEndpoint,
+			BackrestGCSKeyType:       sourcePgcluster.Spec.BackrestGCSKeyType,
+			BackrestAzureContainer:   sourcePgcluster.Spec.BackrestAzureContainer,
+			BackrestAzureEndpoint:    sourcePgcluster.Spec.BackrestAzureEndpoint,
+			BackrestAzureURIStyle:    sourcePgcluster.Spec.BackrestAzureURIStyle,
+			BackrestStorageVerifyTLS: sourcePgcluster.Spec.BackrestStorageVerifyTLS,
+			BackrestCipherType:       sourcePgcluster.Spec.BackrestCipherType,
+			ClusterName:              targetClusterName,
+			CCPImage:                 sourcePgcluster.Spec.CCPImage,
+			CCPImageTag:              sourcePgcluster.Spec.CCPImageTag,
 			// We're not copying over the collect container in the clone...but we will
 			// maintain the secret in case one brings up the collect container
 			CollectSecretName:  fmt.Sprintf("%s%s", targetClusterName, crv1.CollectSecretSuffix),
@@ -849,6 +1015,19 @@ func createCluster(clientset *kubernetes.Clientset, client *rest.RESTClient, tas
 		targetPgcluster.Spec.UserLabels[config.LABEL_COLLECT] = "true"
 	}
 
+	// if the clone has a TTL, it counts from when the cluster is created. The
+	// TTL was validated by the apiserver
+	if task.Spec.Parameters[util.CloneParameterTTL] != "" {
+		ttl, err := time.ParseDuration(task.Spec.Parameters[util.CloneParameterTTL])
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+
+		expirationTime := meta_v1.NewTime(time.Now().Add(ttl))
+		targetPgcluster.Spec.ExpirationTime = &expirationTime
+	}
+
 	// update the workflow to indicate that the cluster is being created
 	if err := UpdateCloneWorkflow(client, namespace, workflowID, crv1.PgtaskWorkflowCloneClusterCreate); err != nil {
 		log.Error(err)
@@ -884,16 +1063,196 @@ func getCloneTaskIdentifiers(task *crv1.Pgtask) (string, string, string) {
 		task.Spec.Parameters[crv1.PgtaskWorkflowID]
 }
 
-// getS3Param returns either the value provided by 'sourceClusterS3param' if not en empty string,
-// otherwise return the equivlant value from the pgo.yaml global configuration filer
-func getS3Param(sourceClusterS3param, pgoConfigParam string) string {
-	if sourceClusterS3param != "" {
-		return sourceClusterS3param
+// getCloneRestoreCommandOpts returns the options that are passed to the
+// pgBackRest restore that is performed as part of the clone. A delta restore is
+// always used, and if a specific backup set or recovery target is requested,
+// the appropriate options are added. As the clone becomes a new primary, it is
+// promoted once the recovery target is reached
+func getCloneRestoreCommandOpts(task *crv1.Pgtask) string {
+	opts := []string{"--delta"}
+
+	if backupSet := task.Spec.Parameters[util.CloneParameterBackupSet]; backupSet != "" {
+		opts = append(opts, fmt.Sprintf("--set=%s", backupSet))
+	}
+
+	if targetType := task.Spec.Parameters[util.CloneParameterRecoveryTargetType]; targetType != "" {
+		opts = append(opts, fmt.Sprintf("--type=%s", targetType), "--target-action=promote")
+	}
+
+	return strings.Join(opts, " ")
+}
+
+// getCloneSourceNamespace returns the namespace of the source cluster of the
+// clone. If it is not set on the task (e.g. a task from an earlier version),
+// the source cluster is in the same namespace as the task
+func getCloneSourceNamespace(task *crv1.Pgtask, namespace string) string {
+	if sourceNamespace := task.Spec.Parameters[util.CloneParameterSourceNamespace]; sourceNamespace != "" {
+		return sourceNamespace
+	}
+
+	return namespace
+}
+
+// getBackrestStorageParam returns either the value provided by 'sourceClusterParam' if not en
+// empty string, otherwise return the equivlant value from the pgo.yaml global configuration file
+func getBackrestStorageParam(sourceClusterParam, pgoConfigParam string) string {
+	if sourceClusterParam != "" {
+		return sourceClusterParam
 	}
 
 	return pgoConfigParam
 }
 
+// getRepoSyncCloudStorageEnv returns the environmental variables that the repo
+// sync job requires to access the S3, GCS or Azure storage of the source
+// cluster. If the source cluster only uses local storage, nothing is returned
+func getRepoSyncCloudStorageEnv(clientset *kubernetes.Clientset, sourceNamespace string,
+	task *crv1.Pgtask, sourcePgcluster crv1.Pgcluster) ([]v1.EnvVar, error) {
+	storageType := sourcePgcluster.Spec.UserLabels[config.LABEL_BACKREST_STORAGE_TYPE]
+	cloudStorageType := util.GetBackrestCloudStorageType(storageType)
+
+	if cloudStorageType == "" {
+		return []v1.EnvVar{}, nil
+	}
+
+	syncEnv := []v1.EnvVar{
+		v1.EnvVar{
+			Name:  "BACKREST_STORAGE_SOURCE",
+			Value: task.Spec.Parameters["backrestStorageType"],
+		},
+		v1.EnvVar{
+			Name:  "BACKREST_CLOUD_STORAGE",
+			Value: cloudStorageType,
+		},
+	}
+
+	switch cloudStorageType {
+	case "s3":
+		// Retrieve current S3 key & key secret
+		s3Creds, err := util.GetS3CredsFromBackrestRepoSecret(clientset, sourceNamespace, sourcePgcluster.Name)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to get S3 key and key secret from source cluster "+
+				"backrest repo secret: %s", err.Error())
+		}
+
+		syncEnv = append(syncEnv, []v1.EnvVar{
+			v1.EnvVar{
+				Name: "PGBACKREST_REPO1_S3_BUCKET",
+				Value: getBackrestStorageParam(sourcePgcluster.Spec.BackrestS3Bucket,
+					operator.Pgo.Cluster.BackrestS3Bucket),
+			},
+			v1.EnvVar{
+				Name: "PGBACKREST_REPO1_S3_ENDPOINT",
+				Value: getBackrestStorageParam(sourcePgcluster.Spec.BackrestS3Endpoint,
+					operator.Pgo.Cluster.BackrestS3Endpoint),
+			},
+			v1.EnvVar{
+				Name: "PGBACKREST_REPO1_S3_REGION",
+				Value: getBackrestStorageParam(sourcePgcluster.Spec.BackrestS3Region,
+					operator.Pgo.Cluster.BackrestS3Region),
+			},
+			v1.EnvVar{
+				Name:  "PGBACKREST_REPO1_S3_KEY",
+				Value: s3Creds.AWSS3Key,
+			},
+			v1.EnvVar{
+				Name:  "PGBACKREST_REPO1_S3_KEY_SECRET",
+				Value: s3Creds.AWSS3KeySecret,
+			},
+			v1.EnvVar{
+				Name:  "PGBACKREST_REPO1_S3_CA_FILE",
+				Value: "/sshd/aws-s3-ca.crt",
+			},
+		}...)
+	case "gcs":
+		keyType := getBackrestStorageParam(sourcePgcluster.Spec.BackrestGCSKeyType,
+			operator.Pgo.Cluster.BackrestGCSKeyType)
+		// the service account key is available in the SSHD volume of the job,
+		// whereas a token needs to be passed in directly
+		key := "/sshd/" + util.BackRestRepoSecretKeyGCSKey
+
+		if keyType == "token" {
+			gcsCreds, err := util.GetGCSCredsFromBackrestRepoSecret(clientset, sourceNamespace, sourcePgcluster.Name)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to get GCS key from source cluster "+
+					"backrest repo secret: %s", err.Error())
+			}
+			key = gcsCreds.GCSKey
+		}
+
+		syncEnv = append(syncEnv, []v1.EnvVar{
+			v1.EnvVar{
+				Name: "PGBACKREST_REPO1_GCS_BUCKET",
+				Value: getBackrestStorageParam(sourcePgcluster.Spec.BackrestGCSBucket,
+					operator.Pgo.Cluster.BackrestGCSBucket),
+			},
+			v1.EnvVar{
+				Name: "PGBACKREST_REPO1_GCS_ENDPOINT",
+				Value: getBackrestStorageParam(sourcePgcluster.Spec.BackrestGCSEndpoint,
+					operator.Pgo.Cluster.BackrestGCSEndpoint),
+			},
+			v1.EnvVar{
+				Name:  "PGBACKREST_REPO1_GCS_KEY_TYPE",
+				Value: keyType,
+			},
+			v1.EnvVar{
+				Name:  "PGBACKREST_REPO1_GCS_KEY",
+				Value: key,
+			},
+			v1.EnvVar{
+				Name:  "PGBACKREST_REPO1_STORAGE_VERIFY_TLS",
+				Value: operator.GetBackrestStorageVerifyTLS(sourcePgcluster),
+			},
+		}...)
+	case "azure":
+		azureCreds, err := util.GetAzureCredsFromBackrestRepoSecret(clientset, sourceNamespace, sourcePgcluster.Name)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to get Azure account and key from source cluster "+
+				"backrest repo secret: %s", err.Error())
+		}
+
+		syncEnv = append(syncEnv, []v1.EnvVar{
+			v1.EnvVar{
+				Name: "PGBACKREST_REPO1_AZURE_CONTAINER",
+				Value: getBackrestStorageParam(sourcePgcluster.Spec.BackrestAzureContainer,
+					operator.Pgo.Cluster.BackrestAzureContainer),
+			},
+			v1.EnvVar{
+				Name: "PGBACKREST_REPO1_AZURE_ENDPOINT",
+				Value: getBackrestStorageParam(sourcePgcluster.Spec.BackrestAzureEndpoint,
+					operator.Pgo.Cluster.BackrestAzureEndpoint),
+			},
+			v1.EnvVar{
+				Name: "PGBACKREST_REPO1_AZURE_URI_STYLE",
+				Value: getBackrestStorageParam(sourcePgcluster.Spec.BackrestAzureURIStyle,
+					operator.Pgo.Cluster.BackrestAzureURIStyle),
+			},
+			v1.EnvVar{
+				Name:  "PGBACKREST_REPO1_AZURE_ACCOUNT",
+				Value: azureCreds.AzureAccount,
+			},
+			v1.EnvVar{
+				Name:  "PGBACKREST_REPO1_AZURE_KEY",
+				Value: azureCreds.AzureKey,
+			},
+			v1.EnvVar{
+				Name:  "PGBACKREST_REPO1_STORAGE_VERIFY_TLS",
+				Value: operator.GetBackrestStorageVerifyTLS(sourcePgcluster),
+			},
+		}...)
+	}
+
+	// indicate if local storage is enabled along with the cloud storage
+	if strings.Contains(storageType, "local") {
+		syncEnv = append(syncEnv, v1.EnvVar{
+			Name:  fmt.Sprintf("PGHA_PGBACKREST_LOCAL_%s_STORAGE", strings.ToUpper(cloudStorageType)),
+			Value: "true",
+		})
+	}
+
+	return syncEnv, nil
+}
+
 // getSourcePgcluster attempts to find the Pgcluster CRD for the source cluster
 // used for the clone
 func getSourcePgcluster(client *rest.RESTClient, namespace, sourceClusterName string) (crv1.Pgcluster, error) {
@@ -905,6 +1264,33 @@ func getSourcePgcluster(client *rest.RESTClient, namespace, sourceClusterName st
 	return sourcePgcluster, err
 }
 
+// createRepoSyncSecret copies the pgBackRest repository secret of the source
+// cluster into the namespace of the target cluster, so the repo sync job is
+// able to connect to the source pgBackRest repository. The copy is labeled with
+// the target cluster so it is cleaned up along with it
+func createRepoSyncSecret(clientset *kubernetes.Clientset, sourceNamespace, namespace, sourceClusterName, targetClusterName string) error {
+	secretName := fmt.Sprintf("%s-%s", sourceClusterName, config.LABEL_BACKREST_REPO_SECRET)
+
+	sourceSecret, _, err := kubeapi.GetSecret(clientset, secretName, sourceNamespace)
+
+	if err != nil {
+		return err
+	}
+
+	secret := v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: fmt.Sprintf(pgBackRestRepoSyncSecretName, targetClusterName),
+			Labels: map[string]string{
+				config.LABEL_VENDOR:     config.LABEL_CRUNCHY,
+				config.LABEL_PG_CLUSTER: targetClusterName,
+			},
+		},
+		Data: sourceSecret.Data,
+	}
+
+	return kubeapi.CreateSecret(clientset, &secret, namespace)
+}
+
 // patchPgtaskComplete updates the pgtask CRD to indicate that the task is now
 // complete
 func patchPgtaskComplete(client *rest.RESTClient, namespace, taskName string) {
@@ -30,6 +30,7 @@ import (
 	"github.com/crunchydata/postgres-operator/kubeapi"
 	"github.com/crunchydata/postgres-operator/operator"
 	"github.com/crunchydata/postgres-operator/operator/pvc"
+	snapshotoperator "github.com/crunchydata/postgres-operator/operator/snapshot"
 	"github.com/crunchydata/postgres-operator/util"
 	log "github.com/sirupsen/logrus"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -46,6 +47,11 @@ type ServiceTemplateFields struct {
 	PGBadgerPort string
 	ExporterPort string
 	ServiceType  string
+	// Annotations, CustomLabels and LoadBalancerSourceRanges are JSON, see
+	// setServiceMetadata
+	Annotations              string
+	CustomLabels             string
+	LoadBalancerSourceRanges string
 }
 
 // ReplicaSuffix ...
@@ -101,6 +107,14 @@ func AddClusterBase(clientset *kubernetes.Clientset, client *rest.RESTClient, cl
 		}
 	}
 
+	// create the NetworkPolicies of the cluster, if requested, before any of
+	// its pods so that they are never reachable by other clients
+	if err := ReconcileNetworkPolicies(clientset, cl); err != nil {
+		log.Error(err)
+		publishClusterCreateFailure(cl, err.Error())
+		return
+	}
+
 	//replaced with ccpimagetag instead of pg version
 
 	AddCluster(clientset, client, cl, namespace, pvcName)
@@ -145,46 +159,19 @@ func AddClusterBase(clientset *kubernetes.Clientset, client *rest.RESTClient, cl
 			publishClusterCreateFailure(cl, err.Error())
 			return
 		}
-		//create a CRD for each replica
-		for i := 0; i < replicaCount; i++ {
-			spec := crv1.PgreplicaSpec{}
-			//get the resource config
-			spec.ContainerResources = cl.Spec.ContainerResources
-			//get the storage config
-			spec.ReplicaStorage = cl.Spec.ReplicaStorage
-
-			spec.UserLabels = cl.Spec.UserLabels
-
-			//the replica should not use the same node labels as the primary
-			spec.UserLabels[config.LABEL_NODE_LABEL_KEY] = ""
-			spec.UserLabels[config.LABEL_NODE_LABEL_VALUE] = ""
-
-			//check for replica node label in pgo.yaml
-			if operator.Pgo.Cluster.ReplicaNodeLabel != "" {
-				parts := strings.Split(operator.Pgo.Cluster.ReplicaNodeLabel, "=")
-				spec.UserLabels[config.LABEL_NODE_LABEL_KEY] = parts[0]
-				spec.UserLabels[config.LABEL_NODE_LABEL_VALUE] = parts[1]
-				log.Debug("using pgo.yaml ReplicaNodeLabel for replica creation")
-			}
+		// pgreplicas that already exist, e.g. as they were applied along with
+		// the pgcluster, count towards the replicas requested
+		replicaList := crv1.PgreplicaList{}
+		if err := kubeapi.GetpgreplicasBySelector(client, &replicaList,
+			config.LABEL_PG_CLUSTER+"="+cl.Spec.Name, namespace); err != nil {
+			log.Error(err)
+			publishClusterCreateFailure(cl, err.Error())
+			return
+		}
 
-			labels := make(map[string]string)
-			labels[config.LABEL_PG_CLUSTER] = cl.Spec.Name
-
-			spec.ClusterName = cl.Spec.Name
-			uniqueName := util.RandStringBytesRmndr(4)
-			labels[config.LABEL_NAME] = cl.Spec.Name + "-" + uniqueName
-			spec.Name = labels[config.LABEL_NAME]
-			newInstance := &crv1.Pgreplica{
-				ObjectMeta: meta_v1.ObjectMeta{
-					Name:   labels[config.LABEL_NAME],
-					Labels: labels,
-				},
-				Spec: spec,
-				Status: crv1.PgreplicaStatus{
-					State:   crv1.PgreplicaStateCreated,
-					Message: "Created, not processed yet",
-				},
-			}
+		//create a CRD for each replica
+		for i := len(replicaList.Items); i < replicaCount; i++ {
+			newInstance := newPgreplica(cl)
 			result := crv1.Pgreplica{}
 
 			err = client.Post().
@@ -251,6 +238,18 @@ func ScaleBase(clientset *kubernetes.Clientset, client *rest.RESTClient, replica
 		return
 	}
 
+	// if the replica is provisioned from a snapshot backup, its PVCs are
+	// provisioned and prepared first. Once that is done, the replica is created
+	// from the existing PVCs
+	if replica.Spec.Snapshot != "" {
+		if ready, err := prepareSnapshotReplica(clientset, replica, &cluster, namespace); err != nil {
+			log.Error(err)
+			return
+		} else if !ready {
+			return
+		}
+	}
+
 	var pvcName string
 	// create the PVC if necessary.  When a replica is being created during a restore, the PVC will already exist.
 	// Otherwise a new PVC will be created.
@@ -275,41 +274,91 @@ func ScaleBase(clientset *kubernetes.Clientset, client *rest.RESTClient, replica
 	}
 
 	//create the replica service if it doesnt exist
+	serviceFields := newReplicaServiceFields(replica, &cluster)
+
+	err = CreateService(clientset, &serviceFields, namespace)
+	if err != nil {
+		log.Error(err)
+		return
+	}
 
-	st := operator.Pgo.Cluster.ServiceType
+	//instantiate the replica
+	Scale(clientset, client, replica, namespace, pvcName, &cluster)
 
-	if replica.Spec.UserLabels[config.LABEL_SERVICE_TYPE] != "" {
-		st = replica.Spec.UserLabels[config.LABEL_SERVICE_TYPE]
-	} else if cluster.Spec.UserLabels[config.LABEL_SERVICE_TYPE] != "" {
-		st = cluster.Spec.UserLabels[config.LABEL_SERVICE_TYPE]
+	//update the replica CRD status
+	err = util.Patch(client, "/spec/status", crv1.CompletedStatus, crv1.PgreplicaResourcePlural, replica.Spec.Name, namespace)
+	if err != nil {
+		log.Error("error in status patch " + err.Error())
 	}
 
-	serviceName := replica.Spec.ClusterName + "-replica"
-	serviceFields := ServiceTemplateFields{
+}
+
+// newReplicaServiceFields returns the fields of the replica service of a
+// cluster, see getReplicaServiceType for its type. The replica is the one the
+// service is created for, if any
+func newReplicaServiceFields(replica *crv1.Pgreplica, cluster *crv1.Pgcluster) ServiceTemplateFields {
+	serviceName := cluster.Spec.Name + ReplicaSuffix
+	fields := ServiceTemplateFields{
 		Name:         serviceName,
 		ServiceName:  serviceName,
-		ClusterName:  replica.Spec.ClusterName,
+		ClusterName:  cluster.Spec.Name,
 		Port:         cluster.Spec.Port,
 		PGBadgerPort: cluster.Spec.PGBadgerPort,
 		ExporterPort: cluster.Spec.ExporterPort,
-		ServiceType:  st,
+		ServiceType:  getReplicaServiceType(cluster, replica),
 	}
 
-	err = CreateService(clientset, &serviceFields, namespace)
-	if err != nil {
-		log.Error(err)
-		return
+	setServiceMetadata(&fields, cluster.Spec.Metadata.ReplicaService, cluster.Spec.LoadBalancerSourceRanges)
+
+	return fields
+}
+
+// prepareSnapshotReplica provisions the PVCs of a replica from a snapshot
+// backup of the cluster and creates the job that prepares the data directory.
+// It returns true once that job has succeeded
+func prepareSnapshotReplica(clientset *kubernetes.Clientset, replica *crv1.Pgreplica, cluster *crv1.Pgcluster,
+	namespace string) (bool, error) {
+	jobName := snapshotoperator.GetRestoreJobName(replica.Spec.Name)
+
+	// if the job already exists, wait for it to succeed
+	if job, found := kubeapi.GetJob(clientset, jobName, namespace); found {
+		if job.Status.Failed > 0 {
+			return false, fmt.Errorf("snapshot restore job %s for replica %s failed", jobName, replica.Spec.Name)
+		}
+
+		return job.Status.Succeeded > 0, nil
 	}
 
-	//instantiate the replica
-	Scale(clientset, client, replica, namespace, pvcName, &cluster)
+	snapshot, found := snapshotoperator.FindSnapshot(cluster, replica.Spec.Snapshot)
+	if !found {
+		return false, fmt.Errorf("snapshot backup %s not found for cluster %s", replica.Spec.Snapshot,
+			cluster.Spec.ClusterName)
+	}
 
-	//update the replica CRD status
-	err = util.Patch(client, "/spec/status", crv1.CompletedStatus, crv1.PgreplicaResourcePlural, replica.Spec.Name, namespace)
+	if err := snapshotoperator.CreatePVCs(clientset, snapshot, cluster.Spec.ClusterName, replica.Spec.Name, "",
+		replica.Spec.ReplicaStorage, cluster.Spec.TablespaceMounts, namespace); err != nil {
+		return false, err
+	}
+
+	// a replica follows the primary once it starts, so the recovery does not
+	// need to be configured
+	job, err := snapshotoperator.RestoreJob{
+		Cluster:     cluster,
+		Snapshot:    snapshot,
+		ClusterName: cluster.Spec.ClusterName,
+		PVCName:     replica.Spec.Name,
+	}.Job()
 	if err != nil {
-		log.Error("error in status patch " + err.Error())
+		return false, err
+	}
+
+	job.ObjectMeta.Labels[config.LABEL_REPLICA_NAME] = replica.Spec.Name
+
+	if _, err := kubeapi.CreateJob(clientset, job, namespace); err != nil {
+		return false, err
 	}
 
+	return false, nil
 }
 
 // ScaleDownBase ...
@@ -434,3 +483,50 @@ func publishClusterShutdown(cluster crv1.Pgcluster) error {
 
 	return nil
 }
+
+// newPgreplica returns a new pgreplica for a cluster, which the pgreplica
+// controller turns into a replica instance
+func newPgreplica(cl *crv1.Pgcluster) *crv1.Pgreplica {
+	spec := crv1.PgreplicaSpec{}
+	//get the resource config
+	spec.ContainerResources = cl.Spec.ContainerResources
+	//get the storage config
+	spec.ReplicaStorage = cl.Spec.ReplicaStorage
+
+	spec.UserLabels = make(map[string]string)
+	for k, v := range cl.Spec.UserLabels {
+		spec.UserLabels[k] = v
+	}
+
+	//the replica should not use the same node labels as the primary
+	spec.UserLabels[config.LABEL_NODE_LABEL_KEY] = ""
+	spec.UserLabels[config.LABEL_NODE_LABEL_VALUE] = ""
+
+	//check for replica node label in pgo.yaml
+	if operator.Pgo.Cluster.ReplicaNodeLabel != "" {
+		parts := strings.Split(operator.Pgo.Cluster.ReplicaNodeLabel, "=")
+		spec.UserLabels[config.LABEL_NODE_LABEL_KEY] = parts[0]
+		spec.UserLabels[config.LABEL_NODE_LABEL_VALUE] = parts[1]
+		log.Debug("using pgo.yaml ReplicaNodeLabel for replica creation")
+	}
+
+	labels := make(map[string]string)
+	labels[config.LABEL_PG_CLUSTER] = cl.Spec.Name
+
+	spec.ClusterName = cl.Spec.Name
+	uniqueName := util.RandStringBytesRmndr(4)
+	labels[config.LABEL_NAME] = cl.Spec.Name + "-" + uniqueName
+	spec.Name = labels[config.LABEL_NAME]
+
+	return &crv1.Pgreplica{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:   labels[config.LABEL_NAME],
+			Labels: labels,
+		},
+		Spec: spec,
+		Status: crv1.PgreplicaStatus{
+			State:   crv1.PgreplicaStateCreated,
+			Message: "Created, not processed yet",
+		},
+	}
+}
@@ -41,37 +41,118 @@ import (
 
 // AddCluster ...
 func AddCluster(clientset *kubernetes.Clientset, client *rest.RESTClient, cl *crv1.Pgcluster, namespace string, primaryPVCName string) error {
-	var primaryDoc bytes.Buffer
 	var err error
 
 	log.Info("creating Pgcluster object  in namespace " + namespace)
 	log.Info("created with Name=" + cl.Spec.Name + " in namespace " + namespace)
 
-	st := operator.Pgo.Cluster.ServiceType
-	if cl.Spec.UserLabels[config.LABEL_SERVICE_TYPE] != "" {
-		st = cl.Spec.UserLabels[config.LABEL_SERVICE_TYPE]
+	//create the primary service
+	serviceFields := newPrimaryServiceFields(cl)
+
+	err = CreateService(clientset, &serviceFields, namespace)
+	if err != nil {
+		log.Error("error in creating primary service " + err.Error())
+		publishClusterCreateFailure(cl, err.Error())
+		return err
 	}
 
-	//create the primary service
-	serviceFields := ServiceTemplateFields{
+	setPrimaryUserLabels(cl)
+
+	if cl.Labels[config.LABEL_BACKREST] == "true" {
+		err = backrest.CreateRepoDeployment(clientset, namespace, cl, true)
+		if err != nil {
+			log.Error("could not create backrest repo deployment")
+			publishClusterCreateFailure(cl, err.Error())
+			return err
+		}
+	}
+
+	// Create a configMap for the cluster that will be utilized to configure whether or not
+	// initialization logic should be executed when the postgres-ha container is run.  This
+	// ensures that the original primary in a PG cluster does not attempt to run any initialization
+	// logic following a restart of the container.
+	if err = operator.CreatePGHAConfigMap(clientset, cl, namespace); err != nil {
+		log.Error(err.Error())
+		publishClusterCreateFailure(cl, err.Error())
+		return err
+	}
+
+	operator.CreateCollectSecret(clientset, &cl.Spec, namespace)
+
+	// the certificate of the cluster is issued before the primary mounts it
+	if cl.Spec.TLS.Auto {
+		if _, err := EnsureClusterTLS(clientset, cl); err != nil {
+			log.Error(err)
+			publishClusterCreateFailure(cl, err.Error())
+			return err
+		}
+	}
+
+	deployment, err := renderPrimaryDeployment(clientset, cl, namespace, primaryPVCName)
+	if err != nil {
+		publishClusterCreateFailure(cl, err.Error())
+		return err
+	}
+
+	if _, found, _ := kubeapi.GetDeployment(clientset, cl.Spec.Name, namespace); !found {
+		err = kubeapi.CreateDeployment(clientset, deployment, namespace)
+		if err != nil {
+			publishClusterCreateFailure(cl, err.Error())
+			return err
+		}
+	} else {
+		log.Info("primary Deployment " + cl.Spec.Name + " in namespace " + namespace + " already existed so not creating it ")
+	}
+
+	cl.Spec.UserLabels[config.LABEL_CURRENT_PRIMARY] = cl.Spec.Name
+
+	err = util.PatchClusterCRD(client, cl.Spec.UserLabels, cl, namespace)
+	if err != nil {
+		log.Error("could not patch primary crv1 with labels")
+		publishClusterCreateFailure(cl, err.Error())
+		return err
+	}
+
+	return err
+
+}
+
+// newPrimaryServiceFields returns the template fields of the primary service of a cluster
+func newPrimaryServiceFields(cl *crv1.Pgcluster) ServiceTemplateFields {
+	fields := ServiceTemplateFields{
 		Name:         cl.Spec.Name,
 		ServiceName:  cl.Spec.Name,
 		ClusterName:  cl.Spec.Name,
 		Port:         cl.Spec.Port,
 		PGBadgerPort: cl.Spec.PGBadgerPort,
 		ExporterPort: cl.Spec.ExporterPort,
-		ServiceType:  st,
+		ServiceType:  getPrimaryServiceType(cl),
 	}
 
-	err = CreateService(clientset, &serviceFields, namespace)
-	if err != nil {
-		log.Error("error in creating primary service " + err.Error())
-		publishClusterCreateFailure(cl, err.Error())
-		return err
-	}
+	setServiceMetadata(&fields, cl.Spec.Metadata.PrimaryService, cl.Spec.LoadBalancerSourceRanges)
 
+	return fields
+}
+
+// setPrimaryUserLabels sets the labels of a new cluster that identify its
+// primary instance
+func setPrimaryUserLabels(cl *crv1.Pgcluster) {
 	cl.Spec.UserLabels["name"] = cl.Spec.Name
 	cl.Spec.UserLabels[config.LABEL_PG_CLUSTER] = cl.Spec.ClusterName
+	cl.Spec.UserLabels[config.LABEL_DEPLOYMENT_NAME] = cl.Spec.Name
+	cl.Spec.UserLabels[config.LABEL_PGOUSER] = cl.ObjectMeta.Labels[config.LABEL_PGOUSER]
+	cl.Spec.UserLabels[config.LABEL_PG_CLUSTER_IDENTIFIER] = cl.ObjectMeta.Labels[config.LABEL_PG_CLUSTER_IDENTIFIER]
+
+	// Set the Patroni scope to the name of the primary deployment.  Replicas will get scope using the
+	// 'crunchy-pgha-scope' label on the pgcluster
+	cl.Spec.UserLabels[config.LABEL_PGHA_SCOPE] = cl.Spec.Name
+}
+
+// renderPrimaryDeployment returns the Deployment of the primary of a new cluster, without creating
+// it
+func renderPrimaryDeployment(clientset *kubernetes.Clientset, cl *crv1.Pgcluster, namespace,
+	primaryPVCName string) (*v1.Deployment, error) {
+	var primaryDoc bytes.Buffer
 
 	archivePVCName := ""
 	archiveMode := "off"
@@ -88,22 +169,8 @@ func AddCluster(clientset *kubernetes.Clientset, client *rest.RESTClient, cl *cr
 		//by setting the name to empty string
 		archivePVCName = ""
 		xlogdir = "false"
-		err = backrest.CreateRepoDeployment(clientset, namespace, cl, true)
-		if err != nil {
-			log.Error("could not create backrest repo deployment")
-			publishClusterCreateFailure(cl, err.Error())
-			return err
-		}
 	}
 
-	cl.Spec.UserLabels[config.LABEL_DEPLOYMENT_NAME] = cl.Spec.Name
-	cl.Spec.UserLabels[config.LABEL_PGOUSER] = cl.ObjectMeta.Labels[config.LABEL_PGOUSER]
-	cl.Spec.UserLabels[config.LABEL_PG_CLUSTER_IDENTIFIER] = cl.ObjectMeta.Labels[config.LABEL_PG_CLUSTER_IDENTIFIER]
-
-	// Set the Patroni scope to the name of the primary deployment.  Replicas will get scope using the
-	// 'crunchy-pgha-scope' label on the pgcluster
-	cl.Spec.UserLabels[config.LABEL_PGHA_SCOPE] = cl.Spec.Name
-
 	// set up a map of the names of the tablespaces as well as the storage classes
 	tablespaceStorageTypeMap := operator.GetTablespaceStorageTypeMap(cl.Spec.TablespaceMounts)
 
@@ -132,6 +199,8 @@ func AddCluster(clientset *kubernetes.Clientset, client *rest.RESTClient, cl *cr
 		UserSecretName:     cl.Spec.UserSecretName,
 		NodeSelector:       operator.GetAffinity(cl.Spec.UserLabels["NodeLabelKey"], cl.Spec.UserLabels["NodeLabelValue"], "In"),
 		PodAntiAffinity:    operator.GetPodAntiAffinity(cl, crv1.PodAntiAffinityDeploymentDefault, cl.Spec.PodAntiAffinity.Default),
+		Tolerations:        operator.GetTolerationsJSON(operator.GetTolerations(cl)),
+		PriorityClassName:  operator.GetPriorityClassName(cl),
 		ContainerResources: operator.GetContainerResourcesJSON(&cl.Spec.ContainerResources),
 		ConfVolume:         operator.GetConfVolume(clientset, cl, namespace),
 		CollectAddon:       operator.GetCollectAddon(clientset, namespace, &cl.Spec),
@@ -141,36 +210,32 @@ func AddCluster(clientset *kubernetes.Clientset, client *rest.RESTClient, cl *cr
 		ScopeLabel:         config.LABEL_PGHA_SCOPE,
 		PgbackrestEnvVars: operator.GetPgbackrestEnvVars(cl, cl.Labels[config.LABEL_BACKREST], cl.Spec.Name,
 			cl.Spec.Port, cl.Spec.UserLabels[config.LABEL_BACKREST_STORAGE_TYPE]),
-		PgbackrestS3EnvVars:      operator.GetPgbackrestS3EnvVars(*cl, clientset, namespace),
-		EnableCrunchyadm:         operator.Pgo.Cluster.EnableCrunchyadm,
-		ReplicaReinitOnStartFail: !operator.Pgo.Cluster.DisableReplicaStartFailReinit,
-		SyncReplication:          operator.GetSyncReplication(cl.Spec.SyncReplication),
-		Tablespaces:              operator.GetTablespaceNames(cl.Spec.TablespaceMounts),
-		TablespaceVolumes:        operator.GetTablespaceVolumesJSON(cl.Spec.Name, tablespaceStorageTypeMap),
-		TablespaceVolumeMounts:   operator.GetTablespaceVolumeMountsJSON(tablespaceStorageTypeMap),
-		TLSEnabled:               cl.Spec.TLS.IsTLSEnabled(),
-		TLSOnly:                  cl.Spec.TLSOnly,
-		TLSSecret:                cl.Spec.TLS.TLSSecret,
-		CASecret:                 cl.Spec.TLS.CASecret,
-		Standby:                  cl.Spec.Standby,
-	}
-
-	// Create a configMap for the cluster that will be utilized to configure whether or not
-	// initialization logic should be executed when the postgres-ha container is run.  This
-	// ensures that the original primary in a PG cluster does not attempt to run any initialization
-	// logic following a restart of the container.
-	if err = operator.CreatePGHAConfigMap(clientset, cl, namespace); err != nil {
-		log.Error(err.Error())
-		publishClusterCreateFailure(cl, err.Error())
-		return err
-	}
+		PgbackrestS3EnvVars:       operator.GetPgbackrestS3EnvVars(*cl, clientset, namespace),
+		PgbackrestGCSEnvVars:      operator.GetPgbackrestGCSEnvVars(*cl),
+		PgbackrestAzureEnvVars:    operator.GetPgbackrestAzureEnvVars(*cl),
+		PgbackrestCipherEnvVars:   operator.GetPgbackrestCipherEnvVars(*cl),
+		EnableCrunchyadm:          operator.Pgo.Cluster.EnableCrunchyadm,
+		ReplicaReinitOnStartFail:  !operator.Pgo.Cluster.DisableReplicaStartFailReinit,
+		SyncReplication:           operator.GetSyncReplication(cl.Spec.SyncReplication),
+		Tablespaces:               operator.GetTablespaceNames(cl.Spec.TablespaceMounts),
+		TablespaceVolumes:         operator.GetTablespaceVolumesJSON(cl.Spec.Name, tablespaceStorageTypeMap),
+		TablespaceVolumeMounts:    operator.GetTablespaceVolumeMountsJSON(tablespaceStorageTypeMap),
+		TLSEnabled:                cl.Spec.TLS.IsTLSEnabled(),
+		TLSOnly:                   cl.Spec.TLSOnly,
+		TLSSecret:                 cl.Spec.TLS.TLSSecret,
+		CASecret:                  cl.Spec.TLS.CASecret,
+		ReplicationTLSSecret:      operator.GetReplicationTLSSecretName(cl),
+		Standby:                   cl.Spec.Standby,
+		TopologySpreadConstraints: operator.GetTopologySpreadConstraintsJSON(cl),
+	}
+	deploymentFields.PodAnnotations, deploymentFields.PodCustomLabels = operator.GetCustomMetadataJSON(
+		cl.Spec.Metadata.Pod, cl.Spec.UserLabels)
 
 	log.Debug("collectaddon value is [" + deploymentFields.CollectAddon + "]")
-	err = config.DeploymentTemplate.Execute(&primaryDoc, deploymentFields)
+	err := config.DeploymentTemplate.Execute(&primaryDoc, deploymentFields)
 	if err != nil {
 		log.Error(err.Error())
-		publishClusterCreateFailure(cl, err.Error())
-		return err
+		return nil, err
 	}
 
 	//a form of debugging
@@ -182,34 +247,13 @@ func AddCluster(clientset *kubernetes.Clientset, client *rest.RESTClient, cl *cr
 	err = json.Unmarshal(primaryDoc.Bytes(), &deployment)
 	if err != nil {
 		log.Error("error unmarshalling primary json into Deployment " + err.Error())
-		publishClusterCreateFailure(cl, err.Error())
-		return err
+		return nil, err
 	}
 
 	// determine if any of the container images need to be overridden
 	operator.OverrideClusterContainerImages(deployment.Spec.Template.Spec.Containers)
 
-	if _, found, _ := kubeapi.GetDeployment(clientset, cl.Spec.Name, namespace); !found {
-		err = kubeapi.CreateDeployment(clientset, &deployment, namespace)
-		if err != nil {
-			publishClusterCreateFailure(cl, err.Error())
-			return err
-		}
-	} else {
-		log.Info("primary Deployment " + cl.Spec.Name + " in namespace " + namespace + " already existed so not creating it ")
-	}
-
-	cl.Spec.UserLabels[config.LABEL_CURRENT_PRIMARY] = cl.Spec.Name
-
-	err = util.PatchClusterCRD(client, cl.Spec.UserLabels, cl, namespace)
-	if err != nil {
-		log.Error("could not patch primary crv1 with labels")
-		publishClusterCreateFailure(cl, err.Error())
-		return err
-	}
-
-	return err
-
+	return &deployment, nil
 }
 
 // DeleteCluster ...
@@ -243,15 +287,74 @@ func Scale(clientset *kubernetes.Clientset, client *rest.RESTClient, replica *cr
 	log.Debug("Scale called pvcName " + pvcName)
 	log.Debug("Scale called namespace " + namespace)
 
-	var replicaDoc bytes.Buffer
+	setReplicaUserLabels(replica, cluster)
+
+	// iterate through all of the tablespaces and attempt to create their PVCs
+	// for the replcia
+	for tablespaceName, storageSpec := range cluster.Spec.TablespaceMounts {
+		// attempt to create the tablespace PVC. If it fails to create, log the
+		// error and publish the failure event
+		// Note that we specify **replica.Spec.Name** in order to create distinct
+		// PVCs for this replica, but we use the **cluster.Spec.ClusterName** for the
+		// "pgcluster" Label
+		tablespacePVCName := operator.GetTablespacePVCName(replica.Spec.Name, tablespaceName)
+
+		if err := CreateTablespacePVC(clientset, namespace, cluster.Spec.ClusterName, tablespacePVCName, &storageSpec); err != nil {
+			log.Error(err)
+			publishScaleError(namespace, replica.ObjectMeta.Labels[config.LABEL_PGOUSER], cluster)
+			return err
+		}
+	}
+
+	operator.CreateCollectSecret(clientset, &cluster.Spec, namespace)
+
+	replicaDeployment, err := renderReplicaDeployment(clientset, replica, cluster, namespace, pvcName)
+	if err != nil {
+		publishScaleError(namespace, replica.ObjectMeta.Labels[config.LABEL_PGOUSER], cluster)
+		return err
+	}
+
+	err = kubeapi.CreateDeployment(clientset, replicaDeployment, namespace)
+
+	//publish event for replica creation
+	topics := make([]string, 1)
+	topics[0] = events.EventTopicCluster
+
+	f := events.EventScaleClusterFormat{
+		EventHeader: events.EventHeader{
+			Namespace: namespace,
+			Username:  replica.ObjectMeta.Labels[config.LABEL_PGOUSER],
+			Topic:     topics,
+			Timestamp: time.Now(),
+			EventType: events.EventScaleCluster,
+		},
+		Clustername: cluster.Spec.UserLabels[config.LABEL_REPLICA_NAME],
+		Replicaname: cluster.Spec.UserLabels[config.LABEL_PG_CLUSTER],
+	}
+
+	err = events.Publish(f)
+	if err != nil {
+		log.Error(err.Error())
+	}
 
-	serviceName := replica.Spec.ClusterName + "-replica"
-	//replicaFlag := true
+	return err
+}
 
-	//	replicaLabels := operator.GetPrimaryLabels(serviceName, replica.Spec.ClusterName, replicaFlag, cluster.Spec.UserLabels)
+// setReplicaUserLabels sets the labels of a cluster that the Deployment of one of its replicas is
+// rendered with
+func setReplicaUserLabels(replica *crv1.Pgreplica, cluster *crv1.Pgcluster) {
 	cluster.Spec.UserLabels[config.LABEL_REPLICA_NAME] = replica.Spec.Name
-	cluster.Spec.UserLabels["name"] = serviceName
+	cluster.Spec.UserLabels["name"] = replica.Spec.ClusterName + "-replica"
 	cluster.Spec.UserLabels[config.LABEL_PG_CLUSTER] = replica.Spec.ClusterName
+	cluster.Spec.UserLabels[config.LABEL_DEPLOYMENT_NAME] = replica.Spec.Name
+}
+
+// renderReplicaDeployment returns the Deployment of a replica of a cluster, without creating it.
+// The labels of the replica have to be set on the cluster using setReplicaUserLabels
+func renderReplicaDeployment(clientset *kubernetes.Clientset, replica *crv1.Pgreplica,
+	cluster *crv1.Pgcluster, namespace, pvcName string) (*v1.Deployment, error) {
+	var err error
+	var replicaDoc bytes.Buffer
 
 	archivePVCName := ""
 	archiveMode := "off"
@@ -283,25 +386,6 @@ func Scale(clientset *kubernetes.Clientset, client *rest.RESTClient, replica *cr
 		cs = cluster.Spec.ContainerResources
 	}
 
-	cluster.Spec.UserLabels[config.LABEL_DEPLOYMENT_NAME] = replica.Spec.Name
-
-	// iterate through all of the tablespaces and attempt to create their PVCs
-	// for the replcia
-	for tablespaceName, storageSpec := range cluster.Spec.TablespaceMounts {
-		// attempt to create the tablespace PVC. If it fails to create, log the
-		// error and publish the failure event
-		// Note that we specify **replica.Spec.Name** in order to create distinct
-		// PVCs for this replica, but we use the **cluster.Spec.ClusterName** for the
-		// "pgcluster" Label
-		tablespacePVCName := operator.GetTablespacePVCName(replica.Spec.Name, tablespaceName)
-
-		if err := CreateTablespacePVC(clientset, namespace, cluster.Spec.ClusterName, tablespacePVCName, &storageSpec); err != nil {
-			log.Error(err)
-			publishScaleError(namespace, replica.ObjectMeta.Labels[config.LABEL_PGOUSER], cluster)
-			return err
-		}
-	}
-
 	// set up a map of the names of the tablespaces as well as the storage classes
 	tablespaceStorageTypeMap := operator.GetTablespaceStorageTypeMap(cluster.Spec.TablespaceMounts)
 
@@ -331,6 +415,8 @@ func Scale(clientset *kubernetes.Clientset, client *rest.RESTClient, replica *cr
 		ContainerResources: operator.GetContainerResourcesJSON(&cs),
 		NodeSelector:       operator.GetReplicaAffinity(cluster.Spec.UserLabels, replica.Spec.UserLabels),
 		PodAntiAffinity:    operator.GetPodAntiAffinity(cluster, crv1.PodAntiAffinityDeploymentDefault, cluster.Spec.PodAntiAffinity.Default),
+		Tolerations:        operator.GetTolerationsJSON(operator.GetReplicaTolerations(cluster, replica)),
+		PriorityClassName:  operator.GetReplicaPriorityClassName(cluster, replica),
 		CollectAddon:       operator.GetCollectAddon(clientset, namespace, &cluster.Spec),
 		CollectVolume:      operator.GetCollectVolume(clientset, cluster, namespace),
 		BadgerAddon:        operator.GetBadgerAddon(clientset, namespace, cluster, replica.Spec.Name),
@@ -338,18 +424,25 @@ func Scale(clientset *kubernetes.Clientset, client *rest.RESTClient, replica *cr
 		ScopeLabel:         config.LABEL_PGHA_SCOPE,
 		PgbackrestEnvVars: operator.GetPgbackrestEnvVars(cluster, cluster.Labels[config.LABEL_BACKREST], replica.Spec.Name,
 			cluster.Spec.Port, cluster.Spec.UserLabels[config.LABEL_BACKREST_STORAGE_TYPE]),
-		PgbackrestS3EnvVars:      operator.GetPgbackrestS3EnvVars(*cluster, clientset, namespace),
-		EnableCrunchyadm:         operator.Pgo.Cluster.EnableCrunchyadm,
-		ReplicaReinitOnStartFail: !operator.Pgo.Cluster.DisableReplicaStartFailReinit,
-		SyncReplication:          operator.GetSyncReplication(cluster.Spec.SyncReplication),
-		Tablespaces:              operator.GetTablespaceNames(cluster.Spec.TablespaceMounts),
-		TablespaceVolumes:        operator.GetTablespaceVolumesJSON(replica.Spec.Name, tablespaceStorageTypeMap),
-		TablespaceVolumeMounts:   operator.GetTablespaceVolumeMountsJSON(tablespaceStorageTypeMap),
-		TLSEnabled:               cluster.Spec.TLS.IsTLSEnabled(),
-		TLSOnly:                  cluster.Spec.TLSOnly,
-		TLSSecret:                cluster.Spec.TLS.TLSSecret,
-		CASecret:                 cluster.Spec.TLS.CASecret,
-	}
+		PgbackrestS3EnvVars:       operator.GetPgbackrestS3EnvVars(*cluster, clientset, namespace),
+		PgbackrestGCSEnvVars:      operator.GetPgbackrestGCSEnvVars(*cluster),
+		PgbackrestAzureEnvVars:    operator.GetPgbackrestAzureEnvVars(*cluster),
+		PgbackrestCipherEnvVars:   operator.GetPgbackrestCipherEnvVars(*cluster),
+		EnableCrunchyadm:          operator.Pgo.Cluster.EnableCrunchyadm,
+		ReplicaReinitOnStartFail:  !operator.Pgo.Cluster.DisableReplicaStartFailReinit,
+		SyncReplication:           operator.GetSyncReplication(cluster.Spec.SyncReplication),
+		Tablespaces:               operator.GetTablespaceNames(cluster.Spec.TablespaceMounts),
+		TablespaceVolumes:         operator.GetTablespaceVolumesJSON(replica.Spec.Name, tablespaceStorageTypeMap),
+		TablespaceVolumeMounts:    operator.GetTablespaceVolumeMountsJSON(tablespaceStorageTypeMap),
+		TLSEnabled:                cluster.Spec.TLS.IsTLSEnabled(),
+		TLSOnly:                   cluster.Spec.TLSOnly,
+		TLSSecret:                 cluster.Spec.TLS.TLSSecret,
+		CASecret:                  cluster.Spec.TLS.CASecret,
+		ReplicationTLSSecret:      operator.GetReplicationTLSSecretName(cluster),
+		TopologySpreadConstraints: operator.GetTopologySpreadConstraintsJSON(cluster),
+	}
+	replicaDeploymentFields.PodAnnotations, replicaDeploymentFields.PodCustomLabels =
+		operator.GetCustomMetadataJSON(cluster.Spec.Metadata.Pod, cluster.Spec.UserLabels)
 
 	switch replica.Spec.ReplicaStorage.StorageType {
 	case "", "emptydir":
@@ -362,8 +455,7 @@ func Scale(clientset *kubernetes.Clientset, client *rest.RESTClient, replica *cr
 
 	if err != nil {
 		log.Error(err.Error())
-		publishScaleError(namespace, replica.ObjectMeta.Labels[config.LABEL_PGOUSER], cluster)
-		return err
+		return nil, err
 	}
 
 	if operator.CRUNCHY_DEBUG {
@@ -374,8 +466,7 @@ func Scale(clientset *kubernetes.Clientset, client *rest.RESTClient, replica *cr
 	err = json.Unmarshal(replicaDoc.Bytes(), &replicaDeployment)
 	if err != nil {
 		log.Error("error unmarshalling replica json into Deployment " + err.Error())
-		publishScaleError(namespace, replica.ObjectMeta.Labels[config.LABEL_PGOUSER], cluster)
-		return err
+		return nil, err
 	}
 
 	// determine if any of the container images need to be overridden
@@ -386,30 +477,7 @@ func Scale(clientset *kubernetes.Clientset, client *rest.RESTClient, replica *cr
 	replicaDeployment.Labels[config.LABEL_PGHA_SCOPE] = cluster.Labels[config.LABEL_PGHA_SCOPE]
 	replicaDeployment.Spec.Template.Labels[config.LABEL_PGHA_SCOPE] = cluster.Labels[config.LABEL_PGHA_SCOPE]
 
-	err = kubeapi.CreateDeployment(clientset, &replicaDeployment, namespace)
-
-	//publish event for replica creation
-	topics := make([]string, 1)
-	topics[0] = events.EventTopicCluster
-
-	f := events.EventScaleClusterFormat{
-		EventHeader: events.EventHeader{
-			Namespace: namespace,
-			Username:  replica.ObjectMeta.Labels[config.LABEL_PGOUSER],
-			Topic:     topics,
-			Timestamp: time.Now(),
-			EventType: events.EventScaleCluster,
-		},
-		Clustername: cluster.Spec.UserLabels[config.LABEL_REPLICA_NAME],
-		Replicaname: cluster.Spec.UserLabels[config.LABEL_PG_CLUSTER],
-	}
-
-	err = events.Publish(f)
-	if err != nil {
-		log.Error(err.Error())
-	}
-
-	return err
+	return &replicaDeployment, nil
 }
 
 // CreateTablespacePVC creates a PVC for a tablespace, whether its a part of a
@@ -611,6 +679,9 @@ func ScaleClusterDeployments(clientset *kubernetes.Clientset, cluster crv1.Pgclu
 
 	for _, deployment := range deploymentList.Items {
 
+		// the number of Pods to scale this deployment to
+		scaleTo := replicas
+
 		// determine if the deployment is a primary, replica, or supporting service (pgBackRest,
 		// pgBouncer, etc.)
 		switch {
@@ -626,6 +697,10 @@ func ScaleClusterDeployments(clientset *kubernetes.Clientset, cluster crv1.Pgclu
 			if !scaleServices {
 				continue
 			}
+			// pgBouncer is scaled up to the number of replicas in its spec
+			if replicas > 0 {
+				scaleTo = int(getPgBouncerReplicas(&cluster))
+			}
 		case deployment.Labels[config.LABEL_PGO_BACKREST_REPO] == "true":
 			// if not scaling services simply move on to the next deployment
 			if !scaleServices {
@@ -641,13 +716,13 @@ func ScaleClusterDeployments(clientset *kubernetes.Clientset, cluster crv1.Pgclu
 			}
 		}
 
-		log.Debugf("scaling deployment %s to %d for cluster %s", deployment.Name, replicas,
+		log.Debugf("scaling deployment %s to %d for cluster %s", deployment.Name, scaleTo,
 			clusterName)
 
 		// Scale the deployment accoriding to the number of replicas specified.  If an error is
 		// encountered, log it and move on to scaling the next deployment.
-		if err = kubeapi.ScaleDeployment(clientset, deployment, replicas); err != nil {
-			log.Error("Error scaling deployment %s to %d: %w", deployment.Name, replicas, err)
+		if err = kubeapi.ScaleDeployment(clientset, deployment, scaleTo); err != nil {
+			log.Error("Error scaling deployment %s to %d: %w", deployment.Name, scaleTo, err)
 		}
 	}
 	return
@@ -0,0 +1,162 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"fmt"
+	"time"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/events"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// ExpirationWarningPeriod is how long before the expiration time of a cluster
+// a warning event is sent
+const ExpirationWarningPeriod = 24 * time.Hour
+
+// clusterExpiringReason is the reason of the Kubernetes Event that is recorded
+// on a pgcluster that is about to expire
+const clusterExpiringReason = "ClusterExpiring"
+
+// expirationAction is what is done with a cluster that has a TTL
+type expirationAction int
+
+const (
+	expirationActionNone expirationAction = iota
+	expirationActionWarn
+	expirationActionDelete
+)
+
+// getExpirationAction determines what to do with a cluster at the given time.
+// A cluster is deleted once it has expired, unless deletion protection is
+// enabled. A warning is sent once per expiration time, which is recorded in the
+// "expiration-warned" annotation
+func getExpirationAction(cluster *crv1.Pgcluster, now time.Time) expirationAction {
+	if cluster.Spec.ExpirationTime == nil || cluster.DeletionTimestamp != nil {
+		return expirationActionNone
+	}
+
+	expirationTime := cluster.Spec.ExpirationTime.Time
+
+	if !now.Before(expirationTime) {
+		if cluster.Spec.DeletionProtection {
+			return expirationActionNone
+		}
+		return expirationActionDelete
+	}
+
+	if now.Add(ExpirationWarningPeriod).Before(expirationTime) ||
+		cluster.Annotations[config.ANNOTATION_EXPIRATION_WARNED] == expirationTime.Format(time.RFC3339) {
+		return expirationActionNone
+	}
+
+	return expirationActionWarn
+}
+
+// ExpireCluster sends a warning event for a cluster that is about to expire,
+// which is also recorded as a Kubernetes Event on its pgcluster, and deletes a
+// cluster that has expired. The pgcluster is deleted, which removes the
+// resources of the cluster through the finalizer, so the "keep-data" and
+// "keep-backups" annotations are honored
+func ExpireCluster(clientset *kubernetes.Clientset, restclient *rest.RESTClient, cluster *crv1.Pgcluster) {
+	now := time.Now()
+
+	switch getExpirationAction(cluster, now) {
+	case expirationActionWarn:
+		expirationTime := cluster.Spec.ExpirationTime.Time
+
+		log.Infof("pgcluster %s expires at %s", cluster.Name, expirationTime.Format(time.RFC3339))
+
+		publishClusterExpiring(cluster, expirationTime)
+
+		if err := kubeapi.CreateEvent(clientset, newClusterExpiringEvent(cluster, now),
+			cluster.Namespace); err != nil {
+			log.Error(err)
+		}
+
+		annotations := map[string]string{
+			config.ANNOTATION_EXPIRATION_WARNED: expirationTime.Format(time.RFC3339),
+		}
+
+		if err := kubeapi.PatchpgclusterAnnotations(restclient, annotations, cluster.Name, cluster.Namespace); err != nil {
+			log.Error(err)
+		}
+	case expirationActionDelete:
+		log.Infof("pgcluster %s has expired, deleting", cluster.Name)
+
+		if err := kubeapi.Deletepgcluster(restclient, cluster.Name, cluster.Namespace); err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+// newClusterExpiringEvent returns the Kubernetes Event that warns that a
+// pgcluster is about to expire
+func newClusterExpiringEvent(cluster *crv1.Pgcluster, now time.Time) *v1.Event {
+	expirationTime := cluster.Spec.ExpirationTime.Time.Format(time.RFC3339)
+
+	return &v1.Event{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", cluster.Name, now.UnixNano()),
+			Namespace: cluster.Namespace,
+		},
+		InvolvedObject: v1.ObjectReference{
+			APIVersion:      crv1.SchemeGroupVersion.String(),
+			Kind:            "Pgcluster",
+			Name:            cluster.Name,
+			Namespace:       cluster.Namespace,
+			UID:             cluster.UID,
+			ResourceVersion: cluster.ResourceVersion,
+		},
+		Reason: clusterExpiringReason,
+		Message: fmt.Sprintf("cluster %s expires at %s and is then deleted, unless its TTL is extended",
+			cluster.Name, expirationTime),
+		Type:           v1.EventTypeWarning,
+		Source:         v1.EventSource{Component: config.LABEL_OPERATOR},
+		FirstTimestamp: meta_v1.NewTime(now),
+		LastTimestamp:  meta_v1.NewTime(now),
+		Count:          1,
+	}
+}
+
+func publishClusterExpiring(cluster *crv1.Pgcluster, expirationTime time.Time) {
+	topics := make([]string, 1)
+	topics[0] = events.EventTopicCluster
+
+	f := events.EventClusterExpiringFormat{
+		EventHeader: events.EventHeader{
+			Namespace: cluster.Namespace,
+			Username:  cluster.ObjectMeta.Labels[config.LABEL_PGOUSER],
+			Topic:     topics,
+			Timestamp: time.Now(),
+			EventType: events.EventClusterExpiring,
+		},
+		Clustername:    cluster.Name,
+		ExpirationTime: expirationTime,
+	}
+
+	err := events.Publish(f)
+	if err != nil {
+		log.Error(err.Error())
+	}
+}
@@ -0,0 +1,123 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetExpirationAction(t *testing.T) {
+	now := time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		description        string
+		ttl                bool
+		expiresIn          time.Duration
+		warned             bool
+		warnedFor          time.Duration
+		deletionProtection bool
+		deleted            bool
+		action             expirationAction
+	}{
+		{description: "no ttl", action: expirationActionNone},
+		{description: "expires later", ttl: true, expiresIn: 72 * time.Hour, action: expirationActionNone},
+		{description: "expires right after the warning period", ttl: true,
+			expiresIn: ExpirationWarningPeriod + time.Second, action: expirationActionNone},
+		{description: "expires within the warning period", ttl: true, expiresIn: ExpirationWarningPeriod,
+			action: expirationActionWarn},
+		{description: "expires soon", ttl: true, expiresIn: time.Hour, action: expirationActionWarn},
+		{description: "already warned", ttl: true, expiresIn: time.Hour, warned: true, warnedFor: time.Hour,
+			action: expirationActionNone},
+		{description: "ttl extended after the warning", ttl: true, expiresIn: time.Hour, warned: true,
+			warnedFor: -time.Hour, action: expirationActionWarn},
+		{description: "expires now", ttl: true, expiresIn: 0, action: expirationActionDelete},
+		{description: "expired", ttl: true, expiresIn: -time.Hour, warned: true, warnedFor: -time.Hour,
+			action: expirationActionDelete},
+		{description: "expired with deletion protection", ttl: true, expiresIn: -time.Hour,
+			deletionProtection: true, action: expirationActionNone},
+		{description: "expired and being deleted", ttl: true, expiresIn: -time.Hour, deleted: true,
+			action: expirationActionNone},
+	}
+
+	for i, test := range tests {
+		cluster := &crv1.Pgcluster{
+			Spec: crv1.PgclusterSpec{DeletionProtection: test.deletionProtection},
+		}
+
+		if test.ttl {
+			cluster.Spec.ExpirationTime = &meta_v1.Time{Time: now.Add(test.expiresIn)}
+		}
+
+		if test.warned {
+			cluster.Annotations = map[string]string{
+				config.ANNOTATION_EXPIRATION_WARNED: now.Add(test.warnedFor).Format(time.RFC3339),
+			}
+		}
+
+		if test.deleted {
+			cluster.DeletionTimestamp = &meta_v1.Time{Time: now}
+		}
+
+		if action := getExpirationAction(cluster, now); action != test.action {
+			t.Fatalf("tests[%d] - %s: expected action %d, got %d", i, test.description, test.action, action)
+		}
+	}
+}
+
+func TestNewClusterExpiringEvent(t *testing.T) {
+	now := time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	cluster := &crv1.Pgcluster{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo", UID: "1234"},
+		Spec: crv1.PgclusterSpec{
+			ExpirationTime: &meta_v1.Time{Time: now.Add(time.Hour)},
+		},
+	}
+
+	event := newClusterExpiringEvent(cluster, now)
+
+	tests := []struct {
+		field, expected, actual string
+	}{
+		{"namespace", "pgo", event.Namespace},
+		{"kind", "Pgcluster", event.InvolvedObject.Kind},
+		{"name", "hippo", event.InvolvedObject.Name},
+		{"uid", "1234", string(event.InvolvedObject.UID)},
+		{"type", v1.EventTypeWarning, event.Type},
+		{"reason", clusterExpiringReason, event.Reason},
+	}
+
+	for i, test := range tests {
+		if test.actual != test.expected {
+			t.Fatalf("tests[%d] - expected %s %q, got %q", i, test.field, test.expected, test.actual)
+		}
+	}
+
+	if !strings.HasPrefix(event.Name, "hippo.") {
+		t.Fatalf("expected the name of the event to start with the name of the cluster, got %q", event.Name)
+	}
+
+	if !strings.Contains(event.Message, "2020-06-01T13:00:00Z") {
+		t.Fatalf("expected the expiration time in the message, got %q", event.Message)
+	}
+}
@@ -0,0 +1,165 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	log "github.com/sirupsen/logrus"
+	v1batch "k8s.io/api/batch/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// rmdataTaskTimeout is how long the rmdata task of a pgcluster that is marked
+// for deletion can go without a pgo-rmdata job before it is created again
+const rmdataTaskTimeout = 5 * time.Minute
+
+// HasFinalizer returns true if the pgcluster has the finalizer that ensures
+// its resources are removed before it is deleted
+func HasFinalizer(cluster *crv1.Pgcluster) bool {
+	for _, finalizer := range cluster.Finalizers {
+		if finalizer == crv1.PgclusterFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// AddFinalizer adds the finalizer that ensures the resources of a cluster are
+// removed before its pgcluster is deleted, e.g. with "kubectl delete pgcluster"
+func AddFinalizer(restclient *rest.RESTClient, cluster *crv1.Pgcluster) error {
+	if HasFinalizer(cluster) || cluster.DeletionTimestamp != nil {
+		return nil
+	}
+
+	log.Debugf("adding finalizer to pgcluster %s", cluster.Name)
+
+	finalizers := append([]string{}, cluster.Finalizers...)
+	finalizers = append(finalizers, crv1.PgclusterFinalizer)
+
+	return kubeapi.PatchpgclusterFinalizers(restclient, finalizers, cluster)
+}
+
+// DeleteClusterFromFinalizer removes the resources of a cluster whose
+// pgcluster has been marked for deletion, using the same pgo-rmdata job as
+// "pgo delete cluster". The data and the backups of the cluster are removed
+// unless the pgcluster has the "keep-data" or "keep-backups" annotations set.
+// pgo-rmdata removes the finalizer once all of the resources are gone, after
+// which Kubernetes deletes the pgcluster. If the pgo-rmdata job failed, or was
+// never started, the rmdata task is created again so that the removal is
+// retried. Nothing is removed while the cluster has deletion protection
+// enabled
+func DeleteClusterFromFinalizer(clientset kubernetes.Interface, restclient *rest.RESTClient,
+	cluster *crv1.Pgcluster) error {
+	if !HasFinalizer(cluster) {
+		return nil
+	}
+
+	// the resources of a protected cluster are kept, and the pgcluster is held
+	// by the finalizer until the protection is disabled
+	if cluster.Spec.DeletionProtection {
+		log.Warnf("pgcluster %s marked for deletion but deletion protection is enabled, "+
+			"not removing its resources", cluster.Name)
+		return nil
+	}
+
+	taskName := cluster.Name + "-rmdata"
+
+	// the cleanup may already be running, e.g. if it was started by
+	// "pgo delete cluster"
+	task := crv1.Pgtask{}
+	if found, _ := kubeapi.Getpgtask(restclient, &task, taskName, cluster.Namespace); found {
+		retry, err := retryRMData(clientset, cluster, &task)
+		if err != nil || !retry {
+			return err
+		}
+
+		if err := kubeapi.Deletepgtask(restclient, taskName, cluster.Namespace); err != nil {
+			return err
+		}
+	}
+
+	keepData, _ := strconv.ParseBool(cluster.Annotations[config.ANNOTATION_KEEP_DATA])
+	keepBackups, _ := strconv.ParseBool(cluster.Annotations[config.ANNOTATION_KEEP_BACKUPS])
+
+	log.Debugf("pgcluster %s marked for deletion, creating rmdata task keep-data=%t keep-backups=%t",
+		cluster.Name, keepData, keepBackups)
+
+	return kubeapi.Createpgtask(restclient, newRMDataTask(cluster, "", taskName, !keepData, !keepBackups),
+		cluster.Namespace)
+}
+
+// retryRMData returns true if the rmdata task of a cluster has to be created
+// again, i.e. if every pgo-rmdata job that was started for the task failed, or
+// if none was started within rmdataTaskTimeout. The failed jobs are removed
+func retryRMData(clientset kubernetes.Interface, cluster *crv1.Pgcluster, task *crv1.Pgtask) (bool, error) {
+	selector := fmt.Sprintf("%s=true,%s=%s", config.LABEL_RMDATA, config.LABEL_PG_CLUSTER, cluster.Name)
+
+	jobs, err := clientset.BatchV1().Jobs(cluster.Namespace).List(meta_v1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return false, err
+	}
+
+	failed := []v1batch.Job{}
+	started := false
+
+	for _, job := range jobs.Items {
+		// jobs from before the task belong to earlier removals, e.g. of a
+		// replica
+		if job.CreationTimestamp.Before(&task.CreationTimestamp) {
+			continue
+		}
+
+		started = true
+
+		if job.Status.Failed == 0 || job.Status.Active > 0 {
+			log.Debugf("rmdata task %s already exists, not creating again", task.Name)
+			return false, nil
+		}
+
+		failed = append(failed, job)
+	}
+
+	if !started {
+		if time.Since(task.CreationTimestamp.Time) < rmdataTaskTimeout {
+			log.Debugf("rmdata task %s already exists, not creating again", task.Name)
+			return false, nil
+		}
+
+		log.Warnf("no rmdata job was started for pgcluster %s, retrying", cluster.Name)
+		return true, nil
+	}
+
+	propagation := meta_v1.DeletePropagationBackground
+
+	for _, job := range failed {
+		log.Warnf("rmdata job %s of pgcluster %s failed, retrying", job.Name, cluster.Name)
+
+		if err := clientset.BatchV1().Jobs(cluster.Namespace).Delete(job.Name,
+			&meta_v1.DeleteOptions{PropagationPolicy: &propagation}); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
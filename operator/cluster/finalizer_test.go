@@ -0,0 +1,297 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/util"
+	appsv1 "k8s.io/api/apps/v1"
+	v1batch "k8s.io/api/batch/v1"
+	policy_v1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	ktesting "k8s.io/client-go/testing"
+)
+
+// fakeCustomResources is an API server for the custom resources of the
+// Operator, and for the Deployments and PodDisruptionBudgets that go along with
+// them, that keeps the objects it is given, and records the requests that
+// change them as "METHOD resource/name"
+type fakeCustomResources struct {
+	objects  map[string][]byte
+	requests []string
+	patches  map[string]string
+}
+
+// newFakeRESTClient returns a RESTClient for the custom resources of the
+// Operator that is served by a fakeCustomResources with the objects provided.
+// The server has to be closed once the test is done
+func newFakeRESTClient(t *testing.T, objects ...runtime.Object) (*rest.RESTClient, *fakeCustomResources,
+	*httptest.Server) {
+	api := &fakeCustomResources{objects: map[string][]byte{}, patches: map[string]string{}}
+
+	for _, object := range objects {
+		accessor, err := meta.Accessor(object)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resource := crv1.PgclusterResourcePlural
+		switch object.(type) {
+		case *crv1.Pgtask:
+			resource = crv1.PgtaskResourcePlural
+		case *appsv1.Deployment:
+			resource = "deployments"
+		case *policy_v1beta1.PodDisruptionBudget:
+			resource = "poddisruptionbudgets"
+		}
+
+		body, err := json.Marshal(object)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		api.objects[resource+"/"+accessor.GetName()] = body
+	}
+
+	server := httptest.NewServer(api)
+
+	restclient, _, err := util.NewClient(&rest.Config{Host: server.URL})
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+
+	return restclient, api, server
+}
+
+func (api *fakeCustomResources) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// e.g. /apis/crunchydata.com/v1/namespaces/pgo/pgtasks/hippo-rmdata
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	key := strings.Join(parts[5:], "/")
+	body, _ := ioutil.ReadAll(r.Body)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		if object, ok := api.objects[key]; ok {
+			w.Write(object)
+			return
+		}
+	case http.MethodPost:
+		object := struct {
+			Metadata meta_v1.ObjectMeta `json:"metadata"`
+		}{}
+		json.Unmarshal(body, &object)
+		key += "/" + object.Metadata.Name
+		api.objects[key] = body
+		api.requests = append(api.requests, r.Method+" "+key)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+		return
+	case http.MethodPut:
+		if _, ok := api.objects[key]; ok {
+			api.objects[key] = body
+			api.requests = append(api.requests, r.Method+" "+key)
+			w.Write(body)
+			return
+		}
+	case http.MethodPatch:
+		if object, ok := api.objects[key]; ok {
+			api.requests = append(api.requests, r.Method+" "+key)
+			api.patches[key] = string(body)
+			w.Write(object)
+			return
+		}
+	case http.MethodDelete:
+		if _, ok := api.objects[key]; ok {
+			delete(api.objects, key)
+			api.requests = append(api.requests, r.Method+" "+key)
+			w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Success"}`))
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`))
+}
+
+func TestAddFinalizer(t *testing.T) {
+	deleted := meta_v1.Now()
+
+	tests := []struct {
+		description string
+		cluster     *crv1.Pgcluster
+		requests    []string
+		finalizers  []string
+	}{
+		{"no finalizers", &crv1.Pgcluster{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo"},
+		}, []string{"PATCH pgclusters/hippo"}, []string{crv1.PgclusterFinalizer}},
+		{"other finalizers", &crv1.Pgcluster{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo", Finalizers: []string{"other"}},
+		}, []string{"PATCH pgclusters/hippo"}, []string{"other", crv1.PgclusterFinalizer}},
+		{"has the finalizer", &crv1.Pgcluster{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo",
+				Finalizers: []string{crv1.PgclusterFinalizer}},
+		}, nil, nil},
+		{"marked for deletion", &crv1.Pgcluster{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo", DeletionTimestamp: &deleted},
+		}, nil, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			restclient, api, server := newFakeRESTClient(t, test.cluster)
+			defer server.Close()
+
+			if err := AddFinalizer(restclient, test.cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(api.requests, test.requests) {
+				t.Fatalf("expected requests %v, got %v", test.requests, api.requests)
+			}
+
+			if test.finalizers == nil {
+				return
+			}
+
+			patch := crv1.Pgcluster{}
+			if err := json.Unmarshal([]byte(api.patches["pgclusters/hippo"]), &patch); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(patch.Finalizers, test.finalizers) {
+				t.Fatalf("expected finalizers %v, got %v", test.finalizers, patch.Finalizers)
+			}
+		})
+	}
+}
+
+func TestDeleteClusterFromFinalizer(t *testing.T) {
+	type job struct {
+		name   string
+		age    time.Duration
+		status v1batch.JobStatus
+	}
+
+	created := []string{"POST pgtasks/hippo-rmdata"}
+	recreated := []string{"DELETE pgtasks/hippo-rmdata", "POST pgtasks/hippo-rmdata"}
+
+	tests := []struct {
+		description string
+		finalizer   bool
+		protected   bool
+		task        bool
+		taskAge     time.Duration
+		jobs        []job
+		requests    []string
+		deletedJobs []string
+	}{
+		{"no finalizer", false, false, false, 0, nil, nil, nil},
+		{"deletion protection", true, true, false, 0, nil, nil, nil},
+		{"no task", true, false, false, 0, nil, created, nil},
+		{"job running", true, false, true, time.Hour, []job{
+			{"hippo-rmdata-abcd", time.Minute, v1batch.JobStatus{Active: 1}},
+		}, nil, nil},
+		{"job succeeded", true, false, true, time.Hour, []job{
+			{"hippo-rmdata-abcd", time.Minute, v1batch.JobStatus{Succeeded: 1}},
+		}, nil, nil},
+		{"job failed", true, false, true, time.Hour, []job{
+			{"hippo-rmdata-abcd", time.Minute, v1batch.JobStatus{Failed: 1}},
+		}, recreated, []string{"hippo-rmdata-abcd"}},
+		{"job of an earlier removal failed", true, false, true, time.Minute, []job{
+			{"hippo-rmdata-wxyz", time.Hour, v1batch.JobStatus{Failed: 1}},
+		}, nil, nil},
+		{"job not started yet", true, false, true, time.Minute, nil, nil, nil},
+		{"job never started", true, false, true, time.Hour, nil, recreated, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			deleted := meta_v1.Now()
+
+			cluster := &crv1.Pgcluster{
+				TypeMeta:   meta_v1.TypeMeta{APIVersion: "crunchydata.com/v1", Kind: "Pgcluster"},
+				ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo", DeletionTimestamp: &deleted},
+				Spec:       crv1.PgclusterSpec{DeletionProtection: test.protected},
+			}
+			if test.finalizer {
+				cluster.Finalizers = []string{crv1.PgclusterFinalizer}
+			}
+
+			objects := []runtime.Object{cluster}
+			if test.task {
+				objects = append(objects, &crv1.Pgtask{
+					TypeMeta: meta_v1.TypeMeta{APIVersion: "crunchydata.com/v1", Kind: "Pgtask"},
+					ObjectMeta: meta_v1.ObjectMeta{Name: "hippo-rmdata", Namespace: "pgo",
+						CreationTimestamp: meta_v1.NewTime(time.Now().Add(-test.taskAge))},
+				})
+			}
+
+			jobs := []runtime.Object{}
+			for _, job := range test.jobs {
+				jobs = append(jobs, &v1batch.Job{
+					ObjectMeta: meta_v1.ObjectMeta{Name: job.name, Namespace: "pgo",
+						CreationTimestamp: meta_v1.NewTime(time.Now().Add(-job.age)),
+						Labels: map[string]string{
+							config.LABEL_RMDATA:     "true",
+							config.LABEL_PG_CLUSTER: "hippo",
+						}},
+					Status: job.status,
+				})
+			}
+
+			restclient, api, server := newFakeRESTClient(t, objects...)
+			defer server.Close()
+			clientset := fake.NewSimpleClientset(jobs...)
+
+			if err := DeleteClusterFromFinalizer(clientset, restclient, cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(api.requests, test.requests) {
+				t.Fatalf("expected requests %v, got %v", test.requests, api.requests)
+			}
+
+			var deletedJobs []string
+			for _, action := range clientset.Actions() {
+				if action, ok := action.(ktesting.DeleteAction); ok && action.GetResource().Resource == "jobs" {
+					deletedJobs = append(deletedJobs, action.GetName())
+				}
+			}
+
+			if !reflect.DeepEqual(deletedJobs, test.deletedJobs) {
+				t.Fatalf("expected deleted jobs %v, got %v", test.deletedJobs, deletedJobs)
+			}
+		})
+	}
+}
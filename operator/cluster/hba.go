@@ -0,0 +1,267 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	"github.com/crunchydata/postgres-operator/operator"
+	"github.com/crunchydata/postgres-operator/util"
+	log "github.com/sirupsen/logrus"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// hbaHeader is the first of the pg_hba.conf rules that the Operator sets in
+// the dynamic configuration of Patroni, which tells them apart from rules that
+// were set otherwise
+const hbaHeader = "# managed by the PostgreSQL Operator"
+
+// patroniDynamicConfig is the part of the dynamic configuration of Patroni
+// that holds the pg_hba.conf rules
+type patroniDynamicConfig struct {
+	PostgreSQL struct {
+		HBA []string `json:"pg_hba"`
+	} `json:"postgresql"`
+}
+
+// GetHBARules returns the pg_hba.conf rules of a cluster if they are managed by
+// the Operator, which is the case when the cluster has pg_hba.conf rules, or
+// the default rules of pgo.yaml are set, or when users of the cluster
+// authenticate with client certificates. Otherwise it returns nil, and the
+// rules of the container or of a custom configuration are used
+func GetHBARules(cluster *crv1.Pgcluster, defaults []string) []string {
+	custom := cluster.Spec.HBA
+	if len(custom) == 0 {
+		// the rules in pgo.yaml are validated when pgo.yaml is loaded
+		custom, _ = config.ParseHBARules(defaults)
+	}
+
+	if len(custom) == 0 && !cluster.Spec.TLS.CertAuth && len(cluster.Spec.TLS.CertAuthUsers) == 0 {
+		return nil
+	}
+
+	return getHBARules(cluster, custom)
+}
+
+// getHBARules returns the pg_hba.conf rules of a cluster. The rules that the
+// Operator needs for replication, monitoring, pgBouncer and the subscriptions
+// of other clusters come first, followed by the rules of the users that
+// authenticate with a client certificate, which are rejected when they try to
+// use a password, and then the custom rules, if any. Otherwise the rules
+// follow the defaults of the container
+func getHBARules(cluster *crv1.Pgcluster, custom []crv1.HBARule) []string {
+	// the local connections of the Operator and of pgBackRest, which runs in
+	// the PostgreSQL pods over SSH, never use a password or a certificate
+	rules := []string{
+		"local all " + crv1.PGUserSuperuser + " peer",
+		"local all " + crv1.PGUserAdmin + " peer",
+	}
+
+	if cluster.Spec.TLSOnly {
+		rules = append(rules, "hostnossl all all all reject")
+	}
+
+	if cluster.Spec.TLS.IsCertAuthUser(crv1.PGUserReplication) {
+		rules = append(rules, "hostssl replication "+crv1.PGUserReplication+" all cert")
+	} else {
+		rules = append(rules, "host replication "+crv1.PGUserReplication+" all md5")
+	}
+
+	// the pgBouncer, monitoring and logical replication users always use
+	// passwords
+	rules = append(rules,
+		"host all "+crv1.PGUserReplication+" all reject",
+		"host all "+crv1.PGUserPgBouncer+" all md5",
+		"host all "+crv1.PGUserMonitor+" all md5",
+		"host all "+crv1.PGUserLogicalReplication+" all md5",
+	)
+
+	// the users that authenticate with a client certificate come before the
+	// custom rules, so that the custom rules cannot let them use a password.
+	// When every user does, the custom rules are never reached
+	if cluster.Spec.TLS.CertAuth {
+		rules = append(rules, "hostssl all all all cert", "host all all all reject")
+	} else {
+		users := append([]string{}, cluster.Spec.TLS.CertAuthUsers...)
+		sort.Strings(users)
+
+		for _, username := range users {
+			// a name that cannot be quoted cannot be the name of a user created
+			// through the Operator either
+			if !cluster.Spec.TLS.IsCertAuthUser(username) || strings.ContainsAny(username, "\"\n") {
+				log.Warnf("hba: not adding a rule for user %q of cluster %s", username, cluster.Name)
+				continue
+			}
+
+			rules = append(rules,
+				fmt.Sprintf(`hostssl all "%s" all cert`, username),
+				fmt.Sprintf(`host all "%s" all reject`, username),
+			)
+		}
+	}
+
+	// the custom rules replace the defaults entirely, so that clients that
+	// they do not allow are rejected
+	if len(custom) > 0 {
+		for _, rule := range custom {
+			if err := rule.Validate(); err != nil {
+				log.Warnf("hba: skipping a rule of cluster %s: %v", cluster.Name, err)
+				continue
+			}
+
+			rules = append(rules, rule.String())
+		}
+
+		return rules
+	}
+
+	if cluster.Spec.TLS.CertAuth {
+		return rules
+	}
+
+	return append(rules, "host all all all md5")
+}
+
+// ValidatePgBouncerHBA returns an error if the custom pg_hba.conf rules of a
+// cluster do not let its pgBouncer pods connect. pgBouncer connects as the
+// users of the application, without TLS, so the rule of the pgBouncer user
+// that the Operator adds is not enough. The pods are only checked once they
+// have an address, i.e. not while the cluster or pgBouncer is being created
+func ValidatePgBouncerHBA(clientset kubernetes.Interface, cluster *crv1.Pgcluster,
+	custom []crv1.HBARule) error {
+	if len(custom) == 0 {
+		return nil
+	}
+
+	selector := fmt.Sprintf("%s=%s,%s=true", config.LABEL_PG_CLUSTER, cluster.Name,
+		config.LABEL_PGBOUNCER)
+
+	pods, err := clientset.CoreV1().Pods(cluster.Namespace).List(meta_v1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods.Items {
+		ip := net.ParseIP(pod.Status.PodIP)
+		if ip == nil {
+			continue
+		}
+
+		if !hbaRulesAllowAddress(custom, ip) {
+			return fmt.Errorf("the pg_hba rules of cluster %s do not let pgBouncer connect from %s, "+
+				"add a \"host\" rule for the network of the pgBouncer pods", cluster.Name, ip)
+		}
+	}
+
+	return nil
+}
+
+// hbaRulesAllowAddress returns true if any of the pg_hba.conf rules lets a
+// client without TLS connect from an address with a password
+func hbaRulesAllowAddress(rules []crv1.HBARule, ip net.IP) bool {
+	for _, rule := range rules {
+		if (rule.Type != "host" && rule.Type != "hostnossl") || rule.Method == "reject" {
+			continue
+		}
+
+		if rule.Address == "all" {
+			return true
+		}
+
+		if _, network, err := net.ParseCIDR(rule.Address); err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reconcileHBA sets the pg_hba.conf rules of a cluster whose rules are managed
+// by the Operator in the dynamic configuration of Patroni, which applies them
+// to every PostgreSQL instance. Rules that the Operator set before are removed
+// once the Operator no longer manages the rules, which restores the ones of
+// the container or of a custom configuration
+func reconcileHBA(clientset *kubernetes.Clientset, restconfig *rest.Config, cluster *crv1.Pgcluster) error {
+	pod, err := util.GetPrimaryPod(clientset, cluster)
+	if err != nil {
+		return err
+	}
+
+	if !isDatabaseContainerReady(pod) {
+		return nil
+	}
+
+	stdout, stderr, err := kubeapi.ExecToPodThroughAPI(restconfig, clientset,
+		[]string{"curl", "-s", "--fail", fmt.Sprintf("http://127.0.0.1:%s/config", config.DEFAULT_PATRONI_PORT)},
+		"database", pod.Name, pod.Namespace, nil)
+	if err != nil {
+		log.Error(stderr)
+		return err
+	}
+
+	current := patroniDynamicConfig{}
+	if err := json.Unmarshal([]byte(stdout), &current); err != nil {
+		return err
+	}
+
+	desired := patroniDynamicConfig{}
+	if rules := GetHBARules(cluster, operator.Pgo.Cluster.HBA); rules != nil {
+		desired.PostgreSQL.HBA = append([]string{hbaHeader}, rules...)
+	} else if len(current.PostgreSQL.HBA) == 0 || current.PostgreSQL.HBA[0] != hbaHeader {
+		return nil
+	}
+
+	if reflect.DeepEqual(current.PostgreSQL.HBA, desired.PostgreSQL.HBA) {
+		return nil
+	}
+
+	log.Infof("reconcile: updating the pg_hba.conf rules of cluster %s", cluster.Name)
+
+	custom := cluster.Spec.HBA
+	if len(custom) == 0 {
+		custom, _ = config.ParseHBARules(operator.Pgo.Cluster.HBA)
+	}
+
+	if err := ValidatePgBouncerHBA(clientset, cluster, custom); err != nil {
+		log.Warn(err)
+	}
+
+	// removing the rules is done by setting them to null
+	patch, err := json.Marshal(desired)
+	if err != nil {
+		return err
+	}
+
+	if _, stderr, err := kubeapi.ExecToPodThroughAPI(restconfig, clientset,
+		[]string{"curl", "-s", "--fail", "-X", "PATCH", "-d", "@-",
+			fmt.Sprintf("http://127.0.0.1:%s/config", config.DEFAULT_PATRONI_PORT)},
+		"database", pod.Name, pod.Namespace, strings.NewReader(string(patch))); err != nil {
+		log.Error(stderr)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"reflect"
+	"testing"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetHBARules(t *testing.T) {
+	newCluster := func(tlsOnly bool, tls crv1.TLSSpec) *crv1.Pgcluster {
+		return &crv1.Pgcluster{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo"},
+			Spec:       crv1.PgclusterSpec{TLS: tls, TLSOnly: tlsOnly},
+		}
+	}
+
+	mandatory := []string{
+		"local all postgres peer",
+		"local all crunchyadm peer",
+		"host replication primaryuser all md5",
+		"host all primaryuser all reject",
+		"host all pgbouncer all md5",
+		"host all ccp_monitoring all md5",
+		"host all logicalreplicator all md5",
+	}
+
+	custom := []crv1.HBARule{
+		{Type: "hostssl", Database: "app", User: "app", Address: "10.0.0.0/8", Method: "scram-sha-256"},
+		{Type: "host", Database: "all", User: "pgbouncer", Address: "all", Method: "trust"},
+		{Type: "hostssl", Database: "all", User: "rhino", Address: "all", Method: "cert"},
+	}
+
+	tests := []struct {
+		description string
+		cluster     *crv1.Pgcluster
+		custom      []crv1.HBARule
+		expected    []string
+	}{
+		{"users", newCluster(false, crv1.TLSSpec{CertAuthUsers: []string{"rhino", "app"}}), nil,
+			append(append([]string{}, mandatory...),
+				`hostssl all "app" all cert`,
+				`host all "app" all reject`,
+				`hostssl all "rhino" all cert`,
+				`host all "rhino" all reject`,
+				"host all all all md5",
+			)},
+		{"cluster", newCluster(true, crv1.TLSSpec{CertAuth: true}), nil, []string{
+			"local all postgres peer",
+			"local all crunchyadm peer",
+			"hostnossl all all all reject",
+			"hostssl replication primaryuser all cert",
+			"host all primaryuser all reject",
+			"host all pgbouncer all md5",
+			"host all ccp_monitoring all md5",
+			"host all logicalreplicator all md5",
+			"hostssl all all all cert",
+			"host all all all reject",
+		}},
+		{"invalid users", newCluster(false, crv1.TLSSpec{CertAuthUsers: []string{"pgbouncer", `a"b`}}), nil,
+			append(append([]string{}, mandatory...), "host all all all md5")},
+		{"custom", newCluster(false, crv1.TLSSpec{CertAuthUsers: []string{"rhino"}}), custom,
+			append(append([]string{}, mandatory...),
+				`hostssl all "rhino" all cert`,
+				`host all "rhino" all reject`,
+				"hostssl app app 10.0.0.0/8 scram-sha-256",
+				"hostssl all rhino all cert",
+			)},
+		{"custom cluster", newCluster(false, crv1.TLSSpec{CertAuth: true}), custom[:1], []string{
+			"local all postgres peer",
+			"local all crunchyadm peer",
+			"hostssl replication primaryuser all cert",
+			"host all primaryuser all reject",
+			"host all pgbouncer all md5",
+			"host all ccp_monitoring all md5",
+			"host all logicalreplicator all md5",
+			"hostssl all all all cert",
+			"host all all all reject",
+			"hostssl app app 10.0.0.0/8 scram-sha-256",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if rules := getHBARules(test.cluster, test.custom); !reflect.DeepEqual(rules, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, rules)
+			}
+		})
+	}
+}
+
+func TestValidatePgBouncerHBA(t *testing.T) {
+	cluster := &crv1.Pgcluster{ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo"}}
+
+	newPod := func(name, ip string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: "pgo", Labels: map[string]string{
+				config.LABEL_PG_CLUSTER: "hippo",
+				config.LABEL_PGBOUNCER:  "true",
+			}},
+			Status: v1.PodStatus{PodIP: ip},
+		}
+	}
+
+	tests := []struct {
+		description string
+		custom      []crv1.HBARule
+		pods        []runtime.Object
+		expectErr   bool
+	}{
+		{"no rules", nil, []runtime.Object{newPod("hippo-pgbouncer-1", "10.1.2.3")}, false},
+		{"no pods", []crv1.HBARule{
+			{Type: "hostssl", Database: "all", User: "all", Address: "192.168.0.0/16", Method: "md5"},
+		}, nil, false},
+		{"pod without an address", []crv1.HBARule{
+			{Type: "hostssl", Database: "all", User: "all", Address: "192.168.0.0/16", Method: "md5"},
+		}, []runtime.Object{newPod("hippo-pgbouncer-1", "")}, false},
+		{"allowed", []crv1.HBARule{
+			{Type: "host", Database: "all", User: "all", Address: "10.0.0.0/8", Method: "md5"},
+		}, []runtime.Object{newPod("hippo-pgbouncer-1", "10.1.2.3"), newPod("hippo-pgbouncer-2", "10.4.5.6")}, false},
+		{"allowed from anywhere", []crv1.HBARule{
+			{Type: "host", Database: "app", User: "app", Address: "all", Method: "scram-sha-256"},
+		}, []runtime.Object{newPod("hippo-pgbouncer-1", "10.1.2.3")}, false},
+		{"other network", []crv1.HBARule{
+			{Type: "host", Database: "all", User: "all", Address: "10.1.0.0/16", Method: "md5"},
+		}, []runtime.Object{newPod("hippo-pgbouncer-1", "10.1.2.3"), newPod("hippo-pgbouncer-2", "10.4.5.6")}, true},
+		{"TLS only", []crv1.HBARule{
+			{Type: "hostssl", Database: "all", User: "all", Address: "10.0.0.0/8", Method: "md5"},
+		}, []runtime.Object{newPod("hippo-pgbouncer-1", "10.1.2.3")}, true},
+		{"rejected", []crv1.HBARule{
+			{Type: "host", Database: "all", User: "all", Address: "10.0.0.0/8", Method: "reject"},
+		}, []runtime.Object{newPod("hippo-pgbouncer-1", "10.1.2.3")}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset(test.pods...)
+
+			if err := ValidatePgBouncerHBA(clientset, cluster, test.custom); (err != nil) != test.expectErr {
+				t.Fatalf("expected error %t, got %v", test.expectErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	"github.com/crunchydata/postgres-operator/util"
+
+	log "github.com/sirupsen/logrus"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// observedSubscription is the state of a subscription in the database of a
+// cluster, as returned by util.SubscriptionStateSQL
+type observedSubscription struct {
+	enabled         bool
+	lastMessageTime string
+	workers         int
+	unsynchronized  int
+}
+
+// RecordSubscriptions records the state of the subscriptions of a cluster in
+// the status of its pgcluster, along with how far behind their replication
+// slots on the source clusters are. The state is read from the primary of the
+// cluster, so a cluster that cannot be queried keeps the state that was
+// recorded last
+func RecordSubscriptions(clientset *kubernetes.Clientset, restclient *rest.RESTClient,
+	restconfig *rest.Config, clusterName, namespace string) error {
+	cluster := crv1.Pgcluster{}
+	if _, err := kubeapi.Getpgcluster(restclient, &cluster, clusterName, namespace); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if cluster.DeletionTimestamp != nil || cluster.Spec.Shutdown {
+		return nil
+	}
+
+	observed := map[string]observedSubscription{}
+	lags := map[string]int64{}
+	databases, sources := map[string]bool{}, map[string]bool{}
+
+	for _, subscription := range cluster.Spec.Subscriptions {
+		if !databases[subscription.Database] {
+			databases[subscription.Database] = true
+
+			output, err := util.ExecSQL(clientset, restconfig, namespace, clusterName,
+				subscription.Database, util.SubscriptionStateSQL())
+			if err != nil {
+				return err
+			}
+
+			for _, row := range parseSQLRows(output) {
+				if len(row) < 5 {
+					continue
+				}
+
+				workers, _ := strconv.Atoi(row[3])
+				unsynchronized, _ := strconv.Atoi(row[4])
+
+				observed[subscription.Database+"/"+row[0]] = observedSubscription{
+					enabled:         row[1] == "t",
+					lastMessageTime: row[2],
+					workers:         workers,
+					unsynchronized:  unsynchronized,
+				}
+			}
+		}
+
+		// the lag is only known while the source cluster can be queried
+		if !sources[subscription.SourceCluster] {
+			sources[subscription.SourceCluster] = true
+
+			output, err := util.ExecSQL(clientset, restconfig, namespace,
+				subscription.SourceCluster, "postgres", util.ReplicationSlotLagSQL())
+			if err != nil {
+				log.Warnf("could not query the replication slots of cluster %s: %v",
+					subscription.SourceCluster, err)
+				continue
+			}
+
+			for _, row := range parseSQLRows(output) {
+				if len(row) < 2 {
+					continue
+				}
+
+				if lag, err := strconv.ParseInt(row[1], 10, 64); err == nil {
+					lags[row[0]] = lag
+				}
+			}
+		}
+	}
+
+	subscriptions := getSubscriptionStatus(&cluster, observed, lags)
+
+	return updateStatus(clientset, restclient, clusterName, namespace,
+		func(status *crv1.PgclusterStatus) {
+			status.Subscriptions = subscriptions
+		})
+}
+
+// getSubscriptionStatus returns the status of each subscription of a cluster
+// from the state that was observed in its databases, by database and name, and
+// the lag of the replication slots on the source clusters, by slot name
+func getSubscriptionStatus(cluster *crv1.Pgcluster, observed map[string]observedSubscription,
+	lags map[string]int64) []crv1.SubscriptionStatus {
+	if len(cluster.Spec.Subscriptions) == 0 {
+		return nil
+	}
+
+	subscriptions := make([]crv1.SubscriptionStatus, 0, len(cluster.Spec.Subscriptions))
+
+	for _, subscription := range cluster.Spec.Subscriptions {
+		status := crv1.SubscriptionStatus{
+			Name:     subscription.Name,
+			Database: subscription.Database,
+		}
+
+		state, ok := observed[subscription.Database+"/"+subscription.Name]
+
+		switch {
+		case !ok:
+			status.State = crv1.SubscriptionStateMissing
+		case !state.enabled:
+			status.State = crv1.SubscriptionStateDisabled
+		case state.workers == 0:
+			status.State = crv1.SubscriptionStateStopped
+		case state.unsynchronized > 0:
+			status.State = crv1.SubscriptionStateInitializing
+		default:
+			status.State = crv1.SubscriptionStateStreaming
+		}
+
+		if ok && state.lastMessageTime != "" {
+			if t, err := time.Parse(time.RFC3339, state.lastMessageTime); err == nil {
+				lastMessageTime := meta_v1.NewTime(t)
+				status.LastMessageTime = &lastMessageTime
+			}
+		}
+
+		if lag, ok := lags[util.LogicalReplicationSlotName(cluster.Name, subscription.Name)]; ok {
+			status.LagBytes = &lag
+		}
+
+		subscriptions = append(subscriptions, status)
+	}
+
+	return subscriptions
+}
+
+// parseSQLRows splits the output of util.ExecSQL into its rows and columns
+func parseSQLRows(output string) [][]string {
+	rows := [][]string{}
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		rows = append(rows, strings.Split(line, "|"))
+	}
+
+	return rows
+}
@@ -0,0 +1,93 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"testing"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetSubscriptionStatus(t *testing.T) {
+	cluster := &crv1.Pgcluster{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "rhino"},
+		Spec: crv1.PgclusterSpec{
+			Subscriptions: []crv1.SubscriptionSpec{
+				{Name: "orders", Database: "hippo", SourceCluster: "hippo", Publication: "orders"},
+				{Name: "customers", Database: "hippo", SourceCluster: "hippo", Publication: "customers"},
+				{Name: "stock", Database: "hippo", SourceCluster: "hippo", Publication: "stock"},
+				{Name: "payments", Database: "hippo", SourceCluster: "hippo", Publication: "payments"},
+				{Name: "returns", Database: "hippo", SourceCluster: "hippo", Publication: "returns"},
+			},
+		},
+	}
+
+	observed := map[string]observedSubscription{
+		"hippo/orders":    {enabled: true, lastMessageTime: "2020-06-01T10:00:00Z", workers: 1},
+		"hippo/customers": {enabled: true, workers: 2, unsynchronized: 3},
+		"hippo/stock":     {enabled: true},
+		"hippo/payments":  {},
+	}
+
+	subscriptions := getSubscriptionStatus(cluster, observed, map[string]int64{"rhino_orders": 1024})
+
+	expected := []string{
+		crv1.SubscriptionStateStreaming,
+		crv1.SubscriptionStateInitializing,
+		crv1.SubscriptionStateStopped,
+		crv1.SubscriptionStateDisabled,
+		crv1.SubscriptionStateMissing,
+	}
+
+	if len(subscriptions) != len(expected) {
+		t.Fatalf("expected %d subscriptions, got %+v", len(expected), subscriptions)
+	}
+
+	for i, state := range expected {
+		if subscriptions[i].State != state {
+			t.Fatalf("subscriptions[%d] - expected state %q, got %q", i, state, subscriptions[i].State)
+		}
+	}
+
+	if subscriptions[0].LagBytes == nil || *subscriptions[0].LagBytes != 1024 {
+		t.Fatalf("expected a lag of 1024 bytes, got %v", subscriptions[0].LagBytes)
+	}
+
+	if subscriptions[0].LastMessageTime == nil || subscriptions[0].LastMessageTime.Hour() != 10 {
+		t.Fatalf("expected the time of the last message, got %v", subscriptions[0].LastMessageTime)
+	}
+
+	if subscriptions[1].LagBytes != nil || subscriptions[1].LastMessageTime != nil {
+		t.Fatalf("expected no lag and no last message, got %+v", subscriptions[1])
+	}
+
+	if subscriptions := getSubscriptionStatus(&crv1.Pgcluster{}, observed, nil); subscriptions != nil {
+		t.Fatalf("expected no subscriptions, got %+v", subscriptions)
+	}
+}
+
+func TestParseSQLRows(t *testing.T) {
+	rows := parseSQLRows("orders|t||1|0\ncustomers|f||0|0\n\n")
+
+	if len(rows) != 2 || len(rows[0]) != 5 || rows[0][0] != "orders" || rows[1][1] != "f" {
+		t.Fatalf("unexpected rows %+v", rows)
+	}
+
+	if rows := parseSQLRows(""); len(rows) != 0 {
+		t.Fatalf("expected no rows, got %+v", rows)
+	}
+}
@@ -0,0 +1,946 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/events"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	"github.com/crunchydata/postgres-operator/operator"
+	backrestoperator "github.com/crunchydata/postgres-operator/operator/backrest"
+	"github.com/crunchydata/postgres-operator/util"
+	log "github.com/sirupsen/logrus"
+	batch_v1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// majorUpgradeSettingsSQL returns the settings of the cluster that the new
+// data directory has to be initialized with, along with the version of the
+// running server
+const majorUpgradeSettingsSQL = `SELECT current_setting('server_version_num'),
+  pg_encoding_to_char(encoding), datcollate, datctype, current_setting('data_checksums')
+FROM pg_database WHERE datname = 'template1';`
+
+// majorUpgradeDatabasesSQL returns the databases that extensions can be
+// installed in
+const majorUpgradeDatabasesSQL = `SELECT datname FROM pg_database WHERE datallowconn ORDER BY datname;`
+
+// majorUpgradeExtensionsSQL returns the extensions installed in a database
+const majorUpgradeExtensionsSQL = `SELECT extname || ' ' || extversion FROM pg_extension ORDER BY extname;`
+
+// majorUpgradeStopCommand cleanly stops PostgreSQL in the database container,
+// which pg_upgrade requires of the old cluster
+const majorUpgradeStopCommand = `pg_ctl stop -m fast -w -D "${PATRONI_POSTGRESQL_DATA_DIR}"`
+
+// majorUpgradePrimaryShutdownTimeout is how long to wait for the pod of the
+// primary to terminate before the upgrade is rolled back
+const majorUpgradePrimaryShutdownTimeout = 2 * time.Minute
+
+// majorUpgradeReplicaStatus is set on the pgreplicas that are re-created
+// after a major upgrade, which ensures they are scaled up again once the
+// cluster is initialized
+const majorUpgradeReplicaStatus = "upgrade"
+
+type pgUpgradeJobTemplateFields struct {
+	JobName                string
+	ClusterName            string
+	PVCName                string
+	SecurityContext        string
+	CCPImagePrefix         string
+	CCPImage               string
+	CCPImageTag            string
+	DataDirectory          string
+	OldVersion             string
+	NewVersion             string
+	Encoding               string
+	LCCollate              string
+	LCCType                string
+	DataChecksums          string
+	Extensions             string
+	TablespaceVolumes      string
+	TablespaceVolumeMounts string
+	WorkflowID             string
+}
+
+// AddMajorUpgrade starts the major upgrade of a cluster. It checks that the
+// primary is running the PostgreSQL version that is upgraded from, records the
+// settings that the new data directory is initialized with, and takes the
+// full pgBackRest backup that the upgrade can be rolled back to
+func AddMajorUpgrade(clientset *kubernetes.Clientset, restclient *rest.RESTClient, restconfig *rest.Config,
+	task *crv1.Pgtask, namespace string) {
+
+	clusterName := task.Spec.Parameters[config.LABEL_PG_CLUSTER]
+	log.Debugf("major upgrade: started for cluster %s", clusterName)
+
+	cluster := crv1.Pgcluster{}
+	if found, err := kubeapi.Getpgcluster(restclient, &cluster, clusterName, namespace); !found || err != nil {
+		log.Errorf("major upgrade: could not find pgcluster %s", clusterName)
+		return
+	}
+
+	primaryPod, err := util.GetPrimaryPod(clientset, &cluster)
+	if err != nil {
+		failMajorUpgrade(restclient, task, err.Error())
+		return
+	}
+
+	if err := checkMajorUpgrade(clientset, restconfig, primaryPod, task); err != nil {
+		failMajorUpgrade(restclient, task, err.Error())
+		return
+	}
+
+	// look up the pgBackRest repository pod, which takes the backup
+	selector := fmt.Sprintf("%s=%s,%s=true", config.LABEL_PG_CLUSTER, clusterName,
+		config.LABEL_PGO_BACKREST_REPO)
+	pods, err := kubeapi.GetPods(clientset, selector, namespace)
+	if err != nil {
+		failMajorUpgrade(restclient, task, err.Error())
+		return
+	} else if len(pods.Items) != 1 {
+		failMajorUpgrade(restclient, task, fmt.Sprintf("expected 1 pgBackRest repository pod for "+
+			"cluster %s, found %d", clusterName, len(pods.Items)))
+		return
+	}
+
+	task.Spec.Parameters[config.LABEL_DEPLOYMENT_NAME] = primaryPod.Labels[config.LABEL_DEPLOYMENT_NAME]
+	task.Spec.Status = crv1.InProgressStatus
+	if err := updateMajorUpgradeStep(restclient, task, crv1.PgtaskWorkflowMajorUpgradeBackup); err != nil {
+		log.Error(err)
+		return
+	}
+
+	if err := labelClusterForMajorUpgrade(restclient, clusterName, namespace,
+		config.LABEL_UPGRADE_IN_PROGRESS); err != nil {
+		log.Error(err)
+	}
+
+	publishMajorUpgradeEvent(events.EventUpgradeCluster, task, "")
+
+	// the backup is taken while the cluster is still running. once it has
+	// completed, the job controller continues with the upgrade
+	if err := backrestoperator.CleanBackupResources(restclient, clientset, namespace, clusterName); err != nil {
+		failMajorUpgrade(restclient, task, err.Error())
+		return
+	}
+
+	params := map[string]string{
+		config.LABEL_PGHA_BACKUP_TYPE: crv1.BackupTypeMajorUpgrade,
+	}
+
+	if _, err := backrestoperator.CreateBackup(restclient, namespace, clusterName, pods.Items[0].Name,
+		params, "--type=full"); err != nil {
+		failMajorUpgrade(restclient, task, err.Error())
+	}
+}
+
+// MajorUpgradeShutdown continues the major upgrade of a cluster once its
+// pre-upgrade backup has completed. The replicas are removed, PostgreSQL is
+// stopped on the primary, and the job that runs pg_upgrade against the
+// data directory of the primary is created
+func MajorUpgradeShutdown(clientset *kubernetes.Clientset, restclient *rest.RESTClient, restconfig *rest.Config,
+	clusterName, namespace string) {
+
+	task, ok := getMajorUpgradeTask(restclient, clusterName, namespace, crv1.PgtaskWorkflowMajorUpgradeBackup)
+	if !ok {
+		return
+	}
+
+	if err := updateMajorUpgradeStep(restclient, task, crv1.PgtaskWorkflowMajorUpgradeShutdown); err != nil {
+		log.Error(err)
+		return
+	}
+
+	cluster := crv1.Pgcluster{}
+	if _, err := kubeapi.Getpgcluster(restclient, &cluster, clusterName, namespace); err != nil {
+		failMajorUpgrade(restclient, task, err.Error())
+		return
+	}
+
+	primary := task.Spec.Parameters[config.LABEL_DEPLOYMENT_NAME]
+	scope := cluster.Labels[config.LABEL_PGHA_SCOPE]
+
+	// pause Patroni so that it does not restart PostgreSQL once it is stopped,
+	// and stop PostgreSQL cleanly, as pg_upgrade requires
+	if err := util.ToggleAutoFailover(clientset, false, scope, namespace); err != nil {
+		failMajorUpgrade(restclient, task, err.Error())
+		return
+	}
+
+	if err := stopPrimaryForMajorUpgrade(clientset, restconfig, primary, namespace); err != nil {
+		if util.IsAutofailEnabled(&cluster) {
+			util.ToggleAutoFailover(clientset, true, scope, namespace)
+		}
+		failMajorUpgrade(restclient, task, err.Error())
+		return
+	}
+
+	// from here on the cluster is down, so if anything goes wrong before
+	// pg_upgrade runs the cluster is restarted on the old version
+	message := fmt.Sprintf("Cluster is being upgraded to PostgreSQL %s",
+		task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_TO_VERSION])
+	if err := kubeapi.PatchpgclusterStatus(restclient, crv1.PgclusterStateMajorUpgrade, message,
+		&cluster, namespace); err != nil {
+		rollbackMajorUpgrade(clientset, restclient, task, err.Error())
+		return
+	}
+
+	if err := removeReplicasForMajorUpgrade(clientset, restclient, &cluster, primary); err != nil {
+		rollbackMajorUpgrade(clientset, restclient, task, err.Error())
+		return
+	}
+
+	if err := scaleMajorUpgradePrimary(clientset, primary, namespace, 0); err != nil {
+		rollbackMajorUpgrade(clientset, restclient, task, err.Error())
+		return
+	}
+
+	if err := waitForPodsDeleted(clientset, primary, namespace); err != nil {
+		rollbackMajorUpgrade(clientset, restclient, task, err.Error())
+		return
+	}
+
+	if err := createPGUpgradeJob(clientset, &cluster, task); err != nil {
+		rollbackMajorUpgrade(clientset, restclient, task, err.Error())
+		return
+	}
+
+	if err := updateMajorUpgradeStep(restclient, task, crv1.PgtaskWorkflowMajorUpgradePGUpgrade); err != nil {
+		log.Error(err)
+	}
+}
+
+// MajorUpgradeStartPrimary starts the primary of a cluster on the new
+// PostgreSQL version once pg_upgrade has upgraded its data directory. As the
+// upgraded cluster has a new system identifier, Patroni is set up to
+// initialize the cluster again. Once the primary is ready, the pod controller
+// upgrades the pgBackRest stanza
+func MajorUpgradeStartPrimary(clientset *kubernetes.Clientset, restclient *rest.RESTClient,
+	clusterName, namespace string) {
+
+	task, ok := getMajorUpgradeTask(restclient, clusterName, namespace, crv1.PgtaskWorkflowMajorUpgradePGUpgrade)
+	if !ok {
+		return
+	}
+
+	// from here on the data directory is upgraded, so rolling back is a
+	// manual process
+	if err := updateMajorUpgradeStep(restclient, task, crv1.PgtaskWorkflowMajorUpgradeStanzaUpgrade); err != nil {
+		log.Error(err)
+		return
+	}
+
+	cluster := crv1.Pgcluster{}
+	if _, err := kubeapi.Getpgcluster(restclient, &cluster, clusterName, namespace); err != nil {
+		failMajorUpgrade(restclient, task, err.Error())
+		return
+	}
+
+	if err := resetPatroniForMajorUpgrade(clientset, cluster.Labels[config.LABEL_PGHA_SCOPE],
+		namespace); err != nil {
+		failMajorUpgrade(restclient, task, err.Error())
+		return
+	}
+
+	ccpImageTag := task.Spec.Parameters["CCPImageTag"]
+	updateClusterCCPImage(restclient, ccpImageTag, clusterName, namespace)
+
+	imageNamePatch, err := createImageNamePatch(cluster, operator.Pgo.Cluster.CCPImagePrefix, ccpImageTag)
+	if err != nil {
+		failMajorUpgrade(restclient, task, err.Error())
+		return
+	}
+
+	primary := task.Spec.Parameters[config.LABEL_DEPLOYMENT_NAME]
+	if err := kubeapi.PatchDeploymentStrategicMerge(clientset, primary, namespace, imageNamePatch); err != nil {
+		failMajorUpgrade(restclient, task, err.Error())
+		return
+	}
+
+	if err := scaleMajorUpgradePrimary(clientset, primary, namespace, 1); err != nil {
+		failMajorUpgrade(restclient, task, err.Error())
+	}
+}
+
+// MajorUpgradeStanzaUpgrade upgrades the pgBackRest stanza of a cluster once
+// the primary is running on the new PostgreSQL version
+func MajorUpgradeStanzaUpgrade(clientset *kubernetes.Clientset, restclient *rest.RESTClient,
+	cluster *crv1.Pgcluster) error {
+
+	if _, ok := getMajorUpgradeTask(restclient, cluster.Name, cluster.Namespace,
+		crv1.PgtaskWorkflowMajorUpgradeStanzaUpgrade); !ok {
+		return fmt.Errorf("no major upgrade of cluster %s is waiting for its stanza upgrade", cluster.Name)
+	}
+
+	return backrestoperator.StanzaUpgrade(cluster.Namespace, cluster.Name, clientset, restclient)
+}
+
+// MajorUpgradeReinitialize takes a new full backup of a cluster once its
+// pgBackRest stanza has been upgraded. When that backup completes, the cluster
+// is initialized and the replicas are re-created from it
+func MajorUpgradeReinitialize(clientset *kubernetes.Clientset, restclient *rest.RESTClient,
+	clusterName, namespace, backrestRepoPodName string) {
+
+	task, ok := getMajorUpgradeTask(restclient, clusterName, namespace, crv1.PgtaskWorkflowMajorUpgradeStanzaUpgrade)
+	if !ok {
+		return
+	}
+
+	if err := updateMajorUpgradeStep(restclient, task, crv1.PgtaskWorkflowMajorUpgradeReplicas); err != nil {
+		log.Error(err)
+		return
+	}
+
+	if err := backrestoperator.CleanBackupResources(restclient, clientset, namespace, clusterName); err != nil {
+		failMajorUpgrade(restclient, task, err.Error())
+		return
+	}
+
+	if _, err := backrestoperator.CreateInitialBackup(restclient, namespace, clusterName,
+		backrestRepoPodName); err != nil {
+		failMajorUpgrade(restclient, task, err.Error())
+	}
+}
+
+// CompleteMajorUpgrade completes the major upgrade of a cluster, if one is in
+// progress, once the cluster has been initialized again
+func CompleteMajorUpgrade(restclient *rest.RESTClient, clusterName, namespace string) {
+	task, ok := getMajorUpgradeTask(restclient, clusterName, namespace, crv1.PgtaskWorkflowMajorUpgradeReplicas)
+	if !ok {
+		return
+	}
+
+	task.Spec.Status = crv1.CompletedStatus
+	if err := updateMajorUpgradeStep(restclient, task, crv1.PgtaskWorkflowCompletedStatus); err != nil {
+		log.Error(err)
+	}
+
+	if err := labelClusterForMajorUpgrade(restclient, clusterName, namespace,
+		config.LABEL_UPGRADE_COMPLETED); err != nil {
+		log.Error(err)
+	}
+
+	publishMajorUpgradeEvent(events.EventUpgradeClusterCompleted, task, "")
+
+	log.Debugf("major upgrade: completed for cluster %s", clusterName)
+}
+
+// FailMajorUpgrade stops the major upgrade of a cluster after one of its jobs
+// failed. If pg_upgrade failed, the PostgreSQL data directory of the old
+// version is intact, so the cluster is restarted on it
+func FailMajorUpgrade(clientset *kubernetes.Clientset, restclient *rest.RESTClient,
+	clusterName, namespace, message string) {
+
+	task := crv1.Pgtask{}
+	found, _ := kubeapi.Getpgtask(restclient, &task, clusterName+"-"+config.LABEL_MAJOR_UPGRADE, namespace)
+	if !found || task.Spec.Status != crv1.InProgressStatus {
+		return
+	}
+
+	if task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_STEP] == crv1.PgtaskWorkflowMajorUpgradePGUpgrade {
+		rollbackMajorUpgrade(clientset, restclient, &task, message)
+		return
+	}
+
+	failMajorUpgrade(restclient, &task, message)
+}
+
+// checkMajorUpgrade checks that the primary runs the PostgreSQL version the
+// cluster is upgraded from and records the settings that the new data
+// directory is initialized with, as well as the installed extensions. The
+// pg_upgrade job checks that each of the extensions is available for the new
+// version before the data directory is changed
+func checkMajorUpgrade(clientset *kubernetes.Clientset, restconfig *rest.Config, pod *v1.Pod,
+	task *crv1.Pgtask) error {
+
+	output, err := execMajorUpgradeSQL(clientset, restconfig, pod, "postgres", majorUpgradeSettingsSQL)
+	if err != nil {
+		return err
+	}
+
+	majorVersion, settings, err := parseMajorUpgradeSettings(output)
+	if err != nil {
+		return err
+	}
+
+	if expected := task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_FROM_VERSION]; majorVersion != expected {
+		return fmt.Errorf("the primary is running PostgreSQL %s, not %s", majorVersion, expected)
+	}
+
+	task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_ENCODING] = settings[0]
+	task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_LC_COLLATE] = settings[1]
+	task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_LC_CTYPE] = settings[2]
+	task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_DATA_CHECKSUMS] = settings[3]
+
+	output, err = execMajorUpgradeSQL(clientset, restconfig, pod, "postgres", majorUpgradeDatabasesSQL)
+	if err != nil {
+		return err
+	}
+
+	extensions, outputs := []string{}, []string{}
+	for _, database := range strings.Fields(output) {
+		output, err := execMajorUpgradeSQL(clientset, restconfig, pod, database, majorUpgradeExtensionsSQL)
+		if err != nil {
+			return err
+		}
+
+		if output != "" {
+			extensions = append(extensions, fmt.Sprintf("%s: %s", database,
+				strings.Join(strings.Split(output, "\n"), ", ")))
+			outputs = append(outputs, output)
+		}
+	}
+
+	task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_EXTENSIONS] = strings.Join(extensions, "; ")
+	task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_EXTENSION_NAMES] =
+		strings.Join(getMajorUpgradeExtensionNames(outputs), " ")
+
+	return nil
+}
+
+// parseMajorUpgradeSettings parses the output of majorUpgradeSettingsSQL. It
+// returns the PostgreSQL major version of the server, e.g. "12" or "9.6", and
+// the encoding, collation, character classification and data checksum
+// settings, in that order
+func parseMajorUpgradeSettings(output string) (string, []string, error) {
+	settings := strings.Split(output, "|")
+	if len(settings) != 5 {
+		return "", nil, fmt.Errorf("unexpected settings returned by the primary: %q", output)
+	}
+
+	serverVersion, err := strconv.Atoi(settings[0])
+	if err != nil {
+		return "", nil, err
+	}
+
+	// prior to PostgreSQL 10 the major version is made up of two numbers
+	majorVersion := strconv.Itoa(serverVersion / 10000)
+	if serverVersion < 100000 {
+		majorVersion = fmt.Sprintf("%d.%d", serverVersion/10000, serverVersion/100%100)
+	}
+
+	return majorVersion, settings[1:], nil
+}
+
+// getMajorUpgradeExtensionNames returns the names of the extensions listed by
+// majorUpgradeExtensionsSQL in each of the databases, sorted and without
+// duplicates
+func getMajorUpgradeExtensionNames(outputs []string) []string {
+	names := []string{}
+	found := map[string]bool{}
+
+	for _, output := range outputs {
+		for _, line := range strings.Split(output, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 || found[fields[0]] {
+				continue
+			}
+
+			found[fields[0]] = true
+			names = append(names, fields[0])
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// createPGUpgradeJob creates the job that runs pg_upgrade against the data
+// directory of the primary. Any job of a previous upgrade is removed first
+func createPGUpgradeJob(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster, task *crv1.Pgtask) error {
+	primary := task.Spec.Parameters[config.LABEL_DEPLOYMENT_NAME]
+	jobName := fmt.Sprintf("%s-pg-upgrade", cluster.Name)
+
+	if job, found := kubeapi.GetJob(clientset, jobName, cluster.Namespace); found {
+		if err := kubeapi.DeleteJob(clientset, jobName, cluster.Namespace); err != nil {
+			return err
+		}
+		if err := kubeapi.IsJobDeleted(clientset, cluster.Namespace, job, time.Minute); err != nil {
+			return err
+		}
+	}
+
+	deployment, found, err := kubeapi.GetDeployment(clientset, primary, cluster.Namespace)
+	if !found {
+		return fmt.Errorf("could not find primary deployment %s: %v", primary, err)
+	}
+
+	pvcName := ""
+	for _, volume := range deployment.Spec.Template.Spec.Volumes {
+		if volume.Name == "pgdata" && volume.PersistentVolumeClaim != nil {
+			pvcName = volume.PersistentVolumeClaim.ClaimName
+		}
+	}
+
+	if pvcName == "" {
+		return fmt.Errorf("primary deployment %s does not have a pgdata PVC", primary)
+	}
+
+	tablespaceStorageTypeMap := operator.GetTablespaceStorageTypeMap(cluster.Spec.TablespaceMounts)
+
+	fields := pgUpgradeJobTemplateFields{
+		JobName:                jobName,
+		ClusterName:            cluster.Name,
+		PVCName:                pvcName,
+		SecurityContext:        util.GetPodSecurityContext(cluster.Spec.PrimaryStorage.GetSupplementalGroups()),
+		CCPImagePrefix:         operator.Pgo.Cluster.CCPImagePrefix,
+		CCPImage:               config.CONTAINER_IMAGE_CRUNCHY_UPGRADE,
+		CCPImageTag:            task.Spec.Parameters["CCPImageTag"],
+		DataDirectory:          primary,
+		OldVersion:             task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_FROM_VERSION],
+		NewVersion:             task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_TO_VERSION],
+		Encoding:               task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_ENCODING],
+		LCCollate:              task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_LC_COLLATE],
+		LCCType:                task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_LC_CTYPE],
+		DataChecksums:          task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_DATA_CHECKSUMS],
+		Extensions:             task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_EXTENSION_NAMES],
+		TablespaceVolumes:      operator.GetTablespaceVolumesJSON(primary, tablespaceStorageTypeMap),
+		TablespaceVolumeMounts: operator.GetTablespaceVolumeMountsJSON(tablespaceStorageTypeMap),
+		WorkflowID:             task.Spec.Parameters[crv1.PgtaskWorkflowID],
+	}
+
+	var doc bytes.Buffer
+	if err := config.PgUpgradeJobTemplate.Execute(&doc, fields); err != nil {
+		return err
+	}
+
+	if operator.CRUNCHY_DEBUG {
+		config.PgUpgradeJobTemplate.Execute(os.Stdout, fields)
+	}
+
+	job := batch_v1.Job{}
+	if err := json.Unmarshal(doc.Bytes(), &job); err != nil {
+		log.Error("error unmarshalling json into Job " + err.Error())
+		return err
+	}
+
+	operator.SetContainerImageOverride(config.CONTAINER_IMAGE_CRUNCHY_UPGRADE,
+		&job.Spec.Template.Spec.Containers[0])
+
+	task.Spec.Parameters[config.LABEL_PVC_NAME] = pvcName
+
+	_, err = kubeapi.CreateJob(clientset, &job, cluster.Namespace)
+	return err
+}
+
+// execMajorUpgradeSQL runs a SQL statement in a database on a pod and returns
+// the result
+func execMajorUpgradeSQL(clientset *kubernetes.Clientset, restconfig *rest.Config, pod *v1.Pod,
+	database, sql string) (string, error) {
+
+	cmd := []string{"psql", "-A", "-t", "-d", database}
+
+	stdout, stderr, err := kubeapi.ExecToPodThroughAPI(restconfig, clientset, cmd, "database",
+		pod.Name, pod.Namespace, strings.NewReader(sql))
+	if err != nil {
+		log.Error(stderr)
+		return "", err
+	}
+
+	return strings.TrimSpace(stdout), nil
+}
+
+// stopPrimaryForMajorUpgrade cleanly stops PostgreSQL on the primary
+func stopPrimaryForMajorUpgrade(clientset *kubernetes.Clientset, restconfig *rest.Config,
+	primary, namespace string) error {
+
+	pod, err := util.GetPod(clientset, primary, namespace)
+	if err != nil {
+		return err
+	}
+
+	cmd := []string{"bash", "-c", majorUpgradeStopCommand}
+
+	if _, stderr, err := kubeapi.ExecToPodThroughAPI(restconfig, clientset, cmd, "database",
+		pod.Name, namespace, nil); err != nil {
+		log.Error(stderr)
+		return fmt.Errorf("could not stop PostgreSQL on %s: %v", pod.Name, err)
+	}
+
+	return nil
+}
+
+// removeReplicasForMajorUpgrade removes the deployments and PVCs of all of the
+// replicas of a cluster, as their data cannot be upgraded. The pgreplicas are
+// kept, and reset so that the replicas are re-created from the backup that is
+// taken once the primary runs on the new version. An instance without a
+// pgreplica, i.e. the original primary after a failover, gets one so that it
+// is re-created as well
+func removeReplicasForMajorUpgrade(clientset *kubernetes.Clientset, restclient *rest.RESTClient,
+	cluster *crv1.Pgcluster, primary string) error {
+
+	namespace := cluster.Namespace
+
+	replicaList := crv1.PgreplicaList{}
+	selector := fmt.Sprintf("%s=%s", config.LABEL_PG_CLUSTER, cluster.Name)
+	if err := kubeapi.GetpgreplicasBySelector(restclient, &replicaList, selector, namespace); err != nil {
+		return err
+	}
+
+	replicas := map[string]crv1.Pgreplica{}
+	for _, replica := range replicaList.Items {
+		if replica.Spec.Name != primary {
+			replicas[replica.Spec.Name] = replica
+		}
+	}
+
+	selector = fmt.Sprintf("%s=%s,%s=true", config.LABEL_PG_CLUSTER, cluster.Name, config.LABEL_PG_DATABASE)
+	deployments, err := kubeapi.GetDeployments(clientset, selector, namespace)
+	if err != nil {
+		return err
+	}
+
+	for _, deployment := range deployments.Items {
+		if deployment.Name == primary {
+			continue
+		}
+
+		if err := kubeapi.DeleteDeployment(clientset, deployment.Name, namespace); err != nil {
+			return err
+		}
+
+		pvcNames := []string{deployment.Name}
+		for tablespaceName := range cluster.Spec.TablespaceMounts {
+			pvcNames = append(pvcNames, operator.GetTablespacePVCName(deployment.Name, tablespaceName))
+		}
+
+		for _, pvcName := range pvcNames {
+			if err := kubeapi.DeletePVC(clientset, pvcName, namespace); err != nil && !kerrors.IsNotFound(err) {
+				return err
+			}
+		}
+
+		if _, ok := replicas[deployment.Name]; ok {
+			continue
+		}
+
+		replica := crv1.Pgreplica{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name: deployment.Name,
+				Labels: map[string]string{
+					config.LABEL_PG_CLUSTER:            cluster.Name,
+					config.LABEL_PG_CLUSTER_IDENTIFIER: cluster.Labels[config.LABEL_PG_CLUSTER_IDENTIFIER],
+					config.LABEL_PGOUSER:               cluster.Labels[config.LABEL_PGOUSER],
+				},
+			},
+			Spec: crv1.PgreplicaSpec{
+				Namespace:      namespace,
+				Name:           deployment.Name,
+				ClusterName:    cluster.Name,
+				ReplicaStorage: cluster.Spec.ReplicaStorage,
+				UserLabels:     map[string]string{},
+				Status:         majorUpgradeReplicaStatus,
+			},
+			Status: crv1.PgreplicaStatus{
+				State: crv1.PgreplicaStatePendingInit,
+			},
+		}
+
+		for k, v := range cluster.Spec.UserLabels {
+			replica.Spec.UserLabels[k] = v
+		}
+
+		if err := kubeapi.Createpgreplica(restclient, &replica, namespace); err != nil {
+			return err
+		}
+	}
+
+	for _, replica := range replicas {
+		replica.Status.State = crv1.PgreplicaStatePendingInit
+		replica.Spec.Status = majorUpgradeReplicaStatus
+		// the replica is created on the new version, and not from a snapshot of
+		// the old version
+		replica.Spec.Snapshot = ""
+		delete(replica.Spec.UserLabels, config.LABEL_CCP_IMAGE_TAG_KEY)
+		delete(replica.Annotations, config.ANNOTATION_PGHA_BOOTSTRAP_REPLICA)
+
+		if err := kubeapi.Updatepgreplica(restclient, &replica, replica.Name, namespace); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resetPatroniForMajorUpgrade removes the state Patroni keeps about the
+// cluster before it is upgraded, i.e. the system identifier it was
+// initialized with and its leader. Automated failover is enabled again so that
+// Patroni starts PostgreSQL, and is disabled again during the initialization
+// of the cluster if it is turned off for the cluster
+func resetPatroniForMajorUpgrade(clientset *kubernetes.Clientset, scope, namespace string) error {
+	configMapName := scope + "-config"
+	configMap, found := kubeapi.GetConfigMap(clientset, configMapName, namespace)
+	if !found {
+		return fmt.Errorf("could not find configMap %s", configMapName)
+	}
+
+	delete(configMap.Annotations, "initialize")
+	if err := kubeapi.UpdateConfigMap(clientset, configMap, namespace); err != nil {
+		return err
+	}
+
+	if err := kubeapi.DeleteConfigMap(clientset, scope+"-leader", namespace); err != nil &&
+		!kerrors.IsNotFound(err) {
+		return err
+	}
+
+	return util.ToggleAutoFailover(clientset, true, scope, namespace)
+}
+
+// rollbackMajorUpgrade stops the major upgrade of a cluster before its data
+// directory was changed, and starts the primary again on the old version. The
+// cluster is then reinitialized the same way as after a restore, which
+// re-creates the replicas
+func rollbackMajorUpgrade(clientset *kubernetes.Clientset, restclient *rest.RESTClient, task *crv1.Pgtask,
+	message string) {
+
+	clusterName := task.Spec.Parameters[config.LABEL_PG_CLUSTER]
+	namespace := task.Spec.Namespace
+
+	log.Errorf("major upgrade: rolling back the upgrade of cluster %s: %s", clusterName, message)
+
+	failMajorUpgrade(restclient, task, message)
+
+	cluster := crv1.Pgcluster{}
+	if _, err := kubeapi.Getpgcluster(restclient, &cluster, clusterName, namespace); err != nil {
+		log.Error(err)
+		return
+	}
+
+	if err := kubeapi.PatchpgclusterStatus(restclient, crv1.PgclusterStateRestore,
+		"Cluster is being restarted after a failed major upgrade", &cluster, namespace); err != nil {
+		log.Error(err)
+		return
+	}
+
+	if err := util.ToggleAutoFailover(clientset, true, cluster.Labels[config.LABEL_PGHA_SCOPE],
+		namespace); err != nil {
+		log.Error(err)
+	}
+
+	if err := scaleMajorUpgradePrimary(clientset, task.Spec.Parameters[config.LABEL_DEPLOYMENT_NAME],
+		namespace, 1); err != nil {
+		log.Error(err)
+	}
+}
+
+// failMajorUpgrade marks the major upgrade of a cluster as failed
+func failMajorUpgrade(restclient *rest.RESTClient, task *crv1.Pgtask, message string) {
+	clusterName := task.Spec.Parameters[config.LABEL_PG_CLUSTER]
+
+	log.Errorf("major upgrade: upgrade of cluster %s failed: %s", clusterName, message)
+
+	task.Spec.Status = crv1.FailedStatus
+	task.Status.Message = message
+	if err := updateMajorUpgradeStep(restclient, task, crv1.PgtaskWorkflowMajorUpgradeFailed); err != nil {
+		log.Error(err)
+	}
+
+	if err := labelClusterForMajorUpgrade(restclient, clusterName, task.Spec.Namespace,
+		config.LABEL_UPGRADE_FAILED); err != nil {
+		log.Error(err)
+	}
+
+	publishMajorUpgradeEvent(events.EventUpgradeClusterFailure, task, message)
+}
+
+// getMajorUpgradeTask returns the task of the major upgrade of a cluster if
+// the upgrade is in progress and at the expected step. Jobs and pods can be
+// updated more than once, so this ensures that each step runs only once
+func getMajorUpgradeTask(restclient *rest.RESTClient, clusterName, namespace, step string) (*crv1.Pgtask, bool) {
+	task := crv1.Pgtask{}
+	found, err := kubeapi.Getpgtask(restclient, &task, clusterName+"-"+config.LABEL_MAJOR_UPGRADE, namespace)
+	if !found {
+		if err != nil {
+			log.Error(err)
+		}
+		return nil, false
+	}
+
+	if task.Spec.Status != crv1.InProgressStatus ||
+		task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_STEP] != step {
+		log.Debugf("major upgrade: upgrade of cluster %s is not at step %q", clusterName, step)
+		return nil, false
+	}
+
+	return &task, true
+}
+
+// updateMajorUpgradeStep records the step a major upgrade is at, along with
+// the instructions to roll back from it, on its task and on its workflow
+func updateMajorUpgradeStep(restclient *rest.RESTClient, task *crv1.Pgtask, step string) error {
+	namespace := task.Spec.Namespace
+
+	if step != crv1.PgtaskWorkflowMajorUpgradeFailed {
+		task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_STEP] = step
+	}
+	task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_ROLLBACK] = majorUpgradeRollbackInstructions(task)
+
+	// the task is updated in steps that are handled by different controllers,
+	// so ensure the latest version of it is the one being updated
+	current := crv1.Pgtask{}
+	if found, err := kubeapi.Getpgtask(restclient, &current, task.Name, namespace); !found {
+		return fmt.Errorf("could not find major upgrade task %s: %v", task.Name, err)
+	}
+	task.ResourceVersion = current.ResourceVersion
+
+	if err := kubeapi.Updatepgtask(restclient, task, task.Name, namespace); err != nil {
+		return err
+	}
+
+	// the workflow records when each step was reached
+	workflowName := task.Spec.Parameters[config.LABEL_PG_CLUSTER] + "-" + crv1.PgtaskWorkflowMajorUpgradeType
+	workflow := crv1.Pgtask{}
+	found, err := kubeapi.Getpgtask(restclient, &workflow, workflowName, namespace)
+	if !found {
+		return fmt.Errorf("could not find major upgrade workflow %s: %v", workflowName, err)
+	}
+
+	workflow.Spec.Parameters[step] = time.Now().Format(time.RFC3339)
+
+	return kubeapi.Updatepgtask(restclient, &workflow, workflow.Name, namespace)
+}
+
+// majorUpgradeRollbackInstructions returns the instructions to roll back a
+// major upgrade from the step it is at
+func majorUpgradeRollbackInstructions(task *crv1.Pgtask) string {
+	clusterName := task.Spec.Parameters[config.LABEL_PG_CLUSTER]
+	namespace := task.Spec.Namespace
+	fromVersion := task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_FROM_VERSION]
+	toVersion := task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_TO_VERSION]
+	fromImageTag := task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_FROM_IMAGE_TAG]
+	primary := task.Spec.Parameters[config.LABEL_DEPLOYMENT_NAME]
+
+	switch task.Spec.Parameters[config.LABEL_MAJOR_UPGRADE_STEP] {
+	case crv1.PgtaskWorkflowMajorUpgradeStanzaUpgrade, crv1.PgtaskWorkflowMajorUpgradeReplicas,
+		crv1.PgtaskWorkflowCompletedStatus:
+		return fmt.Sprintf("The data directory of the primary has been upgraded to PostgreSQL %[3]s "+
+			"with pg_upgrade --link. The PostgreSQL %[2]s data directory is kept as "+
+			"/pgdata/%[5]s-pre-upgrade-%[2]s on PVC %[6]s, but it cannot be started once PostgreSQL "+
+			"%[3]s has run. To roll back, restore the full backup taken before the upgrade, which "+
+			"is listed by \"pgo show backup %[1]s -n %[7]s\", on PostgreSQL %[2]s: "+
+			"kubectl -n %[7]s patch pgcluster %[1]s --type=merge -p '{\"spec\":{\"ccpimagetag\":\"%[4]s\"}}' && "+
+			"pgo restore %[1]s -n %[7]s --backup-opts=\"--set=<backup>\". "+
+			"Once the upgrade is verified, the pre-upgrade data directory can be removed.",
+			clusterName, fromVersion, toVersion, fromImageTag, primary,
+			task.Spec.Parameters[config.LABEL_PVC_NAME], namespace)
+	case crv1.PgtaskWorkflowMajorUpgradePGUpgrade:
+		return fmt.Sprintf("pg_upgrade is running against the data directory of %s. If it fails, "+
+			"the PostgreSQL %s data directory is left unchanged and the cluster is restarted on "+
+			"image tag %s. The reason of the failure is in the logs of job %s-pg-upgrade.",
+			primary, fromVersion, fromImageTag, clusterName)
+	default:
+		return fmt.Sprintf("The data of cluster %s has not been changed. If the upgrade fails before "+
+			"pg_upgrade runs, the cluster is restarted on PostgreSQL %s and its replicas are re-created.",
+			clusterName, fromVersion)
+	}
+}
+
+// labelClusterForMajorUpgrade sets the major upgrade label of a cluster, which
+// shows the state of its most recent major upgrade
+func labelClusterForMajorUpgrade(restclient *rest.RESTClient, clusterName, namespace, value string) error {
+	cluster := crv1.Pgcluster{}
+	if found, err := kubeapi.Getpgcluster(restclient, &cluster, clusterName, namespace); !found {
+		return fmt.Errorf("could not find pgcluster %s: %v", clusterName, err)
+	}
+
+	if cluster.Spec.UserLabels == nil {
+		cluster.Spec.UserLabels = map[string]string{}
+	}
+
+	cluster.Spec.UserLabels[config.LABEL_MAJOR_UPGRADE] = value
+
+	return util.PatchClusterCRD(restclient, cluster.Spec.UserLabels, &cluster, namespace)
+}
+
+// scaleMajorUpgradePrimary scales the deployment of the primary
+func scaleMajorUpgradePrimary(clientset *kubernetes.Clientset, primary, namespace string, replicas int) error {
+	deployment, found, err := kubeapi.GetDeployment(clientset, primary, namespace)
+	if !found {
+		return fmt.Errorf("could not find primary deployment %s: %v", primary, err)
+	}
+
+	return kubeapi.ScaleDeployment(clientset, *deployment, replicas)
+}
+
+// waitForPodsDeleted waits for the pods of a deployment to terminate
+func waitForPodsDeleted(clientset *kubernetes.Clientset, deploymentName, namespace string) error {
+	selector := fmt.Sprintf("%s=%s", config.LABEL_DEPLOYMENT_NAME, deploymentName)
+
+	timeout := time.After(majorUpgradePrimaryShutdownTimeout)
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for the pods of %s to terminate", deploymentName)
+		case <-tick.C:
+			pods, err := kubeapi.GetPods(clientset, selector, namespace)
+			if err != nil {
+				return err
+			}
+			if len(pods.Items) == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+// publishMajorUpgradeEvent publishes an event of a major upgrade
+func publishMajorUpgradeEvent(eventType string, task *crv1.Pgtask, message string) {
+	header := events.EventHeader{
+		Namespace: task.Spec.Namespace,
+		Username:  task.ObjectMeta.Labels[config.LABEL_PGOUSER],
+		Topic:     []string{events.EventTopicCluster},
+		Timestamp: time.Now(),
+		EventType: eventType,
+	}
+	clusterName := task.Spec.Parameters[config.LABEL_PG_CLUSTER]
+
+	var f events.EventInterface
+	switch eventType {
+	case events.EventUpgradeClusterCompleted:
+		f = events.EventUpgradeClusterCompletedFormat{EventHeader: header, Clustername: clusterName}
+	case events.EventUpgradeClusterFailure:
+		f = events.EventUpgradeClusterFailureFormat{
+			EventHeader:  header,
+			Clustername:  clusterName,
+			ErrorMessage: message,
+			WorkflowID:   task.Spec.Parameters[crv1.PgtaskWorkflowID],
+		}
+	default:
+		f = events.EventUpgradeClusterFormat{EventHeader: header, Clustername: clusterName}
+	}
+
+	if err := events.Publish(f); err != nil {
+		log.Error(err)
+	}
+}
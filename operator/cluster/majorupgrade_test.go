@@ -0,0 +1,120 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+)
+
+func TestParseMajorUpgradeSettings(t *testing.T) {
+	tests := []struct {
+		output       string
+		majorVersion string
+		settings     []string
+		err          bool
+	}{
+		{"120003|UTF8|en_US.utf-8|en_US.utf-8|on", "12",
+			[]string{"UTF8", "en_US.utf-8", "en_US.utf-8", "on"}, false},
+		{"100012|SQL_ASCII|C|C|off", "10", []string{"SQL_ASCII", "C", "C", "off"}, false},
+		{"90618|UTF8|C|C|off", "9.6", []string{"UTF8", "C", "C", "off"}, false},
+		{"90522|UTF8|C|C|off", "9.5", []string{"UTF8", "C", "C", "off"}, false},
+		{"120003|UTF8|C|C", "", nil, true},
+		{"twelve|UTF8|C|C|on", "", nil, true},
+		{"", "", nil, true},
+	}
+
+	for i, test := range tests {
+		majorVersion, settings, err := parseMajorUpgradeSettings(test.output)
+
+		if test.err {
+			if err == nil {
+				t.Fatalf("tests[%d] - expected an error for %q", i, test.output)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("tests[%d] - expected no error, got %s", i, err)
+		}
+
+		if majorVersion != test.majorVersion {
+			t.Fatalf("tests[%d] - expected major version %q, got %q", i, test.majorVersion, majorVersion)
+		}
+
+		if !reflect.DeepEqual(settings, test.settings) {
+			t.Fatalf("tests[%d] - expected settings %v, got %v", i, test.settings, settings)
+		}
+	}
+}
+
+func TestGetMajorUpgradeExtensionNames(t *testing.T) {
+	tests := []struct {
+		outputs  []string
+		expected []string
+	}{
+		{nil, []string{}},
+		{[]string{"plpgsql 1.0"}, []string{"plpgsql"}},
+		{[]string{"plpgsql 1.0\npg_stat_statements 1.7", "pgaudit 1.4\nplpgsql 1.0"},
+			[]string{"pg_stat_statements", "pgaudit", "plpgsql"}},
+		{[]string{"postgis 3.0.1\n\nplpgsql 1.0"}, []string{"plpgsql", "postgis"}},
+	}
+
+	for i, test := range tests {
+		if names := getMajorUpgradeExtensionNames(test.outputs); !reflect.DeepEqual(names, test.expected) {
+			t.Fatalf("tests[%d] - expected %v, got %v", i, test.expected, names)
+		}
+	}
+}
+
+func TestMajorUpgradeRollbackInstructions(t *testing.T) {
+	tests := []struct {
+		step     string
+		expected string
+	}{
+		{crv1.PgtaskWorkflowMajorUpgradeBackup, "The data of cluster hippo has not been changed"},
+		{crv1.PgtaskWorkflowMajorUpgradeShutdown, "restarted on PostgreSQL 11"},
+		{crv1.PgtaskWorkflowMajorUpgradePGUpgrade, "logs of job hippo-pg-upgrade"},
+		{crv1.PgtaskWorkflowMajorUpgradeStanzaUpgrade, "/pgdata/hippo-abcd-pre-upgrade-11 on PVC hippo-abcd"},
+		{crv1.PgtaskWorkflowMajorUpgradeReplicas, "pgo restore hippo -n pgo"},
+		{crv1.PgtaskWorkflowCompletedStatus, `"ccpimagetag":"centos7-11.8-4.4.0"`},
+	}
+
+	for i, test := range tests {
+		task := &crv1.Pgtask{
+			Spec: crv1.PgtaskSpec{
+				Namespace: "pgo",
+				Parameters: map[string]string{
+					config.LABEL_PG_CLUSTER:                   "hippo",
+					config.LABEL_MAJOR_UPGRADE_FROM_VERSION:   "11",
+					config.LABEL_MAJOR_UPGRADE_TO_VERSION:     "12",
+					config.LABEL_MAJOR_UPGRADE_FROM_IMAGE_TAG: "centos7-11.8-4.4.0",
+					config.LABEL_MAJOR_UPGRADE_STEP:           test.step,
+					config.LABEL_DEPLOYMENT_NAME:              "hippo-abcd",
+					config.LABEL_PVC_NAME:                     "hippo-abcd",
+				},
+			},
+		}
+
+		if instructions := majorUpgradeRollbackInstructions(task); !strings.Contains(instructions, test.expected) {
+			t.Fatalf("tests[%d] - expected %q in %q", i, test.expected, instructions)
+		}
+	}
+}
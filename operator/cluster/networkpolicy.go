@@ -0,0 +1,330 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	"github.com/crunchydata/postgres-operator/operator"
+	"github.com/crunchydata/postgres-operator/operator/backrest"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	networking_v1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// postgresNetworkPolicyFormat is the name of the NetworkPolicy of the
+// PostgreSQL instances of a cluster. The NetworkPolicies of pgBouncer and the
+// pgBackRest repository are named after their Deployments
+const postgresNetworkPolicyFormat = "%s-postgres"
+
+// prometheusName is the value of the "name" label of the Prometheus pods that
+// are deployed along with the PostgreSQL Operator to scrape the metrics of the
+// clusters
+const prometheusName = "crunchy-prometheus"
+
+// ReconcileNetworkPolicies creates, updates or removes the NetworkPolicies of a
+// cluster so that they match its pgcluster, e.g. once pgBouncer is added or
+// removed. NetworkPolicies that were not created by the Operator are left as
+// they are
+func ReconcileNetworkPolicies(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster) error {
+	policies := map[string]*networking_v1.NetworkPolicy{
+		fmt.Sprintf(postgresNetworkPolicyFormat, cluster.Name):      nil,
+		fmt.Sprintf(pgBouncerDeploymentFormat, cluster.Name):        nil,
+		fmt.Sprintf(backrest.BackrestRepoServiceName, cluster.Name): nil,
+	}
+
+	for _, policy := range getNetworkPolicies(cluster) {
+		policies[policy.Name] = policy
+	}
+
+	for name, desired := range policies {
+		current, found, err := kubeapi.GetNetworkPolicy(clientset, name, cluster.Namespace)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return err
+		}
+
+		if found && (current.Labels[config.LABEL_PG_CLUSTER] != cluster.Name ||
+			current.Labels[config.LABEL_VENDOR] != config.LABEL_CRUNCHY) {
+			log.Warnf("network policy %s exists but was not created for cluster %s, leaving it as is",
+				name, cluster.Name)
+			continue
+		}
+
+		switch {
+		case !found && desired != nil:
+			if err := kubeapi.CreateNetworkPolicy(clientset, desired, cluster.Namespace); err != nil {
+				return err
+			}
+		case found && desired == nil:
+			if err := kubeapi.DeleteNetworkPolicy(clientset, name, cluster.Namespace); err != nil {
+				return err
+			}
+		case found && !equality.Semantic.DeepEqual(current.Spec, desired.Spec):
+			log.Debugf("updating network policy %s of cluster %s", name, cluster.Name)
+
+			current.Spec = desired.Spec
+			if err := kubeapi.UpdateNetworkPolicy(clientset, current, cluster.Namespace); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// getNetworkPolicies returns the NetworkPolicies of a cluster, which has none
+// unless they are enabled in its pgcluster. These are the NetworkPolicies that
+// ReconcileNetworkPolicies creates and that RenderCluster renders. PostgreSQL
+// accepts connections from its other instances, pgBouncer, the jobs that the
+// Operator runs for the cluster, the clusters that subscribe to its
+// publications and the PostgreSQL Operator itself, as well as from the
+// namespaces and CIDRs of the pgcluster, which may also connect to
+// pgBouncer. SSH connections between the instances and the pgBackRest
+// repository are allowed, and so is scraping the metrics and pgBadger
+// sidecars by Prometheus and those clients
+func getNetworkPolicies(cluster *crv1.Pgcluster) []*networking_v1.NetworkPolicy {
+	if !cluster.Spec.NetworkPolicy.Enabled {
+		return nil
+	}
+
+	port := networkPolicyPorts(cluster.Spec.Port)
+	backrestPort := networkPolicyPorts(strconv.Itoa(operator.Pgo.Cluster.BackrestPort))
+
+	instances := networking_v1.NetworkPolicyPeer{
+		PodSelector: &meta_v1.LabelSelector{MatchLabels: map[string]string{
+			config.LABEL_PG_CLUSTER:  cluster.Name,
+			config.LABEL_PG_DATABASE: "true",
+		}},
+	}
+
+	clients := getNetworkPolicyClients(cluster)
+
+	// the jobs that connect to PostgreSQL, e.g. "pgo backup --backup-type=pgdump"
+	// or "pgo load"
+	jobs := []networking_v1.NetworkPolicyPeer{}
+	for _, label := range []string{"pgdump", "pgrestore", "pgo-sqlrunner", config.LABEL_PGO_LOAD} {
+		jobs = append(jobs, networking_v1.NetworkPolicyPeer{
+			PodSelector: &meta_v1.LabelSelector{MatchLabels: map[string]string{
+				config.LABEL_PG_CLUSTER: cluster.Name,
+				label:                   "true",
+			}},
+		})
+	}
+
+	postgresClients := append(jobs, clients...)
+
+	pgBouncerEnabled := cluster.Labels[config.LABEL_PGBOUNCER] == "true"
+	if pgBouncerEnabled {
+		postgresClients = append(postgresClients, networking_v1.NetworkPolicyPeer{
+			PodSelector: &meta_v1.LabelSelector{MatchLabels: map[string]string{
+				config.LABEL_PG_CLUSTER: cluster.Name,
+				config.LABEL_PGBOUNCER:  "true",
+			}},
+		})
+	}
+
+	// the instances of the clusters that subscribe to a publication connect to
+	// the primary
+	subscribers := map[string]struct{}{}
+	for _, publication := range cluster.Spec.Publications {
+		for _, subscriber := range publication.Subscribers {
+			subscribers[subscriber] = struct{}{}
+		}
+	}
+
+	subscriberNames := make([]string, 0, len(subscribers))
+	for subscriber := range subscribers {
+		subscriberNames = append(subscriberNames, subscriber)
+	}
+	sort.Strings(subscriberNames)
+
+	for _, subscriber := range subscriberNames {
+		postgresClients = append(postgresClients, networking_v1.NetworkPolicyPeer{
+			PodSelector: &meta_v1.LabelSelector{MatchLabels: map[string]string{
+				config.LABEL_PG_CLUSTER:  subscriber,
+				config.LABEL_PG_DATABASE: "true",
+			}},
+		})
+	}
+
+	postgresIngress := []networking_v1.NetworkPolicyIngressRule{
+		// replication and Patroni
+		{
+			Ports: networkPolicyPorts(cluster.Spec.Port, config.DEFAULT_PATRONI_PORT),
+			From:  []networking_v1.NetworkPolicyPeer{instances},
+		},
+		{Ports: port, From: postgresClients},
+		// pgBackRest
+		{
+			Ports: backrestPort,
+			From: []networking_v1.NetworkPolicyPeer{{
+				PodSelector: &meta_v1.LabelSelector{MatchLabels: map[string]string{
+					config.LABEL_PG_CLUSTER:        cluster.Name,
+					config.LABEL_PGO_BACKREST_REPO: "true",
+				}},
+			}},
+		},
+	}
+
+	// the metrics and pgBadger sidecars can be scraped by the Prometheus of
+	// the PostgreSQL Operator, in whichever namespace it is deployed to, and by
+	// the clients of the cluster, e.g. a Prometheus in one of its namespaces
+	scrapers := append([]networking_v1.NetworkPolicyPeer{{
+		NamespaceSelector: &meta_v1.LabelSelector{},
+		PodSelector: &meta_v1.LabelSelector{MatchLabels: map[string]string{
+			config.LABEL_NAME:   prometheusName,
+			config.LABEL_VENDOR: config.LABEL_CRUNCHY,
+		}},
+	}}, clients...)
+
+	if cluster.Spec.UserLabels[config.LABEL_COLLECT] == "true" {
+		postgresIngress = append(postgresIngress, networking_v1.NetworkPolicyIngressRule{
+			Ports: networkPolicyPorts(cluster.Spec.ExporterPort),
+			From:  scrapers,
+		})
+	}
+
+	if cluster.Labels[config.LABEL_BADGER] == "true" {
+		postgresIngress = append(postgresIngress, networking_v1.NetworkPolicyIngressRule{
+			Ports: networkPolicyPorts(cluster.Spec.PGBadgerPort),
+			From:  scrapers,
+		})
+	}
+
+	policies := []*networking_v1.NetworkPolicy{
+		newNetworkPolicy(cluster, fmt.Sprintf(postgresNetworkPolicyFormat, cluster.Name),
+			instances.PodSelector.MatchLabels, postgresIngress),
+		// the pods of the cluster push WAL to the pgBackRest repository
+		newNetworkPolicy(cluster, fmt.Sprintf(backrest.BackrestRepoServiceName, cluster.Name),
+			map[string]string{
+				config.LABEL_PG_CLUSTER:        cluster.Name,
+				config.LABEL_PGO_BACKREST_REPO: "true",
+			},
+			[]networking_v1.NetworkPolicyIngressRule{{
+				Ports: backrestPort,
+				From: []networking_v1.NetworkPolicyPeer{{
+					PodSelector: &meta_v1.LabelSelector{MatchLabels: map[string]string{
+						config.LABEL_PG_CLUSTER: cluster.Name,
+					}},
+				}},
+			}}),
+	}
+
+	if pgBouncerEnabled {
+		policies = append(policies, newNetworkPolicy(cluster,
+			fmt.Sprintf(pgBouncerDeploymentFormat, cluster.Name),
+			map[string]string{
+				config.LABEL_PG_CLUSTER: cluster.Name,
+				config.LABEL_PGBOUNCER:  "true",
+			},
+			[]networking_v1.NetworkPolicyIngressRule{{
+				Ports: networkPolicyPorts(operator.Pgo.Cluster.Port),
+				From:  clients,
+			}}))
+	}
+
+	return policies
+}
+
+// getNetworkPolicyClients returns the clients that may connect to PostgreSQL
+// and to pgBouncer, i.e. the PostgreSQL Operator and the namespaces and CIDRs
+// of the pgcluster. The namespace selectors and source ranges are validated
+// when the pgcluster is created or updated, and invalid ones are skipped
+func getNetworkPolicyClients(cluster *crv1.Pgcluster) []networking_v1.NetworkPolicyPeer {
+	clients := []networking_v1.NetworkPolicyPeer{
+		// the PostgreSQL Operator, in whichever namespace it is deployed to
+		{
+			NamespaceSelector: &meta_v1.LabelSelector{},
+			PodSelector: &meta_v1.LabelSelector{MatchLabels: map[string]string{
+				config.LABEL_NAME:   config.LABEL_OPERATOR,
+				config.LABEL_VENDOR: config.LABEL_CRUNCHY,
+			}},
+		},
+	}
+
+	for _, selector := range cluster.Spec.NetworkPolicy.NamespaceSelectors {
+		// an empty selector would select every namespace
+		namespaceSelector, err := meta_v1.ParseToLabelSelector(selector)
+		if err != nil || strings.TrimSpace(selector) == "" {
+			log.Warnf("skipping namespace selector %q of cluster %s", selector, cluster.Name)
+			continue
+		}
+
+		clients = append(clients, networking_v1.NetworkPolicyPeer{NamespaceSelector: namespaceSelector})
+	}
+
+	for _, sourceRange := range cluster.Spec.NetworkPolicy.SourceRanges {
+		if _, _, err := net.ParseCIDR(sourceRange); err != nil {
+			log.Warnf("skipping source range %q of cluster %s: %v", sourceRange, cluster.Name, err)
+			continue
+		}
+
+		clients = append(clients, networking_v1.NetworkPolicyPeer{
+			IPBlock: &networking_v1.IPBlock{CIDR: sourceRange},
+		})
+	}
+
+	return clients
+}
+
+// newNetworkPolicy returns a NetworkPolicy of a cluster that only allows the
+// given ingress to the pods with the given labels
+func newNetworkPolicy(cluster *crv1.Pgcluster, name string, podLabels map[string]string,
+	ingress []networking_v1.NetworkPolicyIngressRule) *networking_v1.NetworkPolicy {
+	return &networking_v1.NetworkPolicy{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				config.LABEL_PG_CLUSTER: cluster.Name,
+				config.LABEL_VENDOR:     config.LABEL_CRUNCHY,
+			},
+		},
+		Spec: networking_v1.NetworkPolicySpec{
+			PodSelector: meta_v1.LabelSelector{MatchLabels: podLabels},
+			Ingress:     ingress,
+			PolicyTypes: []networking_v1.PolicyType{networking_v1.PolicyTypeIngress},
+		},
+	}
+}
+
+// networkPolicyPorts returns the TCP ports of a NetworkPolicy rule
+func networkPolicyPorts(ports ...string) []networking_v1.NetworkPolicyPort {
+	policyPorts := []networking_v1.NetworkPolicyPort{}
+
+	for _, port := range ports {
+		protocol := v1.ProtocolTCP
+		value := intstr.Parse(port)
+
+		policyPorts = append(policyPorts, networking_v1.NetworkPolicyPort{
+			Protocol: &protocol,
+			Port:     &value,
+		})
+	}
+
+	return policyPorts
+}
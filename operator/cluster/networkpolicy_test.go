@@ -0,0 +1,188 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/operator"
+	networking_v1 "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// describeNetworkPolicyPeer returns the namespaces and pods, or the CIDR, that
+// a peer of a NetworkPolicy selects, e.g. "pods pg-cluster=hippo,pgdump=true"
+func describeNetworkPolicyPeer(peer networking_v1.NetworkPolicyPeer) string {
+	if peer.IPBlock != nil {
+		return "cidr " + peer.IPBlock.CIDR
+	}
+
+	description := []string{}
+	if peer.NamespaceSelector != nil {
+		description = append(description, "namespaces "+meta_v1.FormatLabelSelector(peer.NamespaceSelector))
+	}
+	if peer.PodSelector != nil {
+		description = append(description, "pods "+meta_v1.FormatLabelSelector(peer.PodSelector))
+	}
+
+	return strings.Join(description, " ")
+}
+
+func TestGetNetworkPolicies(t *testing.T) {
+	operator.Pgo.Cluster.Port = "5432"
+	operator.Pgo.Cluster.BackrestPort = 2022
+
+	jobs := []string{
+		"pods pg-cluster=hippo,pgdump=true",
+		"pods pg-cluster=hippo,pgrestore=true",
+		"pods pg-cluster=hippo,pgo-sqlrunner=true",
+		"pods pg-cluster=hippo,pgo-load=true",
+	}
+	clients := []string{
+		"namespaces <none> pods name=postgres-operator,vendor=crunchydata",
+		"namespaces team=payments",
+		"cidr 10.0.0.0/8",
+	}
+	scrapers := append([]string{
+		"namespaces <none> pods name=crunchy-prometheus,vendor=crunchydata",
+	}, clients...)
+	pgBouncer := "pods crunchy-pgbouncer=true,pg-cluster=hippo"
+
+	tests := []struct {
+		description     string
+		disabled        bool
+		labels          map[string]string
+		userLabels      map[string]string
+		publications    []crv1.PublicationSpec
+		policies        []string
+		postgresClients []string
+		scrapedPorts    []string
+	}{
+		{
+			description: "disabled",
+			disabled:    true,
+			labels:      map[string]string{config.LABEL_PGBOUNCER: "true"},
+			policies:    []string{},
+		},
+		{
+			description:     "no pgbouncer",
+			policies:        []string{"hippo-postgres", "hippo-backrest-shared-repo"},
+			postgresClients: append(append([]string{}, jobs...), clients...),
+		},
+		{
+			description:     "pgbouncer",
+			labels:          map[string]string{config.LABEL_PGBOUNCER: "true"},
+			policies:        []string{"hippo-postgres", "hippo-backrest-shared-repo", "hippo-pgbouncer"},
+			postgresClients: append(append(append([]string{}, jobs...), clients...), pgBouncer),
+		},
+		{
+			description: "subscribers",
+			publications: []crv1.PublicationSpec{
+				{Name: "orders", Database: "hippo", Subscribers: []string{"rhino", "elephant"}},
+				{Name: "customers", Database: "hippo", Subscribers: []string{"rhino"}},
+			},
+			policies: []string{"hippo-postgres", "hippo-backrest-shared-repo"},
+			postgresClients: append(append(append([]string{}, jobs...), clients...),
+				"pods pg-cluster=elephant,pgo-pg-database=true",
+				"pods pg-cluster=rhino,pgo-pg-database=true"),
+		},
+		{
+			description:     "metrics and pgbadger",
+			labels:          map[string]string{config.LABEL_BADGER: "true"},
+			userLabels:      map[string]string{config.LABEL_COLLECT: "true"},
+			policies:        []string{"hippo-postgres", "hippo-backrest-shared-repo"},
+			postgresClients: append(append([]string{}, jobs...), clients...),
+			scrapedPorts:    []string{"9187", "10000"},
+		},
+	}
+
+	for i, test := range tests {
+		cluster := &crv1.Pgcluster{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Labels: test.labels},
+			Spec: crv1.PgclusterSpec{
+				Port:         "5432",
+				ExporterPort: "9187",
+				PGBadgerPort: "10000",
+				UserLabels:   test.userLabels,
+				Publications: test.publications,
+				NetworkPolicy: crv1.NetworkPolicySpec{
+					Enabled:            !test.disabled,
+					NamespaceSelectors: []string{"team=payments", "", "!!"},
+					SourceRanges:       []string{"10.0.0.0/8", "10.0.0.1"},
+				},
+			},
+		}
+
+		policies := getNetworkPolicies(cluster)
+
+		names := []string{}
+		for _, policy := range policies {
+			names = append(names, policy.Name)
+		}
+		if !reflect.DeepEqual(names, test.policies) {
+			t.Fatalf("tests[%d] - expected policies %v, got %v", i, test.policies, names)
+		}
+
+		if test.disabled {
+			continue
+		}
+
+		// replication and Patroni, PostgreSQL, pgBackRest and the sidecars
+		ingress := policies[0].Spec.Ingress
+		if len(ingress) != 3+len(test.scrapedPorts) {
+			t.Fatalf("tests[%d] - expected %d ingress rules, got %d", i, 3+len(test.scrapedPorts), len(ingress))
+		}
+
+		postgresClients := []string{}
+		for _, peer := range ingress[1].From {
+			postgresClients = append(postgresClients, describeNetworkPolicyPeer(peer))
+		}
+		if !reflect.DeepEqual(postgresClients, test.postgresClients) {
+			t.Fatalf("tests[%d] - expected clients of PostgreSQL %v, got %v", i, test.postgresClients,
+				postgresClients)
+		}
+
+		for j, port := range test.scrapedPorts {
+			rule := ingress[3+j]
+			if rule.Ports[0].Port.String() != port {
+				t.Fatalf("tests[%d] - expected port %s to be scraped, got %s", i, port, rule.Ports[0].Port.String())
+			}
+
+			peers := []string{}
+			for _, peer := range rule.From {
+				peers = append(peers, describeNetworkPolicyPeer(peer))
+			}
+			if !reflect.DeepEqual(peers, scrapers) {
+				t.Fatalf("tests[%d] - expected port %s to be scraped by %v, got %v", i, port, scrapers, peers)
+			}
+		}
+
+		// only the clients of the cluster may connect to pgBouncer
+		if len(policies) > 2 {
+			pgBouncerClients := []string{}
+			for _, peer := range policies[2].Spec.Ingress[0].From {
+				pgBouncerClients = append(pgBouncerClients, describeNetworkPolicyPeer(peer))
+			}
+			if !reflect.DeepEqual(pgBouncerClients, clients) {
+				t.Fatalf("tests[%d] - expected clients of pgBouncer %v, got %v", i, clients, pgBouncerClients)
+			}
+		}
+	}
+}
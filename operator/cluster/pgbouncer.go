@@ -34,7 +34,9 @@ import (
 	log "github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	policy_v1beta1 "k8s.io/api/policy/v1beta1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
@@ -61,12 +63,32 @@ type PgbouncerTemplateFields struct {
 	PodAntiAffinity           string
 	PodAntiAffinityLabelName  string
 	PodAntiAffinityLabelValue string
+	PriorityClassName         string
+	Tolerations               string
+	PodAnnotations            string
+	PodCustomLabels           string
+	ReadOnly                  bool
+	Replicas                  int32
 }
 
 // pgBouncerDeploymentFormat is the name of the Kubernetes Deployment that
 // manages pgBouncer, and follows the format "<clusterName>-pgbouncer"
 const pgBouncerDeploymentFormat = "%s-pgbouncer"
 
+// pgBouncerReadOnlyDeploymentFormat is the name of the Kubernetes Deployment
+// of the read-only pgBouncer, whose pool connects to the replica Service, and
+// follows the format "<clusterName>-pgbouncer-ro"
+const pgBouncerReadOnlyDeploymentFormat = "%s-pgbouncer-ro"
+
+// pgBouncerReadOnlyConfKey is the key in the pgBouncer secret that holds the
+// "pgbouncer.ini" file of the read-only pgBouncer, so that its pool settings
+// can be tuned separately
+const pgBouncerReadOnlyConfKey = "pgbouncer-ro.ini"
+
+// pgBouncerDefaultReplicas is the number of Pods of a pgBouncer Deployment
+// when the cluster does not set it
+const pgBouncerDefaultReplicas int32 = 1
+
 // ...the default PostgreSQL port
 const pgPort = "5432"
 
@@ -78,15 +100,6 @@ const (
 	pgBouncerInstallScript = "/opt/cpm/bin/sql/pgbouncer/pgbouncer-install.sql"
 )
 
-const (
-	// pgBouncerSecretPropagationPeriod is the number of seconds between each
-	// check of when the secret is propogated
-	pgBouncerSecretPropagationPeriod = 5
-	// pgBouncerSecretPropagationTimeout is the maximum amount of time in seconds
-	// to wait for the secret to propagate
-	pgBouncerSecretPropagationTimeout = 60
-)
-
 const (
 	// a string to check to see if the pgbouncer machinery is installed in the
 	// PostgreSQL cluster
@@ -112,9 +125,6 @@ const (
 )
 
 var (
-	// this command allows one to view the users.txt file secret to determine if
-	// it has propagated
-	cmdViewPgBouncerUsersSecret = []string{"cat", "/pgconf/users.txt"}
 	// sqlUninstallPgBouncer provides the final piece of SQL to uninstall
 	// pgbouncer, which is to remove the user
 	sqlUninstallPgBouncer = fmt.Sprintf(`DROP ROLE "%s";`, crv1.PGUserPgBouncer)
@@ -172,12 +182,26 @@ func AddPgbouncer(clientset *kubernetes.Clientset, restclient *rest.RESTClient,
 	}
 
 	// next, create the pgBouncer deployment
-	if err := createPgBouncerDeployment(clientset, cluster); err != nil {
+	if err := createPgBouncerDeployment(clientset, cluster, false); err != nil {
 		return err
 	}
 
-	// finally, try to create the pgBouncer service
-	if err := createPgBouncerService(clientset, cluster); err != nil {
+	// next, try to create the pgBouncer service
+	if err := createPgBouncerService(clientset, cluster, false); err != nil {
+		return err
+	}
+
+	// if the cluster has a read-only pgBouncer as well, create its deployment
+	// and service
+	if cluster.Spec.PgBouncer.ReadOnly {
+		if err := createPgBouncerReadOnly(clientset, cluster); err != nil {
+			return err
+		}
+	}
+
+	// finally, let pgBouncer connect to PostgreSQL if the cluster has
+	// NetworkPolicies
+	if err := ReconcileNetworkPolicies(clientset, cluster); err != nil {
 		return err
 	}
 
@@ -186,6 +210,32 @@ func AddPgbouncer(clientset *kubernetes.Clientset, restclient *rest.RESTClient,
 	return nil
 }
 
+// AddPgbouncerReadOnly adds the read-only pgBouncer Deployment and Service to
+// a PostgreSQL cluster that already has pgBouncer. The read-only pgBouncer
+// shares the secret, and therefore the "pgbouncer" user, of the cluster's
+// pgBouncer
+//
+// Any returned error is logged in the calling function
+func AddPgbouncerReadOnly(clientset *kubernetes.Clientset, restclient *rest.RESTClient, cluster *crv1.Pgcluster) error {
+	log.Debugf("adding a read-only pgbouncer")
+
+	// first, ensure that the Cluster CR is updated to know that there is now
+	// a read-only pgBouncer associated with it
+	cluster.Spec.PgBouncer.ReadOnly = true
+
+	if err := kubeapi.Updatepgcluster(restclient, cluster, cluster.Spec.ClusterName, cluster.Namespace); err != nil {
+		return err
+	}
+
+	if err := createPgBouncerReadOnly(clientset, cluster); err != nil {
+		return err
+	}
+
+	log.Debugf("added read-only pgbouncer to cluster [%s]", cluster.Spec.Name)
+
+	return nil
+}
+
 // AddPgbouncerFromPgTask is effectively a legacy method that helps to bring up
 // the pgBouncer deployment that sits alongside a PostgreSQL cluster
 func AddPgbouncerFromPgTask(clientset *kubernetes.Clientset, restclient *rest.RESTClient, restconfig *rest.Config, task *crv1.Pgtask) {
@@ -204,10 +254,45 @@ func AddPgbouncerFromPgTask(clientset *kubernetes.Clientset, restclient *rest.RE
 		return
 	}
 
-	// bring up the pgbouncer deployment and all of its trappings!
-	if err := AddPgbouncer(clientset, restclient, restconfig, &cluster); err != nil {
-		log.Error(err)
-		return
+	// if a read-only pgBouncer is requested and the cluster already has
+	// pgBouncer, only the read-only pgBouncer is added. Otherwise, it is brought
+	// up along with the pgBouncer of the cluster
+	readOnly, _ := strconv.ParseBool(task.Spec.Parameters[config.LABEL_PGBOUNCER_READ_ONLY])
+
+	// if the number of replicas is set, it applies to every pgBouncer
+	// deployment of the cluster. The read-only pgBouncer otherwise takes the
+	// number of the existing one
+	replicas, err := strconv.Atoi(task.Spec.Parameters[config.LABEL_PGBOUNCER_REPLICAS])
+	scale := err == nil && replicas > 0
+
+	if scale {
+		cluster.Spec.PgBouncer.Replicas = int32(replicas)
+	}
+
+	if readOnly && hasPgBouncerDeployment(clientset, &cluster) {
+		if err := AddPgbouncerReadOnly(clientset, restclient, &cluster); err != nil {
+			log.Error(err)
+			return
+		}
+
+		// the existing pgBouncer is scaled to the same number of Pods as the
+		// read-only pgBouncer that was just added
+		if scale {
+			if err := scalePgBouncerDeployments(clientset, &cluster); err != nil {
+				log.Error(err)
+				return
+			}
+		}
+	} else {
+		if readOnly {
+			cluster.Spec.PgBouncer.ReadOnly = true
+		}
+
+		// bring up the pgbouncer deployment and all of its trappings!
+		if err := AddPgbouncer(clientset, restclient, restconfig, &cluster); err != nil {
+			log.Error(err)
+			return
+		}
 	}
 
 	// publish an event
@@ -223,13 +308,16 @@ func AddPgbouncerFromPgTask(clientset *kubernetes.Clientset, restclient *rest.RE
 }
 
 // CreatePgTaskforAddpgBouncer creates a pgtask to process adding a pgBouncer
-func CreatePgTaskforAddpgBouncer(restclient *rest.RESTClient, cluster *crv1.Pgcluster, pgouser string) error {
+//
+// The "parameters" attribute contains a list of parameters that can guide what
+// will take place when pgBouncer is added, e.g.
+//
+// - pgbouncer-read-only="true" will add the read-only pgBouncer as well
+func CreatePgTaskforAddpgBouncer(restclient *rest.RESTClient, cluster *crv1.Pgcluster, pgouser string, parameters map[string]string) error {
 	log.Debugf("create pgtask for adding pgbouncer to cluster [%s]", cluster.Spec.ClusterName)
 
-	// generate the pgtask, first setting up the parameters it needs
-	parameters := map[string]string{
-		config.LABEL_PGBOUNCER_TASK_CLUSTER: cluster.Spec.ClusterName,
-	}
+	// generate the pgtask, first adding in some boilerplate parameters
+	parameters[config.LABEL_PGBOUNCER_TASK_CLUSTER] = cluster.Spec.ClusterName
 	task := generatePgtaskForPgBouncer(cluster, pgouser,
 		crv1.PgtaskAddPgbouncer, config.LABEL_PGBOUNCER_TASK_ADD, parameters)
 
@@ -285,6 +373,7 @@ func DeletePgbouncer(clientset *kubernetes.Clientset, restclient *rest.RESTClien
 	// longer a pgBouncer associated with it
 	// if we cannot update this we abort
 	cluster.Labels[config.LABEL_PGBOUNCER] = "false"
+	cluster.Spec.PgBouncer.ReadOnly = false
 
 	if err := kubeapi.Updatepgcluster(restclient, cluster, cluster.Spec.ClusterName, namespace); err != nil {
 		return err
@@ -329,6 +418,17 @@ func DeletePgbouncer(clientset *kubernetes.Clientset, restclient *rest.RESTClien
 		log.Warn(err)
 	}
 
+	deletePgBouncerPodDisruptionBudget(clientset, cluster, false)
+
+	// the read-only pgBouncer goes along with it, if there is one
+	deletePgBouncerReadOnly(clientset, cluster)
+
+	// the NetworkPolicies no longer let pgBouncer connect, and the one of
+	// pgBouncer itself is removed
+	if err := ReconcileNetworkPolicies(clientset, cluster); err != nil {
+		log.Warn(err)
+	}
+
 	// remove the secret. again, if this fails, just log the error and apss
 	// through
 	secretName := util.GeneratePgBouncerSecretName(clusterName)
@@ -349,6 +449,27 @@ func DeletePgbouncer(clientset *kubernetes.Clientset, restclient *rest.RESTClien
 	return nil
 }
 
+// DeletePgbouncerReadOnly deletes the read-only pgBouncer Deployment and
+// Service of a PostgreSQL cluster, leaving its pgBouncer as it is
+//
+// Any errors that are returned should be logged in the calling function
+func DeletePgbouncerReadOnly(clientset *kubernetes.Clientset, restclient *rest.RESTClient, cluster *crv1.Pgcluster) error {
+	log.Debugf("delete read-only pgbouncer from cluster [%s] in namespace [%s]",
+		cluster.Spec.ClusterName, cluster.Spec.Namespace)
+
+	// first, ensure that the Cluster CR is updated to know that there is no
+	// longer a read-only pgBouncer associated with it
+	cluster.Spec.PgBouncer.ReadOnly = false
+
+	if err := kubeapi.Updatepgcluster(restclient, cluster, cluster.Spec.ClusterName, cluster.Spec.Namespace); err != nil {
+		return err
+	}
+
+	deletePgBouncerReadOnly(clientset, cluster)
+
+	return nil
+}
+
 // DeletePgbouncerFromPgTask is effectively a legacy method that helps to delete
 // the pgBouncer deployment that sits alongside a PostgreSQL cluster
 func DeletePgbouncerFromPgTask(clientset *kubernetes.Clientset, restclient *rest.RESTClient, restconfig *rest.Config, task *crv1.Pgtask) {
@@ -368,8 +489,14 @@ func DeletePgbouncerFromPgTask(clientset *kubernetes.Clientset, restclient *rest
 		return
 	}
 
-	// attempt to delete the pgbouncer!
-	if err := DeletePgbouncer(clientset, restclient, restconfig, &cluster, uninstall); err != nil {
+	// attempt to delete the pgbouncer! if only the read-only pgBouncer is to be
+	// deleted, the pgBouncer of the cluster is left as it is
+	if readOnly, _ := strconv.ParseBool(task.Spec.Parameters[config.LABEL_PGBOUNCER_READ_ONLY]); readOnly {
+		if err := DeletePgbouncerReadOnly(clientset, restclient, &cluster); err != nil {
+			log.Error(err)
+			return
+		}
+	} else if err := DeletePgbouncer(clientset, restclient, restconfig, &cluster, uninstall); err != nil {
 		log.Error(err)
 		return
 	}
@@ -405,6 +532,17 @@ func UpdatePgbouncer(clientset *kubernetes.Clientset, restclient *rest.RESTClien
 			if err := rotatePgBouncerPassword(clientset, restclient, restconfig, cluster); err != nil {
 				return err
 			}
+		// determine if the number of pgBouncer replicas is to be changed
+		case config.LABEL_PGBOUNCER_REPLICAS:
+			replicas, err := strconv.Atoi(parameters[param])
+
+			if err != nil {
+				return err
+			}
+
+			if err := scalePgBouncer(clientset, restclient, cluster, int32(replicas)); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -432,7 +570,7 @@ func UpdatePgbouncerFromPgTask(clientset *kubernetes.Clientset, restclient *rest
 		return
 	}
 
-	// attempt to delete the pgbouncer!
+	// attempt to update the pgbouncer!
 	if err := UpdatePgbouncer(clientset, restclient, restconfig, &cluster, parameters); err != nil {
 		log.Error(err)
 		return
@@ -477,13 +615,31 @@ func checkPgBouncerInstall(clientset *kubernetes.Clientset, restconfig *rest.Con
 	}
 }
 
-// createPgBouncerDeployment creates the Kubernetes Deployment for pgBouncer
-func createPgBouncerDeployment(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster) error {
+// createPgBouncerDeployment creates the Kubernetes Deployment for pgBouncer,
+// or for the read-only pgBouncer if "readOnly" is set
+func createPgBouncerDeployment(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster, readOnly bool) error {
 	log.Debugf("creating pgbouncer deployment: %s", cluster.Spec.Name)
 
+	deployment, err := renderPgBouncerDeployment(cluster, readOnly)
+
+	if err != nil {
+		return err
+	}
+
+	if err := kubeapi.CreateDeployment(clientset, deployment, cluster.Spec.Namespace); err != nil {
+		return err
+	}
+
+	// lastly, ensure the pgBouncer Pods are not all evicted at once
+	return createPgBouncerPodDisruptionBudget(clientset, cluster, readOnly)
+}
+
+// renderPgBouncerDeployment returns the Deployment that
+// createPgBouncerDeployment creates, without creating it
+func renderPgBouncerDeployment(cluster *crv1.Pgcluster, readOnly bool) (*appsv1.Deployment, error) {
 	// derive the name of the Deployment...which is also used as the name of the
 	// service
-	pgbouncerDeploymentName := fmt.Sprintf(pgBouncerDeploymentFormat, cluster.Spec.Name)
+	pgbouncerDeploymentName := getPgBouncerDeploymentName(cluster, readOnly)
 
 	// get the fields that will be substituted in the pgBouncer template
 	fields := PgbouncerTemplateFields{
@@ -499,7 +655,13 @@ func createPgBouncerDeployment(clientset *kubernetes.Clientset, cluster *crv1.Pg
 		PodAntiAffinityLabelName: config.LABEL_POD_ANTI_AFFINITY,
 		PodAntiAffinityLabelValue: string(operator.GetPodAntiAffinityType(cluster,
 			crv1.PodAntiAffinityDeploymentPgBouncer, cluster.Spec.PodAntiAffinity.PgBouncer)),
+		PriorityClassName: operator.GetPriorityClassName(cluster),
+		Tolerations:       operator.GetTolerationsJSON(operator.GetTolerations(cluster)),
+		ReadOnly:          readOnly,
+		Replicas:          getPgBouncerReplicas(cluster),
 	}
+	fields.PodAnnotations, fields.PodCustomLabels = operator.GetCustomMetadataJSON(
+		cluster.Spec.Metadata.PgBouncer, nil)
 
 	// Determine if a custom resource profile should be used for the pgBouncer
 	// deployment
@@ -523,25 +685,64 @@ func createPgBouncerDeployment(clientset *kubernetes.Clientset, cluster *crv1.Pg
 	doc := bytes.Buffer{}
 
 	if err := config.PgbouncerTemplate.Execute(&doc, fields); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Set up the Kubernetes deployment for pgBouncer
 	deployment := appsv1.Deployment{}
 
 	if err := json.Unmarshal(doc.Bytes(), &deployment); err != nil {
-		return err
+		return nil, err
 	}
 
 	// set the container image to an override value, if one exists
 	operator.SetContainerImageOverride(config.CONTAINER_IMAGE_CRUNCHY_PGBOUNCER,
 		&deployment.Spec.Template.Spec.Containers[0])
 
-	if err := kubeapi.CreateDeployment(clientset, &deployment, cluster.Spec.Namespace); err != nil {
-		return err
+	return &deployment, nil
+}
+
+// createPgBouncerPodDisruptionBudget creates the PodDisruptionBudget of the
+// pgBouncer Deployment, or of the read-only pgBouncer Deployment if "readOnly"
+// is set, which shares its name. At most one pgBouncer Pod can be voluntarily
+// disrupted, e.g. by draining a node, at any time. If the PodDisruptionBudget
+// already exists, it is left as it is
+func createPgBouncerPodDisruptionBudget(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster, readOnly bool) error {
+	name := getPgBouncerDeploymentName(cluster, readOnly)
+
+	if _, found, _ := kubeapi.GetPodDisruptionBudget(clientset, name, cluster.Spec.Namespace); found {
+		return nil
 	}
 
-	return nil
+	return kubeapi.CreatePodDisruptionBudget(clientset, newPgBouncerPodDisruptionBudget(cluster, readOnly),
+		cluster.Spec.Namespace)
+}
+
+// newPgBouncerPodDisruptionBudget returns the PodDisruptionBudget that
+// createPgBouncerPodDisruptionBudget creates
+func newPgBouncerPodDisruptionBudget(cluster *crv1.Pgcluster, readOnly bool) *policy_v1beta1.PodDisruptionBudget {
+	name := getPgBouncerDeploymentName(cluster, readOnly)
+	maxUnavailable := intstr.FromInt(1)
+
+	return &policy_v1beta1.PodDisruptionBudget{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				config.LABEL_PG_CLUSTER: cluster.Spec.Name,
+				config.LABEL_PGBOUNCER:  "true",
+				config.LABEL_VENDOR:     config.LABEL_CRUNCHY,
+			},
+		},
+		Spec: policy_v1beta1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector: &meta_v1.LabelSelector{
+				MatchLabels: map[string]string{
+					config.LABEL_PG_CLUSTER:   cluster.Spec.Name,
+					config.LABEL_SERVICE_NAME: name,
+				},
+			},
+		},
+	}
 }
 
 // createPgbouncerSecret create a secret used by pgbouncer. Returns the
@@ -555,6 +756,24 @@ func createPgbouncerSecret(clientset *kubernetes.Clientset, cluster *crv1.Pgclus
 		return nil
 	}
 
+	secret, err := renderPgBouncerSecret(cluster, password)
+
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if err := kubeapi.CreateSecret(clientset, secret, cluster.Spec.Namespace); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// renderPgBouncerSecret returns the secret that createPgbouncerSecret creates,
+// without creating it
+func renderPgBouncerSecret(cluster *crv1.Pgcluster, password string) (*v1.Secret, error) {
 	// the remainder of this is generating the various entries in the pgbouncer
 	// secret, i.e. substituting values into templates files that contain:
 	// - the pgbouncer.ini file
@@ -563,25 +782,31 @@ func createPgbouncerSecret(clientset *kubernetes.Clientset, cluster *crv1.Pgclus
 	// "pgbouncer" user
 
 	// first, generate the pgbouncer.ini information
-	pgBouncerConf, err := generatePgBouncerConf(cluster)
+	pgBouncerConf, err := generatePgBouncerConf(cluster, cluster.Spec.Name)
 
 	if err != nil {
-		log.Error(err)
-		return err
+		return nil, err
+	}
+
+	// the read-only pgBouncer has its own pgbouncer.ini, which connects to the
+	// replicas
+	pgBouncerReadOnlyConf, err := generatePgBouncerConf(cluster, cluster.Spec.Name+ReplicaSuffix)
+
+	if err != nil {
+		return nil, err
 	}
 
 	// finally, generate the pgbouncer HBA file
 	pgbouncerHBA, err := generatePgBouncerHBA()
 
 	if err != nil {
-		log.Error(err)
-		return err
+		return nil, err
 	}
 
-	// now, we can do what we came here to do, which is create the secret
-	secret := v1.Secret{
+	// now, we can do what we came here to do, which is render the secret
+	secret := &v1.Secret{
 		ObjectMeta: meta_v1.ObjectMeta{
-			Name: secretName,
+			Name: util.GeneratePgBouncerSecretName(cluster.Spec.Name),
 			Labels: map[string]string{
 				config.LABEL_PG_CLUSTER: cluster.Spec.Name,
 				config.LABEL_PGBOUNCER:  "true",
@@ -589,27 +814,68 @@ func createPgbouncerSecret(clientset *kubernetes.Clientset, cluster *crv1.Pgclus
 			},
 		},
 		Data: map[string][]byte{
-			"password":      []byte(password),
-			"pgbouncer.ini": pgBouncerConf,
-			"pg_hba.conf":   pgbouncerHBA,
+			"password":               []byte(password),
+			"pgbouncer.ini":          pgBouncerConf,
+			pgBouncerReadOnlyConfKey: pgBouncerReadOnlyConf,
+			"pg_hba.conf":            pgbouncerHBA,
 			"users.txt": util.GeneratePgBouncerUsersFileBytes(
 				util.GeneratePostgreSQLMD5Password(crv1.PGUserPgBouncer, password)),
 		},
 	}
 
-	if err := kubeapi.CreateSecret(clientset, &secret, cluster.Spec.Namespace); err != nil {
-		log.Error(err)
+	return secret, nil
+}
+
+// createPgBouncerReadOnly creates the Kubernetes Deployment and Service of the
+// read-only pgBouncer. pgBouncer secrets that were created before there was a
+// read-only pgBouncer are given its pgbouncer.ini first
+func createPgBouncerReadOnly(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster) error {
+	secretName := util.GeneratePgBouncerSecretName(cluster.Spec.Name)
+	secret, _, err := kubeapi.GetSecret(clientset, secretName, cluster.Spec.Namespace)
+
+	if err != nil {
+		return err
+	}
+
+	if _, ok := secret.Data[pgBouncerReadOnlyConfKey]; !ok {
+		pgBouncerReadOnlyConf, err := generatePgBouncerConf(cluster, cluster.Spec.Name+ReplicaSuffix)
+
+		if err != nil {
+			return err
+		}
+
+		secret.Data[pgBouncerReadOnlyConfKey] = pgBouncerReadOnlyConf
+
+		if err := kubeapi.UpdateSecret(clientset, secret, cluster.Spec.Namespace); err != nil {
+			return err
+		}
+	}
+
+	if err := createPgBouncerDeployment(clientset, cluster, true); err != nil {
+		return err
+	}
+
+	return createPgBouncerService(clientset, cluster, true)
+}
+
+// createPgBouncerService creates the Kubernetes Service for pgBouncer, or for
+// the read-only pgBouncer if "readOnly" is set
+func createPgBouncerService(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster, readOnly bool) error {
+	fields := newPgBouncerServiceFields(cluster, readOnly)
+
+	if err := CreateService(clientset, &fields, cluster.Spec.Namespace); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// createPgBouncerService creates the Kubernetes Service for pgBouncer
-func createPgBouncerService(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster) error {
+// newPgBouncerServiceFields returns the template fields of the Service of
+// pgBouncer, or of the read-only pgBouncer if "readOnly" is set
+func newPgBouncerServiceFields(cluster *crv1.Pgcluster, readOnly bool) ServiceTemplateFields {
 	// pgBouncerServiceName is the name of the Service of the pgBouncer, which
 	// matches that for the Deploymnt
-	pgBouncerServiceName := fmt.Sprintf(pgBouncerDeploymentFormat, cluster.Spec.Name)
+	pgBouncerServiceName := getPgBouncerDeploymentName(cluster, readOnly)
 
 	// set up the service template fields
 	fields := ServiceTemplateFields{
@@ -621,11 +887,42 @@ func createPgBouncerService(clientset *kubernetes.Clientset, cluster *crv1.Pgclu
 		Port: operator.Pgo.Cluster.Port,
 	}
 
-	if err := CreateService(clientset, &fields, cluster.Spec.Namespace); err != nil {
-		return err
+	setServiceMetadata(&fields, cluster.Spec.Metadata.PgBouncer, nil)
+
+	return fields
+}
+
+// deletePgBouncerReadOnly deletes the Service and the Deployment of the
+// read-only pgBouncer, if they exist. If these fail, we'll just pass through
+func deletePgBouncerReadOnly(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster) {
+	name := getPgBouncerDeploymentName(cluster, true)
+
+	if _, found, _ := kubeapi.GetService(clientset, name, cluster.Spec.Namespace); found {
+		if err := kubeapi.DeleteService(clientset, name, cluster.Spec.Namespace); err != nil {
+			log.Warn(err)
+		}
 	}
 
-	return nil
+	if _, found, _ := kubeapi.GetDeployment(clientset, name, cluster.Spec.Namespace); found {
+		if err := kubeapi.DeleteDeployment(clientset, name, cluster.Spec.Namespace); err != nil {
+			log.Warn(err)
+		}
+	}
+
+	deletePgBouncerPodDisruptionBudget(clientset, cluster, true)
+}
+
+// deletePgBouncerPodDisruptionBudget deletes the PodDisruptionBudget of the
+// pgBouncer Deployment, or of the read-only pgBouncer Deployment if "readOnly"
+// is set, if it exists. If this fails, we'll just pass through
+func deletePgBouncerPodDisruptionBudget(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster, readOnly bool) {
+	name := getPgBouncerDeploymentName(cluster, readOnly)
+
+	if _, found, _ := kubeapi.GetPodDisruptionBudget(clientset, name, cluster.Spec.Namespace); found {
+		if err := kubeapi.DeletePodDisruptionBudget(clientset, name, cluster.Spec.Namespace); err != nil {
+			log.Warn(err)
+		}
+	}
 }
 
 // execPgBouncerScript runs a script pertaining to the management of pgBouncer
@@ -656,8 +953,9 @@ func generatePassword() string {
 }
 
 // generatePgBouncerConf generates the content that is stored in the secret
-// for the "pgbouncer.ini" file
-func generatePgBouncerConf(cluster *crv1.Pgcluster) ([]byte, error) {
+// for the "pgbouncer.ini" file, with the pool connecting to the Service that
+// is provided, i.e. the primary or the replica Service
+func generatePgBouncerConf(cluster *crv1.Pgcluster, serviceName string) ([]byte, error) {
 	// first, get the port
 	port := cluster.Spec.Port
 	// if the "port" value is not set, default to the PostgreSQL port.
@@ -667,7 +965,7 @@ func generatePgBouncerConf(cluster *crv1.Pgcluster) ([]byte, error) {
 
 	// set up the substitution fields for the pgbouncer.ini file
 	fields := PgbouncerConfFields{
-		PG_PRIMARY_SERVICE_NAME: cluster.Spec.Name,
+		PG_PRIMARY_SERVICE_NAME: serviceName,
 		PG_PORT:                 port,
 	}
 
@@ -732,6 +1030,16 @@ func generatePgtaskForPgBouncer(cluster *crv1.Pgcluster, pgouser, taskType, task
 	return task
 }
 
+// getPgBouncerDeploymentName returns the name of the Deployment, and of the
+// Service, of the pgBouncer of a cluster, or of its read-only pgBouncer
+func getPgBouncerDeploymentName(cluster *crv1.Pgcluster, readOnly bool) string {
+	if readOnly {
+		return fmt.Sprintf(pgBouncerReadOnlyDeploymentFormat, cluster.Spec.Name)
+	}
+
+	return fmt.Sprintf(pgBouncerDeploymentFormat, cluster.Spec.Name)
+}
+
 // getPgBouncerDatabases gets the databases in a PostgreSQL cluster that have
 // the pgBouncer objects, etc.
 func getPgBouncerDatabases(clientset *kubernetes.Clientset, restconfig *rest.Config, pod *v1.Pod) (*bufio.Scanner, error) {
@@ -758,6 +1066,24 @@ func getPgBouncerDatabases(clientset *kubernetes.Clientset, restconfig *rest.Con
 	return bufio.NewScanner(strings.NewReader(stdout)), nil
 }
 
+// getPgBouncerReplicas returns the number of Pods of each pgBouncer Deployment
+// of a cluster, which is one unless the cluster sets it
+func getPgBouncerReplicas(cluster *crv1.Pgcluster) int32 {
+	if cluster.Spec.PgBouncer.Replicas < 1 {
+		return pgBouncerDefaultReplicas
+	}
+
+	return cluster.Spec.PgBouncer.Replicas
+}
+
+// hasPgBouncerDeployment returns true if the pgBouncer Deployment of a cluster
+// exists
+func hasPgBouncerDeployment(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster) bool {
+	_, found, _ := kubeapi.GetDeployment(clientset, getPgBouncerDeploymentName(cluster, false), cluster.Spec.Namespace)
+
+	return found
+}
+
 // installPgBouncer installs the "pgbouncer" user and other management objects
 // into the PostgreSQL pod
 func installPgBouncer(clientset *kubernetes.Clientset, restconfig *rest.Config, pod *v1.Pod) error {
@@ -846,8 +1172,8 @@ func rotatePgBouncerPassword(clientset *kubernetes.Clientset, restclient *rest.R
 	//
 	// 1. The PostgreSQL cluster must have the pgbouncer user's password updated
 	// 2. The secret that containers the values of "users.txt" must be updated
-	// 3. The pgBouncer pods must be bounced and have the new password loaded, but
-	// 		we must first ensure the password propagates to them
+	// 3. The pgBouncer pods must be bounced to load the new password, one at a
+	// 		time, so that there is always a pgBouncer that can accept connections
 	//
 	// ...wouldn't it be nice if we could run this in a transaction? rolling back
 	// is hard :(
@@ -873,28 +1199,82 @@ func rotatePgBouncerPassword(clientset *kubernetes.Clientset, restclient *rest.R
 		return err
 	}
 
-	// now we wait for the password to propagate to all of the pgbouncer pods in
-	// the deployment
-	// set up the selector for the primary pod
-	selector := fmt.Sprintf("%s=true", config.LABEL_PGBOUNCER)
+	// lastly, roll the pgBouncer pods so they load the new secret. The
+	// Deployments replace each Pod only once its replacement is ready
+	return restartPgBouncer(clientset, cluster)
+}
 
-	// query the pods
-	pods, err := kubeapi.GetPods(clientset, selector, namspace)
+// restartPgBouncer rolls the Pods of each pgBouncer Deployment of a cluster,
+// i.e. the pgBouncer and the read-only pgBouncer, by updating an annotation of
+// their Pod template. As the Deployments do not let a Pod become unavailable
+// until its replacement is ready, pgBouncer keeps accepting connections
+// throughout
+func restartPgBouncer(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster) error {
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{"%s":"%s"}}}}}`,
+		config.ANNOTATION_PGBOUNCER_RESTARTED_AT, time.Now().Format(time.RFC3339))
 
-	if err != nil {
+	for _, readOnly := range []bool{false, true} {
+		name := getPgBouncerDeploymentName(cluster, readOnly)
+
+		if _, found, _ := kubeapi.GetDeployment(clientset, name, cluster.Spec.Namespace); !found {
+			continue
+		}
+
+		if err := kubeapi.PatchDeploymentStrategicMerge(clientset, name, cluster.Spec.Namespace, patch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scalePgBouncer sets the number of Pods of each pgBouncer Deployment of a
+// cluster, both in the spec of the cluster and in the Deployments themselves.
+// If the cluster is shut down, the Deployments are scaled when it starts up
+func scalePgBouncer(clientset *kubernetes.Clientset, restclient *rest.RESTClient, cluster *crv1.Pgcluster, replicas int32) error {
+	if replicas < 1 {
+		return fmt.Errorf("invalid number of pgbouncer replicas: %d", replicas)
+	}
+
+	cluster.Spec.PgBouncer.Replicas = replicas
+
+	if err := kubeapi.Updatepgcluster(restclient, cluster, cluster.Spec.ClusterName, cluster.Spec.Namespace); err != nil {
 		return err
 	}
 
-	// iterate through each pod and see if the secret has propagated. once it
-	// returns, restart the pod (i.e. deleted it)
-	for _, pod := range pods.Items {
-		waitForSecretPropagation(clientset, restconfig, pod, string(secret.Data["users.txt"]),
-			pgBouncerSecretPropagationTimeout, pgBouncerSecretPropagationPeriod)
+	return scalePgBouncerDeployments(clientset, cluster)
+}
+
+// scalePgBouncerDeployments sets the number of Pods of each existing pgBouncer
+// Deployment of a cluster to the number in its spec. Deployments are given a
+// PodDisruptionBudget if they do not have one yet, but are not scaled while
+// the cluster is shut down
+func scalePgBouncerDeployments(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster) error {
+	replicas := getPgBouncerReplicas(cluster)
+	shutdown := cluster.Spec.Shutdown || cluster.Status.State == crv1.PgclusterStateShutdown
 
-		// after this waiting period has passed, delete Pod. If the pod fails to
-		// delete, warn but continue on
-		if err := kubeapi.DeletePod(clientset, pod.Name, pod.Namespace); err != nil {
-			log.Warn(err)
+	for _, readOnly := range []bool{false, true} {
+		name := getPgBouncerDeploymentName(cluster, readOnly)
+		deployment, found, _ := kubeapi.GetDeployment(clientset, name, cluster.Spec.Namespace)
+
+		if !found {
+			continue
+		}
+
+		// pgBouncer Deployments created before there was a PodDisruptionBudget
+		// are given one as well
+		if err := createPgBouncerPodDisruptionBudget(clientset, cluster, readOnly); err != nil {
+			return err
+		}
+
+		if shutdown {
+			continue
+		}
+
+		log.Debugf("scaling pgbouncer deployment %s to %d", name, replicas)
+
+		if err := kubeapi.ScaleDeployment(clientset, *deployment, int(replicas)); err != nil {
+			return err
 		}
 	}
 
@@ -956,40 +1336,3 @@ func uninstallPgBouncer(clientset *kubernetes.Clientset, restconfig *rest.Config
 
 	return nil
 }
-
-// waitForSecretPropagation waits until the update to the pgbouncer secret has
-// propogated
-func waitForSecretPropagation(clientset *kubernetes.Clientset, restconfig *rest.Config, pod v1.Pod, expected string, timeoutSecs, periodSecs time.Duration) {
-	timeout := time.After(timeoutSecs * time.Second)
-	tick := time.Tick(periodSecs * time.Second)
-
-loop:
-	for {
-		select {
-		// in the case of hitting the timeout, warn that the timeout was hit, but
-		// continue onward
-		case <-timeout:
-			log.Warnf("timed out after [%d]s waiting for secret to propogate to pod [%s]", timeout, pod.Name)
-			return
-		case <-tick:
-			// exec into the pod to run the query
-			stdout, stderr, err := kubeapi.ExecToPodThroughAPI(restconfig, clientset,
-				cmdViewPgBouncerUsersSecret, "pgbouncer", pod.Name, pod.ObjectMeta.Namespace, nil)
-
-			// if there is an error, warn about it, but try again
-			if err != nil {
-				log.Warnf(stderr)
-				continue loop
-			}
-
-			// trim any space that may be there for an accurate read
-			secret := strings.TrimSpace(stdout)
-
-			// if we have match, break the loop
-			if secret == expected {
-				log.Debug("secret propogated to pod [%s]", pod.Name)
-				return
-			}
-		}
-	}
-}
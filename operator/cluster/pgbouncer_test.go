@@ -0,0 +1,211 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	appsv1 "k8s.io/api/apps/v1"
+	policy_v1beta1 "k8s.io/api/policy/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestGetPgBouncerReplicas(t *testing.T) {
+	for _, tc := range []struct {
+		replicas, expected int32
+	}{
+		{0, 1},
+		{-1, 1},
+		{1, 1},
+		{3, 3},
+	} {
+		cluster := &crv1.Pgcluster{Spec: crv1.PgclusterSpec{PgBouncer: crv1.PgBouncerSpec{Replicas: tc.replicas}}}
+
+		if replicas := getPgBouncerReplicas(cluster); replicas != tc.expected {
+			t.Fatalf("expected %d replicas for %d, got %d", tc.expected, tc.replicas, replicas)
+		}
+	}
+}
+
+func TestCreatePgBouncerPodDisruptionBudget(t *testing.T) {
+	tests := []struct {
+		readOnly bool
+		exists   bool
+		name     string
+		requests []string
+	}{
+		{false, false, "hippo-pgbouncer", []string{"POST poddisruptionbudgets/hippo-pgbouncer"}},
+		{true, false, "hippo-pgbouncer-ro", []string{"POST poddisruptionbudgets/hippo-pgbouncer-ro"}},
+		{false, true, "hippo-pgbouncer", nil},
+		{true, true, "hippo-pgbouncer-ro", nil},
+	}
+
+	for i, test := range tests {
+		cluster := &crv1.Pgcluster{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo"},
+			Spec:       crv1.PgclusterSpec{Name: "hippo", ClusterName: "hippo", Namespace: "pgo"},
+		}
+
+		objects := []runtime.Object{cluster}
+		if test.exists {
+			objects = append(objects, &policy_v1beta1.PodDisruptionBudget{
+				ObjectMeta: meta_v1.ObjectMeta{Name: test.name, Namespace: "pgo"},
+			})
+		}
+
+		_, api, server := newFakeRESTClient(t, objects...)
+		clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+		if err != nil {
+			server.Close()
+			t.Fatalf("tests[%d] - %s", i, err)
+		}
+
+		err = createPgBouncerPodDisruptionBudget(clientset, cluster, test.readOnly)
+		server.Close()
+
+		if err != nil {
+			t.Fatalf("tests[%d] - expected no error, got %s", i, err)
+		}
+
+		if !reflect.DeepEqual(api.requests, test.requests) {
+			t.Fatalf("tests[%d] - expected requests %v, got %v", i, test.requests, api.requests)
+		}
+
+		if test.exists {
+			continue
+		}
+
+		pdb := policy_v1beta1.PodDisruptionBudget{}
+		if err := json.Unmarshal(api.objects["poddisruptionbudgets/"+test.name], &pdb); err != nil {
+			t.Fatalf("tests[%d] - %s", i, err)
+		}
+
+		if pdb.Spec.MaxUnavailable == nil || pdb.Spec.MaxUnavailable.IntValue() != 1 {
+			t.Fatalf("tests[%d] - expected max unavailable of 1, got %v", i, pdb.Spec.MaxUnavailable)
+		}
+
+		selector := map[string]string{
+			config.LABEL_PG_CLUSTER:   "hippo",
+			config.LABEL_SERVICE_NAME: test.name,
+		}
+
+		if pdb.Spec.Selector == nil || !reflect.DeepEqual(pdb.Spec.Selector.MatchLabels, selector) {
+			t.Fatalf("tests[%d] - expected selector %v, got %v", i, selector, pdb.Spec.Selector)
+		}
+
+		if pdb.Labels[config.LABEL_PG_CLUSTER] != "hippo" || pdb.Labels[config.LABEL_PGBOUNCER] != "true" {
+			t.Fatalf("tests[%d] - unexpected labels %v", i, pdb.Labels)
+		}
+	}
+}
+
+func TestScalePgBouncer(t *testing.T) {
+	tests := []struct {
+		shutdown bool
+		state    crv1.PgclusterState
+		readOnly bool
+		replicas int32
+		requests []string
+		expected int32
+	}{
+		{false, crv1.PgclusterStateInitialized, false, 2, []string{
+			"PUT pgclusters/hippo",
+			"POST poddisruptionbudgets/hippo-pgbouncer",
+			"PUT deployments/hippo-pgbouncer",
+		}, 2},
+		{false, crv1.PgclusterStateInitialized, true, 3, []string{
+			"PUT pgclusters/hippo",
+			"POST poddisruptionbudgets/hippo-pgbouncer",
+			"PUT deployments/hippo-pgbouncer",
+			"POST poddisruptionbudgets/hippo-pgbouncer-ro",
+			"PUT deployments/hippo-pgbouncer-ro",
+		}, 3},
+		{true, crv1.PgclusterStateInitialized, true, 2, []string{
+			"PUT pgclusters/hippo",
+			"POST poddisruptionbudgets/hippo-pgbouncer",
+			"POST poddisruptionbudgets/hippo-pgbouncer-ro",
+		}, 1},
+		{false, crv1.PgclusterStateShutdown, false, 2, []string{
+			"PUT pgclusters/hippo",
+			"POST poddisruptionbudgets/hippo-pgbouncer",
+		}, 1},
+		{false, crv1.PgclusterStateInitialized, false, 0, nil, 1},
+	}
+
+	for i, test := range tests {
+		cluster := &crv1.Pgcluster{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo"},
+			Spec: crv1.PgclusterSpec{Name: "hippo", ClusterName: "hippo", Namespace: "pgo",
+				Shutdown: test.shutdown},
+			Status: crv1.PgclusterStatus{State: test.state},
+		}
+
+		names := []string{"hippo-pgbouncer"}
+		if test.readOnly {
+			names = append(names, "hippo-pgbouncer-ro")
+		}
+
+		replicas := int32(1)
+		objects := []runtime.Object{cluster}
+		for _, name := range names {
+			objects = append(objects, &appsv1.Deployment{
+				ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: "pgo"},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			})
+		}
+
+		restclient, api, server := newFakeRESTClient(t, objects...)
+		clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+		if err != nil {
+			server.Close()
+			t.Fatalf("tests[%d] - %s", i, err)
+		}
+
+		err = scalePgBouncer(clientset, restclient, cluster, test.replicas)
+		server.Close()
+
+		if test.replicas < 1 && err == nil {
+			t.Fatalf("tests[%d] - expected an error for %d replicas", i, test.replicas)
+		}
+
+		if test.replicas > 0 && err != nil {
+			t.Fatalf("tests[%d] - expected no error, got %s", i, err)
+		}
+
+		if !reflect.DeepEqual(api.requests, test.requests) {
+			t.Fatalf("tests[%d] - expected requests %v, got %v", i, test.requests, api.requests)
+		}
+
+		for _, name := range names {
+			deployment := appsv1.Deployment{}
+			if err := json.Unmarshal(api.objects["deployments/"+name], &deployment); err != nil {
+				t.Fatalf("tests[%d] - %s", i, err)
+			}
+
+			if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != test.expected {
+				t.Fatalf("tests[%d] - expected %s to have %d replicas, got %v",
+					i, name, test.expected, deployment.Spec.Replicas)
+			}
+		}
+	}
+}
@@ -0,0 +1,678 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	"github.com/crunchydata/postgres-operator/operator"
+	"github.com/crunchydata/postgres-operator/operator/backrest"
+	log "github.com/sirupsen/logrus"
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// instanceLabels are the user labels of a pgcluster that are specific to a
+// single PostgreSQL instance, and are therefore not converged onto the
+// Deployments of the cluster
+var instanceLabels = []string{
+	config.LABEL_NAME,
+	config.LABEL_DEPLOYMENT_NAME,
+	config.LABEL_REPLICA_NAME,
+	config.LABEL_SERVICE_NAME,
+	config.LABEL_PGHA_ROLE,
+	config.LABEL_CURRENT_PRIMARY,
+	config.LABEL_NODE_LABEL_KEY,
+	config.LABEL_NODE_LABEL_VALUE,
+	config.LABEL_CCP_IMAGE_TAG_KEY,
+}
+
+// Reconcile compares the objects of a cluster to what its pgcluster specifies
+// and converges any that have drifted:
+//
+//   - the primary and replica Services and the pgha ConfigMap are recreated if
+//     they were deleted
+//   - the custom annotations and labels, the type and the load balancer source
+//     ranges of the Services are updated to match the pgcluster, as well as
+//     the custom annotations and labels of pgBouncer and the pgBackRest
+//     repository
+//   - the NetworkPolicies of the cluster are created, updated or removed to
+//     match the pgcluster, e.g. when pgBouncer or metrics are toggled
+//   - the pg_hba.conf rules of the cluster are set in the dynamic
+//     configuration of Patroni if they are managed by the Operator, i.e. when
+//     custom rules are set or users authenticate with client certificates
+//   - replicas are added or removed to match the "replicas" of the pgcluster
+//   - the labels, image, resources, pod anti-affinity, tolerations, priority
+//     class, topology spread constraints and custom pod annotations and labels
+//     of the PostgreSQL Deployments are updated to match the pgcluster
+//
+// Changes that restart a PostgreSQL instance are made to one Deployment at a
+// time, replicas before the primary, and only once every instance is ready.
+// Reconcile returns true if the cluster has not converged yet and should be
+// reconciled again
+func Reconcile(clientset *kubernetes.Clientset, restclient *rest.RESTClient, restconfig *rest.Config,
+	cluster *crv1.Pgcluster) (bool, error) {
+	if ok, reason := isReconcilable(cluster); !ok {
+		log.Debugf("reconcile: skipping cluster %s: %s", cluster.Name, reason)
+		return false, nil
+	}
+
+	log.Debugf("reconcile: reconciling cluster %s", cluster.Name)
+
+	selector := fmt.Sprintf("%s=%s,%s=true", config.LABEL_PG_CLUSTER, cluster.Name,
+		config.LABEL_PG_DATABASE)
+	deploymentList, err := kubeapi.GetDeployments(clientset, selector, cluster.Namespace)
+	if err != nil {
+		return false, err
+	}
+
+	// if the cluster has no instances then there is nothing to converge, e.g.
+	// the cluster is still being created or is being removed
+	if len(deploymentList.Items) == 0 {
+		log.Debugf("reconcile: no deployments found for cluster %s", cluster.Name)
+		return false, nil
+	}
+
+	if err := reconcileServices(clientset, cluster, len(deploymentList.Items) > 1); err != nil {
+		return false, err
+	}
+
+	if err := reconcilePGHAConfigMap(clientset, cluster); err != nil {
+		return false, err
+	}
+
+	if err := reconcileSupportMetadata(clientset, cluster); err != nil {
+		return false, err
+	}
+
+	if err := ReconcileNetworkPolicies(clientset, cluster); err != nil {
+		return false, err
+	}
+
+	// the primary is found using its pod, as the deployment of the primary
+	// changes on a failover
+	primary, err := getPrimaryDeploymentName(clientset, cluster)
+	if err != nil {
+		return false, err
+	}
+
+	if primary == "" {
+		log.Debugf("reconcile: no primary found for cluster %s, waiting", cluster.Name)
+		return true, nil
+	}
+
+	if err := reconcileHBA(clientset, restconfig, cluster); err != nil {
+		return false, err
+	}
+
+	if requeue, err := reconcileReplicaCount(clientset, restclient, cluster,
+		deploymentList.Items, primary); requeue || err != nil {
+		return requeue, err
+	}
+
+	return reconcileDeployments(clientset, restclient, cluster, deploymentList.Items, primary)
+}
+
+// isReconcilable returns true if a cluster can be reconciled, and otherwise
+// the reason it cannot be
+func isReconcilable(cluster *crv1.Pgcluster) (bool, string) {
+	if paused, _ := strconv.ParseBool(cluster.Annotations[config.ANNOTATION_RECONCILE_PAUSED]); paused {
+		return false, "reconciliation is paused"
+	}
+
+	if cluster.DeletionTimestamp != nil {
+		return false, "the cluster is being deleted"
+	}
+
+	if cluster.Spec.Shutdown || cluster.Status.State == crv1.PgclusterStateShutdown {
+		return false, "the cluster is shut down"
+	}
+
+	// this also covers clusters that are being created, restored or upgraded
+	// to a new major version
+	if cluster.Status.State != crv1.PgclusterStateInitialized {
+		return false, fmt.Sprintf("the cluster is in state %q", cluster.Status.State)
+	}
+
+	// upgrades change the image of the Deployments before they change the
+	// image tag of the pgcluster
+	if cluster.Spec.UserLabels[config.LABEL_MINOR_UPGRADE] == config.LABEL_UPGRADE_IN_PROGRESS ||
+		cluster.Labels[config.LABEL_MINOR_UPGRADE] == config.LABEL_UPGRADE_IN_PROGRESS ||
+		cluster.Spec.UserLabels[config.LABEL_MAJOR_UPGRADE] == config.LABEL_UPGRADE_IN_PROGRESS {
+		return false, "the cluster is being upgraded"
+	}
+
+	return true, ""
+}
+
+// reconcileServices recreates the primary Service of a cluster, and the
+// replica Service if the cluster has replicas, if they do not exist. Existing
+// Services are updated to have the custom annotations and labels and the load
+// balancer source ranges of the pgcluster. Their type is only updated if it is
+// set in the pgcluster, so that a change of the default in pgo.yaml does not
+// change the Services of existing clusters
+func reconcileServices(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster, hasReplicas bool) error {
+	type service struct {
+		fields      ServiceTemplateFields
+		metadata    crv1.MetadataSpec
+		serviceType string
+	}
+
+	services := []service{{
+		fields:      newPrimaryServiceFields(cluster),
+		metadata:    cluster.Spec.Metadata.PrimaryService,
+		serviceType: cluster.Spec.UserLabels[config.LABEL_SERVICE_TYPE],
+	}}
+
+	if hasReplicas {
+		services = append(services, service{
+			fields:      newReplicaServiceFields(nil, cluster),
+			metadata:    cluster.Spec.Metadata.ReplicaService,
+			serviceType: cluster.Spec.ReplicaServiceType,
+		})
+	}
+
+	for i := range services {
+		fields := &services[i].fields
+
+		current, found, err := kubeapi.GetService(clientset, fields.Name, cluster.Namespace)
+		if !found {
+			if !kerrors.IsNotFound(err) {
+				return err
+			}
+
+			log.Infof("reconcile: recreating service %s of cluster %s", fields.Name, cluster.Name)
+
+			if err := CreateService(clientset, fields, cluster.Namespace); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !applyServiceSpec(current, services[i].metadata, services[i].serviceType,
+			cluster.Spec.LoadBalancerSourceRanges) {
+			continue
+		}
+
+		log.Infof("reconcile: updating service %s of cluster %s", fields.Name, cluster.Name)
+
+		if err := kubeapi.UpdateService(clientset, current, cluster.Namespace); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reconcileSupportMetadata updates the Deployments and the Services of
+// pgBouncer and of the pgBackRest repository of a cluster, if they exist, to
+// have the custom annotations and labels of the pgcluster. Changing the
+// metadata of the pods restarts them, which drops the client connections to
+// pgBouncer, so a Deployment is only updated when its pod metadata differs
+func reconcileSupportMetadata(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster) error {
+	objects := []struct {
+		name     string
+		metadata crv1.MetadataSpec
+	}{
+		{fmt.Sprintf(pgBouncerDeploymentFormat, cluster.Name), cluster.Spec.Metadata.PgBouncer},
+		{fmt.Sprintf(pgBouncerReadOnlyDeploymentFormat, cluster.Name), cluster.Spec.Metadata.PgBouncer},
+		{fmt.Sprintf(backrest.BackrestRepoServiceName, cluster.Name), cluster.Spec.Metadata.PgBackRest},
+	}
+
+	for _, object := range objects {
+		annotations, labels := operator.GetCustomMetadata(object.metadata, nil)
+
+		deployment, found, err := kubeapi.GetDeployment(clientset, object.name, cluster.Namespace)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return err
+		}
+
+		if found {
+			template := deployment.Spec.Template.ObjectMeta.DeepCopy()
+
+			operator.ApplyCustomMetadata(template, annotations, labels)
+
+			if !equality.Semantic.DeepEqual(template, &deployment.Spec.Template.ObjectMeta) {
+				log.Infof("reconcile: updating deployment %s of cluster %s", object.name, cluster.Name)
+
+				deployment.Spec.Template.ObjectMeta = *template
+				if err := kubeapi.UpdateDeployment(clientset, deployment); err != nil {
+					return err
+				}
+			}
+		}
+
+		service, found, err := kubeapi.GetService(clientset, object.name, cluster.Namespace)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return err
+		}
+
+		if found && operator.ApplyCustomMetadata(&service.ObjectMeta, annotations, labels) {
+			log.Infof("reconcile: updating service %s of cluster %s", object.name, cluster.Name)
+
+			if err := kubeapi.UpdateService(clientset, service, cluster.Namespace); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// reconcilePGHAConfigMap recreates the pgha ConfigMap of a cluster if it does
+// not exist. The cluster is already initialized, so the "init" setting of the
+// recreated ConfigMap is set to false
+func reconcilePGHAConfigMap(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster) error {
+	name := cluster.Name + "-" + operator.PGHAConfigMapSuffix
+
+	if _, found := kubeapi.GetConfigMap(clientset, name, cluster.Namespace); found {
+		return nil
+	}
+
+	log.Infof("reconcile: recreating configmap %s of cluster %s", name, cluster.Name)
+
+	if err := operator.CreatePGHAConfigMap(clientset, cluster, cluster.Namespace); err != nil {
+		return err
+	}
+
+	return operator.UpdatePGHAConfigInitFlag(clientset, false, cluster.Name, cluster.Namespace)
+}
+
+// getPrimaryDeploymentName returns the name of the Deployment of the primary
+// of a cluster, or an empty string if there is no primary pod
+func getPrimaryDeploymentName(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster) (string, error) {
+	selector := fmt.Sprintf("%s=%s,%s=master", config.LABEL_PG_CLUSTER, cluster.Name,
+		config.LABEL_PGHA_ROLE)
+	pods, err := kubeapi.GetPods(clientset, selector, cluster.Namespace)
+	if err != nil {
+		return "", err
+	}
+
+	if len(pods.Items) != 1 {
+		return "", nil
+	}
+
+	return pods.Items[0].Labels[config.LABEL_DEPLOYMENT_NAME], nil
+}
+
+// reconcileReplicaCount adds or removes replicas until the number of replicas
+// of a cluster matches its pgcluster. Replicas are added all at once, and are
+// removed one at a time once every instance is ready
+func reconcileReplicaCount(clientset *kubernetes.Clientset, restclient *rest.RESTClient,
+	cluster *crv1.Pgcluster, deployments []apps_v1.Deployment, primary string) (bool, error) {
+	// a pgcluster without a replica count does not manage its replicas
+	if cluster.Spec.Replicas == "" {
+		return false, nil
+	}
+
+	desired, err := strconv.Atoi(cluster.Spec.Replicas)
+	if err != nil || desired < 0 {
+		log.Errorf("reconcile: invalid replica count %q for cluster %s", cluster.Spec.Replicas,
+			cluster.Name)
+		return false, nil
+	}
+
+	replicaList := crv1.PgreplicaList{}
+	if err := kubeapi.GetpgreplicasBySelector(restclient, &replicaList,
+		config.LABEL_PG_CLUSTER+"="+cluster.Name, cluster.Namespace); err != nil {
+		return false, err
+	}
+
+	deploymentNames := map[string]bool{}
+	for _, deployment := range deployments {
+		deploymentNames[deployment.Name] = true
+	}
+
+	// pgreplicas whose Deployment does not exist yet are replicas that are
+	// still being created
+	pending := 0
+	for _, replica := range replicaList.Items {
+		if !deploymentNames[replica.Name] {
+			pending++
+		}
+	}
+
+	current := len(deployments) - 1 + pending
+
+	switch {
+	case current < desired:
+		log.Infof("reconcile: cluster %s has %d replicas, adding %d", cluster.Name, current,
+			desired-current)
+
+		for i := current; i < desired; i++ {
+			if err := kubeapi.Createpgreplica(restclient, newPgreplica(cluster), cluster.Namespace); err != nil {
+				return false, err
+			}
+		}
+
+		return true, nil
+	case current > desired:
+		if pending > 0 || !allDeploymentsReady(deployments) {
+			log.Debugf("reconcile: waiting for the instances of cluster %s to be ready to remove a replica",
+				cluster.Name)
+			return true, nil
+		}
+
+		removing, err := isRemovingReplica(restclient, cluster, deploymentNames)
+		if err != nil || removing {
+			return true, err
+		}
+
+		// remove the replica with the last name, so the same replica is chosen
+		// if this is retried
+		replicas := []string{}
+		for name := range deploymentNames {
+			if name != primary {
+				replicas = append(replicas, name)
+			}
+		}
+		sort.Strings(replicas)
+		replicaName := replicas[len(replicas)-1]
+
+		log.Infof("reconcile: cluster %s has %d replicas, removing replica %s", cluster.Name,
+			current, replicaName)
+
+		task := newRMDataTask(cluster, replicaName, replicaName+"-rmdata", true, false)
+		if err := kubeapi.Createpgtask(restclient, task, cluster.Namespace); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// isRemovingReplica returns true if an rmdata task is removing a replica of a
+// cluster whose Deployment still exists
+func isRemovingReplica(restclient *rest.RESTClient, cluster *crv1.Pgcluster,
+	deploymentNames map[string]bool) (bool, error) {
+	selector := fmt.Sprintf("%s=%s,%s=true", config.LABEL_PG_CLUSTER, cluster.Name,
+		config.LABEL_RMDATA)
+
+	taskList := crv1.PgtaskList{}
+	if err := kubeapi.GetpgtasksBySelector(restclient, &taskList, selector, cluster.Namespace); err != nil {
+		return false, err
+	}
+
+	for _, task := range taskList.Items {
+		if deploymentNames[task.Spec.Parameters[config.LABEL_REPLICA_NAME]] {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// reconcileDeployments converges the PostgreSQL Deployments of a cluster.
+// Label changes are applied to every Deployment right away, as they do not
+// restart the instance. All other changes restart the instance, and are made to
+// a single Deployment per call
+func reconcileDeployments(clientset *kubernetes.Clientset, restclient *rest.RESTClient,
+	cluster *crv1.Pgcluster, deployments []apps_v1.Deployment, primary string) (bool, error) {
+	labels := desiredDeploymentLabels(cluster.Spec.UserLabels)
+
+	// order the Deployments so that the replicas are restarted before the
+	// primary
+	sort.Slice(deployments, func(i, j int) bool {
+		if deployments[i].Name == primary {
+			return false
+		} else if deployments[j].Name == primary {
+			return true
+		}
+		return deployments[i].Name < deployments[j].Name
+	})
+
+	ready := allDeploymentsReady(deployments)
+	restarted, requeue := false, false
+
+	for i := range deployments {
+		deployment := &deployments[i]
+
+		update := applyDeploymentLabels(deployment, labels)
+
+		// the changes that restart the instance are made to a copy, so they can
+		// be deferred to a later pass
+		updated := deployment.DeepCopy()
+		templateChanged, err := applyDeploymentTemplate(restclient, cluster, updated)
+		if err != nil {
+			return false, err
+		}
+
+		if templateChanged {
+			requeue = true
+
+			if ready && !restarted {
+				log.Infof("reconcile: updating deployment %s of cluster %s", deployment.Name,
+					cluster.Name)
+				deployment = updated
+				restarted, update = true, true
+			} else {
+				log.Debugf("reconcile: deferring changes to deployment %s", deployment.Name)
+			}
+		}
+
+		if !update {
+			continue
+		}
+
+		if err := kubeapi.UpdateDeployment(clientset, deployment); err != nil {
+			return false, err
+		}
+	}
+
+	return requeue, nil
+}
+
+// desiredDeploymentLabels returns the labels from the user labels of a
+// pgcluster that each of its PostgreSQL Deployments should have
+func desiredDeploymentLabels(userLabels map[string]string) map[string]string {
+	labels := map[string]string{}
+
+	for key, value := range userLabels {
+		if len(validation.IsQualifiedName(key)) == 0 && len(validation.IsValidLabelValue(value)) == 0 {
+			labels[key] = value
+		}
+	}
+
+	for _, key := range instanceLabels {
+		delete(labels, key)
+	}
+
+	return labels
+}
+
+// applyDeploymentLabels sets the labels provided on a Deployment, and returns
+// true if any of them changed. Labels that are not provided are left as they
+// are, as they may have been added by something other than the Operator
+func applyDeploymentLabels(deployment *apps_v1.Deployment, labels map[string]string) bool {
+	changed := false
+
+	if deployment.Labels == nil {
+		deployment.Labels = map[string]string{}
+	}
+
+	for key, value := range labels {
+		if current, ok := deployment.Labels[key]; !ok || current != value {
+			deployment.Labels[key] = value
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// applyDeploymentTemplate sets the number of replicas of a PostgreSQL
+// Deployment, the image and resources of its database container, and the pod
+// anti-affinity, tolerations, priority class, topology spread constraints and
+// custom annotations and labels of its pods, to what the pgcluster specifies.
+// It returns true if any of them changed
+func applyDeploymentTemplate(restclient *rest.RESTClient, cluster *crv1.Pgcluster,
+	deployment *apps_v1.Deployment) (bool, error) {
+	changed := false
+
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != 1 {
+		replicas := int32(1)
+		deployment.Spec.Replicas = &replicas
+		changed = true
+	}
+
+	// a replica may override the resources, tolerations and priority class of
+	// the cluster in its pgreplica. The original primary does not have a
+	// pgreplica
+	resources := cluster.Spec.ContainerResources
+	tolerations := operator.GetTolerations(cluster)
+	priorityClassName := operator.GetPriorityClassName(cluster)
+	replica := crv1.Pgreplica{}
+	if found, _ := kubeapi.Getpgreplica(restclient, &replica, deployment.Name, cluster.Namespace); found {
+		if replica.Spec.ContainerResources.LimitsCPU != "" {
+			resources = replica.Spec.ContainerResources
+		}
+		tolerations = operator.GetReplicaTolerations(cluster, &replica)
+		priorityClassName = operator.GetReplicaPriorityClassName(cluster, &replica)
+	}
+
+	desiredResources, err := getDesiredResources(&resources)
+	if err != nil {
+		return false, err
+	}
+
+	desiredAntiAffinity, err := getDesiredPodAntiAffinity(cluster)
+	if err != nil {
+		return false, err
+	}
+
+	image := getDatabaseImage(*cluster, operator.Pgo.Cluster.CCPImagePrefix, cluster.Spec.CCPImageTag)
+
+	for i := range deployment.Spec.Template.Spec.Containers {
+		container := &deployment.Spec.Template.Spec.Containers[i]
+		if container.Name != "database" {
+			continue
+		}
+
+		if container.Image != image {
+			container.Image = image
+			changed = true
+		}
+
+		if !equality.Semantic.DeepEqual(container.Resources, desiredResources) {
+			container.Resources = desiredResources
+			changed = true
+		}
+	}
+
+	affinity := deployment.Spec.Template.Spec.Affinity
+	if affinity == nil {
+		affinity = &v1.Affinity{}
+	}
+
+	if !equality.Semantic.DeepEqual(affinity.PodAntiAffinity, desiredAntiAffinity) {
+		affinity.PodAntiAffinity = desiredAntiAffinity
+		deployment.Spec.Template.Spec.Affinity = affinity
+		changed = true
+	}
+
+	podSpec := &deployment.Spec.Template.Spec
+
+	if !equality.Semantic.DeepEqual(podSpec.Tolerations, tolerations) {
+		podSpec.Tolerations = tolerations
+		changed = true
+	}
+
+	if podSpec.PriorityClassName != priorityClassName {
+		podSpec.PriorityClassName = priorityClassName
+		changed = true
+	}
+
+	topologySpreadConstraints := operator.GetTopologySpreadConstraints(cluster)
+	if !equality.Semantic.DeepEqual(podSpec.TopologySpreadConstraints, topologySpreadConstraints) {
+		podSpec.TopologySpreadConstraints = topologySpreadConstraints
+		changed = true
+	}
+
+	annotations, labels := operator.GetCustomMetadata(cluster.Spec.Metadata.Pod, cluster.Spec.UserLabels)
+	if operator.ApplyCustomMetadata(&deployment.Spec.Template.ObjectMeta, annotations, labels) {
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// getDesiredResources renders the resources of a database container the same
+// way as when its Deployment is created
+func getDesiredResources(resources *crv1.PgContainerResources) (v1.ResourceRequirements, error) {
+	doc := struct {
+		Resources v1.ResourceRequirements `json:"resources"`
+	}{}
+
+	snippet := strings.TrimSuffix(strings.TrimSpace(operator.GetContainerResourcesJSON(resources)), ",")
+
+	if err := json.Unmarshal([]byte("{"+snippet+"}"), &doc); err != nil {
+		return doc.Resources, fmt.Errorf("could not render container resources: %v", err)
+	}
+
+	return doc.Resources, nil
+}
+
+// getDesiredPodAntiAffinity renders the pod anti-affinity of a PostgreSQL
+// instance the same way as when its Deployment is created. It returns nil if
+// pod anti-affinity is disabled
+func getDesiredPodAntiAffinity(cluster *crv1.Pgcluster) (*v1.PodAntiAffinity, error) {
+	snippet := operator.GetPodAntiAffinity(cluster, crv1.PodAntiAffinityDeploymentDefault,
+		cluster.Spec.PodAntiAffinity.Default)
+
+	if strings.TrimSpace(snippet) == "" {
+		return nil, nil
+	}
+
+	affinity := v1.Affinity{}
+	if err := json.Unmarshal([]byte("{"+snippet+"}"), &affinity); err != nil {
+		return nil, fmt.Errorf("could not render pod anti-affinity: %v", err)
+	}
+
+	return affinity.PodAntiAffinity, nil
+}
+
+// allDeploymentsReady returns true if every Deployment provided has rolled
+// out its latest template and all of its pods are ready
+func allDeploymentsReady(deployments []apps_v1.Deployment) bool {
+	for _, deployment := range deployments {
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+
+		if deployment.Status.ObservedGeneration < deployment.Generation ||
+			deployment.Status.UpdatedReplicas != replicas ||
+			deployment.Status.ReadyReplicas != replicas {
+			return false
+		}
+	}
+
+	return true
+}
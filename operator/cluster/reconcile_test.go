@@ -0,0 +1,130 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"reflect"
+	"testing"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	apps_v1 "k8s.io/api/apps/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsReconcilable(t *testing.T) {
+	now := meta_v1.Now()
+
+	tests := []struct {
+		annotations map[string]string
+		userLabels  map[string]string
+		deletion    *meta_v1.Time
+		shutdown    bool
+		state       crv1.PgclusterState
+		expected    bool
+	}{
+		{nil, nil, nil, false, crv1.PgclusterStateInitialized, true},
+		{map[string]string{config.ANNOTATION_RECONCILE_PAUSED: "false"}, nil, nil, false,
+			crv1.PgclusterStateInitialized, true},
+		{map[string]string{config.ANNOTATION_RECONCILE_PAUSED: "true"}, nil, nil, false,
+			crv1.PgclusterStateInitialized, false},
+		{nil, nil, &now, false, crv1.PgclusterStateInitialized, false},
+		{nil, nil, nil, true, crv1.PgclusterStateInitialized, false},
+		{nil, nil, nil, false, crv1.PgclusterStateShutdown, false},
+		{nil, nil, nil, false, crv1.PgclusterStateProcessed, false},
+		{nil, nil, nil, false, crv1.PgclusterStateRestore, false},
+		{nil, nil, nil, false, crv1.PgclusterStateMajorUpgrade, false},
+		{nil, map[string]string{config.LABEL_MINOR_UPGRADE: config.LABEL_UPGRADE_IN_PROGRESS}, nil, false,
+			crv1.PgclusterStateInitialized, false},
+		{nil, map[string]string{config.LABEL_MINOR_UPGRADE: config.LABEL_UPGRADE_COMPLETED}, nil, false,
+			crv1.PgclusterStateInitialized, true},
+		{nil, map[string]string{config.LABEL_MAJOR_UPGRADE: config.LABEL_UPGRADE_IN_PROGRESS}, nil, false,
+			crv1.PgclusterStateInitialized, false},
+	}
+
+	for i, test := range tests {
+		cluster := &crv1.Pgcluster{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Annotations:       test.annotations,
+				DeletionTimestamp: test.deletion,
+			},
+			Spec: crv1.PgclusterSpec{
+				Shutdown:   test.shutdown,
+				UserLabels: test.userLabels,
+			},
+			Status: crv1.PgclusterStatus{State: test.state},
+		}
+
+		if ok, reason := isReconcilable(cluster); ok != test.expected {
+			t.Fatalf("tests[%d] - expected %t, got %t (%s)", i, test.expected, ok, reason)
+		}
+	}
+}
+
+func TestDesiredDeploymentLabels(t *testing.T) {
+	userLabels := map[string]string{
+		config.LABEL_PG_CLUSTER:        "hippo",
+		config.LABEL_NAME:              "hippo",
+		config.LABEL_DEPLOYMENT_NAME:   "hippo",
+		config.LABEL_CURRENT_PRIMARY:   "hippo",
+		config.LABEL_NODE_LABEL_KEY:    "",
+		config.LABEL_CCP_IMAGE_TAG_KEY: "centos7-12.3-4.4.0",
+		"env":                          "production",
+		"invalid key":                  "value",
+		"team":                         "invalid value!",
+	}
+
+	expected := map[string]string{
+		config.LABEL_PG_CLUSTER: "hippo",
+		"env":                   "production",
+	}
+
+	if labels := desiredDeploymentLabels(userLabels); !reflect.DeepEqual(labels, expected) {
+		t.Fatalf("expected labels %v, got %v", expected, labels)
+	}
+}
+
+func TestApplyDeploymentLabels(t *testing.T) {
+	tests := []struct {
+		current  map[string]string
+		desired  map[string]string
+		expected map[string]string
+		changed  bool
+	}{
+		{nil, map[string]string{}, map[string]string{}, false},
+		{map[string]string{"env": "dev"}, map[string]string{"env": "dev"},
+			map[string]string{"env": "dev"}, false},
+		{map[string]string{"env": "dev"}, map[string]string{"env": "production"},
+			map[string]string{"env": "production"}, true},
+		{nil, map[string]string{"env": "dev"}, map[string]string{"env": "dev"}, true},
+		{map[string]string{"vendor": "crunchydata"}, map[string]string{"env": "dev"},
+			map[string]string{"vendor": "crunchydata", "env": "dev"}, true},
+	}
+
+	for i, test := range tests {
+		deployment := &apps_v1.Deployment{
+			ObjectMeta: meta_v1.ObjectMeta{Labels: test.current},
+		}
+
+		if changed := applyDeploymentLabels(deployment, test.desired); changed != test.changed {
+			t.Fatalf("tests[%d] - expected changed %t, got %t", i, test.changed, changed)
+		}
+
+		if !reflect.DeepEqual(deployment.Labels, test.expected) {
+			t.Fatalf("tests[%d] - expected labels %v, got %v", i, test.expected, deployment.Labels)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"sort"
+	"strconv"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/operator"
+	"github.com/crunchydata/postgres-operator/operator/backrest"
+	"github.com/crunchydata/postgres-operator/operator/pvc"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RenderCluster returns the objects that AddClusterBase and ScaleBase create
+// for a new cluster, i.e. its PVCs, NetworkPolicies, services, pgBackRest
+// repository, secrets, configmap and deployments, as well as those of pgBouncer
+// if the cluster has it, without creating any of them. The pgcluster is left
+// unchanged
+func RenderCluster(clientset *kubernetes.Clientset, cl *crv1.Pgcluster, namespace string) ([]runtime.Object, error) {
+	objects := []runtime.Object{}
+
+	// work on a copy of the cluster, as rendering the deployments sets labels
+	cluster := *cl
+	cluster.ObjectMeta.Labels = copyLabels(cl.ObjectMeta.Labels)
+	cluster.Spec.UserLabels = copyLabels(cl.Spec.UserLabels)
+
+	primaryPVC, pvcName, err := pvc.RenderPVC(&cluster.Spec.PrimaryStorage, cluster.Spec.Name,
+		cluster.Spec.Name)
+	if err != nil {
+		return nil, err
+	}
+	if primaryPVC != nil {
+		objects = append(objects, primaryPVC)
+	}
+
+	tablespacePVCs, err := renderTablespacePVCs(&cluster, cluster.Spec.Name)
+	if err != nil {
+		return nil, err
+	}
+	objects = append(objects, tablespacePVCs...)
+
+	for _, policy := range getNetworkPolicies(&cluster) {
+		objects = append(objects, policy)
+	}
+
+	serviceFields := newPrimaryServiceFields(&cluster)
+	service, err := renderService(&serviceFields)
+	if err != nil {
+		return nil, err
+	}
+	objects = append(objects, service)
+
+	setPrimaryUserLabels(&cluster)
+
+	if cluster.Labels[config.LABEL_BACKREST] == "true" {
+		repo, err := backrest.RenderRepo(clientset, namespace, &cluster)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, repo...)
+	}
+
+	if secret := operator.NewCollectSecret(&cluster.Spec); secret != nil {
+		objects = append(objects, secret)
+	}
+
+	if cluster.Spec.TLS.Auto {
+		objects = append(objects, renderClusterTLS(&cluster)...)
+	}
+
+	objects = append(objects, operator.NewPGHAConfigMap(&cluster))
+
+	deployment, err := renderPrimaryDeployment(clientset, &cluster, namespace, pvcName)
+	if err != nil {
+		return nil, err
+	}
+	objects = append(objects, deployment)
+
+	pgBouncer, err := renderPgBouncer(&cluster)
+	if err != nil {
+		return nil, err
+	}
+	objects = append(objects, pgBouncer...)
+
+	if cluster.Spec.Replicas == "" {
+		return objects, nil
+	}
+
+	replicaCount, err := strconv.Atoi(cluster.Spec.Replicas)
+	if err != nil {
+		return nil, err
+	}
+
+	// the operator labels the pgcluster with the Patroni scope of the primary
+	// once it is created, which is the scope the replicas join
+	cluster.Labels[config.LABEL_PGHA_SCOPE] = cluster.Spec.Name
+
+	for i := 0; i < replicaCount; i++ {
+		replica := newPgreplica(cl)
+
+		if i == 0 {
+			serviceFields := newReplicaServiceFields(replica, &cluster)
+			service, err := renderService(&serviceFields)
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, service)
+		}
+
+		replicaPVC, replicaPVCName, err := pvc.RenderPVC(&replica.Spec.ReplicaStorage, replica.Spec.Name,
+			cluster.Spec.Name)
+		if err != nil {
+			return nil, err
+		}
+		if replicaPVC != nil {
+			objects = append(objects, replicaPVC)
+		}
+
+		tablespacePVCs, err := renderTablespacePVCs(&cluster, replica.Spec.Name)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, tablespacePVCs...)
+
+		// each replica labels its own copy of the cluster
+		replicaCluster := cluster
+		replicaCluster.Spec.UserLabels = copyLabels(cluster.Spec.UserLabels)
+		setReplicaUserLabels(replica, &replicaCluster)
+
+		replicaDeployment, err := renderReplicaDeployment(clientset, replica, &replicaCluster, namespace,
+			replicaPVCName)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, replicaDeployment)
+	}
+
+	return objects, nil
+}
+
+// renderTablespacePVCs returns the PVCs of the tablespaces of an instance of a
+// cluster, ordered by the name of the tablespace
+func renderTablespacePVCs(cluster *crv1.Pgcluster, instanceName string) ([]runtime.Object, error) {
+	objects := []runtime.Object{}
+
+	tablespaceNames := make([]string, 0, len(cluster.Spec.TablespaceMounts))
+	for tablespaceName := range cluster.Spec.TablespaceMounts {
+		tablespaceNames = append(tablespaceNames, tablespaceName)
+	}
+	sort.Strings(tablespaceNames)
+
+	for _, tablespaceName := range tablespaceNames {
+		storageSpec := cluster.Spec.TablespaceMounts[tablespaceName]
+		tablespacePVC, _, err := pvc.RenderPVC(&storageSpec,
+			operator.GetTablespacePVCName(instanceName, tablespaceName), cluster.Spec.Name)
+		if err != nil {
+			return nil, err
+		}
+		if tablespacePVC != nil {
+			objects = append(objects, tablespacePVC)
+		}
+	}
+
+	return objects, nil
+}
+
+// renderPgBouncer returns the objects that AddPgbouncer creates for a cluster
+// that has pgBouncer, i.e. its secret and the Deployment, PodDisruptionBudget
+// and Service of pgBouncer, followed by those of the read-only pgBouncer if
+// requested. The password of the "pgbouncer" user in the secret is generated
+// just as it is for a new pgBouncer
+func renderPgBouncer(cluster *crv1.Pgcluster) ([]runtime.Object, error) {
+	if cluster.Labels[config.LABEL_PGBOUNCER] != "true" {
+		return nil, nil
+	}
+
+	secret, err := renderPgBouncerSecret(cluster, generatePassword())
+	if err != nil {
+		return nil, err
+	}
+	objects := []runtime.Object{secret}
+
+	readOnly := []bool{false}
+	if cluster.Spec.PgBouncer.ReadOnly {
+		readOnly = append(readOnly, true)
+	}
+
+	for _, ro := range readOnly {
+		deployment, err := renderPgBouncerDeployment(cluster, ro)
+		if err != nil {
+			return nil, err
+		}
+
+		serviceFields := newPgBouncerServiceFields(cluster, ro)
+		service, err := renderService(&serviceFields)
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, deployment, newPgBouncerPodDisruptionBudget(cluster, ro), service)
+	}
+
+	return objects, nil
+}
+
+// copyLabels returns a copy of a map of labels that is never nil
+func copyLabels(labels map[string]string) map[string]string {
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return copied
+}
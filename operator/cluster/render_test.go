@@ -0,0 +1,133 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"reflect"
+	"testing"
+	"text/template"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/operator"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	policy_v1beta1 "k8s.io/api/policy/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// testConfigsPath is the path of the default configuration of the Operator,
+// which holds the templates that the objects of a cluster are rendered from
+const testConfigsPath = "../../conf/postgres-operator/"
+
+func TestRenderPgBouncer(t *testing.T) {
+	pgo, pgBouncerTemplate, pgBouncerConfTemplate, pgBouncerHBATemplate, serviceTemplate :=
+		operator.Pgo, config.PgbouncerTemplate, config.PgbouncerConfTemplate,
+		config.PgbouncerHBATemplate, config.ServiceTemplate
+	defer func() {
+		operator.Pgo = pgo
+		config.PgbouncerTemplate = pgBouncerTemplate
+		config.PgbouncerConfTemplate = pgBouncerConfTemplate
+		config.PgbouncerHBATemplate = pgBouncerHBATemplate
+		config.ServiceTemplate = serviceTemplate
+	}()
+
+	operator.Pgo.Cluster.Port = "5432"
+	operator.Pgo.Cluster.CCPImagePrefix = "crunchydata"
+	config.PgbouncerTemplate = template.Must(template.ParseFiles(testConfigsPath + "pgbouncer-template.json"))
+	config.PgbouncerConfTemplate = template.Must(template.ParseFiles(testConfigsPath + "pgbouncer.ini"))
+	config.PgbouncerHBATemplate = template.Must(template.ParseFiles(testConfigsPath + "pgbouncer_hba.conf"))
+	config.ServiceTemplate = template.Must(template.ParseFiles(testConfigsPath + "cluster-service.json"))
+
+	tests := []struct {
+		pgBouncer string
+		readOnly  bool
+		expected  []string
+	}{
+		{"", false, []string{}},
+		{"false", true, []string{}},
+		{"true", false, []string{
+			"Secret/hippo-pgbouncer-secret",
+			"Deployment/hippo-pgbouncer",
+			"PodDisruptionBudget/hippo-pgbouncer",
+			"Service/hippo-pgbouncer",
+		}},
+		{"true", true, []string{
+			"Secret/hippo-pgbouncer-secret",
+			"Deployment/hippo-pgbouncer",
+			"PodDisruptionBudget/hippo-pgbouncer",
+			"Service/hippo-pgbouncer",
+			"Deployment/hippo-pgbouncer-ro",
+			"PodDisruptionBudget/hippo-pgbouncer-ro",
+			"Service/hippo-pgbouncer-ro",
+		}},
+	}
+
+	for i, test := range tests {
+		cluster := &crv1.Pgcluster{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:   "hippo",
+				Labels: map[string]string{config.LABEL_PGBOUNCER: test.pgBouncer},
+			},
+			Spec: crv1.PgclusterSpec{
+				Name:        "hippo",
+				ClusterName: "hippo",
+				CCPImageTag: "centos7-12.4-4.4.1",
+				Port:        "5432",
+				PgBouncer:   crv1.PgBouncerSpec{ReadOnly: test.readOnly},
+				PodAntiAffinity: crv1.PodAntiAffinitySpec{
+					PgBouncer: crv1.PodAntiAffinityDisabled,
+				},
+			},
+		}
+
+		objects, err := renderPgBouncer(cluster)
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %v", i, err)
+		}
+
+		rendered := []string{}
+		for _, object := range objects {
+			switch object := object.(type) {
+			case *v1.Secret:
+				if len(object.Data["password"]) == 0 || len(object.Data[pgBouncerReadOnlyConfKey]) == 0 {
+					t.Fatalf("tests[%d] - expected the secret to have a password and pgbouncer-ro.ini", i)
+				}
+				rendered = append(rendered, "Secret/"+object.Name)
+			case *appsv1.Deployment:
+				if *object.Spec.Replicas != pgBouncerDefaultReplicas {
+					t.Fatalf("tests[%d] - expected %d replicas of %s, got %d", i,
+						pgBouncerDefaultReplicas, object.Name, *object.Spec.Replicas)
+				}
+				rendered = append(rendered, "Deployment/"+object.Name)
+			case *policy_v1beta1.PodDisruptionBudget:
+				rendered = append(rendered, "PodDisruptionBudget/"+object.Name)
+			case *v1.Service:
+				if object.Spec.Selector[config.LABEL_SERVICE_NAME] != object.Name {
+					t.Fatalf("tests[%d] - expected service %s to select its pgBouncer, got %v", i,
+						object.Name, object.Spec.Selector)
+				}
+				rendered = append(rendered, "Service/"+object.Name)
+			default:
+				t.Fatalf("tests[%d] - unexpected object %T", i, object)
+			}
+		}
+
+		if !reflect.DeepEqual(rendered, test.expected) {
+			t.Fatalf("tests[%d] - expected %v, got %v", i, test.expected, rendered)
+		}
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/crunchydata/postgres-operator/util"
 	log "github.com/sirupsen/logrus"
 	v1batch "k8s.io/api/batch/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"os"
 	"strconv"
@@ -104,3 +105,31 @@ func CreateRmdataJob(clientset *kubernetes.Clientset, cl *crv1.Pgcluster, namesp
 
 	return err
 }
+
+// newRMDataTask returns the pgtask that runs pgo-rmdata for a cluster, or for
+// a single replica of a cluster if a replica name is provided
+func newRMDataTask(cluster *crv1.Pgcluster, replicaName, taskName string, deleteData, deleteBackups bool) *crv1.Pgtask {
+	return &crv1.Pgtask{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: taskName,
+			Labels: map[string]string{
+				config.LABEL_PG_CLUSTER: cluster.Name,
+				config.LABEL_RMDATA:     "true",
+			},
+		},
+		Spec: crv1.PgtaskSpec{
+			Namespace: cluster.Namespace,
+			Name:      taskName,
+			TaskType:  crv1.PgtaskDeleteData,
+			Parameters: map[string]string{
+				config.LABEL_DELETE_DATA:    strconv.FormatBool(deleteData),
+				config.LABEL_DELETE_BACKUPS: strconv.FormatBool(deleteBackups),
+				config.LABEL_IS_REPLICA:     strconv.FormatBool(replicaName != ""),
+				config.LABEL_IS_BACKUP:      "false",
+				config.LABEL_PG_CLUSTER:     cluster.Name,
+				config.LABEL_REPLICA_NAME:   replicaName,
+				config.LABEL_PGHA_SCOPE:     cluster.Labels[config.LABEL_PGHA_SCOPE],
+			},
+		},
+	}
+}
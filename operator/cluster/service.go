@@ -21,43 +21,138 @@ package cluster
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"os"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
 	"github.com/crunchydata/postgres-operator/config"
 	"github.com/crunchydata/postgres-operator/kubeapi"
 	"github.com/crunchydata/postgres-operator/operator"
 	log "github.com/sirupsen/logrus"
-	"k8s.io/api/core/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/client-go/kubernetes"
-	"os"
 )
 
 // CreateService ...
 func CreateService(clientset *kubernetes.Clientset, fields *ServiceTemplateFields, namespace string) error {
-	var serviceDoc bytes.Buffer
-
 	//create the service if it doesn't exist
 	_, found, err := kubeapi.GetService(clientset, fields.Name, namespace)
 	if !found || err != nil {
 
-		err = config.ServiceTemplate.Execute(&serviceDoc, fields)
+		var service *v1.Service
+		service, err = renderService(fields)
 		if err != nil {
-			log.Error(err.Error())
 			return err
 		}
 
-		if operator.CRUNCHY_DEBUG {
-			config.ServiceTemplate.Execute(os.Stdout, fields)
-		}
+		_, err = kubeapi.CreateService(clientset, service, namespace)
+	}
+
+	return err
+
+}
 
-		service := v1.Service{}
-		err = json.Unmarshal(serviceDoc.Bytes(), &service)
+// renderService returns the service that CreateService creates, without
+// creating it
+func renderService(fields *ServiceTemplateFields) (*v1.Service, error) {
+	var serviceDoc bytes.Buffer
+
+	err := config.ServiceTemplate.Execute(&serviceDoc, fields)
+	if err != nil {
+		log.Error(err.Error())
+		return nil, err
+	}
+
+	if operator.CRUNCHY_DEBUG {
+		config.ServiceTemplate.Execute(os.Stdout, fields)
+	}
+
+	service := v1.Service{}
+	err = json.Unmarshal(serviceDoc.Bytes(), &service)
+	if err != nil {
+		log.Error("error unmarshalling json into Service " + err.Error())
+		return nil, err
+	}
+
+	return &service, nil
+}
+
+// getPrimaryServiceType returns the type of the primary Service of a cluster,
+// which is the one in its user labels, or the default in pgo.yaml
+func getPrimaryServiceType(cluster *crv1.Pgcluster) string {
+	if cluster.Spec.UserLabels[config.LABEL_SERVICE_TYPE] != "" {
+		return cluster.Spec.UserLabels[config.LABEL_SERVICE_TYPE]
+	}
+
+	return operator.Pgo.Cluster.ServiceType
+}
+
+// getReplicaServiceType returns the type of the replica Service of a cluster.
+// The replica service type of the cluster takes precedence over the service
+// type of the replica the Service is created for, if any, which in turn takes
+// precedence over the type of the primary Service
+func getReplicaServiceType(cluster *crv1.Pgcluster, replica *crv1.Pgreplica) string {
+	if cluster.Spec.ReplicaServiceType != "" {
+		return cluster.Spec.ReplicaServiceType
+	}
+
+	if replica != nil && replica.Spec.UserLabels[config.LABEL_SERVICE_TYPE] != "" {
+		return replica.Spec.UserLabels[config.LABEL_SERVICE_TYPE]
+	}
+
+	return getPrimaryServiceType(cluster)
+}
+
+// setServiceMetadata sets the custom annotations and labels of a Service, and
+// its load balancer source ranges if it is of type LoadBalancer
+func setServiceMetadata(fields *ServiceTemplateFields, metadata crv1.MetadataSpec, sourceRanges []string) {
+	fields.Annotations, fields.CustomLabels = operator.GetCustomMetadataJSON(metadata, nil)
+	fields.LoadBalancerSourceRanges = ""
+
+	if fields.ServiceType == config.LOAD_BALANCER_SERVICE_TYPE && len(sourceRanges) > 0 {
+		doc, err := json.Marshal(sourceRanges)
 		if err != nil {
-			log.Error("error unmarshalling json into Service " + err.Error())
-			return err
+			log.Error(err)
+			return
 		}
 
-		_, err = kubeapi.CreateService(clientset, &service, namespace)
+		fields.LoadBalancerSourceRanges = fmt.Sprintf(`"loadBalancerSourceRanges": %s,`, doc)
 	}
+}
 
-	return err
+// applyServiceSpec sets the custom annotations and labels of an existing
+// Service, as well as its type and load balancer source ranges. An empty
+// service type leaves the type as it is. It returns true if anything changed
+func applyServiceSpec(service *v1.Service, metadata crv1.MetadataSpec, serviceType string,
+	sourceRanges []string) bool {
+	annotations, labels := operator.GetCustomMetadata(metadata, nil)
+	changed := operator.ApplyCustomMetadata(&service.ObjectMeta, annotations, labels)
+
+	if serviceType != "" && string(service.Spec.Type) != serviceType {
+		service.Spec.Type = v1.ServiceType(serviceType)
+		changed = true
+
+		// a ClusterIP Service cannot have node ports or an external traffic
+		// policy, which were assigned when it had a different type
+		if service.Spec.Type == v1.ServiceTypeClusterIP {
+			for i := range service.Spec.Ports {
+				service.Spec.Ports[i].NodePort = 0
+			}
+			service.Spec.ExternalTrafficPolicy = ""
+			service.Spec.HealthCheckNodePort = 0
+		}
+	}
+
+	// source ranges can only be set on a LoadBalancer Service
+	if service.Spec.Type != v1.ServiceTypeLoadBalancer {
+		sourceRanges = nil
+	}
+
+	if !equality.Semantic.DeepEqual(service.Spec.LoadBalancerSourceRanges, sourceRanges) {
+		service.Spec.LoadBalancerSourceRanges = sourceRanges
+		changed = true
+	}
 
+	return changed
 }
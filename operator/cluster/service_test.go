@@ -0,0 +1,61 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"testing"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestApplyServiceSpec(t *testing.T) {
+	sourceRanges := []string{"10.0.0.0/8"}
+
+	t.Run("load balancer", func(t *testing.T) {
+		service := &v1.Service{Spec: v1.ServiceSpec{Type: v1.ServiceTypeClusterIP}}
+
+		if !applyServiceSpec(service, crv1.MetadataSpec{}, string(v1.ServiceTypeLoadBalancer), sourceRanges) {
+			t.Fatal("expected the Service to be changed")
+		}
+
+		if service.Spec.Type != v1.ServiceTypeLoadBalancer || len(service.Spec.LoadBalancerSourceRanges) != 1 {
+			t.Fatalf("unexpected spec %+v", service.Spec)
+		}
+
+		if applyServiceSpec(service, crv1.MetadataSpec{}, "", sourceRanges) {
+			t.Fatal("expected the Service to be unchanged")
+		}
+	})
+
+	t.Run("cluster ip", func(t *testing.T) {
+		service := &v1.Service{Spec: v1.ServiceSpec{
+			Type:                     v1.ServiceTypeLoadBalancer,
+			Ports:                    []v1.ServicePort{{Port: 5432, NodePort: 30432}},
+			ExternalTrafficPolicy:    v1.ServiceExternalTrafficPolicyTypeCluster,
+			LoadBalancerSourceRanges: sourceRanges,
+		}}
+
+		if !applyServiceSpec(service, crv1.MetadataSpec{}, string(v1.ServiceTypeClusterIP), sourceRanges) {
+			t.Fatal("expected the Service to be changed")
+		}
+
+		if service.Spec.Ports[0].NodePort != 0 || service.Spec.ExternalTrafficPolicy != "" ||
+			service.Spec.LoadBalancerSourceRanges != nil {
+			t.Fatalf("unexpected spec %+v", service.Spec)
+		}
+	})
+}
@@ -0,0 +1,407 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	"github.com/crunchydata/postgres-operator/operator"
+	"github.com/crunchydata/postgres-operator/util"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// the reasons given for the status of the conditions of a pgcluster
+const (
+	reasonBackupCompleted  = "BackupCompleted"
+	reasonBackupFailed     = "BackupFailed"
+	reasonClusterReady     = "ClusterReady"
+	reasonNoBackup         = "NoBackup"
+	reasonNoPrimary        = "NoPrimary"
+	reasonNotInitialized   = "NotInitialized"
+	reasonPrimaryNotReady  = "PrimaryNotReady"
+	reasonPrimaryReady     = "PrimaryReady"
+	reasonReplicasNotReady = "ReplicasNotReady"
+	reasonReplicasReady    = "ReplicasReady"
+	reasonRestoring        = "Restoring"
+	reasonRestoreInactive  = "NoRestore"
+	reasonRPOExceeded      = "RPOExceeded"
+	reasonShutdown         = "Shutdown"
+	reasonUpgradeInactive  = "NoUpgrade"
+	reasonUpgrading        = "Upgrading"
+)
+
+// observedState is the state of the Kubernetes objects of a cluster that the
+// status of its pgcluster is derived from
+type observedState struct {
+	primary         string
+	primaryReady    bool
+	replicas        int
+	readyReplicas   int
+	pgBouncerReady  bool
+	postgresVersion string
+}
+
+// UpdateStatus refreshes the conditions and the observed state in the status
+// of a pgcluster from the current state of its Pods and Deployments
+func UpdateStatus(clientset *kubernetes.Clientset, restclient *rest.RESTClient, clusterName,
+	namespace string) error {
+	return updateStatus(clientset, restclient, clusterName, namespace, nil)
+}
+
+// RecordBackupCompleted sets the time the last successful backup of a cluster
+// completed at, and refreshes the status of its pgcluster
+func RecordBackupCompleted(clientset *kubernetes.Clientset, restclient *rest.RESTClient,
+	clusterName, namespace string, completionTime meta_v1.Time) error {
+	return updateStatus(clientset, restclient, clusterName, namespace,
+		func(status *crv1.PgclusterStatus) {
+			if status.LastBackupTime == nil || status.LastBackupTime.Before(&completionTime) {
+				status.LastBackupTime = &completionTime
+			}
+		})
+}
+
+// RecordBackupFailed marks the backups of a cluster as unhealthy until the
+// next successful backup, and refreshes the status of its pgcluster. A failure
+// that happened before the last successful backup is ignored
+func RecordBackupFailed(clientset *kubernetes.Clientset, restclient *rest.RESTClient,
+	clusterName, namespace string, failedTime meta_v1.Time, message string) error {
+	return updateStatus(clientset, restclient, clusterName, namespace,
+		func(status *crv1.PgclusterStatus) {
+			if status.LastBackupTime != nil && !status.LastBackupTime.Before(&failedTime) {
+				return
+			}
+
+			status.SetCondition(crv1.PgclusterCondition{
+				Type:               crv1.PgclusterConditionBackupsHealthy,
+				Status:             meta_v1.ConditionFalse,
+				LastTransitionTime: failedTime,
+				Reason:             reasonBackupFailed,
+				Message:            message,
+			})
+		})
+}
+
+// updateStatus observes the state of a cluster, applies the optional change
+// to the status of its pgcluster, and patches the status if it changed. The
+// patch fails if the pgcluster was modified after it was read
+func updateStatus(clientset *kubernetes.Clientset, restclient *rest.RESTClient, clusterName,
+	namespace string, change func(*crv1.PgclusterStatus)) error {
+	cluster := crv1.Pgcluster{}
+	if _, err := kubeapi.Getpgcluster(restclient, &cluster, clusterName, namespace); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	// there is nothing left to report on a cluster that is being deleted
+	if cluster.DeletionTimestamp != nil {
+		return nil
+	}
+
+	observed, err := observeCluster(clientset, &cluster)
+	if err != nil {
+		return err
+	}
+
+	rpo, err := time.ParseDuration(operator.Pgo.Cluster.BackrestRPO)
+	if err != nil {
+		return err
+	}
+
+	// the change is made to a copy, so that the result can be compared with the
+	// status that was read
+	updated := cluster
+	updated.Status.Conditions = append([]crv1.PgclusterCondition(nil),
+		cluster.Status.Conditions...)
+	if change != nil {
+		change(&updated.Status)
+	}
+	status := computeStatus(&updated, observed, rpo, meta_v1.Now())
+
+	if equality.Semantic.DeepEqual(status, cluster.Status) &&
+		cluster.Status.ObservedGeneration == cluster.Generation {
+		return nil
+	}
+
+	// the generation of a pgcluster is incremented by the patch itself
+	status.ObservedGeneration = cluster.Generation + 1
+
+	log.Debugf("updating status of cluster %s", clusterName)
+
+	return kubeapi.PatchpgclusterObservedStatus(restclient, status, &cluster)
+}
+
+// observeCluster gathers the state of the database Pods and the pgBouncer
+// Deployment of a cluster
+func observeCluster(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster) (observedState,
+	error) {
+	observed := observedState{}
+
+	selector := fmt.Sprintf("%s=%s,%s", config.LABEL_PG_CLUSTER, cluster.Name,
+		config.LABEL_PG_DATABASE)
+	pods, err := kubeapi.GetPods(clientset, selector, cluster.Namespace)
+	if err != nil {
+		return observed, err
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		switch pod.Labels[config.LABEL_PGHA_ROLE] {
+		case "master":
+			observed.primary = pod.Labels[config.LABEL_DEPLOYMENT_NAME]
+			observed.primaryReady = isDatabaseContainerReady(pod)
+			observed.postgresVersion = util.GetPostgresVersion(
+				getImageTag(getDatabaseContainerImage(pod)))
+		case "replica":
+			observed.replicas++
+			if isDatabaseContainerReady(pod) {
+				observed.readyReplicas++
+			}
+		}
+	}
+
+	// an image that is overridden might not have a version in its tag, in which
+	// case the version is taken from the pgcluster
+	if observed.postgresVersion == "" {
+		observed.postgresVersion = util.GetPostgresVersion(cluster.Spec.CCPImageTag)
+	}
+
+	deployment, found, err := kubeapi.GetDeployment(clientset,
+		fmt.Sprintf(pgBouncerDeploymentFormat, cluster.Name), cluster.Namespace)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return observed, err
+	}
+
+	if found && deployment.Spec.Replicas != nil && *deployment.Spec.Replicas > 0 {
+		observed.pgBouncerReady = deployment.Status.ReadyReplicas >= *deployment.Spec.Replicas
+	}
+
+	// a read-only pgBouncer has to be ready as well
+	if observed.pgBouncerReady && cluster.Spec.PgBouncer.ReadOnly {
+		deployment, found, err := kubeapi.GetDeployment(clientset,
+			fmt.Sprintf(pgBouncerReadOnlyDeploymentFormat, cluster.Name), cluster.Namespace)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return observed, err
+		}
+
+		observed.pgBouncerReady = found && deployment.Spec.Replicas != nil &&
+			deployment.Status.ReadyReplicas >= *deployment.Spec.Replicas
+	}
+
+	return observed, nil
+}
+
+// computeStatus returns the status of a pgcluster updated with the state that
+// was observed, with every condition evaluated as of the time provided
+func computeStatus(cluster *crv1.Pgcluster, observed observedState, rpo time.Duration,
+	now meta_v1.Time) crv1.PgclusterStatus {
+	status := cluster.Status
+	status.Conditions = append([]crv1.PgclusterCondition(nil), cluster.Status.Conditions...)
+
+	status.Primary = observed.primary
+	status.Replicas = observed.replicas
+	status.ReadyReplicas = observed.readyReplicas
+	status.PgBouncerReady = observed.pgBouncerReady
+	status.PostgresVersion = observed.postgresVersion
+
+	shutdown := cluster.Spec.Shutdown || status.State == crv1.PgclusterStateShutdown
+
+	// PrimaryAvailable
+	primary := newCondition(crv1.PgclusterConditionPrimaryAvailable, now)
+	switch {
+	case observed.primaryReady:
+		primary.Status = meta_v1.ConditionTrue
+		primary.Reason = reasonPrimaryReady
+		primary.Message = fmt.Sprintf("instance %s is the primary and is ready", observed.primary)
+	case shutdown:
+		primary.Reason = reasonShutdown
+		primary.Message = "the cluster is shut down"
+	case observed.primary == "":
+		primary.Reason = reasonNoPrimary
+		primary.Message = "no instance is the primary"
+	default:
+		primary.Reason = reasonPrimaryNotReady
+		primary.Message = fmt.Sprintf("primary instance %s is not ready", observed.primary)
+	}
+	status.SetCondition(primary)
+
+	// ReplicasReady. When the pgcluster manages its replica count, all of the
+	// requested replicas have to be running
+	desired := observed.replicas
+	if n, err := strconv.Atoi(cluster.Spec.Replicas); err == nil && n > desired {
+		desired = n
+	}
+
+	replicas := newCondition(crv1.PgclusterConditionReplicasReady, now)
+	replicas.Message = fmt.Sprintf("%d of %d replicas are ready", observed.readyReplicas, desired)
+	if observed.readyReplicas >= desired {
+		replicas.Status = meta_v1.ConditionTrue
+		replicas.Reason = reasonReplicasReady
+	} else {
+		replicas.Reason = reasonReplicasNotReady
+	}
+	status.SetCondition(replicas)
+
+	// Upgrading
+	upgrading := newCondition(crv1.PgclusterConditionUpgrading, now)
+	upgrading.Reason = reasonUpgradeInactive
+	switch {
+	case cluster.Spec.UserLabels[config.LABEL_MAJOR_UPGRADE] == config.LABEL_UPGRADE_IN_PROGRESS ||
+		status.State == crv1.PgclusterStateMajorUpgrade:
+		upgrading.Status = meta_v1.ConditionTrue
+		upgrading.Reason = reasonUpgrading
+		upgrading.Message = "a major upgrade is in progress"
+	case cluster.Labels[config.LABEL_MINOR_UPGRADE] == config.LABEL_UPGRADE_IN_PROGRESS ||
+		cluster.Spec.UserLabels[config.LABEL_MINOR_UPGRADE] == config.LABEL_UPGRADE_IN_PROGRESS:
+		upgrading.Status = meta_v1.ConditionTrue
+		upgrading.Reason = reasonUpgrading
+		upgrading.Message = "a minor upgrade is in progress"
+	}
+	status.SetCondition(upgrading)
+
+	// Restoring
+	restoring := newCondition(crv1.PgclusterConditionRestoring, now)
+	restoring.Reason = reasonRestoreInactive
+	if status.State == crv1.PgclusterStateRestore {
+		restoring.Status = meta_v1.ConditionTrue
+		restoring.Reason = reasonRestoring
+		restoring.Message = "the cluster is being restored"
+	}
+	status.SetCondition(restoring)
+
+	// BackupsHealthy. A failed backup is reported until a backup succeeds
+	if backups := status.GetCondition(crv1.PgclusterConditionBackupsHealthy); backups == nil ||
+		backups.Reason != reasonBackupFailed ||
+		(status.LastBackupTime != nil && !status.LastBackupTime.Before(&backups.LastTransitionTime)) {
+		status.SetCondition(newBackupsCondition(status.LastBackupTime, rpo, now))
+	}
+
+	// Ready
+	ready := newCondition(crv1.PgclusterConditionReady, now)
+	switch {
+	case shutdown:
+		ready.Reason = reasonShutdown
+		ready.Message = "the cluster is shut down"
+	case restoring.Status == meta_v1.ConditionTrue:
+		ready.Reason = reasonRestoring
+		ready.Message = restoring.Message
+	case upgrading.Status == meta_v1.ConditionTrue:
+		ready.Reason = reasonUpgrading
+		ready.Message = upgrading.Message
+	case status.State != crv1.PgclusterStateInitialized:
+		ready.Reason = reasonNotInitialized
+		ready.Message = "the cluster has not been initialized"
+	case primary.Status != meta_v1.ConditionTrue:
+		ready.Reason = primary.Reason
+		ready.Message = primary.Message
+	case replicas.Status != meta_v1.ConditionTrue:
+		ready.Reason = replicas.Reason
+		ready.Message = replicas.Message
+	default:
+		ready.Status = meta_v1.ConditionTrue
+		ready.Reason = reasonClusterReady
+		ready.Message = "the primary and all of the replicas are ready"
+	}
+	status.SetCondition(ready)
+
+	return status
+}
+
+// newBackupsCondition evaluates the BackupsHealthy condition from the time the
+// last successful backup completed and the recovery point objective
+func newBackupsCondition(lastBackupTime *meta_v1.Time, rpo time.Duration,
+	now meta_v1.Time) crv1.PgclusterCondition {
+	condition := newCondition(crv1.PgclusterConditionBackupsHealthy, now)
+
+	switch {
+	case lastBackupTime == nil:
+		condition.Reason = reasonNoBackup
+		condition.Message = "no backup has completed"
+	case now.Sub(lastBackupTime.Time) > rpo:
+		condition.Reason = reasonRPOExceeded
+		condition.Message = fmt.Sprintf("the last backup completed at %s, more than %s ago",
+			lastBackupTime.UTC().Format(time.RFC3339), rpo)
+	default:
+		condition.Status = meta_v1.ConditionTrue
+		condition.Reason = reasonBackupCompleted
+		condition.Message = fmt.Sprintf("the last backup completed at %s",
+			lastBackupTime.UTC().Format(time.RFC3339))
+	}
+
+	return condition
+}
+
+// newCondition returns a condition of the type provided with a status of
+// False, which transitioned at the time provided
+func newCondition(conditionType crv1.PgclusterConditionType,
+	now meta_v1.Time) crv1.PgclusterCondition {
+	return crv1.PgclusterCondition{
+		Type:               conditionType,
+		Status:             meta_v1.ConditionFalse,
+		LastTransitionTime: now,
+	}
+}
+
+// isDatabaseContainerReady returns true if the "database" container of a Pod
+// is ready
+func isDatabaseContainerReady(pod *v1.Pod) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == "database" {
+			return status.Ready
+		}
+	}
+	return false
+}
+
+// getDatabaseContainerImage returns the image of the "database" container of
+// a Pod
+func getDatabaseContainerImage(pod *v1.Pod) string {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == "database" {
+			return container.Image
+		}
+	}
+	return ""
+}
+
+// getImageTag returns the tag of an image, e.g. "centos7-12.3-4.4.0" for
+// "registry:5000/crunchydata/crunchy-postgres-ha:centos7-12.3-4.4.0"
+func getImageTag(image string) string {
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return ""
+	}
+	return image[i+1:]
+}
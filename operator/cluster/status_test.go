@@ -0,0 +1,227 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"testing"
+	"time"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestComputeStatusReady(t *testing.T) {
+	now := meta_v1.Now()
+	lastBackup := meta_v1.NewTime(now.Add(-time.Hour))
+
+	healthy := observedState{primary: "hippo", primaryReady: true, replicas: 2, readyReplicas: 2}
+
+	tests := []struct {
+		state      crv1.PgclusterState
+		shutdown   bool
+		userLabels map[string]string
+		replicas   string
+		observed   observedState
+		ready      meta_v1.ConditionStatus
+		reason     string
+	}{
+		{crv1.PgclusterStateInitialized, false, nil, "", healthy, meta_v1.ConditionTrue,
+			reasonClusterReady},
+		{crv1.PgclusterStateInitialized, false, nil, "2", healthy, meta_v1.ConditionTrue,
+			reasonClusterReady},
+		{crv1.PgclusterStateInitialized, false, nil, "3", healthy, meta_v1.ConditionFalse,
+			reasonReplicasNotReady},
+		{crv1.PgclusterStateInitialized, false, nil, "",
+			observedState{primary: "hippo", primaryReady: true, replicas: 2, readyReplicas: 1},
+			meta_v1.ConditionFalse, reasonReplicasNotReady},
+		{crv1.PgclusterStateInitialized, false, nil, "", observedState{primary: "hippo"},
+			meta_v1.ConditionFalse, reasonPrimaryNotReady},
+		{crv1.PgclusterStateInitialized, false, nil, "", observedState{},
+			meta_v1.ConditionFalse, reasonNoPrimary},
+		{crv1.PgclusterStateProcessed, false, nil, "", healthy, meta_v1.ConditionFalse,
+			reasonNotInitialized},
+		{crv1.PgclusterStateRestore, false, nil, "", healthy, meta_v1.ConditionFalse,
+			reasonRestoring},
+		{crv1.PgclusterStateMajorUpgrade, false, nil, "", healthy, meta_v1.ConditionFalse,
+			reasonUpgrading},
+		{crv1.PgclusterStateInitialized, false,
+			map[string]string{config.LABEL_MINOR_UPGRADE: config.LABEL_UPGRADE_IN_PROGRESS}, "",
+			healthy, meta_v1.ConditionFalse, reasonUpgrading},
+		{crv1.PgclusterStateInitialized, true, nil, "", observedState{},
+			meta_v1.ConditionFalse, reasonShutdown},
+		{crv1.PgclusterStateShutdown, false, nil, "", observedState{},
+			meta_v1.ConditionFalse, reasonShutdown},
+	}
+
+	for i, test := range tests {
+		cluster := &crv1.Pgcluster{
+			Spec: crv1.PgclusterSpec{
+				Replicas:   test.replicas,
+				Shutdown:   test.shutdown,
+				UserLabels: test.userLabels,
+			},
+			Status: crv1.PgclusterStatus{
+				State:          test.state,
+				LastBackupTime: &lastBackup,
+			},
+		}
+
+		status := computeStatus(cluster, test.observed, 24*time.Hour, now)
+
+		ready := status.GetCondition(crv1.PgclusterConditionReady)
+		if ready == nil {
+			t.Fatalf("tests[%d] - expected a Ready condition", i)
+		}
+
+		if ready.Status != test.ready || ready.Reason != test.reason {
+			t.Fatalf("tests[%d] - expected Ready %s (%s), got %s (%s)", i, test.ready, test.reason,
+				ready.Status, ready.Reason)
+		}
+
+		if len(status.Conditions) != 6 {
+			t.Fatalf("tests[%d] - expected 6 conditions, got %d", i, len(status.Conditions))
+		}
+	}
+}
+
+func TestComputeStatusObservedState(t *testing.T) {
+	cluster := &crv1.Pgcluster{
+		Status: crv1.PgclusterStatus{
+			State:   crv1.PgclusterStateInitialized,
+			Message: "Cluster has been initialized",
+		},
+	}
+	observed := observedState{
+		primary:         "hippo-abcd",
+		primaryReady:    true,
+		replicas:        1,
+		readyReplicas:   1,
+		pgBouncerReady:  true,
+		postgresVersion: "12.3",
+	}
+
+	status := computeStatus(cluster, observed, time.Hour, meta_v1.Now())
+
+	if status.Primary != "hippo-abcd" || status.Replicas != 1 || status.ReadyReplicas != 1 ||
+		!status.PgBouncerReady || status.PostgresVersion != "12.3" {
+		t.Fatalf("observed state not recorded in status: %+v", status)
+	}
+
+	if status.State != cluster.Status.State || status.Message != cluster.Status.Message {
+		t.Fatalf("expected state and message to be kept, got %q and %q", status.State,
+			status.Message)
+	}
+
+	if cluster.Status.Conditions != nil {
+		t.Fatalf("expected the status of the pgcluster to be left alone")
+	}
+}
+
+func TestComputeStatusKeepsTransitionTime(t *testing.T) {
+	before := meta_v1.NewTime(time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC))
+	now := meta_v1.NewTime(before.Add(time.Hour))
+
+	cluster := &crv1.Pgcluster{
+		Status: crv1.PgclusterStatus{
+			State: crv1.PgclusterStateInitialized,
+			Conditions: []crv1.PgclusterCondition{
+				{Type: crv1.PgclusterConditionPrimaryAvailable, Status: meta_v1.ConditionTrue,
+					LastTransitionTime: before},
+				{Type: crv1.PgclusterConditionReplicasReady, Status: meta_v1.ConditionTrue,
+					LastTransitionTime: before},
+			},
+		},
+	}
+
+	status := computeStatus(cluster, observedState{primary: "hippo"}, time.Hour, now)
+
+	primary := status.GetCondition(crv1.PgclusterConditionPrimaryAvailable)
+	if primary.Status != meta_v1.ConditionFalse || !primary.LastTransitionTime.Equal(&now) {
+		t.Fatalf("expected PrimaryAvailable to transition to False at %s, got %s at %s",
+			now, primary.Status, primary.LastTransitionTime)
+	}
+
+	replicas := status.GetCondition(crv1.PgclusterConditionReplicasReady)
+	if replicas.Status != meta_v1.ConditionTrue || !replicas.LastTransitionTime.Equal(&before) {
+		t.Fatalf("expected ReplicasReady to stay True since %s, got %s since %s",
+			before, replicas.Status, replicas.LastTransitionTime)
+	}
+}
+
+func TestComputeStatusBackupsHealthy(t *testing.T) {
+	now := meta_v1.NewTime(time.Date(2020, 6, 2, 0, 0, 0, 0, time.UTC))
+	recent := meta_v1.NewTime(now.Add(-time.Hour))
+	old := meta_v1.NewTime(now.Add(-48 * time.Hour))
+	failedBefore := meta_v1.NewTime(now.Add(-2 * time.Hour))
+	failedAfter := meta_v1.NewTime(now.Add(-30 * time.Minute))
+
+	failed := func(at meta_v1.Time) []crv1.PgclusterCondition {
+		return []crv1.PgclusterCondition{{Type: crv1.PgclusterConditionBackupsHealthy,
+			Status: meta_v1.ConditionFalse, LastTransitionTime: at, Reason: reasonBackupFailed}}
+	}
+
+	tests := []struct {
+		lastBackupTime *meta_v1.Time
+		conditions     []crv1.PgclusterCondition
+		status         meta_v1.ConditionStatus
+		reason         string
+	}{
+		{nil, nil, meta_v1.ConditionFalse, reasonNoBackup},
+		{&recent, nil, meta_v1.ConditionTrue, reasonBackupCompleted},
+		{&old, nil, meta_v1.ConditionFalse, reasonRPOExceeded},
+		{nil, failed(failedBefore), meta_v1.ConditionFalse, reasonBackupFailed},
+		{&recent, failed(failedBefore), meta_v1.ConditionTrue, reasonBackupCompleted},
+		{&recent, failed(failedAfter), meta_v1.ConditionFalse, reasonBackupFailed},
+	}
+
+	for i, test := range tests {
+		cluster := &crv1.Pgcluster{
+			Status: crv1.PgclusterStatus{
+				State:          crv1.PgclusterStateInitialized,
+				LastBackupTime: test.lastBackupTime,
+				Conditions:     test.conditions,
+			},
+		}
+
+		status := computeStatus(cluster, observedState{}, 24*time.Hour, now)
+
+		backups := status.GetCondition(crv1.PgclusterConditionBackupsHealthy)
+		if backups.Status != test.status || backups.Reason != test.reason {
+			t.Fatalf("tests[%d] - expected BackupsHealthy %s (%s), got %s (%s)", i, test.status,
+				test.reason, backups.Status, backups.Reason)
+		}
+	}
+}
+
+func TestGetImageTag(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected string
+	}{
+		{"crunchydata/crunchy-postgres-ha:centos7-12.3-4.4.0", "centos7-12.3-4.4.0"},
+		{"registry:5000/crunchydata/crunchy-postgres-ha:centos7-12.3-4.4.0", "centos7-12.3-4.4.0"},
+		{"registry:5000/crunchydata/crunchy-postgres-ha", ""},
+		{"crunchy-postgres-ha", ""},
+		{"", ""},
+	}
+
+	for i, test := range tests {
+		if tag := getImageTag(test.image); tag != test.expected {
+			t.Fatalf("tests[%d] - expected tag %q, got %q", i, test.expected, tag)
+		}
+	}
+}
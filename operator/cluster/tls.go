@@ -0,0 +1,566 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	"github.com/crunchydata/postgres-operator/tlsutil"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// tlsCASecretName is the name of the Secret that holds the CA that the
+	// Operator issues the certificates of the clusters in a namespace from. Its
+	// "ca.crt" holds the certificate of the current CA, followed by the ones of
+	// previous CAs that are still valid, and its "ca.key" the private key of the
+	// current CA
+	tlsCASecretName = "pgo-tls-ca"
+	// tlsCACertKey and tlsCAKeyKey are the keys of the CA Secrets
+	tlsCACertKey = "ca.crt"
+	tlsCAKeyKey  = "ca.key"
+	// tlsCertificateDuration is how long the certificates that the Operator
+	// issues for clusters are valid
+	tlsCertificateDuration = 90 * 24 * time.Hour
+	// tlsRenewBefore is how long before their expiration the certificates of
+	// the clusters are renewed. A CA is renewed once it can no longer issue a
+	// certificate that is valid for its full duration
+	tlsRenewBefore = 30 * 24 * time.Hour
+	// tlsClusterDomain is the default domain of a Kubernetes cluster, which is
+	// part of the fully qualified names of the Services
+	tlsClusterDomain = "cluster.local"
+)
+
+// EnsureClusterTLS issues the certificate of a cluster whose certificates are
+// managed by the Operator, and renews it once it is about to expire or no
+// longer matches the Services of the cluster. The CA of the namespace is
+// created, or renewed, first. The client certificates of the users that
+// authenticate with one are issued and renewed as well, see EnsureUserTLS. It
+// returns true if a new certificate was issued for the cluster
+func EnsureClusterTLS(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster) (bool, error) {
+	now := time.Now()
+
+	caCert, caKey, bundle, err := getTLSCA(clientset, cluster.Namespace, now)
+	if err != nil {
+		return false, err
+	}
+
+	for _, username := range getCertAuthUsers(cluster) {
+		if err := ensureUserTLSSecret(clientset, cluster, username, caCert, caKey, bundle, now); err != nil {
+			return false, err
+		}
+	}
+
+	// the CA secret of the cluster holds the certificates of the CA, but not its
+	// private key, as it is mounted into the PostgreSQL pods
+	if err := ensureTLSSecret(clientset, cluster, cluster.Spec.TLS.CASecret, v1.SecretTypeOpaque,
+		map[string][]byte{tlsCACertKey: bundle}); err != nil {
+		return false, err
+	}
+
+	secret, found, err := kubeapi.GetSecret(clientset, cluster.Spec.TLS.TLSSecret, cluster.Namespace)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return false, err
+	}
+
+	dnsNames := getTLSDNSNames(cluster)
+
+	if found && !needsTLSCertificate(secret.Data[v1.TLSCertKey], secret.Data[v1.TLSPrivateKeyKey],
+		bundle, dnsNames, now) {
+		return false, nil
+	}
+
+	key, err := tlsutil.NewPrivateKey()
+	if err != nil {
+		return false, err
+	}
+
+	cert, err := tlsutil.NewSignedServerCertificate(key, caCert, caKey, cluster.Name, dnsNames,
+		tlsCertificateDuration)
+	if err != nil {
+		return false, err
+	}
+
+	log.Infof("issuing a TLS certificate for cluster %s that expires at %s", cluster.Name,
+		cert.NotAfter.Format(time.RFC3339))
+
+	if err := ensureTLSSecret(clientset, cluster, cluster.Spec.TLS.TLSSecret, v1.SecretTypeTLS,
+		map[string][]byte{
+			v1.TLSCertKey:       tlsutil.EncodeCertificatePEM(cert),
+			v1.TLSPrivateKeyKey: tlsutil.EncodePrivateKeyPEM(key),
+		}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// EnsureUserTLS issues the client certificate of a PostgreSQL user of a
+// cluster whose certificates are managed by the Operator, or renews it once it
+// is about to expire. The certificate is issued for the name of the user,
+// which PostgreSQL matches when the user authenticates with it, and is stored
+// in a Secret along with its private key and the certificates of the CA, see
+// crv1.UserTLSSecretFormat
+func EnsureUserTLS(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster, username string) error {
+	now := time.Now()
+
+	caCert, caKey, bundle, err := getTLSCA(clientset, cluster.Namespace, now)
+	if err != nil {
+		return err
+	}
+
+	return ensureUserTLSSecret(clientset, cluster, username, caCert, caKey, bundle, now)
+}
+
+// RotateClusterTLS renews the certificate of a cluster whose certificates are
+// managed by the Operator when needed, see EnsureClusterTLS, and reloads the
+// PostgreSQL instances of a running cluster that do not use the current
+// certificate yet
+func RotateClusterTLS(clientset *kubernetes.Clientset, restconfig *rest.Config, cluster *crv1.Pgcluster) error {
+	if !cluster.Spec.TLS.Auto || cluster.DeletionTimestamp != nil {
+		return nil
+	}
+
+	if _, err := EnsureClusterTLS(clientset, cluster); err != nil {
+		return err
+	}
+
+	if cluster.Spec.Shutdown || cluster.Status.State != crv1.PgclusterStateInitialized {
+		return nil
+	}
+
+	return reloadClusterTLS(clientset, restconfig, cluster)
+}
+
+// getTLSCA returns the current CA of a namespace along with the bundle of the
+// certificates of the CAs that are still valid, see ensureTLSCA
+func getTLSCA(clientset *kubernetes.Clientset, namespace string, now time.Time) (*x509.Certificate,
+	*rsa.PrivateKey, []byte, error) {
+	caCert, caKey, bundle, err := ensureTLSCA(clientset, namespace, now)
+	// the CA may have been created or renewed for another cluster at the same
+	// time, in which case that one is used
+	if kerrors.IsAlreadyExists(err) || kerrors.IsConflict(err) {
+		caCert, caKey, bundle, err = ensureTLSCA(clientset, namespace, now)
+	}
+
+	return caCert, caKey, bundle, err
+}
+
+// ensureTLSCA returns the current CA of a namespace along with the bundle of
+// the certificates of the CAs that are still valid. A CA is created if there
+// is none, and a new one is created if the current one is about to expire
+func ensureTLSCA(clientset *kubernetes.Clientset, namespace string, now time.Time) (*x509.Certificate,
+	*rsa.PrivateKey, []byte, error) {
+	secret, found, err := kubeapi.GetSecret(clientset, tlsCASecretName, namespace)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return nil, nil, nil, err
+	}
+
+	certs := []*x509.Certificate{}
+	var key *rsa.PrivateKey
+
+	if found {
+		certs, _ = tlsutil.ParsePEMEncodedCerts(secret.Data[tlsCACertKey])
+		key, _ = tlsutil.ParsePEMEncodedPrivateKey(secret.Data[tlsCAKeyKey])
+
+		if isTLSCAValid(certs, key, now) {
+			return certs[0], key, secret.Data[tlsCACertKey], nil
+		}
+	}
+
+	if key, err = tlsutil.NewPrivateKey(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	cert, err := tlsutil.NewSelfSignedCACertificate(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	log.Infof("creating a TLS CA in namespace %s that expires at %s", namespace,
+		cert.NotAfter.Format(time.RFC3339))
+
+	// the previous CAs are trusted until they expire, as they issued the
+	// certificates of clusters that have not been renewed yet
+	bundle := tlsutil.EncodeCertificatePEM(cert)
+	for _, previous := range certs {
+		if now.Before(previous.NotAfter) {
+			bundle = append(bundle, tlsutil.EncodeCertificatePEM(previous)...)
+		}
+	}
+
+	data := map[string][]byte{
+		tlsCACertKey: bundle,
+		tlsCAKeyKey:  tlsutil.EncodePrivateKeyPEM(key),
+	}
+
+	if found {
+		secret.Data = data
+		err = kubeapi.UpdateSecret(clientset, secret, namespace)
+	} else {
+		err = kubeapi.CreateSecret(clientset, newTLSCASecret(data), namespace)
+	}
+
+	return cert, key, bundle, err
+}
+
+// ensureTLSSecret creates a secret of a cluster with the data provided, or
+// updates it if it already exists and has different data
+func ensureTLSSecret(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster, name string,
+	secretType v1.SecretType, data map[string][]byte) error {
+	secret, found, err := kubeapi.GetSecret(clientset, name, cluster.Namespace)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+
+	if !found {
+		return kubeapi.CreateSecret(clientset, newTLSSecret(cluster, name, secretType, data),
+			cluster.Namespace)
+	}
+
+	// any other keys, e.g. a "ca.crl" that was added to the CA secret, are kept
+	changed := false
+	for key, value := range data {
+		if !bytes.Equal(secret.Data[key], value) {
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{}
+			}
+			secret.Data[key] = value
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return kubeapi.UpdateSecret(clientset, secret, cluster.Namespace)
+}
+
+// ensureUserTLSSecret issues the client certificate of a PostgreSQL user from
+// the CA provided if the user does not have a current one yet
+func ensureUserTLSSecret(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster, username string,
+	caCert *x509.Certificate, caKey *rsa.PrivateKey, bundle []byte, now time.Time) error {
+	name := fmt.Sprintf(crv1.UserTLSSecretFormat, cluster.Name, username)
+
+	secret, found, err := kubeapi.GetSecret(clientset, name, cluster.Namespace)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+
+	if found && !needsTLSClientCertificate(secret.Data[v1.TLSCertKey], secret.Data[v1.TLSPrivateKeyKey],
+		bundle, username, now) {
+		// the CA certificates are kept current so that the application can
+		// verify the certificate of the cluster with them
+		return ensureTLSSecret(clientset, cluster, name, v1.SecretTypeTLS,
+			map[string][]byte{tlsCACertKey: bundle})
+	}
+
+	key, err := tlsutil.NewPrivateKey()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tlsutil.NewSignedClientCertificate(key, caCert, caKey, username, tlsCertificateDuration)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("issuing a TLS client certificate for user %s of cluster %s that expires at %s", username,
+		cluster.Name, cert.NotAfter.Format(time.RFC3339))
+
+	return ensureTLSSecret(clientset, cluster, name, v1.SecretTypeTLS, map[string][]byte{
+		v1.TLSCertKey:       tlsutil.EncodeCertificatePEM(cert),
+		v1.TLSPrivateKeyKey: tlsutil.EncodePrivateKeyPEM(key),
+		tlsCACertKey:        bundle,
+	})
+}
+
+// newTLSCASecret returns the Secret of the CA of a namespace with the data
+// provided
+func newTLSCASecret(data map[string][]byte) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:   tlsCASecretName,
+			Labels: map[string]string{config.LABEL_VENDOR: config.LABEL_CRUNCHY},
+		},
+		Type: v1.SecretTypeOpaque,
+		Data: data,
+	}
+}
+
+// newTLSSecret returns a secret of a cluster with the data provided
+func newTLSSecret(cluster *crv1.Pgcluster, name string, secretType v1.SecretType,
+	data map[string][]byte) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				config.LABEL_PG_CLUSTER: cluster.Name,
+				config.LABEL_VENDOR:     config.LABEL_CRUNCHY,
+			},
+		},
+		Type: secretType,
+		Data: data,
+	}
+}
+
+// renderClusterTLS returns the secrets that EnsureClusterTLS creates for a new
+// cluster whose certificates are managed by the Operator, i.e. the CA of the
+// namespace, the client certificates of the users, the certificates of the CA
+// and the certificate of the cluster, in that order. No certificate is issued:
+// the secrets hold the keys that EnsureClusterTLS sets, without any values
+func renderClusterTLS(cluster *crv1.Pgcluster) []runtime.Object {
+	objects := []runtime.Object{
+		newTLSCASecret(map[string][]byte{tlsCACertKey: nil, tlsCAKeyKey: nil}),
+	}
+
+	for _, username := range getCertAuthUsers(cluster) {
+		objects = append(objects, newTLSSecret(cluster, fmt.Sprintf(crv1.UserTLSSecretFormat,
+			cluster.Name, username), v1.SecretTypeTLS, map[string][]byte{
+			v1.TLSCertKey:       nil,
+			v1.TLSPrivateKeyKey: nil,
+			tlsCACertKey:        nil,
+		}))
+	}
+
+	return append(objects,
+		newTLSSecret(cluster, cluster.Spec.TLS.CASecret, v1.SecretTypeOpaque,
+			map[string][]byte{tlsCACertKey: nil}),
+		newTLSSecret(cluster, cluster.Spec.TLS.TLSSecret, v1.SecretTypeTLS,
+			map[string][]byte{v1.TLSCertKey: nil, v1.TLSPrivateKeyKey: nil}))
+}
+
+// getCertAuthUsers returns the PostgreSQL users of a cluster that authenticate
+// with a client certificate. When every user of the cluster does, these are
+// the users that the Operator creates along with the ones that were added
+// later on
+func getCertAuthUsers(cluster *crv1.Pgcluster) []string {
+	candidates := []string{}
+	if cluster.Spec.TLS.CertAuth {
+		candidates = append(candidates, crv1.PGUserReplication, crv1.PGUserSuperuser, cluster.Spec.User)
+	}
+	candidates = append(candidates, cluster.Spec.TLS.CertAuthUsers...)
+
+	users := []string{}
+	seen := map[string]bool{}
+	for _, username := range candidates {
+		if username == "" || seen[username] || !cluster.Spec.TLS.IsCertAuthUser(username) {
+			continue
+		}
+
+		seen[username] = true
+		users = append(users, username)
+	}
+
+	return users
+}
+
+// isTLSCAValid returns true if the first of the certificates is the one of the
+// private key of a CA, and it can still issue a certificate that is valid for
+// its full duration and is renewed before the CA expires
+func isTLSCAValid(certs []*x509.Certificate, key *rsa.PrivateKey, now time.Time) bool {
+	if len(certs) == 0 || key == nil {
+		return false
+	}
+
+	publicKey, ok := certs[0].PublicKey.(*rsa.PublicKey)
+	if !ok || publicKey.N.Cmp(key.N) != 0 || publicKey.E != key.E {
+		return false
+	}
+
+	return now.Add(tlsCertificateDuration + tlsRenewBefore).Before(certs[0].NotAfter)
+}
+
+// needsTLSCertificate returns true if a certificate and its private key are
+// missing or do not match, if the certificate is not issued by one of the CAs
+// in the bundle for all of the DNS names, or if it is about to expire
+func needsTLSCertificate(certPEM, keyPEM, bundle []byte, dnsNames []string, now time.Time) bool {
+	cert, roots, ok := parseTLSKeyPair(certPEM, keyPEM, bundle, now)
+	if !ok {
+		return true
+	}
+
+	for _, dnsName := range dnsNames {
+		if _, err := cert.Verify(x509.VerifyOptions{
+			DNSName:     dnsName,
+			Roots:       roots,
+			CurrentTime: now,
+		}); err != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// needsTLSClientCertificate returns true if a client certificate and its
+// private key are missing or do not match, if the certificate is not issued by
+// one of the CAs in the bundle for the user, or if it is about to expire
+func needsTLSClientCertificate(certPEM, keyPEM, bundle []byte, username string, now time.Time) bool {
+	cert, roots, ok := parseTLSKeyPair(certPEM, keyPEM, bundle, now)
+	if !ok || cert.Subject.CommonName != username {
+		return true
+	}
+
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:       roots,
+		CurrentTime: now,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	return err != nil
+}
+
+// parseTLSKeyPair parses a certificate and its private key along with the
+// bundle of the CAs that the certificate should be issued by. It returns
+// false if any of them cannot be parsed, if the certificate does not match the
+// private key or if it is about to expire
+func parseTLSKeyPair(certPEM, keyPEM, bundle []byte, now time.Time) (*x509.Certificate, *x509.CertPool, bool) {
+	cert, err := tlsutil.ParsePEMEncodedCert(certPEM)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	key, err := tlsutil.ParsePEMEncodedPrivateKey(keyPEM)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	if publicKey, ok := cert.PublicKey.(*rsa.PublicKey); !ok || publicKey.N.Cmp(key.N) != 0 {
+		return nil, nil, false
+	}
+
+	if !now.Add(tlsRenewBefore).Before(cert.NotAfter) {
+		return nil, nil, false
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(bundle) {
+		return nil, nil, false
+	}
+
+	return cert, roots, true
+}
+
+// getTLSDNSNames returns the names that the certificate of a cluster is issued
+// for, which are the names of its primary, replica, pgBouncer and read-only
+// pgBouncer Services
+func getTLSDNSNames(cluster *crv1.Pgcluster) []string {
+	dnsNames := []string{}
+
+	for _, service := range []string{
+		cluster.Name,
+		cluster.Name + ReplicaSuffix,
+		fmt.Sprintf(pgBouncerDeploymentFormat, cluster.Name),
+		fmt.Sprintf(pgBouncerReadOnlyDeploymentFormat, cluster.Name),
+	} {
+		dnsNames = append(dnsNames,
+			service,
+			service+"."+cluster.Namespace,
+			service+"."+cluster.Namespace+".svc",
+			service+"."+cluster.Namespace+".svc."+tlsClusterDomain,
+		)
+	}
+
+	return dnsNames
+}
+
+// reloadClusterTLS reloads PostgreSQL on the instances of a cluster that have
+// not been reloaded since their certificate changed, replicas before the
+// primary. An instance is only reloaded once the current certificate is
+// mounted into its pod, which happens some time after the secret is updated.
+// The serial number of the certificate an instance was last reloaded with is
+// recorded in the "pgo-tls-certificate" annotation of its pod
+func reloadClusterTLS(clientset *kubernetes.Clientset, restconfig *rest.Config, cluster *crv1.Pgcluster) error {
+	secret, found, err := kubeapi.GetSecret(clientset, cluster.Spec.TLS.TLSSecret, cluster.Namespace)
+	if !found {
+		return err
+	}
+
+	cert, err := tlsutil.ParsePEMEncodedCert(secret.Data[v1.TLSCertKey])
+	if err != nil {
+		return err
+	}
+	serial := cert.SerialNumber.String()
+
+	selector := fmt.Sprintf("%s=%s,%s=true", config.LABEL_PG_CLUSTER, cluster.Name,
+		config.LABEL_PG_DATABASE)
+	pods, err := kubeapi.GetPods(clientset, selector, cluster.Namespace)
+	if err != nil {
+		return err
+	}
+
+	replicas, primaries := []v1.Pod{}, []v1.Pod{}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil || !isDatabaseContainerReady(&pod) ||
+			pod.Annotations[config.ANNOTATION_TLS_CERTIFICATE] == serial {
+			continue
+		}
+
+		if pod.Labels[config.LABEL_PGHA_ROLE] == "master" {
+			primaries = append(primaries, pod)
+		} else {
+			replicas = append(replicas, pod)
+		}
+	}
+
+	for _, pod := range append(replicas, primaries...) {
+		// the certificate is compared to the mounted one, as it is already
+		// up-to-date in a pod that was started after it was issued
+		stdout, stderr, err := kubeapi.ExecToPodThroughAPI(restconfig, clientset,
+			[]string{"cat", "/pgconf/tls/" + v1.TLSCertKey}, "database", pod.Name, pod.Namespace, nil)
+		if err != nil {
+			log.Error(stderr)
+			return err
+		}
+
+		if strings.TrimSpace(stdout) != strings.TrimSpace(string(secret.Data[v1.TLSCertKey])) {
+			log.Debugf("tls: certificate of cluster %s is not mounted in pod %s yet", cluster.Name, pod.Name)
+			continue
+		}
+
+		log.Infof("tls: reloading pod %s of cluster %s to use its new certificate", pod.Name, cluster.Name)
+
+		if _, stderr, err := kubeapi.ExecToPodThroughAPI(restconfig, clientset,
+			[]string{"psql", "-c", "SELECT pg_reload_conf()"}, "database", pod.Name, pod.Namespace,
+			nil); err != nil {
+			log.Error(stderr)
+			return err
+		}
+
+		if err := kubeapi.AddAnnotationToPod(clientset, &pod, config.ANNOTATION_TLS_CERTIFICATE, serial,
+			pod.Namespace); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
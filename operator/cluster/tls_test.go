@@ -0,0 +1,287 @@
+package cluster
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/tlsutil"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNeedsTLSCertificate(t *testing.T) {
+	caKey, err := tlsutil.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caCert, err := tlsutil.NewSelfSignedCACertificate(caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bundle := tlsutil.EncodeCertificatePEM(caCert)
+	now := time.Now()
+
+	if !isTLSCAValid([]*x509.Certificate{caCert}, caKey, now) {
+		t.Fatal("expected a new CA to be valid")
+	}
+
+	if isTLSCAValid([]*x509.Certificate{caCert}, caKey, caCert.NotAfter.Add(-tlsCertificateDuration)) {
+		t.Fatal("expected a CA that is about to expire to not be valid")
+	}
+
+	cluster := &crv1.Pgcluster{ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo"}}
+	dnsNames := getTLSDNSNames(cluster)
+
+	key, err := tlsutil.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := tlsutil.NewSignedServerCertificate(key, caCert, caKey, cluster.Name, dnsNames,
+		tlsCertificateDuration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// certificates are checked after they are issued
+	now = time.Now()
+	certPEM := tlsutil.EncodeCertificatePEM(cert)
+	keyPEM := tlsutil.EncodePrivateKeyPEM(key)
+
+	otherKey, err := tlsutil.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherCA, err := tlsutil.NewSelfSignedCACertificate(otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		description string
+		certPEM     []byte
+		keyPEM      []byte
+		bundle      []byte
+		dnsNames    []string
+		now         time.Time
+		expected    bool
+	}{
+		{"valid", certPEM, keyPEM, bundle, dnsNames, now, false},
+		{"missing", nil, nil, bundle, dnsNames, now, true},
+		{"other key", certPEM, tlsutil.EncodePrivateKeyPEM(otherKey), bundle, dnsNames, now, true},
+		{"other CA", certPEM, keyPEM, tlsutil.EncodeCertificatePEM(otherCA), dnsNames, now, true},
+		{"CA in bundle", certPEM, keyPEM, append(tlsutil.EncodeCertificatePEM(otherCA), bundle...),
+			dnsNames, now, false},
+		{"other names", certPEM, keyPEM, bundle, []string{"rhino.pgo.svc"}, now, true},
+		{"about to expire", certPEM, keyPEM, bundle, dnsNames, cert.NotAfter.Add(-tlsRenewBefore), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if needs := needsTLSCertificate(test.certPEM, test.keyPEM, test.bundle, test.dnsNames,
+				test.now); needs != test.expected {
+				t.Fatalf("expected %t, got %t", test.expected, needs)
+			}
+		})
+	}
+}
+
+func TestGetTLSDNSNames(t *testing.T) {
+	cluster := &crv1.Pgcluster{ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo"}}
+	dnsNames := getTLSDNSNames(cluster)
+
+	for _, expected := range []string{
+		"hippo",
+		"hippo.pgo.svc",
+		"hippo-replica.pgo",
+		"hippo-pgbouncer.pgo.svc.cluster.local",
+		"hippo-pgbouncer-ro",
+		"hippo-pgbouncer-ro.pgo.svc",
+	} {
+		found := false
+		for _, dnsName := range dnsNames {
+			found = found || dnsName == expected
+		}
+
+		if !found {
+			t.Errorf("expected %q in %v", expected, dnsNames)
+		}
+	}
+}
+
+func TestNeedsTLSClientCertificate(t *testing.T) {
+	caKey, err := tlsutil.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caCert, err := tlsutil.NewSelfSignedCACertificate(caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := tlsutil.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := tlsutil.NewSignedClientCertificate(key, caCert, caKey, "testuser", tlsCertificateDuration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverCert, err := tlsutil.NewSignedServerCertificate(key, caCert, caKey, "testuser", []string{"hippo"},
+		tlsCertificateDuration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// certificates are checked after they are issued
+	now := time.Now()
+	bundle := tlsutil.EncodeCertificatePEM(caCert)
+	certPEM := tlsutil.EncodeCertificatePEM(cert)
+	keyPEM := tlsutil.EncodePrivateKeyPEM(key)
+
+	tests := []struct {
+		description string
+		certPEM     []byte
+		username    string
+		now         time.Time
+		expected    bool
+	}{
+		{"valid", certPEM, "testuser", now, false},
+		{"missing", nil, "testuser", now, true},
+		{"other user", certPEM, "rhino", now, true},
+		{"server certificate", tlsutil.EncodeCertificatePEM(serverCert), "testuser", now, true},
+		{"about to expire", certPEM, "testuser", cert.NotAfter.Add(-tlsRenewBefore), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if needs := needsTLSClientCertificate(test.certPEM, keyPEM, bundle, test.username,
+				test.now); needs != test.expected {
+				t.Fatalf("expected %t, got %t", test.expected, needs)
+			}
+		})
+	}
+}
+
+func TestGetCertAuthUsers(t *testing.T) {
+	cluster := &crv1.Pgcluster{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo"},
+		Spec: crv1.PgclusterSpec{
+			User: "testuser",
+			TLS: crv1.TLSSpec{
+				CertAuthUsers: []string{"app", crv1.PGUserPgBouncer, "app", crv1.PGUserReplication},
+			},
+		},
+	}
+
+	t.Run("users", func(t *testing.T) {
+		users := getCertAuthUsers(cluster)
+
+		if len(users) != 1 || users[0] != "app" {
+			t.Fatalf("expected [app], got %v", users)
+		}
+	})
+
+	t.Run("cluster", func(t *testing.T) {
+		cluster.Spec.TLS.CertAuth = true
+		users := getCertAuthUsers(cluster)
+		expected := []string{crv1.PGUserReplication, crv1.PGUserSuperuser, "testuser", "app"}
+
+		if strings.Join(users, ",") != strings.Join(expected, ",") {
+			t.Fatalf("expected %v, got %v", expected, users)
+		}
+	})
+}
+
+func TestRenderClusterTLS(t *testing.T) {
+	tests := []struct {
+		description string
+		certAuth    bool
+		expected    []string
+	}{
+		{
+			description: "server",
+			expected: []string{
+				"pgo-tls-ca Opaque ca.crt,ca.key",
+				"hippo-tls-ca Opaque ca.crt",
+				"hippo-tls kubernetes.io/tls tls.crt,tls.key",
+			},
+		},
+		{
+			description: "client",
+			certAuth:    true,
+			expected: []string{
+				"pgo-tls-ca Opaque ca.crt,ca.key",
+				"hippo-primaryuser-client-tls kubernetes.io/tls ca.crt,tls.crt,tls.key",
+				"hippo-postgres-client-tls kubernetes.io/tls ca.crt,tls.crt,tls.key",
+				"hippo-testuser-client-tls kubernetes.io/tls ca.crt,tls.crt,tls.key",
+				"hippo-tls-ca Opaque ca.crt",
+				"hippo-tls kubernetes.io/tls tls.crt,tls.key",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			cluster := &crv1.Pgcluster{
+				ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo"},
+				Spec: crv1.PgclusterSpec{
+					User: "testuser",
+					TLS: crv1.TLSSpec{
+						Auto:      true,
+						CertAuth:  test.certAuth,
+						CASecret:  "hippo" + crv1.TLSCASecretSuffix,
+						TLSSecret: "hippo" + crv1.TLSSecretSuffix,
+					},
+				},
+			}
+
+			secrets := []string{}
+			for _, object := range renderClusterTLS(cluster) {
+				secret := object.(*v1.Secret)
+
+				keys := []string{}
+				for key, value := range secret.Data {
+					if len(value) != 0 {
+						t.Fatalf("expected no value for %s in %s", key, secret.Name)
+					}
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+
+				secrets = append(secrets, fmt.Sprintf("%s %s %s", secret.Name, secret.Type,
+					strings.Join(keys, ",")))
+			}
+
+			if strings.Join(secrets, "\n") != strings.Join(test.expected, "\n") {
+				t.Fatalf("expected %v, got %v", test.expected, secrets)
+			}
+		})
+	}
+}
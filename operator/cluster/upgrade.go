@@ -298,6 +298,20 @@ func UpgradeWithAutofailDisabled(clientset *kubernetes.Clientset, restclient *re
 	return
 }
 
+// getDatabaseImage returns the image of the "database" container of a
+// cluster for the image tag provided, taking any image overrides into account
+func getDatabaseImage(cluster crv1.Pgcluster, ccpImagePrefix, ccpImageTag string) string {
+	// see if the image name is overridden
+	if strings.Contains(cluster.Spec.CCPImage, "gis-ha") &&
+		operator.ContainerImageOverrides[config.CONTAINER_IMAGE_CRUNCHY_POSTGRES_GIS_HA] != "" {
+		return operator.ContainerImageOverrides[config.CONTAINER_IMAGE_CRUNCHY_POSTGRES_GIS_HA]
+	} else if operator.ContainerImageOverrides[config.CONTAINER_IMAGE_CRUNCHY_POSTGRES_HA] != "" {
+		return operator.ContainerImageOverrides[config.CONTAINER_IMAGE_CRUNCHY_POSTGRES_HA]
+	}
+
+	return ccpImagePrefix + "/" + cluster.Spec.CCPImage + ":" + ccpImageTag
+}
+
 // createImageNamePatch creates and returns a string containing the JSON structure needed to
 // patch a Deployment specification in order update the image name for the database container, as
 // well as any sidecar containers (e.g. collect, pgbadger and/or crunchyadm) that are enabled
@@ -332,15 +346,7 @@ func createImageNamePatch(cluster crv1.Pgcluster, ccpImagePrefix, ccpImageTag st
 	// add the database container, which will always be patched
 	databaseContainer := patchDeploymentContainers{
 		Name:  "database",
-		Image: ccpImagePrefix + "/" + cluster.Spec.CCPImage + ":" + ccpImageTag,
-	}
-
-	// see if the image name is overridden
-	if strings.Contains(cluster.Spec.CCPImage, "gis-ha") &&
-		operator.ContainerImageOverrides[config.CONTAINER_IMAGE_CRUNCHY_POSTGRES_GIS_HA] != "" {
-		databaseContainer.Image = operator.ContainerImageOverrides[config.CONTAINER_IMAGE_CRUNCHY_POSTGRES_GIS_HA]
-	} else if operator.ContainerImageOverrides[config.CONTAINER_IMAGE_CRUNCHY_POSTGRES_HA] != "" {
-		databaseContainer.Image = operator.ContainerImageOverrides[config.CONTAINER_IMAGE_CRUNCHY_POSTGRES_HA]
+		Image: getDatabaseImage(cluster, ccpImagePrefix, ccpImageTag),
 	}
 
 	containersToPatch = append(containersToPatch, databaseContainer)
@@ -39,6 +39,10 @@ import (
 const AffinityInOperator = "In"
 const AFFINITY_NOTINOperator = "NotIn"
 
+// ZoneTopologyKey is the label of the nodes that the PostgreSQL instances of a
+// cluster are spread across
+const ZoneTopologyKey = "topology.kubernetes.io/zone"
+
 // PGHAConfigMapSuffix defines the suffix for the name of the PGHA configMap created for each PG
 // cluster
 const PGHAConfigMapSuffix = "pgha-config"
@@ -101,13 +105,15 @@ type badgerTemplateFields struct {
 }
 
 type PgbackrestEnvVarsTemplateFields struct {
-	PgbackrestStanza            string
-	PgbackrestDBPath            string
-	PgbackrestRepo1Path         string
-	PgbackrestRepo1Host         string
-	PgbackrestRepo1Type         string
-	PgbackrestLocalAndS3Storage bool
-	PgbackrestPGPort            string
+	PgbackrestStanza               string
+	PgbackrestDBPath               string
+	PgbackrestRepo1Path            string
+	PgbackrestRepo1Host            string
+	PgbackrestRepo1Type            string
+	PgbackrestLocalAndS3Storage    bool
+	PgbackrestLocalAndGCSStorage   bool
+	PgbackrestLocalAndAzureStorage bool
+	PgbackrestPGPort               string
 }
 
 type PgbackrestS3EnvVarsTemplateFields struct {
@@ -119,6 +125,31 @@ type PgbackrestS3EnvVarsTemplateFields struct {
 	PgbackrestS3SecretName string
 }
 
+type PgbackrestGCSEnvVarsTemplateFields struct {
+	PgbackrestGCSBucket        string
+	PgbackrestGCSEndpoint      string
+	PgbackrestGCSKey           string
+	PgbackrestGCSKeyType       string
+	PgbackrestGCSSecretName    string
+	PgbackrestStorageVerifyTLS string
+}
+
+type PgbackrestAzureEnvVarsTemplateFields struct {
+	PgbackrestAzureAccount     string
+	PgbackrestAzureContainer   string
+	PgbackrestAzureEndpoint    string
+	PgbackrestAzureKey         string
+	PgbackrestAzureSecretName  string
+	PgbackrestAzureURIStyle    string
+	PgbackrestStorageVerifyTLS string
+}
+
+type PgbackrestCipherEnvVarsTemplateFields struct {
+	PgbackrestCipherType       string
+	PgbackrestCipherPass       string
+	PgbackrestCipherSecretName string
+}
+
 type PgmonitorEnvVarsTemplateFields struct {
 	PgmonitorPassword string
 }
@@ -126,35 +157,38 @@ type PgmonitorEnvVarsTemplateFields struct {
 // needs to be consolidated with cluster.DeploymentTemplateFields
 // DeploymentTemplateFields ...
 type DeploymentTemplateFields struct {
-	Name                string
-	ClusterName         string
-	Port                string
-	CCPImagePrefix      string
-	CCPImageTag         string
-	CCPImage            string
-	Database            string
-	DeploymentLabels    string
-	PodLabels           string
-	DataPathOverride    string
-	ArchiveMode         string
-	ArchivePVCName      string
-	XLOGDir             string
-	BackrestPVCName     string
-	PVCName             string
-	RootSecretName      string
-	UserSecretName      string
-	PrimarySecretName   string
-	SecurityContext     string
-	ContainerResources  string
-	NodeSelector        string
-	ConfVolume          string
-	CollectAddon        string
-	CollectVolume       string
-	BadgerAddon         string
-	PgbackrestEnvVars   string
-	PgbackrestS3EnvVars string
-	PgmonitorEnvVars    string
-	ScopeLabel          string
+	Name                    string
+	ClusterName             string
+	Port                    string
+	CCPImagePrefix          string
+	CCPImageTag             string
+	CCPImage                string
+	Database                string
+	DeploymentLabels        string
+	PodLabels               string
+	DataPathOverride        string
+	ArchiveMode             string
+	ArchivePVCName          string
+	XLOGDir                 string
+	BackrestPVCName         string
+	PVCName                 string
+	RootSecretName          string
+	UserSecretName          string
+	PrimarySecretName       string
+	SecurityContext         string
+	ContainerResources      string
+	NodeSelector            string
+	ConfVolume              string
+	CollectAddon            string
+	CollectVolume           string
+	BadgerAddon             string
+	PgbackrestEnvVars       string
+	PgbackrestS3EnvVars     string
+	PgbackrestGCSEnvVars    string
+	PgbackrestAzureEnvVars  string
+	PgbackrestCipherEnvVars string
+	PgmonitorEnvVars        string
+	ScopeLabel              string
 	//next 2 are for the replica deployment only
 	Replicas                 string
 	PrimaryHost              string
@@ -162,8 +196,18 @@ type DeploymentTemplateFields struct {
 	EnableCrunchyadm         bool
 	ReplicaReinitOnStartFail bool
 	PodAntiAffinity          string
-	SyncReplication          bool
-	Standby                  bool
+	// Tolerations, PriorityClassName and TopologySpreadConstraints set how
+	// the pod is scheduled. Tolerations and TopologySpreadConstraints are JSON
+	// including a trailing comma
+	Tolerations               string
+	PriorityClassName         string
+	TopologySpreadConstraints string
+	// PodAnnotations and PodCustomLabels are the custom metadata of the pod,
+	// see GetAnnotationsJSON and GetCustomLabelsJSON
+	PodAnnotations  string
+	PodCustomLabels string
+	SyncReplication bool
+	Standby         bool
 	// A comma-separated list of tablespace names...this could be an array, but
 	// given how this would ultimately be interpreted in a shell script tsomewhere
 	// down the line, it's easier for the time being to do it this way. In the
@@ -185,6 +229,10 @@ type DeploymentTemplateFields struct {
 	// CASecret is the name of the Secret that has the trusted CA that the
 	// PostgreSQL server is using
 	CASecret string
+	// ReplicationTLSSecret is the name of the Secret that has the client
+	// certificate of the replication user, if it authenticates with one, see
+	// GetReplicationTLSSecretName
+	ReplicationTLSSecret string
 }
 
 // tablespaceVolumeFields are the fields used to create the volumes in a
@@ -211,13 +259,15 @@ type tablespaceVolumeMountFields struct {
 func GetPgbackrestEnvVars(cluster *crv1.Pgcluster, backrestEnabled, depName, port, storageType string) string {
 	if backrestEnabled == "true" {
 		fields := PgbackrestEnvVarsTemplateFields{
-			PgbackrestStanza:            "db",
-			PgbackrestRepo1Host:         cluster.Name + "-backrest-shared-repo",
-			PgbackrestRepo1Path:         util.GetPGBackRestRepoPath(*cluster),
-			PgbackrestDBPath:            "/pgdata/" + depName,
-			PgbackrestPGPort:            port,
-			PgbackrestRepo1Type:         GetRepoType(storageType),
-			PgbackrestLocalAndS3Storage: IsLocalAndS3Storage(storageType),
+			PgbackrestStanza:               "db",
+			PgbackrestRepo1Host:            cluster.Name + "-backrest-shared-repo",
+			PgbackrestRepo1Path:            util.GetPGBackRestRepoPath(*cluster),
+			PgbackrestDBPath:               "/pgdata/" + depName,
+			PgbackrestPGPort:               port,
+			PgbackrestRepo1Type:            GetRepoType(storageType),
+			PgbackrestLocalAndS3Storage:    IsLocalAndS3Storage(storageType),
+			PgbackrestLocalAndGCSStorage:   IsLocalAndGCSStorage(storageType),
+			PgbackrestLocalAndAzureStorage: IsLocalAndAzureStorage(storageType),
 		}
 
 		var doc bytes.Buffer
@@ -270,15 +320,37 @@ func GetBadgerAddon(clientset *kubernetes.Clientset, namespace string, cluster *
 	return ""
 }
 
+// CreateCollectSecret creates the secret of the monitoring user of a cluster
+// when metrics are collected for the cluster. Errors are logged, as the secret
+// is kept when a cluster is recreated from its storage
+func CreateCollectSecret(clientset *kubernetes.Clientset, spec *crv1.PgclusterSpec, namespace string) {
+	secret := NewCollectSecret(spec)
+	if secret == nil {
+		return
+	}
+
+	log.Debugf("creating collect secret for cluster %s", spec.Name)
+	if err := kubeapi.CreateSecret(clientset, secret, namespace); err != nil {
+		log.Debug(err)
+	}
+}
+
+// NewCollectSecret returns the secret of the monitoring user of a cluster, or
+// nil if metrics are not collected for the cluster
+func NewCollectSecret(spec *crv1.PgclusterSpec) *v1.Secret {
+	if spec.UserLabels[config.LABEL_COLLECT] != "true" {
+		return nil
+	}
+
+	return util.NewSecret(spec.Name, spec.CollectSecretName, config.LABEL_COLLECT_PG_USER,
+		Pgo.Cluster.PgmonitorPassword)
+}
+
 func GetCollectAddon(clientset *kubernetes.Clientset, namespace string, spec *crv1.PgclusterSpec) string {
 
 	if spec.UserLabels[config.LABEL_COLLECT] == "true" {
 		log.Debug("crunchy_collect was found as a label on cluster create")
 
-		log.Debug("creating collect secret for cluster %s", spec.Name)
-		err := util.CreateSecret(clientset, spec.Name, spec.CollectSecretName, config.LABEL_COLLECT_PG_USER,
-			Pgo.Cluster.PgmonitorPassword, namespace)
-
 		collectTemplateFields := collectTemplateFields{}
 		collectTemplateFields.Name = spec.Name
 		collectTemplateFields.JobName = spec.Name
@@ -288,7 +360,7 @@ func GetCollectAddon(clientset *kubernetes.Clientset, namespace string, spec *cr
 		collectTemplateFields.PgPort = spec.Port
 
 		var collectDoc bytes.Buffer
-		err = config.CollectTemplate.Execute(&collectDoc, collectTemplateFields)
+		err := config.CollectTemplate.Execute(&collectDoc, collectTemplateFields)
 		if err != nil {
 			log.Error(err.Error())
 			return ""
@@ -340,6 +412,16 @@ func GetConfVolume(clientset *kubernetes.Clientset, cl *crv1.Pgcluster, namespac
 func CreatePGHAConfigMap(clientset *kubernetes.Clientset, cluster *crv1.Pgcluster,
 	namespace string) error {
 
+	if err := kubeapi.CreateConfigMap(clientset, NewPGHAConfigMap(cluster), namespace); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// NewPGHAConfigMap returns the configMap that CreatePGHAConfigMap creates for a
+// cluster
+func NewPGHAConfigMap(cluster *crv1.Pgcluster) *v1.ConfigMap {
 	labels := make(map[string]string)
 	labels[config.LABEL_VENDOR] = config.LABEL_CRUNCHY
 	labels[config.LABEL_PG_CLUSTER] = cluster.Name
@@ -363,11 +445,19 @@ func CreatePGHAConfigMap(clientset *kubernetes.Clientset, cluster *crv1.Pgcluste
 		Data: data,
 	}
 
-	if err := kubeapi.CreateConfigMap(clientset, configmap, namespace); err != nil {
-		return err
+	return configmap
+}
+
+// GetReplicationTLSSecretName returns the name of the Secret that has the
+// client certificate of the replication user of a cluster, which is mounted
+// into the PostgreSQL pods, or an empty string if the replication user
+// authenticates with a password
+func GetReplicationTLSSecretName(cluster *crv1.Pgcluster) string {
+	if !cluster.Spec.TLS.IsTLSEnabled() || !cluster.Spec.TLS.IsCertAuthUser(crv1.PGUserReplication) {
+		return ""
 	}
 
-	return nil
+	return fmt.Sprintf(crv1.UserTLSSecretFormat, cluster.Name, crv1.PGUserReplication)
 }
 
 // sets the proper collect secret in the deployment spec if collect is enabled
@@ -657,6 +747,130 @@ func GetPodAntiAffinityType(cluster *crv1.Pgcluster, deploymentType crv1.PodAnti
 	return crv1.PodAntiAffinityType(Pgo.Cluster.PodAntiAffinity)
 }
 
+// GetTolerations returns the tolerations of the pods of a cluster, which are
+// the ones in the pgcluster, or the defaults in pgo.yaml if it has none
+func GetTolerations(cluster *crv1.Pgcluster) []v1.Toleration {
+	if len(cluster.Spec.Tolerations) > 0 {
+		return cluster.Spec.Tolerations
+	}
+
+	// the tolerations in pgo.yaml are validated when it is loaded
+	tolerations, err := config.ParseTolerations(Pgo.Cluster.Tolerations)
+	if err != nil {
+		log.Error(err)
+		return nil
+	}
+
+	return tolerations
+}
+
+// GetReplicaTolerations returns the tolerations of the pod of a replica, which
+// are the ones in its pgreplica, or the ones of the cluster if it has none
+func GetReplicaTolerations(cluster *crv1.Pgcluster, replica *crv1.Pgreplica) []v1.Toleration {
+	if len(replica.Spec.Tolerations) > 0 {
+		return replica.Spec.Tolerations
+	}
+
+	return GetTolerations(cluster)
+}
+
+// GetTolerationsJSON returns the "tolerations" of a pod spec, including a
+// trailing comma, or an empty string if there are none
+func GetTolerationsJSON(tolerations []v1.Toleration) string {
+	if len(tolerations) == 0 {
+		return ""
+	}
+
+	doc, err := json.Marshal(tolerations)
+	if err != nil {
+		log.Error(err)
+		return ""
+	}
+
+	return fmt.Sprintf(`"tolerations": %s,`, doc)
+}
+
+// GetPriorityClassName returns the priority class of the pods of a cluster,
+// which is the one in the pgcluster, or the default in pgo.yaml if it has none
+func GetPriorityClassName(cluster *crv1.Pgcluster) string {
+	if cluster.Spec.PriorityClassName != "" {
+		return cluster.Spec.PriorityClassName
+	}
+
+	return Pgo.Cluster.PriorityClassName
+}
+
+// GetReplicaPriorityClassName returns the priority class of the pod of a
+// replica, which is the one in its pgreplica, or the one of the cluster if it
+// has none
+func GetReplicaPriorityClassName(cluster *crv1.Pgcluster, replica *crv1.Pgreplica) string {
+	if replica.Spec.PriorityClassName != "" {
+		return replica.Spec.PriorityClassName
+	}
+
+	return GetPriorityClassName(cluster)
+}
+
+// GetZoneSpread returns how the PostgreSQL instances of a cluster are spread
+// across zones, which is the value in the pgcluster, or the default in pgo.yaml
+// if it has none. Spreading is disabled if neither is set
+func GetZoneSpread(cluster *crv1.Pgcluster) crv1.ZoneSpreadType {
+	if cluster.Spec.ZoneSpread != "" {
+		return cluster.Spec.ZoneSpread
+	}
+
+	if Pgo.Cluster.ZoneSpread != "" {
+		return crv1.ZoneSpreadType(Pgo.Cluster.ZoneSpread)
+	}
+
+	return crv1.ZoneSpreadDisabled
+}
+
+// GetTopologySpreadConstraints returns the topology spread constraints that
+// spread the PostgreSQL instances of a cluster across zones, or nil if zone
+// spreading is disabled
+func GetTopologySpreadConstraints(cluster *crv1.Pgcluster) []v1.TopologySpreadConstraint {
+	whenUnsatisfiable := v1.ScheduleAnyway
+
+	switch GetZoneSpread(cluster) {
+	case crv1.ZoneSpreadRequired:
+		whenUnsatisfiable = v1.DoNotSchedule
+	case crv1.ZoneSpreadPreferred:
+	default:
+		return nil
+	}
+
+	return []v1.TopologySpreadConstraint{{
+		MaxSkew:           1,
+		TopologyKey:       ZoneTopologyKey,
+		WhenUnsatisfiable: whenUnsatisfiable,
+		LabelSelector: &meta_v1.LabelSelector{
+			MatchLabels: map[string]string{
+				config.LABEL_PG_CLUSTER:  cluster.Spec.Name,
+				config.LABEL_PG_DATABASE: "true",
+			},
+		},
+	}}
+}
+
+// GetTopologySpreadConstraintsJSON returns the "topologySpreadConstraints" of
+// the pod spec of a PostgreSQL instance, including a trailing comma, or an
+// empty string if zone spreading is disabled
+func GetTopologySpreadConstraintsJSON(cluster *crv1.Pgcluster) string {
+	constraints := GetTopologySpreadConstraints(cluster)
+	if len(constraints) == 0 {
+		return ""
+	}
+
+	doc, err := json.Marshal(constraints)
+	if err != nil {
+		log.Error(err)
+		return ""
+	}
+
+	return fmt.Sprintf(`"topologySpreadConstraints": %s,`, doc)
+}
+
 func GetPgmonitorEnvVars(metricsEnabled string) string {
 	if metricsEnabled == "true" {
 		fields := PgmonitorEnvVarsTemplateFields{
@@ -732,6 +946,128 @@ func GetPgbackrestS3EnvVars(cluster crv1.Pgcluster, clientset *kubernetes.Client
 	return doc.String()
 }
 
+// GetPgbackrestGCSEnvVars retrieves the values for the various configuration settings required to
+// configure pgBackRest for GCS, including a bucket, endpoint and key type.  Values are
+// either retrieved from the pgcluster spec (if present), otherwise from the pgo.yaml
+// configuration file.  The service account key (or token) is read from the pgBackRest repo
+// secret of the cluster
+func GetPgbackrestGCSEnvVars(cluster crv1.Pgcluster) string {
+
+	if !strings.Contains(cluster.Spec.UserLabels[config.LABEL_BACKREST_STORAGE_TYPE], "gcs") {
+		return ""
+	}
+
+	gcsEnvVars := PgbackrestGCSEnvVarsTemplateFields{
+		PgbackrestGCSBucket: getBackrestStorageParam(cluster.Spec.BackrestGCSBucket,
+			Pgo.Cluster.BackrestGCSBucket),
+		PgbackrestGCSEndpoint: getBackrestStorageParam(cluster.Spec.BackrestGCSEndpoint,
+			Pgo.Cluster.BackrestGCSEndpoint),
+		PgbackrestGCSKey: util.BackRestRepoSecretKeyGCSKey,
+		PgbackrestGCSKeyType: getBackrestStorageParam(cluster.Spec.BackrestGCSKeyType,
+			Pgo.Cluster.BackrestGCSKeyType),
+		PgbackrestGCSSecretName:    fmt.Sprintf("%s-%s", cluster.Name, config.LABEL_BACKREST_REPO_SECRET),
+		PgbackrestStorageVerifyTLS: GetBackrestStorageVerifyTLS(cluster),
+	}
+
+	// pgBackRest defaults to a service account key
+	if gcsEnvVars.PgbackrestGCSKeyType == "" {
+		gcsEnvVars.PgbackrestGCSKeyType = "service"
+	}
+
+	doc := bytes.Buffer{}
+
+	if err := config.PgbackrestGCSEnvVarsTemplate.Execute(&doc, gcsEnvVars); err != nil {
+		log.Error(err.Error())
+		return ""
+	}
+
+	return doc.String()
+}
+
+// GetPgbackrestAzureEnvVars retrieves the values for the various configuration settings
+// required to configure pgBackRest for Azure Blob storage, including a container, endpoint
+// and URI style.  Values are either retrieved from the pgcluster spec (if present), otherwise
+// from the pgo.yaml configuration file.  The storage account and shared key are read from the
+// pgBackRest repo secret of the cluster
+func GetPgbackrestAzureEnvVars(cluster crv1.Pgcluster) string {
+
+	if !strings.Contains(cluster.Spec.UserLabels[config.LABEL_BACKREST_STORAGE_TYPE], "azure") {
+		return ""
+	}
+
+	azureEnvVars := PgbackrestAzureEnvVarsTemplateFields{
+		PgbackrestAzureAccount: util.BackRestRepoSecretKeyAzureAccount,
+		PgbackrestAzureContainer: getBackrestStorageParam(cluster.Spec.BackrestAzureContainer,
+			Pgo.Cluster.BackrestAzureContainer),
+		PgbackrestAzureEndpoint: getBackrestStorageParam(cluster.Spec.BackrestAzureEndpoint,
+			Pgo.Cluster.BackrestAzureEndpoint),
+		PgbackrestAzureKey:        util.BackRestRepoSecretKeyAzureKey,
+		PgbackrestAzureSecretName: fmt.Sprintf("%s-%s", cluster.Name, config.LABEL_BACKREST_REPO_SECRET),
+		PgbackrestAzureURIStyle: getBackrestStorageParam(cluster.Spec.BackrestAzureURIStyle,
+			Pgo.Cluster.BackrestAzureURIStyle),
+		PgbackrestStorageVerifyTLS: GetBackrestStorageVerifyTLS(cluster),
+	}
+
+	doc := bytes.Buffer{}
+
+	if err := config.PgbackrestAzureEnvVarsTemplate.Execute(&doc, azureEnvVars); err != nil {
+		log.Error(err.Error())
+		return ""
+	}
+
+	return doc.String()
+}
+
+// GetPgbackrestCipherEnvVars retrieves the values for the settings required to encrypt the
+// pgBackRest repository, i.e. the cipher type from the pgcluster spec and the passphrase that
+// is stored in the pgBackRest repo secret of the cluster.  If the repository is not encrypted,
+// an empty string is returned
+func GetPgbackrestCipherEnvVars(cluster crv1.Pgcluster) string {
+
+	if !util.IsBackrestCipherEnabled(cluster) {
+		return ""
+	}
+
+	cipherEnvVars := PgbackrestCipherEnvVarsTemplateFields{
+		PgbackrestCipherType:       cluster.Spec.BackrestCipherType,
+		PgbackrestCipherPass:       util.BackRestRepoSecretKeyCipherPass,
+		PgbackrestCipherSecretName: fmt.Sprintf("%s-%s", cluster.Name, config.LABEL_BACKREST_REPO_SECRET),
+	}
+
+	doc := bytes.Buffer{}
+
+	if err := config.PgbackrestCipherEnvVarsTemplate.Execute(&doc, cipherEnvVars); err != nil {
+		log.Error(err.Error())
+		return ""
+	}
+
+	return doc.String()
+}
+
+// getBackrestStorageParam returns the value provided by the pgcluster spec if it is not an
+// empty string, otherwise the equivalent value from the pgo.yaml configuration file
+func getBackrestStorageParam(clusterParam, pgoConfigParam string) string {
+	if clusterParam != "" {
+		return clusterParam
+	}
+
+	return pgoConfigParam
+}
+
+// GetBackrestStorageVerifyTLS returns the value for the pgBackRest "repo1-storage-verify-tls"
+// setting, i.e. "y" or "n". TLS verification is enabled unless it is explicitly disabled in
+// either the pgcluster spec or the pgo.yaml configuration file
+func GetBackrestStorageVerifyTLS(cluster crv1.Pgcluster) string {
+	verifyTLS := getBackrestStorageParam(cluster.Spec.BackrestStorageVerifyTLS,
+		Pgo.Cluster.BackrestStorageVerifyTLS)
+
+	if enabled, err := strconv.ParseBool(verifyTLS); err == nil && !enabled {
+		return "n"
+	}
+
+	return "y"
+}
+
 // UpdatePGHAConfigInitFlag sets the value for the "init" setting in the PGHA configMap for the
 // PG cluster to the value specified via the "initVal" parameter.  For instance, following the
 // initialization of a PG cluster this function will be utilized to set the "init" value to false
@@ -22,6 +22,7 @@ import (
 
 	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
 	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/util"
 	log "github.com/sirupsen/logrus"
 
 	v1 "k8s.io/api/core/v1"
@@ -151,8 +152,9 @@ func GetContainerResourcesJSON(resources *crv1.PgContainerResources) string {
 // GetRepoType returns the proper repo type to set in container based on the
 // backrest storage type provided
 func GetRepoType(backrestStorageType string) string {
-	if backrestStorageType != "" && backrestStorageType == "s3" {
-		return "s3"
+	if backrestStorageType != "" &&
+		util.IsStringOneOf(backrestStorageType, crv1.BackrestCloudStorageTypes...) {
+		return backrestStorageType
 	} else {
 		return "posix"
 	}
@@ -161,7 +163,25 @@ func GetRepoType(backrestStorageType string) string {
 // IsLocalAndS3Storage a boolean indicating whether or not local and s3 storage should
 // be enabled for pgBackRest based on the backrestStorageType string provided
 func IsLocalAndS3Storage(backrestStorageType string) bool {
-	if backrestStorageType != "" && strings.Contains(backrestStorageType, "s3") &&
+	return isLocalAndCloudStorage(backrestStorageType, "s3")
+}
+
+// IsLocalAndGCSStorage a boolean indicating whether or not local and gcs storage should
+// be enabled for pgBackRest based on the backrestStorageType string provided
+func IsLocalAndGCSStorage(backrestStorageType string) bool {
+	return isLocalAndCloudStorage(backrestStorageType, "gcs")
+}
+
+// IsLocalAndAzureStorage a boolean indicating whether or not local and azure storage should
+// be enabled for pgBackRest based on the backrestStorageType string provided
+func IsLocalAndAzureStorage(backrestStorageType string) bool {
+	return isLocalAndCloudStorage(backrestStorageType, "azure")
+}
+
+// isLocalAndCloudStorage returns true if both local storage and the cloud storage type
+// provided (e.g. "s3") are included in the backrestStorageType string provided
+func isLocalAndCloudStorage(backrestStorageType, cloudStorageType string) bool {
+	if backrestStorageType != "" && strings.Contains(backrestStorageType, cloudStorageType) &&
 		strings.Contains(backrestStorageType, "local") {
 		return true
 	}
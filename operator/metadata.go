@@ -0,0 +1,232 @@
+package operator
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	log "github.com/sirupsen/logrus"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// reservedLabels are the labels the Operator sets on the objects of a cluster,
+// which custom labels cannot replace
+var reservedLabels = []string{
+	config.LABEL_NAME,
+	config.LABEL_VENDOR,
+	config.LABEL_PG_CLUSTER,
+	config.LABEL_PG_CLUSTER_IDENTIFIER,
+	config.LABEL_PG_DATABASE,
+	config.LABEL_SERVICE_NAME,
+	config.LABEL_DEPLOYMENT_NAME,
+	config.LABEL_PGHA_ROLE,
+	config.LABEL_PGHA_SCOPE,
+	config.LABEL_POD_ANTI_AFFINITY,
+	config.LABEL_PGBOUNCER,
+	config.LABEL_PGO_BACKREST_REPO,
+}
+
+// IsReservedLabel returns true if a label is set by the Operator, and can
+// therefore not be a custom label
+func IsReservedLabel(key string) bool {
+	for _, reserved := range reservedLabels {
+		if key == reserved {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCustomLabels returns the custom labels of an object. Labels that are not
+// valid or that are reserved are left out, as are the ones that are also in the
+// user labels of the cluster, as those take precedence
+func GetCustomLabels(metadata crv1.MetadataSpec, userLabels map[string]string) map[string]string {
+	labels := map[string]string{}
+
+	for key, value := range metadata.Labels {
+		if _, ok := userLabels[key]; ok || IsReservedLabel(key) {
+			continue
+		}
+
+		if len(validation.IsQualifiedName(key)) == 0 && len(validation.IsValidLabelValue(value)) == 0 {
+			labels[key] = value
+		}
+	}
+
+	return labels
+}
+
+// GetCustomAnnotations returns the custom annotations of an object. If the
+// object has any custom annotations or labels, the keys of both are recorded in
+// annotations as well, so that they can be removed once they are no longer
+// part of the pgcluster
+func GetCustomAnnotations(metadata crv1.MetadataSpec, labels map[string]string) map[string]string {
+	annotations := map[string]string{}
+
+	for key, value := range metadata.Annotations {
+		if key == config.ANNOTATION_CUSTOM_ANNOTATIONS || key == config.ANNOTATION_CUSTOM_LABELS {
+			continue
+		}
+
+		if len(validation.IsQualifiedName(key)) == 0 {
+			annotations[key] = value
+		}
+	}
+
+	if len(annotations) > 0 {
+		annotations[config.ANNOTATION_CUSTOM_ANNOTATIONS] = joinKeys(annotations)
+	}
+
+	if len(labels) > 0 {
+		annotations[config.ANNOTATION_CUSTOM_LABELS] = joinKeys(labels)
+	}
+
+	return annotations
+}
+
+// GetCustomMetadata returns the custom annotations and labels of an object, see
+// GetCustomAnnotations and GetCustomLabels
+func GetCustomMetadata(metadata crv1.MetadataSpec, userLabels map[string]string) (map[string]string, map[string]string) {
+	labels := GetCustomLabels(metadata, userLabels)
+	return GetCustomAnnotations(metadata, labels), labels
+}
+
+// GetCustomMetadataJSON returns the custom annotations and labels of an object
+// as JSON, see GetAnnotationsJSON and GetCustomLabelsJSON
+func GetCustomMetadataJSON(metadata crv1.MetadataSpec, userLabels map[string]string) (string, string) {
+	annotations, labels := GetCustomMetadata(metadata, userLabels)
+	return GetAnnotationsJSON(annotations), GetCustomLabelsJSON(labels)
+}
+
+// GetAnnotationsJSON returns the "annotations" of an object, including a
+// trailing comma, or an empty string if there are none
+func GetAnnotationsJSON(annotations map[string]string) string {
+	if len(annotations) == 0 {
+		return ""
+	}
+
+	doc, err := json.Marshal(annotations)
+	if err != nil {
+		log.Error(err)
+		return ""
+	}
+
+	return fmt.Sprintf(`"annotations": %s,`, doc)
+}
+
+// GetCustomLabelsJSON returns labels as entries of a "labels" object, each
+// including a trailing comma, so that they can precede the labels that the
+// Operator sets
+func GetCustomLabelsJSON(labels map[string]string) string {
+	var output string
+
+	for _, key := range sortedKeys(labels) {
+		doc, err := json.Marshal(map[string]string{key: labels[key]})
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+
+		output += strings.TrimSuffix(strings.TrimPrefix(string(doc), "{"), "}") + ","
+	}
+
+	return output
+}
+
+// ApplyCustomMetadata sets the custom annotations and labels provided on an
+// object, and removes the custom ones it had before that are no longer
+// provided. Annotations and labels that were not added as custom ones are left
+// as they are. It returns true if anything changed
+func ApplyCustomMetadata(object *meta_v1.ObjectMeta, annotations, labels map[string]string) bool {
+	changed := false
+
+	// the annotations and labels that were previously added as custom ones
+	previousAnnotations := splitKeys(object.Annotations[config.ANNOTATION_CUSTOM_ANNOTATIONS])
+	previousLabels := splitKeys(object.Annotations[config.ANNOTATION_CUSTOM_LABELS])
+
+	for _, key := range previousLabels {
+		if _, ok := labels[key]; !ok && object.Labels != nil {
+			if _, found := object.Labels[key]; found {
+				delete(object.Labels, key)
+				changed = true
+			}
+		}
+	}
+
+	for _, key := range append(previousAnnotations,
+		config.ANNOTATION_CUSTOM_ANNOTATIONS, config.ANNOTATION_CUSTOM_LABELS) {
+		if _, ok := annotations[key]; !ok && object.Annotations != nil {
+			if _, found := object.Annotations[key]; found {
+				delete(object.Annotations, key)
+				changed = true
+			}
+		}
+	}
+
+	if len(annotations) > 0 && object.Annotations == nil {
+		object.Annotations = map[string]string{}
+	}
+
+	for key, value := range annotations {
+		if current, ok := object.Annotations[key]; !ok || current != value {
+			object.Annotations[key] = value
+			changed = true
+		}
+	}
+
+	if len(labels) > 0 && object.Labels == nil {
+		object.Labels = map[string]string{}
+	}
+
+	for key, value := range labels {
+		if current, ok := object.Labels[key]; !ok || current != value {
+			object.Labels[key] = value
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// joinKeys returns the sorted keys of a map as a comma separated list
+func joinKeys(values map[string]string) string {
+	return strings.Join(sortedKeys(values), ",")
+}
+
+// splitKeys returns the keys in a comma separated list
+func splitKeys(keys string) []string {
+	if keys == "" {
+		return []string{}
+	}
+	return strings.Split(keys, ",")
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
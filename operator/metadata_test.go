@@ -0,0 +1,127 @@
+package operator
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"reflect"
+	"testing"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetCustomMetadata(t *testing.T) {
+	metadata := crv1.MetadataSpec{
+		Annotations: map[string]string{
+			"example.com/owner":                  "payments",
+			config.ANNOTATION_CUSTOM_ANNOTATIONS: "spoofed",
+		},
+		Labels: map[string]string{
+			"team":                  "payments",
+			"tier":                  "gold",
+			"bad label":             "value",
+			config.LABEL_PG_CLUSTER: "other",
+		},
+	}
+	userLabels := map[string]string{"tier": "silver"}
+
+	annotations, labels := GetCustomMetadata(metadata, userLabels)
+
+	expectedLabels := map[string]string{"team": "payments"}
+	if !reflect.DeepEqual(labels, expectedLabels) {
+		t.Fatalf("expected labels %v, got %v", expectedLabels, labels)
+	}
+
+	expectedAnnotations := map[string]string{
+		"example.com/owner":                  "payments",
+		config.ANNOTATION_CUSTOM_ANNOTATIONS: "example.com/owner",
+		config.ANNOTATION_CUSTOM_LABELS:      "team",
+	}
+	if !reflect.DeepEqual(annotations, expectedAnnotations) {
+		t.Fatalf("expected annotations %v, got %v", expectedAnnotations, annotations)
+	}
+
+	if json := GetCustomLabelsJSON(labels); json != `"team":"payments",` {
+		t.Fatalf("unexpected labels JSON %q", json)
+	}
+
+	if json := GetAnnotationsJSON(nil); json != "" {
+		t.Fatalf("expected no annotations JSON, got %q", json)
+	}
+}
+
+func TestApplyCustomMetadata(t *testing.T) {
+	object := meta_v1.ObjectMeta{
+		Annotations: map[string]string{
+			"kept":                               "yes",
+			"removed":                            "yes",
+			config.ANNOTATION_CUSTOM_ANNOTATIONS: "removed",
+			config.ANNOTATION_CUSTOM_LABELS:      "team,tier",
+		},
+		Labels: map[string]string{
+			config.LABEL_PG_CLUSTER: "hippo",
+			"team":                  "payments",
+			"tier":                  "gold",
+		},
+	}
+
+	annotations, labels := GetCustomMetadata(crv1.MetadataSpec{
+		Labels: map[string]string{"team": "billing"},
+	}, nil)
+
+	t.Run("changed", func(t *testing.T) {
+		if !ApplyCustomMetadata(&object, annotations, labels) {
+			t.Fatal("expected the object to be changed")
+		}
+
+		expectedAnnotations := map[string]string{
+			"kept":                          "yes",
+			config.ANNOTATION_CUSTOM_LABELS: "team",
+		}
+		if !reflect.DeepEqual(object.Annotations, expectedAnnotations) {
+			t.Fatalf("expected annotations %v, got %v", expectedAnnotations, object.Annotations)
+		}
+
+		expectedLabels := map[string]string{
+			config.LABEL_PG_CLUSTER: "hippo",
+			"team":                  "billing",
+		}
+		if !reflect.DeepEqual(object.Labels, expectedLabels) {
+			t.Fatalf("expected labels %v, got %v", expectedLabels, object.Labels)
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		if ApplyCustomMetadata(&object, annotations, labels) {
+			t.Fatal("expected the object to be unchanged")
+		}
+	})
+
+	t.Run("all removed", func(t *testing.T) {
+		if !ApplyCustomMetadata(&object, map[string]string{}, map[string]string{}) {
+			t.Fatal("expected the object to be changed")
+		}
+
+		if _, ok := object.Labels["team"]; ok {
+			t.Fatalf("expected the custom label to be removed, got %v", object.Labels)
+		}
+
+		if _, ok := object.Annotations[config.ANNOTATION_CUSTOM_LABELS]; ok {
+			t.Fatalf("expected the tracking annotation to be removed, got %v", object.Annotations)
+		}
+	})
+}
@@ -23,6 +23,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"strconv"
 	"time"
 )
 
@@ -60,6 +61,18 @@ func updateVersion(restclient *rest.RESTClient, ns string) error {
 			cluster.Spec.UserLabels[config.LABEL_UPGRADE_DATE] = dt
 			log.Infof("operator-upgrade - upgrade pgcluster %s from %s to %s on %s", cluster.Name, cluster.Spec.UserLabels[config.LABEL_PGO_VERSION], msgs.PGO_VERSION, dt)
 			cluster.Spec.UserLabels[config.LABEL_PGO_VERSION] = msgs.PGO_VERSION
+
+			// replicas added with "pgo scale" were not counted in the replica count
+			// of the pgcluster before, and the Operator now reconciles the replicas
+			// of a cluster to this count. Each replica has a pgreplica
+			replicaList := crv1.PgreplicaList{}
+			err = kubeapi.GetpgreplicasBySelector(restclient, &replicaList,
+				config.LABEL_PG_CLUSTER+"="+cluster.Name, ns)
+			if err != nil {
+				return err
+			}
+			cluster.Spec.Replicas = strconv.Itoa(len(replicaList.Items))
+
 			err = kubeapi.Updatepgcluster(restclient, &cluster, cluster.Name, ns)
 			if err != nil {
 				return err
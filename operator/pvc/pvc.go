@@ -75,6 +75,25 @@ func CreatePVC(clientset *kubernetes.Clientset, storageSpec *crv1.PgStorageSpec,
 // Create a pvc
 func Create(clientset *kubernetes.Clientset, name, clusterName string, storageSpec *crv1.PgStorageSpec, namespace string) error {
 	log.Debug("in createPVC")
+
+	newpvc, err := Render(name, clusterName, storageSpec)
+	if err != nil {
+		return err
+	}
+
+	err = kubeapi.CreatePVC(clientset, newpvc, namespace)
+	if err != nil {
+		return err
+	}
+
+	//TODO replace sleep with proper wait
+	time.Sleep(3000 * time.Millisecond)
+	return nil
+
+}
+
+// Render returns the pvc that Create creates, without creating it
+func Render(name, clusterName string, storageSpec *crv1.PgStorageSpec) (*v1.PersistentVolumeClaim, error) {
 	var doc2 bytes.Buffer
 	var err error
 
@@ -99,7 +118,7 @@ func Create(clientset *kubernetes.Clientset, name, clusterName string, storageSp
 			arr := strings.Split(storageSpec.MatchLabels, "=")
 			if len(arr) != 2 {
 				log.Error("%s MatchLabels is not formatted correctly", storageSpec.MatchLabels)
-				return errors.New("match labels is not formatted correctly")
+				return nil, errors.New("match labels is not formatted correctly")
 			}
 			pvcFields.MatchLabels = getMatchLabels(arr[0], arr[1])
 			log.Debugf("matchlabels constructed is %s", pvcFields.MatchLabels)
@@ -112,25 +131,32 @@ func Create(clientset *kubernetes.Clientset, name, clusterName string, storageSp
 	}
 	if err != nil {
 		log.Error("error in pvc create exec" + err.Error())
-		return err
+		return nil, err
 	}
 
 	newpvc := v1.PersistentVolumeClaim{}
 	err = json.Unmarshal(doc2.Bytes(), &newpvc)
 	if err != nil {
 		log.Error("error unmarshalling json into PVC " + err.Error())
-		return err
+		return nil, err
 	}
 
-	err = kubeapi.CreatePVC(clientset, &newpvc, namespace)
-	if err != nil {
-		return err
-	}
+	return &newpvc, nil
+}
 
-	//TODO replace sleep with proper wait
-	time.Sleep(3000 * time.Millisecond)
-	return nil
+// RenderPVC returns the pvc that CreatePVC creates for the storage spec, along
+// with the name of the pvc that is used. The pvc is nil when none is created,
+// e.g. for emptydir or existing storage
+func RenderPVC(storageSpec *crv1.PgStorageSpec, pvcName, clusterName string) (*v1.PersistentVolumeClaim, string, error) {
+	switch storageSpec.StorageType {
+	case "existing":
+		return nil, storageSpec.Name, nil
+	case "create", "dynamic":
+		newpvc, err := Render(pvcName, clusterName, storageSpec)
+		return newpvc, pvcName, err
+	}
 
+	return nil, pvcName, nil
 }
 
 // Delete a pvc
@@ -0,0 +1,347 @@
+package snapshot
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/events"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	"github.com/crunchydata/postgres-operator/util"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// pgDataVolumeName is the name of the volume of the PGDATA PVC in the
+	// PostgreSQL pods
+	pgDataVolumeName = "pgdata"
+	// pgDataSnapshotNameFormat and tablespaceSnapshotNameFormat are the formats
+	// of the names of the VolumeSnapshots of a snapshot backup, which are
+	// prefixed with the name of the snapshot backup
+	pgDataSnapshotNameFormat     = "%s-pgdata"
+	tablespaceSnapshotNameFormat = "%s-tablespace-%s"
+	// sqlStartBackup puts the database into backup mode. An exclusive backup is
+	// used, which writes the backup_label file into PGDATA and therefore into
+	// the snapshot, as the backup is started and stopped in separate sessions.
+	// The checkpoint is requested to be immediate
+	sqlStartBackup = "SELECT pg_start_backup('%s', true);"
+	// sqlStopBackup ends backup mode, and waits until all of the WAL that is
+	// required to make the snapshot consistent is archived. The exclusive form
+	// returns the LSN the backup ended at
+	sqlStopBackup = "SELECT pg_stop_backup();"
+	// sqlClientMinMessages keeps the notices that are expected while a backup
+	// is started and stopped, e.g. that the WAL has been archived, out of
+	// stderr, so that anything written to it is an error
+	sqlClientMinMessages = "SET client_min_messages = warning;\n"
+)
+
+var (
+	// snapshotTimeout is how long to wait for the VolumeSnapshots to be cut,
+	// during which the database is in backup mode
+	snapshotTimeout = 10 * time.Minute
+	// snapshotPollInterval is how often the VolumeSnapshots are checked
+	snapshotPollInterval = 5 * time.Second
+)
+
+// PodExecutor runs a command in a container of a pod and returns its stdout and
+// stderr, e.g. kubeapi.ExecToPodThroughAPI
+type PodExecutor func(namespace, podName, containerName string, command []string,
+	stdin io.Reader) (string, string, error)
+
+// Backup takes a snapshot backup of the cluster in the pgtask: the PGDATA and
+// tablespace PVCs of the primary are snapshotted while the database is in
+// backup mode, and the snapshot backup is added to the catalog in the status
+// of the pgcluster
+func Backup(clientset *kubernetes.Clientset, restclient *rest.RESTClient, restConfig *rest.Config,
+	namespace string, task *crv1.Pgtask) {
+	clusterName := task.Spec.Parameters[config.LABEL_PG_CLUSTER]
+	snapshotName := task.Spec.Parameters[config.LABEL_PGO_SNAPSHOT]
+
+	log.Debugf("snapshot backup %s of cluster %s called", snapshotName, clusterName)
+
+	cluster := crv1.Pgcluster{}
+	if _, err := kubeapi.Getpgcluster(restclient, &cluster, clusterName, namespace); err != nil {
+		log.Error(err)
+		return
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	exec := func(namespace, podName, containerName string, command []string,
+		stdin io.Reader) (string, string, error) {
+		return kubeapi.ExecToPodThroughAPI(restConfig, clientset, command, containerName,
+			podName, namespace, stdin)
+	}
+
+	snapshot, err := takeSnapshotBackup(clientset, dynamicClient, exec, &cluster, snapshotName,
+		task.Spec.Parameters[config.LABEL_SNAPSHOT_CLASS])
+	if err != nil {
+		log.Errorf("snapshot backup %s of cluster %s failed: %s", snapshotName, clusterName, err)
+		if err := kubeapi.PatchpgtaskStatus(restclient, crv1.PgtaskStateProcessed,
+			err.Error(), task, namespace); err != nil {
+			log.Error(err)
+		}
+		return
+	}
+
+	// get the latest version of the pgcluster before updating the catalog, as
+	// the snapshot may have taken a while
+	if _, err := kubeapi.Getpgcluster(restclient, &cluster, clusterName, namespace); err != nil {
+		log.Error(err)
+		return
+	}
+
+	snapshots := append(cluster.Status.Snapshots, *snapshot)
+
+	if err := kubeapi.PatchpgclusterSnapshots(restclient, snapshots, clusterName, namespace); err != nil {
+		log.Error(err)
+		return
+	}
+
+	publishBackupComplete(clusterName, task.ObjectMeta.Labels[config.LABEL_PGOUSER], namespace,
+		snapshot.Name)
+
+	if err := util.Patch(restclient, "/spec/status", crv1.CompletedStatus, crv1.PgtaskResourcePlural,
+		task.Spec.Name, namespace); err != nil {
+		log.Error(err)
+	}
+}
+
+// takeSnapshotBackup puts the primary of the cluster into backup mode, takes a
+// VolumeSnapshot of each of its PGDATA and tablespace PVCs, and ends backup mode
+// once the point-in-time of all of the VolumeSnapshots has been taken. If
+// anything fails, any VolumeSnapshots that were created are removed
+func takeSnapshotBackup(clientset kubernetes.Interface, dynamicClient dynamic.Interface, exec PodExecutor,
+	cluster *crv1.Pgcluster, name, className string) (*crv1.PgclusterSnapshot, error) {
+	namespace := cluster.Namespace
+
+	pod, err := getPrimaryPod(clientset, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &crv1.PgclusterSnapshot{
+		Name:                name,
+		PGDataDirectory:     pod.ObjectMeta.Labels[config.LABEL_DEPLOYMENT_NAME],
+		PGDataSnapshot:      fmt.Sprintf(pgDataSnapshotNameFormat, name),
+		TablespaceSnapshots: map[string]string{},
+		VolumeSnapshotClass: className,
+	}
+
+	// map each of the PVCs of the primary to the VolumeSnapshot that is taken
+	// of it
+	pvcSnapshots := map[string]string{}
+
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+
+		switch {
+		case volume.Name == pgDataVolumeName:
+			pvcSnapshots[volume.PersistentVolumeClaim.ClaimName] = snapshot.PGDataSnapshot
+		case strings.HasPrefix(volume.Name, config.VOLUME_TABLESPACE_NAME_PREFIX):
+			tablespaceName := strings.TrimPrefix(volume.Name, config.VOLUME_TABLESPACE_NAME_PREFIX)
+			snapshot.TablespaceSnapshots[tablespaceName] = fmt.Sprintf(tablespaceSnapshotNameFormat,
+				name, tablespaceName)
+			pvcSnapshots[volume.PersistentVolumeClaim.ClaimName] = snapshot.TablespaceSnapshots[tablespaceName]
+		}
+	}
+
+	if _, ok := pvcSnapshots[getVolumeClaimName(pod, pgDataVolumeName)]; !ok {
+		return nil, fmt.Errorf("primary pod %s does not have a PGDATA PVC", pod.Name)
+	}
+
+	// start the backup...
+	if snapshot.StartLSN, err = execSQL(exec, pod, fmt.Sprintf(sqlStartBackup, name)); err != nil {
+		return nil, fmt.Errorf("could not start backup: %s", err)
+	}
+
+	// ...and from here on, make sure the backup is stopped and any
+	// VolumeSnapshots are removed if anything goes wrong
+	labels := map[string]string{
+		config.LABEL_VENDOR:       config.LABEL_CRUNCHY,
+		config.LABEL_PG_CLUSTER:   cluster.Name,
+		config.LABEL_PGO_SNAPSHOT: name,
+	}
+
+	cleanup := func() {
+		if _, err := execSQL(exec, pod, sqlStopBackup); err != nil {
+			log.Error(err)
+		}
+
+		for _, snapshotName := range pvcSnapshots {
+			_ = kubeapi.DeleteVolumeSnapshot(dynamicClient, snapshotName, namespace)
+		}
+	}
+
+	for pvcName, snapshotName := range pvcSnapshots {
+		if err := kubeapi.CreateVolumeSnapshot(dynamicClient, snapshotName, namespace, pvcName,
+			className, labels); err != nil {
+			cleanup()
+			return nil, err
+		}
+	}
+
+	if err := waitForVolumeSnapshotsCut(dynamicClient, namespace, pvcSnapshots); err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	// all of the VolumeSnapshots are cut, so the backup can be stopped
+	if snapshot.StopLSN, err = execSQL(exec, pod, sqlStopBackup); err != nil {
+		for _, snapshotName := range pvcSnapshots {
+			_ = kubeapi.DeleteVolumeSnapshot(dynamicClient, snapshotName, namespace)
+		}
+		return nil, fmt.Errorf("could not stop backup: %s", err)
+	}
+
+	snapshot.CreationTime = meta_v1.Now()
+
+	return snapshot, nil
+}
+
+// waitForVolumeSnapshotsCut waits until the point-in-time of each of the
+// VolumeSnapshots has been taken, or returns an error if any of them fail or
+// the timeout is reached
+func waitForVolumeSnapshotsCut(dynamicClient dynamic.Interface, namespace string,
+	pvcSnapshots map[string]string) error {
+	timeout := time.After(snapshotTimeout)
+	tick := time.NewTicker(snapshotPollInterval)
+	defer tick.Stop()
+
+	for {
+		cut := true
+
+		for _, snapshotName := range pvcSnapshots {
+			status, _, err := kubeapi.GetVolumeSnapshotStatus(dynamicClient, snapshotName, namespace)
+			if err != nil {
+				return err
+			}
+
+			if status.Error != "" {
+				return fmt.Errorf("volume snapshot %s failed: %s", snapshotName, status.Error)
+			}
+
+			cut = cut && status.Cut
+		}
+
+		if cut {
+			return nil
+		}
+
+		select {
+		case <-timeout:
+			return errors.New("timed out waiting for the volume snapshots to be taken")
+		case <-tick.C:
+		}
+	}
+}
+
+// getPrimaryPod returns the pod of the current primary of the cluster
+func getPrimaryPod(clientset kubernetes.Interface, cluster *crv1.Pgcluster) (*v1.Pod, error) {
+	selector := fmt.Sprintf("%s=%s,%s=master", config.LABEL_PG_CLUSTER, cluster.Name,
+		config.LABEL_PGHA_ROLE)
+
+	pods, err := clientset.CoreV1().Pods(cluster.Namespace).List(meta_v1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pods.Items) != 1 {
+		return nil, fmt.Errorf("expected 1 primary pod for cluster %s, found %d", cluster.Name,
+			len(pods.Items))
+	}
+
+	return &pods.Items[0], nil
+}
+
+// getVolumeClaimName returns the name of the PVC of a volume of a pod, or an
+// empty string if the volume is not a PVC
+func getVolumeClaimName(pod *v1.Pod, volumeName string) string {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Name == volumeName && volume.PersistentVolumeClaim != nil {
+			return volume.PersistentVolumeClaim.ClaimName
+		}
+	}
+
+	return ""
+}
+
+// execSQL runs a SQL statement in the database container of a pod and returns
+// the result. The statement has to return a value, and fails if it returns
+// none or if anything is written to stderr
+func execSQL(exec PodExecutor, pod *v1.Pod, sql string) (string, error) {
+	cmd := []string{"psql", "-X", "-A", "-t", "-q", "-v", "ON_ERROR_STOP=1"}
+
+	stdout, stderr, err := exec(pod.Namespace, pod.Name, "database", cmd,
+		strings.NewReader(sqlClientMinMessages+sql))
+	if err != nil {
+		log.Error(err, stderr)
+		if stderr != "" {
+			return "", fmt.Errorf("%s", strings.TrimSpace(stderr))
+		}
+		return "", err
+	}
+
+	if stderr != "" {
+		return "", fmt.Errorf("%s", strings.TrimSpace(stderr))
+	}
+
+	result := strings.TrimSpace(stdout)
+	if result == "" {
+		return "", fmt.Errorf("no result from %q", sql)
+	}
+
+	return result, nil
+}
+
+// publishBackupComplete publishes the event that a snapshot backup completed
+func publishBackupComplete(clusterName, username, namespace, snapshotName string) {
+	topics := []string{events.EventTopicCluster, events.EventTopicBackup}
+
+	f := events.EventCreateBackupCompletedFormat{
+		EventHeader: events.EventHeader{
+			Namespace: namespace,
+			Username:  username,
+			Topic:     topics,
+			Timestamp: time.Now(),
+			EventType: events.EventCreateBackupCompleted,
+		},
+		Clustername: clusterName,
+		BackupType:  config.LABEL_BACKUP_TYPE_SNAPSHOT,
+		Path:        snapshotName,
+	}
+
+	if err := events.Publish(f); err != nil {
+		log.Error(err.Error())
+	}
+}
@@ -0,0 +1,266 @@
+package snapshot
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newPrimaryPod returns a primary pod with a PGDATA volume and a tablespace
+// volume
+func newPrimaryPod() *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "hippo-abcd-1234",
+			Namespace: "pgo",
+			Labels: map[string]string{
+				config.LABEL_PG_CLUSTER:      "hippo",
+				config.LABEL_PGHA_ROLE:       "master",
+				config.LABEL_DEPLOYMENT_NAME: "hippo-abcd",
+			},
+		},
+		Spec: v1.PodSpec{
+			Volumes: []v1.Volume{
+				{Name: "pgdata", VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "hippo-abcd"}}},
+				{Name: "tablespace-lake", VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "hippo-abcd-tablespace-lake"}}},
+				{Name: "sshd", VolumeSource: v1.VolumeSource{
+					Secret: &v1.SecretVolumeSource{SecretName: "hippo-backrest-repo-config"}}},
+			},
+		},
+	}
+}
+
+// newDynamicClient returns a fake dynamic client that sets the status of each
+// VolumeSnapshot that is created
+func newDynamicClient(status map[string]interface{}) *dynamicfake.FakeDynamicClient {
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+
+	client.PrependReactor("create", "volumesnapshots", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		snapshot := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		snapshot.Object["status"] = status
+		// let the default reactor store the snapshot
+		return false, nil, nil
+	})
+
+	return client
+}
+
+func TestTakeSnapshotBackup(t *testing.T) {
+	snapshotPollInterval = time.Millisecond
+	snapshotTimeout = 100 * time.Millisecond
+
+	cluster := &crv1.Pgcluster{ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo"}}
+
+	tests := []struct {
+		status    map[string]interface{}
+		execError error
+		expectErr bool
+	}{
+		// the snapshots are cut
+		{map[string]interface{}{"creationTime": "2020-06-19T20:35:02Z", "readyToUse": false}, nil, false},
+		// the snapshots fail
+		{map[string]interface{}{"error": map[string]interface{}{"message": "no space"}}, nil, true},
+		// the snapshots are never cut
+		{map[string]interface{}{}, nil, true},
+		// the backup cannot be started
+		{map[string]interface{}{"readyToUse": true}, errors.New("not the primary"), true},
+	}
+
+	for i, test := range tests {
+		clientset := fake.NewSimpleClientset(newPrimaryPod())
+		dynamicClient := newDynamicClient(test.status)
+
+		statements := []string{}
+		exec := func(namespace, podName, containerName string, command []string,
+			stdin io.Reader) (string, string, error) {
+			sql, _ := ioutil.ReadAll(stdin)
+			statements = append(statements, string(sql))
+			if test.execError != nil {
+				return "", "", test.execError
+			}
+			return "0/3000028\n", "", nil
+		}
+
+		snapshot, err := takeSnapshotBackup(clientset, dynamicClient, exec, cluster, "hippo-snap", "csi")
+
+		snapshots, listErr := dynamicClient.Resource(kubeapi.VolumeSnapshotResource).Namespace("pgo").
+			List(meta_v1.ListOptions{})
+		if listErr != nil {
+			t.Fatalf("tests[%d] - unexpected error listing snapshots: %s", i, listErr)
+		}
+
+		if test.expectErr {
+			if err == nil {
+				t.Fatalf("tests[%d] - expected an error", i)
+			}
+
+			if len(snapshots.Items) != 0 {
+				t.Fatalf("tests[%d] - expected the volume snapshots to be removed, found %d", i, len(snapshots.Items))
+			}
+
+			// if the backup was started, it must be stopped
+			if test.execError == nil && (len(statements) != 2 || statements[1] != sqlClientMinMessages+sqlStopBackup) {
+				t.Fatalf("tests[%d] - expected the backup to be stopped, got %v", i, statements)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %s", i, err)
+		}
+
+		if len(snapshots.Items) != 2 {
+			t.Fatalf("tests[%d] - expected 2 volume snapshots, found %d", i, len(snapshots.Items))
+		}
+
+		for _, item := range snapshots.Items {
+			if item.GetLabels()[config.LABEL_PGO_SNAPSHOT] != "hippo-snap" {
+				t.Fatalf("tests[%d] - expected snapshot label on %s, got %v", i, item.GetName(), item.GetLabels())
+			}
+
+			if className, _, _ := unstructured.NestedString(item.Object, "spec", "volumeSnapshotClassName"); className != "csi" {
+				t.Fatalf("tests[%d] - expected volume snapshot class csi, got %q", i, className)
+			}
+		}
+
+		if snapshot.PGDataDirectory != "hippo-abcd" || snapshot.PGDataSnapshot != "hippo-snap-pgdata" {
+			t.Fatalf("tests[%d] - unexpected PGDATA snapshot: %+v", i, snapshot)
+		}
+
+		if snapshot.TablespaceSnapshots["lake"] != "hippo-snap-tablespace-lake" {
+			t.Fatalf("tests[%d] - unexpected tablespace snapshots: %v", i, snapshot.TablespaceSnapshots)
+		}
+
+		if snapshot.StartLSN != "0/3000028" || snapshot.StopLSN != "0/3000028" {
+			t.Fatalf("tests[%d] - unexpected LSNs: %q, %q", i, snapshot.StartLSN, snapshot.StopLSN)
+		}
+
+		if len(statements) != 2 || statements[1] != sqlClientMinMessages+sqlStopBackup {
+			t.Fatalf("tests[%d] - expected the backup to be started and stopped, got %v", i, statements)
+		}
+	}
+}
+
+func TestExecSQL(t *testing.T) {
+	pod := newPrimaryPod()
+
+	tests := []struct {
+		stdout, stderr string
+		err            error
+		expected       string
+		expectErr      bool
+	}{
+		{"0/3000028\n", "", nil, "0/3000028", false},
+		// the statement fails
+		{"", "ERROR:  a backup is not in progress\n", errors.New("command terminated with exit code 3"), "", true},
+		// the statement writes to stderr without failing
+		{"0/3000028\n", "WARNING:  WAL archiving is not enabled\n", nil, "", true},
+		// the statement does not return a value
+		{"\n", "", nil, "", true},
+	}
+
+	for i, test := range tests {
+		exec := func(namespace, podName, containerName string, command []string,
+			stdin io.Reader) (string, string, error) {
+			return test.stdout, test.stderr, test.err
+		}
+
+		result, err := execSQL(exec, pod, sqlStopBackup)
+
+		if test.expectErr {
+			if err == nil {
+				t.Fatalf("tests[%d] - expected an error", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %s", i, err)
+		}
+
+		if result != test.expected {
+			t.Fatalf("tests[%d] - expected %q, got %q", i, test.expected, result)
+		}
+	}
+}
+
+func TestCreatePVCs(t *testing.T) {
+	snapshot := &crv1.PgclusterSnapshot{
+		Name:                "hippo-snap",
+		PGDataSnapshot:      "hippo-snap-pgdata",
+		TablespaceSnapshots: map[string]string{"lake": "hippo-snap-tablespace-lake"},
+	}
+
+	storage := crv1.PgStorageSpec{AccessMode: "ReadWriteOnce", Size: "1G", StorageClass: "csi"}
+	tablespaceStorage := map[string]crv1.PgStorageSpec{"lake": storage}
+
+	clientset := fake.NewSimpleClientset()
+
+	if err := CreatePVCs(clientset, snapshot, "rhino", "rhino", "5G", storage, tablespaceStorage, "pgo"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		pvcName, snapshotName, size string
+	}{
+		{"rhino", "hippo-snap-pgdata", "5G"},
+		{"rhino-tablespace-lake", "hippo-snap-tablespace-lake", "1G"},
+	}
+
+	for i, test := range tests {
+		pvc, err := clientset.CoreV1().PersistentVolumeClaims("pgo").Get(test.pvcName, meta_v1.GetOptions{})
+		if err != nil {
+			t.Fatalf("tests[%d] - expected pvc %s: %s", i, test.pvcName, err)
+		}
+
+		if pvc.Spec.DataSource == nil || pvc.Spec.DataSource.Name != test.snapshotName ||
+			pvc.Spec.DataSource.Kind != kubeapi.VolumeSnapshotKind {
+			t.Fatalf("tests[%d] - unexpected data source: %+v", i, pvc.Spec.DataSource)
+		}
+
+		if size := pvc.Spec.Resources.Requests[v1.ResourceStorage]; size.String() != test.size {
+			t.Fatalf("tests[%d] - expected size %s, got %s", i, test.size, size.String())
+		}
+
+		if pvc.ObjectMeta.Labels[config.LABEL_PG_CLUSTER] != "rhino" {
+			t.Fatalf("tests[%d] - unexpected labels: %v", i, pvc.ObjectMeta.Labels)
+		}
+	}
+
+	// a tablespace without storage cannot be restored
+	if err := CreatePVCs(fake.NewSimpleClientset(), snapshot, "rhino", "rhino", "", storage,
+		map[string]crv1.PgStorageSpec{}, "pgo"); err == nil {
+		t.Fatalf("expected an error for a tablespace without storage")
+	}
+}
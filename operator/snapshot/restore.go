@@ -0,0 +1,253 @@
+package snapshot
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	"github.com/crunchydata/postgres-operator/operator"
+	"github.com/crunchydata/postgres-operator/util"
+	log "github.com/sirupsen/logrus"
+	batch_v1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// restoreJobNameFormat is the format of the name of the job that prepares the
+// PGDATA PVC that was provisioned from a snapshot backup, which is suffixed
+// with the name of the PVC
+const restoreJobNameFormat = "snapshot-restore-%s"
+
+// snapshotRestoreJobTemplateFields are the fields of the snapshot restore job
+// template
+type snapshotRestoreJobTemplateFields struct {
+	JobName             string
+	ClusterName         string
+	SnapshotName        string
+	PVCName             string
+	SecurityContext     string
+	CCPImagePrefix      string
+	CCPImage            string
+	CCPImageTag         string
+	SourceDataDirectory string
+	TargetDataDirectory string
+	EnableRecovery      string
+	PgbackrestStanza    string
+	RecoveryTarget      string
+	RecoveryTargetType  string
+	WorkflowID          string
+}
+
+// RestoreJob holds the information needed to create the job that turns the
+// data directory in a PGDATA PVC that was provisioned from a snapshot backup
+// into the data directory of a new instance
+type RestoreJob struct {
+	// Cluster is the cluster that the snapshot backup was taken of
+	Cluster *crv1.Pgcluster
+	// Snapshot is the snapshot backup the PVC was provisioned from
+	Snapshot *crv1.PgclusterSnapshot
+	// ClusterName is the name of the cluster the new instance belongs to
+	ClusterName string
+	// PVCName is the name of the PGDATA PVC, which is also the name of the
+	// new instance and therefore of its data directory
+	PVCName string
+	// EnableRecovery, if set, configures the data directory to replay the WAL
+	// from the pgBackRest archive and to promote once it is done, optionally
+	// stopping at the recovery target. This is not needed for a replica, which
+	// is configured to follow the primary when it starts
+	EnableRecovery     bool
+	RecoveryTarget     string
+	RecoveryTargetType string
+	WorkflowID         string
+}
+
+// FindSnapshot returns the snapshot backup with the given name from the catalog
+// of a cluster
+func FindSnapshot(cluster *crv1.Pgcluster, name string) (*crv1.PgclusterSnapshot, bool) {
+	for i := range cluster.Status.Snapshots {
+		if cluster.Status.Snapshots[i].Name == name {
+			return &cluster.Status.Snapshots[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// GetRestoreJobName returns the name of the snapshot restore job for a PGDATA
+// PVC
+func GetRestoreJobName(pvcName string) string {
+	return fmt.Sprintf(restoreJobNameFormat, pvcName)
+}
+
+// CreatePVCs provisions the PGDATA PVC and any tablespace PVCs of a new instance
+// from a snapshot backup. The PVCs use the given storage specs, and the size of
+// the PGDATA PVC can be overridden. PVCs that already exist are left alone
+func CreatePVCs(clientset kubernetes.Interface, snapshot *crv1.PgclusterSnapshot, clusterName, pvcName,
+	pvcSize string, storage crv1.PgStorageSpec, tablespaceStorage map[string]crv1.PgStorageSpec,
+	namespace string) error {
+	if pvcSize != "" {
+		storage.Size = pvcSize
+	}
+
+	if err := createPVCFromSnapshot(clientset, snapshot.PGDataSnapshot, pvcName, clusterName,
+		storage, namespace); err != nil {
+		return err
+	}
+
+	for tablespaceName, snapshotName := range snapshot.TablespaceSnapshots {
+		tablespaceStorageSpec, ok := tablespaceStorage[tablespaceName]
+		if !ok {
+			return fmt.Errorf("no storage is configured for tablespace %s", tablespaceName)
+		}
+
+		if err := createPVCFromSnapshot(clientset, snapshotName,
+			operator.GetTablespacePVCName(pvcName, tablespaceName), clusterName,
+			tablespaceStorageSpec, namespace); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createPVCFromSnapshot creates a PVC whose data source is a VolumeSnapshot. If
+// the PVC already exists, it is not recreated
+func createPVCFromSnapshot(clientset kubernetes.Interface, snapshotName, pvcName, clusterName string,
+	storage crv1.PgStorageSpec, namespace string) error {
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(namespace).Get(pvcName,
+		meta_v1.GetOptions{}); err == nil {
+		log.Debugf("pvc %s found, will NOT recreate from snapshot", pvcName)
+		return nil
+	} else if !kerrors.IsNotFound(err) {
+		return err
+	}
+
+	size, err := resource.ParseQuantity(storage.Size)
+	if err != nil {
+		return fmt.Errorf("invalid size %q for pvc %s: %s", storage.Size, pvcName, err)
+	}
+
+	apiGroup := kubeapi.VolumeSnapshotAPIGroup
+
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: pvcName,
+			Labels: map[string]string{
+				config.LABEL_VENDOR:     config.LABEL_CRUNCHY,
+				config.LABEL_PGREMOVE:   "true",
+				config.LABEL_PG_CLUSTER: clusterName,
+			},
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes: []v1.PersistentVolumeAccessMode{v1.PersistentVolumeAccessMode(storage.AccessMode)},
+			DataSource: &v1.TypedLocalObjectReference{
+				APIGroup: &apiGroup,
+				Kind:     kubeapi.VolumeSnapshotKind,
+				Name:     snapshotName,
+			},
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: size},
+			},
+		},
+	}
+
+	if storage.StorageClass != "" {
+		pvc.Spec.StorageClassName = &storage.StorageClass
+	}
+
+	if _, err := clientset.CoreV1().PersistentVolumeClaims(namespace).Create(pvc); err != nil {
+		log.Error("error creating pvc " + err.Error() + " in namespace " + namespace)
+		return err
+	}
+
+	log.Debugf("created pvc %s from volume snapshot %s", pvcName, snapshotName)
+
+	return nil
+}
+
+// Job returns the snapshot restore job. The job renames the data directory of
+// the instance the snapshot was taken of to that of the new instance, and, if
+// requested, configures the recovery from the pgBackRest archive
+func (r RestoreJob) Job() (*batch_v1.Job, error) {
+	fields := snapshotRestoreJobTemplateFields{
+		JobName:      GetRestoreJobName(r.PVCName),
+		ClusterName:  r.ClusterName,
+		SnapshotName: r.Snapshot.Name,
+		PVCName:      r.PVCName,
+		SecurityContext: util.GetPodSecurityContext(
+			r.Cluster.Spec.PrimaryStorage.GetSupplementalGroups()),
+		CCPImagePrefix:      operator.Pgo.Cluster.CCPImagePrefix,
+		CCPImage:            r.Cluster.Spec.CCPImage,
+		CCPImageTag:         r.Cluster.Spec.CCPImageTag,
+		SourceDataDirectory: r.Snapshot.PGDataDirectory,
+		TargetDataDirectory: r.PVCName,
+		EnableRecovery:      "false",
+		PgbackrestStanza:    "db",
+		WorkflowID:          r.WorkflowID,
+	}
+
+	if r.EnableRecovery {
+		fields.EnableRecovery = "true"
+		fields.RecoveryTargetType = r.RecoveryTargetType
+		// the recovery target is written into a single quoted setting, and is
+		// a string in the JSON of the template
+		recoveryTarget, err := escapeJSONString(strings.Replace(r.RecoveryTarget, "'", "''", -1))
+		if err != nil {
+			return nil, err
+		}
+		fields.RecoveryTarget = recoveryTarget
+	}
+
+	var doc bytes.Buffer
+
+	if err := config.SnapshotRestorejobTemplate.Execute(&doc, fields); err != nil {
+		return nil, err
+	}
+
+	if operator.CRUNCHY_DEBUG {
+		config.SnapshotRestorejobTemplate.Execute(os.Stdout, fields)
+	}
+
+	job := &batch_v1.Job{}
+
+	if err := json.Unmarshal(doc.Bytes(), job); err != nil {
+		log.Error("error unmarshalling json into Job " + err.Error())
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// escapeJSONString escapes a value that is placed inside of a string of a JSON
+// template, i.e. without the surrounding quotes
+func escapeJSONString(value string) (string, error) {
+	doc, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(doc[1 : len(doc)-1]), nil
+}
@@ -0,0 +1,48 @@
+package snapshot
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEscapeJSONString(t *testing.T) {
+	tests := []struct {
+		value, expected string
+	}{
+		{"2020-06-19 20:35:02+00", "2020-06-19 20:35:02+00"},
+		{`before "upgrade"`, `before \"upgrade\"`},
+		{`C:\backup`, `C:\\backup`},
+	}
+
+	for i, test := range tests {
+		escaped, err := escapeJSONString(test.value)
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %s", i, err)
+		}
+
+		if escaped != test.expected {
+			t.Fatalf("tests[%d] - expected %q, got %q", i, test.expected, escaped)
+		}
+
+		// the escaped value has to be a valid JSON string of the value
+		var value string
+		if err := json.Unmarshal([]byte(`"`+escaped+`"`), &value); err != nil || value != test.value {
+			t.Fatalf("tests[%d] - expected %q to unmarshal to %q, got %q: %v", i, escaped, test.value, value, err)
+		}
+	}
+}
@@ -54,6 +54,17 @@ type rmdatajobTemplateFields struct {
 // RemoveData ...
 func RemoveData(namespace string, clientset *kubernetes.Clientset, restclient *rest.RESTClient, task *crv1.Pgtask) {
 
+	// refuse to remove a cluster that is protected from being deleted. The task
+	// is removed so the cluster can be deleted once the protection is disabled
+	if isProtectedCluster(restclient, task, namespace) {
+		log.Errorf("deletion protection is enabled for cluster %s, not removing it",
+			task.Spec.Parameters[config.LABEL_PG_CLUSTER])
+		if err := kubeapi.Deletepgtask(restclient, task.Spec.Name, namespace); err != nil {
+			log.Error(err)
+		}
+		return
+	}
+
 	//create marker (clustername, namespace)
 	err := PatchpgtaskDeleteDataStatus(restclient, task, namespace)
 	if err != nil {
@@ -170,6 +181,22 @@ func PatchpgtaskDeleteDataStatus(restclient *rest.RESTClient, oldCrd *crv1.Pgtas
 
 }
 
+// isProtectedCluster returns true if a delete data task removes a whole
+// cluster, i.e. not only a replica or its backups, and that cluster has
+// deletion protection enabled
+func isProtectedCluster(restclient *rest.RESTClient, task *crv1.Pgtask, namespace string) bool {
+	if task.Spec.Parameters[config.LABEL_IS_REPLICA] == "true" ||
+		task.Spec.Parameters[config.LABEL_IS_BACKUP] == "true" {
+		return false
+	}
+
+	cluster := crv1.Pgcluster{}
+	found, _ := kubeapi.Getpgcluster(restclient, &cluster,
+		task.Spec.Parameters[config.LABEL_PG_CLUSTER], namespace)
+
+	return found && cluster.Spec.DeletionProtection
+}
+
 func publishDeleteCluster(clusterName, identifier, username, namespace string) {
 	topics := make([]string, 1)
 	topics[0] = events.EventTopicCluster
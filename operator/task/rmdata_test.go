@@ -0,0 +1,84 @@
+package task
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/util"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestIsProtectedCluster(t *testing.T) {
+	tests := []struct {
+		exists     bool
+		protected  bool
+		parameters map[string]string
+		expected   bool
+	}{
+		{true, true, map[string]string{}, true},
+		{true, false, map[string]string{}, false},
+		{false, false, map[string]string{}, false},
+		{true, true, map[string]string{config.LABEL_IS_REPLICA: "true"}, false},
+		{true, true, map[string]string{config.LABEL_IS_BACKUP: "true"}, false},
+		{true, true, map[string]string{config.LABEL_IS_REPLICA: "false", config.LABEL_IS_BACKUP: "false"}, true},
+	}
+
+	for i, test := range tests {
+		cluster, err := json.Marshal(crv1.Pgcluster{
+			TypeMeta:   meta_v1.TypeMeta{APIVersion: "crunchydata.com/v1", Kind: "Pgcluster"},
+			ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo"},
+			Spec:       crv1.PgclusterSpec{DeletionProtection: test.protected},
+		})
+		if err != nil {
+			t.Fatalf("tests[%d] - %s", i, err)
+		}
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+
+			if !test.exists || r.URL.Path != "/apis/crunchydata.com/v1/namespaces/pgo/pgclusters/hippo" {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`))
+				return
+			}
+
+			w.Write(cluster)
+		}))
+
+		restclient, _, err := util.NewClient(&rest.Config{Host: server.URL})
+		if err != nil {
+			server.Close()
+			t.Fatalf("tests[%d] - %s", i, err)
+		}
+
+		test.parameters[config.LABEL_PG_CLUSTER] = "hippo"
+		task := &crv1.Pgtask{Spec: crv1.PgtaskSpec{Parameters: test.parameters}}
+
+		protected := isProtectedCluster(restclient, task, "pgo")
+		server.Close()
+
+		if protected != test.expected {
+			t.Fatalf("tests[%d] - expected %t, got %t", i, test.expected, protected)
+		}
+	}
+}
@@ -30,8 +30,9 @@ const backrestCommand = "pgbackrest"
 const backrestBackupCommand = `backup`
 const backrestInfoCommand = `info`
 const backrestStanzaCreateCommand = `stanza-create`
+const backrestStanzaUpgradeCommand = `stanza-upgrade`
 const containername = "database"
-const repoTypeFlagS3 = "--repo-type=s3"
+const repoTypeFlag = "--repo-type="
 
 func main() {
 	log.Info("pgo-backrest starts")
@@ -71,11 +72,21 @@ func main() {
 	REPO_TYPE := os.Getenv("PGBACKREST_REPO_TYPE")
 	log.Debugf("setting REPO_TYPE to %s", REPO_TYPE)
 
-	// determine the setting of PGHA_PGBACKREST_LOCAL_S3_STORAGE
-	// we will discard the error and treat the value as "false" if it is not
+	// determine if local storage is enabled along with one of the cloud storage
+	// types, i.e. if PGHA_PGBACKREST_LOCAL_S3_STORAGE,
+	// PGHA_PGBACKREST_LOCAL_GCS_STORAGE or PGHA_PGBACKREST_LOCAL_AZURE_STORAGE is
+	// set. we will discard the error and treat the value as "false" if it is not
 	// explicitly set
-	PGHA_PGBACKREST_LOCAL_S3_STORAGE, _ := strconv.ParseBool(os.Getenv("PGHA_PGBACKREST_LOCAL_S3_STORAGE"))
-	log.Debugf("setting PGHA_PGBACKREST_LOCAL_S3_STORAGE to %v", PGHA_PGBACKREST_LOCAL_S3_STORAGE)
+	localAndCloudStorage := ""
+	for _, cloudStorageType := range crv1.BackrestCloudStorageTypes {
+		envVar := "PGHA_PGBACKREST_LOCAL_" + strings.ToUpper(cloudStorageType) + "_STORAGE"
+		enabled, _ := strconv.ParseBool(os.Getenv(envVar))
+		log.Debugf("setting %s to %v", envVar, enabled)
+
+		if enabled {
+			localAndCloudStorage = cloudStorageType
+		}
+	}
 
 	config, clientset, err := kubeapi.NewClient()
 	if err != nil {
@@ -92,6 +103,11 @@ func main() {
 		cmdStrs = append(cmdStrs, backrestCommand)
 		cmdStrs = append(cmdStrs, backrestStanzaCreateCommand)
 		cmdStrs = append(cmdStrs, COMMAND_OPTS)
+	case crv1.PgtaskBackrestStanzaUpgrade:
+		log.Info("backrest stanza-upgrade command requested")
+		cmdStrs = append(cmdStrs, backrestCommand)
+		cmdStrs = append(cmdStrs, backrestStanzaUpgradeCommand)
+		cmdStrs = append(cmdStrs, COMMAND_OPTS)
 	case crv1.PgtaskBackrestInfo:
 		log.Info("backrest info command requested")
 		cmdStrs = append(cmdStrs, backrestCommand)
@@ -107,15 +123,15 @@ func main() {
 		os.Exit(2)
 	}
 
-	if PGHA_PGBACKREST_LOCAL_S3_STORAGE {
+	if localAndCloudStorage != "" {
 		firstCmd := cmdStrs
 		cmdStrs = append(cmdStrs, "&&")
 		cmdStrs = append(cmdStrs, strings.Join(firstCmd, " "))
-		cmdStrs = append(cmdStrs, repoTypeFlagS3)
-		log.Info("backrest command will be executed for both local and s3 storage")
-	} else if REPO_TYPE == "s3" {
-		cmdStrs = append(cmdStrs, repoTypeFlagS3)
-		log.Info("s3 flag enabled for backrest command")
+		cmdStrs = append(cmdStrs, repoTypeFlag+localAndCloudStorage)
+		log.Infof("backrest command will be executed for both local and %s storage", localAndCloudStorage)
+	} else if isCloudRepoType(REPO_TYPE) {
+		cmdStrs = append(cmdStrs, repoTypeFlag+REPO_TYPE)
+		log.Infof("%s flag enabled for backrest command", REPO_TYPE)
 	}
 
 	log.Infof("command to execute is [%s]", strings.Join(cmdStrs, " "))
@@ -135,3 +151,14 @@ func main() {
 	log.Info("pgo-backrest ends")
 
 }
+
+// isCloudRepoType returns true if the repo type is one of the cloud storage
+// types, i.e. "s3", "gcs" or "azure"
+func isCloudRepoType(repoType string) bool {
+	for _, cloudStorageType := range crv1.BackrestCloudStorageTypes {
+		if repoType == cloudStorageType {
+			return true
+		}
+	}
+	return false
+}
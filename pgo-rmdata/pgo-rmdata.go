@@ -74,6 +74,10 @@ func main() {
 	log.Infoln("pgo-rmdata starts")
 	log.Infof("request is %s", request.String())
 
-	rmdata.Delete(request)
+	// exit with an error if the cluster could not be fully removed, so the
+	// failure shows on the job
+	if err := rmdata.Delete(request); err != nil {
+		log.Fatalln(err.Error())
+	}
 
 }
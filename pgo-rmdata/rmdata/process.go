@@ -29,6 +29,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	kerror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
 
 	"time"
 )
@@ -44,7 +45,10 @@ const (
 	tablespaceReplicaPVCPattern = "%s-tablespace-"
 )
 
-func Delete(request Request) {
+// Delete removes the resources of a cluster, a replica or a backup, depending
+// on the request. An error is returned if the resources of a cluster could not
+// all be removed, in which case the finalizer of its pgcluster is kept
+func Delete(request Request) error {
 	log.Infof("rmdata.Process %v", request)
 
 	// if, check to see if this is a full cluster removal...i.e. "IsReplica"
@@ -74,7 +78,7 @@ func Delete(request Request) {
 			// is no longer a primary, and has become a replica.
 			if !(request.ReplicaName == request.ClusterPGHAScope && kerror.IsNotFound(err)) {
 				log.Error(err)
-				return
+				return nil
 			}
 			log.Debug("replica name matches PGHA scope, assuming scale down of original primary" +
 				"and therefore ignoring error attempting to delete nonexistent pgreplica")
@@ -94,7 +98,7 @@ func Delete(request Request) {
 		}
 
 		//scale down is its own use case so we leave when done
-		return
+		return nil
 	}
 
 	if request.IsBackup {
@@ -105,7 +109,7 @@ func Delete(request Request) {
 		removeLogicalBackupPVCs(request)
 		// this is the special case of removing an ad hoc backup removal, so we can
 		// exit here
-		return
+		return nil
 	}
 
 	log.Info("rmdata.Process cluster use case")
@@ -114,6 +118,10 @@ func Delete(request Request) {
 	// executing asynchronously against any stale data
 	removeSchedules(request)
 
+	// the subscriptions from and to the cluster are dropped while it is still
+	// running, so that no replication slot is left behind on a source cluster
+	removeLogicalReplication(request)
+
 	//the user had done something like:
 	//pgo delete cluster mycluster --delete-data
 	if request.RemoveData {
@@ -127,6 +135,8 @@ func Delete(request Request) {
 		log.Error(err)
 	}
 	removeServices(request)
+	removeNetworkPolicies(request)
+	removePodDisruptionBudgets(request)
 	removeAddons(request)
 	removePgreplicas(request)
 	removePgtasks(request)
@@ -161,6 +171,14 @@ func Delete(request Request) {
 		removeBackupSecrets(request)
 		removeAllBackupPVCs(request)
 	}
+
+	// finally, if everything that was to be removed is gone, remove the
+	// finalizer so that the pgcluster itself is deleted
+	if err := verifyClusterRemoved(request); err != nil {
+		return err
+	}
+
+	return removeClusterFinalizer(request)
 }
 
 // deleteClusterData calls a series of commands to attempt to "safely" delete
@@ -558,6 +576,132 @@ func removeServices(request Request) {
 
 }
 
+// removeNetworkPolicies removes the NetworkPolicies that the Operator created
+// for the cluster
+func removeNetworkPolicies(request Request) {
+	selector := fmt.Sprintf("%s=%s,%s=%s", config.LABEL_PG_CLUSTER, request.ClusterName,
+		config.LABEL_VENDOR, config.LABEL_CRUNCHY)
+
+	policies, err := kubeapi.GetNetworkPolicies(request.Clientset, selector, request.Namespace)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	for _, policy := range policies.Items {
+		if err := kubeapi.DeleteNetworkPolicy(request.Clientset, policy.Name, request.Namespace); err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+// removePodDisruptionBudgets removes the PodDisruptionBudgets that the Operator
+// created for the cluster, e.g. for pgBouncer
+func removePodDisruptionBudgets(request Request) {
+	selector := fmt.Sprintf("%s=%s,%s=%s", config.LABEL_PG_CLUSTER, request.ClusterName,
+		config.LABEL_VENDOR, config.LABEL_CRUNCHY)
+
+	pdbs, err := kubeapi.GetPodDisruptionBudgets(request.Clientset, selector, request.Namespace)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	for _, pdb := range pdbs.Items {
+		if err := kubeapi.DeletePodDisruptionBudget(request.Clientset, pdb.Name, request.Namespace); err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+// removeLogicalReplication drops the subscriptions of the cluster, as well as
+// the subscriptions of other clusters to its publications, along with their
+// replication slots, and removes them from the pgclusters of the other
+// clusters
+func removeLogicalReplication(request Request) {
+	cluster := crv1.Pgcluster{}
+	if found, _ := kubeapi.Getpgcluster(request.RESTClient, &cluster, request.ClusterName,
+		request.Namespace); !found {
+		return
+	}
+
+	for _, subscription := range cluster.Spec.Subscriptions {
+		if err := util.DropSubscription(request.Clientset, request.RESTConfig, request.Namespace,
+			cluster.Name, subscription); err != nil {
+			log.Error(err)
+		}
+
+		updateLogicalReplicationPeer(request, subscription.SourceCluster, func(source *crv1.Pgcluster) {
+			if publication := source.Spec.GetPublication(subscription.Publication); publication != nil {
+				subscribers := []string{}
+				for _, subscriber := range publication.Subscribers {
+					if subscriber != cluster.Name {
+						subscribers = append(subscribers, subscriber)
+					}
+				}
+				publication.Subscribers = subscribers
+			}
+		})
+	}
+
+	targets := map[string]struct{}{}
+	for _, publication := range cluster.Spec.Publications {
+		for _, subscriber := range publication.Subscribers {
+			targets[subscriber] = struct{}{}
+		}
+	}
+
+	for target := range targets {
+		updateLogicalReplicationPeer(request, target, func(target *crv1.Pgcluster) {
+			subscriptions := []crv1.SubscriptionSpec{}
+			removed := map[string]struct{}{}
+
+			for _, subscription := range target.Spec.Subscriptions {
+				if subscription.SourceCluster != cluster.Name {
+					subscriptions = append(subscriptions, subscription)
+					continue
+				}
+
+				if err := util.DropSubscription(request.Clientset, request.RESTConfig, request.Namespace,
+					target.Name, subscription); err != nil {
+					log.Error(err)
+				}
+				removed[subscription.Name] = struct{}{}
+			}
+
+			status := []crv1.SubscriptionStatus{}
+			for _, subscription := range target.Status.Subscriptions {
+				if _, ok := removed[subscription.Name]; !ok {
+					status = append(status, subscription)
+				}
+			}
+
+			target.Spec.Subscriptions = subscriptions
+			target.Status.Subscriptions = status
+		})
+	}
+}
+
+// updateLogicalReplicationPeer applies a change to the pgcluster of a cluster
+// that the cluster being removed replicates from or to, if it still exists
+func updateLogicalReplicationPeer(request Request, name string, change func(*crv1.Pgcluster)) {
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		peer := crv1.Pgcluster{}
+		if found, err := kubeapi.Getpgcluster(request.RESTClient, &peer, name, request.Namespace); !found {
+			if kerror.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+
+		change(&peer)
+
+		return kubeapi.Updatepgcluster(request.RESTClient, &peer, name, request.Namespace)
+	}); err != nil {
+		log.Error(err)
+	}
+}
+
 func removePgreplicas(request Request) {
 	replicaList := crv1.PgreplicaList{}
 
@@ -852,3 +996,71 @@ func rmDataDir(request Request, podName, containerName, path string) (string, st
 		podName,
 		request.Namespace, nil)
 }
+
+// verifyClusterRemoved ensures that the deployments of the cluster, and the
+// PVCs that were to be removed, are either gone or being deleted
+func verifyClusterRemoved(request Request) error {
+	selector := fmt.Sprintf("%s=%s", config.LABEL_PG_CLUSTER, request.ClusterName)
+
+	deployments, err := kubeapi.GetDeployments(request.Clientset, selector, request.Namespace)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range deployments.Items {
+		if d.ObjectMeta.DeletionTimestamp == nil {
+			return fmt.Errorf("deployment %s was not removed", d.ObjectMeta.Name)
+		}
+	}
+
+	pvcs, err := kubeapi.GetPVCs(request.Clientset, selector, request.Namespace)
+	if err != nil {
+		return err
+	}
+
+	pgDump, pgBackRest := fmt.Sprintf(pgDumpPVC, request.ClusterName),
+		fmt.Sprintf(pgBackRestRepoPVC, request.ClusterName)
+
+	for _, pvc := range pvcs.Items {
+		pvcName := pvc.ObjectMeta.Name
+		isBackupPVC := pvcName == pgDump || pvcName == pgBackRest
+
+		if pvc.ObjectMeta.DeletionTimestamp != nil ||
+			(isBackupPVC && !request.RemoveBackup) || (!isBackupPVC && !request.RemoveData) {
+			continue
+		}
+
+		return fmt.Errorf("pvc %s was not removed", pvcName)
+	}
+
+	return nil
+}
+
+// removeClusterFinalizer removes the finalizer from the pgcluster, which lets
+// Kubernetes delete it
+func removeClusterFinalizer(request Request) error {
+	cluster := crv1.Pgcluster{}
+	found, err := kubeapi.Getpgcluster(request.RESTClient, &cluster, request.ClusterName, request.Namespace)
+	if !found {
+		// the pgcluster is already gone if it did not have the finalizer
+		if kerror.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	finalizers := []string{}
+	for _, finalizer := range cluster.ObjectMeta.Finalizers {
+		if finalizer != crv1.PgclusterFinalizer {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+
+	if len(finalizers) == len(cluster.ObjectMeta.Finalizers) {
+		return nil
+	}
+
+	log.Debugf("removing finalizer from pgcluster %s", request.ClusterName)
+
+	return kubeapi.PatchpgclusterFinalizers(request.RESTClient, finalizers, &cluster)
+}
@@ -0,0 +1,106 @@
+package rmdata
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/util"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestRemoveClusterFinalizer(t *testing.T) {
+	tests := []struct {
+		description string
+		exists      bool
+		finalizers  []string
+		patched     bool
+		expected    []string
+	}{
+		{"removed", true, []string{crv1.PgclusterFinalizer}, true, []string{}},
+		{"other finalizers kept", true, []string{"other", crv1.PgclusterFinalizer}, true, []string{"other"}},
+		{"no finalizer", true, []string{"other"}, false, nil},
+		{"pgcluster gone", false, nil, false, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			cluster, err := json.Marshal(crv1.Pgcluster{
+				TypeMeta: meta_v1.TypeMeta{APIVersion: "crunchydata.com/v1", Kind: "Pgcluster"},
+				ObjectMeta: meta_v1.ObjectMeta{Name: "hippo", Namespace: "pgo",
+					Finalizers: test.finalizers},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			patch := ""
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+
+				if !test.exists || r.URL.Path != "/apis/crunchydata.com/v1/namespaces/pgo/pgclusters/hippo" {
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`))
+					return
+				}
+
+				if r.Method == http.MethodPatch {
+					body, _ := ioutil.ReadAll(r.Body)
+					patch = string(body)
+				}
+
+				w.Write(cluster)
+			}))
+			defer server.Close()
+
+			restclient, _, err := util.NewClient(&rest.Config{Host: server.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			request := Request{RESTClient: restclient, ClusterName: "hippo", Namespace: "pgo"}
+
+			if err := removeClusterFinalizer(request); err != nil {
+				t.Fatal(err)
+			}
+
+			if (patch != "") != test.patched {
+				t.Fatalf("expected patched %t, got %q", test.patched, patch)
+			}
+
+			if !test.patched {
+				return
+			}
+
+			patched := crv1.Pgcluster{}
+			if err := json.Unmarshal([]byte(patch), &patched); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(patched.Finalizers, test.expected) {
+				t.Fatalf("expected finalizers %v, got %v", test.expected, patched.Finalizers)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package scheduler
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+	"github.com/crunchydata/postgres-operator/config"
+	"github.com/crunchydata/postgres-operator/kubeapi"
+	"github.com/crunchydata/postgres-operator/util"
+	log "github.com/sirupsen/logrus"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// hibernateRetryInterval is how long a skipped shutdown waits before it
+	// is attempted again. The interval doubles with every attempt, up to
+	// hibernateMaxRetryInterval
+	hibernateRetryInterval    = time.Minute
+	hibernateMaxRetryInterval = 30 * time.Minute
+)
+
+type HibernateJob struct {
+	namespace string
+	cluster   string
+	shutdown  bool
+	stop      string
+	start     string
+}
+
+func (s *ScheduleTemplate) NewHibernateSchedule(shutdown bool) HibernateJob {
+	return HibernateJob{
+		namespace: s.Namespace,
+		cluster:   s.Cluster,
+		shutdown:  shutdown,
+		stop:      s.Hibernate.Stop,
+		start:     s.Hibernate.Start,
+	}
+}
+
+// Run shuts the cluster down or starts it again. A shutdown is skipped if the
+// cluster is in use, i.e. if there are active connections or a backup is
+// running, and is attempted again with a backoff until the cluster is due to
+// be started again. The next stop and start times are recorded in the status
+// of the pgcluster, along with the reason a shutdown was skipped
+func (h HibernateJob) Run() {
+	h.run(0)
+}
+
+func (h HibernateJob) run(attempt int) {
+	contextLogger := log.WithFields(log.Fields{
+		"namespace": h.namespace,
+		"cluster":   h.cluster,
+		"shutdown":  h.shutdown,
+		"attempt":   attempt})
+
+	contextLogger.Info("Running hibernate schedule")
+
+	cluster := crv1.Pgcluster{}
+	found, err := kubeapi.Getpgcluster(restClient, &cluster, h.cluster, h.namespace)
+	if !found {
+		contextLogger.WithFields(log.Fields{
+			"error": err,
+		}).Error("pgCluster not found")
+		return
+	} else if err != nil {
+		contextLogger.WithFields(log.Fields{
+			"error": err,
+		}).Error("error retrieving pgCluster")
+		return
+	}
+
+	if cluster.Spec.Shutdown == h.shutdown {
+		contextLogger.Info("cluster is already in the requested state")
+		h.updateStatus("")
+		return
+	}
+
+	if h.shutdown {
+		if reason := getShutdownBlocker(&cluster); reason != "" {
+			h.retryShutdown(attempt, reason)
+			return
+		}
+	}
+
+	cluster.Spec.Shutdown = h.shutdown
+
+	if err := kubeapi.Updatepgcluster(restClient, &cluster, cluster.Name, h.namespace); err != nil {
+		contextLogger.WithFields(log.Fields{
+			"error": err,
+		}).Error("could not update pgCluster")
+		return
+	}
+
+	h.updateStatus("")
+}
+
+// retryShutdown attempts a skipped shutdown again after a backoff, unless the
+// cluster is due to be started again by then
+func (h HibernateJob) retryShutdown(attempt int, reason string) {
+	now := time.Now()
+	retryAt := now.Add(hibernateRetryDelay(attempt))
+	message := "shutdown skipped: " + reason
+
+	if next, err := util.NextScheduleTime(h.start, now); err == nil && retryAt.Before(next) {
+		message += ", retrying at " + retryAt.Format(time.RFC3339)
+		time.AfterFunc(retryAt.Sub(now), func() { h.run(attempt + 1) })
+	}
+
+	log.WithFields(log.Fields{
+		"namespace": h.namespace,
+		"cluster":   h.cluster,
+	}).Info(message)
+
+	h.updateStatus(message)
+}
+
+// updateStatus records the next stop and start times of the cluster in the
+// status of its pgcluster, along with a message, if any
+func (h HibernateJob) updateStatus(message string) {
+	status := getHibernateStatus(h.stop, h.start, time.Now(), message)
+
+	if err := kubeapi.PatchpgclusterHibernateStatus(restClient, status, h.cluster, h.namespace); err != nil {
+		log.WithFields(log.Fields{
+			"namespace": h.namespace,
+			"cluster":   h.cluster,
+			"error":     err,
+		}).Error("could not update the hibernate status of pgCluster")
+	}
+}
+
+// hibernateRetryDelay returns how long a shutdown that has been skipped the
+// given number of times before waits to be attempted again
+func hibernateRetryDelay(attempt int) time.Duration {
+	delay := hibernateRetryInterval
+	for i := 0; i < attempt && delay < hibernateMaxRetryInterval; i++ {
+		delay *= 2
+	}
+
+	if delay > hibernateMaxRetryInterval {
+		return hibernateMaxRetryInterval
+	}
+	return delay
+}
+
+// getHibernateStatus returns the state of a hibernate schedule at the given
+// time, i.e. when its stop and start schedules run next
+func getHibernateStatus(stop, start string, now time.Time, message string) *crv1.PgclusterHibernateStatus {
+	status := &crv1.PgclusterHibernateStatus{Message: message}
+
+	if next, err := util.NextScheduleTime(stop, now); err == nil {
+		status.NextStop = &meta_v1.Time{Time: next}
+	}
+	if next, err := util.NextScheduleTime(start, now); err == nil {
+		status.NextStart = &meta_v1.Time{Time: next}
+	}
+
+	return status
+}
+
+// getShutdownBlocker returns why a cluster cannot be shut down right now, i.e.
+// because a backup is running or there are active connections, or an empty
+// string if it can be
+func getShutdownBlocker(cluster *crv1.Pgcluster) string {
+	if running, err := isBackupRunning(cluster); err != nil {
+		return fmt.Sprintf("could not determine if a backup is running: %s", err)
+	} else if running {
+		return "a backup is running"
+	}
+
+	if connections, err := getActiveConnections(cluster); err != nil {
+		return fmt.Sprintf("could not determine the active connections: %s", err)
+	} else if connections > 0 {
+		return fmt.Sprintf("%d active connections", connections)
+	}
+
+	return ""
+}
+
+// isBackupRunning returns true if a pgBackRest backup job of the cluster is
+// still active
+func isBackupRunning(cluster *crv1.Pgcluster) (bool, error) {
+	selector := fmt.Sprintf("%s=%s,%s=true,%s=%s", config.LABEL_PG_CLUSTER, cluster.Name,
+		config.LABEL_BACKREST_JOB, config.LABEL_BACKREST_COMMAND, crv1.PgtaskBackrestBackup)
+
+	jobs, err := kubeapi.GetJobs(kubeClient, selector, cluster.Namespace)
+	if err != nil {
+		return false, err
+	}
+
+	for _, job := range jobs.Items {
+		if job.Status.Active > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// getActiveConnections returns the number of connections to the primary of the
+// cluster that are not idle, excluding the connections of the system accounts
+func getActiveConnections(cluster *crv1.Pgcluster) (int, error) {
+	pod, err := util.GetPrimaryPod(kubeClient, cluster)
+	if err != nil {
+		return 0, err
+	}
+
+	stdout, stderr, err := kubeapi.ExecToPodThroughAPI(restConfig, kubeClient,
+		[]string{"psql", "-qAtX", "-f", "-"}, "database", pod.Name, cluster.Namespace,
+		strings.NewReader(activeConnectionsSQL()))
+	if err != nil {
+		return 0, err
+	} else if stderr != "" {
+		return 0, fmt.Errorf(stderr)
+	}
+
+	return strconv.Atoi(strings.TrimSpace(stdout))
+}
+
+// activeConnectionsSQL returns the query that counts the connections that are
+// not idle, excluding the connections of the system accounts
+func activeConnectionsSQL() string {
+	accounts := make([]string, 0, len(crv1.PGUserSystemAccounts))
+	for account := range crv1.PGUserSystemAccounts {
+		accounts = append(accounts, util.SQLQuoteLiteral(account))
+	}
+	sort.Strings(accounts)
+
+	return "SELECT count(*) FROM pg_catalog.pg_stat_activity" +
+		" WHERE pid <> pg_catalog.pg_backend_pid() AND state <> 'idle'" +
+		" AND usename NOT IN (" + strings.Join(accounts, ", ") + ");"
+}
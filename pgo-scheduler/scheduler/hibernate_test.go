@@ -0,0 +1,78 @@
+package scheduler
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHibernateRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{0, time.Minute},
+		{1, 2 * time.Minute},
+		{4, 16 * time.Minute},
+		{5, 30 * time.Minute},
+		{100, 30 * time.Minute},
+	}
+
+	for i, test := range tests {
+		if delay := hibernateRetryDelay(test.attempt); delay != test.expected {
+			t.Fatalf("tests[%d] - expected %s, got %s", i, test.expected, delay)
+		}
+	}
+}
+
+func TestGetHibernateStatus(t *testing.T) {
+	// a Wednesday
+	now := time.Date(2020, time.June, 17, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		stop, start string
+		message     string
+		nextStop    time.Time
+		nextStart   time.Time
+	}{
+		{"0 19 * * 1-5", "0 7 * * 1-5", "",
+			time.Date(2020, time.June, 17, 19, 0, 0, 0, time.UTC),
+			time.Date(2020, time.June, 18, 7, 0, 0, 0, time.UTC)},
+		{"0 19 * * 5", "0 7 * * 1", "shutdown skipped: a backup is running",
+			time.Date(2020, time.June, 19, 19, 0, 0, 0, time.UTC),
+			time.Date(2020, time.June, 22, 7, 0, 0, 0, time.UTC)},
+		{"60 * * * *", "0 7 * * 1-5", "", time.Time{},
+			time.Date(2020, time.June, 18, 7, 0, 0, 0, time.UTC)},
+	}
+
+	for i, test := range tests {
+		status := getHibernateStatus(test.stop, test.start, now, test.message)
+
+		if status.Message != test.message {
+			t.Fatalf("tests[%d] - expected message %q, got %q", i, test.message, status.Message)
+		}
+
+		if test.nextStop.IsZero() != (status.NextStop == nil) ||
+			(status.NextStop != nil && !status.NextStop.Time.Equal(test.nextStop)) {
+			t.Fatalf("tests[%d] - expected next stop %s, got %v", i, test.nextStop, status.NextStop)
+		}
+
+		if status.NextStart == nil || !status.NextStart.Time.Equal(test.nextStart) {
+			t.Fatalf("tests[%d] - expected next start %s, got %v", i, test.nextStart, status.NextStart)
+		}
+	}
+}
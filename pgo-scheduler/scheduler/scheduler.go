@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/crunchydata/postgres-operator/apiserver"
+	"github.com/crunchydata/postgres-operator/kubeapi"
 	log "github.com/sirupsen/logrus"
 
 	cv2 "github.com/robfig/cron"
@@ -33,6 +34,7 @@ import (
 func New(label, namespace string, nsList []string, client *kubernetes.Clientset) *Scheduler {
 	apiserver.ConnectToKube()
 	restClient = apiserver.RESTClient
+	restConfig = apiserver.RESTConfig
 	kubeClient = client
 	cronClient := cv2.New()
 	cronClient.AddFunc("* * * * *", phony)
@@ -42,7 +44,7 @@ func New(label, namespace string, nsList []string, client *kubernetes.Clientset)
 		namespace:     namespace,
 		label:         label,
 		CronClient:    cronClient,
-		entries:       make(map[string]cv2.EntryID),
+		entries:       make(map[string][]cv2.EntryID),
 		namespaceList: nsList,
 	}
 }
@@ -68,7 +70,7 @@ func (s *Scheduler) AddSchedule(config *v1.ConfigMap) error {
 		return fmt.Errorf("Failed to validate schedule: %s", err)
 	}
 
-	id, err := s.schedule(schedule)
+	ids, err := s.schedule(schedule)
 	if err != nil {
 		return fmt.Errorf("Failed to schedule configmap: %s", err)
 	}
@@ -83,7 +85,7 @@ func (s *Scheduler) AddSchedule(config *v1.ConfigMap) error {
 		"container":  schedule.Container,
 	}).Info("Added new schedule")
 
-	s.entries[name] = id
+	s.entries[name] = ids
 	return nil
 }
 
@@ -93,11 +95,22 @@ func (s *Scheduler) DeleteSchedule(config *v1.ConfigMap) {
 	}).Info("Removed schedule")
 
 	name := config.Name + config.Namespace
-	s.CronClient.Remove(s.entries[name])
+	for _, id := range s.entries[name] {
+		s.CronClient.Remove(id)
+	}
 	delete(s.entries, name)
+
+	// a cluster without a hibernate schedule no longer has a next stop or
+	// start time
+	for _, data := range config.Data {
+		var schedule ScheduleTemplate
+		if err := json.Unmarshal([]byte(data), &schedule); err == nil && schedule.Type == "hibernate" {
+			kubeapi.PatchpgclusterHibernateStatus(restClient, nil, schedule.Cluster, schedule.Namespace)
+		}
+	}
 }
 
-func (s *Scheduler) schedule(st ScheduleTemplate) (cv2.EntryID, error) {
+func (s *Scheduler) schedule(st ScheduleTemplate) ([]cv2.EntryID, error) {
 	var job cv2.Job
 
 	switch st.Type {
@@ -105,11 +118,37 @@ func (s *Scheduler) schedule(st ScheduleTemplate) (cv2.EntryID, error) {
 		job = st.NewBackRestSchedule()
 	case "policy":
 		job = st.NewPolicySchedule()
+	case "hibernate":
+		return s.scheduleHibernate(st)
 	default:
-		var id cv2.EntryID
-		return id, fmt.Errorf("schedule type not implemented yet")
+		return nil, fmt.Errorf("schedule type not implemented yet")
 	}
-	return s.CronClient.AddJob(st.Schedule, job)
+
+	id, err := s.CronClient.AddJob(st.Schedule, job)
+	if err != nil {
+		return nil, err
+	}
+	return []cv2.EntryID{id}, nil
+}
+
+// scheduleHibernate adds the two jobs of a hibernate schedule, the one that
+// shuts the cluster down and the one that starts it again
+func (s *Scheduler) scheduleHibernate(st ScheduleTemplate) ([]cv2.EntryID, error) {
+	stopID, err := s.CronClient.AddJob(st.Hibernate.Stop, st.NewHibernateSchedule(true))
+	if err != nil {
+		return nil, err
+	}
+
+	startID, err := s.CronClient.AddJob(st.Hibernate.Start, st.NewHibernateSchedule(false))
+	if err != nil {
+		s.CronClient.Remove(stopID)
+		return nil, err
+	}
+
+	// the next stop and start times are shown along with the cluster
+	st.NewHibernateSchedule(true).updateStatus("")
+
+	return []cv2.EntryID{stopID, startID}, nil
 }
 
 // phony implements a no-op schedule job to prevent a bug that runs newly
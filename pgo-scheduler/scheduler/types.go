@@ -26,9 +26,10 @@ import (
 
 var kubeClient *kubernetes.Clientset
 var restClient *rest.RESTClient
+var restConfig *rest.Config
 
 type Scheduler struct {
-	entries       map[string]cv2.EntryID
+	entries       map[string][]cv2.EntryID
 	CronClient    *cv2.Cron
 	label         string
 	namespace     string
@@ -46,6 +47,7 @@ type ScheduleTemplate struct {
 	Cluster    string    `json:"cluster"`
 	PGBackRest `json:"pgbackrest,omitempty"`
 	Policy     `json:"policy,omitempty"`
+	Hibernate  `json:"hibernate,omitempty"`
 }
 
 type PGBackRest struct {
@@ -65,6 +67,13 @@ type Policy struct {
 	Database    string `json:"database"`
 }
 
+// Hibernate holds the cron expressions of when a cluster is shut down and when
+// it is started again
+type Hibernate struct {
+	Stop  string `json:"stop"`
+	Start string `json:"start"`
+}
+
 type PolicyTemplate struct {
 	JobName        string
 	ClusterName    string
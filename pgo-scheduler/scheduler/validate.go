@@ -20,16 +20,19 @@ import (
 	"fmt"
 	"strings"
 
-	cv3 "github.com/robfig/cron"
+	"github.com/crunchydata/postgres-operator/util"
 )
 
 func validate(s ScheduleTemplate) error {
-	if err := ValidateSchedule(s.Schedule); err != nil {
+	if err := ValidateScheduleType(s.Type); err != nil {
 		return err
 	}
 
-	if err := ValidateScheduleType(s.Type); err != nil {
-		return err
+	// a hibernate schedule has its own stop and start schedules instead
+	if s.Type != "hibernate" {
+		if err := ValidateSchedule(s.Schedule); err != nil {
+			return err
+		}
 	}
 
 	if err := ValidateBackRestSchedule(s.Type, s.Deployment, s.Label, s.PGBackRest.Type,
@@ -41,24 +44,24 @@ func validate(s ScheduleTemplate) error {
 		return err
 	}
 
+	if err := ValidateHibernateSchedule(s.Type, s.Hibernate.Stop, s.Hibernate.Start); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // ValidateSchedule validates that the cron syntax is valid
 // We use the standard format here...
 func ValidateSchedule(schedule string) error {
-	parser := cv3.NewParser(cv3.Minute | cv3.Hour | cv3.Dom | cv3.Month | cv3.Dow)
-
-	if _, err := parser.Parse(schedule); err != nil {
-		return fmt.Errorf("%s is not a valid schedule: ", schedule)
-	}
-	return nil
+	return util.ValidateCronSchedule(schedule)
 }
 
 func ValidateScheduleType(schedule string) error {
 	scheduleTypes := []string{
 		"pgbackrest",
 		"policy",
+		"hibernate",
 	}
 
 	schedule = strings.ToLower(schedule)
@@ -95,16 +98,8 @@ func ValidateBackRestSchedule(scheduleType, deployment, label, backupType, stora
 			return fmt.Errorf("pgBackRest Backup Type invalid: %s", backupType)
 		}
 
-		validStorageTypes := []string{"local", "s3"}
-		for _, sType := range validStorageTypes {
-			if storageType == sType {
-				valid = true
-				break
-			}
-		}
-
-		if !valid {
-			return fmt.Errorf("pgBackRest Backup Type invalid: %s", backupType)
+		if storageType != "" && !util.IsValidBackrestStorageType(storageType) {
+			return fmt.Errorf("pgBackRest Storage Type invalid: %s", storageType)
 		}
 	}
 	return nil
@@ -121,3 +116,12 @@ func ValidatePolicySchedule(scheduleType, policy, database string) error {
 	}
 	return nil
 }
+
+// ValidateHibernateSchedule validates that a hibernate schedule has both a
+// stop and a start schedule, and that both are valid
+func ValidateHibernateSchedule(scheduleType, stop, start string) error {
+	if scheduleType == "hibernate" {
+		return util.ValidateHibernateSchedule(stop, start)
+	}
+	return nil
+}
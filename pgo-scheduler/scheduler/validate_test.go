@@ -57,6 +57,7 @@ func TestValidScheduleType(t *testing.T) {
 		{"POLICY", true},
 		{"pgBackRest", true},
 		{"PoLiCY", true},
+		{"hibernate", true},
 		{"FOO", false},
 		{"BAR", false},
 		{"foo", false},
@@ -85,6 +86,12 @@ func TestValidBackRestSchedule(t *testing.T) {
 		{"pgbackrest", "", "testlabel=label", "diff", "local", true},
 		{"pgbackrest", "testdeployment", "", "full", "s3", true},
 		{"pgbackrest", "", "testlabel=label", "diff", "s3", true},
+		{"pgbackrest", "testdeployment", "", "full", "gcs", true},
+		{"pgbackrest", "", "testlabel=label", "incr", "azure", true},
+		{"pgbackrest", "testdeployment", "", "full", "local,gcs", true},
+		{"pgbackrest", "testdeployment", "", "full", "", true},
+		{"pgbackrest", "testdeployment", "", "full", "foobar", false},
+		{"pgbackrest", "testdeployment", "", "full", "s3,azure", false},
 		{"policy", "", "", "", "local", false},
 		{"pgbackrest", "", "", "", "local", false},
 		{"pgbackrest", "", "", "full", "local", false},
@@ -128,3 +135,28 @@ func TestValidSQLSchedule(t *testing.T) {
 		}
 	}
 }
+
+func TestValidHibernateSchedule(t *testing.T) {
+	tests := []struct {
+		schedule, stop, start string
+		valid                 bool
+	}{
+		{"hibernate", "0 19 * * 1-5", "0 7 * * 1-5", true},
+		{"hibernate", "0 19 * * 1-5", "", false},
+		{"hibernate", "", "0 7 * * 1-5", false},
+		{"hibernate", "0 25 * * 1-5", "0 7 * * 1-5", false},
+		{"hibernate", "0 19 * * 1-5", "* * * * * * *", false},
+		{"policy", "", "", true},
+	}
+
+	for i, test := range tests {
+		err := ValidateHibernateSchedule(test.schedule, test.stop, test.start)
+		if test.valid && err != nil {
+			t.Fatalf("tests[%d] - invalid schedule. expected valid, got invalid: %s",
+				i, err)
+		} else if !test.valid && err == nil {
+			t.Fatalf("tests[%d] - valid schedule. expected invalid, got valid: %s",
+				i, err)
+		}
+	}
+}
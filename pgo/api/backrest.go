@@ -100,3 +100,141 @@ func CreateBackrestBackup(httpclient *http.Client, SessionCredentials *msgs.Basi
 
 	return response, err
 }
+
+// ShowRecoverability makes an API call to return the recoverability window of
+// the pgBackRest repositories of the clusters that match the request
+func ShowRecoverability(httpclient *http.Client, SessionCredentials *msgs.BasicAuthCredentials, request msgs.ShowRecoverabilityRequest) (msgs.ShowRecoverabilityResponse, error) {
+	var response msgs.ShowRecoverabilityResponse
+
+	// explicitly set the client version here
+	request.ClientVersion = msgs.PGO_VERSION
+
+	log.Debugf("ShowRecoverability called [%+v]", request)
+
+	jsonValue, _ := json.Marshal(request)
+	url := SessionCredentials.APIServerURL + "/recoverability"
+
+	action := "POST"
+	req, err := http.NewRequest(action, url, bytes.NewBuffer(jsonValue))
+
+	if err != nil {
+		return response, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(SessionCredentials.Username, SessionCredentials.Password)
+
+	resp, err := httpclient.Do(req)
+
+	if err != nil {
+		return response, err
+	}
+
+	defer resp.Body.Close()
+
+	log.Debugf("%+v", resp)
+
+	if err := StatusCheck(resp); err != nil {
+		return response, err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		fmt.Print("Error: ")
+		fmt.Println(err)
+		return response, err
+	}
+
+	return response, nil
+}
+
+// CreateSnapshotBackup makes an API call to take snapshot backups of the
+// clusters that match the request
+func CreateSnapshotBackup(httpclient *http.Client, SessionCredentials *msgs.BasicAuthCredentials, request msgs.CreateSnapshotBackupRequest) (msgs.CreateSnapshotBackupResponse, error) {
+	var response msgs.CreateSnapshotBackupResponse
+
+	// explicitly set the client version here
+	request.ClientVersion = msgs.PGO_VERSION
+
+	log.Debugf("CreateSnapshotBackup called [%+v]", request)
+
+	jsonValue, _ := json.Marshal(request)
+	url := SessionCredentials.APIServerURL + "/snapshotbackup"
+
+	action := "POST"
+	req, err := http.NewRequest(action, url, bytes.NewBuffer(jsonValue))
+
+	if err != nil {
+		return response, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(SessionCredentials.Username, SessionCredentials.Password)
+
+	resp, err := httpclient.Do(req)
+
+	if err != nil {
+		return response, err
+	}
+
+	defer resp.Body.Close()
+
+	log.Debugf("%+v", resp)
+
+	if err := StatusCheck(resp); err != nil {
+		return response, err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		fmt.Print("Error: ")
+		fmt.Println(err)
+		return response, err
+	}
+
+	return response, nil
+}
+
+// ShowSnapshotBackups makes an API call to return the snapshot backups of the
+// clusters that match the request
+func ShowSnapshotBackups(httpclient *http.Client, SessionCredentials *msgs.BasicAuthCredentials, request msgs.ShowSnapshotBackupRequest) (msgs.ShowSnapshotBackupResponse, error) {
+	var response msgs.ShowSnapshotBackupResponse
+
+	// explicitly set the client version here
+	request.ClientVersion = msgs.PGO_VERSION
+
+	log.Debugf("ShowSnapshotBackups called [%+v]", request)
+
+	jsonValue, _ := json.Marshal(request)
+	url := SessionCredentials.APIServerURL + "/showsnapshotbackup"
+
+	action := "POST"
+	req, err := http.NewRequest(action, url, bytes.NewBuffer(jsonValue))
+
+	if err != nil {
+		return response, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(SessionCredentials.Username, SessionCredentials.Password)
+
+	resp, err := httpclient.Do(req)
+
+	if err != nil {
+		return response, err
+	}
+
+	defer resp.Body.Close()
+
+	log.Debugf("%+v", resp)
+
+	if err := StatusCheck(resp); err != nil {
+		return response, err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		fmt.Print("Error: ")
+		fmt.Println(err)
+		return response, err
+	}
+
+	return response, nil
+}
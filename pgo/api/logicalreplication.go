@@ -0,0 +1,101 @@
+package api
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+	log "github.com/sirupsen/logrus"
+)
+
+// CreatePublication creates a publication through the apiserver
+func CreatePublication(httpclient *http.Client, SessionCredentials *msgs.BasicAuthCredentials, request *msgs.CreatePublicationRequest) (msgs.CreatePublicationResponse, error) {
+
+	var response msgs.CreatePublicationResponse
+
+	jsonValue, _ := json.Marshal(request)
+	url := SessionCredentials.APIServerURL + "/publication"
+	log.Debugf("createPublication called...[%s]", url)
+
+	action := "POST"
+	req, err := http.NewRequest(action, url, bytes.NewBuffer(jsonValue))
+	if err != nil {
+		return response, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(SessionCredentials.Username, SessionCredentials.Password)
+
+	resp, err := httpclient.Do(req)
+	if err != nil {
+		return response, err
+	}
+	defer resp.Body.Close()
+
+	log.Debugf("%v", resp)
+	err = StatusCheck(resp)
+	if err != nil {
+		return response, err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		log.Printf("%v\n", resp.Body)
+		log.Println(err)
+		return response, err
+	}
+
+	return response, err
+}
+
+// CreateSubscription creates a subscription through the apiserver
+func CreateSubscription(httpclient *http.Client, SessionCredentials *msgs.BasicAuthCredentials, request *msgs.CreateSubscriptionRequest) (msgs.CreateSubscriptionResponse, error) {
+
+	var response msgs.CreateSubscriptionResponse
+
+	jsonValue, _ := json.Marshal(request)
+	url := SessionCredentials.APIServerURL + "/subscription"
+	log.Debugf("createSubscription called...[%s]", url)
+
+	action := "POST"
+	req, err := http.NewRequest(action, url, bytes.NewBuffer(jsonValue))
+	if err != nil {
+		return response, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(SessionCredentials.Username, SessionCredentials.Password)
+
+	resp, err := httpclient.Do(req)
+	if err != nil {
+		return response, err
+	}
+	defer resp.Body.Close()
+
+	log.Debugf("%v", resp)
+	err = StatusCheck(resp)
+	if err != nil {
+		return response, err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		log.Printf("%v\n", resp.Body)
+		log.Println(err)
+		return response, err
+	}
+
+	return response, err
+}
@@ -26,8 +26,8 @@ import (
 )
 
 func ScaleCluster(httpclient *http.Client, arg string, ReplicaCount int, ContainerResources,
-	StorageConfig, NodeLabel, CCPImageTag, ServiceType string,
-	SessionCredentials *msgs.BasicAuthCredentials, ns string) (msgs.ClusterScaleResponse, error) {
+	StorageConfig, NodeLabel, CCPImageTag, ServiceType, Snapshot string, Tolerations []string,
+	PriorityClassName string, SessionCredentials *msgs.BasicAuthCredentials, ns string) (msgs.ClusterScaleResponse, error) {
 
 	var response msgs.ClusterScaleResponse
 
@@ -48,6 +48,11 @@ func ScaleCluster(httpclient *http.Client, arg string, ReplicaCount int, Contain
 	q.Add("version", msgs.PGO_VERSION)
 	q.Add("ccp-image-tag", CCPImageTag)
 	q.Add("service-type", ServiceType)
+	q.Add("snapshot", Snapshot)
+	for _, toleration := range Tolerations {
+		q.Add("toleration", toleration)
+	}
+	q.Add("priority-class", PriorityClassName)
 	q.Add("namespace", ns)
 	req.URL.RawQuery = q.Encode()
 
@@ -31,7 +31,8 @@ var backupCmd = &cobra.Command{
 	Short: "Perform a Backup",
 	Long: `BACKUP performs a Backup, for example:
 
-  pgo backup mycluster`,
+  pgo backup mycluster
+  pgo backup mycluster --backup-type=snapshot`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if Namespace == "" {
 			Namespace = PGONamespace
@@ -64,8 +65,23 @@ var backupCmd = &cobra.Command{
 
 				createpgDumpBackup(args, Namespace)
 
+			case config.LABEL_BACKUP_TYPE_SNAPSHOT:
+
+				// storage config flag invalid for snapshots, which are taken of the
+				// existing volumes
+				if StorageConfig != "" {
+					fmt.Println("Error: --storage-config is not allowed when performing a snapshot backup.")
+					exitNow = true
+				}
+
+				if exitNow {
+					return
+				}
+
+				createSnapshotBackup(args, Namespace)
+
 			default:
-				fmt.Println("Error: You must specify either pgbackrest or pgdump for the --backup-type.")
+				fmt.Println("Error: You must specify either pgbackrest, pgdump or snapshot for the --backup-type.")
 
 			}
 
@@ -82,8 +98,9 @@ func init() {
 	backupCmd.Flags().StringVarP(&BackupOpts, "backup-opts", "", "", "The options to pass into pgbackrest.")
 	backupCmd.Flags().StringVarP(&Selector, "selector", "s", "", "The selector to use for cluster filtering.")
 	backupCmd.Flags().StringVarP(&PVCName, "pvc-name", "", "", "The PVC name to use for the backup instead of the default.")
-	backupCmd.Flags().StringVar(&backupType, "backup-type", "pgbackrest", "The backup type to perform. Default is pgbackrest. Valid backup types are pgbackrest and pgdump.")
-	backupCmd.Flags().StringVarP(&BackrestStorageType, "pgbackrest-storage-type", "", "", "The type of storage to use when scheduling pgBackRest backups. Either \"local\", \"s3\" or both, comma separated. (default \"local\")")
+	backupCmd.Flags().StringVar(&backupType, "backup-type", "pgbackrest", "The backup type to perform. Default is pgbackrest. Valid backup types are pgbackrest, pgdump and snapshot.")
+	backupCmd.Flags().StringVarP(&SnapshotClass, "snapshot-class", "", "", "The VolumeSnapshotClass to use for a snapshot backup. If not set, the default in pgo.yaml will be used.")
+	backupCmd.Flags().StringVarP(&BackrestStorageType, "pgbackrest-storage-type", "", "", "The type of storage to use when scheduling pgBackRest backups. Either \"local\", \"s3\", \"gcs\", \"azure\" or \"local\" combined with one of the others, comma separated. (default \"local\")")
 
 }
 
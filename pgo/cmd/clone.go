@@ -31,9 +31,23 @@ var (
 	SourceClusterName string
 	// the target/destination cluster used for the clone, e.g. "newcluster"
 	TargetClusterName string
-	// BackrestStorageSource represents the data source to use (e.g. s3 or local) when both s3
-	// and local are enabled in the cluster being cloned
+	// BackrestStorageSource represents the data source to use (e.g. s3, gcs,
+	// azure or local) when both a cloud storage type and local are enabled in the
+	// cluster being cloned
 	BackrestStorageSource string
+	// BackupSet is the label of the pgBackRest backup to clone from, e.g.
+	// "20200619-203502F"
+	BackupSet string
+	// RecoveryTarget is the point-in-time to recover the clone to
+	RecoveryTarget string
+	// RecoveryTargetType is the type of the recovery target, i.e. "time", "lsn",
+	// "name" or "xid"
+	RecoveryTargetType string
+	// RotateBackrestCipherPass generates a new passphrase for the encrypted
+	// pgBackRest repository of the cloned cluster
+	RotateBackrestCipherPass bool
+	// TargetNamespace is the namespace to create the cloned cluster in
+	TargetNamespace string
 )
 
 var cloneCmd = &cobra.Command{
@@ -41,7 +55,11 @@ var cloneCmd = &cobra.Command{
 	Short: "Copies the primary database of an existing cluster to a new cluster",
 	Long: `Clone makes a copy of an existing PostgreSQL cluster managed by the Operator and creates a new PostgreSQL cluster managed by the Operator, with the data from the old cluster.
 
-	pgo clone oldcluster newcluster`,
+	pgo clone oldcluster newcluster
+	pgo clone oldcluster newcluster --recovery-target-type=time --recovery-target="2020-06-19 12:00:00+00"
+	pgo clone oldcluster newcluster --pgbackrest-backup-set=20200619-203502F --target-namespace=staging
+	pgo clone oldcluster newcluster --snapshot=oldcluster-20200619-203502
+	pgo clone oldcluster newcluster --ttl=72h`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// if the namespace is not specified, default to the PGONamespace specified
 		// in the `PGO_NAMESPACE` environmental variable
@@ -69,14 +87,29 @@ var cloneCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(cloneCmd)
 
+	cloneCmd.Flags().StringVarP(&BackupSet, "pgbackrest-backup-set", "", "",
+		`The label of the pgBackRest backup to clone from, e.g. "20200619-203502F". If not set, the latest backup is used.`)
 	cloneCmd.Flags().StringVarP(&BackrestPVCSize, "pgbackrest-pvc-size", "", "",
 		`The size of the PVC capacity for the pgBackRest repository. Overrides the value set in the storage class. This is ignored if the storage type of "local" is not used. Must follow the standard Kubernetes format, e.g. "10.1Gi"`)
 	cloneCmd.Flags().StringVarP(&BackrestStorageSource, "pgbackrest-storage-source", "", "",
-		"The data source for the clone when both \"local\" and \"s3\" are enabled in the "+
-			"source cluster. Either \"local\", \"s3\" or both, comma separated. (default \"local\")")
+		"The data source for the clone when both \"local\" and a cloud storage type (\"s3\", "+
+			"\"gcs\" or \"azure\") are enabled in the source cluster. Either \"local\", \"s3\", "+
+			"\"gcs\" or \"azure\". (default \"local\")")
 	cloneCmd.Flags().BoolVar(&MetricsFlag, "enable-metrics", false, `If sets, enables metrics collection on the newly cloned cluster`)
 	cloneCmd.Flags().StringVarP(&PVCSize, "pvc-size", "", "",
 		`The size of the PVC capacity for primary and replica PostgreSQL instances. Overrides the value set in the storage class. Must follow the standard Kubernetes format, e.g. "10.1Gi"`)
+	cloneCmd.Flags().StringVarP(&RecoveryTarget, "recovery-target", "", "",
+		`The point to recover the clone to, e.g. a timestamp such as "2020-06-19 12:00:00+00", a LSN, a restore point name or a transaction ID. Requires "--recovery-target-type".`)
+	cloneCmd.Flags().StringVarP(&RecoveryTargetType, "recovery-target-type", "", "",
+		`The type of the recovery target. Either "time", "lsn", "name" or "xid".`)
+	cloneCmd.Flags().BoolVarP(&RotateBackrestCipherPass, "rotate-backrest-cipher-pass", "", false,
+		`If set, the pgBackRest repository of the cloned cluster is encrypted with a new passphrase. This creates a new pgBackRest repository for the cloned cluster, i.e. the backups of the source cluster are not kept. Only supported for encrypted repositories.`)
+	cloneCmd.Flags().StringVarP(&SnapshotName, "snapshot", "", "",
+		"The name of a snapshot backup of the source cluster to provision the storage of the new cluster from. The WAL since the snapshot was taken is replayed from the pgBackRest repository.")
+	cloneCmd.Flags().StringVarP(&TargetNamespace, "target-namespace", "", "",
+		"The namespace to create the cloned cluster in. Defaults to the namespace of the source cluster.")
+	cloneCmd.Flags().StringVar(&TTL, "ttl", "", "If set, the cloned cluster is deleted once this "+
+		"duration has passed since it was created, e.g. \"72h\".")
 }
 
 // clone is a helper function to help set up the clone!
@@ -88,11 +121,18 @@ func clone(namespace, sourceClusterName, targetClusterName string) {
 	request := msgs.CloneRequest{
 		BackrestStorageSource: BackrestStorageSource,
 		BackrestPVCSize:       BackrestPVCSize,
+		BackupSet:             BackupSet,
 		EnableMetrics:         MetricsFlag,
 		Namespace:             Namespace,
 		PVCSize:               PVCSize,
+		RecoveryTarget:        RecoveryTarget,
+		RecoveryTargetType:    RecoveryTargetType,
+		RotateCipherPass:      RotateBackrestCipherPass,
+		Snapshot:              SnapshotName,
 		SourceClusterName:     sourceClusterName,
 		TargetClusterName:     targetClusterName,
+		TargetNamespace:       TargetNamespace,
+		TTL:                   TTL,
 	}
 
 	// make a call to the clone API
@@ -113,5 +153,6 @@ func clone(namespace, sourceClusterName, targetClusterName string) {
 
 	// otherwise, print out some feedback:
 	fmt.Println("Created clone task for: ", response.TargetClusterName)
+	fmt.Printf("target namespace: %s\n", response.TargetNamespace)
 	fmt.Println("workflow id is ", response.WorkflowID)
 }
@@ -18,8 +18,10 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -29,6 +31,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
 )
 
 // below are the tablespace parameters and the expected values of each
@@ -56,6 +59,53 @@ var requiredTablespaceParams = []string{
 	tablespaceParamStorageConfig,
 }
 
+// clusterSetFieldFlags are the flags of "pgo create cluster" that can turn a
+// setting off or set it to zero, along with the name of the setting in the
+// request
+var clusterSetFieldFlags = []struct {
+	flag, field string
+}{
+	{"deletion-protection", "DeletionProtection"},
+	{"disable-autofail", "AutofailFlag"},
+	{"metrics", "MetricsFlag"},
+	{"password-length", "PasswordLength"},
+	{"pgbadger", "BadgerFlag"},
+	{"pgbouncer", "PgbouncerFlag"},
+	{"replica-count", "ReplicaCount"},
+	{"show-system-accounts", "ShowSystemAccounts"},
+	{"standby", "Standby"},
+	{"tls-auto", "TLSAuto"},
+	{"tls-cert-auth", "TLSCertAuth"},
+	{"tls-only", "TLSOnly"},
+}
+
+// ClusterMetadata holds the custom annotations and labels of the objects of a
+// cluster, each in the "key=value" format
+var ClusterMetadata msgs.ClusterMetadata
+
+// addClusterMetadataFlags adds the flags that set the custom annotations and
+// labels of the objects of a cluster to a command. usage is appended to the
+// description of each flag
+func addClusterMetadataFlags(cmd *cobra.Command, usage string) {
+	objects := []struct {
+		flag, description   string
+		annotations, labels *[]string
+	}{
+		{"postgres", "the PostgreSQL pods", &ClusterMetadata.AnnotationsPostgres, &ClusterMetadata.LabelsPostgres},
+		{"primary-service", "the Service of the primary", &ClusterMetadata.AnnotationsPrimaryService, &ClusterMetadata.LabelsPrimaryService},
+		{"replica-service", "the Service of the replicas", &ClusterMetadata.AnnotationsReplicaService, &ClusterMetadata.LabelsReplicaService},
+		{"pgbouncer", "the pgBouncer pods and Service", &ClusterMetadata.AnnotationsPgBouncer, &ClusterMetadata.LabelsPgBouncer},
+		{"pgbackrest", "the pgBackRest repository pod and Service", &ClusterMetadata.AnnotationsPgBackRest, &ClusterMetadata.LabelsPgBackRest},
+	}
+
+	for _, object := range objects {
+		cmd.Flags().StringSliceVar(object.annotations, "annotation-"+object.flag, []string{},
+			"An annotation for "+object.description+", e.g. \"key=value\". Can be repeated."+usage)
+		cmd.Flags().StringSliceVar(object.labels, "label-"+object.flag, []string{},
+			"A label for "+object.description+", e.g. \"key=value\". Can be repeated."+usage)
+	}
+}
+
 // deleteCluster will delete a PostgreSQL cluster that is managed by the
 // PostgreSQL Operator
 func deleteCluster(args []string, ns string) {
@@ -168,6 +218,107 @@ func printCluster(detail *msgs.ShowClusterDetail) {
 		fmt.Printf("%sstandby : %t\n", TreeBranch, detail.Standby)
 	}
 
+	// indicate if the cluster is protected from being deleted
+	if detail.Cluster.Spec.DeletionProtection {
+		fmt.Printf("%sdeletion protection : %t\n", TreeBranch, detail.Cluster.Spec.DeletionProtection)
+	}
+
+	// indicate when the cluster is deleted if it has a TTL
+	if detail.Cluster.Spec.ExpirationTime != nil {
+		expirationTime := detail.Cluster.Spec.ExpirationTime.Time
+		remaining := "expired"
+		if ttl := time.Until(expirationTime); ttl > 0 {
+			remaining = ttl.Round(time.Minute).String() + " remaining"
+		}
+		fmt.Printf("%sexpires : %s (%s)\n", TreeBranch, expirationTime.Format(time.RFC3339), remaining)
+	}
+
+	// indicate when a cluster with a hibernate schedule is next shut down and
+	// started again, and why its last shutdown was skipped, if it was
+	if hibernate := detail.Cluster.Status.Hibernate; hibernate != nil {
+		nextStop, nextStart := "unknown", "unknown"
+		if hibernate.NextStop != nil {
+			nextStop = hibernate.NextStop.Format(time.RFC3339)
+		}
+		if hibernate.NextStart != nil {
+			nextStart = hibernate.NextStart.Format(time.RFC3339)
+		}
+
+		fmt.Printf("%shibernate : next stop %s, next start %s\n", TreeBranch, nextStop, nextStart)
+		if hibernate.Message != "" {
+			fmt.Printf("%shibernate : %s\n", TreeBranch, hibernate.Message)
+		}
+	}
+
+	// indicate when the certificate of a TLS-enabled cluster and its CA expire
+	if detail.TLS != nil {
+		managed := ""
+		if detail.TLS.Auto {
+			managed = " (managed by the Operator)"
+		}
+		fmt.Printf("%stls : certificate expires %s, CA expires %s%s\n", TreeBranch,
+			formatCertificateExpiration(detail.TLS.CertificateExpiration),
+			formatCertificateExpiration(detail.TLS.CAExpiration), managed)
+
+		if detail.TLS.CertAuth {
+			fmt.Printf("%scert auth : all users\n", TreeBranch)
+		} else if len(detail.TLS.CertAuthUsers) > 0 {
+			fmt.Printf("%scert auth : %s\n", TreeBranch, strings.Join(detail.TLS.CertAuthUsers, ", "))
+		}
+	}
+
+	// indicate who may connect to a cluster that has NetworkPolicies
+	if policy := detail.Cluster.Spec.NetworkPolicy; policy.Enabled {
+		clients := append([]string{"pgbouncer", "operator"}, policy.NamespaceSelectors...)
+		clients = append(clients, policy.SourceRanges...)
+		fmt.Printf("%snetwork policy : %s\n", TreeBranch, strings.Join(clients, ", "))
+	}
+
+	// the logical replication publications of the cluster and who subscribes
+	// to them, as well as the last recorded state of its subscriptions
+	for _, publication := range detail.Cluster.Spec.Publications {
+		tables := "all tables"
+		if len(publication.Tables) > 0 {
+			tables = strings.Join(publication.Tables, ", ")
+		}
+
+		subscribers := "none"
+		if len(publication.Subscribers) > 0 {
+			subscribers = strings.Join(publication.Subscribers, ", ")
+		}
+
+		fmt.Printf("%spublication : %s on %s (%s), subscribers: %s\n", TreeBranch, publication.Name,
+			publication.Database, tables, subscribers)
+	}
+
+	for _, subscription := range detail.Cluster.Spec.Subscriptions {
+		state := "unknown"
+		for _, status := range detail.Cluster.Status.Subscriptions {
+			if status.Name != subscription.Name {
+				continue
+			}
+
+			state = status.State
+			if status.LagBytes != nil {
+				state += fmt.Sprintf(", lag %d bytes", *status.LagBytes)
+			}
+		}
+
+		fmt.Printf("%ssubscription : %s on %s to %s/%s (%s)\n", TreeBranch, subscription.Name,
+			subscription.Database, subscription.SourceCluster, subscription.Publication, state)
+	}
+
+	// list the pg_hba.conf rules in the order they are applied, if requested
+	if ShowHBA {
+		if detail.HBA == nil {
+			fmt.Printf("%shba : not managed by the Operator\n", TreeBranch)
+		}
+
+		for _, rule := range detail.HBA {
+			fmt.Printf("%shba : %s\n", TreeBranch, rule)
+		}
+	}
+
 	for _, pod := range detail.Pods {
 		podType := "(" + pod.Type + ")"
 
@@ -212,6 +363,15 @@ func printCluster(detail *msgs.ShowClusterDetail) {
 
 }
 
+// formatCertificateExpiration returns the expiration time of a certificate, or
+// "unknown" if it is not known
+func formatCertificateExpiration(expiration time.Time) string {
+	if expiration.IsZero() {
+		return "unknown"
+	}
+	return expiration.Format(time.RFC3339)
+}
+
 func printPolicies(d *msgs.ShowClusterDeployment) {
 	for _, v := range d.PolicyLabels {
 		fmt.Printf("%spolicy: %s\n", TreeBranch, v)
@@ -232,10 +392,16 @@ func createCluster(args []string, ns string, createClusterCmd *cobra.Command) {
 		return
 	}
 
+	if OutputFormat != "" && OutputFormat != "json" && OutputFormat != "yaml" {
+		fmt.Println(`Error: The output format must be "json" or "yaml".`)
+		os.Exit(1)
+	}
+
 	r := new(msgs.CreateClusterRequest)
 	r.Name = args[0]
 	r.Namespace = ns
 	r.ReplicaCount = ClusterReplicaCount
+	r.Profile = Profile
 	r.NodeLabel = NodeLabel
 	r.PasswordLength = PasswordLength
 	r.PasswordSuperuser = PasswordSuperuser
@@ -266,6 +432,15 @@ func createCluster(args []string, ns string, createClusterCmd *cobra.Command) {
 	r.BackrestS3Bucket = BackrestS3Bucket
 	r.BackrestS3Region = BackrestS3Region
 	r.BackrestS3Endpoint = BackrestS3Endpoint
+	r.BackrestGCSBucket = BackrestGCSBucket
+	r.BackrestGCSEndpoint = BackrestGCSEndpoint
+	r.BackrestGCSKeyType = BackrestGCSKeyType
+	r.BackrestAzureAccount = BackrestAzureAccount
+	r.BackrestAzureKey = BackrestAzureKey
+	r.BackrestAzureContainer = BackrestAzureContainer
+	r.BackrestAzureEndpoint = BackrestAzureEndpoint
+	r.BackrestAzureURIStyle = BackrestAzureURIStyle
+	r.BackrestCipherType = BackrestCipherType
 	r.PVCSize = PVCSize
 	r.BackrestPVCSize = BackrestPVCSize
 	r.Username = Username
@@ -274,8 +449,22 @@ func createCluster(args []string, ns string, createClusterCmd *cobra.Command) {
 	r.TLSOnly = TLSOnly
 	r.TLSSecret = TLSSecret
 	r.CASecret = CASecret
+	r.TLSAuto = TLSAuto
+	r.TLSCertAuth = TLSCertAuth
 	r.Standby = Standby
 	r.BackrestRepoPath = BackrestRepoPath
+	r.DeletionProtection = DeletionProtection
+	r.TTL = TTL
+	r.Tolerations = Tolerations
+	r.PriorityClassName = PriorityClassName
+	r.ZoneSpread = ZoneSpread
+	r.Metadata = ClusterMetadata
+	r.ReplicaServiceType = ReplicaServiceType
+	r.LoadBalancerSourceRanges = LoadBalancerSourceRanges
+	r.HBA = HBARules
+	r.NetworkPolicyNamespaces = NetworkPolicyNamespaces
+	r.NetworkPolicySourceRanges = NetworkPolicySourceRanges
+	r.DryRun = DryRun
 	// determine if the user wants to create tablespaces as part of this request,
 	// and if so, set the values
 	r.Tablespaces = getTablespaces(Tablespaces)
@@ -285,6 +474,38 @@ func createCluster(args []string, ns string, createClusterCmd *cobra.Command) {
 		r.SyncReplication = &SyncReplication
 	}
 
+	// the same goes for the NetworkPolicies
+	if createClusterCmd.Flag("network-policy").Changed {
+		r.NetworkPolicy = &NetworkPolicy
+	}
+
+	// the same goes for the TLS verification of the GCS or Azure endpoint
+	if createClusterCmd.Flag("pgbackrest-storage-verify-tls").Changed {
+		r.BackrestStorageVerifyTLS = &BackrestStorageVerifyTLS
+	}
+
+	// record which of the settings that can be turned off or set to zero were
+	// set explicitly, so that they take precedence over a profile
+	r.SetFields = []string{}
+	for _, setting := range clusterSetFieldFlags {
+		if createClusterCmd.Flag(setting.flag).Changed {
+			r.SetFields = append(r.SetFields, setting.field)
+		}
+	}
+
+	// if a GCS key was provided, read it from the file so it can be stored in
+	// the pgBackRest repository secret
+	if BackrestGCSKey != "" {
+		gcsKey, err := ioutil.ReadFile(BackrestGCSKey)
+
+		if err != nil {
+			fmt.Println("Error: could not read GCS key:", err.Error())
+			os.Exit(1)
+		}
+
+		r.BackrestGCSKey = string(gcsKey)
+	}
+
 	// if the user provided resources for CPU or Memory, validate them to ensure
 	// they are valid Kubernetes values
 	if CPURequest != "" {
@@ -316,6 +537,12 @@ func createCluster(args []string, ns string, createClusterCmd *cobra.Command) {
 		os.Exit(2)
 	}
 
+	// a dry run only prints the objects that would be created
+	if DryRun {
+		printCreateClusterObjects(response.Result.Objects)
+		return
+	}
+
 	// print out the legacy cluster information
 	fmt.Println("created cluster:", response.Result.Name)
 	fmt.Println("workflow id:", response.Result.WorkflowID)
@@ -327,6 +554,27 @@ func createCluster(args []string, ns string, createClusterCmd *cobra.Command) {
 	}
 }
 
+// printCreateClusterObjects prints the objects that would be created for a
+// cluster by a dry run, either as a JSON list or as YAML documents that can be
+// piped into "kubectl"
+func printCreateClusterObjects(objects []json.RawMessage) {
+	if OutputFormat == "json" {
+		printJSON(objects)
+		return
+	}
+
+	for _, object := range objects {
+		content, err := yaml.JSONToYAML(object)
+		if err != nil {
+			fmt.Println("Error: ", err)
+			os.Exit(1)
+		}
+
+		fmt.Println("---")
+		fmt.Print(string(content))
+	}
+}
+
 // getTablespaces determines if there are any Tablespaces that were provided
 // via the `--tablespace` CLI flag, and if so, process their values. If
 // everything checks out, one or more tablespaces are added to the cluster
@@ -435,6 +683,33 @@ func updateCluster(args []string, ns string) {
 		r.Autofail = msgs.UpdateClusterAutofailDisable
 	}
 
+	// check to see if the deletion protection is to be enabled or disabled
+	if EnableDeletionProtection {
+		r.DeletionProtection = msgs.UpdateClusterDeletionProtectionEnable
+	} else if DisableDeletionProtection {
+		r.DeletionProtection = msgs.UpdateClusterDeletionProtectionDisable
+	}
+
+	r.ExtendTTL = ExtendTTL
+	r.Metadata = ClusterMetadata
+	r.ServiceType = ServiceType
+	r.ReplicaServiceType = ReplicaServiceType
+	r.LoadBalancerSourceRanges = LoadBalancerSourceRanges
+	r.HBA = HBARules
+	r.ClearHBA = ClearHBA
+	r.ClearReplicaServiceType = ClearReplicaServiceType
+	r.ClearLoadBalancerSourceRanges = ClearLoadBalancerSourceRanges
+
+	// check to see if the NetworkPolicies are to be added or removed
+	if EnableNetworkPolicy {
+		r.NetworkPolicy = msgs.UpdateClusterNetworkPolicyEnable
+	} else if DisableNetworkPolicy {
+		r.NetworkPolicy = msgs.UpdateClusterNetworkPolicyDisable
+	}
+
+	r.NetworkPolicyNamespaces = NetworkPolicyNamespaces
+	r.NetworkPolicySourceRanges = NetworkPolicySourceRanges
+
 	response, err := api.UpdateCluster(httpclient, &r, &SessionCredentials)
 
 	if err != nil {
@@ -60,6 +60,17 @@ var BackrestS3KeySecret string
 var BackrestS3Bucket string
 var BackrestS3Endpoint string
 var BackrestS3Region string
+var BackrestGCSBucket string
+var BackrestGCSEndpoint string
+var BackrestGCSKey string
+var BackrestGCSKeyType string
+var BackrestAzureAccount string
+var BackrestAzureKey string
+var BackrestAzureContainer string
+var BackrestAzureEndpoint string
+var BackrestAzureURIStyle string
+var BackrestStorageVerifyTLS bool
+var BackrestCipherType string
 var PVCSize string
 var BackrestPVCSize string
 
@@ -69,6 +80,53 @@ var BackrestRepoPath string
 // Standby determines whether or not the cluster should be created as a standby cluster
 var Standby bool
 
+// DeletionProtection determines whether or not the cluster is protected from
+// being deleted
+var DeletionProtection bool
+
+// TTL is the duration after which a new cluster is deleted, e.g. "72h"
+var TTL string
+
+// Tolerations are the tolerations of the pods of a cluster or of a replica, in
+// the "key=value:Effect" format of "kubectl taint"
+var Tolerations []string
+
+// PriorityClassName is the name of the priority class of the pods of a cluster
+// or of a replica
+var PriorityClassName string
+
+// ZoneSpread determines how the PostgreSQL instances of a cluster are spread
+// across zones
+var ZoneSpread string
+
+// ReplicaServiceType is the type of the Service of the replicas of a cluster,
+// if it differs from the type of the Service of the primary
+var ReplicaServiceType string
+
+// LoadBalancerSourceRanges are the CIDRs that may connect to the Services of a
+// cluster that are of type LoadBalancer
+var LoadBalancerSourceRanges []string
+
+// HBARules are the pg_hba.conf rules of a cluster, in the format of a line of
+// pg_hba.conf
+var HBARules []string
+
+// NetworkPolicy determines if a cluster has NetworkPolicies, and
+// NetworkPolicyNamespaces and NetworkPolicySourceRanges are the label selectors
+// of the namespaces and the CIDRs that may connect to it
+var (
+	NetworkPolicy             bool
+	NetworkPolicyNamespaces   []string
+	NetworkPolicySourceRanges []string
+)
+
+// Profile is the name of the cluster profile to create a cluster from
+var Profile string
+
+// ScheduleStop and ScheduleStart are the schedules of when a hibernate schedule
+// shuts a cluster down and starts it again
+var ScheduleStop, ScheduleStart string
+
 // PasswordSuperuser specifies the password for the cluster superuser
 var PasswordSuperuser string
 
@@ -86,6 +144,12 @@ var (
 	// CASecret is the name of the secret that contains the CA information for
 	// enabling TLS in a PostgreSQL cluster
 	CASecret string
+	// TLSAuto indicates that the Operator issues and renews the certificate of
+	// a PostgreSQL cluster
+	TLSAuto bool
+	// TLSCertAuth indicates that the PostgreSQL users of a cluster authenticate
+	// with client certificates
+	TLSCertAuth bool
 )
 
 var CreateCmd = &cobra.Command{
@@ -97,6 +161,9 @@ var CreateCmd = &cobra.Command{
     pgo create pgouser
     pgo create pgorole
     pgo create policy
+    pgo create profile
+    pgo create publication
+    pgo create subscription
     pgo create namespace
     pgo create user`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -108,11 +175,15 @@ var CreateCmd = &cobra.Command{
     * pgouser
     * pgorole
     * policy
+    * profile
+    * publication
+    * subscription
     * namespace
     * user`)
 		} else {
 			switch args[0] {
-			case "cluster", "pgbouncer", "pgouser", "pgorole", "policy", "user", "namespace":
+			case "cluster", "pgbouncer", "pgouser", "pgorole", "policy", "profile", "publication",
+				"subscription", "user", "namespace":
 				break
 			default:
 				fmt.Println(`Error: You must specify the type of resource to create.  Valid resource types include:
@@ -121,6 +192,9 @@ var CreateCmd = &cobra.Command{
     * pgouser
     * pgorole
     * policy
+    * profile
+    * publication
+    * subscription
     * namespace
     * user`)
 			}
@@ -180,7 +254,9 @@ var createPgbouncerCmd = &cobra.Command{
 	Short: "Create a pgbouncer ",
 	Long: `Create a pgbouncer. For example:
 
-	pgo create pgbouncer mycluster`,
+	pgo create pgbouncer mycluster
+	pgo create pgbouncer mycluster --read-only
+	pgo create pgbouncer mycluster --replicas=2`,
 	Run: func(cmd *cobra.Command, args []string) {
 
 		if Namespace == "" {
@@ -196,13 +272,71 @@ var createPgbouncerCmd = &cobra.Command{
 	},
 }
 
+// createPublicationCmd ...
+var createPublicationCmd = &cobra.Command{
+	Use:   "publication",
+	Short: "Create a logical replication publication",
+	Long: `Create a publication of the tables of a database of a cluster, which other clusters
+can subscribe to with "pgo create subscription". For example:
+
+    pgo create publication mycluster --name=orders --database=hippo
+    pgo create publication mycluster --name=orders --database=hippo --table=orders --table=sales.customers`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if Namespace == "" {
+			Namespace = PGONamespace
+		}
+		log.Debug("create publication called")
+
+		if len(args) != 1 {
+			fmt.Println(`Error: A single cluster name is required for this command.`)
+			return
+		}
+
+		if PublicationName == "" || LogicalReplicationDatabase == "" {
+			fmt.Println(`Error: The --name and --database flags are required to create a publication.`)
+			return
+		}
+
+		createPublication(args, Namespace)
+	},
+}
+
+// createSubscriptionCmd ...
+var createSubscriptionCmd = &cobra.Command{
+	Use:   "subscription",
+	Short: "Create a logical replication subscription",
+	Long: `Subscribe a cluster to a publication of another cluster in the same namespace. For example:
+
+    pgo create subscription mycluster --source-cluster=hippo --publication=orders
+    pgo create subscription mycluster --source-cluster=hippo --publication=orders --name=hippo_orders --database=reporting`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if Namespace == "" {
+			Namespace = PGONamespace
+		}
+		log.Debug("create subscription called")
+
+		if len(args) != 1 {
+			fmt.Println(`Error: A single cluster name is required for this command.`)
+			return
+		}
+
+		if SubscriptionSourceCluster == "" || PublicationName == "" {
+			fmt.Println(`Error: The --source-cluster and --publication flags are required to create a subscription.`)
+			return
+		}
+
+		createSubscription(args, Namespace)
+	},
+}
+
 // createScheduleCmd ...
 var createScheduleCmd = &cobra.Command{
 	Use:   "schedule",
 	Short: "Create a cron-like scheduled task",
 	Long: `Schedule creates a cron-like scheduled task.  For example:
 
-    pgo create schedule --schedule="* * * * *" --schedule-type=pgbackrest --pgbackrest-backup-type=full mycluster`,
+    pgo create schedule --schedule="* * * * *" --schedule-type=pgbackrest --pgbackrest-backup-type=full mycluster
+    pgo create schedule --schedule-type=hibernate --stop-schedule="0 19 * * 1-5" --start-schedule="0 7 * * 1-5" mycluster`,
 	Run: func(cmd *cobra.Command, args []string) {
 
 		if Namespace == "" {
@@ -226,7 +360,8 @@ var createUserCmd = &cobra.Command{
     pgo create user --username=someuser --all --managed
     pgo create user --username=someuser  mycluster --managed
     pgo create user --username=someuser -selector=name=mycluster --managed
-    pgo create user --username=user1 --selector=name=mycluster`,
+    pgo create user --username=user1 --selector=name=mycluster
+    pgo create user --username=someuser mycluster --auth=cert`,
 	Run: func(cmd *cobra.Command, args []string) {
 
 		if Namespace == "" {
@@ -249,16 +384,27 @@ func init() {
 	CreateCmd.AddCommand(createPgbouncerCmd)
 	CreateCmd.AddCommand(createPgouserCmd)
 	CreateCmd.AddCommand(createPgoroleCmd)
+	CreateCmd.AddCommand(createProfileCmd)
+	CreateCmd.AddCommand(createPublicationCmd)
+	CreateCmd.AddCommand(createSubscriptionCmd)
 	CreateCmd.AddCommand(createScheduleCmd)
 	CreateCmd.AddCommand(createUserCmd)
 	CreateCmd.AddCommand(createNamespaceCmd)
 
 	// flags for "pgo create cluster"
+	createClusterCmd.Flags().StringVarP(&BackrestCipherType, "backrest-cipher", "", "",
+		"If set, encrypts the pgBackRest repository using the given cipher type, i.e. \"aes-256-cbc\". "+
+			"A random passphrase is generated and stored in the pgBackRest repository secret of the cluster.")
 	createClusterCmd.Flags().StringVarP(&CCPImage, "ccp-image", "", "", "The CCPImage name to use for cluster creation. If specified, overrides the value crunchy-postgres.")
 	createClusterCmd.Flags().StringVarP(&CCPImageTag, "ccp-image-tag", "c", "", "The CCPImageTag to use for cluster creation. If specified, overrides the pgo.yaml setting.")
 	createClusterCmd.Flags().StringVar(&CPURequest, "cpu", "", "Set the number of millicores to request for the CPU, e.g. "+
 		"\"100m\" or \"0.1\". Overrides the value in \"resources-config\"")
+	createClusterCmd.Flags().BoolVar(&DryRun, "dry-run", false, "Shows the objects that would be created for the "+
+		"cluster, with the data of any secrets redacted, without creating them.")
 	createClusterCmd.Flags().StringVarP(&CustomConfig, "custom-config", "", "", "The name of a configMap that holds custom PostgreSQL configuration files used to override defaults.")
+	createClusterCmd.Flags().BoolVar(&DeletionProtection, "deletion-protection", false, "Protects the cluster from "+
+		"being deleted, including by \"pgo delete namespace\", until the protection is disabled with "+
+		"\"pgo update cluster --disable-deletion-protection\".")
 	createClusterCmd.Flags().StringVarP(&Database, "database", "d", "", "If specified, sets the name of the initial database that is created for the user. Defaults to the value set in the PostgreSQL Operator configuration, or if that is not present, the name of the cluster")
 	createClusterCmd.Flags().BoolVarP(&DisableAutofailFlag, "disable-autofail", "", false, "Disables autofail capabitilies in the cluster following cluster initialization.")
 	createClusterCmd.Flags().StringVarP(&UserLabels, "labels", "l", "", "The labels to apply to this cluster.")
@@ -266,6 +412,8 @@ func init() {
 		"1GiB. Overrides the value in \"resources-config\"")
 	createClusterCmd.Flags().BoolVarP(&MetricsFlag, "metrics", "", false, "Adds the crunchy-collect container to the database pod.")
 	createClusterCmd.Flags().StringVarP(&NodeLabel, "node-label", "", "", "The node label (key=value) to use in placing the primary database. If not set, any node is used.")
+	createClusterCmd.Flags().StringVarP(&OutputFormat, "output", "o", "", `The output format of a dry run. `+
+		`Supported types are: "json", "yaml". Defaults to "yaml".`)
 	createClusterCmd.Flags().StringVarP(&Password, "password", "", "", "The password to use for standard user account created during cluster initialization.")
 	createClusterCmd.Flags().IntVarP(&PasswordLength, "password-length", "", 0, "If no password is supplied, sets the length of the automatically generated password. Defaults to the value set on the server.")
 	createClusterCmd.Flags().StringVarP(&PasswordSuperuser, "password-superuser", "", "", "The password to use for the PostgreSQL superuser.")
@@ -290,7 +438,39 @@ func init() {
 	createClusterCmd.Flags().StringVarP(&BackrestS3Region, "pgbackrest-s3-region", "", "",
 		"The AWS S3 region that should be utilized for the cluster when the \"s3\" "+
 			"storage type is enabled for pgBackRest.")
-	createClusterCmd.Flags().StringVarP(&BackrestStorageType, "pgbackrest-storage-type", "", "", "The type of storage to use with pgBackRest. Either \"local\", \"s3\" or both, comma separated. (default \"local\")")
+	createClusterCmd.Flags().StringVarP(&BackrestGCSBucket, "pgbackrest-gcs-bucket", "", "",
+		"The GCS bucket that should be utilized for the cluster when the \"gcs\" "+
+			"storage type is enabled for pgBackRest.")
+	createClusterCmd.Flags().StringVarP(&BackrestGCSEndpoint, "pgbackrest-gcs-endpoint", "", "",
+		"The GCS endpoint that should be utilized for the cluster when the \"gcs\" "+
+			"storage type is enabled for pgBackRest.")
+	createClusterCmd.Flags().StringVarP(&BackrestGCSKey, "pgbackrest-gcs-key", "", "",
+		"The path to a file containing the GCS service account key (or the token, if the key "+
+			"type is \"token\") that should be utilized for the cluster when the \"gcs\" "+
+			"storage type is enabled for pgBackRest.")
+	createClusterCmd.Flags().StringVarP(&BackrestGCSKeyType, "pgbackrest-gcs-key-type", "", "",
+		"The type of the GCS key that should be utilized for the cluster when the \"gcs\" "+
+			"storage type is enabled for pgBackRest. Either \"service\" or \"token\". (default \"service\")")
+	createClusterCmd.Flags().StringVarP(&BackrestAzureAccount, "pgbackrest-azure-account", "", "",
+		"The Azure storage account that should be utilized for the cluster when the \"azure\" "+
+			"storage type is enabled for pgBackRest.")
+	createClusterCmd.Flags().StringVarP(&BackrestAzureContainer, "pgbackrest-azure-container", "", "",
+		"The Azure container that should be utilized for the cluster when the \"azure\" "+
+			"storage type is enabled for pgBackRest.")
+	createClusterCmd.Flags().StringVarP(&BackrestAzureEndpoint, "pgbackrest-azure-endpoint", "", "",
+		"The Azure endpoint that should be utilized for the cluster when the \"azure\" "+
+			"storage type is enabled for pgBackRest.")
+	createClusterCmd.Flags().StringVarP(&BackrestAzureKey, "pgbackrest-azure-key", "", "",
+		"The Azure storage account shared key that should be utilized for the cluster when the "+
+			"\"azure\" storage type is enabled for pgBackRest.")
+	createClusterCmd.Flags().StringVarP(&BackrestAzureURIStyle, "pgbackrest-azure-uri-style", "", "",
+		"The Azure URI style that should be utilized for the cluster when the \"azure\" "+
+			"storage type is enabled for pgBackRest. Either \"host\" or \"path\", e.g. \"path\" when "+
+			"using Azurite. (default \"host\")")
+	createClusterCmd.Flags().BoolVarP(&BackrestStorageVerifyTLS, "pgbackrest-storage-verify-tls", "", true,
+		"Whether or not the TLS certificate of the GCS or Azure endpoint is verified by pgBackRest. "+
+			"Set to false when using a local emulator with a self-signed certificate.")
+	createClusterCmd.Flags().StringVarP(&BackrestStorageType, "pgbackrest-storage-type", "", "", "The type of storage to use with pgBackRest. Either \"local\", \"s3\", \"gcs\", \"azure\" or \"local\" combined with one of the others, comma separated. (default \"local\")")
 	createClusterCmd.Flags().BoolVarP(&BadgerFlag, "pgbadger", "", false, "Adds the crunchy-pgbadger container to the database pod.")
 	createClusterCmd.Flags().BoolVarP(&PgbouncerFlag, "pgbouncer", "", false, "Adds a crunchy-pgbouncer deployment to the cluster.")
 	createClusterCmd.Flags().StringVarP(&ReplicaStorageConfig, "replica-storage-config", "", "", "The name of a Storage config in pgo.yaml to use for the cluster replica storage.")
@@ -306,6 +486,8 @@ func init() {
 			"Pods. Defaults to the default cluster pod anti-affinity (i.e. \"preferred\"), "+
 			"or the value set by --pod-anti-affinity")
 	createClusterCmd.Flags().StringVarP(&PoliciesFlag, "policies", "z", "", "The policies to apply when creating a cluster, comma separated.")
+	createClusterCmd.Flags().StringVar(&Profile, "profile", "", "The name of a cluster profile whose settings are "+
+		"used for any setting that is not set by a flag.")
 	createClusterCmd.Flags().StringVarP(&PVCSize, "pvc-size", "", "",
 		`The size of the PVC capacity for primary and replica PostgreSQL instances. Overrides the value set in the storage class. Must follow the standard Kubernetes format, e.g. "10.1Gi"`)
 	createClusterCmd.Flags().IntVarP(&ClusterReplicaCount, "replica-count", "", 0, "The number of replicas to create as part of the cluster.")
@@ -322,8 +504,14 @@ func init() {
 	createClusterCmd.Flags().StringVarP(&StorageConfig, "storage-config", "", "", "The name of a Storage config in pgo.yaml to use for the cluster storage.")
 	createClusterCmd.Flags().BoolVarP(&SyncReplication, "sync-replication", "", false,
 		"Enables synchronous replication for the cluster.")
+	createClusterCmd.Flags().BoolVar(&TLSAuto, "tls-auto", false, "If true, the Operator issues the TLS certificate "+
+		"of the PostgreSQL cluster from a certificate authority (CA) it maintains in the namespace, and renews it "+
+		"ahead of its expiration. Cannot be used with \"server-tls-secret\" and \"server-ca-secret\"")
+	createClusterCmd.Flags().BoolVar(&TLSCertAuth, "tls-cert-auth", false, "If true, the PostgreSQL users of the "+
+		"cluster, including the replication user, authenticate with client certificates that the Operator issues "+
+		"instead of passwords. The pgBouncer and monitoring users still use passwords. Must be used with \"tls-auto\"")
 	createClusterCmd.Flags().BoolVar(&TLSOnly, "tls-only", false, "If true, forces all PostgreSQL connections to be over TLS. "+
-		"Must also set \"server-tls-secret\" and \"server-ca-secret\"")
+		"Must also set \"server-tls-secret\" and \"server-ca-secret\", or \"tls-auto\"")
 	createClusterCmd.Flags().BoolVarP(&Standby, "standby", "", false, "Creates a standby cluster "+
 		"that replicates from a pgBackRest repository in AWS S3.")
 	createClusterCmd.Flags().StringSliceVar(&Tablespaces, "tablespace", []string{},
@@ -336,10 +524,45 @@ func init() {
 			"Follows the Kubernetes quantity format.\n\n"+
 			"For example, to create a tablespace with the NFS storage configuration with a PVC of size 10GiB:\n\n"+
 			"--tablespace=name=ts1:storageconfig=nfsstorage:pvcsize=10Gi")
+	createClusterCmd.Flags().StringVar(&TTL, "ttl", "", "If set, the cluster is deleted once this "+
+		"duration has passed, e.g. \"72h\". The data and the backups are removed unless the \"keep-data\" "+
+		"or \"keep-backups\" annotations are set. Can be extended with \"pgo update cluster --extend-ttl\".")
+	createClusterCmd.Flags().StringSliceVar(&Tolerations, "toleration", []string{}, "A toleration for the pods "+
+		"of the cluster, in the same format as the taints of \"kubectl taint\", i.e. \"key=value:Effect\". "+
+		"The value and the effect are optional. Can be repeated. Defaults to the value of \"Tolerations\" in pgo.yaml.")
+	createClusterCmd.Flags().StringVar(&PriorityClassName, "priority-class", "", "The name of the PriorityClass "+
+		"of the pods of the cluster. Defaults to the value of \"PriorityClassName\" in pgo.yaml.")
+	createClusterCmd.Flags().StringVar(&ZoneSpread, "zone-spread", "", "How the PostgreSQL instances are "+
+		"spread across zones, either \"required\", \"preferred\" or \"disabled\". Requires Kubernetes 1.18 "+
+		"or later. Defaults to the value of \"ZoneSpread\" in pgo.yaml.")
+	createClusterCmd.Flags().StringVar(&ReplicaServiceType, "replica-service-type", "", "The Service type "+
+		"to use for the replicas of the PostgreSQL cluster. If not set, the Service type of the primary is used.")
+	createClusterCmd.Flags().StringSliceVar(&LoadBalancerSourceRanges, "load-balancer-source-range", []string{},
+		"A CIDR that may connect to the Services of the cluster that are of type LoadBalancer, e.g. "+
+			"\"10.0.0.0/8\". Can be repeated.")
+	createClusterCmd.Flags().StringArrayVar(&HBARules, "hba", []string{}, "A pg_hba.conf rule of the cluster, "+
+		"e.g. \"hostssl all all 10.0.0.0/8 scram-sha-256\". Can be repeated, and the rules are applied in order "+
+		"after the rules that the Operator needs. Defaults to the value of \"HBA\" in pgo.yaml.")
+	createClusterCmd.Flags().BoolVar(&NetworkPolicy, "network-policy", false, "If true, NetworkPolicies only "+
+		"let pgBouncer, the PostgreSQL Operator and the namespaces and CIDRs of \"network-policy-namespace\" "+
+		"and \"network-policy-source-range\" connect to the cluster. Defaults to the value of \"NetworkPolicy\" "+
+		"in pgo.yaml.")
+	createClusterCmd.Flags().StringArrayVar(&NetworkPolicyNamespaces, "network-policy-namespace", []string{},
+		"A label selector of the namespaces whose pods may connect to the cluster, e.g. \"team=payments\". "+
+			"Can be repeated. Implies \"network-policy\".")
+	createClusterCmd.Flags().StringSliceVar(&NetworkPolicySourceRanges, "network-policy-source-range", []string{},
+		"A CIDR that may connect to the cluster, e.g. \"10.0.0.0/8\". Can be repeated. Implies \"network-policy\".")
+	addClusterMetadataFlags(createClusterCmd, "")
 	createClusterCmd.Flags().StringVarP(&Username, "username", "u", "", "The username to use for creating the PostgreSQL user with standard permissions. Defaults to the value in the PostgreSQL Operator configuration.")
 
 	// pgo create pgbouncer
 	createPgbouncerCmd.Flags().StringVarP(&Selector, "selector", "s", "", "The selector to use for cluster filtering.")
+	createPgbouncerCmd.Flags().BoolVar(&PgBouncerReadOnly, "read-only", false, "Adds a read-only pgBouncer, "+
+		"named \"<clusterName>-pgbouncer-ro\", whose pool connects to the replicas. pgBouncer is added as well "+
+		"if the cluster does not have it yet.")
+	createPgbouncerCmd.Flags().IntVar(&PgBouncerReplicas, "replicas", 0, "The number of pgBouncer pods "+
+		"of each pgBouncer deployment. Defaults to 1, or to the number of pods of the existing pgBouncer "+
+		"when only the read-only pgBouncer is added.")
 
 	// "pgo create pgouser" flags
 	createPgouserCmd.Flags().BoolVarP(&AllNamespaces, "all-namespaces", "", false, "specifies this user will have access to all namespaces.")
@@ -348,24 +571,54 @@ func init() {
 	createPgouserCmd.Flags().StringVarP(&PgouserRoles, "pgouser-roles", "", "", "specify a comma separated list of Roles for a pgouser")
 	createPgouserCmd.Flags().StringVarP(&PgouserNamespaces, "pgouser-namespaces", "", "", "specify a comma separated list of Namespaces for a pgouser")
 
+	// "pgo create profile" flags
+	createProfileCmd.Flags().StringVarP(&ProfileFile, "from-file", "f", "", "The path to a YAML or JSON file that holds "+
+		"the settings of the profile.")
+
 	// "pgo create policy" flags
 	createPolicyCmd.Flags().StringVarP(&PolicyFile, "in-file", "i", "", "The policy file path to use for adding a policy.")
 	createPolicyCmd.Flags().StringVarP(&PolicyURL, "url", "u", "", "The url to use for adding a policy.")
 
+	// "pgo create publication" flags
+	createPublicationCmd.Flags().StringVar(&PublicationName, "name", "", "The name of the publication, "+
+		"which consists of lowercase letters, digits and underscores.")
+	createPublicationCmd.Flags().StringVar(&LogicalReplicationDatabase, "database", "", "The database "+
+		"whose tables are published.")
+	createPublicationCmd.Flags().StringArrayVar(&PublicationTables, "table", []string{}, "A table to "+
+		"publish, which may be qualified by its schema, e.g. \"sales.orders\". Can be repeated. Defaults "+
+		"to all of the tables of the database.")
+
+	// "pgo create subscription" flags
+	createSubscriptionCmd.Flags().StringVar(&SubscriptionName, "name", "", "The name of the subscription, "+
+		"which consists of lowercase letters, digits and underscores. Defaults to the name of the publication.")
+	createSubscriptionCmd.Flags().StringVar(&SubscriptionSourceCluster, "source-cluster", "", "The cluster "+
+		"that has the publication.")
+	createSubscriptionCmd.Flags().StringVar(&PublicationName, "publication", "", "The publication of the "+
+		"source cluster to subscribe to.")
+	createSubscriptionCmd.Flags().StringVar(&LogicalReplicationDatabase, "database", "", "The database "+
+		"of the cluster that the subscription is created in. Its tables have to exist already. Defaults to "+
+		"the database of the publication.")
+
 	// "pgo create schedule" flags
 	createScheduleCmd.Flags().StringVarP(&ScheduleDatabase, "database", "", "", "The database to run the SQL policy against.")
 	createScheduleCmd.Flags().StringVarP(&PGBackRestType, "pgbackrest-backup-type", "", "", "The type of pgBackRest backup to schedule (full, diff or incr).")
-	createScheduleCmd.Flags().StringVarP(&BackrestStorageType, "pgbackrest-storage-type", "", "", "The type of storage to use when scheduling pgBackRest backups. Either \"local\", \"s3\" or both, comma separated. (default \"local\")")
+	createScheduleCmd.Flags().StringVarP(&BackrestStorageType, "pgbackrest-storage-type", "", "", "The type of storage to use when scheduling pgBackRest backups. Either \"local\", \"s3\", \"gcs\", \"azure\" or \"local\" combined with one of the others, comma separated. (default \"local\")")
 	createScheduleCmd.Flags().StringVarP(&CCPImageTag, "ccp-image-tag", "c", "", "The CCPImageTag to use for cluster creation. If specified, overrides the pgo.yaml setting.")
 	createScheduleCmd.Flags().StringVarP(&SchedulePolicy, "policy", "", "", "The policy to use for SQL schedules.")
 	createScheduleCmd.Flags().StringVarP(&Schedule, "schedule", "", "", "The schedule assigned to the cron task.")
 	createScheduleCmd.Flags().StringVarP(&ScheduleOptions, "schedule-opts", "", "", "The custom options passed to the create schedule API.")
-	createScheduleCmd.Flags().StringVarP(&ScheduleType, "schedule-type", "", "", "The type of schedule to be created (pgbackrest or policy).")
+	createScheduleCmd.Flags().StringVarP(&ScheduleType, "schedule-type", "", "", "The type of schedule to be created (pgbackrest, policy or hibernate).")
 	createScheduleCmd.Flags().StringVarP(&ScheduleSecret, "secret", "", "", "The secret name for the username and password of the PostgreSQL role for SQL schedules.")
 	createScheduleCmd.Flags().StringVarP(&Selector, "selector", "s", "", "The selector to use for cluster filtering.")
+	createScheduleCmd.Flags().StringVar(&ScheduleStart, "start-schedule", "", "The schedule of when a hibernate schedule starts the cluster.")
+	createScheduleCmd.Flags().StringVar(&ScheduleStop, "stop-schedule", "", "The schedule of when a hibernate schedule shuts the cluster down, "+
+		"unless it has active connections or a backup is running.")
 
 	// "pgo create user" flags
 	createUserCmd.Flags().BoolVar(&AllFlag, "all", false, "Create a user on every cluster.")
+	createUserCmd.Flags().StringVar(&AuthType, "auth", "password", "How the user authenticates. Either \"password\" "+
+		"or \"cert\", which has the Operator issue a client certificate for the user into a secret named "+
+		"\"<clusterName>-<username>-client-tls\". Requires the Operator to manage the certificates of the cluster.")
 	createUserCmd.Flags().BoolVarP(&ManagedUser, "managed", "", false, "Creates a user with secrets that can be managed by the Operator.")
 	createUserCmd.Flags().StringVarP(&OutputFormat, "output", "o", "", `The output format. Supported types are: "json"`)
 	createUserCmd.Flags().StringVarP(&Password, "password", "", "", "The password to use for creating a new user which overrides a generated password.")
@@ -417,6 +670,27 @@ var createPgoroleCmd = &cobra.Command{
 	},
 }
 
+// createProfileCmd ...
+var createProfileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Create a cluster profile",
+	Long: `Create a profile of settings that can be used to create clusters. For example:
+
+    pgo create profile small --from-file=small.yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if Namespace == "" {
+			Namespace = PGONamespace
+		}
+		log.Debug("create profile called ")
+
+		if len(args) == 0 {
+			fmt.Println(`Error: A profile name is required for this command.`)
+		} else {
+			createProfile(args, Namespace)
+		}
+	},
+}
+
 // createNamespaceCmd ...
 var createNamespaceCmd = &cobra.Command{
 	Use:   "namespace",
@@ -43,9 +43,11 @@ var deleteCmd = &cobra.Command{
 	pgo delete label mycluster --label=env=research
 	pgo delete pgbouncer mycluster
 	pgo delete pgbouncer mycluster --uninstall
+	pgo delete pgbouncer mycluster --read-only
 	pgo delete pgouser someuser
 	pgo delete pgorole somerole
 	pgo delete policy mypolicy
+	pgo delete profile myprofile
 	pgo delete namespace mynamespace
 	pgo delete schedule --schedule-name=mycluster-pgbackrest-full
 	pgo delete schedule --selector=name=mycluster
@@ -63,6 +65,7 @@ var deleteCmd = &cobra.Command{
 	* pgorole
 	* namespace
 	* policy
+	* profile
 	* user`)
 		} else {
 			switch args[0] {
@@ -73,6 +76,7 @@ var deleteCmd = &cobra.Command{
 				"pgouser",
 				"pgorole",
 				"policy",
+				"profile",
 				"namespace",
 				"schedule",
 				"user":
@@ -86,6 +90,7 @@ var deleteCmd = &cobra.Command{
 	* pgouser
 	* pgorole
 	* policy
+	* profile
 	* namespace
 	* user`)
 			}
@@ -189,6 +194,9 @@ func init() {
 	// this flag removes all of the pgbouncer machinery that is installed in the
 	// PostgreSQL cluster
 	deletePgbouncerCmd.Flags().BoolVar(&PgBouncerUninstall, "uninstall", false, `Used to remove any "pgbouncer" owned object and user from the PostgreSQL cluster`)
+	// "pgo delete pgbouncer --read-only"
+	// only removes the read-only pgBouncer, leaving the pgBouncer of the cluster
+	deletePgbouncerCmd.Flags().BoolVar(&PgBouncerReadOnly, "read-only", false, "Only deletes the read-only pgBouncer of the cluster.")
 
 	// "pgo delete pgorole"
 	// delete a role that is able to issue commands interface with the
@@ -203,6 +211,18 @@ func init() {
 	deletePgoroleCmd.Flags().BoolVar(&NoPrompt, "no-prompt", false,
 		"No command line confirmation before delete.")
 
+	// "pgo delete profile"
+	// delete a cluster profile
+	deleteCmd.AddCommand(deleteProfileCmd)
+	// "pgo delete profile --all"
+	// allows for the deletion of every cluster profile
+	deleteProfileCmd.Flags().BoolVar(&AllFlag, "all", false, "Delete all cluster profiles.")
+	// "pgo delete profile --no-prompt"
+	// does not display the warning prompt to ensure the user wishes to delete
+	// a cluster profile
+	deleteProfileCmd.Flags().BoolVar(&NoPrompt, "no-prompt", false,
+		"No command line confirmation before delete.")
+
 	// "pgo delete pgouser"
 	// delete a user that is able to issue commands to the PostgreSQL Operator
 	deleteCmd.AddCommand(deletePgouserCmd)
@@ -399,6 +419,29 @@ var deletePgoroleCmd = &cobra.Command{
 	},
 }
 
+// deleteProfileCmd ...
+var deleteProfileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Delete a cluster profile",
+	Long: `Delete a cluster profile. Clusters created from the profile are not affected. For example:
+
+    pgo delete profile myprofile`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if Namespace == "" {
+			Namespace = PGONamespace
+		}
+		if len(args) == 0 && !AllFlag {
+			fmt.Println("Error: A profile name or --all is required for this command.")
+		} else {
+			if util.AskForConfirmation(NoPrompt, "") {
+				deleteProfile(args, Namespace)
+			} else {
+				fmt.Println("Aborting...")
+			}
+		}
+	},
+}
+
 // deletePgouserCmd ...
 var deletePgouserCmd = &cobra.Command{
 	Use:   "pgouser",
@@ -428,13 +471,16 @@ var deletePgbouncerCmd = &cobra.Command{
 	Short: "Delete a pgbouncer from a cluster",
 	Long: `Delete a pgbouncer from a cluster. For example:
 
-	pgo delete pgbouncer mycluster`,
+	pgo delete pgbouncer mycluster
+	pgo delete pgbouncer mycluster --read-only`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if Namespace == "" {
 			Namespace = PGONamespace
 		}
 		if len(args) == 0 && Selector == "" {
 			fmt.Println("Error: A cluster name or selector is required for this command.")
+		} else if PgBouncerReadOnly && PgBouncerUninstall {
+			fmt.Println("Error: The --read-only and --uninstall flags cannot be used together.")
 		} else {
 			if util.AskForConfirmation(NoPrompt, "") {
 				deletePgbouncer(args, Namespace)
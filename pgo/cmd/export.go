@@ -0,0 +1,176 @@
+package cmd
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+	"github.com/crunchydata/postgres-operator/pgo/api"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// ApplyFile is the path to the cluster export that "pgo apply" creates a
+// cluster from
+var ApplyFile string
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export an Operator resource",
+	Long: `EXPORT allows you to export the definition of an Operator resource. For example:
+
+	pgo export cluster mycluster -o yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		log.Debug("export called")
+
+		if len(args) == 0 || args[0] != "cluster" {
+			fmt.Println(`Error: You must specify the type of resource to export.  Valid resource types include:
+	* cluster`)
+		}
+	},
+}
+
+var exportClusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Short: "Export the definition of a PostgreSQL cluster",
+	Long: `Export the definition of a PostgreSQL cluster, i.e. its pgcluster and pgreplicas,
+the policies applied to it, its schedules, its pgBouncer settings and its users.
+Secrets are only referenced by their names. The export can be kept in version
+control and applied with "pgo apply -f" to create the cluster again, e.g. in
+another namespace. For example:
+
+	pgo export cluster mycluster -o yaml > mycluster.yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if Namespace == "" {
+			Namespace = PGONamespace
+		}
+		log.Debug("export cluster called")
+
+		if len(args) != 1 {
+			fmt.Println("Error: A single cluster name argument is required.")
+			os.Exit(1)
+		}
+
+		exportCluster(args[0], Namespace)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportClusterCmd)
+
+	exportClusterCmd.Flags().StringVarP(&OutputFormat, "output", "o", "", `The output format. `+
+		`Supported types are: "json", "yaml". Defaults to "yaml".`)
+}
+
+// exportCluster prints the export of a cluster
+func exportCluster(clusterName, ns string) {
+	if OutputFormat != "" && OutputFormat != "json" && OutputFormat != "yaml" {
+		fmt.Println(`Error: The output format must be "json" or "yaml".`)
+		os.Exit(1)
+	}
+
+	request := msgs.ExportClusterRequest{
+		ClusterName: clusterName,
+		Namespace:   ns,
+	}
+
+	response, err := api.ExportCluster(httpclient, &SessionCredentials, request)
+
+	if err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
+
+	if response.Status.Code != msgs.Ok {
+		fmt.Println("Error: " + response.Status.Msg)
+		os.Exit(1)
+	}
+
+	if OutputFormat == "json" {
+		printJSON(response.Result)
+		return
+	}
+
+	content, err := yaml.Marshal(response.Result)
+	if err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Print(string(content))
+}
+
+// applyCluster creates a cluster from a cluster export that is read from a
+// YAML or JSON file
+func applyCluster(path, ns string) {
+	export, err := readClusterExport(path)
+	if err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
+
+	request := msgs.ApplyClusterRequest{
+		Export:    *export,
+		Namespace: ns,
+	}
+
+	response, err := api.ApplyCluster(httpclient, &SessionCredentials, request)
+
+	if err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
+
+	for _, result := range response.Results {
+		fmt.Println(result)
+	}
+
+	if response.Status.Code != msgs.Ok {
+		fmt.Println("Error: " + response.Status.Msg)
+		os.Exit(1)
+	}
+}
+
+// readClusterExport reads a cluster export from a YAML or JSON file. Fields
+// that are not known are rejected, so that a typo does not go unnoticed
+func readClusterExport(path string) (*msgs.ClusterExport, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &msgs.ClusterExport{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(export); err != nil {
+		return nil, fmt.Errorf("invalid cluster export %s: %s", path, err)
+	}
+
+	return export, nil
+}
@@ -0,0 +1,93 @@
+package cmd
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"fmt"
+	"os"
+
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+	"github.com/crunchydata/postgres-operator/pgo/api"
+)
+
+var (
+	// PublicationName is the name of a publication or of the publication that
+	// a subscription subscribes to
+	PublicationName string
+	// PublicationTables are the tables of a publication
+	PublicationTables []string
+	// SubscriptionName is the name of a subscription
+	SubscriptionName string
+	// SubscriptionSourceCluster is the cluster that has the publication that a
+	// subscription subscribes to
+	SubscriptionSourceCluster string
+	// LogicalReplicationDatabase is the database of a publication or of a
+	// subscription
+	LogicalReplicationDatabase string
+)
+
+// createPublication creates a publication of a database of a cluster
+func createPublication(args []string, ns string) {
+	r := new(msgs.CreatePublicationRequest)
+	r.ClusterName = args[0]
+	r.Namespace = ns
+	r.ClientVersion = msgs.PGO_VERSION
+	r.Name = PublicationName
+	r.Database = LogicalReplicationDatabase
+	r.Tables = PublicationTables
+
+	response, err := api.CreatePublication(httpclient, &SessionCredentials, r)
+	if err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(2)
+	}
+
+	if response.Status.Code == msgs.Ok {
+		for _, v := range response.Results {
+			fmt.Println(v)
+		}
+	} else {
+		fmt.Println("Error: " + response.Status.Msg)
+		os.Exit(2)
+	}
+}
+
+// createSubscription subscribes a cluster to a publication of another cluster
+func createSubscription(args []string, ns string) {
+	r := new(msgs.CreateSubscriptionRequest)
+	r.ClusterName = args[0]
+	r.Namespace = ns
+	r.ClientVersion = msgs.PGO_VERSION
+	r.Name = SubscriptionName
+	r.SourceCluster = SubscriptionSourceCluster
+	r.Publication = PublicationName
+	r.Database = LogicalReplicationDatabase
+
+	response, err := api.CreateSubscription(httpclient, &SessionCredentials, r)
+	if err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(2)
+	}
+
+	if response.Status.Code == msgs.Ok {
+		for _, v := range response.Results {
+			fmt.Println(v)
+		}
+	} else {
+		fmt.Println("Error: " + response.Status.Msg)
+		os.Exit(2)
+	}
+}
@@ -47,6 +47,16 @@ type updatePgBouncerTextPadding struct {
 // or are removed (in the case of a pgo delete pgbouncer)
 var PgBouncerUninstall bool
 
+// PgBouncerReadOnly is used to add or delete the read-only pgBouncer, whose
+// pool connects to the replicas of the cluster
+var PgBouncerReadOnly bool
+
+// PgBouncerReplicas is the number of pods of each pgBouncer deployment
+var PgBouncerReplicas int
+
+// ShowPgBouncerStats is used to show the statistics of the pgBouncer pools
+var ShowPgBouncerStats bool
+
 func createPgbouncer(args []string, ns string) {
 
 	if Selector == "" && len(args) == 0 {
@@ -59,6 +69,8 @@ func createPgbouncer(args []string, ns string) {
 	r.Namespace = ns
 	r.Selector = Selector
 	r.ClientVersion = msgs.PGO_VERSION
+	r.ReadOnly = PgBouncerReadOnly
+	r.Replicas = int32(PgBouncerReplicas)
 
 	response, err := api.CreatePgbouncer(httpclient, &SessionCredentials, r)
 	if err != nil {
@@ -94,6 +106,7 @@ func deletePgbouncer(args []string, ns string) {
 		Selector:      Selector,
 		Namespace:     ns,
 		Uninstall:     PgBouncerUninstall,
+		ReadOnly:      PgBouncerReadOnly,
 	}
 
 	response, err := api.DeletePgbouncer(httpclient, &SessionCredentials, &request)
@@ -155,8 +168,28 @@ func printShowPgBouncerText(response msgs.ShowPgBouncerResponse) {
 		return
 	}
 
+	// the read-only pgBouncer is printed in a row of its own, and shares the
+	// credentials of the pgBouncer
+	rows := []msgs.ShowPgBouncerDetail{}
+
+	for _, result := range response.Results {
+		rows = append(rows, result)
+
+		if result.HasReadOnly {
+			rows = append(rows, msgs.ShowPgBouncerDetail{
+				ClusterName:       result.ClusterName,
+				HasPgBouncer:      true,
+				Password:          result.Password,
+				ServiceClusterIP:  result.ReadOnlyServiceClusterIP,
+				ServiceExternalIP: result.ReadOnlyServiceExternalIP,
+				ServiceName:       result.ReadOnlyServiceName,
+				Username:          result.Username,
+			})
+		}
+	}
+
 	// make the interface for the pgbouncer clusters
-	showPgBouncerInterface := makeShowPgBouncerInterface(response.Results)
+	showPgBouncerInterface := makeShowPgBouncerInterface(rows)
 
 	// format the header
 	// start by setting up the different text paddings
@@ -172,8 +205,36 @@ func printShowPgBouncerText(response msgs.ShowPgBouncerResponse) {
 	printShowPgBouncerTextHeader(padding)
 
 	// iterate through the reuslts and print them out
+	for _, row := range rows {
+		printShowPgBouncerTextRow(row, padding)
+	}
+
+	// print the statistics, if they were requested
 	for _, result := range response.Results {
-		printShowPgBouncerTextRow(result, padding)
+		for _, stats := range result.Stats {
+			printShowPgBouncerStatsText(stats)
+		}
+	}
+}
+
+// printShowPgBouncerStatsText prints out the statistics of a pgBouncer
+// deployment, followed by those of each of its pools
+func printShowPgBouncerStatsText(stats msgs.PgBouncerStats) {
+	fmt.Println("")
+	fmt.Printf("%s (%d pods)\n", stats.Name, stats.Pods)
+	fmt.Printf("\tclients : %d active, %d waiting, max wait %.2fs, average wait %dus\n",
+		stats.ClientsActive, stats.ClientsWaiting, stats.MaxWait, stats.AverageWaitTime)
+	fmt.Printf("\tservers : %d, pool size %d, saturation %.0f%%\n",
+		stats.Servers, stats.PoolSize, stats.Saturation*100)
+
+	for _, pool := range stats.Pools {
+		fmt.Printf("\tpool : %s/%s, %d active, %d waiting clients, %d active, %d idle servers, "+
+			"saturation %.0f%%\n", pool.Database, pool.User, pool.ClientsActive, pool.ClientsWaiting,
+			pool.ServersActive, pool.ServersIdle, pool.Saturation*100)
+	}
+
+	for _, err := range stats.Errors {
+		fmt.Printf("\terror : %s\n", err)
 	}
 }
 
@@ -294,6 +355,7 @@ func showPgBouncer(namespace string, clusterNames []string) {
 		ClusterNames: clusterNames,
 		Namespace:    namespace,
 		Selector:     Selector,
+		Stats:        ShowPgBouncerStats,
 	}
 
 	// and make the API request!
@@ -327,10 +389,17 @@ func updatePgBouncer(namespace string, clusterNames []string) {
 		os.Exit(1)
 	}
 
+	// there has to be something to update
+	if !RotatePassword && PgBouncerReplicas == 0 {
+		fmt.Println("Error: You must set either `--rotate-password` or `--replicas`")
+		os.Exit(1)
+	}
+
 	// next prepare the request!
 	request := msgs.UpdatePgBouncerRequest{
 		ClusterNames:   clusterNames,
 		Namespace:      namespace,
+		Replicas:       int32(PgBouncerReplicas),
 		RotatePassword: RotatePassword,
 		Selector:       Selector,
 	}
@@ -27,12 +27,14 @@ import (
 
 var applyCmd = &cobra.Command{
 	Use:   "apply",
-	Short: "Apply a policy",
-	Long: `APPLY allows you to apply a Policy to a set of clusters. For example:
+	Short: "Apply a policy or a cluster export",
+	Long: `APPLY allows you to apply a Policy to a set of clusters, or to create a cluster
+from the output of "pgo export cluster". For example:
 
 	pgo apply mypolicy1 --selector=name=mycluster
 	pgo apply mypolicy1 --selector=someotherpolicy
-	pgo apply mypolicy1 --selector=someotherpolicy --dry-run`,
+	pgo apply mypolicy1 --selector=someotherpolicy --dry-run
+	pgo apply -f mycluster.yaml -n newnamespace`,
 	Run: func(cmd *cobra.Command, args []string) {
 		log.Debug("apply called")
 
@@ -40,6 +42,15 @@ var applyCmd = &cobra.Command{
 			Namespace = PGONamespace
 		}
 
+		if ApplyFile != "" {
+			if DryRun {
+				fmt.Println("Error: The --dry-run flag can not be used with --filename.")
+				os.Exit(1)
+			}
+			applyCluster(ApplyFile, Namespace)
+			return
+		}
+
 		if Selector == "" {
 			fmt.Println("Error: Selector is required to apply a policy.")
 			return
@@ -55,6 +66,7 @@ var applyCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(applyCmd)
 
+	applyCmd.Flags().StringVarP(&ApplyFile, "filename", "f", "", "The path to a cluster export to create the cluster from.")
 	applyCmd.Flags().StringVarP(&Selector, "selector", "s", "", "The selector to use for cluster filtering.")
 	applyCmd.Flags().BoolVarP(&DryRun, "dry-run", "", false, "Shows the clusters that the label would be applied to, without labelling them.")
 
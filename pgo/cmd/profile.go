@@ -0,0 +1,248 @@
+package cmd
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+	"github.com/crunchydata/postgres-operator/pgo/api"
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+// ProfileFile is the path to the file that holds the settings of a cluster
+// profile
+var ProfileFile string
+
+// createProfile creates a cluster profile from the settings in a YAML or JSON
+// file. The settings use the same names as a request to create a cluster
+func createProfile(args []string, ns string) {
+	if ProfileFile == "" {
+		fmt.Println("Error: The --from-file flag is required.")
+		os.Exit(1)
+	}
+
+	profile, err := readProfileFile(ProfileFile)
+	if err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
+
+	request := msgs.CreateProfileRequest{
+		Name:      args[0],
+		Profile:   *profile,
+		Namespace: ns,
+	}
+
+	response, err := api.CreateProfile(httpclient, &SessionCredentials, request)
+
+	if err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
+
+	if response.Status.Code != msgs.Ok {
+		fmt.Println("Error: " + response.Status.Msg)
+		os.Exit(1)
+	}
+
+	fmt.Println("created profile " + args[0])
+}
+
+// showProfile prints the settings of cluster profiles
+func showProfile(args []string, ns string) {
+	if len(args) == 0 && !AllFlag {
+		fmt.Println("Error: either a profile name or --all flag is required")
+		os.Exit(1)
+	}
+
+	if OutputFormat != "" && OutputFormat != "json" {
+		fmt.Println(`Error: The output format must be "json".`)
+		os.Exit(1)
+	}
+
+	request := msgs.ShowProfileRequest{
+		Names:     args,
+		AllFlag:   AllFlag,
+		Namespace: ns,
+	}
+
+	response, err := api.ShowProfile(httpclient, &SessionCredentials, request)
+
+	if err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
+
+	if OutputFormat == "json" {
+		printJSON(response)
+		return
+	}
+
+	if response.Status.Code != msgs.Ok {
+		fmt.Println("Error: " + response.Status.Msg)
+		os.Exit(1)
+	}
+
+	if len(response.Results) == 0 {
+		fmt.Println("No profiles found.")
+		return
+	}
+
+	log.Debugf("response = %v", response)
+
+	for _, detail := range response.Results {
+		settings, err := getProfileSettings(detail.Profile)
+		if err != nil {
+			fmt.Println("Error: " + err.Error())
+			os.Exit(1)
+		}
+
+		content, err := yaml.Marshal(settings)
+		if err != nil {
+			fmt.Println("Error: " + err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Println("")
+		fmt.Println("profile : " + detail.Name)
+		fmt.Print(string(content))
+	}
+}
+
+// deleteProfile deletes cluster profiles
+func deleteProfile(args []string, ns string) {
+	request := msgs.DeleteProfileRequest{
+		Names:     args,
+		AllFlag:   AllFlag,
+		Namespace: ns,
+	}
+
+	response, err := api.DeleteProfile(httpclient, &SessionCredentials, request)
+
+	if err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
+
+	if response.Status.Code != msgs.Ok {
+		fmt.Println("Error: " + response.Status.Msg)
+		os.Exit(1)
+	}
+
+	for _, result := range response.Results {
+		fmt.Println(result)
+	}
+}
+
+// getProfileSettings returns the settings that are set by a profile, i.e.
+// every setting that was set explicitly or does not have an empty value
+func getProfileSettings(profile msgs.CreateClusterRequest) (map[string]interface{}, error) {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := map[string]interface{}{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+
+	delete(settings, "SetFields")
+
+	for name, value := range settings {
+		if isProfileSetField(profile, name) {
+			continue
+		}
+
+		switch v := value.(type) {
+		case nil:
+			delete(settings, name)
+		case string:
+			if v == "" {
+				delete(settings, name)
+			}
+		case float64:
+			if v == 0 {
+				delete(settings, name)
+			}
+		case bool:
+			if !v {
+				delete(settings, name)
+			}
+		case []interface{}:
+			if len(v) == 0 {
+				delete(settings, name)
+			}
+		}
+	}
+
+	return settings, nil
+}
+
+// readProfileFile reads the settings of a profile from a YAML or JSON file.
+// Settings that are not known are rejected, so that a typo does not go
+// unnoticed
+func readProfileFile(path string) (*msgs.CreateClusterRequest, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &msgs.CreateClusterRequest{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(profile); err != nil {
+		return nil, fmt.Errorf("invalid profile file %s: %s", path, err)
+	}
+
+	// every setting in the file is set explicitly, so that e.g.
+	// "AutofailFlag: false" is used even though it is empty
+	settings := map[string]interface{}{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("invalid profile file %s: %s", path, err)
+	}
+
+	profile.SetFields = make([]string, 0, len(settings))
+	for name := range settings {
+		profile.SetFields = append(profile.SetFields, name)
+	}
+	sort.Strings(profile.SetFields)
+
+	return profile, nil
+}
+
+// isProfileSetField returns true if a setting of a profile was set explicitly
+func isProfileSetField(profile msgs.CreateClusterRequest, name string) bool {
+	for _, setField := range profile.SetFields {
+		if setField == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package cmd
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+	"github.com/crunchydata/postgres-operator/pgo/api"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// RPO is the recovery point objective to check the clusters against. If it is
+// not set, the value from the Operator configuration is used
+var RPO string
+
+// ShowRecoverabilityCmd represents the show recoverability command
+var ShowRecoverabilityCmd = &cobra.Command{
+	Use:   "recoverability",
+	Short: "Show the recoverability window of clusters",
+	Long: `Show the earliest and latest point that each pgBackRest repository of a
+cluster can be recovered to, any gaps in the WAL archive, the age of the last
+backups and the size of the repository, and flag the clusters that breach the
+recovery point objective (RPO). For example:
+
+	pgo show recoverability mycluster
+	pgo show recoverability --selector=env=research
+	pgo show recoverability --all --rpo=1h
+	pgo show recoverability --all -o json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		log.Debug("show recoverability called")
+
+		if Namespace == "" {
+			Namespace = PGONamespace
+		}
+
+		// if the AllFlag is set, set the Selector to "*"
+		if AllFlag {
+			Selector = msgs.RecoverabilityShowAllSelector
+		}
+
+		if Selector == "" && len(args) == 0 {
+			fmt.Println(`Error: You must specify at least one cluster, selector, or use the "--all" flag.`)
+			os.Exit(1)
+		}
+
+		if len(args) > 0 {
+			for _, clusterName := range args {
+				showRecoverability(Namespace, fmt.Sprintf("name=%s", clusterName))
+			}
+			return
+		}
+
+		showRecoverability(Namespace, Selector)
+	},
+}
+
+// formatRecoverabilityAge returns the age of a backup rounded to the minute
+func formatRecoverabilityAge(age int64) string {
+	return (time.Duration(age) * time.Second).Round(time.Minute).String()
+}
+
+// formatRecoverabilityBackup returns a human readable description of the last
+// backup of a given type
+func formatRecoverabilityBackup(backup *msgs.RecoverabilityBackup) string {
+	if backup == nil {
+		return "none"
+	}
+
+	return fmt.Sprintf("%s (%s ago)", backup.Label, formatRecoverabilityAge(backup.Age))
+}
+
+// formatRecoverabilityTime returns the time in a human readable form, or
+// "none" if it is not set
+func formatRecoverabilityTime(t *time.Time) string {
+	if t == nil {
+		return "none"
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+// printRecoverabilityText renders a text response
+func printRecoverabilityText(response msgs.ShowRecoverabilityResponse) {
+	// if the request errored, return the message here and exit with an error
+	if response.Status.Code != msgs.Ok {
+		fmt.Println("Error: " + response.Status.Msg)
+		os.Exit(1)
+	}
+
+	// if no results returned, return an error
+	if len(response.Results) == 0 {
+		fmt.Println("Nothing found.")
+		return
+	}
+
+	// sort the results by cluster and then by repository
+	results := response.Results
+	sort.SliceStable(results, func(i int, j int) bool {
+		if results[i].ClusterName != results[j].ClusterName {
+			return results[i].ClusterName < results[j].ClusterName
+		}
+		return results[i].StorageType < results[j].StorageType
+	})
+
+	for _, result := range results {
+		fmt.Println("")
+		fmt.Printf("cluster: %s\n", result.ClusterName)
+		fmt.Printf("storage type: %s\n", result.StorageType)
+
+		if result.Error != "" {
+			fmt.Printf("\t%s\n", RED("Error: "+result.Error))
+			continue
+		}
+
+		if result.RPOBreached {
+			fmt.Printf("\tRPO (%s): %s\n", result.RPO,
+				RED("BREACHED - "+strings.Join(result.RPOBreaches, ", ")))
+		} else {
+			fmt.Printf("\tRPO (%s): %s\n", result.RPO, GREEN("OK"))
+		}
+
+		fmt.Printf("\tstanza: %s\n", result.Stanza)
+		fmt.Printf("\tearliest recoverable point: %s", formatRecoverabilityTime(result.EarliestRecoverableTime))
+		if result.EarliestRecoverableWAL != "" {
+			fmt.Printf(" (WAL %s)", result.EarliestRecoverableWAL)
+		}
+		fmt.Println("")
+		fmt.Printf("\tlatest recoverable point: %s", formatRecoverabilityTime(result.LatestRecoverableTime))
+		if result.LatestRecoverableWAL != "" {
+			fmt.Printf(" (WAL %s)", result.LatestRecoverableWAL)
+		}
+		fmt.Println("")
+		fmt.Printf("\tlatest backup: %s\n", formatRecoverabilityTime(result.LatestBackupTime))
+		fmt.Printf("\tlast full backup: %s\n", formatRecoverabilityBackup(result.LastFullBackup))
+		fmt.Printf("\tlast diff backup: %s\n", formatRecoverabilityBackup(result.LastDiffBackup))
+		fmt.Printf("\tlast incr backup: %s\n", formatRecoverabilityBackup(result.LastIncrBackup))
+
+		repoSize, repoUnit := getSizeAndUnit(result.RepoSize)
+		fmt.Printf("\trepository size: %.f%s\n", repoSize, getUnitString(repoUnit))
+
+		if len(result.WALGaps) == 0 {
+			fmt.Println("\tWAL gaps: none")
+			continue
+		}
+
+		fmt.Println("\tWAL gaps:")
+		for _, gap := range result.WALGaps {
+			fmt.Printf("\t\t%s\n", RED(gap))
+		}
+	}
+}
+
+// showRecoverability handles processing the "pgo show recoverability" command
+func showRecoverability(namespace, selector string) {
+	request := msgs.ShowRecoverabilityRequest{
+		Namespace: namespace,
+		RPO:       RPO,
+		Selector:  selector,
+	}
+
+	response, err := api.ShowRecoverability(httpclient, &SessionCredentials, request)
+
+	if err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
+
+	// render the next bit based on the output type
+	switch OutputFormat {
+	case "json":
+		printJSON(response)
+	default:
+		printRecoverabilityText(response)
+	}
+}
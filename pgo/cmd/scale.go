@@ -63,6 +63,12 @@ func init() {
 	scaleCmd.Flags().StringVarP(&ContainerResources, "resources-config", "", "", "The name of a container resource configuration in pgo.yaml that holds CPU and memory requests and limits.")
 	scaleCmd.Flags().StringVarP(&StorageConfig, "storage-config", "", "", "The name of a Storage config in pgo.yaml to use for the replica storage.")
 	scaleCmd.Flags().StringVarP(&NodeLabel, "node-label", "", "", "The node label (key) to use in placing the replica database. If not set, any node is used.")
+	scaleCmd.Flags().StringSliceVar(&Tolerations, "toleration", []string{}, "A toleration for the pods of "+
+		"the replicas, in the same format as the taints of \"kubectl taint\", i.e. \"key=value:Effect\". "+
+		"Can be repeated. Defaults to the tolerations of the cluster.")
+	scaleCmd.Flags().StringVar(&PriorityClassName, "priority-class", "", "The name of the PriorityClass of "+
+		"the pods of the replicas. Defaults to the priority class of the cluster.")
+	scaleCmd.Flags().StringVarP(&SnapshotName, "snapshot", "", "", "The name of a snapshot backup of the cluster to provision the replica storage from. The replica then only needs to replay the WAL since the snapshot was taken.")
 }
 
 func scaleCluster(args []string, ns string) {
@@ -70,7 +76,8 @@ func scaleCluster(args []string, ns string) {
 	for _, arg := range args {
 		log.Debugf(" %s ReplicaCount is %d", arg, ReplicaCount)
 		response, err := api.ScaleCluster(httpclient, arg, ReplicaCount, ContainerResources,
-			StorageConfig, NodeLabel, CCPImageTag, ServiceType, &SessionCredentials, ns)
+			StorageConfig, NodeLabel, CCPImageTag, ServiceType, SnapshotName, Tolerations, PriorityClassName,
+			&SessionCredentials, ns)
 
 		if err != nil {
 			fmt.Println("Error: " + err.Error())
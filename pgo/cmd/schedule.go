@@ -38,6 +38,8 @@ type schedule struct {
 	selector            string
 	policy              string
 	database            string
+	stopSchedule        string
+	startSchedule       string
 }
 
 func createSchedule(args []string, ns string) {
@@ -58,6 +60,8 @@ func createSchedule(args []string, ns string) {
 		scheduleType:        ScheduleType,
 		policy:              SchedulePolicy,
 		database:            ScheduleDatabase,
+		stopSchedule:        ScheduleStop,
+		startSchedule:       ScheduleStart,
 	}
 
 	err := s.validateSchedule()
@@ -79,6 +83,8 @@ func createSchedule(args []string, ns string) {
 		Database:            ScheduleDatabase,
 		Secret:              ScheduleSecret,
 		Namespace:           ns,
+		StopSchedule:        ScheduleStop,
+		StartSchedule:       ScheduleStart,
 	}
 
 	response, err := api.CreateSchedule(httpclient, &SessionCredentials, r)
@@ -192,12 +198,15 @@ func showSchedule(args []string, ns string) {
 }
 
 func (s *schedule) validateSchedule() error {
-	if err := scheduler.ValidateSchedule(s.schedule); err != nil {
+	if err := scheduler.ValidateScheduleType(s.scheduleType); err != nil {
 		return err
 	}
 
-	if err := scheduler.ValidateScheduleType(s.scheduleType); err != nil {
-		return err
+	// a hibernate schedule has its own stop and start schedules instead
+	if strings.ToLower(s.scheduleType) != "hibernate" {
+		if err := scheduler.ValidateSchedule(s.schedule); err != nil {
+			return err
+		}
 	}
 
 	if err := scheduler.ValidateBackRestSchedule(s.scheduleType, s.clusterName, s.selector, s.backrestType,
@@ -209,5 +218,10 @@ func (s *schedule) validateSchedule() error {
 		return err
 	}
 
+	if err := scheduler.ValidateHibernateSchedule(strings.ToLower(s.scheduleType), s.stopSchedule,
+		s.startSchedule); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -27,6 +27,9 @@ const TreeTrunk = "\t"
 
 var AllFlag bool
 
+// ShowHBA shows the pg_hba.conf rules of a cluster
+var ShowHBA bool
+
 var ShowCmd = &cobra.Command{
 	Use:   "show",
 	Short: "Show the description of a cluster",
@@ -39,6 +42,7 @@ var ShowCmd = &cobra.Command{
 	pgo show pgouser someuser
 	pgo show policy policy1
 	pgo show pvc mycluster
+	pgo show recoverability --all
 	pgo show namespace
 	pgo show workflow 25927091-b343-4017-be4b-71575f0b3eb5
 	pgo show user --selector=name=mycluster`,
@@ -52,7 +56,9 @@ Valid resource types include:
 	* pgbouncer
 	* pgouser
 	* policy
+	* profile
 	* pvc
+	* recoverability
 	* namespace
 	* workflow
 	* user
@@ -60,7 +66,7 @@ Valid resource types include:
 		} else {
 			switch args[0] {
 			case "backup", "cluster", "config", "pgbouncer", "pgouser",
-				"policy", "pvc", "schedule", "namespace", "workflow",
+				"policy", "profile", "pvc", "recoverability", "schedule", "namespace", "workflow",
 				"user":
 				break
 			default:
@@ -72,7 +78,9 @@ Valid resource types include:
 	* pgbouncer
 	* pgouser
 	* policy
+	* profile
 	* pvc
+	* recoverability
 	* namespace
 	* workflow
 	* user`)
@@ -94,13 +102,17 @@ func init() {
 	ShowCmd.AddCommand(ShowPgouserCmd)
 	ShowCmd.AddCommand(ShowPgoroleCmd)
 	ShowCmd.AddCommand(ShowPolicyCmd)
+	ShowCmd.AddCommand(ShowProfileCmd)
 	ShowCmd.AddCommand(ShowPVCCmd)
+	ShowCmd.AddCommand(ShowRecoverabilityCmd)
 	ShowCmd.AddCommand(ShowWorkflowCmd)
 	ShowCmd.AddCommand(ShowScheduleCmd)
 	ShowCmd.AddCommand(ShowUserCmd)
 
-	ShowBackupCmd.Flags().StringVarP(&showBackupType, "backup-type", "", "pgbackrest", "The backup type output to list. Valid choices are pgbackrest or pgdump.")
+	ShowBackupCmd.Flags().StringVarP(&showBackupType, "backup-type", "", "pgbackrest", "The backup type output to list. Valid choices are pgbackrest, pgdump or snapshot.")
 	ShowClusterCmd.Flags().StringVarP(&CCPImageTag, "ccp-image-tag", "", "", "Filter the results based on the image tag of the cluster.")
+	ShowClusterCmd.Flags().BoolVar(&ShowHBA, "hba", false, "Show the pg_hba.conf rules of the cluster, "+
+		"including the ones that the Operator needs.")
 	ShowClusterCmd.Flags().StringVarP(&OutputFormat, "output", "o", "", "The output format. Currently, json is the only supported value.")
 	ShowClusterCmd.Flags().StringVarP(&Selector, "selector", "s", "", "The selector to use for cluster filtering.")
 	ShowNamespaceCmd.Flags().BoolVar(&AllFlag, "all", false, "show all resources.")
@@ -108,7 +120,13 @@ func init() {
 	ShowPolicyCmd.Flags().BoolVar(&AllFlag, "all", false, "show all resources.")
 	ShowPgBouncerCmd.Flags().StringVarP(&Selector, "selector", "s", "", "The selector to use for cluster filtering.")
 	ShowPgBouncerCmd.Flags().StringVarP(&OutputFormat, "output", "o", "", `The output format. Supported types are: "json"`)
+	ShowPgBouncerCmd.Flags().BoolVar(&ShowPgBouncerStats, "stats", false, "Show the statistics of the pgbouncer pools, "+
+		"e.g. the waiting clients, the average wait time and the saturation of the pools, aggregated across the pgbouncer pods.")
 	ShowPVCCmd.Flags().BoolVar(&AllFlag, "all", false, "show all resources.")
+	ShowRecoverabilityCmd.Flags().BoolVar(&AllFlag, "all", false, "show all clusters.")
+	ShowRecoverabilityCmd.Flags().StringVarP(&OutputFormat, "output", "o", "", `The output format. Supported types are: "json"`)
+	ShowRecoverabilityCmd.Flags().StringVarP(&RPO, "rpo", "", "", "The recovery point objective to check the clusters against, e.g. 1h. Defaults to the BackrestRPO setting of the Operator.")
+	ShowRecoverabilityCmd.Flags().StringVarP(&Selector, "selector", "s", "", "The selector to use for cluster filtering.")
 	ShowScheduleCmd.Flags().StringVarP(&Selector, "selector", "s", "", "The selector to use for cluster filtering.")
 	ShowScheduleCmd.Flags().StringVarP(&ScheduleName, "schedule-name", "", "", "The name of the schedule to show.")
 	ShowScheduleCmd.Flags().BoolVar(&NoPrompt, "no-prompt", false, "No command line confirmation.")
@@ -119,6 +137,8 @@ func init() {
 	ShowUserCmd.Flags().BoolVar(&ShowSystemAccounts, "show-system-accounts", false, "Include the system accounts in the results.")
 	ShowPgouserCmd.Flags().BoolVar(&AllFlag, "all", false, "show all resources.")
 	ShowPgoroleCmd.Flags().BoolVar(&AllFlag, "all", false, "show all resources.")
+	ShowProfileCmd.Flags().BoolVar(&AllFlag, "all", false, "show all resources.")
+	ShowProfileCmd.Flags().StringVarP(&OutputFormat, "output", "o", "", `The output format. Supported types are: "json"`)
 }
 
 var ShowConfigCmd = &cobra.Command{
@@ -138,10 +158,12 @@ var ShowConfigCmd = &cobra.Command{
 var ShowPgBouncerCmd = &cobra.Command{
 	Use:   "pgbouncer",
 	Short: "Show pgbouncer deployment information",
-	Long: `Show user, password, and service information about a pgbouncer deployment. For example:
+	Long: `Show user, password, and service information about a pgbouncer deployment, and about the
+read-only pgbouncer deployment of the cluster if it has one. For example:
 
 	pgo show pgbouncer hacluster
 	pgo show pgounbcer --selector=app=payment
+	pgo show pgbouncer hacluster --stats -o json
 	`,
 
 	Run: func(cmd *cobra.Command, args []string) {
@@ -180,6 +202,21 @@ var ShowPgoroleCmd = &cobra.Command{
 	},
 }
 
+var ShowProfileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Show cluster profile information",
+	Long: `Show the settings of cluster profiles. For example:
+
+	pgo show profile small
+	pgo show profile --all`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if Namespace == "" {
+			Namespace = PGONamespace
+		}
+		showProfile(args, Namespace)
+	},
+}
+
 var ShowNamespaceCmd = &cobra.Command{
 	Use:   "namespace",
 	Short: "Show namespace information",
@@ -265,8 +302,10 @@ var ShowBackupCmd = &cobra.Command{
 				showBackrest(args, Namespace)
 			} else if showBackupType == config.LABEL_BACKUP_TYPE_PGDUMP {
 				showpgDump(args, Namespace)
+			} else if showBackupType == config.LABEL_BACKUP_TYPE_SNAPSHOT {
+				showSnapshotBackups(args, Namespace)
 			} else {
-				fmt.Println("Error: Valid backup-type values are pgbackrest and pgdump. The default if not supplied is pgbackrest.")
+				fmt.Println("Error: Valid backup-type values are pgbackrest, pgdump and snapshot. The default if not supplied is pgbackrest.")
 			}
 		}
 	},
@@ -279,7 +318,8 @@ var ShowClusterCmd = &cobra.Command{
 	Long: `Show a PostgreSQL cluster. For example:
 
 	pgo show cluster --all
-	pgo show cluster mycluster`,
+	pgo show cluster mycluster
+	pgo show cluster mycluster --hba`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if Namespace == "" {
 			Namespace = PGONamespace
@@ -0,0 +1,129 @@
+package cmd
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	msgs "github.com/crunchydata/postgres-operator/apiservermsgs"
+	"github.com/crunchydata/postgres-operator/pgo/api"
+	log "github.com/sirupsen/logrus"
+)
+
+// SnapshotClass is the name of the VolumeSnapshotClass to use for a snapshot
+// backup. If it is not set, the value from the Operator configuration is used
+var SnapshotClass string
+
+// SnapshotName is the name of a snapshot backup to provision the storage of a
+// new replica or cluster from
+var SnapshotName string
+
+// createSnapshotBackup handles the "pgo backup --backup-type=snapshot" command
+func createSnapshotBackup(args []string, ns string) {
+	log.Debugf("createSnapshotBackup called %v %s", args, SnapshotClass)
+
+	request := msgs.CreateSnapshotBackupRequest{
+		Args:          args,
+		ClientVersion: msgs.PGO_VERSION,
+		Namespace:     ns,
+		Selector:      Selector,
+		SnapshotClass: SnapshotClass,
+	}
+
+	response, err := api.CreateSnapshotBackup(httpclient, &SessionCredentials, request)
+	if err != nil {
+		fmt.Println("Error: ", err.Error())
+		os.Exit(2)
+	}
+
+	if response.Status.Code != msgs.Ok {
+		fmt.Println("Error: " + response.Status.Msg)
+		os.Exit(2)
+	}
+
+	if len(response.Results) == 0 {
+		fmt.Println("No clusters found.")
+		return
+	}
+
+	for _, result := range response.Results {
+		fmt.Println(result)
+	}
+}
+
+// showSnapshotBackups handles the "pgo show backup --backup-type=snapshot"
+// command
+func showSnapshotBackups(args []string, ns string) {
+	log.Debugf("showSnapshotBackups called %v", args)
+
+	request := msgs.ShowSnapshotBackupRequest{
+		Args:          args,
+		ClientVersion: msgs.PGO_VERSION,
+		Namespace:     ns,
+		Selector:      Selector,
+	}
+
+	response, err := api.ShowSnapshotBackups(httpclient, &SessionCredentials, request)
+	if err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(2)
+	}
+
+	if response.Status.Code != msgs.Ok {
+		fmt.Println("Error: " + response.Status.Msg)
+		os.Exit(2)
+	}
+
+	if len(response.Results) == 0 {
+		fmt.Println("No snapshot backups found.")
+		return
+	}
+
+	// sort the snapshot backups by cluster and then by when they were taken
+	results := response.Results
+	sort.SliceStable(results, func(i int, j int) bool {
+		if results[i].ClusterName != results[j].ClusterName {
+			return results[i].ClusterName < results[j].ClusterName
+		}
+		return results[i].CreationTime.Before(results[j].CreationTime)
+	})
+
+	for _, result := range results {
+		fmt.Println("")
+		fmt.Printf("cluster: %s\n", result.ClusterName)
+		fmt.Printf("snapshot backup: %s\n", result.Name)
+		fmt.Printf("\ttimestamp: %s\n", result.CreationTime.Format(time.RFC3339))
+		fmt.Printf("\tvolume snapshot class: %s\n", result.VolumeSnapshotClass)
+		fmt.Printf("\tstart LSN: %s\n", result.StartLSN)
+		fmt.Printf("\tstop LSN: %s\n", result.StopLSN)
+		fmt.Printf("\tpgdata snapshot: %s\n", result.PGDataSnapshot)
+
+		// print the tablespace snapshots in a stable order
+		tablespaceNames := []string{}
+		for tablespaceName := range result.TablespaceSnapshots {
+			tablespaceNames = append(tablespaceNames, tablespaceName)
+		}
+		sort.Strings(tablespaceNames)
+
+		for _, tablespaceName := range tablespaceNames {
+			fmt.Printf("\ttablespace %s snapshot: %s\n", tablespaceName,
+				result.TablespaceSnapshots[tablespaceName])
+		}
+	}
+}
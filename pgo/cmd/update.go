@@ -48,6 +48,26 @@ var (
 	Shutdown bool
 	// Startup is used to indicate that the cluster should be started (assuming it is shutdown)
 	Startup bool
+	// EnableDeletionProtection protects a cluster from being deleted
+	EnableDeletionProtection bool
+	// DisableDeletionProtection removes the protection of a cluster from being
+	// deleted, which requires the DisableDeletionProtection permission
+	DisableDeletionProtection bool
+	// ExtendTTL is the duration that the expiration time of a cluster is pushed
+	// back by
+	ExtendTTL string
+	// ClearHBA removes the pg_hba.conf rules of a cluster
+	ClearHBA bool
+	// ClearReplicaServiceType removes the type of the replica Service of a
+	// cluster, which then has the type of the primary Service
+	ClearReplicaServiceType bool
+	// ClearLoadBalancerSourceRanges removes the CIDRs that the clients of the
+	// Services of type LoadBalancer of a cluster are restricted to
+	ClearLoadBalancerSourceRanges bool
+	// EnableNetworkPolicy and DisableNetworkPolicy add or remove the
+	// NetworkPolicies of a cluster
+	EnableNetworkPolicy  bool
+	DisableNetworkPolicy bool
 )
 
 func init() {
@@ -63,6 +83,43 @@ func init() {
 	UpdateClusterCmd.Flags().BoolVar(&AllFlag, "all", false, "all resources.")
 	UpdateClusterCmd.Flags().BoolVar(&DisableAutofailFlag, "disable-autofail", false, "Disables autofail capabitilies in the cluster.")
 	UpdateClusterCmd.Flags().BoolVar(&EnableAutofailFlag, "enable-autofail", false, "Enables autofail capabitilies in the cluster.")
+	UpdateClusterCmd.Flags().BoolVar(&DisableDeletionProtection, "disable-deletion-protection", false,
+		"Allows the cluster(s) specified to be deleted again. Requires the DisableDeletionProtection permission.")
+	UpdateClusterCmd.Flags().BoolVar(&EnableDeletionProtection, "enable-deletion-protection", false,
+		"Protects the cluster(s) specified from being deleted.")
+	UpdateClusterCmd.Flags().StringVar(&ExtendTTL, "extend-ttl", "", "Pushes back the time at which "+
+		"the cluster(s) specified are deleted by this duration, e.g. \"24h\". If the cluster has already "+
+		"expired, the duration counts from now. Only for clusters created with a TTL.")
+	UpdateClusterCmd.Flags().StringSliceVar(&LoadBalancerSourceRanges, "load-balancer-source-range", []string{},
+		"A CIDR that may connect to the Services of the cluster(s) that are of type LoadBalancer, e.g. "+
+			"\"10.0.0.0/8\". Can be repeated. Replaces the source ranges that are currently set.")
+	UpdateClusterCmd.Flags().StringArrayVar(&HBARules, "hba", []string{}, "A pg_hba.conf rule of the "+
+		"cluster(s), e.g. \"hostssl all all 10.0.0.0/8 scram-sha-256\". Can be repeated. Replaces the rules "+
+		"that are currently set, and is applied without a restart.")
+	UpdateClusterCmd.Flags().BoolVar(&EnableNetworkPolicy, "enable-network-policy", false, "Adds "+
+		"NetworkPolicies that only let pgBouncer, the PostgreSQL Operator and the namespaces and CIDRs of "+
+		"\"network-policy-namespace\" and \"network-policy-source-range\" connect to the cluster(s).")
+	UpdateClusterCmd.Flags().BoolVar(&DisableNetworkPolicy, "disable-network-policy", false, "Removes the "+
+		"NetworkPolicies of the cluster(s).")
+	UpdateClusterCmd.Flags().StringArrayVar(&NetworkPolicyNamespaces, "network-policy-namespace", []string{},
+		"A label selector of the namespaces whose pods may connect to the cluster(s), e.g. \"team=payments\". "+
+			"Can be repeated. Replaces the namespaces that are currently set.")
+	UpdateClusterCmd.Flags().StringSliceVar(&NetworkPolicySourceRanges, "network-policy-source-range", []string{},
+		"A CIDR that may connect to the cluster(s), e.g. \"10.0.0.0/8\". Can be repeated. Replaces the "+
+			"source ranges that are currently set.")
+	UpdateClusterCmd.Flags().BoolVar(&ClearHBA, "clear-hba", false, "Removes the pg_hba.conf rules of the "+
+		"cluster(s), which then use the value of \"HBA\" in pgo.yaml.")
+	UpdateClusterCmd.Flags().BoolVar(&ClearLoadBalancerSourceRanges, "clear-load-balancer-source-ranges", false,
+		"Removes the CIDRs that may connect to the Services of the cluster(s) that are of type LoadBalancer, "+
+			"which then accept any client.")
+	UpdateClusterCmd.Flags().BoolVar(&ClearReplicaServiceType, "clear-replica-service-type", false,
+		"Removes the Service type of the replicas of the cluster(s), which then use the Service type of the "+
+			"primary.")
+	UpdateClusterCmd.Flags().StringVar(&ReplicaServiceType, "replica-service-type", "", "The Service type "+
+		"to use for the replicas of the cluster(s). If not set, the Service type of the primary is used.")
+	UpdateClusterCmd.Flags().StringVar(&ServiceType, "service-type", "", "The Service type to use for the "+
+		"primary of the cluster(s).")
+	addClusterMetadataFlags(UpdateClusterCmd, " A trailing \"-\", e.g. \"key-\", removes the key.")
 	UpdateClusterCmd.Flags().StringVarP(&Selector, "selector", "s", "", "The selector to use for cluster filtering.")
 	UpdateClusterCmd.Flags().BoolVarP(&DisableStandby, "disable-standby", "", false,
 		"Disables standby mode if enabled in the cluster(s) specified.")
@@ -84,7 +141,8 @@ func init() {
 			"--tablespace=name=ts1:storageconfig=nfsstorage:pvcsize=10Gi")
 	UpdatePgBouncerCmd.Flags().BoolVar(&NoPrompt, "no-prompt", false, "No command line confirmation.")
 	UpdatePgBouncerCmd.Flags().StringVarP(&OutputFormat, "output", "o", "", `The output format. Supported types are: "json"`)
-	UpdatePgBouncerCmd.Flags().BoolVar(&RotatePassword, "rotate-password", false, "Used to rotate the pgBouncer service account password. Can cause interruption of service.")
+	UpdatePgBouncerCmd.Flags().IntVar(&PgBouncerReplicas, "replicas", 0, "The number of pgBouncer pods of each pgBouncer deployment of the cluster.")
+	UpdatePgBouncerCmd.Flags().BoolVar(&RotatePassword, "rotate-password", false, "Used to rotate the pgBouncer service account password. The pgBouncer pods are restarted one at a time.")
 	UpdatePgBouncerCmd.Flags().StringVarP(&Selector, "selector", "s", "", "The selector to use for cluster filtering.")
 	UpdatePgouserCmd.Flags().StringVarP(&PgouserNamespaces, "pgouser-namespaces", "", "", "The namespaces to use for updating the pgouser roles.")
 	UpdatePgouserCmd.Flags().BoolVar(&AllNamespaces, "all-namespaces", false, "all namespaces.")
@@ -165,7 +223,11 @@ var UpdateClusterCmd = &cobra.Command{
     pgo update cluster mycluster --autofail=false
     pgo update cluster mycluster myothercluster --disable-autofail
     pgo update cluster --selector=name=mycluster --disable-autofail
-    pgo update cluster --all --enable-autofail`,
+    pgo update cluster --all --enable-autofail
+    pgo update cluster mycluster --enable-deletion-protection
+    pgo update cluster mycluster --extend-ttl=24h
+    pgo update cluster mycluster --annotation-primary-service=team=payments --label-postgres=tier-
+    pgo update cluster mycluster --hba="hostssl all all 10.0.0.0/8 scram-sha-256"`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if Namespace == "" {
 			Namespace = PGONamespace
@@ -182,6 +244,16 @@ var UpdateClusterCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		if EnableDeletionProtection && DisableDeletionProtection {
+			fmt.Println("Error: Cannot set --enable-deletion-protection and --disable-deletion-protection simultaneously")
+			os.Exit(1)
+		}
+
+		if EnableNetworkPolicy && DisableNetworkPolicy {
+			fmt.Println("Error: Cannot set --enable-network-policy and --disable-network-policy simultaneously")
+			os.Exit(1)
+		}
+
 		if EnableStandby {
 			fmt.Println("Enabling standby mode will result in the deltion of all PVCs " +
 				"for this cluster!\nData will only be retained if the proper retention policy " +
@@ -261,9 +333,11 @@ var UpdatePgBouncerCmd = &cobra.Command{
 	Use:   "pgbouncer",
 	Short: "Update a pgBouncer deployment for a PostgreSQL cluster",
 	Long: `Used to update the pgBouncer deployment for a PostgreSQL cluster, such
-	as by rotating a password. For example:
+	as by rotating a password or by changing the number of pgBouncer pods. The
+	pgBouncer pods are replaced one at a time. For example:
 
 	pgo update pgbouncer hacluster --rotate-password
+	pgo update pgbouncer hacluster --replicas=2
 	`,
 
 	Run: func(cmd *cobra.Command, args []string) {
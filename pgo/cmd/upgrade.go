@@ -25,6 +25,10 @@ import (
 	"os"
 )
 
+// UpgradeMajorVersion is the PostgreSQL major version to upgrade a cluster to
+// when performing a major upgrade
+var UpgradeMajorVersion string
+
 var upgradeCmd = &cobra.Command{
 	Use:   "upgrade",
 	Short: "Perform an upgrade",
@@ -35,6 +39,12 @@ var upgradeCmd = &cobra.Command{
  This upgrade will update the CCPImageTag of the deployment for the primary and all replicas.
  The running containers are upgraded one at a time, sequentially, in the following order: replicas, backrest-repo, then primary.
 
+ A major upgrade of PostgreSQL is performed using pg_upgrade when the --major flag is set, e.g.:
+
+  pgo upgrade mycluster --major=13 --ccp-image-tag=centos7-13.0-4.4.0
+
+ A major upgrade takes a full pgBackRest backup, shuts down the cluster, upgrades the data directory of the primary with "pg_upgrade --link" and upgrades the pgBackRest stanza. The replicas are then reinitialized from a new backup. The progress and the rollback instructions for each step are kept on the upgrade task.
+
  Note: If the PostgreSQL Operator is deployed using OLM, the value of the CCPImageTag is overriden by what is in the RELATED_IMAGE_* environmental variables, e.g. for the PostgreSQL container, it would be the value of RELATED_IMAGE_CRUNCHY_POSTGRES_HA`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if Namespace == "" {
@@ -54,6 +64,7 @@ func init() {
 	RootCmd.AddCommand(upgradeCmd)
 
 	upgradeCmd.Flags().StringVarP(&CCPImageTag, "ccp-image-tag", "", "", "The CCPImageTag to use for cluster creation. If specified, overrides the pgo.yaml setting.")
+	upgradeCmd.Flags().StringVarP(&UpgradeMajorVersion, "major", "", "", "Performs a major upgrade to the given PostgreSQL version (e.g. 13) using pg_upgrade. The CCPImageTag must be for that PostgreSQL version.")
 
 }
 
@@ -70,6 +81,7 @@ func createUpgrade(args []string, ns string) {
 	request.Namespace = ns
 	request.Selector = Selector
 	request.CCPImageTag = CCPImageTag
+	request.MajorVersion = UpgradeMajorVersion
 	request.ClientVersion = msgs.PGO_VERSION
 
 	response, err := api.CreateUpgrade(httpclient, &SessionCredentials, &request)
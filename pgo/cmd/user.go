@@ -38,6 +38,10 @@ type userTextPadding struct {
 	Status       int
 }
 
+// AuthType is how a new PostgreSQL user authenticates, i.e. with a "password"
+// or a client certificate ("cert")
+var AuthType string
+
 // PasswordAgeDays password age flag
 var PasswordAgeDays int
 
@@ -76,6 +80,7 @@ func createUser(args []string, ns string) {
 
 	request := msgs.CreateUserRequest{
 		AllFlag:         AllFlag,
+		AuthType:        AuthType,
 		Clusters:        args,
 		ManagedUser:     ManagedUser,
 		Namespace:       ns,
@@ -193,6 +198,15 @@ func printCreateUserText(response msgs.CreateUserResponse) {
 	for _, result := range response.Results {
 		printUserTextRow(result, padding)
 	}
+
+	// users that authenticate with a client certificate have no password, so
+	// point to where their certificate is instead
+	for _, result := range response.Results {
+		if !result.Error && result.TLSSecret != "" {
+			fmt.Printf("\nThe client certificate of user %s on cluster %s is in secret %s\n",
+				result.Username, result.ClusterName, result.TLSSecret)
+		}
+	}
 }
 
 // printDeleteUserText prints out the information that is created after
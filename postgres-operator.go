@@ -96,10 +96,12 @@ func main() {
 	}
 
 	pgClustercontroller := &pgcluster.Controller{
+		PgclusterConfig:    config,
 		PgclusterClient:    crdClient,
 		PgclusterScheme:    crdScheme,
 		PgclusterClientset: Clientset,
 		Queue:              workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		ReconcileQueue:     workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
 		Ctx:                ctx,
 		InformerNamespaces: make(map[string]struct{}),
 	}
@@ -144,11 +146,16 @@ func main() {
 		PgtaskController:    pgTaskcontroller,
 	}
 
+	// update the pgclusters before the controllers start, as the pgcluster
+	// controller reconciles the clusters to their pgclusters
+	operatorupgrade.OperatorUpdateCRPgoVersion(Clientset, crdClient, namespaceList)
+
 	defer cancelFunc()
 	go pgTaskcontroller.Run()
 	go pgTaskcontroller.RunWorker()
 	go pgClustercontroller.Run()
 	go pgClustercontroller.RunWorker()
+	go pgClustercontroller.RunReconcileWorker()
 	go pgReplicacontroller.Run()
 	go pgReplicacontroller.RunWorker()
 	go pgPolicycontroller.Run()
@@ -156,8 +163,6 @@ func main() {
 	go nscontroller.Run()
 	go jobcontroller.Run()
 
-	operatorupgrade.OperatorUpdateCRPgoVersion(Clientset, crdClient, namespaceList)
-
 	fmt.Print("at end of setup, beginning wait...")
 
 	signals := make(chan os.Signal, 1)
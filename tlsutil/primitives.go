@@ -19,6 +19,7 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
 	"errors"
 	"io"
@@ -63,6 +64,31 @@ func ParsePEMEncodedCert(pemdata []byte) (*x509.Certificate, error) {
 	return x509.ParseCertificate(decoded.Bytes)
 }
 
+// ParsePEMEncodedCerts parses all of the certificates in the given pemdata,
+// e.g. a bundle of CA certificates, in the order they appear
+func ParsePEMEncodedCerts(pemdata []byte) ([]*x509.Certificate, error) {
+	certs := []*x509.Certificate{}
+
+	for {
+		var decoded *pem.Block
+		decoded, pemdata = pem.Decode(pemdata)
+		if decoded == nil {
+			break
+		}
+
+		cert, err := x509.ParseCertificate(decoded.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM data found")
+	}
+	return certs, nil
+}
+
 // parsePEMEncodedPrivateKey parses a private key from given pemdata
 func ParsePEMEncodedPrivateKey(pemdata []byte) (*rsa.PrivateKey, error) {
 	decoded, _ := pem.Decode(pemdata)
@@ -95,6 +121,68 @@ func NewSelfSignedCACertificate(key *rsa.PrivateKey) (*x509.Certificate, error)
 	return x509.ParseCertificate(certDERBytes)
 }
 
+// NewSignedServerCertificate returns a server certificate for the given DNS
+// names that is signed by the given CA. The certificate is valid for the given
+// duration, but not for longer than the CA is
+func NewSignedServerCertificate(key *rsa.PrivateKey, caCert *x509.Certificate, caKey *rsa.PrivateKey,
+	commonName string, dnsNames []string, duration time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	notAfter := now.Add(duration)
+	if notAfter.After(caCert.NotAfter) {
+		notAfter = caCert.NotAfter
+	}
+	tmpl := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              dnsNames,
+		NotBefore:             now.UTC(),
+		NotAfter:              notAfter.UTC(),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	certDERBytes, err := x509.CreateCertificate(rand.Reader, &tmpl, caCert, key.Public(), caKey)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(certDERBytes)
+}
+
+// NewSignedClientCertificate returns a client certificate for the given common
+// name, e.g. the name of a PostgreSQL user, that is signed by the given CA. The
+// certificate is valid for the given duration, but not for longer than the CA
+// is
+func NewSignedClientCertificate(key *rsa.PrivateKey, caCert *x509.Certificate, caKey *rsa.PrivateKey,
+	commonName string, duration time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	notAfter := now.Add(duration)
+	if notAfter.After(caCert.NotAfter) {
+		notAfter = caCert.NotAfter
+	}
+	tmpl := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now.UTC(),
+		NotAfter:              notAfter.UTC(),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+	certDERBytes, err := x509.CreateCertificate(rand.Reader, &tmpl, caCert, key.Public(), caKey)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(certDERBytes)
+}
+
 // ExtendTrust extends the provided certpool with the PEM-encoded certificates
 // presented by certSource. If reading from certSource produces an error
 // the base pool remains unmodified
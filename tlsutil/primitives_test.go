@@ -70,6 +70,110 @@ func TestCertPEMSymmetry(t *testing.T) {
 	}
 }
 
+func TestSignedServerCertificate(t *testing.T) {
+	caKey, err := NewPrivateKey()
+	if err != nil {
+		t.Fatalf("unable to generate new key - %s", err)
+	}
+
+	caCert, err := NewSelfSignedCACertificate(caKey)
+	if err != nil {
+		t.Fatalf("unable to generate cert - %s", err)
+	}
+
+	key, err := NewPrivateKey()
+	if err != nil {
+		t.Fatalf("unable to generate new key - %s", err)
+	}
+
+	cert, err := NewSignedServerCertificate(key, caCert, caKey, "hippo",
+		[]string{"hippo", "hippo.pgo.svc"}, 2*duration365d)
+	if err != nil {
+		t.Fatalf("unable to generate cert - %s", err)
+	}
+
+	if !cert.NotAfter.Equal(caCert.NotAfter) {
+		t.Fatalf("expected the cert to expire with the CA at %s, got %s", caCert.NotAfter, cert.NotAfter)
+	}
+
+	// the CA is found in a bundle that has other certificates as well
+	otherKey, err := NewPrivateKey()
+	if err != nil {
+		t.Fatalf("unable to generate new key - %s", err)
+	}
+
+	otherCert, err := NewSelfSignedCACertificate(otherKey)
+	if err != nil {
+		t.Fatalf("unable to generate cert - %s", err)
+	}
+
+	bundle, err := ParsePEMEncodedCerts(append(EncodeCertificatePEM(otherCert), EncodeCertificatePEM(caCert)...))
+	if err != nil {
+		t.Fatalf("error decoding cert PEM - %s", err)
+	}
+
+	if len(bundle) != 2 || !bundle[1].Equal(caCert) {
+		t.Fatal("decoded bundle did not match its input source")
+	}
+
+	roots := x509.NewCertPool()
+	for _, c := range bundle {
+		roots.AddCert(c)
+	}
+
+	if _, err := cert.Verify(x509.VerifyOptions{DNSName: "hippo.pgo.svc", Roots: roots}); err != nil {
+		t.Fatalf("unable to verify cert - %s", err)
+	}
+
+	if _, err := cert.Verify(x509.VerifyOptions{DNSName: "rhino", Roots: roots}); err == nil {
+		t.Fatal("expected the cert to not be valid for another name")
+	}
+}
+
+func TestSignedClientCertificate(t *testing.T) {
+	caKey, err := NewPrivateKey()
+	if err != nil {
+		t.Fatalf("unable to generate new key - %s", err)
+	}
+
+	caCert, err := NewSelfSignedCACertificate(caKey)
+	if err != nil {
+		t.Fatalf("unable to generate cert - %s", err)
+	}
+
+	key, err := NewPrivateKey()
+	if err != nil {
+		t.Fatalf("unable to generate new key - %s", err)
+	}
+
+	cert, err := NewSignedClientCertificate(key, caCert, caKey, "testuser", 2*duration365d)
+	if err != nil {
+		t.Fatalf("unable to generate cert - %s", err)
+	}
+
+	if cert.Subject.CommonName != "testuser" {
+		t.Fatalf("expected the cert to be issued for %q, got %q", "testuser", cert.Subject.CommonName)
+	}
+
+	if !cert.NotAfter.Equal(caCert.NotAfter) {
+		t.Fatalf("expected the cert to expire with the CA at %s, got %s", caCert.NotAfter, cert.NotAfter)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		t.Fatalf("unable to verify cert - %s", err)
+	}
+
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots}); err == nil {
+		t.Fatal("expected the cert to not be valid for server authentication")
+	}
+}
+
 func TestExtendedTrust(t *testing.T) {
 	expected := "You do that very well. It's as if i was looking in a mirror."
 
@@ -16,7 +16,8 @@ package util
 */
 
 import (
-	"errors"
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"strings"
 
@@ -28,29 +29,42 @@ import (
 // variable is utilized
 const defaultBackrestRepoPath = "/backrestrepo/%s-backrest-shared-repo"
 
+// backrestCipherPassLength is the number of random bytes used to generate the passphrase for an
+// encrypted pgBackRest repository, which matches the pgBackRest recommendation of using the output
+// of "openssl rand -base64 48"
+const backrestCipherPassLength = 48
+
 // ValidateBackrestStorageTypeOnBackupRestore checks to see if the pgbackrest storage type provided
 // when performing either pgbackrest backup or restore is valid.  This includes ensuring the value
 // provided is a valid storage type (e.g. "s3" and/or "local").  This also includes ensuring the
-// storage type specified (e.g. "s3" or "local") is enabled in the current cluster.  And finally,
-// validation is ocurring for a restore, the ensure only one storage type is selected.
+// storage type specified (e.g. "s3", "gcs", "azure" or "local") is enabled in the current cluster.
+// And finally, validation is ocurring for a restore, the ensure only one storage type is selected.
 func ValidateBackrestStorageTypeOnBackupRestore(newBackRestStorageType,
 	currentBackRestStorageType string, restore bool) error {
 
 	if newBackRestStorageType != "" && !IsValidBackrestStorageType(newBackRestStorageType) {
 		return fmt.Errorf("Invalid value provided for pgBackRest storage type. The following "+
 			"values are allowed: %s", "\""+strings.Join(crv1.BackrestStorageTypes, "\", \"")+"\"")
-	} else if newBackRestStorageType != "" &&
-		strings.Contains(newBackRestStorageType, "s3") &&
-		!strings.Contains(currentBackRestStorageType, "s3") {
-		return errors.New("Storage type 's3' not allowed. S3 storage is not enabled for " +
-			"pgBackRest in this cluster")
-	} else if (newBackRestStorageType == "" ||
+	}
+
+	// any of the cloud storage types that were requested must be enabled in the
+	// cluster
+	for _, cloudStorageType := range crv1.BackrestCloudStorageTypes {
+		if newBackRestStorageType != "" &&
+			strings.Contains(newBackRestStorageType, cloudStorageType) &&
+			!strings.Contains(currentBackRestStorageType, cloudStorageType) {
+			return fmt.Errorf("Storage type '%[1]s' not allowed. %[1]s storage is not enabled for "+
+				"pgBackRest in this cluster", cloudStorageType)
+		}
+	}
+
+	if (newBackRestStorageType == "" ||
 		strings.Contains(newBackRestStorageType, "local")) &&
 		(currentBackRestStorageType != "" &&
 			!strings.Contains(currentBackRestStorageType, "local")) {
-		return errors.New("Storage type 'local' not allowed. Local storage is not enabled for " +
-			"pgBackRest in this cluster. If this cluster uses S3 storage only, specify 's3' " +
-			"for the pgBackRest storage type.")
+		return fmt.Errorf("Storage type 'local' not allowed. Local storage is not enabled for "+
+			"pgBackRest in this cluster. If this cluster uses %[1]s storage only, specify '%[1]s' "+
+			"for the pgBackRest storage type.", GetBackrestCloudStorageType(currentBackRestStorageType))
 	}
 
 	// storage type validation that is only applicable for restores
@@ -65,16 +79,31 @@ func ValidateBackrestStorageTypeOnBackupRestore(newBackRestStorageType,
 }
 
 // IsValidBackrestStorageType determines if the storage source string contains valid pgBackRest
-// storage type values
+// storage type values.  As all of the cloud storage types make use of the same pgBackRest
+// repository, at most one of them can be selected
 func IsValidBackrestStorageType(storageType string) bool {
-	isValid := true
+	cloudStorageTypes := 0
 	for _, storageType := range strings.Split(storageType, ",") {
 		if !IsStringOneOf(storageType, crv1.BackrestStorageTypes...) {
-			isValid = false
-			break
+			return false
+		}
+		if IsStringOneOf(storageType, crv1.BackrestCloudStorageTypes...) {
+			cloudStorageTypes++
 		}
 	}
-	return isValid
+	return cloudStorageTypes <= 1
+}
+
+// GetBackrestCloudStorageType returns the cloud storage type (e.g. "s3", "gcs" or "azure") that
+// is included in the storage type string provided, or an empty string if only local storage
+// is being used
+func GetBackrestCloudStorageType(storageType string) string {
+	for _, storageType := range strings.Split(storageType, ",") {
+		if IsStringOneOf(storageType, crv1.BackrestCloudStorageTypes...) {
+			return storageType
+		}
+	}
+	return ""
 }
 
 // GetPGBackRestRepoPath is responsible for determining the repo path setting (i.e. 'repo1-path'
@@ -87,3 +116,22 @@ func GetPGBackRestRepoPath(cluster crv1.Pgcluster) string {
 	}
 	return fmt.Sprintf(defaultBackrestRepoPath, cluster.Name)
 }
+
+// GenerateBackrestCipherPass generates a random passphrase that can be used to encrypt a
+// pgBackRest repository. The passphrase is generated with a cryptographically secure random
+// number generator and is base64 encoded
+func GenerateBackrestCipherPass() (string, error) {
+	b := make([]byte, backrestCipherPassLength)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// IsBackrestCipherEnabled returns true if the pgBackRest repository of the cluster is encrypted,
+// i.e. if a cipher type other than "none" is set in the pgcluster spec
+func IsBackrestCipherEnabled(cluster crv1.Pgcluster) bool {
+	return cluster.Spec.BackrestCipherType != "" && cluster.Spec.BackrestCipherType != "none"
+}
@@ -0,0 +1,118 @@
+package util
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"encoding/base64"
+	"testing"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+)
+
+func TestIsValidBackrestStorageType(t *testing.T) {
+	tests := []struct {
+		storageType string
+		valid       bool
+	}{
+		{"local", true},
+		{"s3", true},
+		{"gcs", true},
+		{"azure", true},
+		{"local,s3", true},
+		{"local,gcs", true},
+		{"azure,local", true},
+		{"posix", false},
+		{"local,", false},
+		{"s3,gcs", false},
+		{"local,gcs,azure", false},
+	}
+
+	for i, test := range tests {
+		if valid := IsValidBackrestStorageType(test.storageType); valid != test.valid {
+			t.Fatalf("tests[%d] - storage type %q. expected valid %t, got %t",
+				i, test.storageType, test.valid, valid)
+		}
+	}
+}
+
+func TestValidateBackrestStorageTypeOnBackupRestore(t *testing.T) {
+	tests := []struct {
+		newStorageType, currentStorageType string
+		restore                            bool
+		valid                              bool
+	}{
+		{"", "", false, true},
+		{"local", "local,gcs", false, true},
+		{"gcs", "local,gcs", false, true},
+		{"local,gcs", "local,gcs", false, true},
+		{"azure", "azure", true, true},
+		{"gcs", "local,s3", false, false},
+		{"azure", "local", false, false},
+		{"", "gcs", false, false},
+		{"local", "azure", false, false},
+		{"local,gcs", "local,gcs", true, false},
+	}
+
+	for i, test := range tests {
+		err := ValidateBackrestStorageTypeOnBackupRestore(test.newStorageType,
+			test.currentStorageType, test.restore)
+		if test.valid && err != nil {
+			t.Fatalf("tests[%d] - invalid storage type. expected valid, got invalid: %s",
+				i, err)
+		} else if !test.valid && err == nil {
+			t.Fatalf("tests[%d] - valid storage type. expected invalid, got valid", i)
+		}
+	}
+}
+
+func TestGenerateBackrestCipherPass(t *testing.T) {
+	pass, err := GenerateBackrestCipherPass()
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	b, err := base64.StdEncoding.DecodeString(pass)
+	if err != nil {
+		t.Fatalf("expected base64 encoded passphrase, got %s", err)
+	}
+
+	if len(b) != backrestCipherPassLength {
+		t.Fatalf("expected %d random bytes, got %d", backrestCipherPassLength, len(b))
+	}
+
+	if other, _ := GenerateBackrestCipherPass(); other == pass {
+		t.Fatalf("expected different passphrases, got %q twice", pass)
+	}
+}
+
+func TestIsBackrestCipherEnabled(t *testing.T) {
+	tests := []struct {
+		cipherType string
+		enabled    bool
+	}{
+		{"", false},
+		{"none", false},
+		{"aes-256-cbc", true},
+	}
+
+	for i, test := range tests {
+		cluster := crv1.Pgcluster{Spec: crv1.PgclusterSpec{BackrestCipherType: test.cipherType}}
+		if enabled := IsBackrestCipherEnabled(cluster); enabled != test.enabled {
+			t.Fatalf("tests[%d] - cipher type %q. expected enabled %t, got %t",
+				i, test.cipherType, test.enabled, enabled)
+		}
+	}
+}
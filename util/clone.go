@@ -16,7 +16,10 @@ package util
 */
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
@@ -29,26 +32,71 @@ const (
 	// CloneParameterBackrestPVCSize is the parameter name for the Backrest PVC
 	// size parameter
 	CloneParameterBackrestPVCSize = "backrestPVCSize"
+	// CloneParameterBackupSet is the parameter name for the label of the
+	// pgBackRest backup set to restore from
+	CloneParameterBackupSet = "backupSet"
 	// CloneParameterEnableMetrics if set to true, enables metrics collection in
 	// a newly created cluster
 	CloneParameterEnableMetrics = "enableMetrics"
 	// CloneParameterPVCSize is the parameter name for the PVC parameter for
 	// primary and replicas
 	CloneParameterPVCSize = "pvcSize"
+	// CloneParameterRecoveryTarget is the parameter name for the point-in-time
+	// that the clone is recovered to
+	CloneParameterRecoveryTarget = "recoveryTarget"
+	// CloneParameterRecoveryTargetType is the parameter name for the type of
+	// the recovery target, i.e. "time", "lsn", "name" or "xid"
+	CloneParameterRecoveryTargetType = "recoveryTargetType"
+	// CloneParameterRotateCipherPass if set to true, generates a new passphrase
+	// for the encrypted pgBackRest repository of the newly created cluster
+	CloneParameterRotateCipherPass = "rotateCipherPass"
+	// CloneParameterSnapshot is the parameter name for the snapshot backup of
+	// the source cluster that the clone is provisioned from, instead of
+	// restoring a pgBackRest backup
+	CloneParameterSnapshot = "snapshot"
+	// CloneParameterSourceNamespace is the parameter name for the namespace
+	// that the source cluster is in. The clone tasks themselves always live in
+	// the namespace of the target cluster
+	CloneParameterSourceNamespace = "sourceNamespace"
+	// CloneParameterTTL is the parameter name for the TTL of the newly created
+	// cluster, after which it is deleted
+	CloneParameterTTL = "ttl"
+)
+
+// CloneRecoveryTargetTypes are the types of recovery targets that a clone can
+// be restored to, which map to the pgBackRest "--type" restore option
+var CloneRecoveryTargetTypes = []string{"time", "lsn", "name", "xid"}
+
+var (
+	// cloneBackupSetRegex matches the label of a pgBackRest full, differential
+	// or incremental backup, e.g. "20200619-203502F" or
+	// "20200619-203502F_20200619-204012I"
+	cloneBackupSetRegex = regexp.MustCompile(`^[0-9]{8}-[0-9]{6}F(_[0-9]{8}-[0-9]{6}[DI])?$`)
+	// cloneLSNRegex matches a PostgreSQL LSN, e.g. "0/3000060"
+	cloneLSNRegex = regexp.MustCompile(`^[0-9A-Fa-f]{1,8}/[0-9A-Fa-f]{1,8}$`)
+	// cloneXIDRegex matches a PostgreSQL transaction ID
+	cloneXIDRegex = regexp.MustCompile(`^[0-9]+$`)
 )
 
 // CloneTask allows you to create a Pgtask CRD with the appropriate options
 type CloneTask struct {
 	BackrestPVCSize       string
 	BackrestStorageSource string
+	BackupSet             string
 	EnableMetrics         bool
 	PGOUser               string
 	PVCSize               string
+	RecoveryTarget        string
+	RecoveryTargetType    string
+	RotateCipherPass      bool
+	Snapshot              string
 	SourceClusterName     string
+	SourceNamespace       string
 	TargetClusterName     string
 	TaskStepLabel         string
 	TaskType              string
 	Timestamp             time.Time
+	TTL                   string
 	WorkflowID            string
 }
 
@@ -63,6 +111,12 @@ func (clone CloneTask) Create() *crv1.Pgtask {
 		enableMetrics = "true"
 	}
 
+	// ...and the same for rotating the pgBackRest cipher passphrase
+	rotateCipherPass := "false"
+	if clone.RotateCipherPass {
+		rotateCipherPass = "true"
+	}
+
 	return &crv1.Pgtask{
 		ObjectMeta: meta_v1.ObjectMeta{
 			Name: taskName,
@@ -77,15 +131,22 @@ func (clone CloneTask) Create() *crv1.Pgtask {
 			Name:     taskName,
 			TaskType: clone.TaskType,
 			Parameters: map[string]string{
-				CloneParameterBackrestPVCSize: clone.BackrestPVCSize,
-				"backrestStorageType":         clone.BackrestStorageSource,
-				CloneParameterEnableMetrics:   enableMetrics,
-				CloneParameterPVCSize:         clone.PVCSize,
-				"sourceClusterName":           clone.SourceClusterName,
-				"targetClusterName":           clone.TargetClusterName,
-				"taskName":                    taskName,
-				"timestamp":                   clone.Timestamp.Format(time.RFC3339),
-				crv1.PgtaskWorkflowID:         clone.WorkflowID,
+				CloneParameterBackrestPVCSize:    clone.BackrestPVCSize,
+				"backrestStorageType":            clone.BackrestStorageSource,
+				CloneParameterBackupSet:          clone.BackupSet,
+				CloneParameterEnableMetrics:      enableMetrics,
+				CloneParameterPVCSize:            clone.PVCSize,
+				CloneParameterRecoveryTarget:     clone.RecoveryTarget,
+				CloneParameterRecoveryTargetType: clone.RecoveryTargetType,
+				CloneParameterRotateCipherPass:   rotateCipherPass,
+				CloneParameterSnapshot:           clone.Snapshot,
+				"sourceClusterName":              clone.SourceClusterName,
+				CloneParameterSourceNamespace:    clone.SourceNamespace,
+				"targetClusterName":              clone.TargetClusterName,
+				"taskName":                       taskName,
+				"timestamp":                      clone.Timestamp.Format(time.RFC3339),
+				CloneParameterTTL:                clone.TTL,
+				crv1.PgtaskWorkflowID:            clone.WorkflowID,
 			},
 		},
 	}
@@ -99,3 +160,53 @@ func (clone CloneTask) taskName() string {
 	uid := RandStringBytesRmndr(4)
 	return fmt.Sprintf("%s-%s-%s", clone.TaskType, clone.TargetClusterName, uid)
 }
+
+// ValidateCloneBackupSet ensures that the backup set that a clone is restored
+// from is a valid pgBackRest backup label. An empty backup set is valid, as
+// this means the latest backup is used
+func ValidateCloneBackupSet(backupSet string) error {
+	if backupSet == "" || cloneBackupSetRegex.MatchString(backupSet) {
+		return nil
+	}
+
+	return fmt.Errorf("invalid pgBackRest backup set %q, e.g. \"20200619-203502F\"", backupSet)
+}
+
+// ValidateCloneRecoveryTarget ensures that the recovery target and its type
+// are both set (or unset), and that the recovery target is in a format that
+// is valid for its type.
+//
+// As the recovery target is eventually placed into a job template, this also
+// guards against characters that would break the template
+func ValidateCloneRecoveryTarget(targetType, target string) error {
+	// if neither is set, we recover to the end of the WAL archive, which is fine
+	if targetType == "" && target == "" {
+		return nil
+	}
+
+	if targetType == "" || target == "" {
+		return errors.New("both the recovery target and the recovery target type must be set")
+	}
+
+	if !IsStringOneOf(targetType, CloneRecoveryTargetTypes...) {
+		return fmt.Errorf("invalid recovery target type %q. Valid values are: %s",
+			targetType, strings.Join(CloneRecoveryTargetTypes, ", "))
+	}
+
+	if strings.ContainsAny(target, "\"\\") {
+		return errors.New("the recovery target cannot contain double quotes or backslashes")
+	}
+
+	switch targetType {
+	case "lsn":
+		if !cloneLSNRegex.MatchString(target) {
+			return fmt.Errorf("invalid LSN recovery target %q, e.g. \"0/3000060\"", target)
+		}
+	case "xid":
+		if !cloneXIDRegex.MatchString(target) {
+			return fmt.Errorf("invalid transaction ID recovery target %q", target)
+		}
+	}
+
+	return nil
+}
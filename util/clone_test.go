@@ -0,0 +1,78 @@
+package util
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"testing"
+)
+
+func TestValidateCloneBackupSet(t *testing.T) {
+	tests := []struct {
+		backupSet string
+		valid     bool
+	}{
+		{"", true},
+		{"20200619-203502F", true},
+		{"20200619-203502F_20200619-204012D", true},
+		{"20200619-203502F_20200619-204012I", true},
+		{"20200619-203502I", false},
+		{"20200619-203502F_20200619-204012F", false},
+		{"latest", false},
+		{"20200619-203502F --force", false},
+	}
+
+	for i, test := range tests {
+		err := ValidateCloneBackupSet(test.backupSet)
+		if test.valid && err != nil {
+			t.Fatalf("tests[%d] - invalid backup set. expected valid, got invalid: %s",
+				i, err)
+		} else if !test.valid && err == nil {
+			t.Fatalf("tests[%d] - valid backup set. expected invalid, got valid", i)
+		}
+	}
+}
+
+func TestValidateCloneRecoveryTarget(t *testing.T) {
+	tests := []struct {
+		targetType string
+		target     string
+		valid      bool
+	}{
+		{"", "", true},
+		{"time", "2020-06-19 12:00:00.000000+00", true},
+		{"lsn", "0/3000060", true},
+		{"lsn", "16/B374D848", true},
+		{"name", "before-migration", true},
+		{"xid", "5678", true},
+		{"time", "", false},
+		{"", "2020-06-19 12:00:00+00", false},
+		{"immediate", "now", false},
+		{"lsn", "3000060", false},
+		{"xid", "-1", false},
+		{"name", `before"migration`, false},
+		{"name", `before\migration`, false},
+	}
+
+	for i, test := range tests {
+		err := ValidateCloneRecoveryTarget(test.targetType, test.target)
+		if test.valid && err != nil {
+			t.Fatalf("tests[%d] - invalid recovery target. expected valid, got invalid: %s",
+				i, err)
+		} else if !test.valid && err == nil {
+			t.Fatalf("tests[%d] - valid recovery target. expected invalid, got valid", i)
+		}
+	}
+}
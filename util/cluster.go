@@ -36,11 +36,15 @@ import (
 
 // BackrestRepoConfig represents the configuration required to created backrest repo secrets
 type BackrestRepoConfig struct {
-	BackrestS3Key       string
-	BackrestS3KeySecret string
-	ClusterName         string
-	ClusterNamespace    string
-	OperatorNamespace   string
+	BackrestS3Key        string
+	BackrestS3KeySecret  string
+	BackrestGCSKey       string
+	BackrestAzureAccount string
+	BackrestAzureKey     string
+	BackrestCipherPass   string
+	ClusterName          string
+	ClusterNamespace     string
+	OperatorNamespace    string
 }
 
 // AWSS3Secret is a structured representation for providing  an AWS S3 key and
@@ -50,6 +54,20 @@ type AWSS3Secret struct {
 	AWSS3KeySecret string
 }
 
+// GCSSecret is a structured representation of the credentials used to access a
+// pgBackRest repository in Google Cloud Storage, i.e. either the contents of a
+// service account key file or a token
+type GCSSecret struct {
+	GCSKey string
+}
+
+// AzureSecret is a structured representation for providing an Azure storage
+// account name and shared key
+type AzureSecret struct {
+	AzureAccount string
+	AzureKey     string
+}
+
 const (
 	// DefaultGeneratedPasswordLength is the length of what a generated password
 	// is if it's not set in the pgo.yaml file, and to create some semblance of
@@ -63,10 +81,14 @@ const (
 
 // values for the keys used to access the pgBackRest repository Secret
 const (
-	// two are these are exported, as they are used to help add the information
-	// into the templates. Say the second one 10 times fast
+	// the credentials keys are exported, as they are used to help add the
+	// information into the templates. Say the second one 10 times fast
 	BackRestRepoSecretKeyAWSS3KeyAWSS3Key       = "aws-s3-key"
 	BackRestRepoSecretKeyAWSS3KeyAWSS3KeySecret = "aws-s3-key-secret"
+	BackRestRepoSecretKeyGCSKey                 = "gcs-key"
+	BackRestRepoSecretKeyAzureAccount           = "azure-account"
+	BackRestRepoSecretKeyAzureKey               = "azure-key"
+	BackRestRepoSecretKeyCipherPass             = "repo1-cipher-pass"
 	// the rest are private
 	backRestRepoSecretKeyAuthorizedKeys      = "authorized_keys"
 	backRestRepoSecretKeyAWSS3KeyAWSS3CACert = "aws-s3-ca.crt"
@@ -105,16 +127,30 @@ var (
 func CreateBackrestRepoSecrets(clientset *kubernetes.Clientset,
 	backrestRepoConfig BackrestRepoConfig) error {
 
-	keys, err := sshutil.NewPrivatePublicKeyPair()
+	secret, err := NewBackrestRepoSecret(clientset, backrestRepoConfig)
 	if err != nil {
 		return err
 	}
 
-	// Retrieve the S3/SSHD configuration files from secret
+	return kubeapi.CreateSecret(clientset, secret, backrestRepoConfig.ClusterNamespace)
+}
+
+// NewBackrestRepoSecret returns the secret that CreateBackrestRepoSecrets
+// creates, without creating it. The configuration of the repository that is
+// shared by all clusters is read from the namespace of the Operator
+func NewBackrestRepoSecret(clientset *kubernetes.Clientset,
+	backrestRepoConfig BackrestRepoConfig) (*v1.Secret, error) {
+
+	keys, err := sshutil.NewPrivatePublicKeyPair()
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve the S3/GCS/Azure/SSHD configuration files from secret
 	configs, _, err := kubeapi.GetSecret(clientset, "pgo-backrest-repo-config",
 		backrestRepoConfig.OperatorNamespace)
 	if kerrors.IsNotFound(err) || err != nil {
-		return err
+		return nil, err
 	}
 
 	// if an S3 key has been provided via the request, then use key and key secret
@@ -132,6 +168,25 @@ func CreateBackrestRepoSecrets(clientset *kubernetes.Clientset,
 		backrestS3KeySecret = configs.Data[BackRestRepoSecretKeyAWSS3KeyAWSS3KeySecret]
 	}
 
+	// the same goes for the GCS and Azure credentials
+	backrestGCSKey := []byte(backrestRepoConfig.BackrestGCSKey)
+
+	if backrestRepoConfig.BackrestGCSKey == "" {
+		backrestGCSKey = configs.Data[BackRestRepoSecretKeyGCSKey]
+	}
+
+	backrestAzureAccount := []byte(backrestRepoConfig.BackrestAzureAccount)
+
+	if backrestRepoConfig.BackrestAzureAccount == "" {
+		backrestAzureAccount = configs.Data[BackRestRepoSecretKeyAzureAccount]
+	}
+
+	backrestAzureKey := []byte(backrestRepoConfig.BackrestAzureKey)
+
+	if backrestRepoConfig.BackrestAzureKey == "" {
+		backrestAzureKey = configs.Data[BackRestRepoSecretKeyAzureKey]
+	}
+
 	// set up the secret for the cluster that contains the pgBackRest information
 	secret := v1.Secret{
 		ObjectMeta: meta_v1.ObjectMeta{
@@ -146,6 +201,9 @@ func CreateBackrestRepoSecrets(clientset *kubernetes.Clientset,
 		Data: map[string][]byte{
 			BackRestRepoSecretKeyAWSS3KeyAWSS3Key:       backrestS3Key,
 			BackRestRepoSecretKeyAWSS3KeyAWSS3KeySecret: backrestS3KeySecret,
+			BackRestRepoSecretKeyGCSKey:                 backrestGCSKey,
+			BackRestRepoSecretKeyAzureAccount:           backrestAzureAccount,
+			BackRestRepoSecretKeyAzureKey:               backrestAzureKey,
 			backRestRepoSecretKeyAuthorizedKeys:         keys.Public,
 			backRestRepoSecretKeyAWSS3KeyAWSS3CACert:    configs.Data[backRestRepoSecretKeyAWSS3KeyAWSS3CACert],
 			backRestRepoSecretKeySSHConfig:              configs.Data[backRestRepoSecretKeySSHConfig],
@@ -155,7 +213,13 @@ func CreateBackrestRepoSecrets(clientset *kubernetes.Clientset,
 		},
 	}
 
-	return kubeapi.CreateSecret(clientset, &secret, backrestRepoConfig.ClusterNamespace)
+	// the passphrase used to encrypt the repository is only stored if the
+	// repository is encrypted
+	if backrestRepoConfig.BackrestCipherPass != "" {
+		secret.Data[BackRestRepoSecretKeyCipherPass] = []byte(backrestRepoConfig.BackrestCipherPass)
+	}
+
+	return &secret, nil
 }
 
 // IsAutofailEnabled - returns true if autofail label is set to true, false if not.
@@ -235,6 +299,83 @@ func GetS3CredsFromBackrestRepoSecret(clientset *kubernetes.Clientset, namespace
 	return s3Secret, nil
 }
 
+// GetGCSCredsFromBackrestRepoSecret retrieves the GCS credentials, i.e. the
+// service account key or token, from a specific cluster's backrest repo secret
+func GetGCSCredsFromBackrestRepoSecret(clientset *kubernetes.Clientset, namespace, clusterName string) (GCSSecret, error) {
+	secretName := fmt.Sprintf("%s-%s", clusterName, config.LABEL_BACKREST_REPO_SECRET)
+	gcsSecret := GCSSecret{}
+
+	secret, _, err := kubeapi.GetSecret(clientset, secretName, namespace)
+
+	if err != nil {
+		log.Error(err)
+		return gcsSecret, err
+	}
+
+	gcsSecret.GCSKey = string(secret.Data[BackRestRepoSecretKeyGCSKey])
+
+	return gcsSecret, nil
+}
+
+// GetAzureCredsFromBackrestRepoSecret retrieves the Azure credentials, i.e. the
+// storage account name and shared key, from a specific cluster's backrest repo
+// secret
+func GetAzureCredsFromBackrestRepoSecret(clientset *kubernetes.Clientset, namespace, clusterName string) (AzureSecret, error) {
+	secretName := fmt.Sprintf("%s-%s", clusterName, config.LABEL_BACKREST_REPO_SECRET)
+	azureSecret := AzureSecret{}
+
+	secret, _, err := kubeapi.GetSecret(clientset, secretName, namespace)
+
+	if err != nil {
+		log.Error(err)
+		return azureSecret, err
+	}
+
+	azureSecret.AzureAccount = string(secret.Data[BackRestRepoSecretKeyAzureAccount])
+	azureSecret.AzureKey = string(secret.Data[BackRestRepoSecretKeyAzureKey])
+
+	return azureSecret, nil
+}
+
+// GetCipherPassFromBackrestRepoSecret retrieves the passphrase used to encrypt
+// the pgBackRest repository from a specific cluster's backrest repo secret. If
+// the repository is not encrypted, an empty string is returned
+func GetCipherPassFromBackrestRepoSecret(clientset *kubernetes.Clientset, namespace, clusterName string) (string, error) {
+	secretName := fmt.Sprintf("%s-%s", clusterName, config.LABEL_BACKREST_REPO_SECRET)
+
+	secret, _, err := kubeapi.GetSecret(clientset, secretName, namespace)
+
+	if err != nil {
+		log.Error(err)
+		return "", err
+	}
+
+	return string(secret.Data[BackRestRepoSecretKeyCipherPass]), nil
+}
+
+// SetCipherPassInBackrestRepoSecret updates the passphrase used to encrypt the
+// pgBackRest repository in a specific cluster's backrest repo secret. As
+// pgBackRest does not allow the passphrase of an existing stanza to be changed,
+// this should only be done before a new stanza is created
+func SetCipherPassInBackrestRepoSecret(clientset *kubernetes.Clientset, namespace, clusterName, cipherPass string) error {
+	secretName := fmt.Sprintf("%s-%s", clusterName, config.LABEL_BACKREST_REPO_SECRET)
+
+	secret, _, err := kubeapi.GetSecret(clientset, secretName, namespace)
+
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+
+	secret.Data[BackRestRepoSecretKeyCipherPass] = []byte(cipherPass)
+
+	return kubeapi.UpdateSecret(clientset, secret, namespace)
+}
+
 // SetPostgreSQLPassword updates the password for a PostgreSQL role in the
 // PostgreSQL cluster by executing into the primary Pod and changing it
 //
@@ -0,0 +1,310 @@
+package util
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// logicalReplicationSlotNameMaxLength is the maximum length of the name of a
+// replication slot, i.e. NAMEDATALEN - 1
+const logicalReplicationSlotNameMaxLength = 63
+
+// logicalReplicationSlotNameInvalid matches the characters that are not
+// allowed in the name of a replication slot
+var logicalReplicationSlotNameInvalid = regexp.MustCompile(`[^a-z0-9_]`)
+
+const (
+	// sqlCreateLogicalReplicationUser creates the user that subscriptions
+	// connect to the publications of a cluster as, or updates its password
+	sqlCreateLogicalReplicationUser = `DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_catalog.pg_roles WHERE rolname = %[1]s) THEN
+    CREATE ROLE %[2]s;
+  END IF;
+END $$;
+ALTER ROLE %[2]s LOGIN REPLICATION PASSWORD %[3]s;
+`
+
+	// sqlCheckWalLevel fails unless PostgreSQL writes the WAL that logical
+	// replication decodes
+	sqlCheckWalLevel = `DO $$
+BEGIN
+  IF current_setting('wal_level') <> 'logical' THEN
+    RAISE EXCEPTION 'logical replication requires wal_level to be "logical", it is "%"',
+      current_setting('wal_level');
+  END IF;
+END $$;
+`
+
+	// sqlGrantPublicationTables allows the logical replication user to copy the
+	// tables of a publication when a subscription is created
+	sqlGrantPublicationTables = `DO $$
+DECLARE t record;
+BEGIN
+  FOR t IN SELECT schemaname, tablename FROM pg_catalog.pg_publication_tables
+    WHERE pubname = %[1]s LOOP
+    EXECUTE format('GRANT USAGE ON SCHEMA %%I TO %%I', t.schemaname, %[2]s);
+    EXECUTE format('GRANT SELECT ON TABLE %%I.%%I TO %%I', t.schemaname, t.tablename, %[2]s);
+  END LOOP;
+END $$;
+`
+
+	// sqlDropSubscription drops a subscription along with its replication slot
+	// on the source cluster, which has to be reachable
+	sqlDropSubscription = "DROP SUBSCRIPTION IF EXISTS %s;\n"
+
+	// sqlDetachSubscription drops a subscription without dropping its
+	// replication slot on the source cluster, which may no longer be reachable
+	sqlDetachSubscription = `DO $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM pg_catalog.pg_subscription
+    WHERE subname = %[1]s AND subdbid = (SELECT oid FROM pg_catalog.pg_database
+      WHERE datname = current_database())) THEN
+    EXECUTE 'ALTER SUBSCRIPTION ' || quote_ident(%[1]s) || ' DISABLE';
+    EXECUTE 'ALTER SUBSCRIPTION ' || quote_ident(%[1]s) || ' SET (slot_name = NONE)';
+    EXECUTE 'DROP SUBSCRIPTION ' || quote_ident(%[1]s);
+  END IF;
+END $$;
+`
+
+	// sqlDropReplicationSlot drops the replication slot of a subscription on
+	// the source cluster if it still exists. A subscription that is still
+	// connected to it is disconnected first, which can take a moment, and the
+	// slot is reported if it is still in use after that
+	sqlDropReplicationSlot = `DO $$
+DECLARE
+  slot_pid integer;
+BEGIN
+  FOR i IN 1..30 LOOP
+    SELECT active_pid INTO slot_pid FROM pg_catalog.pg_replication_slots
+      WHERE slot_name = %[1]s;
+    IF NOT FOUND THEN
+      RETURN;
+    END IF;
+    IF slot_pid IS NULL THEN
+      PERFORM pg_catalog.pg_drop_replication_slot(%[1]s);
+      RETURN;
+    END IF;
+    PERFORM pg_catalog.pg_terminate_backend(slot_pid);
+    PERFORM pg_catalog.pg_sleep(1);
+  END LOOP;
+  RAISE EXCEPTION 'replication slot %% is still in use', %[1]s;
+END $$;
+`
+
+	// sqlDropPublication drops a publication if it exists
+	sqlDropPublication = "DROP PUBLICATION IF EXISTS %s;\n"
+
+	// sqlSubscriptionState returns the name, whether it is enabled, the time
+	// of the last message from the source cluster, the number of running
+	// workers and the number of tables that are not yet synchronized of each
+	// subscription in the current database. The tables of a subscription are
+	// only listed in its own database
+	sqlSubscriptionState = `SELECT s.subname, s.subenabled,
+  COALESCE(to_char(max(st.last_msg_receipt_time) AT TIME ZONE 'UTC',
+    'YYYY-MM-DD"T"HH24:MI:SS"Z"'), ''),
+  count(st.pid),
+  (SELECT count(*) FROM pg_catalog.pg_subscription_rel r
+    WHERE r.srsubid = s.oid AND r.srsubstate <> 'r')
+FROM pg_catalog.pg_subscription s
+JOIN pg_catalog.pg_database d ON d.oid = s.subdbid
+LEFT JOIN pg_catalog.pg_stat_subscription st ON st.subid = s.oid
+WHERE d.datname = current_database()
+GROUP BY s.oid, s.subname, s.subenabled;
+`
+
+	// sqlReplicationSlotLag returns the name of each logical replication slot
+	// and how many bytes of WAL it has not confirmed
+	sqlReplicationSlotLag = `SELECT slot_name,
+  COALESCE(pg_catalog.pg_wal_lsn_diff(pg_catalog.pg_current_wal_lsn(), confirmed_flush_lsn), 0)::bigint
+FROM pg_catalog.pg_replication_slots WHERE slot_type = 'logical';
+`
+)
+
+// LogicalReplicationSlotName returns the name of the replication slot on the
+// source cluster of a subscription of a cluster. The slot is named after both
+// so that several clusters can subscribe to a publication with subscriptions
+// of the same name
+func LogicalReplicationSlotName(clusterName, subscriptionName string) string {
+	name := logicalReplicationSlotNameInvalid.ReplaceAllString(
+		strings.ToLower(clusterName+"_"+subscriptionName), "_")
+
+	if len(name) > logicalReplicationSlotNameMaxLength {
+		name = name[:logicalReplicationSlotNameMaxLength]
+	}
+
+	return name
+}
+
+// GenerateLogicalReplicationSecretName returns the name of the secret that
+// holds the credentials of the logical replication user of a cluster
+func GenerateLogicalReplicationSecretName(clusterName string) string {
+	return fmt.Sprintf("%s-%s-secret", clusterName, crv1.PGUserLogicalReplication)
+}
+
+// CreateLogicalReplicationUserSQL returns the SQL that creates the logical
+// replication user of a cluster, or updates its password. The password is
+// stored as an MD5 hash so that it does not appear in the logs
+func CreateLogicalReplicationUserSQL(password string) string {
+	return fmt.Sprintf(sqlCreateLogicalReplicationUser,
+		SQLQuoteLiteral(crv1.PGUserLogicalReplication),
+		SQLQuoteIdentifier(crv1.PGUserLogicalReplication),
+		SQLQuoteLiteral(GeneratePostgreSQLMD5Password(crv1.PGUserLogicalReplication, password)))
+}
+
+// CreatePublicationSQL returns the SQL that creates a publication of the
+// tables of a database, or of all of its tables if none are given, and allows
+// the logical replication user to copy them. A table may be qualified by its
+// schema, e.g. "sales.orders"
+func CreatePublicationSQL(publication crv1.PublicationSpec) string {
+	target := "ALL TABLES"
+
+	if len(publication.Tables) > 0 {
+		tables := make([]string, len(publication.Tables))
+		for i, table := range publication.Tables {
+			tables[i] = quoteQualifiedIdentifier(table)
+		}
+
+		target = "TABLE " + strings.Join(tables, ", ")
+	}
+
+	return sqlCheckWalLevel +
+		fmt.Sprintf("CREATE PUBLICATION %s FOR %s;\n",
+			SQLQuoteIdentifier(publication.Name), target) +
+		fmt.Sprintf(sqlGrantPublicationTables,
+			SQLQuoteLiteral(publication.Name),
+			SQLQuoteLiteral(crv1.PGUserLogicalReplication))
+}
+
+// CreateSubscriptionSQL returns the SQL that creates a subscription of a
+// cluster to a publication in a database of the source cluster, which is
+// connected to through its Service as the logical replication user
+func CreateSubscriptionSQL(clusterName string, subscription crv1.SubscriptionSpec,
+	sourceHost, sourcePort, sourceDatabase, password string) string {
+	connection := strings.Join([]string{
+		"host=" + conninfoQuote(sourceHost),
+		"port=" + conninfoQuote(sourcePort),
+		"dbname=" + conninfoQuote(sourceDatabase),
+		"user=" + conninfoQuote(crv1.PGUserLogicalReplication),
+		"password=" + conninfoQuote(password),
+	}, " ")
+
+	return fmt.Sprintf("CREATE SUBSCRIPTION %s CONNECTION %s PUBLICATION %s WITH (slot_name = %s);\n",
+		SQLQuoteIdentifier(subscription.Name),
+		SQLQuoteLiteral(connection),
+		SQLQuoteIdentifier(subscription.Publication),
+		SQLQuoteLiteral(LogicalReplicationSlotName(clusterName, subscription.Name)))
+}
+
+// DropPublicationSQL returns the SQL that drops a publication if it exists in
+// the database
+func DropPublicationSQL(publicationName string) string {
+	return fmt.Sprintf(sqlDropPublication, SQLQuoteIdentifier(publicationName))
+}
+
+// DropSubscriptionSQL returns the SQL that drops a subscription if it exists
+// in the database, along with its replication slot on the source cluster
+func DropSubscriptionSQL(subscriptionName string) string {
+	return fmt.Sprintf(sqlDropSubscription, SQLQuoteIdentifier(subscriptionName))
+}
+
+// DetachSubscriptionSQL returns the SQL that drops a subscription if it exists
+// in the database without connecting to the source cluster. Its replication
+// slot on the source cluster is left as it is and has to be dropped with
+// DropReplicationSlotSQL
+func DetachSubscriptionSQL(subscriptionName string) string {
+	return fmt.Sprintf(sqlDetachSubscription, SQLQuoteLiteral(subscriptionName))
+}
+
+// DropReplicationSlotSQL returns the SQL that drops the replication slot of
+// a subscription of a cluster on its source cluster
+func DropReplicationSlotSQL(clusterName, subscriptionName string) string {
+	return fmt.Sprintf(sqlDropReplicationSlot,
+		SQLQuoteLiteral(LogicalReplicationSlotName(clusterName, subscriptionName)))
+}
+
+// DropSubscription drops a subscription of a cluster along with its
+// replication slot on the source cluster, so that the source cluster does not
+// keep the WAL for it. When the subscription cannot be dropped that way, e.g.
+// because the source cluster cannot be reached, it is detached from the slot
+// and dropped on its own. Either way, the slot is then dropped on the source
+// cluster if it still exists, and an error is returned if it cannot be
+func DropSubscription(clientset *kubernetes.Clientset, restconfig *rest.Config, namespace,
+	clusterName string, subscription crv1.SubscriptionSpec) error {
+	log.Debugf("dropping subscription %s of cluster %s", subscription.Name, clusterName)
+
+	var dropErr error
+
+	if _, err := ExecSQL(clientset, restconfig, namespace, clusterName, subscription.Database,
+		DropSubscriptionSQL(subscription.Name)); err != nil {
+		log.Warnf("could not drop subscription %s of cluster %s along with its replication slot: %v",
+			subscription.Name, clusterName, err)
+
+		_, dropErr = ExecSQL(clientset, restconfig, namespace, clusterName, subscription.Database,
+			DetachSubscriptionSQL(subscription.Name))
+	}
+
+	if _, err := ExecSQL(clientset, restconfig, namespace, subscription.SourceCluster,
+		"postgres", DropReplicationSlotSQL(clusterName, subscription.Name)); err != nil {
+		return fmt.Errorf("could not drop the replication slot of subscription %s of cluster %s "+
+			"on cluster %s, which keeps its WAL until it is dropped: %v",
+			subscription.Name, clusterName, subscription.SourceCluster, err)
+	}
+
+	return dropErr
+}
+
+// SubscriptionStateSQL returns the SQL that queries the state of the
+// subscriptions in a database of a cluster
+func SubscriptionStateSQL() string {
+	return sqlSubscriptionState
+}
+
+// ReplicationSlotLagSQL returns the SQL that queries how far behind each
+// logical replication slot of a cluster is
+func ReplicationSlotLagSQL() string {
+	return sqlReplicationSlotLag
+}
+
+// quoteQualifiedIdentifier quotes an identifier that may be qualified by a
+// schema, e.g. "sales.orders"
+func quoteQualifiedIdentifier(identifier string) string {
+	parts := strings.SplitN(identifier, ".", 2)
+
+	for i := range parts {
+		parts[i] = SQLQuoteIdentifier(parts[i])
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// conninfoQuote quotes a value of a libpq connection string, escaping
+// backslashes and single quotes
+func conninfoQuote(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `'`, `\'`, -1)
+
+	return `'` + value + `'`
+}
@@ -0,0 +1,114 @@
+package util
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"strings"
+	"testing"
+
+	crv1 "github.com/crunchydata/postgres-operator/apis/cr/v1"
+)
+
+func TestLogicalReplicationSlotName(t *testing.T) {
+	tests := []struct {
+		clusterName, subscriptionName string
+		expected                      string
+	}{
+		{"hippo", "orders", "hippo_orders"},
+		{"hippo-east", "orders", "hippo_east_orders"},
+		{strings.Repeat("a", 60), "orders", strings.Repeat("a", 60) + "_or"},
+	}
+
+	for i, test := range tests {
+		if name := LogicalReplicationSlotName(test.clusterName, test.subscriptionName); name != test.expected {
+			t.Fatalf("tests[%d] - expected slot name %q, got %q", i, test.expected, name)
+		}
+	}
+}
+
+func TestCreatePublicationSQL(t *testing.T) {
+	t.Run("all tables", func(t *testing.T) {
+		sql := CreatePublicationSQL(crv1.PublicationSpec{Name: "orders", Database: "hippo"})
+
+		if !strings.Contains(sql, `CREATE PUBLICATION "orders" FOR ALL TABLES;`) {
+			t.Fatalf("expected a publication of all tables, got %q", sql)
+		}
+
+		if !strings.Contains(sql, `WHERE pubname = 'orders'`) ||
+			!strings.Contains(sql, `'logicalreplicator'`) {
+			t.Fatalf("expected the tables to be granted, got %q", sql)
+		}
+	})
+
+	t.Run("tables", func(t *testing.T) {
+		sql := CreatePublicationSQL(crv1.PublicationSpec{
+			Name:   "orders",
+			Tables: []string{"orders", `sales.line"items`},
+		})
+
+		if !strings.Contains(sql, `CREATE PUBLICATION "orders" FOR TABLE "orders", "sales"."line""items";`) {
+			t.Fatalf("expected a publication of the tables, got %q", sql)
+		}
+	})
+}
+
+func TestCreateSubscriptionSQL(t *testing.T) {
+	subscription := crv1.SubscriptionSpec{
+		Name:          "orders",
+		Database:      "rhino",
+		SourceCluster: "hippo-east",
+		Publication:   "orders",
+	}
+
+	sql := CreateSubscriptionSQL("hippo-west", subscription, "hippo-east.pgo.svc", "5432", "hippo", `it's\secret`)
+	expected := `CREATE SUBSCRIPTION "orders" CONNECTION  E'host=''hippo-east.pgo.svc'' port=''5432'' ` +
+		`dbname=''hippo'' user=''logicalreplicator'' password=''it\\''s\\\\secret''' ` +
+		`PUBLICATION "orders" WITH (slot_name = 'hippo_west_orders');` + "\n"
+
+	if sql != expected {
+		t.Fatalf("expected %q, got %q", expected, sql)
+	}
+}
+
+func TestDropSubscriptionSQL(t *testing.T) {
+	if sql := DropSubscriptionSQL("orders"); sql != "DROP SUBSCRIPTION IF EXISTS \"orders\";\n" {
+		t.Fatalf("expected the subscription to be dropped along with its slot, got %q", sql)
+	}
+
+	sql := DetachSubscriptionSQL("orders")
+
+	if !strings.Contains(sql, `subname = 'orders'`) ||
+		!strings.Contains(sql, `SET (slot_name = NONE)`) {
+		t.Fatalf("expected the subscription to be detached from its slot and dropped, got %q", sql)
+	}
+
+	sql = DropReplicationSlotSQL("hippo-west", "orders")
+
+	if !strings.Contains(sql, `WHERE slot_name = 'hippo_west_orders'`) ||
+		!strings.Contains(sql, `pg_drop_replication_slot('hippo_west_orders')`) {
+		t.Fatalf("expected the slot of the subscription to be dropped, got %q", sql)
+	}
+
+	// a slot that is in use is not skipped
+	if strings.Contains(sql, "NOT active") || !strings.Contains(sql, "pg_terminate_backend(slot_pid)") ||
+		!strings.Contains(sql, `RAISE EXCEPTION 'replication slot % is still in use', 'hippo_west_orders'`) {
+		t.Fatalf("expected the slot to be released or reported, got %q", sql)
+	}
+
+	if sql := DropPublicationSQL("orders"); sql != "DROP PUBLICATION IF EXISTS \"orders\";\n" {
+		t.Fatalf("expected the publication to be dropped, got %q", sql)
+	}
+}
@@ -48,32 +48,6 @@ func ExecPolicy(clientset *kubernetes.Clientset, restclient *rest.RESTClient, re
 		return err
 	}
 
-	// prepare the SQL string to be something that can be passed to a STDIN
-	// interface
-	stdin := strings.NewReader(sql)
-
-	// now, we need to ensure we can get the Pod name of the primary PostgreSQL
-	// instance. Thname being passed in is actually the "serviceName" of the Pod
-	// We can isolate the exact Pod we want by using this (LABEL_SERVICE_NAME) and
-	// the LABEL_PGHA_ROLE labels
-	selector := fmt.Sprintf("%s=%s,%s=%s",
-		config.LABEL_SERVICE_NAME, serviceName,
-		config.LABEL_PGHA_ROLE, primaryClusterLabel)
-
-	podList, err := kubeapi.GetPods(clientset, selector, namespace)
-
-	if err != nil {
-		return err
-	} else if len(podList.Items) != 1 {
-		msg := fmt.Sprintf("could not find the primary pod selector:[%s] pods returned:[%d]",
-			selector, len(podList.Items))
-
-		return errors.New(msg)
-	}
-
-	// get the primary Pod
-	pod := podList.Items[0]
-
 	// in the Pod spec, the first container is always the one with the PostgreSQL
 	// instnace. We can use that to build out our execution call
 	//
@@ -93,8 +67,8 @@ func ExecPolicy(clientset *kubernetes.Clientset, restclient *rest.RESTClient, re
 	}
 
 	// execute the command! if it fails, return the error
-	if _, stderr, err := kubeapi.ExecToPodThroughAPI(restconfig, clientset,
-		command, pod.Spec.Containers[0].Name, pod.Name, namespace, stdin); err != nil || stderr != "" {
+	if _, stderr, err := execOnPrimary(clientset, restconfig, namespace, serviceName,
+		command, sql); err != nil || stderr != "" {
 		// log the error from the pod and stderr, but return the stderr
 		log.Error(err, stderr)
 
@@ -104,6 +78,73 @@ func ExecPolicy(clientset *kubernetes.Clientset, restclient *rest.RESTClient, re
 	return nil
 }
 
+// ExecSQL executes SQL in a database of the primary PostgreSQL instance of a
+// cluster as the "postgres" user, and returns the rows that it outputs, one
+// per line with their columns separated by "|". The statements are run one by
+// one, and the first one that fails stops the execution and is returned as
+// the error. Unlike ExecPolicy, the notices that PostgreSQL writes to stderr
+// are not considered errors
+func ExecSQL(clientset *kubernetes.Clientset, restconfig *rest.Config, namespace, serviceName,
+	database, sql string) (string, error) {
+	command := []string{
+		"psql",
+		"-X", "-A", "-t", "-q",
+		"-v", "ON_ERROR_STOP=1",
+		database,
+		"postgres",
+		"-f",
+		"-",
+	}
+
+	stdout, stderr, err := execOnPrimary(clientset, restconfig, namespace, serviceName,
+		command, sql)
+
+	if err != nil {
+		log.Error(err, stderr)
+
+		if stderr != "" {
+			return "", fmt.Errorf("%s", strings.TrimSpace(stderr))
+		}
+		return "", err
+	}
+
+	return stdout, nil
+}
+
+// execOnPrimary runs a command in the PostgreSQL container of the primary
+// instance of a cluster with the SQL as its stdin
+func execOnPrimary(clientset *kubernetes.Clientset, restconfig *rest.Config, namespace,
+	serviceName string, command []string, sql string) (string, string, error) {
+	// prepare the SQL string to be something that can be passed to a STDIN
+	// interface
+	stdin := strings.NewReader(sql)
+
+	// now, we need to ensure we can get the Pod name of the primary PostgreSQL
+	// instance. Thname being passed in is actually the "serviceName" of the Pod
+	// We can isolate the exact Pod we want by using this (LABEL_SERVICE_NAME) and
+	// the LABEL_PGHA_ROLE labels
+	selector := fmt.Sprintf("%s=%s,%s=%s",
+		config.LABEL_SERVICE_NAME, serviceName,
+		config.LABEL_PGHA_ROLE, primaryClusterLabel)
+
+	podList, err := kubeapi.GetPods(clientset, selector, namespace)
+
+	if err != nil {
+		return "", "", err
+	} else if len(podList.Items) != 1 {
+		msg := fmt.Sprintf("could not find the primary pod selector:[%s] pods returned:[%d]",
+			selector, len(podList.Items))
+
+		return "", "", errors.New(msg)
+	}
+
+	// get the primary Pod
+	pod := podList.Items[0]
+
+	return kubeapi.ExecToPodThroughAPI(restconfig, clientset,
+		command, pod.Spec.Containers[0].Name, pod.Name, namespace, stdin)
+}
+
 // GetPolicySQL returns the SQL string from a policy
 func GetPolicySQL(restclient *rest.RESTClient, namespace, policyName string) (string, error) {
 	p := crv1.Pgpolicy{}
@@ -0,0 +1,58 @@
+package util
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	cron "github.com/robfig/cron"
+)
+
+// cronParser parses schedules in the standard cron format, which is the format
+// of the schedules that pgo-scheduler runs
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+// ValidateCronSchedule validates that a schedule is in the standard cron format
+func ValidateCronSchedule(schedule string) error {
+	if _, err := cronParser.Parse(schedule); err != nil {
+		return fmt.Errorf("%s is not a valid schedule: %w", schedule, err)
+	}
+	return nil
+}
+
+// ValidateHibernateSchedule validates that a hibernate schedule has both a
+// stop and a start schedule, and that both are valid
+func ValidateHibernateSchedule(stop, start string) error {
+	if stop == "" || start == "" {
+		return errors.New("Stop and start schedules required for hibernate schedules")
+	}
+	if err := ValidateCronSchedule(stop); err != nil {
+		return err
+	}
+	return ValidateCronSchedule(start)
+}
+
+// NextScheduleTime returns the next time after the given time that a schedule
+// in the standard cron format runs
+func NextScheduleTime(schedule string, from time.Time) (time.Time, error) {
+	sched, err := cronParser.Parse(schedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s is not a valid schedule: %w", schedule, err)
+	}
+	return sched.Next(from), nil
+}
@@ -0,0 +1,72 @@
+package util
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidateCronSchedule(t *testing.T) {
+	tests := []struct {
+		schedule string
+		valid    bool
+	}{
+		{"0 19 * * 1-5", true},
+		{"*/15 * * * *", true},
+		{"60 * * * *", false},
+		{"* * * * * * *", false},
+	}
+
+	for i, test := range tests {
+		err := ValidateCronSchedule(test.schedule)
+		if test.valid && err != nil {
+			t.Fatalf("tests[%d] - expected valid, got %s", i, err)
+		} else if !test.valid && (err == nil || errors.Unwrap(err) == nil) {
+			t.Fatalf("tests[%d] - expected a wrapped error, got %v", i, err)
+		}
+	}
+}
+
+func TestNextScheduleTime(t *testing.T) {
+	// a Wednesday
+	from := time.Date(2020, time.June, 17, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		schedule string
+		expected time.Time
+	}{
+		{"0 19 * * 1-5", time.Date(2020, time.June, 17, 19, 0, 0, 0, time.UTC)},
+		{"0 7 * * 1-5", time.Date(2020, time.June, 18, 7, 0, 0, 0, time.UTC)},
+		{"0 7 * * 1", time.Date(2020, time.June, 22, 7, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2020, time.June, 17, 12, 45, 0, 0, time.UTC)},
+	}
+
+	for i, test := range tests {
+		next, err := NextScheduleTime(test.schedule, from)
+		if err != nil {
+			t.Fatalf("tests[%d] - unexpected error: %s", i, err)
+		}
+		if !next.Equal(test.expected) {
+			t.Fatalf("tests[%d] - expected %s, got %s", i, test.expected, next)
+		}
+	}
+
+	if _, err := NextScheduleTime("60 * * * *", from); err == nil {
+		t.Fatalf("expected an error for an invalid schedule")
+	}
+}
@@ -43,6 +43,16 @@ var seededRand = rand.New(
 
 // CreateSecret create the secret, user, and primary secrets
 func CreateSecret(clientset *kubernetes.Clientset, db, secretName, username, password, namespace string) error {
+	secret := NewSecret(db, secretName, username, password)
+
+	err := kubeapi.CreateSecret(clientset, secret, namespace)
+
+	return err
+
+}
+
+// NewSecret returns the secret that CreateSecret creates, without creating it
+func NewSecret(db, secretName, username, password string) *v1.Secret {
 
 	var enUsername = username
 
@@ -56,10 +66,7 @@ func CreateSecret(clientset *kubernetes.Clientset, db, secretName, username, pas
 	secret.Data["username"] = []byte(enUsername)
 	secret.Data["password"] = []byte(password)
 
-	err := kubeapi.CreateSecret(clientset, &secret, namespace)
-
-	return err
-
+	return &secret
 }
 
 // stringWithCharset returns a generated string value
@@ -148,12 +155,23 @@ type CloneClusterSecrets struct {
 	SourceClusterName string
 	// The name of the PostgreSQL cluster that we are copying the secrets to
 	TargetClusterName string
+	// The Namespace that the secrets are copied to, if the target cluster is not
+	// in the same Namespace as the source cluster
+	TargetNamespace string
 }
 
 // Clone performs the actual clone of the secrets between PostgreSQL clusters
 func (cs CloneClusterSecrets) Clone() error {
 	log.Debugf("clone secrets [%s] to [%s]", cs.SourceClusterName, cs.TargetClusterName)
 
+	// if a target namespace is not set, the secrets are copied within the same
+	// namespace
+	targetNamespace := cs.TargetNamespace
+
+	if targetNamespace == "" {
+		targetNamespace = cs.Namespace
+	}
+
 	// initialize the selector, and add any additional options to it
 	selector := fmt.Sprintf("pg-cluster=%s", cs.SourceClusterName)
 
@@ -193,7 +211,7 @@ func (cs CloneClusterSecrets) Clone() error {
 		}
 
 		// create the secret
-		kubeapi.CreateSecret(cs.ClientSet, &secret, cs.Namespace)
+		kubeapi.CreateSecret(cs.ClientSet, &secret, targetNamespace)
 	}
 
 	return nil
@@ -0,0 +1,102 @@
+package util
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SupportedPostgresMajorVersions are the PostgreSQL major versions, in order,
+// that a cluster can be upgraded between using a major upgrade
+var SupportedPostgresMajorVersions = []string{"9.5", "9.6", "10", "11", "12", "13"}
+
+// ccpImageTagVersionRegex extracts the PostgreSQL version from a CCPImageTag,
+// e.g. "12.3" from "centos7-12.3-4.4.0" or "9.6.18" from "centos7-9.6.18-4.4.0"
+var ccpImageTagVersionRegex = regexp.MustCompile(`^[^-]+-(\d+(\.\d+)*)-`)
+
+// GetPostgresVersion returns the full PostgreSQL version that is contained in
+// a CCPImageTag, e.g. "12.3" for "centos7-12.3-4.4.0", or an empty string if
+// the image tag does not contain a version
+func GetPostgresVersion(ccpImageTag string) string {
+	match := ccpImageTagVersionRegex.FindStringSubmatch(ccpImageTag)
+
+	if match == nil {
+		return ""
+	}
+
+	return match[1]
+}
+
+// GetPostgresMajorVersion returns the PostgreSQL major version that is
+// contained in a CCPImageTag, e.g. "12" for "centos7-12.3-4.4.0" and "9.6" for
+// "centos7-9.6.18-4.4.0"
+func GetPostgresMajorVersion(ccpImageTag string) (string, error) {
+	match := ccpImageTagVersionRegex.FindStringSubmatch(ccpImageTag)
+
+	if match == nil {
+		return "", fmt.Errorf("could not determine the PostgreSQL version of image tag %q",
+			ccpImageTag)
+	}
+
+	parts := strings.Split(match[1], ".")
+
+	// prior to PostgreSQL 10 the major version consists of the first two parts
+	// of the version number
+	if parts[0] == "9" {
+		if len(parts) < 2 {
+			return "", fmt.Errorf("could not determine the PostgreSQL version of image tag %q",
+				ccpImageTag)
+		}
+		return parts[0] + "." + parts[1], nil
+	}
+
+	return parts[0], nil
+}
+
+// ValidateMajorUpgrade ensures that a cluster running the current PostgreSQL
+// major version can be upgraded to the target major version, i.e. that both
+// versions are supported and the target version is newer
+func ValidateMajorUpgrade(currentMajorVersion, targetMajorVersion string) error {
+	current, target := -1, -1
+
+	for i, version := range SupportedPostgresMajorVersions {
+		if version == currentMajorVersion {
+			current = i
+		}
+		if version == targetMajorVersion {
+			target = i
+		}
+	}
+
+	if current == -1 {
+		return fmt.Errorf("major upgrades from PostgreSQL %s are not supported. Supported versions: %s",
+			currentMajorVersion, strings.Join(SupportedPostgresMajorVersions, ", "))
+	}
+
+	if target == -1 {
+		return fmt.Errorf("major upgrades to PostgreSQL %s are not supported. Supported versions: %s",
+			targetMajorVersion, strings.Join(SupportedPostgresMajorVersions, ", "))
+	}
+
+	if target <= current {
+		return fmt.Errorf("cannot upgrade from PostgreSQL %s to %s: the target version must be newer",
+			currentMajorVersion, targetMajorVersion)
+	}
+
+	return nil
+}
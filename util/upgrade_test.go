@@ -0,0 +1,100 @@
+package util
+
+/*
+ Copyright 2020 Crunchy Data Solutions, Inc.
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+import (
+	"testing"
+)
+
+func TestGetPostgresVersion(t *testing.T) {
+	tests := []struct {
+		ccpImageTag string
+		expected    string
+	}{
+		{"centos7-12.3-4.4.0", "12.3"},
+		{"centos7-9.6.18-4.4.0", "9.6.18"},
+		{"centos7-12.3-4.4.0-rc.1", "12.3"},
+		{"latest", ""},
+		{"", ""},
+	}
+
+	for i, test := range tests {
+		if version := GetPostgresVersion(test.ccpImageTag); version != test.expected {
+			t.Fatalf("tests[%d] - expected version %q, got %q", i, test.expected, version)
+		}
+	}
+}
+
+func TestGetPostgresMajorVersion(t *testing.T) {
+	tests := []struct {
+		ccpImageTag string
+		expected    string
+		valid       bool
+	}{
+		{"centos7-12.3-4.4.0", "12", true},
+		{"centos7-13.0-4.4.0", "13", true},
+		{"centos8-10.13-4.4.0", "10", true},
+		{"centos7-9.6.18-4.4.0", "9.6", true},
+		{"ubi7-9.5.22-4.4.0", "9.5", true},
+		{"centos7-12.3-4.4.0-rc.1", "12", true},
+		{"latest", "", false},
+		{"centos7-4.4.0", "", false},
+		{"centos7-9-4.4.0", "", false},
+		{"", "", false},
+	}
+
+	for i, test := range tests {
+		version, err := GetPostgresMajorVersion(test.ccpImageTag)
+		if test.valid && err != nil {
+			t.Fatalf("tests[%d] - expected valid image tag, got error: %s", i, err)
+		} else if !test.valid && err == nil {
+			t.Fatalf("tests[%d] - expected invalid image tag, got version %q", i, version)
+		}
+
+		if version != test.expected {
+			t.Fatalf("tests[%d] - wrong major version. expected %q, got %q",
+				i, test.expected, version)
+		}
+	}
+}
+
+func TestValidateMajorUpgrade(t *testing.T) {
+	tests := []struct {
+		current string
+		target  string
+		valid   bool
+	}{
+		{"12", "13", true},
+		{"9.6", "12", true},
+		{"9.5", "9.6", true},
+		{"10", "11", true},
+		{"12", "12", false},
+		{"13", "12", false},
+		{"11", "9.6", false},
+		{"9.4", "12", false},
+		{"12", "14", false},
+		{"12", "", false},
+	}
+
+	for i, test := range tests {
+		err := ValidateMajorUpgrade(test.current, test.target)
+		if test.valid && err != nil {
+			t.Fatalf("tests[%d] - invalid upgrade. expected valid, got invalid: %s", i, err)
+		} else if !test.valid && err == nil {
+			t.Fatalf("tests[%d] - valid upgrade. expected invalid, got valid", i)
+		}
+	}
+}